@@ -3,6 +3,7 @@ package coordinator
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -40,6 +41,10 @@ type Config struct {
 	// SchemaVersion is the database schema version this service expects
 	SchemaVersion int
 
+	// SchemaCheck verifies the database against SchemaVersion before connecting (optional).
+	// migrate.Migrator.SchemaCheck returns a suitable function for a registered component.
+	SchemaCheck func(ctx context.Context, expected int) error
+
 	// Reconnect settings
 	ReconnectInitialDelay  time.Duration
 	ReconnectMaxDelay      time.Duration
@@ -49,6 +54,12 @@ type Config struct {
 	// PingInterval is how often to send pings
 	PingInterval time.Duration
 
+	// Outbox durably buffers outgoing messages while disconnected (optional).
+	// Buffered messages are replayed after re-registration and removed when the
+	// server acknowledges them, so it requires a server that sends ack messages
+	// (such as coordinator/hub). The caller owns the outbox and closes it.
+	Outbox Outbox
+
 	// Logger for coordinator messages
 	Logger *logrus.Entry
 }
@@ -72,6 +83,7 @@ type Coordinator struct {
 
 	conn      *websocket.Conn
 	connMu    sync.RWMutex
+	writeMu   sync.Mutex // gorilla/websocket allows one concurrent writer
 	connected bool
 
 	// Phase management
@@ -83,6 +95,7 @@ type Coordinator struct {
 
 	// Outgoing messages
 	sendChan chan *WSMessage
+	replayMu sync.Mutex // serializes outbox replays
 
 	// Lifecycle
 	ctx        context.Context
@@ -137,6 +150,7 @@ func (c *Coordinator) registerDefaultHandlers() {
 	c.handlers[MessageTypeResume] = c.handleResume
 	c.handlers[MessageTypeCancel] = c.handleCancel
 	c.handlers[MessageTypeStatus] = c.handleStatus
+	c.handlers[MessageTypeAck] = c.handleAck
 }
 
 // OnMessage registers a custom handler for a message type.
@@ -167,7 +181,15 @@ func (c *Coordinator) Phases() *PhaseManager {
 }
 
 // Connect establishes the WebSocket connection and starts processing.
+// If Config.SchemaCheck is set, the database schema is verified first and a
+// mismatch is returned without connecting.
 func (c *Coordinator) Connect() error {
+	if c.config.SchemaCheck != nil && c.config.SchemaVersion > 0 {
+		if err := c.config.SchemaCheck(c.ctx, c.config.SchemaVersion); err != nil {
+			return fmt.Errorf("schema version check failed: %w", err)
+		}
+	}
+
 	c.wg.Add(1)
 	go c.connectionLoop()
 	return nil
@@ -432,11 +454,35 @@ func (c *Coordinator) sendMessage(msg *WSMessage) error {
 		return fmt.Errorf("marshal error: %w", err)
 	}
 
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 	return conn.WriteMessage(websocket.TextMessage, data)
 }
 
+// isRegistered returns whether registration with when-v3 completed on the current connection.
+func (c *Coordinator) isRegistered() bool {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.connected && c.registered
+}
+
 // Send queues a message for sending.
+// With an outbox configured the message is stored first; while not registered
+// it is only stored and goes out with the replay after registration.
 func (c *Coordinator) Send(msg *WSMessage) {
+	if c.config.Outbox != nil {
+		if _, err := c.config.Outbox.Enqueue(msg); err != nil {
+			if errors.Is(err, ErrOutboxFull) {
+				c.logger.WithField("type", msg.Type).Debug("Outbox full, dropping message")
+				return
+			}
+			c.logger.WithError(err).Warn("Failed to buffer message")
+		}
+		if !c.isRegistered() {
+			return
+		}
+	}
+
 	select {
 	case c.sendChan <- msg:
 	default:
@@ -485,14 +531,24 @@ func (c *Coordinator) handleRegistered(msg *WSMessage) error {
 
 	c.logger.WithField("service_id", c.serviceID).Info("Registered with when-v3")
 
-	if c.onRegistered != nil {
-		c.onRegistered(c.serviceID)
-	}
+	// Replay messages buffered while disconnected before reporting current state.
+	// This runs off the read loop so acks for the replayed messages are processed
+	// while the replay is still sending.
+	serviceID := c.serviceID
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.replayOutbox()
 
-	// Report state of all active workflows
-	for _, state := range c.phases.GetActiveWorkflows() {
-		c.sendPhaseChanged(state)
-	}
+		if c.onRegistered != nil {
+			c.onRegistered(serviceID)
+		}
+
+		// Report state of all active workflows
+		for _, state := range c.phases.GetActiveWorkflows() {
+			c.sendPhaseChanged(state)
+		}
+	}()
 
 	return nil
 }
@@ -527,7 +583,7 @@ func (c *Coordinator) handleCancel(msg *WSMessage) error {
 
 	c.logger.WithField("workflow_id", payload.WorkflowID).Info("Received cancel command")
 
-	return c.phases.Cancel(payload.WorkflowID, payload.Reason)
+	return c.phases.CancelContext(c.ctx, payload.WorkflowID, payload.Reason)
 }
 
 func (c *Coordinator) handleStatus(msg *WSMessage) error {
@@ -555,11 +611,47 @@ func (c *Coordinator) handleStatus(msg *WSMessage) error {
 	return c.sendMessage(response)
 }
 
+func (c *Coordinator) handleAck(msg *WSMessage) error {
+	if c.config.Outbox == nil {
+		return nil
+	}
+	var payload AckPayload
+	if err := PayloadToStruct(msg.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid ack payload: %w", err)
+	}
+	return c.config.Outbox.Ack(payload.MessageIDs...)
+}
+
+// replayOutbox resends all unacknowledged messages in sequence order.
+// Replays after quick reconnects run one at a time.
+func (c *Coordinator) replayOutbox() {
+	if c.config.Outbox == nil {
+		return
+	}
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+
+	pending, err := c.config.Outbox.Pending(0)
+	if err != nil {
+		c.logger.WithError(err).Warn("Failed to read outbox")
+		return
+	}
+	for _, msg := range pending {
+		if err := c.sendMessage(msg); err != nil {
+			c.logger.WithError(err).Warn("Outbox replay interrupted")
+			return
+		}
+	}
+	if len(pending) > 0 {
+		c.logger.WithField("count", len(pending)).Info("Replayed buffered messages")
+	}
+}
+
 // Outgoing message helpers
 
 // sendPhaseChanged sends a phase_changed message to when-v3.
 func (c *Coordinator) sendPhaseChanged(state *PhaseState) {
-	if !c.IsConnected() {
+	if !c.IsConnected() && c.config.Outbox == nil {
 		return
 	}
 
@@ -626,7 +718,7 @@ func (c *Coordinator) SendProgress(workflowID, actionID string, percent float64,
 
 // SendLog sends a single log entry to when-v3 for centralized aggregation.
 func (c *Coordinator) SendLog(entry LogEntry) {
-	if !c.IsConnected() {
+	if !c.IsConnected() && c.config.Outbox == nil {
 		return
 	}
 	if entry.Timestamp.IsZero() {
@@ -640,7 +732,7 @@ func (c *Coordinator) SendLog(entry LogEntry) {
 // SendLogBatch sends multiple log entries to when-v3 for centralized aggregation.
 // This is more efficient than sending individual logs when you have multiple entries.
 func (c *Coordinator) SendLogBatch(entries []LogEntry) {
-	if !c.IsConnected() || len(entries) == 0 {
+	if (!c.IsConnected() && c.config.Outbox == nil) || len(entries) == 0 {
 		return
 	}
 	// Set timestamps for any entries missing them
@@ -0,0 +1,585 @@
+// Package hub implements the server end of the coordinator WebSocket protocol.
+// Services connect with a coordinator.Coordinator, register, and report phase
+// changes, progress and logs; the hub tracks which instance owns each workflow,
+// routes pause/resume/cancel/status commands to that instance, and fans out
+// progress and log streams to subscribers.
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"eve.evalgo.org/coordinator"
+)
+
+// Errors returned by the control API.
+var (
+	ErrUnknownWorkflow = errors.New("workflow has no known owner")
+	ErrOwnerOffline    = errors.New("workflow owner is not connected")
+	ErrHubClosed       = errors.New("hub closed")
+)
+
+// Config holds configuration for the Hub.
+type Config struct {
+	// ProtocolVersion is the hub's coordination protocol version (default "1.0").
+	// Clients with the same major version are accepted; the negotiated version
+	// is the lower of the two.
+	ProtocolVersion string
+
+	// MinSchemaVersion and MaxSchemaVersion bound the database schema versions
+	// accepted from services (0 = no bound). Services that report no schema
+	// version are always accepted.
+	MinSchemaVersion int
+	MaxSchemaVersion int
+
+	// RegisterTimeout is how long a new connection may take to register
+	RegisterTimeout time.Duration
+
+	// PingInterval is how often connected services are pinged
+	PingInterval time.Duration
+
+	// PresenceTimeout marks a session stale when nothing was received for this long
+	PresenceTimeout time.Duration
+
+	// SendBuffer is the per-connection and per-subscriber queue size
+	SendBuffer int
+
+	// DedupWindow is the number of recent outbox message IDs remembered per
+	// instance to drop replays (default coordinator.DefaultOutboxMaxMessages).
+	// It should be at least the outbox size configured on the services.
+	DedupWindow int
+
+	// CheckOrigin is passed to the WebSocket upgrader (nil allows all origins)
+	CheckOrigin func(r *http.Request) bool
+
+	// Logger for hub messages
+	Logger *logrus.Entry
+}
+
+// DefaultConfig returns a Config with sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		ProtocolVersion: "1.0",
+		RegisterTimeout: 10 * time.Second,
+		PingInterval:    30 * time.Second,
+		PresenceTimeout: 90 * time.Second,
+		SendBuffer:      256,
+		DedupWindow:     coordinator.DefaultOutboxMaxMessages,
+	}
+}
+
+// WorkflowState is the hub's view of a workflow reported by a service.
+type WorkflowState struct {
+	WorkflowID       string
+	Owner            string // Instance key of the owning session
+	Phase            coordinator.Phase
+	Progress         float64
+	Stage            string
+	ParentWorkflowID string
+	RootWorkflowID   string
+	CheckpointID     string
+	UpdatedAt        time.Time
+}
+
+// Hub accepts coordinator connections and routes messages between services,
+// the control API and subscribers.
+type Hub struct {
+	config   Config
+	logger   *logrus.Entry
+	upgrader websocket.Upgrader
+
+	mu        sync.RWMutex
+	sessions  map[string]*session // instance key -> live session
+	workflows map[string]*WorkflowState
+	pending   map[string]chan *coordinator.WSMessage // message ID -> waiting request
+	subs      map[*Subscription]struct{}
+	seen      map[string]*idWindow // instance key -> recently processed outbox message IDs
+	presence  []func(PresenceEvent)
+	closed    bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a new Hub.
+func New(config Config) *Hub {
+	defaults := DefaultConfig()
+	if config.ProtocolVersion == "" {
+		config.ProtocolVersion = defaults.ProtocolVersion
+	}
+	if config.RegisterTimeout == 0 {
+		config.RegisterTimeout = defaults.RegisterTimeout
+	}
+	if config.PingInterval == 0 {
+		config.PingInterval = defaults.PingInterval
+	}
+	if config.PresenceTimeout == 0 {
+		config.PresenceTimeout = defaults.PresenceTimeout
+	}
+	if config.SendBuffer == 0 {
+		config.SendBuffer = defaults.SendBuffer
+	}
+	if config.DedupWindow == 0 {
+		config.DedupWindow = defaults.DedupWindow
+	}
+	if config.Logger == nil {
+		config.Logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	checkOrigin := config.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = func(r *http.Request) bool { return true }
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Hub{
+		config:    config,
+		logger:    config.Logger.WithField("component", "coordination-hub"),
+		upgrader:  websocket.Upgrader{CheckOrigin: checkOrigin},
+		sessions:  make(map[string]*session),
+		workflows: make(map[string]*WorkflowState),
+		pending:   make(map[string]chan *coordinator.WSMessage),
+		subs:      make(map[*Subscription]struct{}),
+		seen:      make(map[string]*idWindow),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// ServeHTTP upgrades the request and serves one service connection.
+// Mount it at the URL services use as coordinator.Config.WhenURL.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.WithError(err).Warn("WebSocket upgrade failed")
+		return
+	}
+
+	h.mu.RLock()
+	closed := h.closed
+	h.mu.RUnlock()
+	if closed {
+		conn.Close()
+		return
+	}
+
+	h.wg.Add(1)
+	defer h.wg.Done()
+	h.serveConn(conn, r.Header.Get("X-Service-Name"))
+}
+
+// Close disconnects all services and subscribers.
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	sessions := make([]*session, 0, len(h.sessions))
+	for _, s := range h.sessions {
+		sessions = append(sessions, s)
+	}
+	subs := make([]*Subscription, 0, len(h.subs))
+	for sub := range h.subs {
+		subs = append(subs, sub)
+	}
+	h.mu.Unlock()
+
+	h.cancel()
+	for _, s := range sessions {
+		s.close()
+	}
+	for _, sub := range subs {
+		sub.Close()
+	}
+	h.wg.Wait()
+	return nil
+}
+
+// OnPresence registers a callback for services joining and leaving.
+func (h *Hub) OnPresence(fn func(PresenceEvent)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.presence = append(h.presence, fn)
+}
+
+// Sessions returns the currently connected services ordered by instance key.
+func (h *Hub) Sessions() []SessionInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	infos := make([]SessionInfo, 0, len(h.sessions))
+	for _, s := range h.sessions {
+		infos = append(infos, s.snapshot(h.config.PresenceTimeout))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].InstanceKey < infos[j].InstanceKey })
+	return infos
+}
+
+// Session returns a connected service by instance key.
+func (h *Hub) Session(instanceKey string) (SessionInfo, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	s, ok := h.sessions[instanceKey]
+	if !ok {
+		return SessionInfo{}, false
+	}
+	return s.snapshot(h.config.PresenceTimeout), true
+}
+
+// Workflow returns the last reported state of a workflow.
+func (h *Hub) Workflow(workflowID string) (WorkflowState, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	wf, ok := h.workflows[workflowID]
+	if !ok {
+		return WorkflowState{}, false
+	}
+	return *wf, true
+}
+
+// Workflows returns all known workflows ordered by ID.
+func (h *Hub) Workflows() []WorkflowState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	states := make([]WorkflowState, 0, len(h.workflows))
+	for _, wf := range h.workflows {
+		states = append(states, *wf)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].WorkflowID < states[j].WorkflowID })
+	return states
+}
+
+// Owner returns the instance key of the session owning a workflow.
+func (h *Hub) Owner(workflowID string) (string, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	wf, ok := h.workflows[workflowID]
+	if !ok || wf.Owner == "" {
+		return "", false
+	}
+	return wf.Owner, true
+}
+
+// AssignWorkflow records ownership of a workflow before the service reports it,
+// e.g. when the orchestrator dispatches a workflow to a chosen instance.
+func (h *Hub) AssignWorkflow(workflowID, instanceKey string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	wf := h.workflowLocked(workflowID)
+	wf.Owner = instanceKey
+	wf.UpdatedAt = time.Now()
+}
+
+// Pause asks the owning service to pause a workflow.
+func (h *Hub) Pause(workflowID, reason string) error {
+	msg := coordinator.NewMessageWithWorkflow(coordinator.MessageTypePause, workflowID)
+	if err := msg.SetPayload(coordinator.PausePayload{WorkflowID: workflowID, Reason: reason}); err != nil {
+		return err
+	}
+	return h.SendToOwner(workflowID, msg)
+}
+
+// Resume asks the owning service to resume a workflow.
+func (h *Hub) Resume(workflowID, fromCheckpoint string) error {
+	msg := coordinator.NewMessageWithWorkflow(coordinator.MessageTypeResume, workflowID)
+	if err := msg.SetPayload(coordinator.ResumePayload{WorkflowID: workflowID, FromCheckpoint: fromCheckpoint}); err != nil {
+		return err
+	}
+	return h.SendToOwner(workflowID, msg)
+}
+
+// Cancel asks the owning service to cancel a workflow.
+func (h *Hub) Cancel(workflowID, reason string, force bool) error {
+	msg := coordinator.NewMessageWithWorkflow(coordinator.MessageTypeCancel, workflowID)
+	if err := msg.SetPayload(coordinator.CancelPayload{WorkflowID: workflowID, Reason: reason, Force: force}); err != nil {
+		return err
+	}
+	return h.SendToOwner(workflowID, msg)
+}
+
+// Status queries the owning service and waits for its status_response.
+func (h *Hub) Status(ctx context.Context, workflowID string) (*coordinator.StatusResponsePayload, error) {
+	msg := coordinator.NewMessageWithWorkflow(coordinator.MessageTypeStatus, workflowID)
+	if err := msg.SetPayload(coordinator.StatusPayload{WorkflowID: workflowID}); err != nil {
+		return nil, err
+	}
+
+	reply, err := h.Request(ctx, workflowID, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var status coordinator.StatusResponsePayload
+	if err := coordinator.PayloadToStruct(reply.Payload, &status); err != nil {
+		return nil, fmt.Errorf("invalid status response: %w", err)
+	}
+	return &status, nil
+}
+
+// Request sends msg to the workflow owner and waits for a reply with the same message ID.
+func (h *Hub) Request(ctx context.Context, workflowID string, msg *coordinator.WSMessage) (*coordinator.WSMessage, error) {
+	reply := make(chan *coordinator.WSMessage, 1)
+
+	h.mu.Lock()
+	h.pending[msg.ID] = reply
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.pending, msg.ID)
+		h.mu.Unlock()
+	}()
+
+	if err := h.SendToOwner(workflowID, msg); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-reply:
+		return resp, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no response for workflow %s: %w", workflowID, ctx.Err())
+	case <-h.ctx.Done():
+		return nil, ErrHubClosed
+	}
+}
+
+// SendToOwner routes a message to the session that owns the workflow.
+func (h *Hub) SendToOwner(workflowID string, msg *coordinator.WSMessage) error {
+	h.mu.RLock()
+	wf, ok := h.workflows[workflowID]
+	var s *session
+	if ok && wf.Owner != "" {
+		s = h.sessions[wf.Owner]
+	}
+	h.mu.RUnlock()
+
+	switch {
+	case !ok || wf.Owner == "":
+		return fmt.Errorf("%w: %s", ErrUnknownWorkflow, workflowID)
+	case s == nil:
+		return fmt.Errorf("%w: %s (owner %s)", ErrOwnerOffline, workflowID, wf.Owner)
+	}
+	return s.send(msg)
+}
+
+// SendToInstance sends a message to a connected service by instance key.
+func (h *Hub) SendToInstance(instanceKey string, msg *coordinator.WSMessage) error {
+	h.mu.RLock()
+	s, ok := h.sessions[instanceKey]
+	h.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrOwnerOffline, instanceKey)
+	}
+	return s.send(msg)
+}
+
+// handleMessage processes a message received from a registered session.
+func (h *Hub) handleMessage(s *session, msg *coordinator.WSMessage) {
+	s.touch()
+
+	// Messages from a client outbox carry a sequence number; acknowledge them and
+	// skip replays of messages that were already processed
+	if msg.Sequence > 0 {
+		s.ack(msg.ID)
+		if h.isDuplicate(s.key, msg.ID) {
+			return
+		}
+	}
+
+	// Replies to hub requests are correlated by message ID
+	if msg.Type == coordinator.MessageTypeStatusResponse || msg.Type == coordinator.MessageTypePong {
+		h.mu.RLock()
+		reply, waiting := h.pending[msg.ID]
+		h.mu.RUnlock()
+		if waiting {
+			select {
+			case reply <- msg:
+			default:
+			}
+		}
+	}
+
+	workflowID := messageWorkflowID(msg)
+	if workflowID != "" {
+		h.updateWorkflow(s, workflowID, msg)
+	}
+
+	h.publish(s, msg)
+}
+
+// isDuplicate records messageID for the instance and reports whether it was seen before.
+func (h *Hub) isDuplicate(instanceKey, messageID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	window, ok := h.seen[instanceKey]
+	if !ok {
+		window = newIDWindow(h.config.DedupWindow)
+		h.seen[instanceKey] = window
+	}
+	return !window.add(messageID)
+}
+
+// updateWorkflow applies a service report to the workflow state and claims ownership.
+func (h *Hub) updateWorkflow(s *session, workflowID string, msg *coordinator.WSMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	wf := h.workflowLocked(workflowID)
+	switch msg.Type {
+	case coordinator.MessageTypeLog, coordinator.MessageTypeLogBatch:
+		// Logs may be forwarded by other services and do not claim ownership
+	default:
+		wf.Owner = s.key
+	}
+	wf.UpdatedAt = time.Now()
+
+	switch msg.Type {
+	case coordinator.MessageTypeWorkflowCreated:
+		var p coordinator.WorkflowCreatedPayload
+		if coordinator.PayloadToStruct(msg.Payload, &p) == nil {
+			wf.ParentWorkflowID = p.ParentWorkflowID
+			wf.RootWorkflowID = p.RootWorkflowID
+			if wf.Phase == "" {
+				wf.Phase = coordinator.PhasePending
+			}
+		}
+	case coordinator.MessageTypePhaseChanged:
+		var p coordinator.PhaseChangedPayload
+		if coordinator.PayloadToStruct(msg.Payload, &p) == nil {
+			wf.Phase = p.ToPhase
+			if p.CheckpointID != "" {
+				wf.CheckpointID = p.CheckpointID
+			}
+		}
+	case coordinator.MessageTypeProgress:
+		var p coordinator.ProgressPayload
+		if coordinator.PayloadToStruct(msg.Payload, &p) == nil {
+			wf.Progress = p.Percent
+			wf.Stage = p.Stage
+		}
+	case coordinator.MessageTypeCheckpoint:
+		var p coordinator.CheckpointPayload
+		if coordinator.PayloadToStruct(msg.Payload, &p) == nil {
+			wf.CheckpointID = p.CheckpointID
+		}
+	case coordinator.MessageTypeStatusResponse:
+		var p coordinator.StatusResponsePayload
+		if coordinator.PayloadToStruct(msg.Payload, &p) == nil {
+			wf.Phase = p.Phase
+			wf.Progress = p.Progress
+		}
+	}
+}
+
+// workflowLocked returns the state for workflowID, creating it; callers hold h.mu.
+func (h *Hub) workflowLocked(workflowID string) *WorkflowState {
+	wf, ok := h.workflows[workflowID]
+	if !ok {
+		wf = &WorkflowState{WorkflowID: workflowID}
+		h.workflows[workflowID] = wf
+	}
+	return wf
+}
+
+// messageWorkflowID extracts the workflow a message refers to.
+// Log entries carry it inside the payload rather than on the message.
+func messageWorkflowID(msg *coordinator.WSMessage) string {
+	if msg.WorkflowID != "" {
+		return msg.WorkflowID
+	}
+	if wid, ok := msg.Payload["workflow_id"].(string); ok {
+		return wid
+	}
+	return ""
+}
+
+// negotiateProtocol returns the protocol version both sides speak.
+// Versions are "major.minor"; majors must match and the lower minor wins.
+func negotiateProtocol(hubVersion, clientVersion string) (string, error) {
+	if clientVersion == "" {
+		clientVersion = "1.0"
+	}
+	hubMajor, hubMinor, err := parseProtocolVersion(hubVersion)
+	if err != nil {
+		return "", err
+	}
+	clientMajor, clientMinor, err := parseProtocolVersion(clientVersion)
+	if err != nil {
+		return "", err
+	}
+	if hubMajor != clientMajor {
+		return "", fmt.Errorf("protocol version %s is incompatible with hub version %s", clientVersion, hubVersion)
+	}
+	if clientMinor < hubMinor {
+		return clientVersion, nil
+	}
+	return hubVersion, nil
+}
+
+// parseProtocolVersion splits "major.minor" (minor optional)
+func parseProtocolVersion(version string) (int, int, error) {
+	majorStr, minorStr, _ := strings.Cut(version, ".")
+	major, err := strconv.Atoi(majorStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid protocol version %q", version)
+	}
+	minor := 0
+	if minorStr != "" {
+		if minor, err = strconv.Atoi(minorStr); err != nil {
+			return 0, 0, fmt.Errorf("invalid protocol version %q", version)
+		}
+	}
+	return major, minor, nil
+}
+
+// checkSchemaVersion validates the schema version reported by a service.
+func (h *Hub) checkSchemaVersion(version int) error {
+	if version == 0 {
+		return nil
+	}
+	if h.config.MinSchemaVersion > 0 && version < h.config.MinSchemaVersion {
+		return fmt.Errorf("schema version %d is older than the minimum %d", version, h.config.MinSchemaVersion)
+	}
+	if h.config.MaxSchemaVersion > 0 && version > h.config.MaxSchemaVersion {
+		return fmt.Errorf("schema version %d is newer than the maximum %d", version, h.config.MaxSchemaVersion)
+	}
+	return nil
+}
+
+// idWindow remembers the most recent message IDs in insertion order.
+type idWindow struct {
+	ids   map[string]struct{}
+	order []string
+	next  int
+}
+
+func newIDWindow(size int) *idWindow {
+	return &idWindow{ids: make(map[string]struct{}, size), order: make([]string, size)}
+}
+
+// add records id and returns false if it is already in the window.
+func (w *idWindow) add(id string) bool {
+	if _, ok := w.ids[id]; ok {
+		return false
+	}
+	if old := w.order[w.next]; old != "" {
+		delete(w.ids, old)
+	}
+	w.order[w.next] = id
+	w.next = (w.next + 1) % len(w.order)
+	w.ids[id] = struct{}{}
+	return true
+}
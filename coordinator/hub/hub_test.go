@@ -0,0 +1,250 @@
+package hub
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eve.evalgo.org/coordinator"
+)
+
+func startHub(t *testing.T, config Config) (*Hub, string) {
+	t.Helper()
+	h := New(config)
+	server := httptest.NewServer(h)
+	t.Cleanup(func() {
+		h.Close()
+		server.Close()
+	})
+	return h, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func connectService(t *testing.T, url, name, instanceID string) *coordinator.Coordinator {
+	t.Helper()
+	config := coordinator.DefaultConfig()
+	config.WhenURL = url
+	config.ServiceName = name
+	config.InstanceID = instanceID
+	config.ProtocolVersion = "1.2"
+
+	client := coordinator.New(config)
+	registered := make(chan string, 1)
+	client.OnRegistered(func(serviceID string) { registered <- serviceID })
+	require.NoError(t, client.Connect())
+	t.Cleanup(func() { client.Close() })
+
+	select {
+	case <-registered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("service did not register")
+	}
+	return client
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	require.Eventually(t, condition, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestHubRegistrationAndPresence(t *testing.T) {
+	h, url := startHub(t, DefaultConfig())
+
+	events := make(chan PresenceEvent, 4)
+	h.OnPresence(func(e PresenceEvent) { events <- e })
+
+	client := connectService(t, url, "containerservice", "worker-1")
+
+	info, ok := h.Session("worker-1")
+	require.True(t, ok)
+	assert.Equal(t, "containerservice", info.ServiceName)
+	assert.Equal(t, "1.0", info.ProtocolVersion, "lower minor version wins")
+
+	joined := <-events
+	assert.Equal(t, PresenceJoined, joined.Type)
+
+	client.Close()
+	left := <-events
+	assert.Equal(t, PresenceLeft, left.Type)
+	assert.Empty(t, h.Sessions())
+}
+
+func TestHubRejectsIncompatibleClients(t *testing.T) {
+	config := DefaultConfig()
+	config.MinSchemaVersion = 3
+	_, url := startHub(t, config)
+
+	for name, payload := range map[string]coordinator.RegisterPayload{
+		"protocol": {ServiceName: "svc", ProtocolVersion: "2.0"},
+		"schema":   {ServiceName: "svc", ProtocolVersion: "1.0", SchemaVersion: 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			msg := coordinator.NewMessage(coordinator.MessageTypeRegister)
+			require.NoError(t, msg.SetPayload(payload))
+			data, _ := msg.JSON()
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, data))
+
+			_, data, err = conn.ReadMessage()
+			require.NoError(t, err)
+			reply, err := coordinator.ParseMessage(data)
+			require.NoError(t, err)
+			assert.Equal(t, coordinator.MessageTypeError, reply.Type)
+			assert.Equal(t, false, reply.Payload["recoverable"])
+		})
+	}
+}
+
+func TestHubRoutesControlToOwner(t *testing.T) {
+	h, url := startHub(t, DefaultConfig())
+	owner := connectService(t, url, "workflowservice", "owner")
+	other := connectService(t, url, "workflowservice", "other")
+
+	sub := h.Subscribe(Filter{WorkflowIDs: []string{"wf-1"}})
+	defer sub.Close()
+
+	phases := owner.Phases()
+	phases.RegisterWorkflow("wf-1", "", "wf-1")
+	// Phase notifications are sent asynchronously, so wait for each one
+	for _, phase := range []coordinator.Phase{coordinator.PhasePreFlight, coordinator.PhasePlanning, coordinator.PhaseExecution} {
+		require.NoError(t, phases.TransitionTo("wf-1", phase, ""))
+		waitFor(t, func() bool {
+			wf, ok := h.Workflow("wf-1")
+			return ok && wf.Owner == "owner" && wf.Phase == phase
+		})
+	}
+
+	owner.SendProgress("wf-1", "step-1", 40, "build", "compiling")
+	select {
+	case event := <-sub.Events():
+		// Phase changes arrive first; drain until progress
+		for event.Message.Type != coordinator.MessageTypeProgress {
+			event = <-sub.Events()
+		}
+		assert.Equal(t, "owner", event.InstanceKey)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no events delivered")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	status, err := h.Status(ctx, "wf-1")
+	require.NoError(t, err)
+	assert.Equal(t, coordinator.PhaseExecution, status.Phase)
+
+	require.NoError(t, h.Pause("wf-1", "maintenance"))
+	waitFor(t, func() bool {
+		phase, _ := phases.GetPhase("wf-1")
+		return phase == coordinator.PhasePausing
+	})
+	_, found := other.Phases().GetState("wf-1")
+	assert.False(t, found, "commands must only reach the owner")
+
+	assert.ErrorIs(t, h.Cancel("unknown", "", false), ErrUnknownWorkflow)
+
+	owner.Close()
+	waitFor(t, func() bool { _, ok := h.Session("owner"); return !ok })
+	assert.ErrorIs(t, h.Resume("wf-1", ""), ErrOwnerOffline)
+}
+
+func TestHubReplaysOutboxAfterReconnect(t *testing.T) {
+	h, url := startHub(t, DefaultConfig())
+
+	outbox, err := coordinator.OpenBoltOutbox(filepath.Join(t.TempDir(), "outbox.db"), 0)
+	require.NoError(t, err)
+	defer outbox.Close()
+
+	config := coordinator.DefaultConfig()
+	config.WhenURL = url
+	config.ServiceName = "workflowservice"
+	config.InstanceID = "buffered"
+	config.Outbox = outbox
+	client := coordinator.New(config)
+	defer client.Close()
+
+	// Emitted while disconnected: kept in the outbox instead of being dropped
+	client.SendWorkflowCreated("wf-offline", "", "wf-offline", "", "")
+	client.SendCheckpoint("wf-offline", "cp-1", "before connect")
+	pending, err := outbox.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 2, pending)
+
+	require.NoError(t, client.Connect())
+	waitFor(t, func() bool {
+		wf, ok := h.Workflow("wf-offline")
+		return ok && wf.Owner == "buffered" && wf.CheckpointID == "cp-1"
+	})
+	waitFor(t, func() bool {
+		n, err := outbox.Len()
+		return err == nil && n == 0
+	})
+
+	// A replayed duplicate is acknowledged but not processed twice
+	sub := h.Subscribe(Filter{WorkflowIDs: []string{"wf-offline"}})
+	defer sub.Close()
+	dup := coordinator.NewMessageWithWorkflow(coordinator.MessageTypeCheckpoint, "wf-offline")
+	require.NoError(t, dup.SetPayload(coordinator.CheckpointPayload{WorkflowID: "wf-offline", CheckpointID: "cp-2"}))
+	client.Send(dup)
+	client.Send(dup)
+
+	<-sub.Events()
+	select {
+	case event := <-sub.Events():
+		t.Fatalf("duplicate delivered: %s", event.Message.ID)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestHubAcksReplayWithSmallSendBuffer(t *testing.T) {
+	config := DefaultConfig()
+	config.SendBuffer = 1
+	_, url := startHub(t, config)
+
+	outbox, err := coordinator.OpenBoltOutbox(filepath.Join(t.TempDir(), "outbox.db"), 0)
+	require.NoError(t, err)
+	defer outbox.Close()
+
+	clientConfig := coordinator.DefaultConfig()
+	clientConfig.WhenURL = url
+	clientConfig.ServiceName = "workflowservice"
+	clientConfig.InstanceID = "burst"
+	clientConfig.Outbox = outbox
+	client := coordinator.New(clientConfig)
+	defer client.Close()
+
+	// More buffered messages than the send queue holds: every one must be acknowledged
+	for i := 0; i < 200; i++ {
+		client.SendProgress("wf-burst", "", float64(i)/2, "replay", "")
+	}
+	pending, err := outbox.Len()
+	require.NoError(t, err)
+	require.Equal(t, 200, pending)
+
+	require.NoError(t, client.Connect())
+	waitFor(t, func() bool {
+		n, err := outbox.Len()
+		return err == nil && n == 0
+	})
+}
+
+func TestNegotiateProtocol(t *testing.T) {
+	version, err := negotiateProtocol("1.3", "1.1")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1", version)
+
+	version, err = negotiateProtocol("1.0", "")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", version)
+
+	_, err = negotiateProtocol("1.0", "x")
+	assert.Error(t, err)
+}
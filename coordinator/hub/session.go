@@ -0,0 +1,367 @@
+package hub
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"eve.evalgo.org/coordinator"
+)
+
+// PresenceEventType identifies a presence change.
+type PresenceEventType string
+
+const (
+	PresenceJoined PresenceEventType = "joined"
+	PresenceLeft   PresenceEventType = "left"
+)
+
+// PresenceEvent is emitted when a service registers or disconnects.
+type PresenceEvent struct {
+	Type    PresenceEventType
+	Session SessionInfo
+	Error   error // Why the session ended (nil on clean close)
+}
+
+// SessionInfo describes a registered service connection.
+type SessionInfo struct {
+	InstanceKey     string // InstanceID if set, otherwise ServiceID
+	ServiceName     string
+	ServiceID       string
+	InstanceID      string
+	Capabilities    []string
+	Version         string
+	ProtocolVersion string // Negotiated protocol version
+	SchemaVersion   int
+	ConnectedAt     time.Time
+	LastSeen        time.Time
+	Stale           bool // Nothing received within Config.PresenceTimeout
+}
+
+// session is one registered service connection.
+type session struct {
+	hub  *Hub
+	conn *websocket.Conn
+	key  string
+	info SessionInfo
+
+	mu       sync.Mutex
+	lastSeen time.Time
+
+	sendChan  chan *coordinator.WSMessage
+	acks      []string      // outbox message IDs awaiting acknowledgement; guarded by mu
+	ackReady  chan struct{} // signals the writer that acks are queued
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// serveConn runs registration and the read loop for one connection.
+func (h *Hub) serveConn(conn *websocket.Conn, headerServiceName string) {
+	s, err := h.register(conn, headerServiceName)
+	if err != nil {
+		h.logger.WithError(err).Warn("Service registration rejected")
+		h.reject(conn, err)
+		conn.Close()
+		return
+	}
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		s.writeLoop(h.config.PingInterval)
+	}()
+
+	err = s.readLoop()
+	s.close()
+	<-writerDone
+
+	h.unregister(s, err)
+}
+
+// register waits for the register message and creates the session.
+func (h *Hub) register(conn *websocket.Conn, headerServiceName string) (*session, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(h.config.RegisterTimeout)); err != nil {
+		return nil, err
+	}
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return nil, fmt.Errorf("no register message: %w", err)
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	msg, err := coordinator.ParseMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid register message: %w", err)
+	}
+	if msg.Type != coordinator.MessageTypeRegister {
+		return nil, fmt.Errorf("expected %s message, got %s", coordinator.MessageTypeRegister, msg.Type)
+	}
+	payload, err := msg.GetRegisterPayload()
+	if err != nil {
+		return nil, fmt.Errorf("invalid register payload: %w", err)
+	}
+
+	if payload.ServiceName == "" {
+		payload.ServiceName = headerServiceName
+	}
+	if payload.ServiceName == "" {
+		return nil, fmt.Errorf("service_name is required")
+	}
+
+	negotiated, err := negotiateProtocol(h.config.ProtocolVersion, payload.ProtocolVersion)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.checkSchemaVersion(payload.SchemaVersion); err != nil {
+		return nil, err
+	}
+
+	serviceID := payload.ServiceID
+	if serviceID == "" {
+		serviceID = fmt.Sprintf("%s-%s", payload.ServiceName, randomSuffix())
+	}
+	key := payload.InstanceID
+	if key == "" {
+		key = serviceID
+	}
+
+	now := time.Now()
+	s := &session{
+		hub:      h,
+		conn:     conn,
+		key:      key,
+		lastSeen: now,
+		sendChan: make(chan *coordinator.WSMessage, h.config.SendBuffer),
+		ackReady: make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		info: SessionInfo{
+			InstanceKey:     key,
+			ServiceName:     payload.ServiceName,
+			ServiceID:       serviceID,
+			InstanceID:      payload.InstanceID,
+			Capabilities:    payload.Capabilities,
+			Version:         payload.Version,
+			ProtocolVersion: negotiated,
+			SchemaVersion:   payload.SchemaVersion,
+			ConnectedAt:     now,
+		},
+	}
+
+	// A reconnecting instance replaces its previous connection and keeps its workflows
+	h.mu.Lock()
+	previous := h.sessions[key]
+	h.sessions[key] = s
+	listeners := append([]func(PresenceEvent){}, h.presence...)
+	h.mu.Unlock()
+	if previous != nil {
+		previous.close()
+	}
+
+	reply := coordinator.NewMessage(coordinator.MessageTypeRegistered)
+	reply.ID = msg.ID
+	if err := reply.SetPayload(coordinator.RegisteredPayload{
+		ServiceID:          serviceID,
+		InstanceID:         payload.InstanceID,
+		Message:            "registered",
+		ProtocolVersion:    negotiated,
+		HubProtocolVersion: h.config.ProtocolVersion,
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.send(reply); err != nil {
+		return nil, err
+	}
+
+	h.logger.WithFields(map[string]interface{}{
+		"service_name": payload.ServiceName,
+		"instance":     key,
+		"protocol":     negotiated,
+	}).Info("Service registered")
+
+	event := PresenceEvent{Type: PresenceJoined, Session: s.snapshot(h.config.PresenceTimeout)}
+	for _, fn := range listeners {
+		fn(event)
+	}
+	return s, nil
+}
+
+// reject tells a client why it was not registered.
+func (h *Hub) reject(conn *websocket.Conn, reason error) {
+	msg := coordinator.NewMessage(coordinator.MessageTypeError)
+	if err := msg.SetPayload(coordinator.ErrorPayload{Error: reason.Error(), Recoverable: false}); err != nil {
+		return
+	}
+	if data, err := msg.JSON(); err == nil {
+		_ = conn.WriteMessage(websocket.TextMessage, data)
+	}
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason.Error()),
+		time.Now().Add(time.Second))
+}
+
+// unregister removes a session unless it was already replaced by a reconnect.
+func (h *Hub) unregister(s *session, reason error) {
+	h.mu.Lock()
+	current := h.sessions[s.key] == s
+	if current {
+		delete(h.sessions, s.key)
+	}
+	listeners := append([]func(PresenceEvent){}, h.presence...)
+	h.mu.Unlock()
+
+	if !current {
+		return
+	}
+	if websocket.IsCloseError(reason, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		reason = nil
+	}
+
+	h.logger.WithField("instance", s.key).Info("Service disconnected")
+	event := PresenceEvent{Type: PresenceLeft, Session: s.snapshot(h.config.PresenceTimeout), Error: reason}
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
+
+// readLoop dispatches messages until the connection fails.
+func (s *session) readLoop() error {
+	s.conn.SetPongHandler(func(string) error {
+		s.touch()
+		return nil
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		msg, err := coordinator.ParseMessage(data)
+		if err != nil {
+			s.hub.logger.WithError(err).WithField("instance", s.key).Warn("Failed to parse message")
+			continue
+		}
+		s.hub.handleMessage(s, msg)
+	}
+}
+
+// writeLoop is the only writer on the connection; it also sends pings.
+func (s *session) writeLoop(pingInterval time.Duration) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			_ = s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+				time.Now().Add(time.Second))
+			s.conn.Close()
+			return
+		case msg := <-s.sendChan:
+			if !s.write(msg) {
+				return
+			}
+		case <-s.ackReady:
+			if ack := s.takeAcks(); ack != nil && !s.write(ack) {
+				return
+			}
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				s.hub.logger.WithError(err).Debug("Ping failed")
+			}
+		}
+	}
+}
+
+// write sends msg on the connection and reports whether the connection is still usable.
+func (s *session) write(msg *coordinator.WSMessage) bool {
+	data, err := msg.JSON()
+	if err != nil {
+		s.hub.logger.WithError(err).Warn("Failed to marshal message")
+		return true
+	}
+	if err := s.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		s.conn.Close()
+		return false
+	}
+	return true
+}
+
+// ack queues an acknowledgement for an outbox message. Acks bypass the send
+// queue so they are never dropped when it is full; the writer batches them.
+func (s *session) ack(messageID string) {
+	s.mu.Lock()
+	s.acks = append(s.acks, messageID)
+	s.mu.Unlock()
+	select {
+	case s.ackReady <- struct{}{}:
+	default:
+	}
+}
+
+// takeAcks returns one message acknowledging all queued IDs, or nil if none are queued.
+func (s *session) takeAcks() *coordinator.WSMessage {
+	s.mu.Lock()
+	ids := s.acks
+	s.acks = nil
+	s.mu.Unlock()
+	if len(ids) == 0 {
+		return nil
+	}
+	ack := coordinator.NewMessage(coordinator.MessageTypeAck)
+	if err := ack.SetPayload(coordinator.AckPayload{MessageIDs: ids}); err != nil {
+		s.hub.logger.WithError(err).Warn("Failed to build ack")
+		return nil
+	}
+	return ack
+}
+
+// send queues a message for the writer.
+func (s *session) send(msg *coordinator.WSMessage) error {
+	select {
+	case <-s.done:
+		return fmt.Errorf("%w: %s", ErrOwnerOffline, s.key)
+	default:
+	}
+	select {
+	case s.sendChan <- msg:
+		return nil
+	default:
+		return fmt.Errorf("send queue full for %s", s.key)
+	}
+}
+
+// close stops the writer, which closes the connection.
+func (s *session) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+func (s *session) touch() {
+	s.mu.Lock()
+	s.lastSeen = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *session) snapshot(presenceTimeout time.Duration) SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := s.info
+	info.LastSeen = s.lastSeen
+	info.Stale = presenceTimeout > 0 && time.Since(s.lastSeen) > presenceTimeout
+	return info
+}
+
+// randomSuffix generates a short ID for services registering without one.
+func randomSuffix() string {
+	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = charset[rand.Intn(len(charset))]
+	}
+	return string(b)
+}
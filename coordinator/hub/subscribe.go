@@ -0,0 +1,220 @@
+package hub
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"eve.evalgo.org/coordinator"
+)
+
+// DefaultStreamTypes are the message types delivered to subscribers that do not
+// set Filter.Types.
+var DefaultStreamTypes = []coordinator.MessageType{
+	coordinator.MessageTypeWorkflowCreated,
+	coordinator.MessageTypePhaseChanged,
+	coordinator.MessageTypeProgress,
+	coordinator.MessageTypeCheckpoint,
+	coordinator.MessageTypeError,
+	coordinator.MessageTypeLog,
+	coordinator.MessageTypeLogBatch,
+}
+
+// Filter selects the messages a subscriber receives.
+// Empty fields match everything; RootWorkflowID also matches all workflows
+// whose reported root is that workflow.
+type Filter struct {
+	WorkflowIDs    []string
+	RootWorkflowID string
+	ServiceNames   []string
+	Types          []coordinator.MessageType
+}
+
+// Event is a service message delivered to subscribers.
+type Event struct {
+	Message     *coordinator.WSMessage
+	InstanceKey string
+	ServiceName string
+	ReceivedAt  time.Time
+}
+
+// Subscription receives events matching its filter.
+// Events are dropped (and counted) when the subscriber falls behind.
+type Subscription struct {
+	hub     *Hub
+	filter  Filter
+	events  chan Event
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// Subscribe registers a subscriber for progress, log and phase streams.
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	if len(filter.Types) == 0 {
+		filter.Types = DefaultStreamTypes
+	}
+	sub := &Subscription{
+		hub:    h,
+		filter: filter,
+		events: make(chan Event, h.config.SendBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.events)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Events returns the event channel; it is closed by Close.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns the number of events discarded because the subscriber was slow.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes and closes the event channel.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		_, active := s.hub.subs[s]
+		delete(s.hub.subs, s)
+		s.hub.mu.Unlock()
+		if active {
+			close(s.events)
+		}
+	})
+}
+
+// publish fans a service message out to matching subscribers.
+func (h *Hub) publish(s *session, msg *coordinator.WSMessage) {
+	event := Event{
+		Message:     msg,
+		InstanceKey: s.key,
+		ServiceName: s.info.ServiceName,
+		ReceivedAt:  time.Now(),
+	}
+	workflowID := messageWorkflowID(msg)
+
+	// Hold the read lock while sending so Close cannot close a channel mid-send
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	root := ""
+	if wf, ok := h.workflows[workflowID]; ok {
+		root = wf.RootWorkflowID
+	}
+	for sub := range h.subs {
+		if !sub.filter.matches(msg, workflowID, root, s.info.ServiceName) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// matches reports whether a message passes the filter.
+func (f Filter) matches(msg *coordinator.WSMessage, workflowID, rootWorkflowID, serviceName string) bool {
+	if !containsType(f.Types, msg.Type) {
+		return false
+	}
+	if len(f.ServiceNames) > 0 && !containsString(f.ServiceNames, serviceName) {
+		return false
+	}
+	if len(f.WorkflowIDs) == 0 && f.RootWorkflowID == "" {
+		return true
+	}
+	if containsString(f.WorkflowIDs, workflowID) {
+		return true
+	}
+	return f.RootWorkflowID != "" && (workflowID == f.RootWorkflowID || rootWorkflowID == f.RootWorkflowID)
+}
+
+// StreamHandler serves subscriber WebSocket connections.
+// Query parameters: workflow_id (repeatable), root_workflow_id, service, type (repeatable).
+// Each matching service message is forwarded as-is.
+func (h *Hub) StreamHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := h.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			h.logger.WithError(err).Warn("WebSocket upgrade failed")
+			return
+		}
+		defer conn.Close()
+
+		query := r.URL.Query()
+		filter := Filter{
+			WorkflowIDs:    query["workflow_id"],
+			RootWorkflowID: query.Get("root_workflow_id"),
+			ServiceNames:   query["service"],
+		}
+		for _, t := range query["type"] {
+			for _, part := range strings.Split(t, ",") {
+				filter.Types = append(filter.Types, coordinator.MessageType(part))
+			}
+		}
+
+		sub := h.Subscribe(filter)
+		defer sub.Close()
+
+		// Detect subscriber disconnects
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				data, err := event.Message.JSON()
+				if err != nil {
+					continue
+				}
+				if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+func containsType(types []coordinator.MessageType, t coordinator.MessageType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
@@ -31,6 +31,7 @@ const (
 	MessageTypeCancel     MessageType = "cancel"
 	MessageTypeStatus     MessageType = "status"
 	MessageTypePing       MessageType = "ping"
+	MessageTypeAck        MessageType = "ack" // Acknowledges outbox messages
 )
 
 // WSMessage is the base message structure for all WebSocket communication.
@@ -39,6 +40,7 @@ type WSMessage struct {
 	Type       MessageType            `json:"type"`                  // Message type
 	WorkflowID string                 `json:"workflow_id,omitempty"` // Associated workflow
 	Timestamp  time.Time              `json:"timestamp"`             // Message timestamp
+	Sequence   uint64                 `json:"seq,omitempty"`         // Outbox sequence number (set when buffered)
 	Payload    map[string]interface{} `json:"payload,omitempty"`     // Message-specific data
 }
 
@@ -196,6 +198,11 @@ type LogBatchPayload struct {
 	Logs []LogEntry `json:"logs"`
 }
 
+// AckPayload is the payload for an ack message.
+type AckPayload struct {
+	MessageIDs []string `json:"message_ids"`
+}
+
 // Helper functions to extract typed payloads from messages
 
 // GetRegisterPayload extracts RegisterPayload from message.
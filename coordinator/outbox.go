@@ -0,0 +1,273 @@
+package coordinator
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"eve.evalgo.org/db/bolt"
+)
+
+// ErrOutboxFull is returned when a low-priority message is dropped because the outbox is at capacity.
+var ErrOutboxFull = errors.New("outbox full")
+
+// DefaultOutboxMaxMessages bounds the outbox when no limit is configured.
+const DefaultOutboxMaxMessages = 10000
+
+// Outbox durably buffers outgoing messages until the server acknowledges them.
+// Messages are assigned increasing sequence numbers and de-duplicated by WSMessage ID.
+type Outbox interface {
+	// Enqueue stores a message and sets msg.Sequence.
+	// Enqueuing an ID that is already stored returns the existing sequence.
+	Enqueue(msg *WSMessage) (uint64, error)
+
+	// Pending returns unacknowledged messages in sequence order (limit <= 0 = all).
+	Pending(limit int) ([]*WSMessage, error)
+
+	// Ack removes acknowledged messages by ID; unknown IDs are ignored.
+	Ack(messageIDs ...string) error
+
+	// Len returns the number of unacknowledged messages.
+	Len() (int, error)
+
+	// Close releases the underlying storage.
+	Close() error
+}
+
+// IsLowPriority reports whether a message type may be dropped when the outbox is full.
+// Only log traffic is droppable; phase, checkpoint and error messages are always kept.
+func IsLowPriority(msgType MessageType) bool {
+	return msgType == MessageTypeLog || msgType == MessageTypeLogBatch
+}
+
+// Bolt buckets used by BoltOutbox
+const (
+	outboxMessagesBucket = "coordinator_outbox"
+	outboxIDsBucket      = "coordinator_outbox_ids"
+)
+
+// outboxRecord is the stored form of a buffered message
+type outboxRecord struct {
+	LowPriority bool       `json:"low_priority,omitempty"`
+	Message     *WSMessage `json:"message"`
+}
+
+// BoltOutbox implements Outbox on a bbolt database.
+// When maxMessages is reached, new log messages are dropped and other messages
+// evict the oldest buffered log message; if none is left the bound is exceeded
+// rather than losing a phase change or checkpoint.
+type BoltOutbox struct {
+	db          *bolt.DB
+	ownsDB      bool
+	maxMessages int
+	dropped     atomic.Int64
+	mu          sync.Mutex
+	count       int // buffered messages; guarded by mu
+}
+
+// OpenBoltOutbox opens (or creates) an outbox database at path.
+func OpenBoltOutbox(path string, maxMessages int) (*BoltOutbox, error) {
+	db, err := bolt.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	outbox, err := NewBoltOutbox(db, maxMessages)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	outbox.ownsDB = true
+	return outbox, nil
+}
+
+// NewBoltOutbox creates an outbox in an existing database (e.g. the service's state DB).
+func NewBoltOutbox(db *bolt.DB, maxMessages int) (*BoltOutbox, error) {
+	if maxMessages <= 0 {
+		maxMessages = DefaultOutboxMaxMessages
+	}
+	for _, bucket := range []string{outboxMessagesBucket, outboxIDsBucket} {
+		if err := db.CreateBucket(bucket); err != nil {
+			return nil, err
+		}
+	}
+	outbox := &BoltOutbox{db: db, maxMessages: maxMessages}
+	// Count the messages left by a previous run once; afterwards the count is maintained
+	err := db.View(func(tx *bbolt.Tx) error {
+		outbox.count = tx.Bucket([]byte(outboxMessagesBucket)).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outbox, nil
+}
+
+// Dropped returns the number of low-priority messages discarded since opening.
+func (o *BoltOutbox) Dropped() int64 {
+	return o.dropped.Load()
+}
+
+// Enqueue stores a message, applying the drop policy when the outbox is full.
+func (o *BoltOutbox) Enqueue(msg *WSMessage) (uint64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	lowPriority := IsLowPriority(msg.Type)
+	var seq uint64
+	dropped := false
+	delta := 0
+
+	err := o.db.Update(func(tx *bbolt.Tx) error {
+		messages := tx.Bucket([]byte(outboxMessagesBucket))
+		ids := tx.Bucket([]byte(outboxIDsBucket))
+
+		if existing := ids.Get([]byte(msg.ID)); existing != nil {
+			// Already queued: keep the stored copy and report its sequence. The
+			// message may be in flight, so it is only written when it differs.
+			seq = binary.BigEndian.Uint64(existing)
+			if msg.Sequence != seq {
+				msg.Sequence = seq
+			}
+			return nil
+		}
+
+		if o.count >= o.maxMessages {
+			if lowPriority {
+				dropped = true
+				return nil
+			}
+			evicted, err := evictOldestLowPriority(messages, ids)
+			if err != nil {
+				return err
+			}
+			if evicted {
+				delta--
+			}
+		}
+
+		next, err := messages.NextSequence()
+		if err != nil {
+			return err
+		}
+		seq = next
+		msg.Sequence = seq
+
+		data, err := json.Marshal(outboxRecord{LowPriority: lowPriority, Message: msg})
+		if err != nil {
+			return fmt.Errorf("failed to marshal outbox message: %w", err)
+		}
+		key := sequenceKey(seq)
+		if err := messages.Put(key, data); err != nil {
+			return err
+		}
+		delta++
+		return ids.Put([]byte(msg.ID), key)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue message %s: %w", msg.ID, err)
+	}
+	o.count += delta
+	if dropped {
+		o.dropped.Add(1)
+		return 0, ErrOutboxFull
+	}
+	return seq, nil
+}
+
+// evictOldestLowPriority removes the oldest droppable message and reports whether one was found.
+func evictOldestLowPriority(messages, ids *bbolt.Bucket) (bool, error) {
+	c := messages.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var record outboxRecord
+		if err := json.Unmarshal(v, &record); err != nil {
+			continue
+		}
+		if !record.LowPriority {
+			continue
+		}
+		if record.Message != nil {
+			if err := ids.Delete([]byte(record.Message.ID)); err != nil {
+				return false, err
+			}
+		}
+		return true, c.Delete()
+	}
+	return false, nil
+}
+
+// Pending returns buffered messages in sequence order.
+func (o *BoltOutbox) Pending(limit int) ([]*WSMessage, error) {
+	var pending []*WSMessage
+	err := o.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte(outboxMessagesBucket)).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var record outboxRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("failed to unmarshal outbox message %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			pending = append(pending, record.Message)
+			if limit > 0 && len(pending) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	return pending, err
+}
+
+// Ack removes acknowledged messages.
+func (o *BoltOutbox) Ack(messageIDs ...string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	removed := 0
+	err := o.db.Update(func(tx *bbolt.Tx) error {
+		messages := tx.Bucket([]byte(outboxMessagesBucket))
+		ids := tx.Bucket([]byte(outboxIDsBucket))
+		for _, id := range messageIDs {
+			key := ids.Get([]byte(id))
+			if key == nil {
+				continue
+			}
+			if err := messages.Delete(key); err != nil {
+				return err
+			}
+			if err := ids.Delete([]byte(id)); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err == nil {
+		o.count -= removed
+	}
+	return err
+}
+
+// Len returns the number of buffered messages.
+func (o *BoltOutbox) Len() (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.count, nil
+}
+
+// Close closes the database if the outbox opened it.
+func (o *BoltOutbox) Close() error {
+	if o.ownsDB {
+		return o.db.Close()
+	}
+	return nil
+}
+
+// sequenceKey encodes a sequence number so keys sort in sequence order
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
@@ -0,0 +1,97 @@
+package coordinator
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoltOutboxSequenceAndDedup(t *testing.T) {
+	outbox, err := OpenBoltOutbox(filepath.Join(t.TempDir(), "outbox.db"), 0)
+	require.NoError(t, err)
+	defer outbox.Close()
+
+	first := NewMessageWithWorkflow(MessageTypePhaseChanged, "wf-1")
+	second := NewMessageWithWorkflow(MessageTypeCheckpoint, "wf-1")
+
+	seq1, err := outbox.Enqueue(first)
+	require.NoError(t, err)
+	seq2, err := outbox.Enqueue(second)
+	require.NoError(t, err)
+	assert.Less(t, seq1, seq2)
+	assert.Equal(t, seq2, second.Sequence)
+
+	again, err := outbox.Enqueue(first)
+	require.NoError(t, err)
+	assert.Equal(t, seq1, again, "same message ID is stored once")
+
+	pending, err := outbox.Pending(0)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, first.ID, pending[0].ID)
+
+	require.NoError(t, outbox.Ack(first.ID, "unknown"))
+	n, err := outbox.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestBoltOutboxDropsOnlyLogs(t *testing.T) {
+	outbox, err := OpenBoltOutbox(filepath.Join(t.TempDir(), "outbox.db"), 2)
+	require.NoError(t, err)
+	defer outbox.Close()
+
+	logMsg := NewMessage(MessageTypeLog)
+	_, err = outbox.Enqueue(logMsg)
+	require.NoError(t, err)
+	_, err = outbox.Enqueue(NewMessageWithWorkflow(MessageTypePhaseChanged, "wf-1"))
+	require.NoError(t, err)
+
+	// Full: new logs are dropped
+	_, err = outbox.Enqueue(NewMessage(MessageTypeLogBatch))
+	assert.ErrorIs(t, err, ErrOutboxFull)
+	assert.Equal(t, int64(1), outbox.Dropped())
+
+	// Full: a checkpoint evicts the buffered log
+	_, err = outbox.Enqueue(NewMessageWithWorkflow(MessageTypeCheckpoint, "wf-1"))
+	require.NoError(t, err)
+	pending, err := outbox.Pending(0)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	for _, msg := range pending {
+		assert.NotEqual(t, logMsg.ID, msg.ID)
+	}
+
+	// Full with no logs left: phase changes are still kept
+	_, err = outbox.Enqueue(NewMessageWithWorkflow(MessageTypePhaseChanged, "wf-2"))
+	require.NoError(t, err)
+	n, err := outbox.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestBoltOutboxLenAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.db")
+	outbox, err := OpenBoltOutbox(path, 0)
+	require.NoError(t, err)
+	first := NewMessageWithWorkflow(MessageTypePhaseChanged, "wf-1")
+	_, err = outbox.Enqueue(first)
+	require.NoError(t, err)
+	_, err = outbox.Enqueue(NewMessageWithWorkflow(MessageTypeCheckpoint, "wf-1"))
+	require.NoError(t, err)
+	require.NoError(t, outbox.Close())
+
+	outbox, err = OpenBoltOutbox(path, 0)
+	require.NoError(t, err)
+	defer outbox.Close()
+	n, err := outbox.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "messages left by a previous run are counted")
+
+	require.NoError(t, outbox.Ack(first.ID, first.ID))
+	n, err = outbox.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
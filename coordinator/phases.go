@@ -1,6 +1,8 @@
 package coordinator
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -87,10 +89,23 @@ type PhaseState struct {
 	RootWorkflowID   string
 }
 
+// snapshot returns a copy that callbacks can read without holding the manager lock.
+func (s *PhaseState) snapshot() *PhaseState {
+	c := *s
+	return &c
+}
+
+// ChildWorkflowSource lists the sub-workflow instances recorded for a workflow
+// instance, e.g. a repository.WorkflowLineageRepository filled by workflow.Expand.
+type ChildWorkflowSource interface {
+	GetChildWorkflows(ctx context.Context, instanceID string) ([]string, error)
+}
+
 // PhaseManager manages phase states for multiple workflows.
 type PhaseManager struct {
 	mu             sync.RWMutex
 	workflows      map[string]*PhaseState
+	lineage        ChildWorkflowSource
 	onPhaseChanged func(state *PhaseState)
 	onCheckpoint   func(workflowID, checkpointID string, state map[string]interface{})
 }
@@ -109,6 +124,14 @@ func (pm *PhaseManager) OnPhaseChanged(fn func(state *PhaseState)) {
 	pm.onPhaseChanged = fn
 }
 
+// SetLineageSource sets where persisted sub-workflow lineage is looked up, so
+// cancellation also reaches children registered without a parent.
+func (pm *PhaseManager) SetLineageSource(source ChildWorkflowSource) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.lineage = source
+}
+
 // OnCheckpoint sets a callback for checkpoint creation.
 func (pm *PhaseManager) OnCheckpoint(fn func(workflowID, checkpointID string, state map[string]interface{})) {
 	pm.mu.Lock()
@@ -182,7 +205,7 @@ func (pm *PhaseManager) TransitionTo(workflowID string, newPhase Phase, reason s
 
 	// Notify callback
 	if pm.onPhaseChanged != nil {
-		go pm.onPhaseChanged(state)
+		go pm.onPhaseChanged(state.snapshot())
 	}
 
 	return nil
@@ -240,7 +263,7 @@ func (pm *PhaseManager) CompletePause(workflowID, checkpointID string) error {
 	state.CheckpointID = checkpointID
 
 	if pm.onPhaseChanged != nil {
-		go pm.onPhaseChanged(state)
+		go pm.onPhaseChanged(state.snapshot())
 	}
 	pm.mu.Unlock()
 
@@ -275,8 +298,17 @@ func (pm *PhaseManager) CompleteResume(workflowID string) error {
 	return pm.TransitionTo(workflowID, PhaseExecution, "resumed")
 }
 
-// Cancel initiates cancellation of a workflow.
+// Cancel initiates cancellation of a workflow and its sub-workflows.
 func (pm *PhaseManager) Cancel(workflowID, reason string) error {
+	return pm.CancelContext(context.Background(), workflowID, reason)
+}
+
+// CancelContext initiates cancellation of a workflow and propagates it to its
+// sub-workflows, using ctx for lineage lookups. Sub-workflows known only from
+// lineage and not tracked by this manager are passed through, so their own
+// children are still cancelled. Lineage lookup errors are returned after every
+// reachable sub-workflow has been cancelled.
+func (pm *PhaseManager) CancelContext(ctx context.Context, workflowID, reason string) error {
 	pm.mu.Lock()
 	state, ok := pm.workflows[workflowID]
 	pm.mu.Unlock()
@@ -290,7 +322,73 @@ func (pm *PhaseManager) Cancel(workflowID, reason string) error {
 		return fmt.Errorf("workflow %s is already in terminal state %s", workflowID, state.Phase)
 	}
 
-	return pm.TransitionTo(workflowID, PhaseCancelling, reason)
+	if err := pm.TransitionTo(workflowID, PhaseCancelling, reason); err != nil {
+		return err
+	}
+
+	return pm.cancelChildren(ctx, workflowID, reason, map[string]bool{workflowID: true})
+}
+
+// cancelChildren propagates the cancellation of parentID to its sub-workflows.
+// visited guards against cycles in recorded lineage.
+func (pm *PhaseManager) cancelChildren(ctx context.Context, parentID, reason string, visited map[string]bool) error {
+	children, err := pm.GetChildWorkflows(ctx, parentID)
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, childID := range children {
+		if visited[childID] {
+			continue
+		}
+		visited[childID] = true
+
+		childReason := fmt.Sprintf("parent workflow %s cancelled: %s", parentID, reason)
+		if phase, ok := pm.GetPhase(childID); ok {
+			if phase.IsTerminal() || phase == PhaseCancelling {
+				continue
+			}
+			// Children that cannot be cancelled yet (e.g. pending) are left as they are
+			_ = pm.TransitionTo(childID, PhaseCancelling, childReason)
+		}
+		if err := pm.cancelChildren(ctx, childID, childReason, visited); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetChildWorkflows returns the IDs of workflows registered with workflowID as
+// parent, plus the children recorded in the lineage source. If the lineage
+// lookup fails, the registered children are returned together with the error.
+func (pm *PhaseManager) GetChildWorkflows(ctx context.Context, workflowID string) ([]string, error) {
+	pm.mu.RLock()
+	var children []string
+	seen := map[string]bool{workflowID: true}
+	for id, state := range pm.workflows {
+		if state.ParentWorkflowID == workflowID && !seen[id] {
+			seen[id] = true
+			children = append(children, id)
+		}
+	}
+	lineage := pm.lineage
+	pm.mu.RUnlock()
+
+	if lineage == nil {
+		return children, nil
+	}
+	recorded, err := lineage.GetChildWorkflows(ctx, workflowID)
+	if err != nil {
+		return children, fmt.Errorf("failed to look up sub-workflows of %s: %w", workflowID, err)
+	}
+	for _, id := range recorded {
+		if !seen[id] {
+			seen[id] = true
+			children = append(children, id)
+		}
+	}
+	return children, nil
 }
 
 // CompleteCancellation finishes the cancellation.
@@ -318,7 +416,7 @@ func (pm *PhaseManager) Fail(workflowID, reason string) error {
 	state.Reason = reason
 
 	if pm.onPhaseChanged != nil {
-		go pm.onPhaseChanged(state)
+		go pm.onPhaseChanged(state.snapshot())
 	}
 	pm.mu.Unlock()
 
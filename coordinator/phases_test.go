@@ -0,0 +1,134 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLineageSource maps workflow instance IDs to their recorded children
+type fakeLineageSource map[string][]string
+
+func (f fakeLineageSource) GetChildWorkflows(ctx context.Context, instanceID string) ([]string, error) {
+	return f[instanceID], nil
+}
+
+// startWorkflow registers a workflow and moves it into execution
+func startWorkflow(t *testing.T, pm *PhaseManager, workflowID, parentID, rootID string) {
+	t.Helper()
+	pm.RegisterWorkflow(workflowID, parentID, rootID)
+	for _, phase := range []Phase{PhasePreFlight, PhasePlanning, PhaseExecution} {
+		require.NoError(t, pm.TransitionTo(workflowID, phase, "test"))
+	}
+}
+
+// TestPhaseManagerCancelPropagatesToRegisteredChildren tests cancellation of children registered with a parent
+func TestPhaseManagerCancelPropagatesToRegisteredChildren(t *testing.T) {
+	pm := NewPhaseManager()
+	startWorkflow(t, pm, "root", "", "root")
+	startWorkflow(t, pm, "child", "root", "root")
+	startWorkflow(t, pm, "grandchild", "child", "root")
+	startWorkflow(t, pm, "done", "root", "root")
+	require.NoError(t, pm.Complete("done"))
+
+	require.NoError(t, pm.Cancel("root", "user request"))
+
+	for _, id := range []string{"root", "child", "grandchild"} {
+		phase, _ := pm.GetPhase(id)
+		assert.Equal(t, PhaseCancelling, phase, id)
+	}
+	phase, _ := pm.GetPhase("done")
+	assert.Equal(t, PhaseCompleted, phase, "terminal children are left alone")
+
+	state, _ := pm.GetState("grandchild")
+	assert.Equal(t, "parent workflow child cancelled: parent workflow root cancelled: user request", state.Reason)
+}
+
+// TestPhaseManagerCancelPropagatesThroughLineage tests cancellation of children known only from recorded lineage
+func TestPhaseManagerCancelPropagatesThroughLineage(t *testing.T) {
+	pm := NewPhaseManager()
+	pm.SetLineageSource(fakeLineageSource{
+		"root":  {"child", "unknown"},
+		"child": {"grandchild"},
+	})
+	startWorkflow(t, pm, "root", "", "root")
+	startWorkflow(t, pm, "child", "", "root")
+	startWorkflow(t, pm, "grandchild", "", "root")
+	startWorkflow(t, pm, "other", "", "other")
+
+	children, err := pm.GetChildWorkflows(context.Background(), "root")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"child", "unknown"}, children)
+
+	require.NoError(t, pm.Cancel("root", "user request"))
+
+	for _, id := range []string{"root", "child", "grandchild"} {
+		phase, _ := pm.GetPhase(id)
+		assert.Equal(t, PhaseCancelling, phase, id)
+	}
+	phase, _ := pm.GetPhase("other")
+	assert.Equal(t, PhaseExecution, phase)
+}
+
+// ctxKey marks the context passed to Cancel
+type ctxKey struct{}
+
+// recordingLineageSource fails lookups for the workflows in failing and
+// records the contexts it is called with
+type recordingLineageSource struct {
+	children map[string][]string
+	failing  map[string]bool
+	contexts []context.Context
+}
+
+func (r *recordingLineageSource) GetChildWorkflows(ctx context.Context, instanceID string) ([]string, error) {
+	r.contexts = append(r.contexts, ctx)
+	if r.failing[instanceID] {
+		return nil, errors.New("lineage unavailable")
+	}
+	return r.children[instanceID], nil
+}
+
+// TestPhaseManagerCancelThroughUntrackedChildren tests that children known only from lineage are passed through
+func TestPhaseManagerCancelThroughUntrackedChildren(t *testing.T) {
+	pm := NewPhaseManager()
+	source := &recordingLineageSource{
+		children: map[string][]string{
+			"root":   {"remote"},
+			"remote": {"grandchild", "root"}, // Cycles in lineage are ignored
+		},
+		failing: map[string]bool{"grandchild": true},
+	}
+	pm.SetLineageSource(source)
+	startWorkflow(t, pm, "root", "", "root")
+	startWorkflow(t, pm, "grandchild", "", "root")
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "cancel")
+	err := pm.CancelContext(ctx, "root", "user request")
+	assert.ErrorContains(t, err, "failed to look up sub-workflows of grandchild: lineage unavailable")
+
+	phase, _ := pm.GetPhase("grandchild")
+	assert.Equal(t, PhaseCancelling, phase, "descendants of untracked children are cancelled")
+	state, _ := pm.GetState("grandchild")
+	assert.Equal(t, "parent workflow remote cancelled: parent workflow root cancelled: user request", state.Reason)
+
+	require.NotEmpty(t, source.contexts)
+	for _, c := range source.contexts {
+		assert.Equal(t, "cancel", c.Value(ctxKey{}), "lookups use the caller's context")
+	}
+}
+
+// TestPhaseManagerGetChildWorkflowsLineageError tests that lookup errors are returned with the registered children
+func TestPhaseManagerGetChildWorkflowsLineageError(t *testing.T) {
+	pm := NewPhaseManager()
+	pm.SetLineageSource(&recordingLineageSource{failing: map[string]bool{"root": true}})
+	pm.RegisterWorkflow("root", "", "root")
+	pm.RegisterWorkflow("child", "root", "root")
+
+	children, err := pm.GetChildWorkflows(context.Background(), "root")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"child"}, children)
+}
@@ -0,0 +1,59 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// CheckpointBucket holds changes feed checkpoints
+const CheckpointBucket = "changes_checkpoints"
+
+// CheckpointStore keeps changes feed checkpoints in a bbolt database.
+// It implements db.CheckpointStore.
+type CheckpointStore struct {
+	db *DB
+}
+
+// checkpoint is a stored consumer checkpoint
+type checkpoint struct {
+	Seq       string    `json:"seq"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewCheckpointStore creates a checkpoint store, creating its bucket if needed
+func NewCheckpointStore(db *DB) (*CheckpointStore, error) {
+	if err := db.CreateBucket(CheckpointBucket); err != nil {
+		return nil, err
+	}
+	return &CheckpointStore{db: db}, nil
+}
+
+// LoadCheckpoint reads a consumer checkpoint, returning "" if there is none
+func (s *CheckpointStore) LoadCheckpoint(ctx context.Context, database, consumer string) (string, error) {
+	var cp checkpoint
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte(CheckpointBucket)).Get(checkpointKey(database, consumer))
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &cp)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	return cp.Seq, nil
+}
+
+// SaveCheckpoint writes a consumer checkpoint
+func (s *CheckpointStore) SaveCheckpoint(ctx context.Context, database, consumer, seq string) error {
+	return s.db.PutJSON(CheckpointBucket, string(checkpointKey(database, consumer)),
+		checkpoint{Seq: seq, UpdatedAt: time.Now().UTC()})
+}
+
+func checkpointKey(database, consumer string) []byte {
+	return []byte(database + "/" + consumer)
+}
@@ -0,0 +1,418 @@
+package db
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	kivik "github.com/go-kivik/kivik/v4"
+)
+
+// DefaultInlineAttachmentSize is the largest attachment GetDocumentWithAttachments
+// loads inline when no limit is given
+const DefaultInlineAttachmentSize = 64 * 1024
+
+// ErrAttachmentDigestMismatch is returned when attachment content does not
+// match its MD5 digest
+var ErrAttachmentDigestMismatch = errors.New("attachment digest mismatch")
+
+// ErrAttachmentPathInvalid is returned when an exported document references an
+// attachment file outside its export directory
+var ErrAttachmentPathInvalid = errors.New("attachment file outside the export directory")
+
+// Attachment is an entry of a document's _attachments field.
+// Stubs carry metadata only; inline attachments carry their content in Data,
+// which is base64 encoded in JSON as CouchDB expects.
+//
+// Documents embed attachments with an Attachments field:
+//
+//	type Report struct {
+//	    ID          string      `json:"_id,omitempty"`
+//	    Rev         string      `json:"_rev,omitempty"`
+//	    Title       string      `json:"title"`
+//	    Attachments Attachments `json:"_attachments,omitempty"`
+//	}
+type Attachment struct {
+	ContentType string `json:"content_type"`
+	Length      int64  `json:"length,omitempty"`
+	Digest      string `json:"digest,omitempty"` // "md5-<base64>"
+	RevPos      int64  `json:"revpos,omitempty"`
+	Stub        bool   `json:"stub,omitempty"`
+	Data        []byte `json:"data,omitempty"`
+}
+
+// Attachments maps attachment file names to attachments
+type Attachments map[string]Attachment
+
+// NewInlineAttachment creates an attachment saved together with its document.
+// Inline attachments suit small files; stream larger ones with PutAttachment.
+//
+// Example Usage:
+//
+//	report.Attachments = Attachments{
+//	    "summary.txt": NewInlineAttachment("text/plain", []byte("All checks passed")),
+//	}
+//	_, err := SaveDocument(service, report)
+func NewInlineAttachment(contentType string, data []byte) Attachment {
+	return Attachment{
+		ContentType: contentType,
+		Length:      int64(len(data)),
+		Digest:      attachmentDigest(data),
+		Data:        data,
+	}
+}
+
+// AttachmentContent streams an attachment. Reading it to the end verifies
+// the content against the digest reported by CouchDB; a mismatch is returned
+// as ErrAttachmentDigestMismatch instead of io.EOF. Callers must Close it.
+type AttachmentContent struct {
+	Filename    string
+	ContentType string
+	Length      int64 // -1 if unknown
+	Digest      string
+	io.ReadCloser
+}
+
+// PutAttachment uploads an attachment, streaming its content from r.
+// The document is created if it does not exist and rev is empty.
+// After the upload, the digest stored by CouchDB is compared with the digest
+// of the streamed content.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - docID: Document to attach to
+//   - rev: Current document revision (empty for new documents)
+//   - filename: Attachment name, may contain slashes
+//   - contentType: MIME type (default: application/octet-stream)
+//   - r: Attachment content
+//
+// Returns:
+//   - string: New document revision
+//   - error: CouchDBError (409 for stale revisions) or ErrAttachmentDigestMismatch
+//
+// Example Usage:
+//
+//	file, _ := os.Open("report.pdf")
+//	defer file.Close()
+//	rev, err := service.PutAttachment(ctx, "report-2024", doc.Rev, "report.pdf", "application/pdf", file)
+func (c *CouchDBService) PutAttachment(ctx context.Context, docID, rev, filename, contentType string, r io.Reader) (string, error) {
+	if filename == "" {
+		return "", fmt.Errorf("attachment filename is required")
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	digest := md5.New()
+	att := &kivik.Attachment{
+		Filename:    escapeAttachmentName(filename),
+		ContentType: contentType,
+		Content:     io.NopCloser(io.TeeReader(r, digest)),
+	}
+	var options []kivik.Option
+	if rev != "" {
+		options = append(options, kivik.Rev(rev))
+	}
+
+	newRev, err := c.database.PutAttachment(ctx, docID, att, options...)
+	if err != nil {
+		return "", wrapCouchDBError(err, "failed to put attachment")
+	}
+
+	stored, err := c.GetAttachmentInfo(ctx, docID, filename)
+	if err != nil {
+		return newRev, err
+	}
+	if !digestsEqual(stored.Digest, encodeDigest(digest)) {
+		return newRev, fmt.Errorf("%w: %s/%s", ErrAttachmentDigestMismatch, docID, filename)
+	}
+	return newRev, nil
+}
+
+// GetAttachment opens an attachment for streaming
+func (c *CouchDBService) GetAttachment(ctx context.Context, docID, filename string) (*AttachmentContent, error) {
+	return getAttachment(ctx, c.database, docID, filename)
+}
+
+// GetAttachmentInfo returns attachment metadata without its content
+func (c *CouchDBService) GetAttachmentInfo(ctx context.Context, docID, filename string) (*Attachment, error) {
+	att, err := c.database.GetAttachmentMeta(ctx, docID, escapeAttachmentName(filename))
+	if err != nil {
+		return nil, wrapCouchDBError(err, "failed to get attachment")
+	}
+	return &Attachment{
+		ContentType: att.ContentType,
+		Length:      att.Size,
+		Digest:      normalizeDigest(att.Digest),
+		Stub:        true,
+	}, nil
+}
+
+// ListAttachments returns the attachment stubs of a document
+func (c *CouchDBService) ListAttachments(ctx context.Context, docID string) (Attachments, error) {
+	var doc struct {
+		Attachments Attachments `json:"_attachments"`
+	}
+	if err := c.database.Get(ctx, docID).ScanDoc(&doc); err != nil {
+		return nil, wrapCouchDBError(err, "failed to get document")
+	}
+	if doc.Attachments == nil {
+		doc.Attachments = Attachments{}
+	}
+	return doc.Attachments, nil
+}
+
+// DeleteAttachment removes an attachment and returns the new document revision
+func (c *CouchDBService) DeleteAttachment(ctx context.Context, docID, rev, filename string) (string, error) {
+	newRev, err := c.database.DeleteAttachment(ctx, docID, rev, escapeAttachmentName(filename))
+	if err != nil {
+		return "", wrapCouchDBError(err, "failed to delete attachment")
+	}
+	return newRev, nil
+}
+
+// GetDocumentWithAttachments retrieves a document together with its attachments.
+// Attachments up to maxInline bytes (default: DefaultInlineAttachmentSize) are
+// loaded into Data and verified; larger ones are returned as stubs to be
+// streamed with GetAttachment.
+//
+// Example Usage:
+//
+//	report, attachments, err := GetDocumentWithAttachments[Report](service, "report-2024", 0)
+//	if err != nil {
+//	    return err
+//	}
+//	summary := attachments["summary.txt"].Data
+func GetDocumentWithAttachments[T any](c *CouchDBService, id string, maxInline int64) (*T, Attachments, error) {
+	ctx := context.Background()
+	if maxInline <= 0 {
+		maxInline = DefaultInlineAttachmentSize
+	}
+
+	var raw json.RawMessage
+	if err := c.database.Get(ctx, id).ScanDoc(&raw); err != nil {
+		return nil, nil, wrapCouchDBError(err, "failed to get document")
+	}
+	var doc T
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to scan document: %w", err)
+	}
+	var stubs struct {
+		Attachments Attachments `json:"_attachments"`
+	}
+	if err := json.Unmarshal(raw, &stubs); err != nil {
+		return nil, nil, fmt.Errorf("failed to scan attachments: %w", err)
+	}
+
+	attachments := Attachments{}
+	for name, att := range stubs.Attachments {
+		if att.Length <= maxInline {
+			data, err := readAttachment(ctx, c.database, id, name)
+			if err != nil {
+				return nil, nil, err
+			}
+			att.Data = data
+			att.Stub = false
+		}
+		attachments[name] = att
+	}
+	return &doc, attachments, nil
+}
+
+// getAttachment opens a verified attachment stream
+func getAttachment(ctx context.Context, database *kivik.DB, docID, filename string) (*AttachmentContent, error) {
+	att, err := database.GetAttachment(ctx, docID, escapeAttachmentName(filename))
+	if err != nil {
+		return nil, wrapCouchDBError(err, "failed to get attachment")
+	}
+	digest := normalizeDigest(att.Digest)
+	return &AttachmentContent{
+		Filename:    filename,
+		ContentType: att.ContentType,
+		Length:      att.Size,
+		Digest:      digest,
+		ReadCloser: &verifyingReader{
+			ReadCloser: att.Content,
+			hash:       md5.New(),
+			digest:     digest,
+			name:       docID + "/" + filename,
+		},
+	}, nil
+}
+
+// readAttachment reads a whole verified attachment
+func readAttachment(ctx context.Context, database *kivik.DB, docID, filename string) ([]byte, error) {
+	content, err := getAttachment(ctx, database, docID, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment %s/%s: %w", docID, filename, err)
+	}
+	return data, nil
+}
+
+// verifyingReader hashes content as it is read and checks the digest at EOF
+type verifyingReader struct {
+	io.ReadCloser
+	hash   hash.Hash
+	digest string
+	name   string
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.ReadCloser.Read(p)
+	v.hash.Write(p[:n])
+	if err == io.EOF && v.digest != "" && !digestsEqual(v.digest, encodeDigest(v.hash)) {
+		return n, fmt.Errorf("%w: %s", ErrAttachmentDigestMismatch, v.name)
+	}
+	return n, err
+}
+
+// attachmentDigest returns the CouchDB digest of data
+func attachmentDigest(data []byte) string {
+	sum := md5.Sum(data)
+	return "md5-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func encodeDigest(h hash.Hash) string {
+	return "md5-" + base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// normalizeDigest adds the md5- prefix CouchDB omits from attachment ETags
+func normalizeDigest(digest string) string {
+	if digest == "" || strings.Contains(digest, "-") {
+		return digest
+	}
+	return "md5-" + digest
+}
+
+// digestsEqual compares MD5 digests; other algorithms are not verified
+func digestsEqual(stored, computed string) bool {
+	if !strings.HasPrefix(stored, "md5-") {
+		return true
+	}
+	return stored == computed
+}
+
+// escapeAttachmentName escapes an attachment name for use in a URL path
+func escapeAttachmentName(filename string) string {
+	return url.PathEscape(filename)
+}
+
+// inlineAttachments loads attachment files referenced by an exported document
+// into its _attachments field, verifying their digests. File names must be
+// relative paths that stay inside the export directory.
+func inlineAttachments(doc map[string]interface{}, readFile func(name string) ([]byte, error)) (int64, error) {
+	entries, ok := doc["_attachments"].(map[string]interface{})
+	if !ok {
+		return 0, nil
+	}
+
+	var size int64
+	for name, value := range entries {
+		entry, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		file, _ := entry["file"].(string)
+		if file == "" {
+			return 0, fmt.Errorf("attachment %s has no exported file", name)
+		}
+		if !filepath.IsLocal(filepath.FromSlash(file)) {
+			return 0, fmt.Errorf("%w: %s (%s)", ErrAttachmentPathInvalid, name, file)
+		}
+		data, err := readFile(file)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read attachment %s: %w", name, err)
+		}
+		contentType, _ := entry["content_type"].(string)
+		inline := NewInlineAttachment(contentType, data)
+		if digest, _ := entry["digest"].(string); !digestsEqual(digest, inline.Digest) {
+			return 0, fmt.Errorf("%w: %s", ErrAttachmentDigestMismatch, name)
+		}
+		entries[name] = map[string]interface{}{
+			"content_type": inline.ContentType,
+			"data":         base64.StdEncoding.EncodeToString(data),
+		}
+		size += int64(len(data))
+	}
+	return size, nil
+}
+
+// exportAttachments streams the attachments of an exported document into
+// dir/<document>.attachments/ and records each file in its stub
+func exportAttachments(ctx context.Context, database *kivik.DB, docID, dir string, doc map[string]interface{}) error {
+	entries, ok := doc["_attachments"].(map[string]interface{})
+	if !ok || len(entries) == 0 {
+		return nil
+	}
+
+	attDir := sanitizeFilename(docID) + ".attachments"
+	if err := os.MkdirAll(filepath.Join(dir, attDir), 0755); err != nil {
+		return fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+
+	used := map[string]bool{}
+	for name, value := range entries {
+		entry, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		file := sanitizeFilename(name)
+		for i := 1; used[file]; i++ {
+			file = fmt.Sprintf("%s-%d", sanitizeFilename(name), i)
+		}
+		used[file] = true
+		file = filepath.ToSlash(filepath.Join(attDir, file))
+
+		if err := exportAttachment(ctx, database, docID, name, filepath.Join(dir, file)); err != nil {
+			return err
+		}
+		entry["file"] = file
+	}
+	return nil
+}
+
+func exportAttachment(ctx context.Context, database *kivik.DB, docID, name, path string) error {
+	content, err := getAttachment(ctx, database, docID, name)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment file: %w", err)
+	}
+	if _, err := io.Copy(out, content); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to export attachment %s/%s: %w", docID, name, err)
+	}
+	return out.Close()
+}
+
+// exportedFilePath resolves an attachment file name of an export below dir
+func exportedFilePath(dir, name string) (string, error) {
+	local := filepath.FromSlash(name)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("%w: %s", ErrAttachmentPathInvalid, name)
+	}
+	root := filepath.Clean(dir)
+	p := filepath.Join(root, local)
+	if rel, err := filepath.Rel(root, p); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%w: %s", ErrAttachmentPathInvalid, name)
+	}
+	return p, nil
+}
@@ -0,0 +1,417 @@
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	kivik "github.com/go-kivik/kivik/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAttachmentServer is a minimal CouchDB with documents and attachments
+type fakeAttachmentServer struct {
+	mu      sync.Mutex
+	dbs     map[string]map[string]map[string]interface{} // db -> id -> doc without _attachments
+	atts    map[string]map[string]*fakeAttachment        // db/id -> name -> attachment
+	revs    map[string]int
+	corrupt bool // Serve attachment content that does not match its digest
+}
+
+type fakeAttachment struct {
+	contentType string
+	data        []byte
+	revpos      int
+}
+
+func newFakeAttachmentServer(t *testing.T) (*fakeAttachmentServer, string) {
+	t.Helper()
+	fake := &fakeAttachmentServer{
+		dbs:  map[string]map[string]map[string]interface{}{"testdb": {}},
+		atts: map[string]map[string]*fakeAttachment{},
+		revs: map[string]int{},
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server.URL
+}
+
+func (f *fakeAttachmentServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var parts []string
+	for _, part := range strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/") {
+		unescaped, _ := url.PathUnescape(part)
+		parts = append(parts, unescaped)
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	dbName := parts[0]
+	docs, exists := f.dbs[dbName]
+	switch {
+	case len(parts) == 1:
+		if r.Method == http.MethodPut {
+			f.dbs[dbName] = map[string]map[string]interface{}{}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"ok":true}`)
+			return
+		}
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"db_name":%q}`, dbName)
+	case !exists:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"not_found","reason":"Database does not exist."}`)
+	case parts[1] == "_all_docs":
+		rows := []string{}
+		for id := range docs {
+			data, _ := json.Marshal(f.document(dbName, id))
+			rows = append(rows, fmt.Sprintf(`{"id":%q,"key":%q,"value":{"rev":"1-a"},"doc":%s}`, id, id, data))
+		}
+		fmt.Fprintf(w, `{"total_rows":%d,"offset":0,"rows":[%s]}`, len(rows), strings.Join(rows, ","))
+	case parts[1] == "_bulk_docs":
+		var body struct {
+			Docs []map[string]interface{} `json:"docs"`
+		}
+		_ = json.NewDecoder(requestBody(r)).Decode(&body)
+		results := []string{}
+		for _, doc := range body.Docs {
+			id := doc["_id"].(string)
+			if _, ok := docs[id]; ok {
+				results = append(results, fmt.Sprintf(`{"id":%q,"error":"conflict","reason":"Document update conflict."}`, id))
+				continue
+			}
+			rev := f.saveDoc(dbName, id, doc)
+			results = append(results, fmt.Sprintf(`{"ok":true,"id":%q,"rev":%q}`, id, rev))
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "[%s]", strings.Join(results, ","))
+	case len(parts) == 2:
+		f.serveDoc(w, r, dbName, parts[1])
+	default:
+		f.serveAttachment(w, r, dbName, parts[1], strings.Join(parts[2:], "/"))
+	}
+}
+
+func (f *fakeAttachmentServer) serveDoc(w http.ResponseWriter, r *http.Request, dbName, id string) {
+	if r.Method == http.MethodPut {
+		var doc map[string]interface{}
+		_ = json.NewDecoder(requestBody(r)).Decode(&doc)
+		rev := f.saveDoc(dbName, id, doc)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"ok":true,"id":%q,"rev":%q}`, id, rev)
+		return
+	}
+	if _, ok := f.dbs[dbName][id]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"not_found","reason":"missing"}`)
+		return
+	}
+	doc := f.document(dbName, id)
+	w.Header().Set("ETag", fmt.Sprintf("%q", doc["_rev"]))
+	_ = json.NewEncoder(w).Encode(doc)
+}
+
+func (f *fakeAttachmentServer) serveAttachment(w http.ResponseWriter, r *http.Request, dbName, id, name string) {
+	key := dbName + "/" + id
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(requestBody(r))
+		if _, ok := f.dbs[dbName][id]; !ok {
+			f.dbs[dbName][id] = map[string]interface{}{"_id": id}
+		}
+		f.revs[key]++
+		if f.atts[key] == nil {
+			f.atts[key] = map[string]*fakeAttachment{}
+		}
+		f.atts[key][name] = &fakeAttachment{contentType: r.Header.Get("Content-Type"), data: data, revpos: f.revs[key]}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"ok":true,"id":%q,"rev":"%d-a"}`, id, f.revs[key])
+	case http.MethodDelete:
+		delete(f.atts[key], name)
+		f.revs[key]++
+		fmt.Fprintf(w, `{"ok":true,"id":%q,"rev":"%d-a"}`, id, f.revs[key])
+	default:
+		att, ok := f.atts[key][name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"not_found","reason":"Document is missing attachment"}`)
+			return
+		}
+		sum := md5.Sum(att.data)
+		w.Header().Set("Content-Type", att.contentType)
+		w.Header().Set("ETag", `"`+base64.StdEncoding.EncodeToString(sum[:])+`"`)
+		if r.Method == http.MethodHead {
+			return
+		}
+		data := att.data
+		if f.corrupt {
+			data = append([]byte("x"), data...)
+		}
+		_, _ = w.Write(data)
+	}
+}
+
+// saveDoc stores a document, moving inline attachments out of it
+func (f *fakeAttachmentServer) saveDoc(dbName, id string, doc map[string]interface{}) string {
+	key := dbName + "/" + id
+	f.revs[key]++
+	if entries, ok := doc["_attachments"].(map[string]interface{}); ok {
+		if f.atts[key] == nil {
+			f.atts[key] = map[string]*fakeAttachment{}
+		}
+		for name, value := range entries {
+			entry := value.(map[string]interface{})
+			if encoded, ok := entry["data"].(string); ok {
+				data, _ := base64.StdEncoding.DecodeString(encoded)
+				f.atts[key][name] = &fakeAttachment{contentType: entry["content_type"].(string), data: data, revpos: f.revs[key]}
+			}
+		}
+	}
+	delete(doc, "_attachments")
+	delete(doc, "_rev")
+	doc["_id"] = id
+	f.dbs[dbName][id] = doc
+	return fmt.Sprintf("%d-a", f.revs[key])
+}
+
+// document returns a stored document with attachment stubs
+func (f *fakeAttachmentServer) document(dbName, id string) map[string]interface{} {
+	key := dbName + "/" + id
+	doc := map[string]interface{}{}
+	for k, v := range f.dbs[dbName][id] {
+		doc[k] = v
+	}
+	doc["_rev"] = fmt.Sprintf("%d-a", f.revs[key])
+	if len(f.atts[key]) > 0 {
+		stubs := map[string]interface{}{}
+		for name, att := range f.atts[key] {
+			stubs[name] = map[string]interface{}{
+				"content_type": att.contentType,
+				"length":       len(att.data),
+				"digest":       attachmentDigest(att.data),
+				"revpos":       att.revpos,
+				"stub":         true,
+			}
+		}
+		doc["_attachments"] = stubs
+	}
+	return doc
+}
+
+func requestBody(r *http.Request) io.Reader {
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		if gz, err := gzip.NewReader(r.Body); err == nil {
+			return gz
+		}
+	}
+	return r.Body
+}
+
+func newAttachmentService(t *testing.T, serverURL string) *CouchDBService {
+	t.Helper()
+	client, err := kivik.New("couch", serverURL)
+	require.NoError(t, err)
+	return &CouchDBService{client: client, database: client.DB("testdb"), dbName: "testdb"}
+}
+
+func TestPutGetAttachment(t *testing.T) {
+	fake, serverURL := newFakeAttachmentServer(t)
+	service := newAttachmentService(t, serverURL)
+	ctx := context.Background()
+
+	content := []byte("quarterly numbers")
+	rev, err := service.PutAttachment(ctx, "report", "", "docs/summary.txt", "text/plain", bytes.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, "1-a", rev)
+
+	info, err := service.GetAttachmentInfo(ctx, "report", "docs/summary.txt")
+	require.NoError(t, err)
+	assert.Equal(t, attachmentDigest(content), info.Digest)
+	assert.Equal(t, "text/plain", info.ContentType)
+
+	att, err := service.GetAttachment(ctx, "report", "docs/summary.txt")
+	require.NoError(t, err)
+	data, err := io.ReadAll(att)
+	require.NoError(t, err)
+	require.NoError(t, att.Close())
+	assert.Equal(t, content, data)
+
+	attachments, err := service.ListAttachments(ctx, "report")
+	require.NoError(t, err)
+	require.Contains(t, attachments, "docs/summary.txt")
+	assert.True(t, attachments["docs/summary.txt"].Stub)
+	assert.Equal(t, int64(len(content)), attachments["docs/summary.txt"].Length)
+
+	fake.mu.Lock()
+	fake.corrupt = true
+	fake.mu.Unlock()
+	att, err = service.GetAttachment(ctx, "report", "docs/summary.txt")
+	require.NoError(t, err)
+	_, err = io.ReadAll(att)
+	assert.ErrorIs(t, err, ErrAttachmentDigestMismatch)
+	att.Close()
+
+	rev, err = service.DeleteAttachment(ctx, "report", rev, "docs/summary.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "2-a", rev)
+	_, err = service.GetAttachmentInfo(ctx, "report", "docs/summary.txt")
+	var couchErr *CouchDBError
+	require.ErrorAs(t, err, &couchErr)
+	assert.True(t, couchErr.IsNotFound())
+}
+
+type attachmentReport struct {
+	ID          string      `json:"_id,omitempty"`
+	Rev         string      `json:"_rev,omitempty"`
+	Title       string      `json:"title"`
+	Attachments Attachments `json:"_attachments,omitempty"`
+}
+
+func TestGetDocumentWithAttachments(t *testing.T) {
+	_, serverURL := newFakeAttachmentServer(t)
+	service := newAttachmentService(t, serverURL)
+
+	large := bytes.Repeat([]byte("0123456789"), 100)
+	_, err := SaveDocument(service, attachmentReport{
+		ID:    "report",
+		Title: "Q3",
+		Attachments: Attachments{
+			"summary.txt": NewInlineAttachment("text/plain", []byte("small")),
+			"data.bin":    NewInlineAttachment("application/octet-stream", large),
+		},
+	})
+	require.NoError(t, err)
+
+	report, attachments, err := GetDocumentWithAttachments[attachmentReport](service, "report", 100)
+	require.NoError(t, err)
+	assert.Equal(t, "Q3", report.Title)
+	require.Len(t, attachments, 2)
+	assert.Equal(t, []byte("small"), attachments["summary.txt"].Data)
+	assert.False(t, attachments["summary.txt"].Stub)
+	assert.True(t, attachments["data.bin"].Stub, "large attachments stay stubs")
+	assert.Nil(t, attachments["data.bin"].Data)
+
+	_, attachments, err = GetDocumentWithAttachments[attachmentReport](service, "report", 0)
+	require.NoError(t, err)
+	assert.Equal(t, large, attachments["data.bin"].Data)
+}
+
+func TestExportImportAttachments(t *testing.T) {
+	_, serverURL := newFakeAttachmentServer(t)
+	service := newAttachmentService(t, serverURL)
+	ctx := context.Background()
+
+	_, err := SaveDocument(service, attachmentReport{ID: "plain", Title: "No files"})
+	require.NoError(t, err)
+	_, err = SaveDocument(service, attachmentReport{ID: "report", Title: "Q3"})
+	require.NoError(t, err)
+	_, err = service.PutAttachment(ctx, "report", "1-a", "a/b.txt", "text/plain", strings.NewReader("slash"))
+	require.NoError(t, err)
+	_, err = service.PutAttachment(ctx, "report", "2-a", "a_b.txt", "text/plain", strings.NewReader("underscore"))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, DownloadAllDocuments(serverURL, "testdb", dir))
+
+	exported, err := os.ReadFile(filepath.Join(dir, "testdb", "report.json"))
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(exported, &doc))
+	files := map[string]bool{}
+	for _, entry := range doc["_attachments"].(map[string]interface{}) {
+		files[entry.(map[string]interface{})["file"].(string)] = true
+	}
+	assert.Len(t, files, 2, "colliding sanitized names get distinct files")
+
+	target, targetURL := newFakeAttachmentServer(t)
+	target.dbs = map[string]map[string]map[string]interface{}{}
+	require.NoError(t, UploadAllDocuments(targetURL, "testdb", dir))
+
+	restored := newAttachmentService(t, targetURL)
+	report, attachments, err := GetDocumentWithAttachments[attachmentReport](restored, "report", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "Q3", report.Title)
+	assert.Equal(t, []byte("slash"), attachments["a/b.txt"].Data)
+	assert.Equal(t, []byte("underscore"), attachments["a_b.txt"].Data)
+
+	target.mu.Lock()
+	assert.Contains(t, target.dbs["testdb"], "plain")
+	target.mu.Unlock()
+
+	// Re-importing reports conflicts instead of overwriting
+	results, err := restored.ImportDocuments(filepath.Join(dir, "testdb"))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.False(t, results[0].OK)
+
+	// Tampered attachment files are rejected
+	for file := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "testdb", filepath.FromSlash(file)), []byte("tampered"), 0644))
+	}
+	_, err = restored.ImportDocuments(filepath.Join(dir, "testdb"))
+	assert.ErrorIs(t, err, ErrAttachmentDigestMismatch)
+}
+
+func TestImportRejectsAttachmentPathsOutsideExport(t *testing.T) {
+	target, targetURL := newFakeAttachmentServer(t)
+	target.dbs = map[string]map[string]map[string]interface{}{}
+	service := newAttachmentService(t, targetURL)
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0644))
+	dir := filepath.Join(root, "testdb")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	for _, file := range []string{"../secret.txt", "attachments/../../secret.txt", filepath.ToSlash(filepath.Join(root, "secret.txt"))} {
+		doc := map[string]interface{}{
+			"_id": "crafted",
+			"_attachments": map[string]interface{}{
+				"leak.txt": map[string]interface{}{
+					"content_type": "text/plain",
+					"digest":       NewInlineAttachment("text/plain", []byte("secret")).Digest,
+					"file":         file,
+				},
+			},
+		}
+		data, err := json.Marshal(doc)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "crafted.json"), data, 0644))
+
+		_, err = service.ImportDocuments(dir)
+		assert.ErrorIs(t, err, ErrAttachmentPathInvalid, file)
+	}
+
+	target.mu.Lock()
+	assert.NotContains(t, target.dbs["testdb"], "crafted")
+	target.mu.Unlock()
+}
+
+func TestExportedFilePath(t *testing.T) {
+	dir := t.TempDir()
+	p, err := exportedFilePath(dir, "attachments/report/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "attachments", "report", "a.txt"), p)
+
+	for _, name := range []string{"", "..", "../x", "a/../../x", "/etc/passwd"} {
+		_, err := exportedFilePath(dir, name)
+		assert.ErrorIs(t, err, ErrAttachmentPathInvalid, name)
+	}
+}
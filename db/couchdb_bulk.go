@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	kivik "github.com/go-kivik/kivik/v4"
 )
@@ -477,3 +479,99 @@ func BulkUpsert[T any](c *CouchDBService, docs []T, getIDFunc func(T) string) ([
 	// Perform bulk save
 	return c.BulkSaveDocuments(docsToSave)
 }
+
+// importBatchSize and importBatchBytes bound the documents and inline
+// attachment bytes sent per _bulk_docs request by ImportDocuments
+const (
+	importBatchSize  = 100
+	importBatchBytes = 16 << 20
+)
+
+// ImportDocuments re-imports documents exported by DownloadAllDocuments,
+// together with their attachments, using bulk saves.
+//
+// Parameters:
+//   - dir: Database export directory (outputDir/database of DownloadAllDocuments)
+//
+// Returns:
+//   - []BulkResult: Result for each document (success or error)
+//   - error: File system, digest or request errors
+//
+// Import Behavior:
+//   - Revisions are dropped; documents are created with new revisions
+//   - Existing documents are reported as conflicts in the results
+//   - Attachment files are verified against their exported digests
+//   - Inline attachments are batched to at most 16 MB per request
+//
+// Example Usage:
+//
+//	results, err := service.ImportDocuments("/backup/couchdb_export/flow_processes")
+//	if err != nil {
+//	    log.Printf("Import failed: %v", err)
+//	    return
+//	}
+//	for _, result := range results {
+//	    if !result.OK {
+//	        log.Printf("Failed %s: %s", result.ID, result.Reason)
+//	    }
+//	}
+func (c *CouchDBService) ImportDocuments(dir string) ([]BulkResult, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list export files: %w", err)
+	}
+
+	results := []BulkResult{}
+	var (
+		batch      []interface{}
+		batchBytes int64
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		batchResults, err := c.BulkSaveDocuments(batch)
+		if err != nil {
+			return err
+		}
+		results = append(results, batchResults...)
+		batch, batchBytes = nil, 0
+		return nil
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return results, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return results, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		delete(doc, "_rev")
+
+		size, err := inlineAttachments(doc, func(name string) ([]byte, error) {
+			p, err := exportedFilePath(dir, name)
+			if err != nil {
+				return nil, err
+			}
+			return os.ReadFile(p)
+		})
+		if err != nil {
+			return results, fmt.Errorf("failed to import %s: %w", file, err)
+		}
+
+		if len(batch) > 0 && (len(batch) >= importBatchSize || batchBytes+size > importBatchBytes) {
+			if err := flush(); err != nil {
+				return results, err
+			}
+		}
+		batch = append(batch, doc)
+		batchBytes += size
+	}
+
+	if err := flush(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
@@ -73,43 +73,7 @@ import (
 func (c *CouchDBService) ListenChanges(opts ChangesFeedOptions, handler func(Change)) error {
 	ctx := context.Background()
 
-	// Build changes parameters
-	params := make(map[string]interface{})
-
-	if opts.Since != "" {
-		params["since"] = opts.Since
-	}
-	if opts.Feed != "" {
-		params["feed"] = opts.Feed
-	} else {
-		params["feed"] = "continuous" // Default to continuous
-	}
-	if opts.IncludeDocs {
-		params["include_docs"] = true
-	}
-	if opts.Heartbeat > 0 {
-		params["heartbeat"] = opts.Heartbeat
-	}
-	if opts.Timeout > 0 {
-		params["timeout"] = opts.Timeout
-	}
-	if opts.Limit > 0 {
-		params["limit"] = opts.Limit
-	}
-	if opts.Descending {
-		params["descending"] = true
-	}
-	if opts.Filter != "" {
-		params["filter"] = opts.Filter
-	}
-	if opts.Selector != nil {
-		// Selector requires JSON encoding
-		selectorJSON, err := json.Marshal(opts.Selector)
-		if err == nil {
-			params["filter"] = "_selector"
-			params["selector"] = string(selectorJSON)
-		}
-	}
+	params := changesParams(opts)
 
 	// Get changes feed
 	changes := c.database.Changes(ctx, kivik.Params(params))
@@ -117,40 +81,7 @@ func (c *CouchDBService) ListenChanges(opts ChangesFeedOptions, handler func(Cha
 
 	// Process changes
 	for changes.Next() {
-		change := Change{}
-
-		// Get sequence
-		change.Seq = changes.Seq()
-
-		// Get ID
-		change.ID = changes.ID()
-
-		// Get deleted flag
-		change.Deleted = changes.Deleted()
-
-		// Get changes (revisions) - scan the document for change details
-		var changesData []ChangeRev
-		var rawDoc map[string]interface{}
-		if err := changes.ScanDoc(&rawDoc); err == nil {
-			if changesArray, ok := rawDoc["changes"].([]interface{}); ok {
-				for _, chg := range changesArray {
-					if chgMap, ok := chg.(map[string]interface{}); ok {
-						if rev, ok := chgMap["rev"].(string); ok {
-							changesData = append(changesData, ChangeRev{Rev: rev})
-						}
-					}
-				}
-			}
-			change.Changes = changesData
-
-			// Get document if include_docs was specified
-			if opts.IncludeDocs && !change.Deleted {
-				if docData, ok := rawDoc["doc"]; ok {
-					docJSON, _ := json.Marshal(docData)
-					change.Doc = docJSON
-				}
-			}
-		}
+		change := changeFromRows(changes, opts.IncludeDocs)
 
 		// Call handler
 		handler(change)
@@ -218,34 +149,11 @@ func (c *CouchDBService) ListenChanges(opts ChangesFeedOptions, handler func(Cha
 func (c *CouchDBService) GetChanges(opts ChangesFeedOptions) ([]Change, string, error) {
 	ctx := context.Background()
 
-	// Build parameters
-	params := make(map[string]interface{})
-
-	if opts.Since != "" {
-		params["since"] = opts.Since
-	}
 	// Force normal feed for GetChanges
+	params := changesParams(opts)
 	params["feed"] = "normal"
-
-	if opts.IncludeDocs {
-		params["include_docs"] = true
-	}
-	if opts.Limit > 0 {
-		params["limit"] = opts.Limit
-	}
-	if opts.Descending {
-		params["descending"] = true
-	}
-	if opts.Filter != "" {
-		params["filter"] = opts.Filter
-	}
-	if opts.Selector != nil {
-		selectorJSON, err := json.Marshal(opts.Selector)
-		if err == nil {
-			params["filter"] = "_selector"
-			params["selector"] = string(selectorJSON)
-		}
-	}
+	delete(params, "heartbeat")
+	delete(params, "timeout")
 
 	// Get changes
 	rows := c.database.Changes(ctx, kivik.Params(params))
@@ -255,42 +163,9 @@ func (c *CouchDBService) GetChanges(opts ChangesFeedOptions) ([]Change, string,
 	lastSeq := ""
 
 	for rows.Next() {
-		change := Change{}
-
-		// Get sequence
-		change.Seq = rows.Seq()
+		change := changeFromRows(rows, opts.IncludeDocs)
 		lastSeq = change.Seq
 
-		// Get ID
-		change.ID = rows.ID()
-
-		// Get deleted flag
-		change.Deleted = rows.Deleted()
-
-		// Get changes (revisions) - scan the document for change details
-		var changesData []ChangeRev
-		var rawDoc map[string]interface{}
-		if err := rows.ScanDoc(&rawDoc); err == nil {
-			if changesArray, ok := rawDoc["changes"].([]interface{}); ok {
-				for _, chg := range changesArray {
-					if chgMap, ok := chg.(map[string]interface{}); ok {
-						if rev, ok := chgMap["rev"].(string); ok {
-							changesData = append(changesData, ChangeRev{Rev: rev})
-						}
-					}
-				}
-			}
-			change.Changes = changesData
-
-			// Get document if include_docs was specified
-			if opts.IncludeDocs && !change.Deleted {
-				if docData, ok := rawDoc["doc"]; ok {
-					docJSON, _ := json.Marshal(docData)
-					change.Doc = docJSON
-				}
-			}
-		}
-
 		changes = append(changes, change)
 	}
 
@@ -408,3 +283,69 @@ func (c *CouchDBService) GetLastSequence() (string, error) {
 	}
 	return info.UpdateSeq, nil
 }
+
+// changesParams converts feed options to _changes query parameters.
+// The feed defaults to continuous.
+func changesParams(opts ChangesFeedOptions) map[string]interface{} {
+	params := make(map[string]interface{})
+
+	if opts.Since != "" {
+		params["since"] = opts.Since
+	}
+	if opts.Feed != "" {
+		params["feed"] = opts.Feed
+	} else {
+		params["feed"] = "continuous" // Default to continuous
+	}
+	if opts.IncludeDocs {
+		params["include_docs"] = true
+	}
+	if opts.Heartbeat > 0 {
+		params["heartbeat"] = opts.Heartbeat
+	}
+	if opts.Timeout > 0 {
+		params["timeout"] = opts.Timeout
+	}
+	if opts.Limit > 0 {
+		params["limit"] = opts.Limit
+	}
+	if opts.Descending {
+		params["descending"] = true
+	}
+	if opts.Filter != "" {
+		params["filter"] = opts.Filter
+	}
+	if opts.Selector != nil {
+		// Selector requires JSON encoding
+		selectorJSON, err := json.Marshal(opts.Selector)
+		if err == nil {
+			params["filter"] = "_selector"
+			params["selector"] = string(selectorJSON)
+		}
+	}
+
+	return params
+}
+
+// changeFromRows converts the current changes feed row to a Change
+func changeFromRows(rows *kivik.Changes, includeDocs bool) Change {
+	change := Change{
+		Seq:     rows.Seq(),
+		ID:      rows.ID(),
+		Deleted: rows.Deleted(),
+	}
+
+	for _, rev := range rows.Changes() {
+		change.Changes = append(change.Changes, ChangeRev{Rev: rev})
+	}
+
+	// Get document if include_docs was specified
+	if includeDocs && !change.Deleted {
+		var doc json.RawMessage
+		if err := rows.ScanDoc(&doc); err == nil && len(doc) > 0 && string(doc) != "null" {
+			change.Doc = doc
+		}
+	}
+
+	return change
+}
@@ -0,0 +1,573 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	kivik "github.com/go-kivik/kivik/v4"
+)
+
+const (
+	// DefaultUpdateRetries is the number of conflict retries UpdateWithRetry
+	// performs when maxRetries is not positive
+	DefaultUpdateRetries = 5
+
+	// ConflictsDesignDoc is the design document holding the conflict scanner view
+	ConflictsDesignDoc = "_design/eve_conflicts"
+
+	// ConflictsView is the name of the view listing conflicted documents
+	ConflictsView = "conflicts"
+
+	// DefaultTimestampField is the field LastWriteWins compares when no
+	// field is configured
+	DefaultTimestampField = "dateModified"
+
+	conflictsViewMap = `function (doc) { if (doc._conflicts) { emit(doc._id, doc._conflicts); } }`
+)
+
+// ErrNoConflicts is returned by ResolveConflicts when the document has no
+// conflicting revisions
+var ErrNoConflicts = errors.New("document has no conflicts")
+
+// ConflictRevision is one leaf revision of a conflicted document.
+// The revision CouchDB currently picks as winner is marked with Winner and
+// is always the first revision passed to a ConflictResolver.
+type ConflictRevision struct {
+	Rev    string                 `json:"rev"`
+	Winner bool                   `json:"winner"`
+	Doc    map[string]interface{} `json:"doc"`
+}
+
+// ConflictedDocument is a document reported by the conflict scanner
+type ConflictedDocument struct {
+	ID        string   `json:"id"`
+	Conflicts []string `json:"conflicts"` // Losing leaf revisions
+}
+
+// ConflictResolution describes the outcome of resolving one document
+type ConflictResolution struct {
+	ID          string   `json:"id"`
+	WinningRev  string   `json:"winningRev"`       // Revision the resolved body was written on
+	Rev         string   `json:"rev"`              // Current revision after resolution
+	Updated     bool     `json:"updated"`          // Whether the winning body was changed
+	DeletedRevs []string `json:"deletedRevs"`      // Losing revisions that were deleted
+	Errors      []string `json:"errors,omitempty"` // Per-revision bulk errors
+}
+
+// ConflictResolver picks the resolved body of a conflicted document.
+// revisions[0] is CouchDB's current winner; the returned map is written on
+// top of it and all other revisions are deleted. Resolvers must not return
+// the _id, _rev or _conflicts fields; they are set by ResolveConflicts.
+type ConflictResolver interface {
+	Resolve(id string, revisions []ConflictRevision) (map[string]interface{}, error)
+}
+
+// ConflictResolverFunc adapts a function into a ConflictResolver, allowing
+// custom resolution callbacks
+type ConflictResolverFunc func(id string, revisions []ConflictRevision) (map[string]interface{}, error)
+
+// Resolve calls f(id, revisions)
+func (f ConflictResolverFunc) Resolve(id string, revisions []ConflictRevision) (map[string]interface{}, error) {
+	return f(id, revisions)
+}
+
+// FieldMergeFunc combines the values of one field across revisions. values
+// holds the field's value of every revision that has it, ordered from the
+// most recently modified revision to the oldest.
+type FieldMergeFunc func(values []interface{}) interface{}
+
+// UpdateWithRetry reads a document, applies mutate and saves it, re-reading
+// and re-applying mutate whenever the save fails with a revision conflict.
+// This is the optimistic concurrency pattern for documents that several
+// writers update at the same time.
+//
+// Type Parameter:
+//   - T: Document type; it must carry the revision in a `json:"_rev"` field
+//
+// Parameters:
+//   - c: CouchDBService instance
+//   - id: Document identifier to update
+//   - mutate: Function applying the change to a freshly read document;
+//     it may be called several times and must be free of side effects
+//   - maxRetries: Number of retries after conflicts (DefaultUpdateRetries if <= 0)
+//
+// Returns:
+//   - *T: The saved document with its new revision
+//   - error: Read errors, mutate errors, save errors, or the last conflict
+//     once the retries are exhausted (a *CouchDBError with IsConflict)
+//
+// Example Usage:
+//
+//	updated, err := UpdateWithRetry(service, "counter-1", func(doc *Counter) error {
+//	    doc.Value++
+//	    return nil
+//	}, 0)
+//	if err != nil {
+//	    log.Printf("Update failed: %v", err)
+//	    return
+//	}
+//	fmt.Printf("Counter is %d at revision %s\n", updated.Value, updated.Rev)
+func UpdateWithRetry[T any](c *CouchDBService, id string, mutate func(*T) error, maxRetries int) (*T, error) {
+	if maxRetries <= 0 {
+		maxRetries = DefaultUpdateRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		doc, err := GetDocument[T](c, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := mutate(doc); err != nil {
+			return nil, err
+		}
+
+		response, err := SaveDocument(c, *doc)
+		if err == nil {
+			if err := setDocumentRev(doc, response.Rev); err != nil {
+				return nil, err
+			}
+			return doc, nil
+		}
+
+		var couchErr *CouchDBError
+		if !errors.As(err, &couchErr) || !couchErr.IsConflict() {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// setDocumentRev stores rev in the _rev field of doc
+func setDocumentRev[T any](doc *T, rev string) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+	var docMap map[string]interface{}
+	if err := json.Unmarshal(data, &docMap); err != nil {
+		// Not an object, nothing to update
+		return nil
+	}
+	docMap["_rev"] = rev
+	data, err = json.Marshal(docMap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return fmt.Errorf("failed to update document revision: %w", err)
+	}
+	return nil
+}
+
+// EnsureConflictsView creates or updates the design document with the view
+// used by FindConflicts. It is called by FindConflicts and only needs to be
+// called directly to build the view index ahead of time.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//
+// Returns:
+//   - error: Design document read or write errors
+func (c *CouchDBService) EnsureConflictsView(ctx context.Context) error {
+	var existing map[string]interface{}
+	row := c.database.Get(ctx, ConflictsDesignDoc)
+	if err := row.Err(); err != nil {
+		if kivik.HTTPStatus(err) != http.StatusNotFound {
+			return wrapCouchDBError(err, "failed to get conflicts view")
+		}
+		existing = map[string]interface{}{"_id": ConflictsDesignDoc}
+	} else if err := row.ScanDoc(&existing); err != nil {
+		return fmt.Errorf("failed to scan conflicts view: %w", err)
+	}
+
+	if views, ok := existing["views"].(map[string]interface{}); ok {
+		if view, ok := views[ConflictsView].(map[string]interface{}); ok && view["map"] == conflictsViewMap {
+			return nil
+		}
+	}
+
+	existing["language"] = "javascript"
+	existing["views"] = map[string]interface{}{
+		ConflictsView: map[string]string{"map": conflictsViewMap},
+	}
+	if _, err := c.database.Put(ctx, ConflictsDesignDoc, existing); err != nil {
+		return wrapCouchDBError(err, "failed to save conflicts view")
+	}
+	return nil
+}
+
+// FindConflicts lists all documents that have conflicting revisions.
+// It queries a view emitting the _conflicts of each document, creating the
+// view on first use. Conflicts appear after replication or bulk writes with
+// new_edits=false, never through regular updates.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//
+// Returns:
+//   - []ConflictedDocument: Conflicted documents with their losing revisions
+//   - error: View creation or query errors
+//
+// Example Usage:
+//
+//	conflicts, err := service.FindConflicts(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, doc := range conflicts {
+//	    fmt.Printf("%s has %d conflicting revisions\n", doc.ID, len(doc.Conflicts))
+//	}
+func (c *CouchDBService) FindConflicts(ctx context.Context) ([]ConflictedDocument, error) {
+	if err := c.EnsureConflictsView(ctx); err != nil {
+		return nil, err
+	}
+
+	rows := c.database.Query(ctx, ConflictsDesignDoc, ConflictsView)
+	defer rows.Close()
+
+	conflicts := []ConflictedDocument{}
+	for rows.Next() {
+		id, err := rows.ID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read conflicts row: %w", err)
+		}
+		var revs []string
+		if err := rows.ScanValue(&revs); err != nil {
+			return nil, fmt.Errorf("failed to scan conflicts of %s: %w", id, err)
+		}
+		conflicts = append(conflicts, ConflictedDocument{ID: id, Conflicts: revs})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapCouchDBError(err, "failed to query conflicts view")
+	}
+
+	return conflicts, nil
+}
+
+// GetConflictRevisions reads all leaf revisions of a document.
+// The first revision is CouchDB's current winner, followed by the
+// conflicting revisions in the order CouchDB reports them.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - id: Document identifier
+//
+// Returns:
+//   - []ConflictRevision: The winner and all conflicting revisions
+//   - error: CouchDBError for HTTP errors, or read errors
+func (c *CouchDBService) GetConflictRevisions(ctx context.Context, id string) ([]ConflictRevision, error) {
+	var winner map[string]interface{}
+	if err := c.database.Get(ctx, id, kivik.Param("conflicts", true)).ScanDoc(&winner); err != nil {
+		return nil, wrapCouchDBError(err, fmt.Sprintf("failed to get document %s", id))
+	}
+
+	var conflicts []string
+	if raw, ok := winner["_conflicts"].([]interface{}); ok {
+		for _, rev := range raw {
+			if s, ok := rev.(string); ok {
+				conflicts = append(conflicts, s)
+			}
+		}
+	}
+	delete(winner, "_conflicts")
+
+	winningRev, _ := winner["_rev"].(string)
+	revisions := []ConflictRevision{{Rev: winningRev, Winner: true, Doc: winner}}
+	for _, rev := range conflicts {
+		var doc map[string]interface{}
+		if err := c.database.Get(ctx, id, kivik.Rev(rev)).ScanDoc(&doc); err != nil {
+			return nil, wrapCouchDBError(err, fmt.Sprintf("failed to get revision %s of %s", rev, id))
+		}
+		revisions = append(revisions, ConflictRevision{Rev: rev, Doc: doc})
+	}
+
+	return revisions, nil
+}
+
+// ResolveConflicts resolves the conflicts of one document. The resolver's
+// result is written on the winning revision and every losing revision is
+// deleted, all in a single _bulk_docs request.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - id: Document identifier
+//   - resolver: Strategy choosing the resolved body
+//
+// Returns:
+//   - *ConflictResolution: Revisions written and deleted; per-revision
+//     failures are listed in Errors
+//   - error: ErrNoConflicts, read errors, resolver errors or request errors
+//
+// Example Usage:
+//
+//	resolution, err := service.ResolveConflicts(ctx, "container-123", LastWriteWins(""))
+//	if err != nil && !errors.Is(err, ErrNoConflicts) {
+//	    log.Printf("Resolution failed: %v", err)
+//	    return
+//	}
+func (c *CouchDBService) ResolveConflicts(ctx context.Context, id string, resolver ConflictResolver) (*ConflictResolution, error) {
+	if resolver == nil {
+		return nil, fmt.Errorf("conflict resolver is nil")
+	}
+
+	revisions, err := c.GetConflictRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) < 2 {
+		return nil, ErrNoConflicts
+	}
+
+	resolved, err := resolver.Resolve(id, cloneRevisions(revisions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve conflicts of %s: %w", id, err)
+	}
+
+	winner := revisions[0]
+	resolution := &ConflictResolution{ID: id, WinningRev: winner.Rev, Rev: winner.Rev}
+
+	var docs []interface{}
+	if !sameBody(resolved, winner.Doc) {
+		body := make(map[string]interface{}, len(resolved)+2)
+		for k, v := range resolved {
+			body[k] = v
+		}
+		body["_id"] = id
+		body["_rev"] = winner.Rev
+		docs = append(docs, body)
+		resolution.Updated = true
+	}
+	for _, loser := range revisions[1:] {
+		docs = append(docs, BulkDeleteDoc{ID: id, Rev: loser.Rev, Deleted: true})
+	}
+
+	results, err := c.database.BulkDocs(ctx, docs)
+	if err != nil {
+		return nil, wrapCouchDBError(err, fmt.Sprintf("failed to resolve conflicts of %s", id))
+	}
+
+	offset := 0
+	if resolution.Updated {
+		offset = 1
+		if len(results) > 0 {
+			if results[0].Error != nil {
+				resolution.Errors = append(resolution.Errors, fmt.Sprintf("%s: %v", winner.Rev, results[0].Error))
+			} else {
+				resolution.Rev = results[0].Rev
+			}
+		}
+	}
+	for i, loser := range revisions[1:] {
+		if offset+i >= len(results) {
+			break
+		}
+		if results[offset+i].Error != nil {
+			resolution.Errors = append(resolution.Errors, fmt.Sprintf("%s: %v", loser.Rev, results[offset+i].Error))
+			continue
+		}
+		resolution.DeletedRevs = append(resolution.DeletedRevs, loser.Rev)
+	}
+
+	return resolution, nil
+}
+
+// ResolveAllConflicts finds all conflicted documents and resolves each with
+// the given resolver. Resolution continues past failing documents; their
+// errors are joined into the returned error.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - resolver: Strategy choosing the resolved bodies
+//
+// Returns:
+//   - []ConflictResolution: Resolutions of all successfully processed documents
+//   - error: Scanner errors, or the joined per-document errors
+func (c *CouchDBService) ResolveAllConflicts(ctx context.Context, resolver ConflictResolver) ([]ConflictResolution, error) {
+	conflicts, err := c.FindConflicts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolutions := []ConflictResolution{}
+	var errs []error
+	for _, doc := range conflicts {
+		if err := ctx.Err(); err != nil {
+			return resolutions, err
+		}
+		resolution, err := c.ResolveConflicts(ctx, doc.ID, resolver)
+		if errors.Is(err, ErrNoConflicts) {
+			// Resolved since the view was queried
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		resolutions = append(resolutions, *resolution)
+	}
+
+	return resolutions, errors.Join(errs...)
+}
+
+// LastWriteWins resolves conflicts by keeping the revision with the most
+// recent timestamp in field (DefaultTimestampField if empty). Timestamps
+// are RFC 3339 strings; revisions without a parsable timestamp lose, and
+// ties keep CouchDB's current winner.
+func LastWriteWins(field string) ConflictResolver {
+	if field == "" {
+		field = DefaultTimestampField
+	}
+	return ConflictResolverFunc(func(id string, revisions []ConflictRevision) (map[string]interface{}, error) {
+		ordered := orderByTimestamp(revisions, field)
+		return stripMeta(ordered[0].Doc), nil
+	})
+}
+
+// FieldMerge resolves conflicts field by field. Every field present in any
+// revision is kept. Fields with a rule in merges are combined by that rule;
+// all other fields take the value of the most recently modified revision
+// that has them, ordered by timestamp field (DefaultTimestampField if empty).
+//
+// Example Usage:
+//
+//	resolver := FieldMerge("", map[string]FieldMergeFunc{
+//	    "tags": MergeUnion,
+//	})
+func FieldMerge(field string, merges map[string]FieldMergeFunc) ConflictResolver {
+	if field == "" {
+		field = DefaultTimestampField
+	}
+	return ConflictResolverFunc(func(id string, revisions []ConflictRevision) (map[string]interface{}, error) {
+		ordered := orderByTimestamp(revisions, field)
+
+		values := make(map[string][]interface{})
+		var keys []string
+		for _, revision := range ordered {
+			for key, value := range stripMeta(revision.Doc) {
+				if _, seen := values[key]; !seen {
+					keys = append(keys, key)
+				}
+				values[key] = append(values[key], value)
+			}
+		}
+
+		merged := make(map[string]interface{}, len(keys))
+		for _, key := range keys {
+			if merge, ok := merges[key]; ok && key != field {
+				merged[key] = merge(values[key])
+				continue
+			}
+			merged[key] = values[key][0]
+		}
+		return merged, nil
+	})
+}
+
+// MergeUnion is a FieldMergeFunc combining array values into one array
+// without duplicates, in order of first appearance. Non-array values are
+// treated as single elements.
+func MergeUnion(values []interface{}) interface{} {
+	union := []interface{}{}
+	for _, value := range values {
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		for _, item := range items {
+			duplicate := false
+			for _, existing := range union {
+				if reflect.DeepEqual(existing, item) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				union = append(union, item)
+			}
+		}
+	}
+	return union
+}
+
+// MergeMax is a FieldMergeFunc keeping the largest numeric value.
+// Non-numeric values are ignored unless no value is numeric.
+func MergeMax(values []interface{}) interface{} {
+	var best interface{}
+	var bestValue float64
+	for _, value := range values {
+		number, ok := value.(float64)
+		if !ok {
+			continue
+		}
+		if best == nil || number > bestValue {
+			best, bestValue = value, number
+		}
+	}
+	if best == nil && len(values) > 0 {
+		return values[0]
+	}
+	return best
+}
+
+// orderByTimestamp returns revisions sorted by field, newest first; the
+// sort is stable so ties keep the current winner in front
+func orderByTimestamp(revisions []ConflictRevision, field string) []ConflictRevision {
+	ordered := append([]ConflictRevision(nil), revisions...)
+	timestamps := make(map[string]time.Time, len(ordered))
+	for _, revision := range ordered {
+		if s, ok := revision.Doc[field].(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				timestamps[revision.Rev] = t
+			}
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return timestamps[ordered[i].Rev].After(timestamps[ordered[j].Rev])
+	})
+	return ordered
+}
+
+// stripMeta returns a copy of doc without _id, _rev and _conflicts
+func stripMeta(doc map[string]interface{}) map[string]interface{} {
+	body := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		switch k {
+		case "_id", "_rev", "_conflicts":
+			continue
+		}
+		body[k] = v
+	}
+	return body
+}
+
+// sameBody reports whether resolved equals doc, ignoring metadata fields
+func sameBody(resolved, doc map[string]interface{}) bool {
+	return reflect.DeepEqual(stripMeta(resolved), stripMeta(doc))
+}
+
+// cloneRevisions deep-copies revisions so resolvers cannot modify the
+// winner used for comparison
+func cloneRevisions(revisions []ConflictRevision) []ConflictRevision {
+	cloned := make([]ConflictRevision, len(revisions))
+	for i, revision := range revisions {
+		cloned[i] = revision
+		data, err := json.Marshal(revision.Doc)
+		if err != nil {
+			continue
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err == nil {
+			cloned[i].Doc = doc
+		}
+	}
+	return cloned
+}
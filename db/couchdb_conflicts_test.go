@@ -0,0 +1,317 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	kivik "github.com/go-kivik/kivik/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConflictDoc is a document with its winning and conflicting leaves
+type fakeConflictDoc struct {
+	rev       int
+	body      map[string]interface{}
+	conflicts map[string]map[string]interface{} // rev -> body
+}
+
+// fakeConflictServer is a minimal CouchDB that keeps conflicting revisions
+type fakeConflictServer struct {
+	mu         sync.Mutex
+	docs       map[string]*fakeConflictDoc
+	designDoc  map[string]interface{}
+	concurrent int // Number of PUTs losing against a simulated concurrent writer
+	puts       int
+}
+
+func newFakeConflictServer(t *testing.T) (*fakeConflictServer, *CouchDBService) {
+	t.Helper()
+	fake := &fakeConflictServer{docs: map[string]*fakeConflictDoc{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := kivik.New("couch", server.URL)
+	require.NoError(t, err)
+	return fake, &CouchDBService{client: client, database: client.DB("testdb"), dbName: "testdb"}
+}
+
+func (f *fakeConflictServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+
+	path := strings.TrimPrefix(r.URL.Path, "/testdb/")
+	switch {
+	case path == "_bulk_docs":
+		f.serveBulkDocs(w, r)
+	case path == "_design/eve_conflicts/_view/conflicts":
+		rows := []string{}
+		for id, doc := range f.docs {
+			if len(doc.conflicts) == 0 {
+				continue
+			}
+			revs, _ := json.Marshal(doc.conflictRevs())
+			rows = append(rows, fmt.Sprintf(`{"id":%q,"key":%q,"value":%s}`, id, id, revs))
+		}
+		fmt.Fprintf(w, `{"total_rows":%d,"offset":0,"rows":[%s]}`, len(rows), strings.Join(rows, ","))
+	case path == "_design/eve_conflicts":
+		if r.Method == http.MethodPut {
+			_ = json.NewDecoder(requestBody(r)).Decode(&f.designDoc)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"ok":true,"id":"_design/eve_conflicts","rev":"1-d"}`)
+			return
+		}
+		if f.designDoc == nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"not_found","reason":"missing"}`)
+			return
+		}
+		w.Header().Set("ETag", `"1-d"`)
+		_ = json.NewEncoder(w).Encode(f.designDoc)
+	default:
+		f.serveDoc(w, r, path)
+	}
+}
+
+func (f *fakeConflictServer) serveDoc(w http.ResponseWriter, r *http.Request, id string) {
+	doc, ok := f.docs[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"not_found","reason":"missing"}`)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var body map[string]interface{}
+		_ = json.NewDecoder(requestBody(r)).Decode(&body)
+		f.puts++
+		if f.concurrent > 0 {
+			// Another writer saves first
+			f.concurrent--
+			doc.rev++
+			doc.body["value"] = doc.body["value"].(float64) + 10
+		}
+		if body["_rev"] != doc.currentRev() {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"error":"conflict","reason":"Document update conflict."}`)
+			return
+		}
+		doc.rev++
+		doc.body = stripMeta(body)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"ok":true,"id":%q,"rev":%q}`, id, doc.currentRev())
+		return
+	}
+
+	var out map[string]interface{}
+	if rev := r.URL.Query().Get("rev"); rev != "" && rev != doc.currentRev() {
+		body, ok := doc.conflicts[rev]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"not_found","reason":"missing"}`)
+			return
+		}
+		out = withMeta(body, id, rev)
+	} else {
+		out = withMeta(doc.body, id, doc.currentRev())
+		if r.URL.Query().Get("conflicts") == "true" && len(doc.conflicts) > 0 {
+			out["_conflicts"] = doc.conflictRevs()
+		}
+	}
+	w.Header().Set("ETag", fmt.Sprintf("%q", out["_rev"]))
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func (f *fakeConflictServer) serveBulkDocs(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Docs []map[string]interface{} `json:"docs"`
+	}
+	_ = json.NewDecoder(requestBody(r)).Decode(&request)
+
+	results := []string{}
+	for _, body := range request.Docs {
+		id, _ := body["_id"].(string)
+		rev, _ := body["_rev"].(string)
+		doc := f.docs[id]
+		switch {
+		case doc == nil:
+			results = append(results, fmt.Sprintf(`{"id":%q,"error":"not_found","reason":"missing"}`, id))
+		case body["_deleted"] == true && doc.conflicts[rev] != nil:
+			delete(doc.conflicts, rev)
+			results = append(results, fmt.Sprintf(`{"ok":true,"id":%q,"rev":"%s-deleted"}`, id, rev))
+		case rev == doc.currentRev():
+			doc.rev++
+			doc.body = stripMeta(body)
+			results = append(results, fmt.Sprintf(`{"ok":true,"id":%q,"rev":%q}`, id, doc.currentRev()))
+		default:
+			results = append(results, fmt.Sprintf(`{"id":%q,"error":"conflict","reason":"Document update conflict."}`, id))
+		}
+	}
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, "[%s]", strings.Join(results, ","))
+}
+
+func (d *fakeConflictDoc) currentRev() string {
+	return fmt.Sprintf("%d-w", d.rev)
+}
+
+func (d *fakeConflictDoc) conflictRevs() []string {
+	revs := []string{}
+	for rev := range d.conflicts {
+		revs = append(revs, rev)
+	}
+	return revs
+}
+
+func withMeta(body map[string]interface{}, id, rev string) map[string]interface{} {
+	out := map[string]interface{}{"_id": id, "_rev": rev}
+	for k, v := range body {
+		out[k] = v
+	}
+	return out
+}
+
+type conflictTestDoc struct {
+	ID    string `json:"_id"`
+	Rev   string `json:"_rev,omitempty"`
+	Value int    `json:"value"`
+}
+
+func TestUpdateWithRetry(t *testing.T) {
+	fake, service := newFakeConflictServer(t)
+	fake.docs["counter"] = &fakeConflictDoc{rev: 1, body: map[string]interface{}{"value": float64(1)}}
+	fake.concurrent = 2
+
+	updated, err := UpdateWithRetry(service, "counter", func(doc *conflictTestDoc) error {
+		doc.Value++
+		return nil
+	}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 22, updated.Value, "mutation is re-applied on top of the concurrent writes")
+	assert.Equal(t, "4-w", updated.Rev)
+	assert.Equal(t, 3, fake.puts)
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		fake.concurrent = 5
+		_, err := UpdateWithRetry(service, "counter", func(doc *conflictTestDoc) error {
+			doc.Value++
+			return nil
+		}, 2)
+		var couchErr *CouchDBError
+		require.True(t, errors.As(err, &couchErr))
+		assert.True(t, couchErr.IsConflict())
+	})
+
+	t.Run("mutate error", func(t *testing.T) {
+		fake.concurrent = 0
+		boom := errors.New("boom")
+		_, err := UpdateWithRetry(service, "counter", func(doc *conflictTestDoc) error {
+			return boom
+		}, 0)
+		assert.ErrorIs(t, err, boom)
+	})
+}
+
+func newConflictedDoc() *fakeConflictDoc {
+	return &fakeConflictDoc{
+		rev: 3,
+		body: map[string]interface{}{
+			"name":         "winner",
+			"tags":         []interface{}{"a"},
+			"dateModified": "2026-01-01T10:00:00Z",
+		},
+		conflicts: map[string]map[string]interface{}{
+			"3-x": {
+				"name":         "newer",
+				"tags":         []interface{}{"b"},
+				"owner":        "ops",
+				"dateModified": "2026-01-02T10:00:00Z",
+			},
+		},
+	}
+}
+
+func TestFindAndResolveConflicts(t *testing.T) {
+	fake, service := newFakeConflictServer(t)
+	ctx := context.Background()
+	fake.docs["doc-1"] = newConflictedDoc()
+	fake.docs["doc-2"] = &fakeConflictDoc{rev: 1, body: map[string]interface{}{"name": "clean"}}
+
+	conflicts, err := service.FindConflicts(ctx)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, ConflictedDocument{ID: "doc-1", Conflicts: []string{"3-x"}}, conflicts[0])
+	assert.NotNil(t, fake.designDoc, "scanner view is created on first use")
+
+	resolutions, err := service.ResolveAllConflicts(ctx, LastWriteWins(""))
+	require.NoError(t, err)
+	require.Len(t, resolutions, 1)
+	assert.Equal(t, "3-w", resolutions[0].WinningRev)
+	assert.Equal(t, "4-w", resolutions[0].Rev)
+	assert.True(t, resolutions[0].Updated)
+	assert.Equal(t, []string{"3-x"}, resolutions[0].DeletedRevs)
+	assert.Equal(t, "newer", fake.docs["doc-1"].body["name"])
+	assert.Empty(t, fake.docs["doc-1"].conflicts)
+
+	_, err = service.ResolveConflicts(ctx, "doc-2", LastWriteWins(""))
+	assert.ErrorIs(t, err, ErrNoConflicts)
+}
+
+func TestConflictResolvers(t *testing.T) {
+	t.Run("field merge", func(t *testing.T) {
+		fake, service := newFakeConflictServer(t)
+		fake.docs["doc-1"] = newConflictedDoc()
+
+		resolution, err := service.ResolveConflicts(context.Background(), "doc-1", FieldMerge("", map[string]FieldMergeFunc{
+			"tags": MergeUnion,
+		}))
+		require.NoError(t, err)
+		assert.True(t, resolution.Updated)
+		body := fake.docs["doc-1"].body
+		assert.Equal(t, "newer", body["name"])
+		assert.Equal(t, "ops", body["owner"])
+		assert.Equal(t, []interface{}{"b", "a"}, body["tags"])
+	})
+
+	t.Run("custom callback keeping the winner", func(t *testing.T) {
+		fake, service := newFakeConflictServer(t)
+		fake.docs["doc-1"] = newConflictedDoc()
+
+		var seen []string
+		resolution, err := service.ResolveConflicts(context.Background(), "doc-1", ConflictResolverFunc(
+			func(id string, revisions []ConflictRevision) (map[string]interface{}, error) {
+				for _, revision := range revisions {
+					seen = append(seen, revision.Rev)
+				}
+				revisions[0].Doc["name"] = "modified copy"
+				return stripMeta(revisions[1].Doc), nil
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"3-w", "3-x"}, seen)
+		assert.True(t, resolution.Updated)
+		assert.Equal(t, "newer", fake.docs["doc-1"].body["name"])
+
+		fake.docs["doc-1"] = newConflictedDoc()
+		resolution, err = service.ResolveConflicts(context.Background(), "doc-1", ConflictResolverFunc(
+			func(id string, revisions []ConflictRevision) (map[string]interface{}, error) {
+				return revisions[0].Doc, nil
+			}))
+		require.NoError(t, err)
+		assert.False(t, resolution.Updated, "unchanged winner is not rewritten")
+		assert.Equal(t, "3-w", resolution.Rev)
+		assert.Equal(t, []string{"3-x"}, resolution.DeletedRevs)
+	})
+
+	t.Run("merge helpers", func(t *testing.T) {
+		assert.Equal(t, []interface{}{"a", "b", "c"}, MergeUnion([]interface{}{[]interface{}{"a", "b"}, []interface{}{"b", "c"}}))
+		assert.Equal(t, float64(7), MergeMax([]interface{}{float64(3), "x", float64(7)}))
+	})
+}
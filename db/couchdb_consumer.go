@@ -0,0 +1,450 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	kivik "github.com/go-kivik/kivik/v4"
+)
+
+// Defaults for ChangesConsumerConfig
+const (
+	DefaultConsumerBatchSize    = 100
+	DefaultConsumerBatchTimeout = time.Second
+	DefaultConsumerHeartbeat    = 30 * time.Second
+	DefaultConsumerMinBackoff   = time.Second
+	DefaultConsumerMaxBackoff   = time.Minute
+)
+
+// ChangesHandler processes a batch of changes. Returning an error redelivers
+// the same batch after a backoff; the checkpoint only advances past batches
+// the handler accepted, so changes are delivered at least once.
+type ChangesHandler func(ctx context.Context, changes []Change) error
+
+// ChangesConsumerConfig configures a named, checkpointed changes consumer.
+//
+// Configuration Options:
+//   - Name: Consumer name, unique per database; the checkpoint is stored under it
+//   - Since: Sequence to start from when no checkpoint exists (default: "0", use "now" to skip history)
+//   - BatchSize: Maximum changes per handler call (default: 100)
+//   - BatchTimeout: Delivers a partial batch after this delay (default: 1s)
+//   - IncludeDocs, Filter, Selector: Passed to the changes feed
+//   - Heartbeat: Keeps idle connections open (default: 30s)
+//   - MinBackoff, MaxBackoff: Reconnect and redelivery backoff (default: 1s, 1m)
+//   - Checkpoints: Checkpoint storage (default: _local documents in the consumed database)
+//   - OnError: Called for feed, handler and checkpoint errors before retrying (optional)
+type ChangesConsumerConfig struct {
+	Name         string
+	Since        string
+	BatchSize    int
+	BatchTimeout time.Duration
+	IncludeDocs  bool
+	Filter       string
+	Selector     map[string]interface{}
+	Heartbeat    time.Duration
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	Checkpoints  CheckpointStore
+	OnError      func(err error)
+}
+
+// CheckpointStore persists the last processed sequence of changes consumers.
+// LoadCheckpoint returns "" if the consumer has no checkpoint yet.
+type CheckpointStore interface {
+	LoadCheckpoint(ctx context.Context, database, consumer string) (string, error)
+	SaveCheckpoint(ctx context.Context, database, consumer, seq string) error
+}
+
+// ChangesConsumer delivers a database's changes feed to a handler in batches.
+// It resumes from its checkpoint after restarts and reconnects with
+// exponential backoff after network errors. Several consumers with different
+// names can consume the same database independently.
+//
+// Example Usage:
+//
+//	consumer, err := service.NewChangesConsumer(ChangesConsumerConfig{
+//	    Name:        "search-indexer",
+//	    IncludeDocs: true,
+//	}, func(ctx context.Context, changes []Change) error {
+//	    return index.Update(ctx, changes) // Error: batch is redelivered
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	err = consumer.Run(ctx) // Blocks until ctx is cancelled
+type ChangesConsumer struct {
+	database *kivik.DB
+	config   ChangesConsumerConfig
+	handler  ChangesHandler
+
+	mu  sync.RWMutex
+	seq string // Last checkpointed sequence
+}
+
+// NewChangesConsumer creates a consumer for the service's database
+func (c *CouchDBService) NewChangesConsumer(config ChangesConsumerConfig, handler ChangesHandler) (*ChangesConsumer, error) {
+	return NewChangesConsumer(c.database, config, handler)
+}
+
+// NewChangesConsumer creates a consumer for a database
+func NewChangesConsumer(database *kivik.DB, config ChangesConsumerConfig, handler ChangesHandler) (*ChangesConsumer, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("consumer name is required")
+	}
+	if handler == nil {
+		return nil, fmt.Errorf("changes handler is required")
+	}
+	if config.Since == "" {
+		config.Since = "0"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultConsumerBatchSize
+	}
+	if config.BatchTimeout <= 0 {
+		config.BatchTimeout = DefaultConsumerBatchTimeout
+	}
+	if config.Heartbeat <= 0 {
+		config.Heartbeat = DefaultConsumerHeartbeat
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultConsumerMinBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = DefaultConsumerMaxBackoff
+		if config.MaxBackoff < config.MinBackoff {
+			config.MaxBackoff = config.MinBackoff
+		}
+	}
+	if config.Checkpoints == nil {
+		config.Checkpoints = NewCouchDBCheckpointStore(database.Client())
+	}
+
+	return &ChangesConsumer{
+		database: database,
+		config:   config,
+		handler:  handler,
+	}, nil
+}
+
+// Name returns the consumer name
+func (cc *ChangesConsumer) Name() string {
+	return cc.config.Name
+}
+
+// Checkpoint returns the last checkpointed sequence
+func (cc *ChangesConsumer) Checkpoint() string {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+	return cc.seq
+}
+
+// Run consumes the changes feed until ctx is cancelled and returns ctx.Err().
+// Feed errors are retried with backoff; only loading the initial checkpoint
+// fails fast.
+func (cc *ChangesConsumer) Run(ctx context.Context) error {
+	seq, err := cc.config.Checkpoints.LoadCheckpoint(ctx, cc.database.Name(), cc.config.Name)
+	if err != nil {
+		return fmt.Errorf("load checkpoint of consumer %s: %w", cc.config.Name, err)
+	}
+	if seq == "" {
+		seq = cc.config.Since
+	}
+	cc.setSeq(seq)
+
+	attempt := 0
+	for {
+		delivered, err := cc.consume(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if delivered {
+			attempt = 0
+		}
+		if err == nil && delivered {
+			// The server closed the feed cleanly; reconnect right away
+			continue
+		}
+
+		if err != nil {
+			cc.reportError(fmt.Errorf("changes feed of consumer %s: %w", cc.config.Name, err))
+		}
+		if !sleepContext(ctx, cc.backoff(attempt)) {
+			return ctx.Err()
+		}
+		attempt++
+	}
+}
+
+// consume reads the feed from the checkpoint until it closes or fails,
+// reporting whether any batch was delivered
+func (cc *ChangesConsumer) consume(ctx context.Context) (delivered bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	opts := ChangesFeedOptions{
+		Since:       cc.Checkpoint(),
+		Feed:        "continuous",
+		IncludeDocs: cc.config.IncludeDocs,
+		Filter:      cc.config.Filter,
+		Selector:    cc.config.Selector,
+		Heartbeat:   int(cc.config.Heartbeat / time.Millisecond),
+	}
+	rows := cc.database.Changes(ctx, kivik.Params(changesParams(opts)))
+
+	// Read the feed in the background so partial batches can be flushed on a timer
+	feed := make(chan Change)
+	feedErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer func() {
+		cancel()
+		<-done
+		rows.Close()
+	}()
+	go func() {
+		defer close(done)
+		defer close(feed)
+		for rows.Next() {
+			select {
+			case feed <- changeFromRows(rows, opts.IncludeDocs):
+			case <-ctx.Done():
+				return
+			}
+		}
+		feedErr <- rows.Err()
+	}()
+
+	batch := make([]Change, 0, cc.config.BatchSize)
+	timer := time.NewTimer(cc.config.BatchTimeout)
+	defer timer.Stop()
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := cc.deliver(ctx, batch); err != nil {
+			return err
+		}
+		delivered = true
+		batch = make([]Change, 0, cc.config.BatchSize)
+		return nil
+	}
+
+	for {
+		select {
+		case change, ok := <-feed:
+			if !ok {
+				if err := flush(); err != nil {
+					return delivered, err
+				}
+				select {
+				case err := <-feedErr:
+					return delivered, err
+				default:
+					return delivered, ctx.Err()
+				}
+			}
+			batch = append(batch, change)
+			if len(batch) >= cc.config.BatchSize {
+				if err := flush(); err != nil {
+					return delivered, err
+				}
+				resetTimer(timer, cc.config.BatchTimeout)
+			}
+		case <-timer.C:
+			if err := flush(); err != nil {
+				return delivered, err
+			}
+			timer.Reset(cc.config.BatchTimeout)
+		case <-ctx.Done():
+			return delivered, ctx.Err()
+		}
+	}
+}
+
+// deliver hands a batch to the handler until it succeeds, then saves the checkpoint
+func (cc *ChangesConsumer) deliver(ctx context.Context, batch []Change) error {
+	for attempt := 0; ; attempt++ {
+		err := cc.handler(ctx, batch)
+		if err == nil {
+			break
+		}
+		cc.reportError(fmt.Errorf("handler of consumer %s: %w", cc.config.Name, err))
+		if !sleepContext(ctx, cc.backoff(attempt)) {
+			return ctx.Err()
+		}
+	}
+
+	seq := batch[len(batch)-1].Seq
+	cc.setSeq(seq)
+	if err := cc.config.Checkpoints.SaveCheckpoint(ctx, cc.database.Name(), cc.config.Name, seq); err != nil {
+		// The batch was processed; a lost checkpoint only causes redelivery after a restart
+		cc.reportError(fmt.Errorf("save checkpoint of consumer %s: %w", cc.config.Name, err))
+	}
+	return nil
+}
+
+func (cc *ChangesConsumer) setSeq(seq string) {
+	cc.mu.Lock()
+	cc.seq = seq
+	cc.mu.Unlock()
+}
+
+func (cc *ChangesConsumer) reportError(err error) {
+	if cc.config.OnError != nil {
+		cc.config.OnError(err)
+	}
+}
+
+// backoff returns the exponential backoff with jitter for a retry attempt
+func (cc *ChangesConsumer) backoff(attempt int) time.Duration {
+	delay := cc.config.MinBackoff
+	for i := 0; i < attempt && delay < cc.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > cc.config.MaxBackoff {
+		delay = cc.config.MaxBackoff
+	}
+	// Up to 20% jitter so consumers do not reconnect in lockstep
+	return delay - time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// sleepContext waits for d, returning false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// resetTimer stops, drains and restarts a timer
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
+
+// CouchDBCheckpointStore keeps checkpoints in _local documents of the consumed
+// database. _local documents are not replicated, so every replica keeps its own.
+type CouchDBCheckpointStore struct {
+	client *kivik.Client
+}
+
+// checkpointDoc is the _local document holding a consumer checkpoint
+type checkpointDoc struct {
+	ID        string    `json:"_id"`
+	Rev       string    `json:"_rev,omitempty"`
+	Consumer  string    `json:"consumer"`
+	Seq       string    `json:"seq"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewCouchDBCheckpointStore creates a checkpoint store on a CouchDB server
+func NewCouchDBCheckpointStore(client *kivik.Client) *CouchDBCheckpointStore {
+	return &CouchDBCheckpointStore{client: client}
+}
+
+// LoadCheckpoint reads a consumer checkpoint
+func (s *CouchDBCheckpointStore) LoadCheckpoint(ctx context.Context, database, consumer string) (string, error) {
+	doc, err := s.get(ctx, database, consumer)
+	if err != nil || doc == nil {
+		return "", err
+	}
+	return doc.Seq, nil
+}
+
+// SaveCheckpoint writes a consumer checkpoint
+func (s *CouchDBCheckpointStore) SaveCheckpoint(ctx context.Context, database, consumer, seq string) error {
+	doc, err := s.get(ctx, database, consumer)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		doc = &checkpointDoc{ID: checkpointDocID(consumer), Consumer: consumer}
+	}
+	doc.Seq = seq
+	doc.UpdatedAt = time.Now().UTC()
+
+	if _, err := s.client.DB(database).Put(ctx, doc.ID, doc); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// get reads a checkpoint document, returning nil if it does not exist
+func (s *CouchDBCheckpointStore) get(ctx context.Context, database, consumer string) (*checkpointDoc, error) {
+	var doc checkpointDoc
+	err := s.client.DB(database).Get(ctx, checkpointDocID(consumer)).ScanDoc(&doc)
+	if kivik.HTTPStatus(err) == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint: %w", err)
+	}
+	return &doc, nil
+}
+
+func checkpointDocID(consumer string) string {
+	return "_local/changes-consumer-" + consumer
+}
+
+// MemoryCheckpointStore keeps checkpoints in memory, so consumers resume after
+// reconnects but start over after a restart
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]string
+}
+
+// NewMemoryCheckpointStore creates an in-memory checkpoint store
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[string]string)}
+}
+
+// LoadCheckpoint reads a consumer checkpoint
+func (s *MemoryCheckpointStore) LoadCheckpoint(ctx context.Context, database, consumer string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoints[database+"/"+consumer], nil
+}
+
+// SaveCheckpoint writes a consumer checkpoint
+func (s *MemoryCheckpointStore) SaveCheckpoint(ctx context.Context, database, consumer, seq string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[database+"/"+consumer] = seq
+	return nil
+}
+
+// RunConsumers runs consumers concurrently until ctx is cancelled or one of
+// them fails to load its checkpoint, and returns the first error
+func RunConsumers(ctx context.Context, consumers ...*ChangesConsumer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(consumers))
+	for _, consumer := range consumers {
+		go func(consumer *ChangesConsumer) {
+			errs <- consumer.Run(ctx)
+		}(consumer)
+	}
+
+	var first error
+	for range consumers {
+		if err := <-errs; first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
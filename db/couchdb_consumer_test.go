@@ -0,0 +1,259 @@
+package db
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	kivik "github.com/go-kivik/kivik/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChangesServer serves a continuous changes feed and _local documents
+type fakeChangesServer struct {
+	mu        sync.Mutex
+	docs      []string // Document IDs; the sequence of docs[i] is i+1
+	local     map[string]json.RawMessage
+	dropAfter int // The first feed connection fails after this many rows
+	sinces    []string
+}
+
+func (f *fakeChangesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/_changes"):
+		f.serveChanges(w, r)
+	case strings.Contains(r.URL.Path, "/_local/"):
+		id := r.URL.Path[strings.Index(r.URL.Path, "_local/"):]
+		if r.Method == http.MethodPut {
+			body := io.Reader(r.Body)
+			if r.Header.Get("Content-Encoding") == "gzip" {
+				gz, err := gzip.NewReader(r.Body)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				body = gz
+			}
+			var doc json.RawMessage
+			_ = json.NewDecoder(body).Decode(&doc)
+			f.local[id] = doc
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"ok":true,"id":%q,"rev":"0-1"}`, id)
+			return
+		}
+		doc, ok := f.local[id]
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"not_found","reason":"missing"}`)
+			return
+		}
+		w.Header().Set("ETag", `"0-1"`)
+		_, _ = w.Write(doc)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeChangesServer) serveChanges(w http.ResponseWriter, r *http.Request) {
+	since := r.URL.Query().Get("since")
+	f.sinces = append(f.sinces, since)
+	first := len(f.sinces) == 1
+	start, _ := strconv.Atoi(since)
+	docs := append([]string(nil), f.docs...)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	for i := start; i < len(docs); i++ {
+		if first && f.dropAfter > 0 && i-start == f.dropAfter {
+			w.(http.Flusher).Flush()
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close() // Drop the connection mid-feed
+			}
+			return
+		}
+		fmt.Fprintf(w, `{"seq":"%d","id":%q,"changes":[{"rev":"1-a"}]}`+"\n", i+1, docs[i])
+		w.(http.Flusher).Flush()
+	}
+
+	// Keep the feed open like CouchDB does until the client goes away
+	f.mu.Unlock()
+	<-r.Context().Done()
+	f.mu.Lock()
+}
+
+func (f *fakeChangesServer) checkpoint(consumer string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var doc checkpointDoc
+	_ = json.Unmarshal(f.local[checkpointDocID(consumer)], &doc)
+	return doc.Seq
+}
+
+func newFakeChangesDB(t *testing.T, fake *fakeChangesServer) *kivik.DB {
+	t.Helper()
+	if fake.local == nil {
+		fake.local = make(map[string]json.RawMessage)
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := kivik.New("couch", server.URL)
+	require.NoError(t, err)
+	return client.DB("testdb")
+}
+
+func TestChangesConsumer_ReconnectsAndRedelivers(t *testing.T) {
+	fake := &fakeChangesServer{docs: []string{"doc1", "doc2", "doc3", "doc4", "doc5"}, dropAfter: 3}
+	database := newFakeChangesDB(t, fake)
+
+	var (
+		mu        sync.Mutex
+		delivered []string
+		calls     int
+		errs      []error
+	)
+	consumer, err := NewChangesConsumer(database, ChangesConsumerConfig{
+		Name:         "indexer",
+		BatchSize:    2,
+		BatchTimeout: 20 * time.Millisecond,
+		MinBackoff:   5 * time.Millisecond,
+		MaxBackoff:   20 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	}, func(ctx context.Context, changes []Change) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			return errors.New("temporary failure")
+		}
+		for _, change := range changes {
+			delivered = append(delivered, change.ID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return fake.checkpoint("indexer") == "5" }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"doc1", "doc2", "doc3", "doc4", "doc5"}, delivered)
+	assert.Equal(t, "5", consumer.Checkpoint())
+	assert.GreaterOrEqual(t, len(errs), 2, "handler and feed errors are reported")
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	require.GreaterOrEqual(t, len(fake.sinces), 2)
+	assert.Equal(t, []string{"0", "3"}, fake.sinces[:2], "reconnects from the last checkpoint")
+}
+
+func TestChangesConsumer_ResumesFromCheckpoint(t *testing.T) {
+	fake := &fakeChangesServer{docs: []string{"doc1", "doc2", "doc3", "doc4", "doc5"}}
+	database := newFakeChangesDB(t, fake)
+
+	checkpoints := NewMemoryCheckpointStore()
+	require.NoError(t, checkpoints.SaveCheckpoint(context.Background(), "testdb", "indexer", "3"))
+
+	var (
+		mu        sync.Mutex
+		delivered []string
+	)
+	consumer, err := NewChangesConsumer(database, ChangesConsumerConfig{
+		Name:         "indexer",
+		BatchTimeout: 10 * time.Millisecond,
+		Checkpoints:  checkpoints,
+	}, func(ctx context.Context, changes []Change) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, change := range changes {
+			delivered = append(delivered, change.ID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		seq, _ := checkpoints.LoadCheckpoint(ctx, "testdb", "indexer")
+		return seq == "5"
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"doc4", "doc5"}, delivered)
+	assert.Equal(t, "3", fake.sinces[0])
+}
+
+func TestCouchDBCheckpointStore(t *testing.T) {
+	fake := &fakeChangesServer{}
+	database := newFakeChangesDB(t, fake)
+	store := NewCouchDBCheckpointStore(database.Client())
+	ctx := context.Background()
+
+	seq, err := store.LoadCheckpoint(ctx, "testdb", "indexer")
+	require.NoError(t, err)
+	assert.Empty(t, seq)
+
+	require.NoError(t, store.SaveCheckpoint(ctx, "testdb", "indexer", "42-abc"))
+	seq, err = store.LoadCheckpoint(ctx, "testdb", "indexer")
+	require.NoError(t, err)
+	assert.Equal(t, "42-abc", seq)
+
+	seq, err = store.LoadCheckpoint(ctx, "testdb", "other")
+	require.NoError(t, err)
+	assert.Empty(t, seq, "consumers are checkpointed independently")
+}
+
+func TestNewChangesConsumer_Validation(t *testing.T) {
+	handler := func(ctx context.Context, changes []Change) error { return nil }
+
+	_, err := NewChangesConsumer(nil, ChangesConsumerConfig{}, handler)
+	assert.Error(t, err)
+
+	_, err = NewChangesConsumer(nil, ChangesConsumerConfig{Name: "a"}, nil)
+	assert.Error(t, err)
+
+	consumer, err := NewChangesConsumer(nil, ChangesConsumerConfig{Name: "a", Checkpoints: NewMemoryCheckpointStore()}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "0", consumer.config.Since)
+	assert.Equal(t, DefaultConsumerBatchSize, consumer.config.BatchSize)
+
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := consumer.backoff(attempt)
+		assert.LessOrEqual(t, delay, DefaultConsumerMaxBackoff)
+		assert.GreaterOrEqual(t, delay, DefaultConsumerMinBackoff*4/5)
+	}
+}
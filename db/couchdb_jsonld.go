@@ -3,7 +3,6 @@ package db
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 )
 
 // ValidateJSONLD performs basic JSON-LD validation on a document.
@@ -86,31 +85,19 @@ func ValidateJSONLD(doc interface{}, context string) error {
 	return nil
 }
 
-// ExpandJSONLD performs basic JSON-LD expansion.
-// This is a simplified implementation that handles common expansion patterns
-// without requiring a full JSON-LD processor.
+// ExpandJSONLD expands a JSON-LD document with the JSON-LD 1.1 expansion
+// algorithm. Terms, prefixes and @vocab are resolved to full IRIs, values
+// become value objects with their coerced @type or @language, and nested and
+// type-scoped contexts are honored. Remote contexts are loaded with
+// DefaultDocumentLoader, which serves schema.org offline.
 //
 // Parameters:
-//   - doc: Document to expand
+//   - doc: Document to expand (map or struct)
 //
 // Returns:
-//   - map[string]interface{}: Expanded document
-//   - error: Expansion errors
-//
-// Expansion Process:
-//
-//	JSON-LD expansion converts compact representation to explicit form:
-//	- Resolves all terms to full IRIs
-//	- Converts values to explicit object format
-//	- Expands @type to @type array
-//	- Converts single values to arrays
-//
-// Limitations:
-//
-//	This is a basic implementation:
-//	- Does not fetch remote contexts
-//	- Limited to simple context resolution
-//	- For full expansion, use a dedicated JSON-LD library
+//   - map[string]interface{}: The expanded node; documents expanding to
+//     several nodes are returned as {"@graph": [...]}
+//   - error: Marshaling, context loading or JSON-LD processing errors
 //
 // Example Usage:
 //
@@ -126,82 +113,77 @@ func ValidateJSONLD(doc interface{}, context string) error {
 //	    return
 //	}
 //
-//	// expanded now has full IRIs
+//	// {"@type": ["http://schema.org/SoftwareApplication"],
+//	//  "http://schema.org/name": [{"@value": "nginx"}]}
 //	fmt.Printf("Expanded: %+v\n", expanded)
 func ExpandJSONLD(doc interface{}) (map[string]interface{}, error) {
-	// Convert to map
-	jsonData, err := json.Marshal(doc)
+	nodes, err := ExpandJSONLDDocument(doc, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal document: %w", err)
+		return nil, err
 	}
 
-	var docMap map[string]interface{}
-	if err := json.Unmarshal(jsonData, &docMap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal document: %w", err)
-	}
-
-	// Basic expansion (simplified)
-	expanded := make(map[string]interface{})
-
-	// Get context for term expansion
-	context := ""
-	if ctx, ok := docMap["@context"]; ok {
-		context = fmt.Sprintf("%v", ctx)
-		context = strings.TrimSuffix(context, "/")
-	}
-
-	// Process each field
-	for key, value := range docMap {
-		// Skip @context in expansion
-		if key == "@context" {
-			continue
-		}
-
-		// Expand @type
-		if key == "@type" {
-			expanded["@type"] = []interface{}{expandTerm(value, context)}
-			continue
+	switch len(nodes) {
+	case 0:
+		return map[string]interface{}{}, nil
+	case 1:
+		if node, ok := nodes[0].(map[string]interface{}); ok {
+			return node, nil
 		}
-
-		// Expand @id
-		if key == "@id" {
-			expanded["@id"] = value
-			continue
-		}
-
-		// Expand regular properties
-		expandedKey := expandTerm(key, context)
-		expanded[expandedKey] = expandValue(value)
 	}
+	return map[string]interface{}{"@graph": nodes}, nil
+}
 
-	return expanded, nil
+// ExpandJSONLDDocument expands a JSON-LD document and returns the expanded
+// node objects.
+//
+// Parameters:
+//   - doc: Document to expand (map, slice or struct)
+//   - opts: Base IRI, document loader and expand context (nil for defaults)
+//
+// Returns:
+//   - []interface{}: Expanded node objects
+//   - error: Marshaling, context loading or JSON-LD processing errors
+//
+// Example Usage:
+//
+//	loader := NewStaticDocumentLoader(nil)
+//	loader.AddDocument("https://eve.evalgo.org/context", `{"@context": {"eve": "https://eve.evalgo.org/ns#"}}`)
+//
+//	nodes, err := ExpandJSONLDDocument(doc, &JSONLDOptions{DocumentLoader: loader})
+func ExpandJSONLDDocument(doc interface{}, opts *JSONLDOptions) ([]interface{}, error) {
+	document, err := toJSONValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	return newJSONLDProcessor(opts).expandDocument(document)
 }
 
-// CompactJSONLD performs basic JSON-LD compaction.
-// This converts expanded JSON-LD to a more compact form using a context.
+// CompactJSONLD compacts a JSON-LD document with the JSON-LD 1.1 compaction
+// algorithm. The document is expanded first, so it may be in expanded form
+// or compacted with any other context.
 //
 // Parameters:
-//   - doc: Expanded document to compact
-//   - context: Context to use for compaction
+//   - doc: Document to compact
+//   - context: Context URL to compact with (empty for no context)
 //
 // Returns:
-//   - map[string]interface{}: Compacted document
-//   - error: Compaction errors
+//   - map[string]interface{}: Compacted document with @context set to context
+//   - error: Marshaling, context loading or JSON-LD processing errors
 //
 // Compaction Process:
 //
 //	Converts expanded form back to compact:
-//	- Replaces full IRIs with short terms
-//	- Uses context for term mapping
-//	- Simplifies value objects to simple values
-//	- Converts arrays to single values where appropriate
+//	- Replaces full IRIs with terms, @vocab-relative names or compact IRIs
+//	- Picks the term whose type, language and container mappings fit each value
+//	- Simplifies value objects to plain values where the context allows
+//	- Converts single-element arrays to single values
 //
 // Example Usage:
 //
 //	expanded := map[string]interface{}{
-//	    "@type":                              []interface{}{"https://schema.org/SoftwareApplication"},
-//	    "https://schema.org/name":            "nginx",
-//	    "https://schema.org/applicationCategory": "web-server",
+//	    "@type":                              []interface{}{"http://schema.org/SoftwareApplication"},
+//	    "http://schema.org/name":             "nginx",
+//	    "http://schema.org/applicationCategory": "web-server",
 //	}
 //
 //	compacted, err := CompactJSONLD(expanded, "https://schema.org")
@@ -211,69 +193,132 @@ func ExpandJSONLD(doc interface{}) (map[string]interface{}, error) {
 //	}
 //
 //	// compacted uses short terms:
-//	// {"@context": "https://schema.org", "@type": "SoftwareApplication", "name": "nginx", ...}
+//	// {"@context": "https://schema.org", "type": "SoftwareApplication", "name": "nginx", ...}
 func CompactJSONLD(doc interface{}, context string) (map[string]interface{}, error) {
-	// Convert to map
-	jsonData, err := json.Marshal(doc)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	var ctx interface{}
+	if context != "" {
+		ctx = context
 	}
+	return CompactJSONLDDocument(doc, ctx, nil)
+}
 
-	var docMap map[string]interface{}
-	if err := json.Unmarshal(jsonData, &docMap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal document: %w", err)
+// CompactJSONLDDocument compacts a JSON-LD document with a context given as
+// a URL, a context object or an array of both.
+//
+// Parameters:
+//   - doc: Document to compact
+//   - context: Context to compact with; a context document's @context is used
+//   - opts: Base IRI and document loader (nil for defaults)
+//
+// Returns:
+//   - map[string]interface{}: Compacted document including @context
+//   - error: Marshaling, context loading or JSON-LD processing errors
+//
+// Example Usage:
+//
+//	context := map[string]interface{}{
+//	    "@vocab": "http://schema.org/",
+//	    "eve":    "https://eve.evalgo.org/ns#",
+//	}
+//	compacted, err := CompactJSONLDDocument(doc, context, nil)
+func CompactJSONLDDocument(doc interface{}, context interface{}, opts *JSONLDOptions) (map[string]interface{}, error) {
+	document, err := toJSONValue(doc)
+	if err != nil {
+		return nil, err
 	}
+	if context, err = toJSONValue(context); err != nil {
+		return nil, err
+	}
+	return newJSONLDProcessor(opts).compactDocument(document, context)
+}
 
-	// Basic compaction (simplified)
-	compacted := make(map[string]interface{})
-	compacted["@context"] = context
-
-	contextPrefix := strings.TrimSuffix(context, "/") + "/"
-
-	// Process each field
-	for key, value := range docMap {
-		// Compact @type
-		if key == "@type" {
-			if typeArray, ok := value.([]interface{}); ok && len(typeArray) > 0 {
-				typeStr := fmt.Sprintf("%v", typeArray[0])
-				compacted["@type"] = compactTerm(typeStr, contextPrefix)
-			} else {
-				compacted["@type"] = value
-			}
-			continue
-		}
+// FlattenJSONLD flattens a JSON-LD document: all nodes are collected into a
+// single @graph array sorted by @id, nested nodes are replaced by references
+// and blank nodes get stable _:b0, _:b1, ... labels. Named graphs are kept in
+// the @graph of their graph node.
+//
+// Parameters:
+//   - doc: Document to flatten
+//   - context: Context to compact the result with (nil for expanded form)
+//   - opts: Base IRI and document loader (nil for defaults)
+//
+// Returns:
+//   - map[string]interface{}: {"@context": context, "@graph": [...]}
+//   - error: Marshaling, context loading or JSON-LD processing errors
+//
+// Example Usage:
+//
+//	flattened, err := FlattenJSONLD(doc, "https://schema.org", nil)
+//	for _, node := range flattened["@graph"].([]interface{}) {
+//	    fmt.Println(node.(map[string]interface{})["id"])
+//	}
+func FlattenJSONLD(doc interface{}, context interface{}, opts *JSONLDOptions) (map[string]interface{}, error) {
+	document, err := toJSONValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	if context, err = toJSONValue(context); err != nil {
+		return nil, err
+	}
 
-		// Keep @id as-is
-		if key == "@id" {
-			compacted["@id"] = value
-			continue
-		}
+	p := newJSONLDProcessor(opts)
+	expanded, err := p.expandDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	flattened, err := p.flattenExpanded(expanded)
+	if err != nil {
+		return nil, err
+	}
+	if context == nil {
+		return map[string]interface{}{"@graph": flattened}, nil
+	}
+	return p.compactExpanded(flattened, context, true)
+}
 
-		// Compact property names
-		compactedKey := compactTerm(key, contextPrefix)
-		compacted[compactedKey] = compactValue(value)
+// JSONLDToRDF converts a JSON-LD document to RDF quads. Properties and
+// nodes with relative IRIs cannot be represented in RDF and are dropped.
+//
+// Parameters:
+//   - doc: Document to convert
+//   - opts: Base IRI and document loader (nil for defaults)
+//
+// Returns:
+//   - []Quad: Statements of the default graph and named graphs
+//   - error: Marshaling, context loading or JSON-LD processing errors
+//
+// Example Usage:
+//
+//	quads, err := JSONLDToRDF(doc, nil)
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Print(FormatNQuads(quads))
+func JSONLDToRDF(doc interface{}, opts *JSONLDOptions) ([]Quad, error) {
+	document, err := toJSONValue(doc)
+	if err != nil {
+		return nil, err
 	}
 
-	return compacted, nil
+	p := newJSONLDProcessor(opts)
+	expanded, err := p.expandDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	return p.toRDF(expanded)
 }
 
-// NormalizeJSONLD performs basic JSON-LD normalization (canonicalization).
-// This produces a deterministic representation for comparison and hashing.
+// NormalizeJSONLD canonicalizes a JSON-LD document with the URDNA2015
+// algorithm and returns its canonical N-Quads. Documents describing the same
+// graph produce identical output regardless of key order, context, compact
+// or expanded form and blank node labels.
 //
 // Parameters:
 //   - doc: Document to normalize
 //
 // Returns:
-//   - string: Normalized JSON-LD as string
-//   - error: Normalization errors
-//
-// Normalization Process:
-//
-//	Creates canonical form:
-//	- Sorts all keys alphabetically
-//	- Removes whitespace
-//	- Ensures consistent formatting
-//	- Produces deterministic output
+//   - string: Canonical N-Quads, one sorted statement per line
+//   - error: Marshaling, context loading or JSON-LD processing errors
 //
 // Use Cases:
 //   - Document comparison and deduplication
@@ -295,101 +340,89 @@ func CompactJSONLD(doc interface{}, context string) (map[string]interface{}, err
 //	    return
 //	}
 //
-//	// normalized is deterministic string representation
-//	fmt.Printf("Hash: %x\n", sha256.Sum256([]byte(normalized)))
+//	// _:c14n0 <http://schema.org/name> "nginx" .
+//	// _:c14n0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/SoftwareApplication> .
+//	fmt.Print(normalized)
 func NormalizeJSONLD(doc interface{}) (string, error) {
-	// Convert to map
-	jsonData, err := json.Marshal(doc)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal document: %w", err)
-	}
-
-	var docMap map[string]interface{}
-	if err := json.Unmarshal(jsonData, &docMap); err != nil {
-		return "", fmt.Errorf("failed to unmarshal document: %w", err)
-	}
-
-	// Marshal with sorted keys (Go's json.Marshal sorts keys by default)
-	normalized, err := json.Marshal(docMap)
+	quads, err := JSONLDToRDF(doc, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to normalize: %w", err)
+		return "", err
 	}
-
-	return string(normalized), nil
+	return CanonicalNQuads(quads), nil
 }
 
-// expandTerm expands a term using the context.
-// Internal helper for JSON-LD expansion.
-func expandTerm(term interface{}, context string) string {
-	termStr := fmt.Sprintf("%v", term)
-
-	// If already a full IRI, return as-is
-	if strings.HasPrefix(termStr, "http://") || strings.HasPrefix(termStr, "https://") {
-		return termStr
-	}
-
-	// If context is provided, prepend it
-	if context != "" && !strings.HasPrefix(termStr, "@") {
-		return context + "/" + termStr
+// HashJSONLD returns the hex encoded SHA-256 hash of a document's canonical
+// N-Quads. Semantically equivalent documents have the same hash, which makes
+// it suitable as a content address or de-duplication key.
+//
+// Parameters:
+//   - doc: Document to hash
+//
+// Returns:
+//   - string: 64 character hex digest
+//   - error: Normalization errors
+//
+// Example Usage:
+//
+//	hash, err := HashJSONLD(doc)
+//	if err != nil {
+//	    return err
+//	}
+//	doc["identifier"] = "sha256:" + hash
+func HashJSONLD(doc interface{}) (string, error) {
+	normalized, err := NormalizeJSONLD(doc)
+	if err != nil {
+		return "", err
 	}
-
-	return termStr
+	return sha256Hex(normalized), nil
 }
 
-// expandValue expands a value to JSON-LD format.
-// Internal helper for JSON-LD expansion.
-func expandValue(value interface{}) interface{} {
-	// Arrays stay as arrays
-	if arr, ok := value.([]interface{}); ok {
-		expanded := make([]interface{}, len(arr))
-		for i, item := range arr {
-			expanded[i] = expandValue(item)
+// DeduplicateJSONLD removes semantically duplicate documents, keeping the
+// first document of every group with equal canonical N-Quads.
+//
+// Parameters:
+//   - docs: Documents to de-duplicate
+//
+// Returns:
+//   - []interface{}: The unique documents in their original order
+//   - error: Normalization errors, naming the index of the failing document
+//
+// Example Usage:
+//
+//	unique, err := DeduplicateJSONLD(docs)
+//	fmt.Printf("removed %d duplicates\n", len(docs)-len(unique))
+func DeduplicateJSONLD(docs []interface{}) ([]interface{}, error) {
+	seen := make(map[string]bool, len(docs))
+	unique := make([]interface{}, 0, len(docs))
+	for i, doc := range docs {
+		hash, err := HashJSONLD(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
 		}
-		return expanded
-	}
-
-	// Objects stay as objects (recursive expansion)
-	if obj, ok := value.(map[string]interface{}); ok {
-		expanded := make(map[string]interface{})
-		for k, v := range obj {
-			expanded[k] = expandValue(v)
+		if !seen[hash] {
+			seen[hash] = true
+			unique = append(unique, doc)
 		}
-		return expanded
-	}
-
-	// Simple values become value objects
-	return []interface{}{
-		map[string]interface{}{
-			"@value": value,
-		},
 	}
+	return unique, nil
 }
 
-// compactTerm compacts a full IRI to a short term.
-// Internal helper for JSON-LD compaction.
-func compactTerm(iri string, contextPrefix string) string {
-	// Remove context prefix if present
-	if strings.HasPrefix(iri, contextPrefix) {
-		return strings.TrimPrefix(iri, contextPrefix)
+// toJSONValue converts a document to its generic JSON representation.
+// Internal helper for JSON-LD processing.
+func toJSONValue(doc interface{}) (interface{}, error) {
+	if doc == nil {
+		return nil, nil
 	}
-	return iri
-}
-
-// compactValue compacts a value from expanded form.
-// Internal helper for JSON-LD compaction.
-func compactValue(value interface{}) interface{} {
-	// Check if it's a value object array
-	if arr, ok := value.([]interface{}); ok {
-		if len(arr) == 1 {
-			if valueObj, ok := arr[0].(map[string]interface{}); ok {
-				if val, ok := valueObj["@value"]; ok {
-					return val
-				}
-			}
-		}
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
 	}
 
-	return value
+	var value interface{}
+	if err := json.Unmarshal(jsonData, &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal document: %w", err)
+	}
+	return value, nil
 }
 
 // ExtractJSONLDType extracts the @type value from a JSON-LD document.
@@ -110,6 +110,20 @@ func TestMangoQuery_toParams(t *testing.T) {
 		assert.Equal(t, 25, params["limit"])
 	})
 
+	t.Run("bookmark and execution stats", func(t *testing.T) {
+		query := MangoQuery{
+			Bookmark:       "g1AAAA",
+			ExecutionStats: true,
+			UseIndex:       "_design/status-ddoc/status-index",
+		}
+
+		params := query.toParams()
+
+		assert.Equal(t, "g1AAAA", params["bookmark"])
+		assert.Equal(t, true, params["execution_stats"])
+		assert.Equal(t, []string{"status-ddoc", "status-index"}, params["use_index"])
+	})
+
 	t.Run("zero limit uses default", func(t *testing.T) {
 		query := MangoQuery{
 			Selector: map[string]interface{}{"status": "active"},
@@ -261,6 +275,17 @@ func TestQueryBuilder(t *testing.T) {
 		assert.Equal(t, "status-index", query.UseIndex)
 	})
 
+	t.Run("bookmark and execution stats", func(t *testing.T) {
+		query := NewQueryBuilder().
+			Where("status", "eq", "active").
+			Bookmark("g1AAAA").
+			ExecutionStats().
+			Build()
+
+		assert.Equal(t, "g1AAAA", query.Bookmark)
+		assert.True(t, query.ExecutionStats)
+	})
+
 	t.Run("complex query", func(t *testing.T) {
 		query := NewQueryBuilder().
 			Where("@type", "eq", "SoftwareApplication").
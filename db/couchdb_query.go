@@ -4,10 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 
 	kivik "github.com/go-kivik/kivik/v4"
 )
 
+// DefaultFindPageSize is the page size FindIter uses when the query has no Limit
+const DefaultFindPageSize = 100
+
 // Find executes a Mango query and returns results as json.RawMessage.
 // Mango queries provide MongoDB-style declarative filtering without MapReduce views.
 //
@@ -183,6 +190,224 @@ func FindTyped[T any](c *CouchDBService, query MangoQuery) ([]T, error) {
 	return results, nil
 }
 
+// FindPage executes a Mango query and returns one page of results with its
+// bookmark, warnings and, if requested, execution statistics.
+// Pass the returned bookmark as query.Bookmark to fetch the next page.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - query: MangoQuery; Limit is the page size
+//
+// Returns:
+//   - *FindResult: Documents, bookmark, warning and execution statistics
+//   - error: CouchDBError for HTTP errors, or request errors
+//
+// Example Usage:
+//
+//	query := NewQueryBuilder().Where("status", "eq", "running").Limit(100).ExecutionStats().Build()
+//	for {
+//	    page, err := service.FindPage(ctx, query)
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    for _, warning := range page.Warnings() {
+//	        log.Printf("query warning: %s", warning)
+//	    }
+//	    process(page.Docs)
+//	    if len(page.Docs) < query.Limit {
+//	        break
+//	    }
+//	    query.Bookmark = page.Bookmark
+//	}
+func (c *CouchDBService) FindPage(ctx context.Context, query MangoQuery) (*FindResult, error) {
+	var result FindResult
+	path := "/" + url.PathEscape(c.dbName) + "/_find"
+	if err := c.serverRequest(ctx, http.MethodPost, path, query.toRequest(), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Explain returns CouchDB's query plan for a Mango query, showing the index
+// that is actually used. This is the way to verify a QueryBuilder.UseIndex
+// hint, since CouchDB silently falls back to another index or a full scan.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - query: MangoQuery to explain
+//
+// Returns:
+//   - *QueryExplanation: Selected index, range and options of the query
+//   - error: CouchDBError for HTTP errors, or request errors
+//
+// Example Usage:
+//
+//	query := NewQueryBuilder().Where("status", "eq", "running").UseIndex("status-index").Build()
+//	plan, err := service.Explain(ctx, query)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if !plan.UsesIndex(query.UseIndex) {
+//	    log.Printf("query uses %s/%s instead", plan.Index.DDoc, plan.Index.Name)
+//	}
+func (c *CouchDBService) Explain(ctx context.Context, query MangoQuery) (*QueryExplanation, error) {
+	var explanation QueryExplanation
+	path := "/" + url.PathEscape(c.dbName) + "/_explain"
+	if err := c.serverRequest(ctx, http.MethodPost, path, query.toRequest(), &explanation); err != nil {
+		return nil, err
+	}
+	return &explanation, nil
+}
+
+// FindIterator iterates over all results of a Mango query, fetching pages
+// on demand and following bookmarks. Create it with FindIter.
+type FindIterator[T any] struct {
+	c        *CouchDBService
+	ctx      context.Context
+	query    MangoQuery
+	pageSize int
+
+	page     []json.RawMessage
+	pos      int
+	current  T
+	done     bool
+	err      error
+	warnings []string
+	stats    *ExecutionStats
+	pages    int
+}
+
+// FindIter returns an iterator over all documents matching query.
+// Pages of query.Limit documents (DefaultFindPageSize if not set) are
+// requested one at a time; each page continues from the bookmark of the
+// previous one, so large result sets are read without Skip.
+//
+// Type Parameter:
+//   - T: Expected document type
+//
+// Parameters:
+//   - ctx: Context for cancellation, checked before each page request
+//   - c: CouchDBService instance
+//   - query: MangoQuery; Skip applies to the first page only
+//
+// Returns:
+//   - *FindIterator[T]: Iterator; call Next until it returns false, then Err
+//
+// Example Usage:
+//
+//	it := FindIter[Action](ctx, service, NewQueryBuilder().
+//	    Where("@type", "eq", "Action").
+//	    Limit(200).
+//	    Build())
+//	for it.Next() {
+//	    action := it.Doc()
+//	    fmt.Println(action.ID)
+//	}
+//	if err := it.Err(); err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, warning := range it.Warnings() {
+//	    log.Printf("query warning: %s", warning)
+//	}
+func FindIter[T any](ctx context.Context, c *CouchDBService, query MangoQuery) *FindIterator[T] {
+	pageSize := query.Limit
+	if pageSize <= 0 {
+		pageSize = DefaultFindPageSize
+	}
+	query.Limit = pageSize
+	return &FindIterator[T]{c: c, ctx: ctx, query: query, pageSize: pageSize}
+}
+
+// Next advances to the next document, fetching the next page when the
+// current one is exhausted. It returns false when all documents have been
+// read or an error occurred.
+func (it *FindIterator[T]) Next() bool {
+	for it.pos >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+	}
+
+	var doc T
+	if err := json.Unmarshal(it.page[it.pos], &doc); err != nil {
+		it.err = fmt.Errorf("failed to scan document: %w", err)
+		return false
+	}
+	it.pos++
+	it.current = doc
+	return true
+}
+
+// fetch requests the next page
+func (it *FindIterator[T]) fetch() error {
+	if err := it.ctx.Err(); err != nil {
+		return err
+	}
+
+	page, err := it.c.FindPage(it.ctx, it.query)
+	if err != nil {
+		return err
+	}
+	it.pages++
+	it.page, it.pos = page.Docs, 0
+
+	for _, warning := range page.Warnings() {
+		if !slices.Contains(it.warnings, warning) {
+			it.warnings = append(it.warnings, warning)
+		}
+	}
+	if page.ExecutionStats != nil {
+		if it.stats == nil {
+			it.stats = &ExecutionStats{}
+		}
+		it.stats.add(page.ExecutionStats)
+	}
+
+	// A short page is the last one; an unchanged bookmark would repeat it
+	if len(page.Docs) < it.pageSize || page.Bookmark == "" || page.Bookmark == it.query.Bookmark {
+		it.done = true
+	}
+	it.query.Bookmark = page.Bookmark
+	it.query.Skip = 0
+	return nil
+}
+
+// Doc returns the current document
+func (it *FindIterator[T]) Doc() T {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *FindIterator[T]) Err() error {
+	return it.err
+}
+
+// Bookmark returns the bookmark after the last fetched page, which resumes
+// the query in a new iterator
+func (it *FindIterator[T]) Bookmark() string {
+	return it.query.Bookmark
+}
+
+// Warnings returns the distinct warnings of all fetched pages
+func (it *FindIterator[T]) Warnings() []string {
+	return it.warnings
+}
+
+// Stats returns the accumulated execution statistics of all fetched pages,
+// or nil if the query did not request them
+func (it *FindIterator[T]) Stats() *ExecutionStats {
+	return it.stats
+}
+
+// Pages returns the number of pages fetched so far
+func (it *FindIterator[T]) Pages() int {
+	return it.pages
+}
+
 // toParams converts MangoQuery to Kivik parameters.
 // This internal helper method converts query options to CouchDB parameters.
 func (q *MangoQuery) toParams() map[string]interface{} {
@@ -206,12 +431,38 @@ func (q *MangoQuery) toParams() map[string]interface{} {
 		params["skip"] = q.Skip
 	}
 	if q.UseIndex != "" {
-		params["use_index"] = q.UseIndex
+		params["use_index"] = useIndexParam(q.UseIndex)
+	}
+	if q.Bookmark != "" {
+		params["bookmark"] = q.Bookmark
+	}
+	if q.ExecutionStats {
+		params["execution_stats"] = true
 	}
 
 	return params
 }
 
+// toRequest builds the _find request body of the query
+func (q *MangoQuery) toRequest() map[string]interface{} {
+	request := q.toParams()
+	request["selector"] = q.Selector
+	if q.Selector == nil {
+		request["selector"] = map[string]interface{}{}
+	}
+	return request
+}
+
+// useIndexParam converts an index hint into the use_index parameter.
+// CouchDB reads a plain string as a design document name, so a
+// "<design document>/<index name>" hint is sent as a two element array.
+func useIndexParam(hint string) interface{} {
+	if ddoc, name, ok := strings.Cut(strings.TrimPrefix(hint, "_design/"), "/"); ok && ddoc != "" && name != "" {
+		return []string{ddoc, name}
+	}
+	return hint
+}
+
 // QueryBuilder provides a fluent API for constructing complex Mango queries.
 // This builder pattern simplifies query construction with method chaining.
 //
@@ -234,6 +485,8 @@ type QueryBuilder struct {
 	limitValue     int
 	skipValue      int
 	useIndexValue  string
+	bookmarkValue  string
+	executionStats bool
 	currentCondSet []map[string]interface{} // Current set of conditions
 }
 
@@ -454,7 +707,10 @@ func (qb *QueryBuilder) Skip(n int) *QueryBuilder {
 // Improves performance by explicitly selecting an index.
 //
 // Parameters:
-//   - indexName: Name of the index to use
+//   - indexName: Design document of the index, or "<design document>/<index name>"
+//
+// CouchDB ignores hints that match no usable index and answers with a
+// warning; use Explain to check which index a query actually uses.
 //
 // Returns:
 //   - *QueryBuilder: Builder instance for method chaining
@@ -467,6 +723,38 @@ func (qb *QueryBuilder) UseIndex(indexName string) *QueryBuilder {
 	return qb
 }
 
+// Bookmark continues the query after a previous page.
+// Bookmarks replace Skip for paging through large result sets.
+//
+// Parameters:
+//   - bookmark: Bookmark returned with the previous page (FindResult.Bookmark)
+//
+// Returns:
+//   - *QueryBuilder: Builder instance for method chaining
+//
+// Example Usage:
+//
+//	page, _ := service.FindPage(ctx, qb.Limit(100).Build())
+//	next, _ := service.FindPage(ctx, qb.Bookmark(page.Bookmark).Build())
+func (qb *QueryBuilder) Bookmark(bookmark string) *QueryBuilder {
+	qb.bookmarkValue = bookmark
+	return qb
+}
+
+// ExecutionStats requests execution statistics with the query results.
+// The statistics are returned in FindResult.ExecutionStats.
+//
+// Returns:
+//   - *QueryBuilder: Builder instance for method chaining
+//
+// Example Usage:
+//
+//	qb.Where("status", "eq", "running").ExecutionStats()
+func (qb *QueryBuilder) ExecutionStats() *QueryBuilder {
+	qb.executionStats = true
+	return qb
+}
+
 // Build constructs the final MangoQuery from the builder.
 // Returns a MangoQuery ready for execution.
 //
@@ -520,6 +808,9 @@ func (qb *QueryBuilder) Build() MangoQuery {
 		Limit:    qb.limitValue,
 		Skip:     qb.skipValue,
 		UseIndex: qb.useIndexValue,
+
+		Bookmark:       qb.bookmarkValue,
+		ExecutionStats: qb.executionStats,
 	}
 
 	return query
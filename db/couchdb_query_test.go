@@ -0,0 +1,231 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFindServer serves _find pages with numeric bookmarks and _explain plans
+type fakeFindServer struct {
+	mu       sync.Mutex
+	docs     []map[string]interface{}
+	requests []map[string]interface{}
+}
+
+func newFakeFindServer(t *testing.T, count int) (*fakeFindServer, *CouchDBService) {
+	t.Helper()
+	fake := &fakeFindServer{}
+	for i := 0; i < count; i++ {
+		fake.docs = append(fake.docs, map[string]interface{}{"_id": fmt.Sprintf("action-%02d", i), "name": fmt.Sprintf("Action %d", i)})
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, httpClient, err := newCouchDBClient(server.URL)
+	require.NoError(t, err)
+	return fake, &CouchDBService{client: client, database: client.DB("testdb"), dbName: "testdb", httpClient: httpClient}
+}
+
+func (f *fakeFindServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+
+	var request map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"bad_request","reason":"invalid request"}`)
+		return
+	}
+	f.requests = append(f.requests, request)
+
+	switch r.URL.Path {
+	case "/testdb/_explain":
+		fmt.Fprint(w, `{"dbname":"testdb","index":{"ddoc":null,"name":"_all_docs","type":"special","def":{"fields":[{"_id":"asc"}]}},"selector":{},"opts":{},"limit":25,"skip":0,"fields":"all_fields","range":{}}`)
+	case "/testdb/_find":
+		start := 0
+		if bookmark, ok := request["bookmark"].(string); ok {
+			start, _ = strconv.Atoi(bookmark)
+		}
+		if skip, ok := request["skip"].(float64); ok {
+			start += int(skip)
+		}
+		end := start + int(request["limit"].(float64))
+		if end > len(f.docs) {
+			end = len(f.docs)
+		}
+		if start > end {
+			start = end
+		}
+		page := f.docs[start:end]
+		response := map[string]interface{}{
+			"docs":     page,
+			"bookmark": strconv.Itoa(end),
+			"warning":  "No matching index found, create an index to optimize query time.",
+		}
+		if request["execution_stats"] == true {
+			response["execution_stats"] = map[string]interface{}{
+				"total_docs_examined": len(f.docs),
+				"results_returned":    len(page),
+				"execution_time_ms":   1.5,
+			}
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"not_found","reason":"missing"}`)
+	}
+}
+
+type findTestDoc struct {
+	ID   string `json:"_id"`
+	Name string `json:"name"`
+}
+
+func TestFindPage(t *testing.T) {
+	_, service := newFakeFindServer(t, 5)
+
+	query := NewQueryBuilder().Where("@type", "eq", "Action").Limit(3).ExecutionStats().Build()
+	page, err := service.FindPage(context.Background(), query)
+	require.NoError(t, err)
+	assert.Len(t, page.Docs, 3)
+	assert.Equal(t, "3", page.Bookmark)
+	assert.Equal(t, []string{"No matching index found, create an index to optimize query time."}, page.Warnings())
+	require.NotNil(t, page.ExecutionStats)
+	assert.Equal(t, 5, page.ExecutionStats.TotalDocsExamined)
+
+	query.Bookmark = page.Bookmark
+	page, err = service.FindPage(context.Background(), query)
+	require.NoError(t, err)
+	assert.Len(t, page.Docs, 2)
+}
+
+func TestFindIter(t *testing.T) {
+	fake, service := newFakeFindServer(t, 8)
+
+	it := FindIter[findTestDoc](context.Background(), service, MangoQuery{Limit: 3, Skip: 1, ExecutionStats: true})
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Doc().ID)
+	}
+	require.NoError(t, it.Err())
+
+	assert.Len(t, ids, 7)
+	assert.Equal(t, "action-01", ids[0])
+	assert.Equal(t, "action-07", ids[6])
+	assert.Equal(t, 3, it.Pages(), "a short page ends the iteration")
+	assert.Equal(t, "8", it.Bookmark())
+	assert.Len(t, it.Warnings(), 1, "warnings are de-duplicated across pages")
+	require.NotNil(t, it.Stats())
+	assert.Equal(t, 7, it.Stats().ResultsReturned)
+	assert.NotContains(t, fake.requests[1], "skip", "skip applies to the first page only")
+
+	t.Run("default page size and exact multiple", func(t *testing.T) {
+		_, service := newFakeFindServer(t, 4)
+		it := FindIter[findTestDoc](context.Background(), service, MangoQuery{Limit: 2})
+		count := 0
+		for it.Next() {
+			count++
+		}
+		require.NoError(t, it.Err())
+		assert.Equal(t, 4, count)
+		assert.Equal(t, 3, it.Pages(), "an empty page ends the iteration")
+
+		it = FindIter[findTestDoc](context.Background(), service, MangoQuery{})
+		assert.Equal(t, DefaultFindPageSize, it.pageSize)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		it := FindIter[findTestDoc](ctx, service, MangoQuery{})
+		assert.False(t, it.Next())
+		assert.ErrorIs(t, it.Err(), context.Canceled)
+	})
+}
+
+func TestExplain(t *testing.T) {
+	fake, service := newFakeFindServer(t, 0)
+
+	query := NewQueryBuilder().Where("status", "eq", "running").UseIndex("status-index").Build()
+	plan, err := service.Explain(context.Background(), query)
+	require.NoError(t, err)
+	assert.True(t, plan.IsFullScan())
+	assert.False(t, plan.UsesIndex(query.UseIndex))
+	assert.Equal(t, "_all_docs", plan.Index.Name)
+	assert.Equal(t, "status-index", fake.requests[0]["use_index"])
+
+	indexed := QueryExplanation{Index: ExplainedIndex{DDoc: "_design/a1b2", Name: "status-index", Type: "json"}}
+	assert.False(t, indexed.IsFullScan())
+	assert.True(t, indexed.UsesIndex("status-index"))
+	assert.True(t, indexed.UsesIndex("a1b2"))
+	assert.True(t, indexed.UsesIndex("_design/a1b2/status-index"))
+	assert.False(t, indexed.UsesIndex("other"))
+}
+
+func TestServerRequestSharesKivikSession(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		sessions int
+		auth     []string // How each _find request authenticated
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/_session":
+			sessions++
+			http.SetCookie(w, &http.Cookie{Name: "AuthSession", Value: "token", Path: "/"})
+			fmt.Fprint(w, `{"ok":true,"name":"admin","roles":["_admin"]}`)
+		case "/testdb":
+			fmt.Fprint(w, `{"db_name":"testdb"}`)
+		case "/testdb/_find":
+			if cookie, err := r.Cookie("AuthSession"); err == nil && cookie.Value == "token" {
+				auth = append(auth, "cookie")
+			} else if user, _, ok := r.BasicAuth(); ok {
+				auth = append(auth, "basic:"+user)
+			} else {
+				auth = append(auth, "none")
+			}
+			fmt.Fprint(w, `{"docs":[],"bookmark":"nil"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"not_found","reason":"missing"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, httpClient, err := newCouchDBClient(strings.Replace(server.URL, "http://", "http://admin:secret@", 1))
+	require.NoError(t, err)
+	service := &CouchDBService{client: client, database: client.DB("testdb"), dbName: "testdb", httpClient: httpClient}
+	ctx := context.Background()
+	query := NewQueryBuilder().Where("@type", "eq", "Action").Build()
+
+	// Before kivik has a session, the DSN credentials are sent
+	_, err = service.FindPage(ctx, query)
+	require.NoError(t, err)
+
+	exists, err := client.DBExists(ctx, "testdb")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// Afterwards the session cookie kivik obtained is reused
+	_, err = service.FindPage(ctx, query)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, sessions)
+	assert.Equal(t, []string{"basic:admin", "cookie"}, auth)
+}
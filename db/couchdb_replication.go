@@ -0,0 +1,524 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	kivik "github.com/go-kivik/kivik/v4"
+)
+
+// ReplicatorDatabase is the database watched by the CouchDB replication scheduler
+const ReplicatorDatabase = "_replicator"
+
+// DefaultReplicationPollInterval is the WaitForReplication polling interval
+const DefaultReplicationPollInterval = 2 * time.Second
+
+// Replication states reported by the CouchDB scheduler
+const (
+	ReplicationStateInitializing = "initializing"
+	ReplicationStatePending      = "pending"
+	ReplicationStateRunning      = "running"
+	ReplicationStateCrashing     = "crashing"
+	ReplicationStateError        = "error"
+	ReplicationStateFailed       = "failed"
+	ReplicationStateCompleted    = "completed"
+)
+
+// ErrReplicationFailed is returned by WaitForReplication when a replication fails
+var ErrReplicationFailed = errors.New("replication failed")
+
+// ReplicationEndpoint is the source or target of a replication.
+//
+// URL is either a full database URL or the name of a database on the service's
+// own server. Credentials are taken from Username/Password, or else from the
+// URL's userinfo; local databases use the service's credentials. They are
+// always stored as basic auth in the replication document, never in the URL.
+type ReplicationEndpoint struct {
+	URL      string            `json:"url"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+// ReplicationConfig describes a replication managed through the _replicator database.
+//
+// Configuration Options:
+//   - ID: Replication document ID (optional, generated by CouchDB if empty)
+//   - Source, Target: Replication endpoints
+//   - Continuous: Keep replicating new changes instead of stopping when caught up
+//   - CreateTarget: Create the target database if it does not exist
+//   - Selector: Mango selector limiting the replicated documents
+//   - DocIDs: Replicate only these documents
+//   - Filter, QueryParams: Design document filter function and its parameters
+//   - SinceSeq: Source sequence to start from (default: beginning of the feed)
+//
+// Selector, DocIDs and Filter are mutually exclusive.
+//
+// Example Usage:
+//
+//	rep, err := service.CreateReplication(ctx, ReplicationConfig{
+//	    ID:         "flows-to-staging",
+//	    Source:     ReplicationEndpoint{URL: "flows"}, // Database on the same server
+//	    Target:     ReplicationEndpoint{URL: "https://staging.example.com/flows", Username: "rep", Password: "secret"},
+//	    Continuous: true,
+//	    Selector:   map[string]interface{}{"state": "completed"},
+//	})
+type ReplicationConfig struct {
+	ID           string                 `json:"id,omitempty"`
+	Source       ReplicationEndpoint    `json:"source"`
+	Target       ReplicationEndpoint    `json:"target"`
+	Continuous   bool                   `json:"continuous,omitempty"`
+	CreateTarget bool                   `json:"create_target,omitempty"`
+	Selector     map[string]interface{} `json:"selector,omitempty"`
+	DocIDs       []string               `json:"doc_ids,omitempty"`
+	Filter       string                 `json:"filter,omitempty"`
+	QueryParams  map[string]string      `json:"query_params,omitempty"`
+	SinceSeq     string                 `json:"since_seq,omitempty"`
+}
+
+// Replication is a replication document from the _replicator database.
+// Source and Target have their credentials removed.
+type Replication struct {
+	ID             string                 `json:"id"`
+	Rev            string                 `json:"rev"`
+	Source         string                 `json:"source"`
+	Target         string                 `json:"target"`
+	HasCredentials bool                   `json:"has_credentials"`
+	Continuous     bool                   `json:"continuous"`
+	CreateTarget   bool                   `json:"create_target"`
+	Selector       map[string]interface{} `json:"selector,omitempty"`
+	DocIDs         []string               `json:"doc_ids,omitempty"`
+	Filter         string                 `json:"filter,omitempty"`
+	State          string                 `json:"state,omitempty"`
+	StateReason    string                 `json:"state_reason,omitempty"`
+	StateTime      string                 `json:"state_time,omitempty"`
+}
+
+// ReplicationStatus is the scheduler state of a replication document,
+// read from _scheduler/docs
+type ReplicationStatus struct {
+	DocID         string           `json:"doc_id"`
+	Database      string           `json:"database"`
+	ReplicationID string           `json:"id"`
+	Source        string           `json:"source"`
+	Target        string           `json:"target"`
+	State         string           `json:"state"`
+	StartTime     *time.Time       `json:"start_time,omitempty"`
+	LastUpdated   *time.Time       `json:"last_updated,omitempty"`
+	ErrorCount    int              `json:"error_count"`
+	Error         string           `json:"error,omitempty"`
+	Stats         ReplicationStats `json:"stats"`
+}
+
+// ReplicationStats are the document counters of a replication
+type ReplicationStats struct {
+	DocsRead              int64 `json:"docs_read"`
+	DocsWritten           int64 `json:"docs_written"`
+	DocWriteFailures      int64 `json:"doc_write_failures"`
+	ChangesPending        int64 `json:"changes_pending"`
+	MissingRevisionsFound int64 `json:"missing_revisions_found"`
+	RevisionsChecked      int64 `json:"revisions_checked"`
+}
+
+// ReplicationJob is a replication currently scheduled on the cluster,
+// read from _scheduler/jobs
+type ReplicationJob struct {
+	ReplicationID string                `json:"id"`
+	Database      string                `json:"database"`
+	DocID         string                `json:"doc_id"`
+	Source        string                `json:"source"`
+	Target        string                `json:"target"`
+	User          string                `json:"user"`
+	Node          string                `json:"node"`
+	StartTime     *time.Time            `json:"start_time,omitempty"`
+	History       []ReplicationJobEvent `json:"history"`
+}
+
+// ReplicationJobEvent is an entry of a replication job's history
+type ReplicationJobEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// IsTerminal reports whether the replication has stopped for good
+func (s *ReplicationStatus) IsTerminal() bool {
+	return s.State == ReplicationStateCompleted || s.State == ReplicationStateFailed
+}
+
+// UnmarshalJSON decodes a scheduler doc, whose info field holds either
+// counters or an error
+func (s *ReplicationStatus) UnmarshalJSON(data []byte) error {
+	type status ReplicationStatus
+	var raw struct {
+		status
+		Info json.RawMessage `json:"info"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = ReplicationStatus(raw.status)
+
+	info := bytes.TrimSpace(raw.Info)
+	if len(info) == 0 || string(info) == "null" {
+		return nil
+	}
+	var withError struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if info[0] == '{' {
+		if err := json.Unmarshal(info, &withError); err != nil {
+			return err
+		}
+		if withError.Error == nil {
+			return json.Unmarshal(info, &s.Stats)
+		}
+		info = withError.Error
+	}
+	var message string
+	if err := json.Unmarshal(info, &message); err != nil {
+		message = string(info)
+	}
+	s.Error = message
+	return nil
+}
+
+// replicationDoc is the document stored in the _replicator database
+type replicationDoc struct {
+	ID           string                 `json:"_id,omitempty"`
+	Rev          string                 `json:"_rev,omitempty"`
+	Source       replicationTarget      `json:"source"`
+	Target       replicationTarget      `json:"target"`
+	Continuous   bool                   `json:"continuous,omitempty"`
+	CreateTarget bool                   `json:"create_target,omitempty"`
+	Selector     map[string]interface{} `json:"selector,omitempty"`
+	DocIDs       []string               `json:"doc_ids,omitempty"`
+	Filter       string                 `json:"filter,omitempty"`
+	QueryParams  map[string]string      `json:"query_params,omitempty"`
+	SinceSeq     string                 `json:"since_seq,omitempty"`
+
+	// Maintained by CouchDB
+	State       string `json:"_replication_state,omitempty"`
+	StateReason string `json:"_replication_state_reason,omitempty"`
+	StateTime   string `json:"_replication_state_time,omitempty"`
+}
+
+// replicationTarget is a source or target in a replication document
+type replicationTarget struct {
+	URL     string            `json:"url"`
+	Auth    *replicationAuth  `json:"auth,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type replicationAuth struct {
+	Basic *replicationBasicAuth `json:"basic,omitempty"`
+}
+
+type replicationBasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// UnmarshalJSON also accepts the legacy plain URL form
+func (t *replicationTarget) UnmarshalJSON(data []byte) error {
+	var plain string
+	if err := json.Unmarshal(data, &plain); err == nil {
+		*t = replicationTarget{URL: plain}
+		return nil
+	}
+	type target replicationTarget
+	return json.Unmarshal(data, (*target)(t))
+}
+
+// CreateReplication starts a replication by writing a document to the
+// _replicator database. The scheduler picks it up asynchronously; use
+// ReplicationStatus or WaitForReplication to follow it.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - config: Replication source, target and options
+//
+// Returns:
+//   - *Replication: The stored replication, without credentials
+//   - error: Validation errors, or a CouchDBError (409 if the ID is already in use)
+func (c *CouchDBService) CreateReplication(ctx context.Context, config ReplicationConfig) (*Replication, error) {
+	doc, err := c.replicationDoc(config)
+	if err != nil {
+		return nil, err
+	}
+
+	replicator := c.client.DB(ReplicatorDatabase)
+	if doc.ID == "" {
+		doc.ID, doc.Rev, err = replicator.CreateDoc(ctx, doc)
+	} else {
+		doc.Rev, err = replicator.Put(ctx, doc.ID, doc)
+	}
+	if err != nil {
+		return nil, wrapCouchDBError(err, "failed to create replication")
+	}
+
+	return doc.replication(), nil
+}
+
+// ListReplications returns all replications of the _replicator database,
+// without credentials
+func (c *CouchDBService) ListReplications(ctx context.Context) ([]Replication, error) {
+	rows := c.client.DB(ReplicatorDatabase).AllDocs(ctx, kivik.Param("include_docs", true))
+	defer rows.Close()
+
+	replications := []Replication{}
+	for rows.Next() {
+		id, err := rows.ID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read replication ID: %w", err)
+		}
+		if strings.HasPrefix(id, "_design/") {
+			continue
+		}
+		var doc replicationDoc
+		if err := rows.ScanDoc(&doc); err != nil {
+			return nil, fmt.Errorf("failed to scan replication %s: %w", id, err)
+		}
+		replications = append(replications, *doc.replication())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapCouchDBError(err, "failed to list replications")
+	}
+	return replications, nil
+}
+
+// GetReplication returns a replication document, without credentials
+func (c *CouchDBService) GetReplication(ctx context.Context, id string) (*Replication, error) {
+	var doc replicationDoc
+	if err := c.client.DB(ReplicatorDatabase).Get(ctx, id).ScanDoc(&doc); err != nil {
+		return nil, wrapCouchDBError(err, "failed to get replication")
+	}
+	return doc.replication(), nil
+}
+
+// CancelReplication stops a replication by deleting its document.
+// Completed one-shot replications are removed the same way.
+func (c *CouchDBService) CancelReplication(ctx context.Context, id string) error {
+	replicator := c.client.DB(ReplicatorDatabase)
+	rev, err := replicator.GetRev(ctx, id)
+	if err != nil {
+		return wrapCouchDBError(err, "failed to cancel replication")
+	}
+	if _, err := replicator.Delete(ctx, id, rev); err != nil {
+		return wrapCouchDBError(err, "failed to cancel replication")
+	}
+	return nil
+}
+
+// ReplicationStatus returns the scheduler state of a replication document.
+// The scheduler may take a moment to report a newly created replication,
+// in which case a not found CouchDBError is returned.
+func (c *CouchDBService) ReplicationStatus(ctx context.Context, id string) (*ReplicationStatus, error) {
+	var status ReplicationStatus
+	path := "/_scheduler/docs/" + ReplicatorDatabase + "/" + url.PathEscape(id)
+	if err := c.serverRequest(ctx, http.MethodGet, path, nil, &status); err != nil {
+		return nil, err
+	}
+	status.Source = redactURL(status.Source)
+	status.Target = redactURL(status.Target)
+	return &status, nil
+}
+
+// ReplicationJobs returns the replications currently scheduled on the server
+func (c *CouchDBService) ReplicationJobs(ctx context.Context) ([]ReplicationJob, error) {
+	var result struct {
+		Jobs []ReplicationJob `json:"jobs"`
+	}
+	if err := c.serverRequest(ctx, http.MethodGet, "/_scheduler/jobs", nil, &result); err != nil {
+		return nil, err
+	}
+	for i := range result.Jobs {
+		result.Jobs[i].Source = redactURL(result.Jobs[i].Source)
+		result.Jobs[i].Target = redactURL(result.Jobs[i].Target)
+	}
+	if result.Jobs == nil {
+		result.Jobs = []ReplicationJob{}
+	}
+	return result.Jobs, nil
+}
+
+// WaitForReplication polls the scheduler until a one-shot replication has
+// completed, or a continuous replication is running with no pending changes.
+//
+// Parameters:
+//   - ctx: Bounds the wait; use a deadline for replications that may never finish
+//   - id: Replication document ID
+//   - interval: Polling interval (default: 2s)
+//
+// Returns:
+//   - *ReplicationStatus: The last status seen
+//   - error: ErrReplicationFailed for failed replications, or ctx.Err()
+//
+// Example Usage:
+//
+//	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+//	defer cancel()
+//	status, err := service.WaitForReplication(ctx, "flows-backup", 0)
+//	if errors.Is(err, ErrReplicationFailed) {
+//	    log.Printf("replication failed: %s", status.Error)
+//	}
+func (c *CouchDBService) WaitForReplication(ctx context.Context, id string, interval time.Duration) (*ReplicationStatus, error) {
+	if interval <= 0 {
+		interval = DefaultReplicationPollInterval
+	}
+
+	var last *ReplicationStatus
+	for {
+		status, err := c.ReplicationStatus(ctx, id)
+		var couchErr *CouchDBError
+		switch {
+		case errors.As(err, &couchErr) && couchErr.IsNotFound():
+			// Not picked up by the scheduler yet
+		case err != nil:
+			return last, err
+		default:
+			last = status
+			switch {
+			case status.State == ReplicationStateFailed:
+				return status, fmt.Errorf("%w: %s: %s", ErrReplicationFailed, id, status.Error)
+			case status.State == ReplicationStateCompleted:
+				return status, nil
+			case status.State == ReplicationStateRunning && status.Stats.ChangesPending == 0 && c.isContinuous(ctx, id):
+				return status, nil
+			}
+		}
+
+		if !sleepContext(ctx, interval) {
+			return last, ctx.Err()
+		}
+	}
+}
+
+// isContinuous reports whether a replication document is continuous
+func (c *CouchDBService) isContinuous(ctx context.Context, id string) bool {
+	rep, err := c.GetReplication(ctx, id)
+	return err == nil && rep.Continuous
+}
+
+// replicationDoc validates a config and builds its replication document
+func (c *CouchDBService) replicationDoc(config ReplicationConfig) (*replicationDoc, error) {
+	if config.Source.URL == "" || config.Target.URL == "" {
+		return nil, fmt.Errorf("replication source and target are required")
+	}
+	filters := 0
+	if len(config.Selector) > 0 {
+		filters++
+	}
+	if len(config.DocIDs) > 0 {
+		filters++
+	}
+	if config.Filter != "" {
+		filters++
+	}
+	if filters > 1 {
+		return nil, fmt.Errorf("selector, doc_ids and filter are mutually exclusive")
+	}
+	if strings.HasPrefix(config.ID, "_") {
+		return nil, fmt.Errorf("invalid replication ID %q", config.ID)
+	}
+
+	source, err := c.replicationTarget(config.Source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid replication source: %w", err)
+	}
+	target, err := c.replicationTarget(config.Target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid replication target: %w", err)
+	}
+
+	return &replicationDoc{
+		ID:           config.ID,
+		Source:       source,
+		Target:       target,
+		Continuous:   config.Continuous,
+		CreateTarget: config.CreateTarget,
+		Selector:     config.Selector,
+		DocIDs:       config.DocIDs,
+		Filter:       config.Filter,
+		QueryParams:  config.QueryParams,
+		SinceSeq:     config.SinceSeq,
+	}, nil
+}
+
+// replicationTarget resolves an endpoint to a full URL and moves its
+// credentials into basic auth
+func (c *CouchDBService) replicationTarget(endpoint ReplicationEndpoint) (replicationTarget, error) {
+	var target *url.URL
+	if strings.Contains(endpoint.URL, "://") {
+		parsed, err := url.Parse(endpoint.URL)
+		if err != nil {
+			return replicationTarget{}, err
+		}
+		target = parsed
+	} else {
+		// A database on the service's own server
+		server, err := url.Parse(c.client.DSN())
+		if err != nil {
+			return replicationTarget{}, fmt.Errorf("invalid server URL: %w", err)
+		}
+		target = server
+		target.Path = strings.TrimSuffix(server.Path, "/") + "/" + url.PathEscape(endpoint.URL)
+		target.RawPath = ""
+	}
+
+	username, password := endpoint.Username, endpoint.Password
+	if username == "" && target.User != nil {
+		username = target.User.Username()
+		password, _ = target.User.Password()
+	}
+	target.User = nil
+
+	result := replicationTarget{URL: target.String(), Headers: endpoint.Headers}
+	if username != "" {
+		result.Auth = &replicationAuth{Basic: &replicationBasicAuth{Username: username, Password: password}}
+	}
+	return result, nil
+}
+
+// replication converts a replication document into its redacted form
+func (d *replicationDoc) replication() *Replication {
+	return &Replication{
+		ID:             d.ID,
+		Rev:            d.Rev,
+		Source:         redactURL(d.Source.URL),
+		Target:         redactURL(d.Target.URL),
+		HasCredentials: d.Source.hasCredentials() || d.Target.hasCredentials(),
+		Continuous:     d.Continuous,
+		CreateTarget:   d.CreateTarget,
+		Selector:       d.Selector,
+		DocIDs:         d.DocIDs,
+		Filter:         d.Filter,
+		State:          d.State,
+		StateReason:    d.StateReason,
+		StateTime:      d.StateTime,
+	}
+}
+
+func (t replicationTarget) hasCredentials() bool {
+	if t.Auth != nil && t.Auth.Basic != nil {
+		return true
+	}
+	u, err := url.Parse(t.URL)
+	return err == nil && u.User != nil
+}
+
+// redactURL removes the password from a URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"eve.evalgo.org/semantic"
+)
+
+// ReplicationConfigFromAction builds a ReplicationConfig from a CouchDB
+// replicate action (see semantic.NewSemanticCouchDBReplicateAction).
+// The action identifier becomes the replication document ID.
+func ReplicationConfigFromAction(action *semantic.SemanticAction) (ReplicationConfig, error) {
+	source, err := replicationEndpointFromAction(action, "fromLocation")
+	if err != nil {
+		return ReplicationConfig{}, err
+	}
+	target, err := replicationEndpointFromAction(action, "toLocation")
+	if err != nil {
+		return ReplicationConfig{}, err
+	}
+	options, err := semantic.GetCouchDBReplicationOptionsFromAction(action)
+	if err != nil {
+		return ReplicationConfig{}, err
+	}
+
+	return ReplicationConfig{
+		ID:           action.Identifier,
+		Source:       source,
+		Target:       target,
+		Continuous:   options.Continuous,
+		CreateTarget: options.CreateTarget,
+		Selector:     options.Selector,
+		DocIDs:       options.DocIDs,
+	}, nil
+}
+
+func replicationEndpointFromAction(action *semantic.SemanticAction, propertyKey string) (ReplicationEndpoint, error) {
+	database, err := semantic.GetCouchDBDatabaseFromAction(action, propertyKey)
+	if err != nil {
+		return ReplicationEndpoint{}, err
+	}
+	databaseURL, username, password, err := semantic.ExtractCouchDBCredentials(database)
+	if err != nil {
+		return ReplicationEndpoint{}, fmt.Errorf("invalid %s: %w", propertyKey, err)
+	}
+	return ReplicationEndpoint{URL: databaseURL, Username: username, Password: password}, nil
+}
+
+// ReplicationActionHandler returns a semantic action handler that starts the
+// CouchDB replication described by a TransferAction. With the "wait" option,
+// one-shot replications are followed until they complete; the request context
+// bounds the wait.
+//
+// Example Usage:
+//
+//	registry := semantic.NewActionRegistry()
+//	registry.MustRegister("TransferAction", service.ReplicationActionHandler())
+func (c *CouchDBService) ReplicationActionHandler() semantic.ActionHandler {
+	return func(ctx echo.Context, actionInterface interface{}) error {
+		action, ok := actionInterface.(*semantic.SemanticAction)
+		if scheduled, isScheduled := actionInterface.(*semantic.SemanticScheduledAction); isScheduled {
+			action, ok = &scheduled.SemanticAction, true
+		}
+		if !ok {
+			return fmt.Errorf("invalid action type: %T", actionInterface)
+		}
+
+		config, err := ReplicationConfigFromAction(action)
+		// The action is echoed in every response; keep the endpoint credentials out of it
+		redactReplicationAction(action)
+		if err != nil {
+			return semantic.ReturnActionErrorWithStatus(ctx, action, http.StatusBadRequest, "Invalid replication action", err)
+		}
+		options, _ := semantic.GetCouchDBReplicationOptionsFromAction(action)
+
+		reqCtx := ctx.Request().Context()
+		replication, err := c.CreateReplication(reqCtx, config)
+		if err != nil {
+			return semantic.ReturnActionError(ctx, action, "Failed to create replication", err)
+		}
+
+		value := map[string]interface{}{"replication": replication}
+		if options.Wait && !config.Continuous {
+			status, err := c.WaitForReplication(reqCtx, replication.ID, 0)
+			if err != nil {
+				return semantic.ReturnActionError(ctx, action, "Replication did not complete", err)
+			}
+			value["status"] = status
+		}
+
+		action.Result = &semantic.SemanticResult{
+			Type:   "Result",
+			Value:  value,
+			Format: "application/json",
+		}
+		semantic.SetSuccessOnAction(action)
+		return ctx.JSON(http.StatusOK, action)
+	}
+}
+
+// redactReplicationAction replaces the source and target databases of a
+// replicate action with copies without passwords, in the properties and in
+// fromLocation/toLocation
+func redactReplicationAction(action *semantic.SemanticAction) {
+	for _, key := range []string{"fromLocation", "toLocation"} {
+		database, err := semantic.GetCouchDBDatabaseFromAction(action, key)
+		if err != nil {
+			continue
+		}
+		redacted := redactCouchDBDatabase(database)
+		if action.Properties != nil && action.Properties[key] != nil {
+			action.Properties[key] = redacted
+		}
+		switch key {
+		case "fromLocation":
+			if action.FromLocation != nil {
+				action.FromLocation = redacted
+			}
+		case "toLocation":
+			if action.ToLocation != nil {
+				action.ToLocation = redacted
+			}
+		}
+	}
+}
+
+// redactCouchDBDatabase returns a copy of database without a password
+func redactCouchDBDatabase(database *semantic.CouchDBDatabase) *semantic.CouchDBDatabase {
+	redacted := *database
+	redacted.URL = redactURL(database.URL)
+	if database.Properties != nil {
+		redacted.Properties = make(map[string]interface{}, len(database.Properties))
+		for k, v := range database.Properties {
+			if k != "password" {
+				redacted.Properties[k] = v
+			}
+		}
+	}
+	return &redacted
+}
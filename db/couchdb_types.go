@@ -1,9 +1,18 @@
 package db
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+
+	kivik "github.com/go-kivik/kivik/v4"
+	"github.com/go-kivik/kivik/v4/couchdb"
 )
 
 // CouchDBConfig provides generic CouchDB connection configuration.
@@ -174,6 +183,111 @@ func (e *CouchDBError) IsUnauthorized() bool {
 	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
 }
 
+// wrapCouchDBError converts kivik HTTP errors into CouchDBErrors, keeping
+// other errors wrapped with message
+func wrapCouchDBError(err error, message string) error {
+	if status := kivik.HTTPStatus(err); status != 0 && status != http.StatusInternalServerError {
+		return &CouchDBError{
+			StatusCode: status,
+			ErrorType:  http.StatusText(status),
+			Reason:     fmt.Sprintf("%s: %v", message, err),
+		}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// newCouchDBClient connects a kivik client that shares its HTTP client with
+// the service's direct server requests. kivik copies the client, so both use
+// the same transport and the cookie jar holding kivik's session cookie.
+func newCouchDBClient(dsn string) (*kivik.Client, *http.Client, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpClient := &http.Client{Jar: jar}
+	client, err := kivik.New("couch", dsn, couchdb.OptionHTTPClient(httpClient))
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, httpClient, nil
+}
+
+// serverRequest sends a request with an optional JSON body to path on the
+// service's server and decodes the JSON response into v. It is used for
+// endpoints kivik does not expose, such as the replication scheduler and Mango
+// execution statistics, and goes through the HTTP client shared with kivik.
+func (c *CouchDBService) serverRequest(ctx context.Context, method, path string, body, v interface{}) error {
+	server, err := url.Parse(c.client.DSN())
+	if err != nil {
+		return fmt.Errorf("invalid server URL: %w", err)
+	}
+	user := server.User
+	server.User = nil
+	endpoint := strings.TrimSuffix(server.String(), "/") + path
+
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// Reuse kivik's session; send the DSN credentials only until it has one
+	if user != nil && !hasSessionCookie(httpClient.Jar, server) {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response of %s: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		couchErr := &CouchDBError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(data, couchErr)
+		couchErr.StatusCode = resp.StatusCode
+		return couchErr
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", path, err)
+	}
+	return nil
+}
+
+// hasSessionCookie reports whether jar holds a CouchDB session cookie for server
+func hasSessionCookie(jar http.CookieJar, server *url.URL) bool {
+	if jar == nil {
+		return false
+	}
+	for _, cookie := range jar.Cookies(server) {
+		if cookie.Name == kivik.SessionCookieName {
+			return true
+		}
+	}
+	return false
+}
+
 // ViewOptions configures parameters for querying CouchDB MapReduce views.
 // This structure provides comprehensive control over view query behavior including
 // key ranges, document inclusion, pagination, sorting, and reduce function usage.
@@ -424,6 +538,89 @@ type MangoQuery struct {
 	Limit    int                    `json:"limit,omitempty"`     // Maximum results
 	Skip     int                    `json:"skip,omitempty"`      // Pagination offset
 	UseIndex string                 `json:"use_index,omitempty"` // Index hint
+	// Bookmark continues a query after the page that returned it
+	Bookmark string `json:"bookmark,omitempty"`
+	// ExecutionStats requests execution statistics with the results
+	ExecutionStats bool `json:"execution_stats,omitempty"`
+}
+
+// FindResult is one page of Mango query results.
+// Bookmark is passed as MangoQuery.Bookmark to fetch the next page; Warning
+// carries CouchDB's query warnings such as "No matching index found".
+type FindResult struct {
+	Docs           []json.RawMessage `json:"docs"`
+	Bookmark       string            `json:"bookmark,omitempty"`
+	Warning        string            `json:"warning,omitempty"`
+	ExecutionStats *ExecutionStats   `json:"execution_stats,omitempty"`
+}
+
+// Warnings returns the individual warnings of the page
+func (r *FindResult) Warnings() []string {
+	var warnings []string
+	for _, warning := range strings.Split(r.Warning, "\n") {
+		if warning = strings.TrimSpace(warning); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
+// ExecutionStats reports how much work CouchDB did to answer a Mango query.
+// A TotalDocsExamined far above ResultsReturned indicates a missing index.
+type ExecutionStats struct {
+	TotalKeysExamined       int     `json:"total_keys_examined"`
+	TotalDocsExamined       int     `json:"total_docs_examined"`
+	TotalQuorumDocsExamined int     `json:"total_quorum_docs_examined"`
+	ResultsReturned         int     `json:"results_returned"`
+	ExecutionTimeMs         float64 `json:"execution_time_ms"`
+}
+
+// add accumulates the statistics of another page
+func (s *ExecutionStats) add(other *ExecutionStats) {
+	if other == nil {
+		return
+	}
+	s.TotalKeysExamined += other.TotalKeysExamined
+	s.TotalDocsExamined += other.TotalDocsExamined
+	s.TotalQuorumDocsExamined += other.TotalQuorumDocsExamined
+	s.ResultsReturned += other.ResultsReturned
+	s.ExecutionTimeMs += other.ExecutionTimeMs
+}
+
+// QueryExplanation is CouchDB's plan for a Mango query as returned by _explain.
+// Index is the index CouchDB actually selected, which may differ from the
+// MangoQuery.UseIndex hint when the hint does not match a usable index.
+type QueryExplanation struct {
+	DBName   string                 `json:"dbname"`
+	Index    ExplainedIndex         `json:"index"`
+	Selector map[string]interface{} `json:"selector"`
+	Options  map[string]interface{} `json:"opts"`
+	Limit    int                    `json:"limit"`
+	Skip     int                    `json:"skip"`
+	Fields   interface{}            `json:"fields"` // "all_fields" or the projected field list
+	Range    map[string]interface{} `json:"range,omitempty"`
+}
+
+// ExplainedIndex describes the index selected for a query
+type ExplainedIndex struct {
+	DDoc string                 `json:"ddoc"` // Design document, empty for _all_docs
+	Name string                 `json:"name"`
+	Type string                 `json:"type"` // "json", "text" or "special" for _all_docs
+	Def  map[string]interface{} `json:"def"`
+}
+
+// IsFullScan reports whether the query scans all documents instead of
+// using an index
+func (e *QueryExplanation) IsFullScan() bool {
+	return e.Index.Type == "special" || e.Index.Name == "_all_docs"
+}
+
+// UsesIndex reports whether the selected index matches hint, given as an
+// index name, a design document name or "<design document>/<index name>"
+func (e *QueryExplanation) UsesIndex(hint string) bool {
+	ddoc := strings.TrimPrefix(e.Index.DDoc, "_design/")
+	hint = strings.TrimPrefix(hint, "_design/")
+	return hint != "" && (hint == e.Index.Name || hint == ddoc || hint == ddoc+"/"+e.Index.Name)
 }
 
 // Index represents a CouchDB index for query optimization.
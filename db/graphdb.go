@@ -39,6 +39,7 @@ package db
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -1176,3 +1177,60 @@ func GraphDBExportGraphRdf(url, user, pass, repo, graph, exportFile string) erro
 	}
 	return fmt.Errorf("failed to export graph: %d %s", res.StatusCode, http.StatusText(res.StatusCode))
 }
+
+// GraphDBSPARQLClient returns a SPARQL 1.1 protocol client for a GraphDB repository.
+// Requests go through HttpClient, so Ziti connectivity applies to queries too.
+//
+// Parameters:
+//   - url: Base URL of the GraphDB server
+//   - user: Username for HTTP Basic Authentication
+//   - pass: Password for HTTP Basic Authentication
+//   - repo: Repository identifier
+//
+// Returns:
+//   - *SPARQLClient: Client bound to the repository's query and statements endpoints
+//
+// Example Usage:
+//
+//	client := GraphDBSPARQLClient("http://localhost:7200", "admin", "password", "knowledge-base")
+//	exists, err := client.Ask(ctx, `ASK { <http://example.org/alice> ?p ?o }`)
+//
+//	graphClient := client.WithGraphs([]string{"http://example.org/graph/publications"}, nil)
+//	quads, err := graphClient.Construct(ctx, `CONSTRUCT WHERE { ?s ?p ?o }`)
+func GraphDBSPARQLClient(url, user, pass, repo string) *SPARQLClient {
+	return NewSPARQLClient(&GraphDBStore{URL: url, Repository: repo, Username: user, Password: pass})
+}
+
+// GraphDBSyncGraphRdf updates a named graph to match an RDF file by applying
+// only the added and removed statements as a SPARQL update, instead of
+// replacing the graph as GraphDBImportGraphRdf does. The file format is chosen
+// by extension (.nt, .nq, .ttl, .rdf, .owl or .xml) and the file is parsed
+// before anything is sent, so malformed files are rejected up front.
+//
+// Parameters:
+//   - url: Base URL of the GraphDB server
+//   - user: Username for HTTP Basic Authentication
+//   - pass: Password for HTTP Basic Authentication
+//   - repo: Repository identifier
+//   - graph: Named graph IRI to synchronize
+//   - rdfFile: Path to the file holding the desired graph content
+//
+// Returns:
+//   - *GraphDiff: The statements that were added and removed
+//   - error: Parse, query or update errors
+//
+// Example Usage:
+//
+//	diff, err := GraphDBSyncGraphRdf("http://localhost:7200", "admin", "password",
+//	    "knowledge-base", "http://example.org/graph/apps", "apps.ttl")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("added %d, removed %d statements\n", len(diff.Added), len(diff.Removed))
+func GraphDBSyncGraphRdf(url, user, pass, repo, graph, rdfFile string) (*GraphDiff, error) {
+	quads, err := ParseRDFFile(rdfFile)
+	if err != nil {
+		return nil, err
+	}
+	return SyncGraph(context.Background(), GraphDBSPARQLClient(url, user, pass, repo), graph, quads)
+}
@@ -0,0 +1,714 @@
+package db
+
+import (
+	"sort"
+	"strings"
+)
+
+// compactDocument expands document and compacts it with context
+func (p *jsonldProcessor) compactDocument(document, context interface{}) (map[string]interface{}, error) {
+	expanded, err := p.expandDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	return p.compactExpanded(expanded, context, false)
+}
+
+// compactExpanded compacts expanded JSON-LD with context. With forceGraph
+// the result always has a top-level @graph, as required for flattening.
+func (p *jsonldProcessor) compactExpanded(expanded []interface{}, context interface{}, forceGraph bool) (map[string]interface{}, error) {
+	context = unwrapContext(context)
+
+	active := newJSONLDContext(p.options.Base)
+	if context != nil {
+		var err error
+		if active, err = p.processContext(active, context, nil, true); err != nil {
+			return nil, err
+		}
+	}
+
+	compacted, err := p.compact(active, "", expanded)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	switch v := compacted.(type) {
+	case map[string]interface{}:
+		if forceGraph {
+			result = map[string]interface{}{p.compactIRI(active, "@graph", true): []interface{}{v}}
+		} else {
+			result = v
+		}
+	case []interface{}:
+		result = make(map[string]interface{})
+		if len(v) > 0 || forceGraph {
+			result[p.compactIRI(active, "@graph", true)] = v
+		}
+	default:
+		result = make(map[string]interface{})
+	}
+
+	if !isEmptyContext(context) {
+		result["@context"] = context
+	}
+	return result, nil
+}
+
+// compact implements the JSON-LD 1.1 compaction algorithm for one element
+func (p *jsonldProcessor) compact(active *jsonldContext, activeProperty string, element interface{}) (interface{}, error) {
+	propertyDef := active.terms[activeProperty]
+
+	switch v := element.(type) {
+	case []interface{}:
+		result := []interface{}{}
+		for _, item := range v {
+			compacted, err := p.compact(active, activeProperty, item)
+			if err != nil {
+				return nil, err
+			}
+			if compacted != nil {
+				result = append(result, compacted)
+			}
+		}
+		if len(result) == 1 && activeProperty != "@list" && activeProperty != "@graph" &&
+			!propertyDef.hasContainer("@list") && !propertyDef.hasContainer("@set") {
+			return result[0], nil
+		}
+		return result, nil
+
+	case map[string]interface{}:
+		return p.compactMap(active, activeProperty, propertyDef, v)
+
+	default:
+		return element, nil
+	}
+}
+
+// compactMap compacts a node, value, list or graph object
+func (p *jsonldProcessor) compactMap(active *jsonldContext, activeProperty string, propertyDef *termDefinition, element map[string]interface{}) (interface{}, error) {
+	if active.previous != nil && !isValueObject(element) && !isNodeReference(element) {
+		active = active.previous
+	}
+
+	var err error
+	if propertyDef != nil && propertyDef.hasContext {
+		if active, err = p.processContext(active, propertyDef.context, nil, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if isValueObject(element) {
+		return p.compactValue(active, activeProperty, element), nil
+	}
+	if isNodeReference(element) {
+		if compacted := p.compactValue(active, activeProperty, element); !isMap(compacted) {
+			return compacted, nil
+		}
+	}
+	if list, ok := element["@list"]; ok && propertyDef.hasContainer("@list") {
+		compacted, err := p.compact(active, activeProperty, list)
+		if err != nil {
+			return nil, err
+		}
+		return asArray(compacted), nil
+	}
+
+	insideReverse := activeProperty == "@reverse"
+	result := make(map[string]interface{})
+
+	// Type-scoped contexts apply to the node's properties
+	inputContext := active
+	if types, ok := element["@type"]; ok {
+		var compactedTypes []string
+		for _, t := range stringValues(types) {
+			compactedTypes = append(compactedTypes, p.compactIRI(inputContext, t, true))
+		}
+		sort.Strings(compactedTypes)
+		for _, t := range compactedTypes {
+			if def := inputContext.terms[t]; def != nil && def.hasContext {
+				if active, err = p.processContext(active, def.context, nil, false); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, expandedProperty := range sortedKeys(element) {
+		value := element[expandedProperty]
+
+		switch expandedProperty {
+		case "@id":
+			result[p.compactIRI(active, "@id", true)] = p.compactIRI(active, value.(string), false)
+			continue
+		case "@type":
+			var compacted []interface{}
+			for _, t := range stringValues(value) {
+				compacted = append(compacted, p.compactIRI(inputContext, t, true))
+			}
+			alias := p.compactIRI(active, "@type", true)
+			if len(compacted) == 1 && !active.terms[alias].hasContainer("@set") {
+				result[alias] = compacted[0]
+			} else {
+				result[alias] = compacted
+			}
+			continue
+		case "@reverse":
+			compacted, err := p.compact(active, "@reverse", value)
+			if err != nil {
+				return nil, err
+			}
+			reverseMap, _ := compacted.(map[string]interface{})
+			for _, property := range sortedKeys(reverseMap) {
+				if def := active.terms[property]; def != nil && def.reverse {
+					asArr := def.hasContainer("@set")
+					addValue(result, property, reverseMap[property], asArr)
+					delete(reverseMap, property)
+				}
+			}
+			if len(reverseMap) > 0 {
+				result[p.compactIRI(active, "@reverse", true)] = reverseMap
+			}
+			continue
+		case "@preserve":
+			continue
+		case "@index":
+			if propertyDef.hasContainer("@index") {
+				continue
+			}
+			result[p.compactIRI(active, "@index", true)] = value
+			continue
+		case "@direction", "@language", "@value":
+			result[p.compactIRI(active, expandedProperty, true)] = value
+			continue
+		case "@included":
+			compacted, err := p.compact(active, "", value)
+			if err != nil {
+				return nil, err
+			}
+			result[p.compactIRI(active, "@included", true)] = asArray(compacted)
+			continue
+		}
+
+		items := asArray(value)
+		if len(items) == 0 {
+			term := p.selectTerm(active, expandedProperty, nil, insideReverse)
+			target := p.nestTarget(active, result, term)
+			if _, exists := target[term]; !exists {
+				target[term] = []interface{}{}
+			}
+			continue
+		}
+
+		for _, item := range items {
+			term := p.selectTerm(active, expandedProperty, item, insideReverse)
+			def := active.terms[term]
+			target := p.nestTarget(active, result, term)
+			asArr := def.hasContainer("@set") || def.hasContainer("@list") || term == "@graph" || term == "@list"
+
+			if isListObject(item) {
+				itemMap := item.(map[string]interface{})
+				compacted, err := p.compact(active, term, itemMap["@list"])
+				if err != nil {
+					return nil, err
+				}
+				list := asArray(compacted)
+				if def.hasContainer("@list") {
+					target[term] = list
+					continue
+				}
+				listObject := map[string]interface{}{p.compactIRI(active, "@list", true): list}
+				if index, ok := itemMap["@index"]; ok {
+					listObject[p.compactIRI(active, "@index", true)] = index
+				}
+				addValue(target, term, listObject, asArr)
+				continue
+			}
+
+			if isGraphObject(item) {
+				itemMap := item.(map[string]interface{})
+				compacted, err := p.compact(active, term, itemMap["@graph"])
+				if err != nil {
+					return nil, err
+				}
+				switch {
+				case def.hasContainer("@graph") && def.hasContainer("@id"):
+					mapObject, _ := target[term].(map[string]interface{})
+					if mapObject == nil {
+						mapObject = make(map[string]interface{})
+					}
+					key := p.compactIRI(active, "@none", true)
+					if id, ok := itemMap["@id"].(string); ok {
+						key = p.compactIRI(active, id, false)
+					}
+					addValue(mapObject, key, compacted, asArr)
+					target[term] = mapObject
+				case def.hasContainer("@graph") && def.hasContainer("@index"):
+					mapObject, _ := target[term].(map[string]interface{})
+					if mapObject == nil {
+						mapObject = make(map[string]interface{})
+					}
+					key := p.compactIRI(active, "@none", true)
+					if index, ok := itemMap["@index"].(string); ok {
+						key = index
+					}
+					addValue(mapObject, key, compacted, asArr)
+					target[term] = mapObject
+				case def.hasContainer("@graph") && itemMap["@id"] == nil:
+					if list, ok := compacted.([]interface{}); ok && len(list) > 1 {
+						compacted = map[string]interface{}{p.compactIRI(active, "@included", true): list}
+					}
+					addValue(target, term, compacted, asArr)
+				default:
+					graphObject := map[string]interface{}{p.compactIRI(active, "@graph", true): asArray(compacted)}
+					if id, ok := itemMap["@id"].(string); ok {
+						graphObject[p.compactIRI(active, "@id", true)] = p.compactIRI(active, id, false)
+					}
+					if index, ok := itemMap["@index"]; ok {
+						graphObject[p.compactIRI(active, "@index", true)] = index
+					}
+					addValue(target, term, graphObject, asArr)
+				}
+				continue
+			}
+
+			compacted, err := p.compact(active, term, item)
+			if err != nil {
+				return nil, err
+			}
+
+			if def.hasContainer("@language") || def.hasContainer("@index") || def.hasContainer("@id") || def.hasContainer("@type") {
+				mapObject, _ := target[term].(map[string]interface{})
+				if mapObject == nil {
+					mapObject = make(map[string]interface{})
+				}
+				itemMap, _ := item.(map[string]interface{})
+				key := ""
+				switch {
+				case def.hasContainer("@language"):
+					key, _ = itemMap["@language"].(string)
+					if isValueObject(item) {
+						compacted = itemMap["@value"]
+					}
+				case def.hasContainer("@index") && (def.index == "" || def.index == "@index"):
+					key, _ = itemMap["@index"].(string)
+				case def.hasContainer("@index"):
+					indexProperty := p.compactIRI(active, def.index, true)
+					if compactedMap, ok := compacted.(map[string]interface{}); ok {
+						values := asArray(compactedMap[indexProperty])
+						if len(values) > 0 {
+							if s, ok := values[0].(string); ok {
+								key = s
+								if len(values) > 1 {
+									compactedMap[indexProperty] = values[1:]
+								} else {
+									delete(compactedMap, indexProperty)
+								}
+							}
+						}
+					}
+				case def.hasContainer("@id"):
+					if compactedMap, ok := compacted.(map[string]interface{}); ok {
+						idAlias := p.compactIRI(active, "@id", true)
+						key, _ = compactedMap[idAlias].(string)
+						delete(compactedMap, idAlias)
+					}
+				case def.hasContainer("@type"):
+					if compactedMap, ok := compacted.(map[string]interface{}); ok {
+						typeAlias := p.compactIRI(active, "@type", true)
+						types := asArray(compactedMap[typeAlias])
+						if len(types) > 0 {
+							key, _ = types[0].(string)
+							switch len(types) {
+							case 1:
+								delete(compactedMap, typeAlias)
+							case 2:
+								compactedMap[typeAlias] = types[1]
+							default:
+								compactedMap[typeAlias] = types[1:]
+							}
+						}
+						if id, ok := itemMap["@id"].(string); ok && len(compactedMap) == 1 {
+							if _, onlyID := compactedMap[p.compactIRI(active, "@id", true)]; onlyID {
+								compacted, err = p.compact(active, term, map[string]interface{}{"@id": id})
+								if err != nil {
+									return nil, err
+								}
+							}
+						}
+					}
+				}
+				if key == "" {
+					key = p.compactIRI(active, "@none", true)
+				}
+				addValue(mapObject, key, compacted, asArr)
+				target[term] = mapObject
+				continue
+			}
+
+			addValue(target, term, compacted, asArr)
+		}
+	}
+
+	return result, nil
+}
+
+// nestTarget returns the object a term's values are added to, creating the
+// @nest object of terms with a nest value
+func (p *jsonldProcessor) nestTarget(active *jsonldContext, result map[string]interface{}, term string) map[string]interface{} {
+	def := active.terms[term]
+	if def == nil || def.nest == "" {
+		return result
+	}
+	nested, _ := result[def.nest].(map[string]interface{})
+	if nested == nil {
+		nested = make(map[string]interface{})
+		result[def.nest] = nested
+	}
+	return nested
+}
+
+// compactValue compacts a value object or node reference, returning a
+// scalar when the term definition of activeProperty allows it
+func (p *jsonldProcessor) compactValue(active *jsonldContext, activeProperty string, value map[string]interface{}) interface{} {
+	def := active.terms[activeProperty]
+	typeMapping := ""
+	if def != nil {
+		typeMapping = def.typeMapping
+	}
+	language := active.language
+	if def != nil && def.language != nil {
+		language = *def.language
+	}
+	direction := active.direction
+	if def != nil && def.direction != nil {
+		direction = *def.direction
+	}
+	_, hasIndex := value["@index"]
+	keepIndex := hasIndex && !def.hasContainer("@index")
+
+	if id, ok := value["@id"].(string); ok && !isValueObject(value) {
+		if !keepIndex && len(value) <= 2 {
+			switch typeMapping {
+			case "@id":
+				return p.compactIRI(active, id, false)
+			case "@vocab":
+				return p.compactIRI(active, id, true)
+			}
+		}
+		result := map[string]interface{}{p.compactIRI(active, "@id", true): p.compactIRI(active, id, false)}
+		if keepIndex {
+			result[p.compactIRI(active, "@index", true)] = value["@index"]
+		}
+		return result
+	}
+
+	v := value["@value"]
+	valueType, hasType := value["@type"].(string)
+	valueLanguage, hasLanguage := value["@language"].(string)
+	valueDirection, hasDirection := value["@direction"].(string)
+
+	if !keepIndex {
+		switch {
+		case hasType:
+			if valueType == typeMapping {
+				return v
+			}
+		case typeMapping == "@json":
+		case typeMapping != "" && typeMapping != "@none":
+			// A typed term cannot hold an untyped value
+		case hasLanguage || hasDirection:
+			if strings.EqualFold(valueLanguage, language) && valueDirection == direction {
+				return v
+			}
+			if def.hasContainer("@language") && valueDirection == direction {
+				return v
+			}
+		default:
+			if _, isString := v.(string); !isString || (language == "" && direction == "") {
+				return v
+			}
+		}
+	}
+
+	result := map[string]interface{}{p.compactIRI(active, "@value", true): v}
+	if hasType {
+		if valueType == "@json" {
+			result[p.compactIRI(active, "@type", true)] = "@json"
+		} else {
+			result[p.compactIRI(active, "@type", true)] = p.compactIRI(active, valueType, true)
+		}
+	}
+	if hasLanguage {
+		result[p.compactIRI(active, "@language", true)] = valueLanguage
+	}
+	if hasDirection {
+		result[p.compactIRI(active, "@direction", true)] = valueDirection
+	}
+	if keepIndex {
+		result[p.compactIRI(active, "@index", true)] = value["@index"]
+	}
+	return result
+}
+
+// selectTerm picks the term for a property IRI and one of its expanded
+// values, preferring terms whose container, type and language mappings
+// let the value be written in its most compact form
+func (p *jsonldProcessor) selectTerm(active *jsonldContext, iri string, value interface{}, reverse bool) string {
+	best, bestScore := "", -1
+	for _, term := range active.sortedTerms() {
+		def := active.terms[term]
+		if def.null || def.id != iri || def.reverse != reverse {
+			continue
+		}
+		if score := termScore(active, def, value); score > bestScore {
+			best, bestScore = term, score
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return p.compactIRI(active, iri, true)
+}
+
+// termScore rates how well a term definition fits an expanded value;
+// -1 means the term cannot represent the value
+func termScore(active *jsonldContext, def *termDefinition, value interface{}) int {
+	if value == nil {
+		if len(def.container) == 0 || def.hasContainer("@set") {
+			return 1
+		}
+		return 0
+	}
+
+	score := 0
+	item := value
+	switch {
+	case isListObject(value):
+		if def.hasContainer("@list") {
+			list := asArray(value.(map[string]interface{})["@list"])
+			score += 20
+			minimum := 10
+			for _, listItem := range list {
+				if s := typeScore(active, def, listItem); s < minimum {
+					minimum = s
+				}
+			}
+			if minimum < 0 {
+				return -1
+			}
+			return score + minimum
+		}
+		if len(def.container) > 0 && !def.hasContainer("@set") {
+			return -1
+		}
+		return 1
+	case def.hasContainer("@list"):
+		return -1
+	case isGraphObject(value):
+		if def.hasContainer("@graph") {
+			score += 20
+		} else if len(def.container) > 0 && !def.hasContainer("@set") {
+			return -1
+		}
+		return score
+	case def.hasContainer("@graph"):
+		return -1
+	}
+
+	itemMap, _ := item.(map[string]interface{})
+	switch {
+	case def.hasContainer("@language"):
+		if _, ok := itemMap["@language"]; !ok || !isValueObject(item) {
+			return -1
+		}
+		score += 20
+	case def.hasContainer("@index"):
+		if def.index == "" || def.index == "@index" {
+			if _, ok := itemMap["@index"]; !ok {
+				return -1
+			}
+		}
+		score += 20
+	case def.hasContainer("@id"):
+		if _, ok := itemMap["@id"]; !ok || isValueObject(item) {
+			return -1
+		}
+		score += 15
+	case def.hasContainer("@type"):
+		if _, ok := itemMap["@type"]; !ok || isValueObject(item) {
+			return -1
+		}
+		score += 15
+	case def.hasContainer("@set"):
+		score++
+	}
+
+	if def.hasContainer("@language") {
+		return score
+	}
+	s := typeScore(active, def, item)
+	if s < 0 {
+		return -1
+	}
+	return score + s
+}
+
+// typeScore rates the type and language mapping of a term for a value
+func typeScore(active *jsonldContext, def *termDefinition, value interface{}) int {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	plain := (def.typeMapping == "" || def.typeMapping == "@none") && def.language == nil && def.direction == nil
+
+	if isValueObject(m) {
+		if t, ok := m["@type"].(string); ok {
+			switch {
+			case t == def.typeMapping:
+				return 10
+			case plain:
+				return 1
+			}
+			return -1
+		}
+		if def.typeMapping != "" && def.typeMapping != "@none" {
+			return -1
+		}
+		language, hasLanguage := m["@language"].(string)
+		direction, _ := m["@direction"].(string)
+		if _, isString := m["@value"].(string); !isString {
+			if plain || def.language != nil {
+				return 5
+			}
+			return -1
+		}
+		termLanguage := active.language
+		if def.language != nil {
+			termLanguage = *def.language
+		}
+		termDirection := active.direction
+		if def.direction != nil {
+			termDirection = *def.direction
+		}
+		if strings.EqualFold(language, termLanguage) && direction == termDirection {
+			if hasLanguage == (def.language != nil && *def.language != "") && def.language != nil {
+				return 10
+			}
+			return 8
+		}
+		if plain {
+			return 1
+		}
+		return -1
+	}
+
+	if isNodeReference(m) {
+		switch def.typeMapping {
+		case "@id":
+			return 10
+		case "@vocab":
+			return 9
+		case "", "@none":
+			if def.language == nil {
+				return 1
+			}
+		}
+		return -1
+	}
+
+	// Node objects
+	switch def.typeMapping {
+	case "", "@none", "@id", "@vocab":
+		return 5
+	}
+	return -1
+}
+
+// compactIRI compacts an IRI or keyword. With vocab the IRI is a property or
+// type and may be compacted to a term or relative to @vocab.
+func (p *jsonldProcessor) compactIRI(active *jsonldContext, iri string, vocab bool) string {
+	if iri == "" {
+		return iri
+	}
+
+	if vocab {
+		for _, term := range active.sortedTerms() {
+			def := active.terms[term]
+			if def.id == iri && !def.null && !def.reverse && (isKeyword(iri) || def.typeMapping == "" || def.typeMapping == "@id" || def.typeMapping == "@vocab") {
+				return term
+			}
+		}
+		if isKeyword(iri) {
+			return iri
+		}
+		if active.vocab != "" && strings.HasPrefix(iri, active.vocab) && len(iri) > len(active.vocab) {
+			suffix := iri[len(active.vocab):]
+			if active.terms[suffix] == nil {
+				return suffix
+			}
+		}
+	}
+
+	best := ""
+	for _, term := range active.sortedTerms() {
+		def := active.terms[term]
+		if def.null || !def.prefix || def.id == iri || !strings.HasPrefix(iri, def.id) {
+			continue
+		}
+		candidate := term + ":" + iri[len(def.id):]
+		if existing := active.terms[candidate]; existing != nil && (existing.id != iri || !vocab) {
+			continue
+		}
+		if best == "" || len(candidate) < len(best) || (len(candidate) == len(best) && candidate < best) {
+			best = candidate
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	if !vocab && active.base != "" {
+		return relativeIRI(active.base, iri)
+	}
+	return iri
+}
+
+// relativeIRI makes iri relative to base when it shares its directory
+func relativeIRI(base, iri string) string {
+	if iri == base {
+		return ""
+	}
+	directory := base[:strings.LastIndex(base, "/")+1]
+	if strings.Contains(directory, "://") && strings.HasPrefix(iri, directory) && len(iri) > len(directory) {
+		relative := iri[len(directory):]
+		if !strings.Contains(strings.SplitN(relative, "/", 2)[0], ":") {
+			return relative
+		}
+	}
+	return iri
+}
+
+// unwrapContext returns the @context of a context document
+func unwrapContext(context interface{}) interface{} {
+	if m, ok := context.(map[string]interface{}); ok {
+		if inner, ok := m["@context"]; ok {
+			return inner
+		}
+	}
+	return context
+}
+
+func isEmptyContext(context interface{}) bool {
+	switch v := context.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
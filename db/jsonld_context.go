@@ -0,0 +1,685 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// JSONLDError is a JSON-LD processing error. Code is the error code defined
+// by the JSON-LD 1.1 API specification, e.g. "invalid term definition".
+type JSONLDError struct {
+	Code    string
+	Message string
+}
+
+func (e *JSONLDError) Error() string {
+	if e.Message == "" {
+		return "jsonld: " + e.Code
+	}
+	return fmt.Sprintf("jsonld: %s: %s", e.Code, e.Message)
+}
+
+// JSONLDOptions configures JSON-LD processing
+type JSONLDOptions struct {
+	// Base is the base IRI relative IRIs are resolved against
+	Base string
+	// DocumentLoader loads remote contexts (DefaultDocumentLoader if nil)
+	DocumentLoader DocumentLoader
+	// ExpandContext is applied before the document's own @context
+	ExpandContext interface{}
+}
+
+// jsonldKeywords are the JSON-LD 1.1 keywords
+var jsonldKeywords = map[string]bool{
+	"@base": true, "@container": true, "@context": true, "@default": true,
+	"@direction": true, "@embed": true, "@explicit": true, "@graph": true,
+	"@id": true, "@import": true, "@included": true, "@index": true,
+	"@json": true, "@language": true, "@list": true, "@nest": true,
+	"@none": true, "@omitDefault": true, "@prefix": true, "@preserve": true,
+	"@propagate": true, "@protected": true, "@requireAll": true,
+	"@reverse": true, "@set": true, "@type": true, "@value": true,
+	"@version": true, "@vocab": true,
+}
+
+var (
+	keywordLike    = regexp.MustCompile(`^@[a-zA-Z]+$`)
+	absoluteIRIRe  = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.\-]*:`)
+	maxContextLoad = 32
+)
+
+func isKeyword(s string) bool {
+	return jsonldKeywords[s]
+}
+
+func isAbsoluteIRI(s string) bool {
+	return absoluteIRIRe.MatchString(s)
+}
+
+func isBlankNodeID(s string) bool {
+	return strings.HasPrefix(s, "_:")
+}
+
+// termDefinition is a term of an active context
+type termDefinition struct {
+	id          string
+	null        bool // Term is explicitly mapped to null
+	reverse     bool
+	typeMapping string
+	language    *string // nil: not set, "": explicitly null
+	direction   *string
+	container   map[string]bool
+	context     interface{}
+	hasContext  bool
+	prefix      bool
+	nest        string
+	index       string
+}
+
+func (d *termDefinition) hasContainer(container string) bool {
+	return d != nil && d.container[container]
+}
+
+// jsonldContext is an active context
+type jsonldContext struct {
+	base      string
+	vocab     string
+	language  string
+	direction string
+	terms     map[string]*termDefinition
+	previous  *jsonldContext // Context to revert to for non-propagated contexts
+}
+
+func newJSONLDContext(base string) *jsonldContext {
+	return &jsonldContext{base: base, terms: make(map[string]*termDefinition)}
+}
+
+func (c *jsonldContext) clone() *jsonldContext {
+	clone := *c
+	clone.terms = make(map[string]*termDefinition, len(c.terms))
+	for term, definition := range c.terms {
+		clone.terms[term] = definition
+	}
+	return &clone
+}
+
+// sortedTerms returns the terms of the context, shortest first
+func (c *jsonldContext) sortedTerms() []string {
+	terms := make([]string, 0, len(c.terms))
+	for term := range c.terms {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i]) != len(terms[j]) {
+			return len(terms[i]) < len(terms[j])
+		}
+		return terms[i] < terms[j]
+	})
+	return terms
+}
+
+// jsonldProcessor holds the state of one JSON-LD API call
+type jsonldProcessor struct {
+	options  JSONLDOptions
+	loader   DocumentLoader
+	contexts map[string]interface{} // Loaded remote contexts
+	issuer   *blankNodeIssuer
+}
+
+func newJSONLDProcessor(opts *JSONLDOptions) *jsonldProcessor {
+	p := &jsonldProcessor{contexts: make(map[string]interface{}), issuer: newBlankNodeIssuer("_:b")}
+	if opts != nil {
+		p.options = *opts
+	}
+	p.loader = p.options.DocumentLoader
+	if p.loader == nil {
+		p.loader = DefaultDocumentLoader
+	}
+	return p
+}
+
+// processContext applies a local context to an active context
+func (p *jsonldProcessor) processContext(active *jsonldContext, local interface{}, remote []string, propagate bool) (*jsonldContext, error) {
+	result := active.clone()
+
+	contexts, ok := local.([]interface{})
+	if !ok {
+		contexts = []interface{}{local}
+	}
+	if len(contexts) == 1 {
+		if m, ok := contexts[0].(map[string]interface{}); ok {
+			if v, ok := m["@propagate"].(bool); ok {
+				propagate = v
+			}
+		}
+	}
+	if !propagate && result.previous == nil {
+		result.previous = active
+	}
+
+	for _, context := range contexts {
+		switch ctx := context.(type) {
+		case nil:
+			previous := result.previous
+			result = newJSONLDContext(p.options.Base)
+			if !propagate {
+				result.previous = previous
+			}
+		case string:
+			contextURL := resolveIRI(p.options.Base, ctx)
+			for _, loaded := range remote {
+				if loaded == contextURL {
+					return nil, &JSONLDError{Code: "recursive context inclusion", Message: contextURL}
+				}
+			}
+			if len(remote) >= maxContextLoad {
+				return nil, &JSONLDError{Code: "context overflow", Message: contextURL}
+			}
+			remoteContext, err := p.loadContext(contextURL)
+			if err != nil {
+				return nil, err
+			}
+			processed, err := p.processContext(result, remoteContext, append(remote, contextURL), true)
+			if err != nil {
+				return nil, err
+			}
+			// Remote contexts keep the previous context of the including one
+			processed.previous = result.previous
+			result = processed
+		case map[string]interface{}:
+			if err := p.processContextObject(result, ctx, remote); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, &JSONLDError{Code: "invalid local context", Message: fmt.Sprintf("unexpected %T", context)}
+		}
+	}
+
+	return result, nil
+}
+
+// processContextObject applies one context definition object
+func (p *jsonldProcessor) processContextObject(result *jsonldContext, ctx map[string]interface{}, remote []string) error {
+	if version, ok := ctx["@version"]; ok {
+		if v, ok := version.(float64); !ok || v != 1.1 {
+			return &JSONLDError{Code: "invalid @version value", Message: fmt.Sprintf("%v", version)}
+		}
+	}
+
+	if imported, ok := ctx["@import"]; ok {
+		importURL, ok := imported.(string)
+		if !ok {
+			return &JSONLDError{Code: "invalid @import value", Message: fmt.Sprintf("%v", imported)}
+		}
+		importedContext, err := p.loadContext(resolveIRI(result.base, importURL))
+		if err != nil {
+			return err
+		}
+		importedMap, ok := importedContext.(map[string]interface{})
+		if !ok {
+			return &JSONLDError{Code: "invalid remote context", Message: importURL}
+		}
+		if _, nested := importedMap["@import"]; nested {
+			return &JSONLDError{Code: "invalid context entry", Message: "@import in imported context"}
+		}
+		merged := make(map[string]interface{}, len(ctx)+len(importedMap))
+		for k, v := range importedMap {
+			merged[k] = v
+		}
+		for k, v := range ctx {
+			if k != "@import" {
+				merged[k] = v
+			}
+		}
+		ctx = merged
+	}
+
+	if base, ok := ctx["@base"]; ok && len(remote) == 0 {
+		switch v := base.(type) {
+		case nil:
+			result.base = ""
+		case string:
+			if isAbsoluteIRI(v) {
+				result.base = v
+			} else if result.base != "" {
+				result.base = resolveIRI(result.base, v)
+			} else {
+				return &JSONLDError{Code: "invalid base IRI", Message: v}
+			}
+		default:
+			return &JSONLDError{Code: "invalid base IRI", Message: fmt.Sprintf("%v", base)}
+		}
+	}
+
+	if vocab, ok := ctx["@vocab"]; ok {
+		switch v := vocab.(type) {
+		case nil:
+			result.vocab = ""
+		case string:
+			expanded, _, err := p.expandIRI(result, v, true, true, nil, nil)
+			if err != nil {
+				return err
+			}
+			if !isAbsoluteIRI(expanded) && !isBlankNodeID(expanded) && expanded != "" {
+				return &JSONLDError{Code: "invalid vocab mapping", Message: v}
+			}
+			result.vocab = expanded
+		default:
+			return &JSONLDError{Code: "invalid vocab mapping", Message: fmt.Sprintf("%v", vocab)}
+		}
+	}
+
+	if language, ok := ctx["@language"]; ok {
+		switch v := language.(type) {
+		case nil:
+			result.language = ""
+		case string:
+			result.language = strings.ToLower(v)
+		default:
+			return &JSONLDError{Code: "invalid default language", Message: fmt.Sprintf("%v", language)}
+		}
+	}
+
+	if direction, ok := ctx["@direction"]; ok {
+		switch v := direction.(type) {
+		case nil:
+			result.direction = ""
+		case string:
+			if v != "ltr" && v != "rtl" {
+				return &JSONLDError{Code: "invalid base direction", Message: v}
+			}
+			result.direction = v
+		default:
+			return &JSONLDError{Code: "invalid base direction", Message: fmt.Sprintf("%v", direction)}
+		}
+	}
+
+	if propagate, ok := ctx["@propagate"]; ok {
+		if _, ok := propagate.(bool); !ok {
+			return &JSONLDError{Code: "invalid @propagate value", Message: fmt.Sprintf("%v", propagate)}
+		}
+	}
+
+	defined := make(map[string]bool)
+	for _, term := range sortedKeys(ctx) {
+		switch term {
+		case "@base", "@direction", "@import", "@language", "@propagate", "@protected", "@version", "@vocab":
+			continue
+		}
+		if err := p.createTermDefinition(result, ctx, term, defined); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadContext loads the @context of a remote document
+func (p *jsonldProcessor) loadContext(contextURL string) (interface{}, error) {
+	if context, ok := p.contexts[contextURL]; ok {
+		return context, nil
+	}
+	remoteDocument, err := p.loader.LoadDocument(contextURL)
+	if err != nil {
+		if _, ok := err.(*JSONLDError); ok {
+			return nil, err
+		}
+		return nil, &JSONLDError{Code: "loading remote context failed", Message: fmt.Sprintf("%s: %v", contextURL, err)}
+	}
+	document, ok := remoteDocument.Document.(map[string]interface{})
+	if !ok {
+		return nil, &JSONLDError{Code: "invalid remote context", Message: contextURL}
+	}
+	context, ok := document["@context"]
+	if !ok {
+		return nil, &JSONLDError{Code: "invalid remote context", Message: contextURL + " has no @context"}
+	}
+	p.contexts[contextURL] = context
+	return context, nil
+}
+
+// createTermDefinition defines term of the local context in active
+func (p *jsonldProcessor) createTermDefinition(active *jsonldContext, local map[string]interface{}, term string, defined map[string]bool) error {
+	if done, ok := defined[term]; ok {
+		if done {
+			return nil
+		}
+		return &JSONLDError{Code: "cyclic IRI mapping", Message: term}
+	}
+	if term == "" {
+		return &JSONLDError{Code: "invalid term definition", Message: "empty term"}
+	}
+	defined[term] = false
+
+	value := local[term]
+	if term == "@type" {
+		// Only @container: @set and @protected may be set for @type
+		if m, ok := value.(map[string]interface{}); ok {
+			for k, v := range m {
+				if (k != "@container" || v != "@set") && k != "@protected" {
+					return &JSONLDError{Code: "keyword redefinition", Message: term}
+				}
+			}
+			defined[term] = true
+			return nil
+		}
+		return &JSONLDError{Code: "keyword redefinition", Message: term}
+	}
+	if isKeyword(term) {
+		return &JSONLDError{Code: "keyword redefinition", Message: term}
+	}
+	if keywordLike.MatchString(term) {
+		// Reserved for future keywords, ignored
+		defined[term] = true
+		return nil
+	}
+
+	delete(active.terms, term)
+
+	simpleTerm := false
+	var definition map[string]interface{}
+	switch v := value.(type) {
+	case nil:
+		definition = map[string]interface{}{"@id": nil}
+	case string:
+		definition = map[string]interface{}{"@id": v}
+		simpleTerm = true
+	case map[string]interface{}:
+		definition = v
+	default:
+		return &JSONLDError{Code: "invalid term definition", Message: term}
+	}
+
+	def := &termDefinition{}
+
+	if typeValue, ok := definition["@type"]; ok {
+		typeString, ok := typeValue.(string)
+		if !ok {
+			return &JSONLDError{Code: "invalid type mapping", Message: term}
+		}
+		expanded, _, err := p.expandIRI(active, typeString, false, true, local, defined)
+		if err != nil {
+			return err
+		}
+		if expanded != "@id" && expanded != "@vocab" && expanded != "@json" && expanded != "@none" && !isAbsoluteIRI(expanded) {
+			return &JSONLDError{Code: "invalid type mapping", Message: typeString}
+		}
+		def.typeMapping = expanded
+	}
+
+	if reverse, ok := definition["@reverse"]; ok {
+		if _, ok := definition["@id"]; ok {
+			return &JSONLDError{Code: "invalid reverse property", Message: term}
+		}
+		reverseString, ok := reverse.(string)
+		if !ok {
+			return &JSONLDError{Code: "invalid IRI mapping", Message: term}
+		}
+		expanded, _, err := p.expandIRI(active, reverseString, false, true, local, defined)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(expanded, ":") {
+			return &JSONLDError{Code: "invalid IRI mapping", Message: reverseString}
+		}
+		def.id = expanded
+		def.reverse = true
+		if err := parseContainer(def, definition, term); err != nil {
+			return err
+		}
+		active.terms[term] = def
+		defined[term] = true
+		return nil
+	}
+
+	if id, ok := definition["@id"]; ok && id != term {
+		switch v := id.(type) {
+		case nil:
+			def.null = true
+		case string:
+			if !isKeyword(v) && keywordLike.MatchString(v) {
+				// Reserved for future keywords, ignored
+				defined[term] = true
+				return nil
+			}
+			expanded, _, err := p.expandIRI(active, v, false, true, local, defined)
+			if err != nil {
+				return err
+			}
+			if !isKeyword(expanded) && !strings.Contains(expanded, ":") {
+				return &JSONLDError{Code: "invalid IRI mapping", Message: v}
+			}
+			if expanded == "@context" {
+				return &JSONLDError{Code: "invalid keyword alias", Message: term}
+			}
+			def.id = expanded
+			if simpleTerm && !strings.Contains(term, ":") && !strings.Contains(term, "/") &&
+				(isBlankNodeID(expanded) || strings.ContainsAny(expanded[len(expanded)-1:], ":/?#[]@")) {
+				def.prefix = true
+			}
+		default:
+			return &JSONLDError{Code: "invalid IRI mapping", Message: term}
+		}
+	} else if idx := strings.Index(term[1:], ":"); idx >= 0 {
+		prefix, suffix := term[:idx+1], term[idx+2:]
+		if _, ok := local[prefix]; ok {
+			if err := p.createTermDefinition(active, local, prefix, defined); err != nil {
+				return err
+			}
+		}
+		if prefixDef := active.terms[prefix]; prefixDef != nil && !prefixDef.null {
+			def.id = prefixDef.id + suffix
+		} else {
+			def.id = term
+		}
+	} else if strings.Contains(term, "/") {
+		expanded, _, err := p.expandIRI(active, term, false, true, nil, nil)
+		if err != nil {
+			return err
+		}
+		if !isAbsoluteIRI(expanded) {
+			return &JSONLDError{Code: "invalid IRI mapping", Message: term}
+		}
+		def.id = expanded
+	} else if active.vocab != "" {
+		def.id = active.vocab + term
+	} else {
+		return &JSONLDError{Code: "invalid IRI mapping", Message: fmt.Sprintf("%s has no IRI mapping and there is no @vocab", term)}
+	}
+
+	if err := parseContainer(def, definition, term); err != nil {
+		return err
+	}
+
+	if index, ok := definition["@index"]; ok {
+		indexString, ok := index.(string)
+		if !ok || !def.hasContainer("@index") {
+			return &JSONLDError{Code: "invalid term definition", Message: term}
+		}
+		def.index = indexString
+	}
+
+	if context, ok := definition["@context"]; ok {
+		def.context = context
+		def.hasContext = true
+	}
+
+	if _, hasType := definition["@type"]; !hasType {
+		if language, ok := definition["@language"]; ok {
+			switch v := language.(type) {
+			case nil:
+				empty := ""
+				def.language = &empty
+			case string:
+				lower := strings.ToLower(v)
+				def.language = &lower
+			default:
+				return &JSONLDError{Code: "invalid language mapping", Message: term}
+			}
+		}
+		if direction, ok := definition["@direction"]; ok {
+			switch v := direction.(type) {
+			case nil:
+				empty := ""
+				def.direction = &empty
+			case string:
+				if v != "ltr" && v != "rtl" {
+					return &JSONLDError{Code: "invalid base direction", Message: term}
+				}
+				def.direction = &v
+			default:
+				return &JSONLDError{Code: "invalid base direction", Message: term}
+			}
+		}
+	}
+
+	if nest, ok := definition["@nest"]; ok {
+		nestString, ok := nest.(string)
+		if !ok || (isKeyword(nestString) && nestString != "@nest") {
+			return &JSONLDError{Code: "invalid @nest value", Message: term}
+		}
+		def.nest = nestString
+	}
+
+	if prefix, ok := definition["@prefix"]; ok {
+		prefixBool, ok := prefix.(bool)
+		if !ok || strings.Contains(term, ":") || strings.Contains(term, "/") {
+			return &JSONLDError{Code: "invalid term definition", Message: term}
+		}
+		def.prefix = prefixBool
+	}
+
+	active.terms[term] = def
+	defined[term] = true
+	return nil
+}
+
+// parseContainer reads the @container of a term definition
+func parseContainer(def *termDefinition, definition map[string]interface{}, term string) error {
+	container, ok := definition["@container"]
+	if !ok || container == nil {
+		return nil
+	}
+	values, ok := container.([]interface{})
+	if !ok {
+		values = []interface{}{container}
+	}
+	def.container = make(map[string]bool, len(values))
+	for _, value := range values {
+		s, ok := value.(string)
+		switch s {
+		case "@list", "@set", "@index", "@language", "@id", "@type", "@graph":
+		default:
+			ok = false
+		}
+		if !ok {
+			return &JSONLDError{Code: "invalid container mapping", Message: term}
+		}
+		def.container[s] = true
+	}
+	if def.container["@list"] && len(def.container) > 1 {
+		return &JSONLDError{Code: "invalid container mapping", Message: term}
+	}
+	if def.container["@type"] {
+		if def.typeMapping == "" {
+			def.typeMapping = "@id"
+		}
+		if def.typeMapping != "@id" && def.typeMapping != "@vocab" {
+			return &JSONLDError{Code: "invalid type mapping", Message: term}
+		}
+	}
+	if def.reverse {
+		for c := range def.container {
+			if c != "@set" && c != "@index" {
+				return &JSONLDError{Code: "invalid reverse property", Message: term}
+			}
+		}
+	}
+	return nil
+}
+
+// expandIRI expands a term, compact IRI or relative IRI. The boolean result
+// is false when value is mapped to null or ignored and must be dropped.
+func (p *jsonldProcessor) expandIRI(active *jsonldContext, value string, documentRelative, vocab bool, local map[string]interface{}, defined map[string]bool) (string, bool, error) {
+	if isKeyword(value) {
+		return value, true, nil
+	}
+	if keywordLike.MatchString(value) {
+		return "", false, nil
+	}
+
+	if local != nil {
+		if _, ok := local[value]; ok && !defined[value] {
+			if err := p.createTermDefinition(active, local, value, defined); err != nil {
+				return "", false, err
+			}
+		}
+	}
+
+	if def := active.terms[value]; def != nil {
+		if isKeyword(def.id) {
+			return def.id, true, nil
+		}
+		if vocab {
+			if def.null {
+				return "", false, nil
+			}
+			return def.id, true, nil
+		}
+	}
+
+	if idx := strings.Index(value, ":"); idx > 0 {
+		prefix, suffix := value[:idx], value[idx+1:]
+		if prefix == "_" || strings.HasPrefix(suffix, "//") {
+			return value, true, nil
+		}
+		if local != nil {
+			if _, ok := local[prefix]; ok && !defined[prefix] {
+				if err := p.createTermDefinition(active, local, prefix, defined); err != nil {
+					return "", false, err
+				}
+			}
+		}
+		if def := active.terms[prefix]; def != nil && !def.null && def.prefix {
+			return def.id + suffix, true, nil
+		}
+		if isAbsoluteIRI(value) {
+			return value, true, nil
+		}
+	}
+
+	if vocab && active.vocab != "" {
+		return active.vocab + value, true, nil
+	}
+	if documentRelative {
+		return resolveIRI(active.base, value), true, nil
+	}
+	return value, true, nil
+}
+
+// resolveIRI resolves a relative IRI against base
+func resolveIRI(base, value string) string {
+	if base == "" || isAbsoluteIRI(value) {
+		return value
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return value
+	}
+	ref, err := url.Parse(value)
+	if err != nil {
+		return value
+	}
+	return baseURL.ResolveReference(ref).String()
+}
+
+// sortedKeys returns the keys of m in lexicographic order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
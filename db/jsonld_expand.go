@@ -0,0 +1,729 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// expandDocument runs the expansion algorithm on a document and returns
+// the expanded form as an array of node objects
+func (p *jsonldProcessor) expandDocument(document interface{}) ([]interface{}, error) {
+	active := newJSONLDContext(p.options.Base)
+	if p.options.ExpandContext != nil {
+		context := p.options.ExpandContext
+		if m, ok := context.(map[string]interface{}); ok {
+			if inner, ok := m["@context"]; ok {
+				context = inner
+			}
+		}
+		var err error
+		if active, err = p.processContext(active, context, nil, true); err != nil {
+			return nil, err
+		}
+	}
+
+	expanded, err := p.expand(active, "", document, false)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := expanded.(map[string]interface{}); ok && len(m) == 1 {
+		if graph, ok := m["@graph"]; ok {
+			expanded = graph
+		}
+	}
+	if expanded == nil {
+		return []interface{}{}, nil
+	}
+	return asArray(expanded), nil
+}
+
+// expand implements the JSON-LD 1.1 expansion algorithm for one element
+func (p *jsonldProcessor) expand(active *jsonldContext, activeProperty string, element interface{}, fromMap bool) (interface{}, error) {
+	if element == nil {
+		return nil, nil
+	}
+
+	propertyDef := active.terms[activeProperty]
+
+	switch v := element.(type) {
+	case []interface{}:
+		result := []interface{}{}
+		for _, item := range v {
+			expanded, err := p.expand(active, activeProperty, item, fromMap)
+			if err != nil {
+				return nil, err
+			}
+			if propertyDef.hasContainer("@list") {
+				if list, ok := expanded.([]interface{}); ok {
+					expanded = map[string]interface{}{"@list": list}
+				}
+			}
+			switch e := expanded.(type) {
+			case nil:
+			case []interface{}:
+				result = append(result, e...)
+			default:
+				result = append(result, e)
+			}
+		}
+		return result, nil
+
+	case map[string]interface{}:
+		return p.expandMap(active, activeProperty, propertyDef, v, fromMap)
+
+	default:
+		// Free-floating scalars are dropped
+		if activeProperty == "" || activeProperty == "@graph" {
+			return nil, nil
+		}
+		if propertyDef != nil && propertyDef.hasContext {
+			var err error
+			if active, err = p.processContext(active, propertyDef.context, nil, true); err != nil {
+				return nil, err
+			}
+		}
+		return p.expandValue(active, activeProperty, element)
+	}
+}
+
+// expandMap expands a JSON object into a node, value, list or set object
+func (p *jsonldProcessor) expandMap(active *jsonldContext, activeProperty string, propertyDef *termDefinition, element map[string]interface{}, fromMap bool) (interface{}, error) {
+	// Type-scoped contexts do not propagate into nested node objects
+	if active.previous != nil && !fromMap {
+		revert := true
+		for key := range element {
+			expanded, _, err := p.expandIRI(active, key, false, true, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			if expanded == "@value" || (expanded == "@id" && len(element) == 1) {
+				revert = false
+				break
+			}
+		}
+		if revert {
+			active = active.previous
+		}
+	}
+
+	var err error
+	if propertyDef != nil && propertyDef.hasContext {
+		if active, err = p.processContext(active, propertyDef.context, nil, true); err != nil {
+			return nil, err
+		}
+	}
+	if context, ok := element["@context"]; ok {
+		if active, err = p.processContext(active, context, nil, true); err != nil {
+			return nil, err
+		}
+	}
+
+	typeScoped := active
+	inputType := ""
+	for _, key := range sortedKeys(element) {
+		expanded, _, err := p.expandIRI(active, key, false, true, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if expanded != "@type" {
+			continue
+		}
+		types := stringValues(element[key])
+		sortedTypes := append([]string(nil), types...)
+		sort.Strings(sortedTypes)
+		for _, t := range sortedTypes {
+			if def := typeScoped.terms[t]; def != nil && def.hasContext {
+				if active, err = p.processContext(active, def.context, nil, false); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(types) > 0 {
+			inputType, _, err = p.expandIRI(active, types[len(types)-1], true, true, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := make(map[string]interface{})
+	if err := p.expandObject(active, typeScoped, activeProperty, element, result, inputType); err != nil {
+		return nil, err
+	}
+
+	if value, ok := result["@value"]; ok {
+		for key := range result {
+			switch key {
+			case "@direction", "@index", "@language", "@type", "@value":
+			default:
+				return nil, &JSONLDError{Code: "invalid value object", Message: fmt.Sprintf("unexpected %s", key)}
+			}
+		}
+		if _, hasLanguage := result["@language"]; hasLanguage {
+			if _, hasType := result["@type"]; hasType {
+				return nil, &JSONLDError{Code: "invalid value object", Message: "@language and @type"}
+			}
+		}
+		if result["@type"] == "@json" {
+			return result, nil
+		}
+		if value == nil {
+			return nil, nil
+		}
+		if _, hasLanguage := result["@language"]; hasLanguage {
+			if _, ok := value.(string); !ok {
+				return nil, &JSONLDError{Code: "invalid language-tagged value", Message: fmt.Sprintf("%v", value)}
+			}
+		}
+		if t, ok := result["@type"]; ok {
+			if s, ok := t.(string); !ok || !isAbsoluteIRI(s) {
+				return nil, &JSONLDError{Code: "invalid typed value", Message: fmt.Sprintf("%v", t)}
+			}
+		}
+	} else if t, ok := result["@type"]; ok {
+		result["@type"] = asArray(t)
+	} else if _, isSet := result["@set"]; isSet {
+		if len(result) > 2 || (len(result) == 2 && result["@index"] == nil) {
+			return nil, &JSONLDError{Code: "invalid set or list object", Message: "unexpected entries"}
+		}
+		return result["@set"], nil
+	} else if _, isList := result["@list"]; isList {
+		if len(result) > 2 || (len(result) == 2 && result["@index"] == nil) {
+			return nil, &JSONLDError{Code: "invalid set or list object", Message: "unexpected entries"}
+		}
+	}
+
+	if len(result) == 1 {
+		if _, ok := result["@language"]; ok {
+			return nil, nil
+		}
+	}
+
+	if activeProperty == "" || activeProperty == "@graph" {
+		_, hasValue := result["@value"]
+		_, hasList := result["@list"]
+		_, hasID := result["@id"]
+		if len(result) == 0 || hasValue || hasList || (len(result) == 1 && hasID) {
+			return nil, nil
+		}
+	}
+
+	return result, nil
+}
+
+// expandObject expands the entries of element into result
+func (p *jsonldProcessor) expandObject(active, typeScoped *jsonldContext, activeProperty string, element, result map[string]interface{}, inputType string) error {
+	var nests []string
+
+	for _, key := range sortedKeys(element) {
+		if key == "@context" {
+			continue
+		}
+		value := element[key]
+		expandedProperty, ok, err := p.expandIRI(active, key, false, true, nil, nil)
+		if err != nil {
+			return err
+		}
+		if !ok || expandedProperty == "" || (!isKeyword(expandedProperty) && !strings.Contains(expandedProperty, ":")) {
+			continue
+		}
+
+		if isKeyword(expandedProperty) {
+			if activeProperty == "@reverse" {
+				return &JSONLDError{Code: "invalid reverse property map", Message: key}
+			}
+			if _, exists := result[expandedProperty]; exists && expandedProperty != "@included" && expandedProperty != "@type" {
+				return &JSONLDError{Code: "colliding keywords", Message: expandedProperty}
+			}
+
+			var expandedValue interface{}
+			switch expandedProperty {
+			case "@id":
+				id, ok := value.(string)
+				if !ok {
+					return &JSONLDError{Code: "invalid @id value", Message: fmt.Sprintf("%v", value)}
+				}
+				expandedValue, _, err = p.expandIRI(active, id, true, false, nil, nil)
+				if err != nil {
+					return err
+				}
+			case "@type":
+				types, ok := typeStrings(value)
+				if !ok {
+					return &JSONLDError{Code: "invalid type value", Message: fmt.Sprintf("%v", value)}
+				}
+				expandedTypes := make([]interface{}, 0, len(types))
+				for _, t := range types {
+					expanded, ok, err := p.expandIRI(typeScoped, t, true, true, nil, nil)
+					if err != nil {
+						return err
+					}
+					if ok {
+						expandedTypes = append(expandedTypes, expanded)
+					}
+				}
+				if _, isString := value.(string); isString && len(expandedTypes) == 1 {
+					expandedValue = expandedTypes[0]
+				} else {
+					expandedValue = expandedTypes
+				}
+				if existing, ok := result["@type"]; ok {
+					expandedValue = append(asArray(existing), asArray(expandedValue)...)
+				}
+			case "@graph":
+				expanded, err := p.expand(active, "@graph", value, false)
+				if err != nil {
+					return err
+				}
+				expandedValue = asArray(expanded)
+			case "@included":
+				expanded, err := p.expand(active, "", value, false)
+				if err != nil {
+					return err
+				}
+				included := asArray(expanded)
+				for _, item := range included {
+					if !isNodeObject(item) {
+						return &JSONLDError{Code: "invalid @included value", Message: fmt.Sprintf("%v", item)}
+					}
+				}
+				if existing, ok := result["@included"]; ok {
+					included = append(asArray(existing), included...)
+				}
+				expandedValue = included
+			case "@value":
+				if inputType == "@json" {
+					result["@value"] = value
+					continue
+				}
+				switch value.(type) {
+				case nil:
+					result["@value"] = nil
+					continue
+				case string, bool, float64, int, int64:
+					expandedValue = value
+				default:
+					return &JSONLDError{Code: "invalid value object value", Message: fmt.Sprintf("%v", value)}
+				}
+			case "@language":
+				language, ok := value.(string)
+				if !ok {
+					return &JSONLDError{Code: "invalid language-tagged string", Message: fmt.Sprintf("%v", value)}
+				}
+				expandedValue = strings.ToLower(language)
+			case "@direction":
+				if value != "ltr" && value != "rtl" {
+					return &JSONLDError{Code: "invalid base direction", Message: fmt.Sprintf("%v", value)}
+				}
+				expandedValue = value
+			case "@index":
+				if _, ok := value.(string); !ok {
+					return &JSONLDError{Code: "invalid @index value", Message: fmt.Sprintf("%v", value)}
+				}
+				expandedValue = value
+			case "@list":
+				if activeProperty == "" || activeProperty == "@graph" {
+					continue
+				}
+				expanded, err := p.expand(active, activeProperty, value, false)
+				if err != nil {
+					return err
+				}
+				expandedValue = asArray(expanded)
+			case "@set":
+				expandedValue, err = p.expand(active, activeProperty, value, false)
+				if err != nil {
+					return err
+				}
+			case "@reverse":
+				if _, ok := value.(map[string]interface{}); !ok {
+					return &JSONLDError{Code: "invalid @reverse value", Message: fmt.Sprintf("%v", value)}
+				}
+				expanded, err := p.expand(active, "@reverse", value, false)
+				if err != nil {
+					return err
+				}
+				expandedMap, _ := expanded.(map[string]interface{})
+				if nested, ok := expandedMap["@reverse"].(map[string]interface{}); ok {
+					for property, items := range nested {
+						addValue(result, property, items, true)
+					}
+				}
+				for property, items := range expandedMap {
+					if property == "@reverse" {
+						continue
+					}
+					reverseMap, _ := result["@reverse"].(map[string]interface{})
+					if reverseMap == nil {
+						reverseMap = make(map[string]interface{})
+						result["@reverse"] = reverseMap
+					}
+					for _, item := range asArray(items) {
+						if isValueObject(item) || isListObject(item) {
+							return &JSONLDError{Code: "invalid reverse property value", Message: property}
+						}
+						addValue(reverseMap, property, item, true)
+					}
+				}
+				continue
+			case "@nest":
+				nests = append(nests, key)
+				continue
+			default:
+				// Framing keywords are not supported and ignored
+				continue
+			}
+			if expandedValue != nil {
+				result[expandedProperty] = expandedValue
+			}
+			continue
+		}
+
+		def := active.terms[key]
+		var expandedValue interface{}
+		switch {
+		case def != nil && def.typeMapping == "@json":
+			expandedValue = map[string]interface{}{"@value": value, "@type": "@json"}
+		case def.hasContainer("@language") && isMap(value):
+			expandedValue, err = p.expandLanguageMap(active, def, value.(map[string]interface{}))
+		case (def.hasContainer("@index") || def.hasContainer("@type") || def.hasContainer("@id")) && isMap(value):
+			expandedValue, err = p.expandIndexMap(active, key, def, value.(map[string]interface{}))
+		default:
+			expandedValue, err = p.expand(active, key, value, false)
+		}
+		if err != nil {
+			return err
+		}
+		if expandedValue == nil {
+			continue
+		}
+
+		if def.hasContainer("@list") && !isListObject(expandedValue) {
+			expandedValue = map[string]interface{}{"@list": asArray(expandedValue)}
+		}
+		if def.hasContainer("@graph") && !def.hasContainer("@id") && !def.hasContainer("@index") {
+			var graphs []interface{}
+			for _, item := range asArray(expandedValue) {
+				graphs = append(graphs, map[string]interface{}{"@graph": asArray(item)})
+			}
+			expandedValue = graphs
+		}
+
+		if def != nil && def.reverse {
+			reverseMap, _ := result["@reverse"].(map[string]interface{})
+			if reverseMap == nil {
+				reverseMap = make(map[string]interface{})
+				result["@reverse"] = reverseMap
+			}
+			for _, item := range asArray(expandedValue) {
+				if isValueObject(item) || isListObject(item) {
+					return &JSONLDError{Code: "invalid reverse property value", Message: key}
+				}
+				addValue(reverseMap, expandedProperty, item, true)
+			}
+			continue
+		}
+		addValue(result, expandedProperty, expandedValue, true)
+	}
+
+	for _, nestKey := range nests {
+		for _, nested := range asArray(element[nestKey]) {
+			nestedMap, ok := nested.(map[string]interface{})
+			if !ok {
+				return &JSONLDError{Code: "invalid @nest value", Message: nestKey}
+			}
+			for key := range nestedMap {
+				if expanded, _, _ := p.expandIRI(active, key, false, true, nil, nil); expanded == "@value" {
+					return &JSONLDError{Code: "invalid @nest value", Message: nestKey}
+				}
+			}
+			if err := p.expandObject(active, typeScoped, activeProperty, nestedMap, result, inputType); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// expandLanguageMap expands the value of a term with an @language container
+func (p *jsonldProcessor) expandLanguageMap(active *jsonldContext, def *termDefinition, value map[string]interface{}) (interface{}, error) {
+	result := []interface{}{}
+	direction := active.direction
+	if def.direction != nil {
+		direction = *def.direction
+	}
+	for _, language := range sortedKeys(value) {
+		expandedLanguage, _, err := p.expandIRI(active, language, false, true, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range asArray(value[language]) {
+			if item == nil {
+				continue
+			}
+			s, ok := item.(string)
+			if !ok {
+				return nil, &JSONLDError{Code: "invalid language map value", Message: fmt.Sprintf("%v", item)}
+			}
+			v := map[string]interface{}{"@value": s}
+			if expandedLanguage != "@none" {
+				v["@language"] = strings.ToLower(language)
+			}
+			if direction != "" {
+				v["@direction"] = direction
+			}
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// expandIndexMap expands the value of a term with an @index, @id or @type container
+func (p *jsonldProcessor) expandIndexMap(active *jsonldContext, key string, def *termDefinition, value map[string]interface{}) (interface{}, error) {
+	result := []interface{}{}
+	indexKey := def.index
+	if indexKey == "" {
+		indexKey = "@index"
+	}
+
+	for _, index := range sortedKeys(value) {
+		mapContext := active
+		if (def.hasContainer("@id") || def.hasContainer("@type")) && active.previous != nil {
+			mapContext = active.previous
+		}
+		if def.hasContainer("@type") {
+			if typeDef := mapContext.terms[index]; typeDef != nil && typeDef.hasContext {
+				var err error
+				if mapContext, err = p.processContext(mapContext, typeDef.context, nil, true); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		expandedIndex, _, err := p.expandIRI(active, index, false, true, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		expanded, err := p.expand(mapContext, key, asArray(value[index]), true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range asArray(expanded) {
+			if def.hasContainer("@graph") && !isGraphObject(item) {
+				item = map[string]interface{}{"@graph": asArray(item)}
+			}
+			itemMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch {
+			case def.hasContainer("@index") && indexKey != "@index" && expandedIndex != "@none":
+				reExpanded, err := p.expandValue(active, indexKey, index)
+				if err != nil {
+					return nil, err
+				}
+				property, _, err := p.expandIRI(active, indexKey, false, true, nil, nil)
+				if err != nil {
+					return nil, err
+				}
+				if isValueObject(itemMap) {
+					return nil, &JSONLDError{Code: "invalid value object", Message: "property-valued index on a value"}
+				}
+				itemMap[property] = append([]interface{}{reExpanded}, asArray(itemMap[property])...)
+			case def.hasContainer("@index") && itemMap["@index"] == nil && expandedIndex != "@none":
+				itemMap["@index"] = index
+			case def.hasContainer("@id") && itemMap["@id"] == nil && expandedIndex != "@none":
+				id, _, err := p.expandIRI(active, index, true, false, nil, nil)
+				if err != nil {
+					return nil, err
+				}
+				itemMap["@id"] = id
+			case def.hasContainer("@type") && expandedIndex != "@none":
+				itemMap["@type"] = append([]interface{}{expandedIndex}, asArray(itemMap["@type"])...)
+			}
+			result = append(result, itemMap)
+		}
+	}
+	return result, nil
+}
+
+// expandValue expands a scalar value using the term definition of activeProperty
+func (p *jsonldProcessor) expandValue(active *jsonldContext, activeProperty string, value interface{}) (interface{}, error) {
+	def := active.terms[activeProperty]
+	if s, ok := value.(string); ok && def != nil {
+		switch def.typeMapping {
+		case "@id":
+			id, _, err := p.expandIRI(active, s, true, false, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"@id": id}, nil
+		case "@vocab":
+			id, _, err := p.expandIRI(active, s, true, true, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"@id": id}, nil
+		}
+	}
+
+	result := map[string]interface{}{"@value": value}
+	if def != nil && def.typeMapping != "" && def.typeMapping != "@id" && def.typeMapping != "@vocab" && def.typeMapping != "@none" {
+		result["@type"] = def.typeMapping
+		return result, nil
+	}
+	if _, ok := value.(string); ok {
+		language := active.language
+		if def != nil && def.language != nil {
+			language = *def.language
+		}
+		if language != "" {
+			result["@language"] = language
+		}
+		direction := active.direction
+		if def != nil && def.direction != nil {
+			direction = *def.direction
+		}
+		if direction != "" {
+			result["@direction"] = direction
+		}
+	}
+	return result, nil
+}
+
+// addValue adds value to the entry key of object, creating an array when
+// asArray is set or the entry already has a value
+func addValue(object map[string]interface{}, key string, value interface{}, asArr bool) {
+	if values, ok := value.([]interface{}); ok {
+		if len(values) == 0 && asArr {
+			if _, exists := object[key]; !exists {
+				object[key] = []interface{}{}
+			}
+		}
+		for _, v := range values {
+			addValue(object, key, v, asArr)
+		}
+		return
+	}
+	existing, exists := object[key]
+	switch {
+	case exists:
+		object[key] = append(asArray(existing), value)
+	case asArr:
+		object[key] = []interface{}{value}
+	default:
+		object[key] = value
+	}
+}
+
+// addUniqueValue adds value to the array entry key unless it is already present
+func addUniqueValue(object map[string]interface{}, key string, value interface{}) {
+	existing := asArray(object[key])
+	for _, v := range existing {
+		if reflect.DeepEqual(v, value) {
+			object[key] = existing
+			return
+		}
+	}
+	object[key] = append(existing, value)
+}
+
+// asArray wraps non-array values in an array
+func asArray(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return []interface{}{}
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
+func isMap(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
+
+func isValueObject(value interface{}) bool {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, has := m["@value"]
+	return has
+}
+
+func isListObject(value interface{}) bool {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, has := m["@list"]
+	return has
+}
+
+func isGraphObject(value interface{}) bool {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if _, has := m["@graph"]; !has {
+		return false
+	}
+	for key := range m {
+		if key != "@graph" && key != "@id" && key != "@index" {
+			return false
+		}
+	}
+	return true
+}
+
+func isNodeObject(value interface{}) bool {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, hasValue := m["@value"]
+	_, hasList := m["@list"]
+	_, hasSet := m["@set"]
+	return !hasValue && !hasList && !hasSet
+}
+
+// isNodeReference reports whether value is a node object with only an @id
+func isNodeReference(value interface{}) bool {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, hasID := m["@id"]
+	return hasID && len(m) == 1
+}
+
+// stringValues returns the string items of a string or array value
+func stringValues(value interface{}) []string {
+	var values []string
+	for _, item := range asArray(value) {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// typeStrings returns the values of an @type entry, which must be strings
+func typeStrings(value interface{}) ([]string, bool) {
+	var values []string
+	for _, item := range asArray(value) {
+		s, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, s)
+	}
+	return values, true
+}
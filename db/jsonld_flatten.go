@@ -0,0 +1,197 @@
+package db
+
+import (
+	"reflect"
+)
+
+// jsonldNodeMap maps graph names to the nodes of the graph by @id.
+// The default graph is stored under "@default".
+type jsonldNodeMap map[string]map[string]map[string]interface{}
+
+// flattenExpanded flattens expanded JSON-LD into a sorted list of node
+// objects with named graphs embedded in their graph nodes
+func (p *jsonldProcessor) flattenExpanded(expanded []interface{}) ([]interface{}, error) {
+	nodeMap := jsonldNodeMap{"@default": {}}
+	if err := p.generateNodeMap(expanded, nodeMap, "@default", nil, "", nil); err != nil {
+		return nil, err
+	}
+
+	defaultGraph := nodeMap["@default"]
+	for _, graphName := range sortedKeys(nodeMap) {
+		if graphName == "@default" {
+			continue
+		}
+		entry, ok := defaultGraph[graphName]
+		if !ok {
+			entry = map[string]interface{}{"@id": graphName}
+			defaultGraph[graphName] = entry
+		}
+		entry["@graph"] = graphNodes(nodeMap[graphName])
+	}
+	return graphNodes(defaultGraph), nil
+}
+
+// graphNodes returns the nodes of a graph sorted by @id, omitting nodes
+// that consist of nothing but their @id
+func graphNodes(graph map[string]map[string]interface{}) []interface{} {
+	nodes := []interface{}{}
+	for _, id := range sortedKeys(graph) {
+		node := graph[id]
+		if len(node) == 1 {
+			if _, onlyID := node["@id"]; onlyID {
+				continue
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// generateNodeMap implements the node map generation algorithm. Blank nodes
+// are relabeled with the processor's issuer; activeSubject is a node @id, or
+// a node reference when collecting reverse properties.
+func (p *jsonldProcessor) generateNodeMap(element interface{}, nodeMap jsonldNodeMap, activeGraph string, activeSubject interface{}, activeProperty string, list map[string]interface{}) error {
+	if items, ok := element.([]interface{}); ok {
+		for _, item := range items {
+			if err := p.generateNodeMap(item, nodeMap, activeGraph, activeSubject, activeProperty, list); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	elem, ok := element.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	graph := nodeMap[activeGraph]
+	if graph == nil {
+		graph = make(map[string]map[string]interface{})
+		nodeMap[activeGraph] = graph
+	}
+	var subjectNode map[string]interface{}
+	if subject, ok := activeSubject.(string); ok {
+		subjectNode = graph[subject]
+	}
+
+	if types, ok := elem["@type"]; ok && !isValueObject(elem) {
+		var relabeled []interface{}
+		for _, t := range stringValues(types) {
+			relabeled = append(relabeled, p.relabelBlankNode(t))
+		}
+		elem = shallowCopy(elem)
+		elem["@type"] = relabeled
+	}
+
+	if isValueObject(elem) {
+		if list != nil {
+			list["@list"] = append(asArray(list["@list"]), elem)
+		} else if subjectNode != nil {
+			addUniqueValue(subjectNode, activeProperty, elem)
+		}
+		return nil
+	}
+
+	if listValue, ok := elem["@list"]; ok {
+		result := map[string]interface{}{"@list": []interface{}{}}
+		if err := p.generateNodeMap(listValue, nodeMap, activeGraph, activeSubject, activeProperty, result); err != nil {
+			return err
+		}
+		if list != nil {
+			list["@list"] = append(asArray(list["@list"]), result)
+		} else if subjectNode != nil {
+			subjectNode[activeProperty] = append(asArray(subjectNode[activeProperty]), result)
+		}
+		return nil
+	}
+
+	var id string
+	if value, ok := elem["@id"].(string); ok {
+		id = p.relabelBlankNode(value)
+	} else {
+		id = p.issuer.next()
+	}
+	node, exists := graph[id]
+	if !exists {
+		node = map[string]interface{}{"@id": id}
+		graph[id] = node
+	}
+
+	switch subject := activeSubject.(type) {
+	case map[string]interface{}:
+		addUniqueValue(node, activeProperty, subject)
+	default:
+		if activeProperty != "" {
+			reference := map[string]interface{}{"@id": id}
+			if list != nil {
+				list["@list"] = append(asArray(list["@list"]), reference)
+			} else if subjectNode != nil {
+				addUniqueValue(subjectNode, activeProperty, reference)
+			}
+		}
+	}
+
+	if types, ok := elem["@type"]; ok {
+		for _, t := range asArray(types) {
+			addUniqueValue(node, "@type", t)
+		}
+	}
+	if index, ok := elem["@index"]; ok {
+		if existing, ok := node["@index"]; ok && !reflect.DeepEqual(existing, index) {
+			return &JSONLDError{Code: "conflicting indexes", Message: id}
+		}
+		node["@index"] = index
+	}
+	if reverse, ok := elem["@reverse"].(map[string]interface{}); ok {
+		referenced := map[string]interface{}{"@id": id}
+		for _, property := range sortedKeys(reverse) {
+			for _, value := range asArray(reverse[property]) {
+				if err := p.generateNodeMap(value, nodeMap, activeGraph, referenced, property, nil); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if graphValue, ok := elem["@graph"]; ok {
+		if err := p.generateNodeMap(graphValue, nodeMap, id, nil, "", nil); err != nil {
+			return err
+		}
+	}
+	if included, ok := elem["@included"]; ok {
+		if err := p.generateNodeMap(included, nodeMap, activeGraph, nil, "", nil); err != nil {
+			return err
+		}
+	}
+
+	for _, property := range sortedKeys(elem) {
+		switch property {
+		case "@id", "@type", "@index", "@reverse", "@graph", "@included":
+			continue
+		}
+		target := p.relabelBlankNode(property)
+		if _, ok := node[target]; !ok {
+			node[target] = []interface{}{}
+		}
+		if err := p.generateNodeMap(elem[property], nodeMap, activeGraph, id, target, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relabelBlankNode issues a new label for blank node identifiers and
+// returns other values unchanged
+func (p *jsonldProcessor) relabelBlankNode(id string) string {
+	if isBlankNodeID(id) {
+		return p.issuer.issue(id)
+	}
+	return id
+}
+
+func shallowCopy(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// toRDF converts expanded JSON-LD to an RDF dataset. Relative IRIs and
+// blank node properties cannot be represented and are skipped.
+func (p *jsonldProcessor) toRDF(expanded []interface{}) ([]Quad, error) {
+	nodeMap := jsonldNodeMap{"@default": {}}
+	if err := p.generateNodeMap(expanded, nodeMap, "@default", nil, "", nil); err != nil {
+		return nil, err
+	}
+
+	var quads []Quad
+	for _, graphName := range sortedKeys(nodeMap) {
+		var graph Term
+		if graphName != "@default" {
+			var ok bool
+			if graph, ok = nodeTerm(graphName); !ok {
+				continue
+			}
+		}
+
+		nodes := nodeMap[graphName]
+		for _, id := range sortedKeys(nodes) {
+			subject, ok := nodeTerm(id)
+			if !ok {
+				continue
+			}
+			node := nodes[id]
+			for _, property := range sortedKeys(node) {
+				if property == "@type" {
+					for _, t := range stringValues(node[property]) {
+						if object, ok := nodeTerm(t); ok {
+							quads = append(quads, Quad{Subject: subject, Predicate: NewIRI(RDFType), Object: object, Graph: graph})
+						}
+					}
+					continue
+				}
+				if isKeyword(property) || isBlankNodeID(property) || !isAbsoluteIRI(property) {
+					continue
+				}
+				predicate := NewIRI(property)
+				for _, item := range asArray(node[property]) {
+					var listQuads []Quad
+					object, ok := p.objectToRDF(item, &listQuads)
+					if !ok {
+						continue
+					}
+					quads = append(quads, Quad{Subject: subject, Predicate: predicate, Object: object, Graph: graph})
+					for _, listQuad := range listQuads {
+						listQuad.Graph = graph
+						quads = append(quads, listQuad)
+					}
+				}
+			}
+		}
+	}
+	return quads, nil
+}
+
+// nodeTerm returns the term for a node identifier; relative IRIs are not
+// valid RDF terms
+func nodeTerm(id string) (Term, bool) {
+	if isBlankNodeID(id) {
+		return NewBlankNode(id), true
+	}
+	if isAbsoluteIRI(id) {
+		return NewIRI(id), true
+	}
+	return Term{}, false
+}
+
+// objectToRDF converts a node reference, list or value object to a term,
+// appending the statements of list nodes to listQuads
+func (p *jsonldProcessor) objectToRDF(item interface{}, listQuads *[]Quad) (Term, bool) {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return Term{}, false
+	}
+
+	if isNodeReference(m) {
+		id, _ := m["@id"].(string)
+		return nodeTerm(id)
+	}
+	if list, ok := m["@list"]; ok {
+		return p.listToRDF(asArray(list), listQuads), true
+	}
+	if !isValueObject(m) {
+		return Term{}, false
+	}
+
+	value := m["@value"]
+	datatype, _ := m["@type"].(string)
+	if datatype != "" && datatype != "@json" && !isAbsoluteIRI(datatype) {
+		return Term{}, false
+	}
+
+	if datatype == "@json" {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(value); err != nil {
+			return Term{}, false
+		}
+		return NewLiteral(strings.TrimSuffix(buf.String(), "\n"), RDFJSON), true
+	}
+
+	switch v := value.(type) {
+	case bool:
+		if datatype == "" {
+			datatype = XSDBoolean
+		}
+		return NewLiteral(strconv.FormatBool(v), datatype), true
+	case string:
+		if language, ok := m["@language"].(string); ok {
+			return NewLangLiteral(v, strings.ToLower(language)), true
+		}
+		return NewLiteral(v, datatype), true
+	default:
+		number, ok := toFloat64(value)
+		if !ok {
+			return Term{}, false
+		}
+		if number != math.Trunc(number) || math.Abs(number) >= 1e21 || datatype == XSDDouble {
+			if datatype == "" {
+				datatype = XSDDouble
+			}
+			return NewLiteral(canonicalDouble(number), datatype), true
+		}
+		if datatype == "" {
+			datatype = XSDInteger
+		}
+		return NewLiteral(strconv.FormatFloat(number, 'f', -1, 64), datatype), true
+	}
+}
+
+// listToRDF converts a list to an rdf:first/rdf:rest chain and returns its head
+func (p *jsonldProcessor) listToRDF(list []interface{}, listQuads *[]Quad) Term {
+	if len(list) == 0 {
+		return NewIRI(RDFNil)
+	}
+
+	nodes := make([]Term, len(list))
+	for i := range list {
+		nodes[i] = NewBlankNode(p.issuer.next())
+	}
+	for i, item := range list {
+		if object, ok := p.objectToRDF(item, listQuads); ok {
+			*listQuads = append(*listQuads, Quad{Subject: nodes[i], Predicate: NewIRI(RDFFirst), Object: object})
+		}
+		rest := NewIRI(RDFNil)
+		if i+1 < len(nodes) {
+			rest = nodes[i+1]
+		}
+		*listQuads = append(*listQuads, Quad{Subject: nodes[i], Predicate: NewIRI(RDFRest), Object: rest})
+	}
+	return nodes[0]
+}
+
+// canonicalDouble formats f in the canonical xsd:double form, e.g. 1.1E0
+func canonicalDouble(f float64) string {
+	s := strconv.FormatFloat(f, 'E', -1, 64)
+	mantissa, exponent, _ := strings.Cut(s, "E")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	exp, _ := strconv.Atoi(exponent)
+	return mantissa + "E" + strconv.Itoa(exp)
+}
+
+// toFloat64 converts the numeric types a decoded or hand-built document may contain
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,472 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const personContext = `{
+  "@vocab": "http://schema.org/",
+  "ex": "http://example.org/",
+  "foaf": "http://xmlns.com/foaf/0.1/",
+  "xsd": "http://www.w3.org/2001/XMLSchema#",
+  "knows": {"@id": "foaf:knows", "@type": "@id"},
+  "born": {"@id": "ex:born", "@type": "xsd:date"},
+  "tags": {"@id": "ex:tags", "@container": "@list"},
+  "label": {"@id": "ex:label", "@container": "@language"}
+}`
+
+// jsonDoc parses a JSON test document
+func jsonDoc(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(s), &doc))
+	return doc
+}
+
+func personDoc(t *testing.T) map[string]interface{} {
+	return jsonDoc(t, `{
+	  "@context": `+personContext+`,
+	  "@id": "ex:alice",
+	  "@type": "Person",
+	  "name": "Alice",
+	  "knows": ["ex:bob", "ex:carol"],
+	  "born": "1990-01-01",
+	  "tags": ["a", "b"],
+	  "label": {"en": "Alice", "de": "Alicia"},
+	  "age": 42,
+	  "address": {
+	    "@context": {"city": "ex:city"},
+	    "city": "Berlin",
+	    "streetAddress": "Main 1"
+	  }
+	}`)
+}
+
+// TestExpandJSONLDDocument tests expansion with prefixes, @vocab, coercion and nested contexts
+func TestExpandJSONLDDocument(t *testing.T) {
+	t.Run("custom context", func(t *testing.T) {
+		nodes, err := ExpandJSONLDDocument(personDoc(t), nil)
+		require.NoError(t, err)
+		require.Len(t, nodes, 1)
+		node := nodes[0].(map[string]interface{})
+
+		assert.Equal(t, "http://example.org/alice", node["@id"])
+		assert.Equal(t, []interface{}{"http://schema.org/Person"}, node["@type"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"@value": "Alice"}}, node["http://schema.org/name"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"@id": "http://example.org/bob"},
+			map[string]interface{}{"@id": "http://example.org/carol"},
+		}, node["http://xmlns.com/foaf/0.1/knows"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"@value": "1990-01-01", "@type": "http://www.w3.org/2001/XMLSchema#date"},
+		}, node["http://example.org/born"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"@list": []interface{}{
+			map[string]interface{}{"@value": "a"},
+			map[string]interface{}{"@value": "b"},
+		}}}, node["http://example.org/tags"])
+		assert.ElementsMatch(t, []interface{}{
+			map[string]interface{}{"@value": "Alice", "@language": "en"},
+			map[string]interface{}{"@value": "Alicia", "@language": "de"},
+		}, node["http://example.org/label"])
+
+		address := node["http://schema.org/address"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, []interface{}{map[string]interface{}{"@value": "Berlin"}}, address["http://example.org/city"])
+		assert.Contains(t, address, "http://schema.org/streetAddress")
+	})
+
+	t.Run("offline schema.org context", func(t *testing.T) {
+		expanded, err := ExpandJSONLD(map[string]interface{}{
+			"@context":  "https://schema.org/",
+			"type":      "Person",
+			"id":        "https://example.com/john",
+			"name":      "John",
+			"url":       "https://john.example",
+			"birthDate": "1990-01-01",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://example.com/john", expanded["@id"])
+		assert.Equal(t, []interface{}{"http://schema.org/Person"}, expanded["@type"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"@id": "https://john.example"}}, expanded["http://schema.org/url"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"@value": "1990-01-01", "@type": "http://schema.org/Date"},
+		}, expanded["http://schema.org/birthDate"])
+	})
+
+	t.Run("registered remote context", func(t *testing.T) {
+		loader := NewStaticDocumentLoader(nil)
+		require.NoError(t, loader.AddDocument("https://eve.evalgo.org/context", `{"@context": {"eve": "https://eve.evalgo.org/ns#", "status": "eve:status"}}`))
+
+		nodes, err := ExpandJSONLDDocument(map[string]interface{}{
+			"@context": []interface{}{"https://schema.org", "https://eve.evalgo.org/context"},
+			"name":     "nginx",
+			"status":   "running",
+		}, &JSONLDOptions{DocumentLoader: loader})
+		require.NoError(t, err)
+		require.Len(t, nodes, 1)
+		node := nodes[0].(map[string]interface{})
+		assert.Contains(t, node, "http://schema.org/name")
+		assert.Contains(t, node, "https://eve.evalgo.org/ns#status")
+	})
+
+	t.Run("unknown remote context", func(t *testing.T) {
+		_, err := ExpandJSONLD(map[string]interface{}{
+			"@context": "https://example.com/unknown",
+			"name":     "x",
+		})
+		var jsonldErr *JSONLDError
+		require.True(t, errors.As(err, &jsonldErr))
+		assert.Equal(t, "loading document failed", jsonldErr.Code)
+	})
+
+	t.Run("multiple nodes", func(t *testing.T) {
+		expanded, err := ExpandJSONLD(map[string]interface{}{
+			"@context": "https://schema.org",
+			"@graph": []interface{}{
+				map[string]interface{}{"@id": "urn:a", "name": "A"},
+				map[string]interface{}{"@id": "urn:b", "name": "B"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Len(t, expanded["@graph"], 2)
+	})
+}
+
+// TestCompactJSONLDDocument tests compaction back to terms and containers
+func TestCompactJSONLDDocument(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		doc := personDoc(t)
+		context := jsonDoc(t, personContext)
+
+		expanded, err := ExpandJSONLDDocument(doc, nil)
+		require.NoError(t, err)
+		compacted, err := CompactJSONLDDocument(expanded, context, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, context, compacted["@context"])
+		assert.Equal(t, "ex:alice", compacted["@id"])
+		assert.Equal(t, "Person", compacted["@type"])
+		assert.Equal(t, "Alice", compacted["name"])
+		assert.Equal(t, []interface{}{"ex:bob", "ex:carol"}, compacted["knows"])
+		assert.Equal(t, "1990-01-01", compacted["born"])
+		assert.Equal(t, []interface{}{"a", "b"}, compacted["tags"])
+		assert.Equal(t, map[string]interface{}{"en": "Alice", "de": "Alicia"}, compacted["label"])
+		assert.Equal(t, float64(42), compacted["age"])
+		assert.Equal(t, map[string]interface{}{"ex:city": "Berlin", "streetAddress": "Main 1"}, compacted["address"])
+	})
+
+	t.Run("schema.org context", func(t *testing.T) {
+		compacted, err := CompactJSONLD(map[string]interface{}{
+			"@type":                  []interface{}{"http://schema.org/SoftwareApplication"},
+			"http://schema.org/name": "nginx",
+			"http://schema.org/url":  map[string]interface{}{"@id": "https://nginx.org"},
+		}, "https://schema.org")
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://schema.org", compacted["@context"])
+		assert.Equal(t, "SoftwareApplication", compacted["type"])
+		assert.Equal(t, "nginx", compacted["name"])
+		assert.Equal(t, "https://nginx.org", compacted["url"])
+	})
+
+	t.Run("values not matching coercion keep value objects", func(t *testing.T) {
+		compacted, err := CompactJSONLDDocument(map[string]interface{}{
+			"http://example.org/born": map[string]interface{}{"@value": "yesterday"},
+		}, jsonDoc(t, personContext), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "yesterday", compacted["ex:born"])
+		assert.NotContains(t, compacted, "born")
+	})
+}
+
+// TestFlattenJSONLD tests flattening of nested nodes
+func TestFlattenJSONLD(t *testing.T) {
+	doc := personDoc(t)
+
+	flattened, err := FlattenJSONLD(doc, nil, nil)
+	require.NoError(t, err)
+	graph := flattened["@graph"].([]interface{})
+	require.Len(t, graph, 2)
+
+	address := graph[0].(map[string]interface{})
+	alice := graph[1].(map[string]interface{})
+	assert.Equal(t, "_:b0", address["@id"])
+	assert.Equal(t, "http://example.org/alice", alice["@id"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"@id": "_:b0"}}, alice["http://schema.org/address"])
+
+	compacted, err := FlattenJSONLD(doc, jsonDoc(t, personContext), nil)
+	require.NoError(t, err)
+	graph = compacted["@graph"].([]interface{})
+	require.Len(t, graph, 2)
+	assert.Equal(t, map[string]interface{}{"@id": "_:b0"}, graph[1].(map[string]interface{})["address"])
+
+	single, err := FlattenJSONLD(map[string]interface{}{"@id": "urn:a", "http://schema.org/name": "A"}, "https://schema.org", nil)
+	require.NoError(t, err)
+	assert.Len(t, single["@graph"], 1)
+}
+
+// TestJSONLDToRDF tests conversion to RDF quads
+func TestJSONLDToRDF(t *testing.T) {
+	quads, err := JSONLDToRDF(map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@vocab": "http://schema.org/",
+			"list":   map[string]interface{}{"@id": "http://example.org/list", "@container": "@list"},
+		},
+		"@id":      "urn:app",
+		"@type":    "SoftwareApplication",
+		"name":     map[string]interface{}{"@value": "nginx", "@language": "EN"},
+		"active":   true,
+		"replicas": 3,
+		"ratio":    0.5,
+		"list":     []interface{}{"x"},
+		"relative": map[string]interface{}{"@id": "relative"},
+	}, nil)
+	require.NoError(t, err)
+
+	nquads := FormatNQuads(SortQuads(quads))
+	assert.Contains(t, nquads, `<urn:app> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/SoftwareApplication> .`)
+	assert.Contains(t, nquads, `<urn:app> <http://schema.org/name> "nginx"@en .`)
+	assert.Contains(t, nquads, `<urn:app> <http://schema.org/active> "true"^^<http://www.w3.org/2001/XMLSchema#boolean> .`)
+	assert.Contains(t, nquads, `<urn:app> <http://schema.org/replicas> "3"^^<http://www.w3.org/2001/XMLSchema#integer> .`)
+	assert.Contains(t, nquads, `<urn:app> <http://schema.org/ratio> "5.0E-1"^^<http://www.w3.org/2001/XMLSchema#double> .`)
+	assert.Contains(t, nquads, `<urn:app> <http://example.org/list> _:b0 .`)
+	assert.Contains(t, nquads, `_:b0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "x" .`)
+	assert.Contains(t, nquads, `_:b0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .`)
+	assert.NotContains(t, nquads, "relative")
+}
+
+// TestCanonicalizeQuads tests URDNA2015 blank node labeling
+func TestCanonicalizeQuads(t *testing.T) {
+	p1 := NewIRI("http://example.com/#p1")
+	p2 := NewIRI("http://example.com/#p2")
+	foo := NewLiteral("Foo", "")
+
+	t.Run("duplicated paths", func(t *testing.T) {
+		quads := []Quad{
+			{Subject: NewBlankNode("e0"), Predicate: p1, Object: NewBlankNode("e1")},
+			{Subject: NewBlankNode("e1"), Predicate: p2, Object: foo},
+			{Subject: NewBlankNode("e2"), Predicate: p1, Object: NewBlankNode("e3")},
+			{Subject: NewBlankNode("e3"), Predicate: p2, Object: foo},
+		}
+		assert.Equal(t, `_:c14n0 <http://example.com/#p1> _:c14n1 .
+_:c14n1 <http://example.com/#p2> "Foo" .
+_:c14n2 <http://example.com/#p1> _:c14n3 .
+_:c14n3 <http://example.com/#p2> "Foo" .
+`, CanonicalNQuads(quads))
+	})
+
+	t.Run("invariant to labels and order", func(t *testing.T) {
+		cycle := func(a, b, c string) []Quad {
+			return []Quad{
+				{Subject: NewBlankNode(a), Predicate: p1, Object: NewBlankNode(b)},
+				{Subject: NewBlankNode(b), Predicate: p1, Object: NewBlankNode(c)},
+				{Subject: NewBlankNode(c), Predicate: p1, Object: NewBlankNode(a)},
+				{Subject: NewBlankNode(a), Predicate: p2, Object: foo, Graph: NewIRI("urn:g")},
+			}
+		}
+		expected := CanonicalNQuads(cycle("x", "y", "z"))
+		assert.Equal(t, 4, strings.Count(expected, "\n"))
+		assert.Equal(t, expected, CanonicalNQuads(cycle("b", "c", "a")))
+
+		reversed := cycle("n1", "n2", "n3")
+		for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+			reversed[i], reversed[j] = reversed[j], reversed[i]
+		}
+		assert.Equal(t, expected, CanonicalNQuads(reversed))
+	})
+}
+
+// TestHashJSONLD tests hashing and de-duplication of equivalent documents
+func TestHashJSONLD(t *testing.T) {
+	compact := map[string]interface{}{
+		"@context": "https://schema.org",
+		"@type":    "Person",
+		"name":     "John Doe",
+		"address":  map[string]interface{}{"@id": "_:addr", "addressLocality": "Berlin"},
+	}
+	expanded := map[string]interface{}{
+		"http://schema.org/address": map[string]interface{}{
+			"http://schema.org/addressLocality": "Berlin",
+		},
+		"http://schema.org/name": "John Doe",
+		"@type":                  "http://schema.org/Person",
+	}
+	other := map[string]interface{}{
+		"@context": "https://schema.org",
+		"@type":    "Person",
+		"name":     "Jane Doe",
+	}
+
+	h1, err := HashJSONLD(compact)
+	require.NoError(t, err)
+	h2, err := HashJSONLD(expanded)
+	require.NoError(t, err)
+	h3, err := HashJSONLD(other)
+	require.NoError(t, err)
+	assert.Len(t, h1, 64)
+	assert.Equal(t, h1, h2)
+	assert.NotEqual(t, h1, h3)
+
+	unique, err := DeduplicateJSONLD([]interface{}{compact, other, expanded})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{compact, other}, unique)
+
+	_, err = DeduplicateJSONLD([]interface{}{compact, make(chan int)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "document 1")
+}
+
+// TestJSONLDToRDFCases checks expansion and RDF conversion of JSON-LD 1.1
+// features against the N-Quads the specification's algorithms produce
+func TestJSONLDToRDFCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		doc      string
+		expected string
+	}{
+		{
+			name:     "@base in context",
+			doc:      `{"@context": {"@base": "http://example.org/base/"}, "@id": "a", "http://ex/p": "v"}`,
+			expected: `<http://example.org/base/a> <http://ex/p> "v" .` + "\n",
+		},
+		{
+			name:     "base option",
+			base:     "http://example.com/doc",
+			doc:      `{"@id": "#me", "http://ex/p": {"@id": "other"}}`,
+			expected: `<http://example.com/doc#me> <http://ex/p> <http://example.com/other> .` + "\n",
+		},
+		{
+			name: "reverse property",
+			doc: `{"@context": {"children": {"@reverse": "http://ex/parent"}},
+			  "@id": "http://ex/bob", "children": [{"@id": "http://ex/alice"}, {"@id": "http://ex/carol"}]}`,
+			expected: `<http://ex/alice> <http://ex/parent> <http://ex/bob> .
+<http://ex/carol> <http://ex/parent> <http://ex/bob> .
+`,
+		},
+		{
+			name: "default language and term without language",
+			doc: `{"@context": {"@language": "de", "p": "http://ex/p", "q": {"@id": "http://ex/q", "@language": null}},
+			  "@id": "http://ex/s", "p": "Hallo", "q": "x"}`,
+			expected: `<http://ex/s> <http://ex/p> "Hallo"@de .
+<http://ex/s> <http://ex/q> "x" .
+`,
+		},
+		{
+			name: "vocabulary relative @type coercion",
+			doc: `{"@context": {"@vocab": "http://ex/", "link": {"@type": "@vocab"}},
+			  "@id": "http://ex/s", "link": "Thing"}`,
+			expected: `<http://ex/s> <http://ex/link> <http://ex/Thing> .` + "\n",
+		},
+		{
+			name:     "named graph",
+			doc:      `{"@id": "http://ex/g", "@graph": [{"@id": "http://ex/s", "http://ex/p": "o"}]}`,
+			expected: `<http://ex/s> <http://ex/p> "o" <http://ex/g> .` + "\n",
+		},
+		{
+			name: "index container",
+			doc: `{"@context": {"p": {"@id": "http://ex/p", "@container": "@index"}},
+			  "@id": "http://ex/s", "p": {"a": "x", "b": "y"}}`,
+			expected: `<http://ex/s> <http://ex/p> "x" .
+<http://ex/s> <http://ex/p> "y" .
+`,
+		},
+		{
+			name: "id map",
+			doc: `{"@context": {"p": {"@id": "http://ex/p", "@container": "@id"}},
+			  "@id": "http://ex/s", "p": {"http://ex/a": {"http://ex/q": "v"}}}`,
+			expected: `<http://ex/a> <http://ex/q> "v" .
+<http://ex/s> <http://ex/p> <http://ex/a> .
+`,
+		},
+		{
+			name: "type map",
+			doc: `{"@context": {"p": {"@id": "http://ex/p", "@container": "@type"}},
+			  "@id": "http://ex/s", "p": {"http://ex/T": {"@id": "http://ex/a"}}}`,
+			expected: `<http://ex/a> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://ex/T> .
+<http://ex/s> <http://ex/p> <http://ex/a> .
+`,
+		},
+		{
+			name: "type-scoped context",
+			doc: `{"@context": {"@vocab": "http://ex/", "Person": {"@context": {"name": "http://xmlns.com/foaf/0.1/name"}}},
+			  "@id": "http://ex/s", "@type": "Person", "name": "x"}`,
+			expected: `<http://ex/s> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://ex/Person> .
+<http://ex/s> <http://xmlns.com/foaf/0.1/name> "x" .
+`,
+		},
+		{
+			name: "property-scoped context",
+			doc: `{"@context": {"@vocab": "http://ex/", "knows": {"@context": {"name": "http://xmlns.com/foaf/0.1/name"}}},
+			  "@id": "http://ex/s", "name": "a", "knows": {"@id": "http://ex/o", "name": "b"}}`,
+			expected: `<http://ex/o> <http://xmlns.com/foaf/0.1/name> "b" .
+<http://ex/s> <http://ex/knows> <http://ex/o> .
+<http://ex/s> <http://ex/name> "a" .
+`,
+		},
+		{
+			name: "nested properties",
+			doc: `{"@context": {"@vocab": "http://ex/", "labels": "@nest"},
+			  "@id": "http://ex/s", "labels": {"main": "x"}}`,
+			expected: `<http://ex/s> <http://ex/main> "x" .` + "\n",
+		},
+		{
+			name:     "embedded blank node",
+			doc:      `{"@id": "http://ex/s", "http://ex/p": {"http://ex/q": "v"}}`,
+			expected: "<http://ex/s> <http://ex/p> _:c14n0 .\n" + `_:c14n0 <http://ex/q> "v" .` + "\n",
+		},
+		{
+			name: "numbers",
+			doc:  `{"@id": "http://ex/s", "http://ex/p": [10, 1.1, 1e21, 1.0]}`,
+			expected: `<http://ex/s> <http://ex/p> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://ex/s> <http://ex/p> "1.0E21"^^<http://www.w3.org/2001/XMLSchema#double> .
+<http://ex/s> <http://ex/p> "1.1E0"^^<http://www.w3.org/2001/XMLSchema#double> .
+<http://ex/s> <http://ex/p> "10"^^<http://www.w3.org/2001/XMLSchema#integer> .
+`,
+		},
+		{
+			name:     "null values and null terms are dropped",
+			doc:      `{"@context": {"p": null}, "@id": "http://ex/s", "p": "x", "http://ex/q": null, "http://ex/r": "y"}`,
+			expected: `<http://ex/s> <http://ex/r> "y" .` + "\n",
+		},
+		{
+			name: "included nodes",
+			doc: `{"@id": "http://ex/s", "http://ex/p": "v",
+			  "@included": [{"@id": "http://ex/t", "http://ex/p": "w"}]}`,
+			expected: `<http://ex/s> <http://ex/p> "v" .
+<http://ex/t> <http://ex/p> "w" .
+`,
+		},
+		{
+			name: "typed value with compact IRI datatype",
+			doc: `{"@context": {"xsd": "http://www.w3.org/2001/XMLSchema#", "d": {"@id": "http://ex/d", "@type": "xsd:date"}},
+			  "@id": "http://ex/s", "d": "2024-01-01"}`,
+			expected: `<http://ex/s> <http://ex/d> "2024-01-01"^^<http://www.w3.org/2001/XMLSchema#date> .` + "\n",
+		},
+		{
+			name: "empty list",
+			doc: `{"@context": {"l": {"@id": "http://ex/l", "@container": "@list"}},
+			  "@id": "http://ex/s", "l": []}`,
+			expected: `<http://ex/s> <http://ex/l> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .` + "\n",
+		},
+		{
+			name:     "literal escaping",
+			doc:      `{"@id": "http://ex/s", "http://ex/p": "tab\tquote\"line\nback\\"}`,
+			expected: "<http://ex/s> <http://ex/p> \"tab\tquote\\\"line\\nback\\\\\" .\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quads, err := JSONLDToRDF(jsonDoc(t, tt.doc), &JSONLDOptions{Base: tt.base})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, CanonicalNQuads(quads))
+		})
+	}
+}
@@ -0,0 +1,541 @@
+// Package migrate provides embedded, versioned PostgreSQL schema migrations.
+//
+// Packages that own tables (tracing, the state store, the event store) embed
+// their SQL files and register them as a component at init time:
+//
+//	//go:embed migrations/*.sql
+//	var migrationFS embed.FS
+//
+//	func init() {
+//		migrate.Register(migrate.MustLoad("tracing", migrationFS, "migrations"))
+//	}
+//
+// Migration files are named NNNN_description.up.sql and NNNN_description.down.sql.
+// Applied versions are recorded per component in the schema_migrations table
+// together with a checksum of the up script, so edits to already-applied
+// migrations are detected instead of silently ignored. Up and Down hold a
+// PostgreSQL advisory lock, so concurrently starting instances apply each
+// migration exactly once.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// TableName is the table recording applied migrations
+const TableName = "schema_migrations"
+
+// lockID is the advisory lock key held while migrating (shared by all components)
+const lockID int64 = 7461981625027153731
+
+var (
+	// ErrChecksumMismatch is returned when an applied migration differs from the embedded file
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+	// ErrSchemaBehind is returned by Verify when the database is older than expected
+	ErrSchemaBehind = errors.New("database schema is behind")
+
+	// ErrUnknownComponent is returned for components that were never registered
+	ErrUnknownComponent = errors.New("unknown migration component")
+)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum returns the SHA-256 of the up script
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// Set is the ordered list of migrations owned by a component
+type Set struct {
+	Component  string
+	Migrations []Migration
+}
+
+// Latest returns the highest version in the set (0 if empty)
+func (s *Set) Latest() int {
+	if len(s.Migrations) == 0 {
+		return 0
+	}
+	return s.Migrations[len(s.Migrations)-1].Version
+}
+
+// find returns the migration with the given version
+func (s *Set) find(version int) (Migration, bool) {
+	for _, m := range s.Migrations {
+		if m.Version == version {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_\-]+)\.(up|down)\.sql$`)
+
+// Load reads NNNN_name.up.sql / NNNN_name.down.sql files from dir in fsys.
+// Every version needs an up script; down scripts are optional.
+func Load(component string, fsys fs.FS, dir string) (*Set, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations for %s: %w", component, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s/%s", dir, entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("migration %s: version must be positive", entry.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	set := &Set{Component: component}
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		set.Migrations = append(set.Migrations, *m)
+	}
+	sort.Slice(set.Migrations, func(i, j int) bool {
+		return set.Migrations[i].Version < set.Migrations[j].Version
+	})
+	return set, nil
+}
+
+// MustLoad is like Load but panics on error; intended for embedded files in init functions
+func MustLoad(component string, fsys fs.FS, dir string) *Set {
+	set, err := Load(component, fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*Set)
+)
+
+// Register makes a component's migrations available to Up and Verify.
+// Registering the same component twice panics.
+func Register(set *Set) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[set.Component]; exists {
+		panic(fmt.Sprintf("migrate: component %s registered twice", set.Component))
+	}
+	registry[set.Component] = set
+}
+
+// Lookup returns the registered migrations of a component
+func Lookup(component string) (*Set, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	set, ok := registry[component]
+	return set, ok
+}
+
+// Components returns the registered component names in sorted order
+func Components() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Status describes one migration of a component
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Drifted   bool       `json:"drifted,omitempty"`
+}
+
+// appliedRecord is a row of schema_migrations
+type appliedRecord struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applies registered migrations to a database
+type Migrator struct {
+	db     *sql.DB
+	ownsDB bool
+}
+
+// New creates a migrator for a database/sql connection (lib/pq or pgx stdlib)
+func New(db *sql.DB) *Migrator {
+	return &Migrator{db: db}
+}
+
+// NewFromPool creates a migrator that borrows connections from a pgx pool.
+// Call Close when done; closing the pool remains the caller's responsibility.
+func NewFromPool(pool *pgxpool.Pool) *Migrator {
+	return &Migrator{db: stdlib.OpenDBFromPool(pool), ownsDB: true}
+}
+
+// Close releases the database/sql handle created by NewFromPool.
+// A database passed to New is left open.
+func (m *Migrator) Close() error {
+	if m.ownsDB {
+		return m.db.Close()
+	}
+	return nil
+}
+
+// Up applies all pending migrations of the given components (all registered components if none given).
+// A component whose applied migrations have drifted is not touched and stops the run.
+func (m *Migrator) Up(ctx context.Context, components ...string) error {
+	if len(components) == 0 {
+		components = Components()
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		for _, component := range components {
+			set, ok := Lookup(component)
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrUnknownComponent, component)
+			}
+			if err := m.upTo(ctx, conn, set, set.Latest()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpTo applies pending migrations of a component up to and including version
+func (m *Migrator) UpTo(ctx context.Context, component string, version int) error {
+	set, ok := Lookup(component)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownComponent, component)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		return m.upTo(ctx, conn, set, version)
+	})
+}
+
+// Down reverts the last steps applied migrations of a component, newest first
+func (m *Migrator) Down(ctx context.Context, component string, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive")
+	}
+	set, ok := Lookup(component)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownComponent, component)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := loadApplied(ctx, conn, component)
+		if err != nil {
+			return err
+		}
+		if err := checkDrift(set, applied); err != nil {
+			return err
+		}
+
+		versions := make([]int, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+		if steps < len(versions) {
+			versions = versions[:steps]
+		}
+
+		for _, version := range versions {
+			migration, ok := set.find(version)
+			if !ok {
+				return fmt.Errorf("cannot revert %s migration %d: not known to this build", component, version)
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("cannot revert %s migration %d_%s: no down script", component, version, migration.Name)
+			}
+			err := runInTx(ctx, conn, migration.Down,
+				fmt.Sprintf(`DELETE FROM %s WHERE component = $1 AND version = $2`, TableName),
+				component, version)
+			if err != nil {
+				return fmt.Errorf("failed to revert %s migration %d_%s: %w", component, version, migration.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Version returns the highest applied version of a component (0 if none)
+func (m *Migrator) Version(ctx context.Context, component string) (int, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return 0, err
+	}
+	applied, err := loadApplied(ctx, conn, component)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Status lists every known or applied migration of a component
+func (m *Migrator) Status(ctx context.Context, component string) ([]Status, error) {
+	set, ok := Lookup(component)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownComponent, component)
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := loadApplied(ctx, conn, component)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range set.Migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Drifted = record.Checksum != migration.Checksum()
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	// Versions applied by a newer build
+	for _, record := range applied {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, Applied: true, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Verify checks that a component is migrated to at least expected and that no
+// applied migration has drifted. A database ahead of expected is accepted, since
+// migrations are expected to stay backwards compatible for rolling deploys.
+func (m *Migrator) Verify(ctx context.Context, component string, expected int) error {
+	set, ok := Lookup(component)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownComponent, component)
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	applied, err := loadApplied(ctx, conn, component)
+	if err != nil {
+		return err
+	}
+	if err := checkDrift(set, applied); err != nil {
+		return err
+	}
+
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	if current < expected {
+		return fmt.Errorf("%w: %s is at version %d, expected %d", ErrSchemaBehind, component, current, expected)
+	}
+	return nil
+}
+
+// SchemaCheck returns a function suitable for coordinator.Config.SchemaCheck that verifies component
+func (m *Migrator) SchemaCheck(component string) func(ctx context.Context, expected int) error {
+	return func(ctx context.Context, expected int) error {
+		return m.Verify(ctx, component, expected)
+	}
+}
+
+// upTo applies pending migrations of set up to version on a locked connection
+func (m *Migrator) upTo(ctx context.Context, conn *sql.Conn, set *Set, version int) error {
+	applied, err := loadApplied(ctx, conn, set.Component)
+	if err != nil {
+		return err
+	}
+	if err := checkDrift(set, applied); err != nil {
+		return err
+	}
+
+	for _, migration := range set.Migrations {
+		if migration.Version > version {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := runInTx(ctx, conn, migration.Up,
+			fmt.Sprintf(`INSERT INTO %s (component, version, name, checksum) VALUES ($1, $2, $3, $4)`, TableName),
+			set.Component, migration.Version, migration.Name, migration.Checksum())
+		if err != nil {
+			return fmt.Errorf("failed to apply %s migration %d_%s: %w", set.Component, migration.Version, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Unlock even if ctx was cancelled; the lock is released with the session otherwise
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockID)
+	}()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// runInTx executes a migration script and its bookkeeping statement atomically
+func runInTx(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ensureTable creates schema_migrations if it does not exist
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			component TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (component, version)
+		)`, TableName))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", TableName, err)
+	}
+	return nil
+}
+
+// loadApplied returns the applied migrations of a component keyed by version
+func loadApplied(ctx context.Context, conn *sql.Conn, component string) (map[int]appliedRecord, error) {
+	rows, err := conn.QueryContext(ctx,
+		fmt.Sprintf(`SELECT version, name, checksum, applied_at FROM %s WHERE component = $1`, TableName),
+		component)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", TableName, err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedRecord)
+	for rows.Next() {
+		var record appliedRecord
+		if err := rows.Scan(&record.Version, &record.Name, &record.Checksum, &record.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", TableName, err)
+		}
+		applied[record.Version] = record
+	}
+	return applied, rows.Err()
+}
+
+// checkDrift compares applied checksums with the embedded migrations
+func checkDrift(set *Set, applied map[int]appliedRecord) error {
+	for _, migration := range set.Migrations {
+		record, ok := applied[migration.Version]
+		if ok && record.Checksum != migration.Checksum() {
+			return fmt.Errorf("%w: %s migration %d_%s was modified after it was applied",
+				ErrChecksumMismatch, set.Component, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+//go:build integration
+
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// setupPostgres starts a PostgreSQL container and returns a connection
+func setupPostgres(t *testing.T) *sql.DB {
+	ctx := context.Background()
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "postgres:16-alpine",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_USER":     "testuser",
+				"POSTGRES_PASSWORD": "testpass",
+				"POSTGRES_DB":       "testdb",
+			},
+			WaitingFor: wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	require.NoError(t, err, "Failed to start PostgreSQL container")
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%s user=testuser password=testpass dbname=testdb sslmode=disable", host, port.Port()))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrator_Integration_UpDownVerify(t *testing.T) {
+	db := setupPostgres(t)
+	ctx := context.Background()
+
+	Register(MustLoad("integration", fstest.MapFS{
+		"m/0001_items.up.sql":   {Data: []byte("CREATE TABLE items (id INT PRIMARY KEY);")},
+		"m/0001_items.down.sql": {Data: []byte("DROP TABLE items;")},
+		"m/0002_name.up.sql":    {Data: []byte("ALTER TABLE items ADD COLUMN name TEXT;")},
+		"m/0002_name.down.sql":  {Data: []byte("ALTER TABLE items DROP COLUMN name;")},
+	}, "m"))
+
+	m := New(db)
+	assert.ErrorIs(t, m.Verify(ctx, "integration", 1), ErrSchemaBehind)
+
+	// Concurrent runs apply each migration once
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() { errs <- m.Up(ctx, "integration") }()
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	version, err := m.Version(ctx, "integration")
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+	require.NoError(t, m.Verify(ctx, "integration", 2))
+
+	require.NoError(t, m.Down(ctx, "integration", 1))
+	version, err = m.Version(ctx, "integration")
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	// Simulate an edited migration
+	_, err = db.ExecContext(ctx, `UPDATE schema_migrations SET checksum = 'edited' WHERE component = 'integration' AND version = 1`)
+	require.NoError(t, err)
+	assert.ErrorIs(t, m.Up(ctx, "integration"), ErrChecksumMismatch)
+
+	statuses, err := m.Status(ctx, "integration")
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[0].Drifted)
+	assert.False(t, statuses[1].Applied)
+}
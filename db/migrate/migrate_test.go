@@ -0,0 +1,87 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_index.up.sql":   {Data: []byte("CREATE INDEX i ON t (a);")},
+		"migrations/0001_create_t.up.sql":    {Data: []byte("CREATE TABLE t (a INT);")},
+		"migrations/0001_create_t.down.sql":  {Data: []byte("DROP TABLE t;")},
+		"migrations/0002_add_index.down.sql": {Data: []byte("DROP INDEX i;")},
+	}
+
+	set, err := Load("test", fsys, "migrations")
+	require.NoError(t, err)
+	require.Len(t, set.Migrations, 2)
+	assert.Equal(t, 1, set.Migrations[0].Version)
+	assert.Equal(t, "create_t", set.Migrations[0].Name)
+	assert.Equal(t, "DROP TABLE t;", set.Migrations[0].Down)
+	assert.Equal(t, 2, set.Latest())
+
+	// The checksum only covers the up script
+	changed := set.Migrations[0]
+	changed.Down = "-- no-op"
+	assert.Equal(t, set.Migrations[0].Checksum(), changed.Checksum())
+	changed.Up += "\n-- edited"
+	assert.NotEqual(t, set.Migrations[0].Checksum(), changed.Checksum())
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"bad name":       {"m/create.sql": {Data: []byte("SELECT 1")}},
+		"missing up":     {"m/0001_a.down.sql": {Data: []byte("SELECT 1")}},
+		"conflict names": {"m/0001_a.up.sql": {Data: []byte("SELECT 1")}, "m/0001_b.down.sql": {Data: []byte("SELECT 1")}},
+		"zero version":   {"m/0000_a.up.sql": {Data: []byte("SELECT 1")}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load("test", fsys, "m")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	set := &Set{Component: "test", Migrations: []Migration{{Version: 1, Name: "a", Up: "SELECT 1"}}}
+
+	assert.NoError(t, checkDrift(set, map[int]appliedRecord{1: {Version: 1, Checksum: set.Migrations[0].Checksum()}}))
+	// Versions unknown to this build (applied by a newer one) are not drift
+	assert.NoError(t, checkDrift(set, map[int]appliedRecord{2: {Version: 2, Checksum: "x"}}))
+	assert.ErrorIs(t, checkDrift(set, map[int]appliedRecord{1: {Version: 1, Checksum: "x"}}), ErrChecksumMismatch)
+}
+
+func TestRegisteredComponentsLoad(t *testing.T) {
+	Register(&Set{Component: "registry-test"})
+	_, ok := Lookup("registry-test")
+	assert.True(t, ok)
+	assert.Contains(t, Components(), "registry-test")
+	assert.Panics(t, func() { Register(&Set{Component: "registry-test"}) })
+}
+
+func TestMigratorCloseOwnership(t *testing.T) {
+	// The pool connects lazily, so no database is needed
+	pool, err := pgxpool.New(context.Background(), "postgres://migrate@127.0.0.1:1/migrate?connect_timeout=1")
+	require.NoError(t, err)
+	defer pool.Close()
+
+	fromPool := NewFromPool(pool)
+	require.NoError(t, fromPool.Close())
+	_, err = fromPool.db.Conn(context.Background())
+	assert.ErrorContains(t, err, "database is closed", "NewFromPool handle is released")
+
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+	require.NoError(t, New(db).Close())
+	_, err = db.Conn(context.Background())
+	if err != nil {
+		assert.NotContains(t, err.Error(), "database is closed", "caller's handle stays open")
+	}
+}
@@ -0,0 +1,25 @@
+-- Persistent action execution state used by StateStore.
+-- Phases and statuses mirror coordinator/phases.go.
+
+CREATE TABLE IF NOT EXISTS service_action_executions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id TEXT NOT NULL,
+    action_id TEXT NOT NULL,
+    phase TEXT NOT NULL DEFAULT 'pending',
+    status TEXT NOT NULL DEFAULT 'pending',
+    progress_pct INTEGER NOT NULL DEFAULT 0,
+    progress_stage TEXT,
+    progress_message TEXT,
+    checkpoint_id TEXT,
+    checkpoint_data JSONB,
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (workflow_id, action_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_action_exec_phase
+    ON service_action_executions (workflow_id, phase);
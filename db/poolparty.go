@@ -66,9 +66,15 @@ type SPARQLBindings struct {
 // It includes the value type (uri, literal, bnode), the value itself,
 // and optional language tag for literals.
 type SPARQLValue struct {
-	Type  string `json:"type"`
-	Value string `json:"value"`
-	Lang  string `json:"xml:lang,omitempty"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	Lang     string `json:"xml:lang,omitempty"`
+	Datatype string `json:"datatype,omitempty"`
+}
+
+// Term converts the value to a typed RDF term
+func (v SPARQLValue) Term() Term {
+	return sparqlTerm(v.Type, v.Value, v.Datatype, v.Lang)
 }
 
 // RDF represents an RDF graph with multiple resource descriptions.
@@ -436,6 +442,38 @@ func (c *PoolPartyClient) ExecuteSPARQL(projectID, query, contentType string) ([
 	return body, nil
 }
 
+// SPARQLClient returns a SPARQL 1.1 protocol client for a project that shares
+// this client's credentials and HTTP client. Unlike ExecuteSPARQL it decodes
+// results into typed terms and can stream large result sets.
+//
+// Parameters:
+//   - projectID: The PoolParty project/thesaurus ID
+//
+// Returns:
+//   - *SPARQLClient: Read-only client for the project's SPARQL endpoint
+//
+// Example:
+//
+//	rows, err := client.SPARQLClient("myproject").SelectRows(ctx,
+//	    `SELECT ?concept ?label WHERE { ?concept skos:prefLabel ?label }`)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//	    label := rows.Binding()["label"]
+//	    fmt.Println(label.Value, label.Language)
+//	}
+func (c *PoolPartyClient) SPARQLClient(projectID string) *SPARQLClient {
+	return NewSPARQLClient(&PoolPartyStore{
+		BaseURL:   c.BaseURL,
+		ProjectID: projectID,
+		Username:  c.Username,
+		Password:  c.Password,
+		Client:    c.HTTPClient,
+	})
+}
+
 // RunSparQLFromFile is a convenience function that creates a PoolParty client and
 // executes a SPARQL query from a template file in a single call. This is useful
 // for one-off queries where you don't need to maintain a persistent client.
@@ -0,0 +1,263 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Common RDF vocabulary IRIs
+const (
+	RDFNamespace  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	RDFSNamespace = "http://www.w3.org/2000/01/rdf-schema#"
+	XSDNamespace  = "http://www.w3.org/2001/XMLSchema#"
+
+	RDFType       = RDFNamespace + "type"
+	RDFFirst      = RDFNamespace + "first"
+	RDFRest       = RDFNamespace + "rest"
+	RDFNil        = RDFNamespace + "nil"
+	RDFLangString = RDFNamespace + "langString"
+	RDFJSON       = RDFNamespace + "JSON"
+
+	XSDString   = XSDNamespace + "string"
+	XSDBoolean  = XSDNamespace + "boolean"
+	XSDInteger  = XSDNamespace + "integer"
+	XSDDecimal  = XSDNamespace + "decimal"
+	XSDDouble   = XSDNamespace + "double"
+	XSDFloat    = XSDNamespace + "float"
+	XSDDate     = XSDNamespace + "date"
+	XSDDateTime = XSDNamespace + "dateTime"
+)
+
+// xsdIntegerTypes are the XSD datatypes derived from xsd:integer
+var xsdIntegerTypes = map[string]bool{
+	XSDInteger: true, XSDNamespace + "long": true, XSDNamespace + "int": true,
+	XSDNamespace + "short": true, XSDNamespace + "byte": true,
+	XSDNamespace + "nonNegativeInteger": true, XSDNamespace + "positiveInteger": true,
+	XSDNamespace + "nonPositiveInteger": true, XSDNamespace + "negativeInteger": true,
+	XSDNamespace + "unsignedLong": true, XSDNamespace + "unsignedInt": true,
+	XSDNamespace + "unsignedShort": true, XSDNamespace + "unsignedByte": true,
+}
+
+// TermKind identifies the kind of an RDF term
+type TermKind int
+
+const (
+	// TermIRI is an IRI reference
+	TermIRI TermKind = iota + 1
+	// TermBlankNode is a blank node
+	TermBlankNode
+	// TermLiteral is a literal with a datatype and optional language tag
+	TermLiteral
+)
+
+// Term is an RDF term: an IRI, a blank node or a literal.
+// The zero Term denotes the default graph when used as a quad's graph.
+type Term struct {
+	Kind     TermKind `json:"kind"`
+	Value    string   `json:"value"`              // IRI, blank node label without "_:", or lexical form
+	Datatype string   `json:"datatype,omitempty"` // Literal datatype IRI
+	Language string   `json:"language,omitempty"` // Literal language tag
+}
+
+// NewIRI returns an IRI term
+func NewIRI(iri string) Term {
+	return Term{Kind: TermIRI, Value: iri}
+}
+
+// NewBlankNode returns a blank node term; a leading "_:" is removed from label
+func NewBlankNode(label string) Term {
+	return Term{Kind: TermBlankNode, Value: strings.TrimPrefix(label, "_:")}
+}
+
+// NewLiteral returns a literal term; an empty datatype means xsd:string
+func NewLiteral(value, datatype string) Term {
+	if datatype == "" {
+		datatype = XSDString
+	}
+	return Term{Kind: TermLiteral, Value: value, Datatype: datatype}
+}
+
+// NewLangLiteral returns a language-tagged string literal
+func NewLangLiteral(value, language string) Term {
+	return Term{Kind: TermLiteral, Value: value, Datatype: RDFLangString, Language: language}
+}
+
+// IsZero reports whether t is the zero Term
+func (t Term) IsZero() bool {
+	return t.Kind == 0
+}
+
+// IsIRI reports whether t is an IRI
+func (t Term) IsIRI() bool {
+	return t.Kind == TermIRI
+}
+
+// IsBlankNode reports whether t is a blank node
+func (t Term) IsBlankNode() bool {
+	return t.Kind == TermBlankNode
+}
+
+// IsLiteral reports whether t is a literal
+func (t Term) IsLiteral() bool {
+	return t.Kind == TermLiteral
+}
+
+// String returns the N-Triples representation of t
+func (t Term) String() string {
+	switch t.Kind {
+	case TermIRI:
+		return "<" + escapeIRI(t.Value) + ">"
+	case TermBlankNode:
+		return "_:" + t.Value
+	case TermLiteral:
+		literal := `"` + escapeLiteral(t.Value) + `"`
+		switch {
+		case t.Language != "":
+			return literal + "@" + t.Language
+		case t.Datatype != "" && t.Datatype != XSDString:
+			return literal + "^^<" + escapeIRI(t.Datatype) + ">"
+		}
+		return literal
+	}
+	return ""
+}
+
+// Native converts t to a Go value: IRIs, blank nodes and literals of unknown
+// datatypes become their string value, numeric XSD literals become int64 or
+// float64, xsd:boolean becomes bool and xsd:date and xsd:dateTime become
+// time.Time. Literals whose lexical form is invalid for their datatype are
+// returned as strings.
+func (t Term) Native() interface{} {
+	if t.Kind != TermLiteral {
+		return t.Value
+	}
+
+	value := strings.TrimSpace(t.Value)
+	switch {
+	case xsdIntegerTypes[t.Datatype]:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case t.Datatype == XSDDecimal || t.Datatype == XSDDouble || t.Datatype == XSDFloat:
+		switch value {
+		case "INF":
+			return math.Inf(1)
+		case "-INF":
+			return math.Inf(-1)
+		}
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case t.Datatype == XSDBoolean:
+		switch value {
+		case "true", "1":
+			return true
+		case "false", "0":
+			return false
+		}
+	case t.Datatype == XSDDateTime:
+		if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return ts
+		}
+		if ts, err := time.Parse("2006-01-02T15:04:05.999999999", value); err == nil {
+			return ts
+		}
+	case t.Datatype == XSDDate:
+		for _, layout := range []string{"2006-01-02", "2006-01-02Z07:00"} {
+			if ts, err := time.Parse(layout, value); err == nil {
+				return ts
+			}
+		}
+	}
+	return t.Value
+}
+
+// Quad is an RDF statement in a graph. A zero Graph is the default graph,
+// so a Quad also represents a triple.
+type Quad struct {
+	Subject   Term `json:"subject"`
+	Predicate Term `json:"predicate"`
+	Object    Term `json:"object"`
+	Graph     Term `json:"graph,omitempty"`
+}
+
+// String returns the N-Quads line of q without the trailing newline
+func (q Quad) String() string {
+	line := q.Subject.String() + " " + q.Predicate.String() + " " + q.Object.String()
+	if !q.Graph.IsZero() {
+		line += " " + q.Graph.String()
+	}
+	return line + " ."
+}
+
+// FormatNQuads serializes quads as N-Quads in the given order
+func FormatNQuads(quads []Quad) string {
+	var b strings.Builder
+	for _, quad := range quads {
+		b.WriteString(quad.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// SortQuads sorts quads by their N-Quads representation and removes duplicates
+func SortQuads(quads []Quad) []Quad {
+	lines := make(map[string]Quad, len(quads))
+	for _, quad := range quads {
+		lines[quad.String()] = quad
+	}
+	keys := make([]string, 0, len(lines))
+	for line := range lines {
+		keys = append(keys, line)
+	}
+	sort.Strings(keys)
+	sorted := make([]Quad, len(keys))
+	for i, key := range keys {
+		sorted[i] = lines[key]
+	}
+	return sorted
+}
+
+// escapeLiteral escapes a literal's lexical form as in canonical N-Quads,
+// which only escapes backslash, double quote, line feed and carriage return
+func escapeLiteral(s string) string {
+	if !strings.ContainsAny(s, "\\\"\n\r") {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// escapeIRI escapes characters that are not allowed in an N-Triples IRI
+func escapeIRI(s string) string {
+	if !strings.ContainsAny(s, "<>\"{}|^`\\ \t\n\r") {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if r <= 0x20 || strings.ContainsRune("<>\"{}|^`\\", r) {
+			fmt.Fprintf(&b, `\u%04X`, r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
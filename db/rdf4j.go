@@ -44,7 +44,7 @@ package db
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -53,59 +53,6 @@ import (
 	"unicode/utf8"
 )
 
-// sparqlValue represents a single value in a SPARQL query result.
-// This structure encapsulates the type and value information for
-// RDF literals, URIs, and blank nodes returned by SPARQL queries.
-//
-// SPARQL Value Types:
-//   - "uri": Resource identifiers (IRIs)
-//   - "literal": String literals with optional language tags or datatypes
-//   - "bnode": Blank nodes (anonymous resources)
-//   - "typed-literal": Literals with explicit datatype URIs
-//
-// The Type field indicates the RDF term type while Value contains
-// the actual string representation of the term.
-type sparqlValue struct {
-	Type  string `json:"type"`  // RDF term type (uri, literal, bnode)
-	Value string `json:"value"` // String representation of the value
-}
-
-// sparqlResult contains the bindings section of a SPARQL SELECT query response.
-// Each binding maps variable names to their corresponding values, enabling
-// structured access to query results with proper type information.
-//
-// Structure:
-//
-//	The Bindings array contains one map per result row, where each map
-//	associates SPARQL variable names (without the ? prefix) with their
-//	corresponding sparqlValue structures.
-//
-// Example:
-//
-//	For a query "SELECT ?subject ?predicate WHERE { ... }", a binding might be:
-//	{
-//	  "subject": {"type": "uri", "value": "http://example.org/person1"},
-//	  "predicate": {"type": "uri", "value": "http://xmlns.com/foaf/0.1/name"}
-//	}
-type sparqlResult struct {
-	Bindings []map[string]sparqlValue `json:"bindings"` // Query result bindings
-}
-
-// sparqlResponse represents the complete response from a SPARQL SELECT query.
-// This structure follows the W3C SPARQL Query Results JSON Format specification,
-// providing both metadata about the query variables and the actual result data.
-//
-// Components:
-//   - Head: Contains metadata including variable names used in the query
-//   - Results: Contains the actual query results with variable bindings
-//
-// The Head section provides information about the query structure while
-// Results contains the data returned by the query execution.
-type sparqlResponse struct {
-	Head    map[string][]string `json:"head"`    // Query metadata (variables, etc.)
-	Results sparqlResult        `json:"results"` // Query results with bindings
-}
-
 // Repository represents an RDF4J repository configuration and metadata.
 // This structure provides essential information about repositories available
 // on an RDF4J server, including identification, description, and storage type.
@@ -218,19 +165,19 @@ func ListRepositories(serverURL, username, password string) ([]Repository, error
 		return nil, fmt.Errorf("failed to list repositories. Status: %s, Body: %s", resp.Status, string(body))
 	}
 
-	var data sparqlResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
+	rows := newSPARQLRows(resp.Body)
 	var repos []Repository
-	for _, binding := range data.Results.Bindings {
+	for rows.Next() {
+		binding := rows.Binding()
 		repos = append(repos, Repository{
-			ID:    binding["id"].Value,
-			Title: binding["title"].Value,
-			Type:  binding["type"].Value,
+			ID:    binding.Value("id"),
+			Title: binding.Value("title"),
+			Type:  binding.Value("type"),
 		})
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
 
 	return repos, nil
 }
@@ -868,3 +815,99 @@ func CreateLMDBRepository(serverURL, repositoryID, username, password string) er
 
 	return nil
 }
+
+// RDF4JSPARQLClient returns a SPARQL 1.1 protocol client for an RDF4J repository.
+// The client supports SELECT, ASK, CONSTRUCT and DESCRIBE queries as well as
+// updates, named graph datasets and streaming of large results.
+//
+// Parameters:
+//   - serverURL: Base URL of the RDF4J server
+//   - repositoryID: Repository to query
+//   - username: Username for HTTP Basic Authentication
+//   - password: Password for HTTP Basic Authentication
+//
+// Returns:
+//   - *SPARQLClient: Client bound to the repository's query and statements endpoints
+//
+// Example Usage:
+//
+//	client := RDF4JSPARQLClient("http://localhost:8080/rdf4j-server", "my-repo", "admin", "password")
+//	rows, err := client.SelectRows(ctx, "SELECT ?s ?p ?o WHERE { ?s ?p ?o }")
+func RDF4JSPARQLClient(serverURL, repositoryID, username, password string) *SPARQLClient {
+	return NewSPARQLClient(&RDF4JStore{ServerURL: serverURL, Repository: repositoryID, Username: username, Password: password})
+}
+
+// RDF4JQuery executes a SPARQL SELECT query against an RDF4J repository.
+//
+// Parameters:
+//   - serverURL: Base URL of the RDF4J server
+//   - repositoryID: Repository to query
+//   - username: Username for HTTP Basic Authentication
+//   - password: Password for HTTP Basic Authentication
+//   - query: SPARQL SELECT query
+//
+// Returns:
+//   - *SPARQLResultSet: Variables and typed solutions
+//   - error: *SPARQLError for rejected queries, or communication errors
+//
+// Example Usage:
+//
+//	results, err := RDF4JQuery(serverURL, "my-repo", "admin", "password",
+//	    "SELECT ?person ?name WHERE { ?person <http://xmlns.com/foaf/0.1/name> ?name }")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, row := range results.Bindings {
+//	    fmt.Printf("%s: %s\n", row.Value("person"), row.Value("name"))
+//	}
+func RDF4JQuery(serverURL, repositoryID, username, password, query string) (*SPARQLResultSet, error) {
+	return RDF4JSPARQLClient(serverURL, repositoryID, username, password).Select(context.Background(), query)
+}
+
+// RDF4JUpdate executes a SPARQL 1.1 update against an RDF4J repository.
+//
+// Parameters:
+//   - serverURL: Base URL of the RDF4J server
+//   - repositoryID: Repository to modify
+//   - username: Username for HTTP Basic Authentication
+//   - password: Password for HTTP Basic Authentication
+//   - update: SPARQL update request
+//
+// Returns:
+//   - error: *SPARQLError for rejected updates, or communication errors
+//
+// Example Usage:
+//
+//	err := RDF4JUpdate(serverURL, "my-repo", "admin", "password",
+//	    `DELETE WHERE { GRAPH <http://example.org/tmp> { ?s ?p ?o } }`)
+func RDF4JUpdate(serverURL, repositoryID, username, password, update string) error {
+	return RDF4JSPARQLClient(serverURL, repositoryID, username, password).Update(context.Background(), update)
+}
+
+// RDF4JSyncGraph updates a graph in an RDF4J repository to match an RDF file
+// by applying only the differences as a SPARQL update. The file format is
+// chosen by extension and the file is parsed before anything is sent.
+//
+// Parameters:
+//   - serverURL: Base URL of the RDF4J server
+//   - repositoryID: Repository to modify
+//   - username: Username for HTTP Basic Authentication
+//   - password: Password for HTTP Basic Authentication
+//   - graph: Named graph IRI to synchronize (empty for the default graph)
+//   - rdfFilePath: Path to the file holding the desired graph content
+//
+// Returns:
+//   - *GraphDiff: The statements that were added and removed
+//   - error: Parse, query or update errors
+//
+// Example Usage:
+//
+//	diff, err := RDF4JSyncGraph(serverURL, "my-repo", "admin", "password",
+//	    "http://example.org/graph/ontology", "ontology.rdf")
+func RDF4JSyncGraph(serverURL, repositoryID, username, password, graph, rdfFilePath string) (*GraphDiff, error) {
+	quads, err := ParseRDFFile(rdfFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return SyncGraph(context.Background(), RDF4JSPARQLClient(serverURL, repositoryID, username, password), graph, quads)
+}
@@ -0,0 +1,288 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// blankNodeIssuer issues sequential blank node identifiers and remembers
+// the order in which existing identifiers were relabeled
+type blankNodeIssuer struct {
+	prefix  string
+	counter int
+	issued  map[string]string
+	order   []string
+}
+
+func newBlankNodeIssuer(prefix string) *blankNodeIssuer {
+	return &blankNodeIssuer{prefix: prefix, issued: make(map[string]string)}
+}
+
+// issue returns the identifier issued for existing, issuing a new one if needed
+func (i *blankNodeIssuer) issue(existing string) string {
+	if id, ok := i.issued[existing]; ok {
+		return id
+	}
+	id := i.prefix + strconv.Itoa(i.counter)
+	i.counter++
+	i.issued[existing] = id
+	i.order = append(i.order, existing)
+	return id
+}
+
+// next issues a new identifier that is not tied to an existing one
+func (i *blankNodeIssuer) next() string {
+	id := i.prefix + strconv.Itoa(i.counter)
+	i.counter++
+	return id
+}
+
+// has reports whether an identifier was issued for existing
+func (i *blankNodeIssuer) has(existing string) bool {
+	_, ok := i.issued[existing]
+	return ok
+}
+
+func (i *blankNodeIssuer) clone() *blankNodeIssuer {
+	c := &blankNodeIssuer{prefix: i.prefix, counter: i.counter, issued: make(map[string]string, len(i.issued))}
+	for k, v := range i.issued {
+		c.issued[k] = v
+	}
+	c.order = append([]string(nil), i.order...)
+	return c
+}
+
+// CanonicalizeQuads relabels the blank nodes of a dataset with the URDNA2015
+// algorithm and returns the quads in canonical order. Isomorphic datasets
+// yield identical results regardless of their original blank node labels.
+//
+// Parameters:
+//   - quads: The dataset to canonicalize
+//
+// Returns:
+//   - []Quad: Deduplicated quads with blank nodes labeled c14n0, c14n1, ...
+//     sorted by their N-Quads representation
+//
+// Example Usage:
+//
+//	quads, _ := JSONLDToRDF(doc, nil)
+//	canonical := FormatNQuads(CanonicalizeQuads(quads))
+func CanonicalizeQuads(quads []Quad) []Quad {
+	c := &canonicalizer{
+		blankNodeQuads: make(map[string][]Quad),
+		canonical:      newBlankNodeIssuer("c14n"),
+	}
+	return c.run(quads)
+}
+
+// CanonicalNQuads returns the URDNA2015 canonical N-Quads of a dataset
+func CanonicalNQuads(quads []Quad) string {
+	return FormatNQuads(CanonicalizeQuads(quads))
+}
+
+// canonicalizer holds the state of one URDNA2015 run
+type canonicalizer struct {
+	blankNodeQuads map[string][]Quad
+	canonical      *blankNodeIssuer
+}
+
+type nDegreeResult struct {
+	hash   string
+	issuer *blankNodeIssuer
+}
+
+func (c *canonicalizer) run(quads []Quad) []Quad {
+	for _, quad := range quads {
+		for _, term := range []Term{quad.Subject, quad.Object, quad.Graph} {
+			if term.IsBlankNode() {
+				c.blankNodeQuads[term.Value] = append(c.blankNodeQuads[term.Value], quad)
+			}
+		}
+	}
+
+	// Blank nodes with a unique first degree hash are labeled first
+	hashToBlankNodes := make(map[string][]string)
+	for _, id := range sortedKeys(c.blankNodeQuads) {
+		hash := c.hashFirstDegree(id)
+		hashToBlankNodes[hash] = append(hashToBlankNodes[hash], id)
+	}
+	hashes := sortedKeys(hashToBlankNodes)
+	var shared []string
+	for _, hash := range hashes {
+		if ids := hashToBlankNodes[hash]; len(ids) == 1 {
+			c.canonical.issue(ids[0])
+		} else {
+			shared = append(shared, hash)
+		}
+	}
+
+	// The remaining ones are distinguished by their n-degree hashes
+	for _, hash := range shared {
+		var results []nDegreeResult
+		for _, id := range hashToBlankNodes[hash] {
+			if c.canonical.has(id) {
+				continue
+			}
+			issuer := newBlankNodeIssuer("b")
+			issuer.issue(id)
+			results = append(results, c.hashNDegree(id, issuer))
+		}
+		sort.SliceStable(results, func(i, j int) bool { return results[i].hash < results[j].hash })
+		for _, result := range results {
+			for _, existing := range result.issuer.order {
+				c.canonical.issue(existing)
+			}
+		}
+	}
+
+	relabeled := make([]Quad, len(quads))
+	for i, quad := range quads {
+		quad.Subject = c.relabel(quad.Subject)
+		quad.Object = c.relabel(quad.Object)
+		quad.Graph = c.relabel(quad.Graph)
+		relabeled[i] = quad
+	}
+	return SortQuads(relabeled)
+}
+
+func (c *canonicalizer) relabel(term Term) Term {
+	if term.IsBlankNode() {
+		return NewBlankNode(c.canonical.issue(term.Value))
+	}
+	return term
+}
+
+// hashFirstDegree hashes the quads mentioning id, with id written as _:a and
+// every other blank node as _:z
+func (c *canonicalizer) hashFirstDegree(id string) string {
+	replace := func(term Term) Term {
+		if !term.IsBlankNode() {
+			return term
+		}
+		if term.Value == id {
+			return NewBlankNode("a")
+		}
+		return NewBlankNode("z")
+	}
+
+	quads := c.blankNodeQuads[id]
+	lines := make([]string, len(quads))
+	for i, quad := range quads {
+		quad.Subject = replace(quad.Subject)
+		quad.Object = replace(quad.Object)
+		quad.Graph = replace(quad.Graph)
+		lines[i] = quad.String() + "\n"
+	}
+	sort.Strings(lines)
+	return sha256Hex(strings.Join(lines, ""))
+}
+
+// hashRelatedBlankNode hashes a blank node related to another through quad
+// at position (s, o or g)
+func (c *canonicalizer) hashRelatedBlankNode(related string, quad Quad, issuer *blankNodeIssuer, position string) string {
+	var identifier string
+	switch {
+	case c.canonical.has(related):
+		identifier = "_:" + c.canonical.issue(related)
+	case issuer.has(related):
+		identifier = "_:" + issuer.issue(related)
+	default:
+		identifier = c.hashFirstDegree(related)
+	}
+	input := position
+	if position != "g" {
+		input += quad.Predicate.String()
+	}
+	return sha256Hex(input + identifier)
+}
+
+// hashNDegree hashes the paths from id to its related blank nodes, choosing
+// the lexicographically smallest labeling among all their permutations
+func (c *canonicalizer) hashNDegree(id string, issuer *blankNodeIssuer) nDegreeResult {
+	hashToRelated := make(map[string][]string)
+	for _, quad := range c.blankNodeQuads[id] {
+		for _, component := range []struct {
+			term     Term
+			position string
+		}{{quad.Subject, "s"}, {quad.Object, "o"}, {quad.Graph, "g"}} {
+			if component.term.IsBlankNode() && component.term.Value != id {
+				hash := c.hashRelatedBlankNode(component.term.Value, quad, issuer, component.position)
+				hashToRelated[hash] = append(hashToRelated[hash], component.term.Value)
+			}
+		}
+	}
+
+	var data strings.Builder
+	for _, hash := range sortedKeys(hashToRelated) {
+		data.WriteString(hash)
+		chosenPath := ""
+		var chosenIssuer *blankNodeIssuer
+
+		permute(hashToRelated[hash], func(permutation []string) {
+			issuerCopy := issuer.clone()
+			path := ""
+			var recursion []string
+			longer := func() bool {
+				return chosenIssuer != nil && len(path) >= len(chosenPath) && path > chosenPath
+			}
+
+			for _, related := range permutation {
+				if c.canonical.has(related) {
+					path += "_:" + c.canonical.issue(related)
+				} else {
+					if !issuerCopy.has(related) {
+						recursion = append(recursion, related)
+					}
+					path += "_:" + issuerCopy.issue(related)
+				}
+				if longer() {
+					return
+				}
+			}
+			for _, related := range recursion {
+				result := c.hashNDegree(related, issuerCopy)
+				path += "_:" + issuerCopy.issue(related)
+				path += "<" + result.hash + ">"
+				issuerCopy = result.issuer
+				if longer() {
+					return
+				}
+			}
+			if chosenIssuer == nil || path < chosenPath {
+				chosenPath = path
+				chosenIssuer = issuerCopy
+			}
+		})
+
+		data.WriteString(chosenPath)
+		issuer = chosenIssuer
+	}
+
+	return nDegreeResult{hash: sha256Hex(data.String()), issuer: issuer}
+}
+
+// permute calls fn with every permutation of items
+func permute(items []string, fn func([]string)) {
+	items = append([]string(nil), items...)
+	var generate func(k int)
+	generate = func(k int) {
+		if k == len(items) {
+			fn(items)
+			return
+		}
+		for i := k; i < len(items); i++ {
+			items[k], items[i] = items[i], items[k]
+			generate(k + 1)
+			items[k], items[i] = items[i], items[k]
+		}
+	}
+	generate(0)
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
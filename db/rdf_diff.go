@@ -0,0 +1,384 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GraphDiff holds the statements that differ between two graphs
+type GraphDiff struct {
+	Added   []Quad `json:"added"`
+	Removed []Quad `json:"removed"`
+}
+
+// DiffGraphs computes the statements to remove from and add to from so that
+// it equals to. Both sides are canonicalized first, so blank nodes with the
+// same structure compare equal regardless of their labels. Blank nodes cannot
+// be changed in place, as INSERT DATA always creates new ones, so blank nodes
+// connected by statements are compared as a whole: when the number of copies
+// of such a group differs between the graphs, all copies in from are removed
+// and all copies in to are added.
+//
+// Example Usage:
+//
+//	current, _ := ParseRDFFile("current.ttl")
+//	desired, _ := ParseRDFFile("desired.ttl")
+//	diff := DiffGraphs(current, desired)
+//	fmt.Printf("+%d -%d\n", len(diff.Added), len(diff.Removed))
+func DiffGraphs(from, to []Quad) *GraphDiff {
+	before := newGraphShape(CanonicalizeQuads(from))
+	after := newGraphShape(CanonicalizeQuads(to))
+	return &GraphDiff{
+		Removed: before.changedQuads(after),
+		Added:   after.changedQuads(before),
+	}
+}
+
+// graphShape indexes a graph by its statements without blank nodes and by
+// the structure of its groups of connected blank nodes
+type graphShape struct {
+	quads  []Quad
+	ground map[string]bool
+	shapes map[string]string // blank node label to the shape of its group
+	counts map[string]int    // shape to the number of groups with it
+}
+
+func newGraphShape(quads []Quad) *graphShape {
+	g := &graphShape{
+		quads:  quads,
+		ground: make(map[string]bool),
+		shapes: make(map[string]string),
+		counts: make(map[string]int),
+	}
+	var withBlankNodes []Quad
+	for _, quad := range quads {
+		if quad.Subject.IsBlankNode() || quad.Object.IsBlankNode() {
+			withBlankNodes = append(withBlankNodes, quad)
+		} else {
+			g.ground[quad.String()] = true
+		}
+	}
+	for _, component := range blankNodeComponents(withBlankNodes) {
+		shape := CanonicalNQuads(component)
+		g.counts[shape]++
+		for _, label := range blankNodeLabels(component) {
+			g.shapes[label] = shape
+		}
+	}
+	return g
+}
+
+// changedQuads returns the statements of g that are missing from other,
+// including every statement of blank node groups whose number of copies
+// differs
+func (g *graphShape) changedQuads(other *graphShape) []Quad {
+	var changed []Quad
+	for _, quad := range g.quads {
+		label := ""
+		if quad.Subject.IsBlankNode() {
+			label = quad.Subject.Value
+		} else if quad.Object.IsBlankNode() {
+			label = quad.Object.Value
+		}
+		if label == "" {
+			if !other.ground[quad.String()] {
+				changed = append(changed, quad)
+			}
+			continue
+		}
+		if shape := g.shapes[label]; g.counts[shape] != other.counts[shape] {
+			changed = append(changed, quad)
+		}
+	}
+	return changed
+}
+
+// IsEmpty reports whether the graphs were equal
+func (d *GraphDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// SPARQLUpdate returns a SPARQL 1.1 update applying the diff. Statements
+// without a graph name are placed in graph, or in the default graph if graph
+// is empty. Removed statements mentioning blank nodes cannot be addressed
+// directly and are matched with a DELETE/WHERE pattern instead. Its variables
+// are restricted to distinct blank nodes that have exactly the removed
+// statements, so the pattern cannot match statements about IRIs or blank
+// nodes with further statements.
+//
+// Example Usage:
+//
+//	update := diff.SPARQLUpdate("http://example.org/graph/apps")
+//	err := client.Update(ctx, update)
+func (d *GraphDiff) SPARQLUpdate(graph string) string {
+	var operations []string
+
+	var ground, withBlankNodes []Quad
+	for _, quad := range d.Removed {
+		if quad.Subject.IsBlankNode() || quad.Object.IsBlankNode() {
+			withBlankNodes = append(withBlankNodes, quad)
+		} else {
+			ground = append(ground, quad)
+		}
+	}
+	if len(ground) > 0 {
+		operations = append(operations, "DELETE DATA {\n"+sparqlQuadData(ground, graph, Term.String)+"}")
+	}
+	for _, component := range blankNodeComponents(withBlankNodes) {
+		pattern := sparqlQuadData(component, graph, sparqlPatternTerm)
+		operations = append(operations, "DELETE {\n"+pattern+"} WHERE {\n"+pattern+blankNodeFilters(component, graph)+"}")
+	}
+	if len(d.Added) > 0 {
+		operations = append(operations, "INSERT DATA {\n"+sparqlQuadData(d.Added, graph, Term.String)+"}")
+	}
+	return strings.Join(operations, " ;\n")
+}
+
+// Apply sends the diff to a store as a single SPARQL update. An empty diff
+// is not sent.
+func (d *GraphDiff) Apply(ctx context.Context, client *SPARQLClient, graph string) error {
+	if d.IsEmpty() {
+		return nil
+	}
+	return client.Update(ctx, d.SPARQLUpdate(graph))
+}
+
+// SyncGraph makes a graph in a store equal to quads by fetching its current
+// content, computing the difference and applying it as a SPARQL update rather
+// than replacing the whole graph. Graph names in quads are ignored.
+//
+// Parameters:
+//   - ctx: Context for the requests
+//   - client: Client of the store
+//   - graph: Named graph to synchronize (empty for the default graph)
+//   - quads: Desired content of the graph
+//
+// Returns:
+//   - *GraphDiff: The applied changes
+//   - error: Query or update errors
+//
+// Example Usage:
+//
+//	quads, _ := ParseRDFFile("apps.ttl")
+//	diff, err := SyncGraph(ctx, GraphDBSPARQLClient(url, user, pass, "apps"), "http://example.org/apps", quads)
+func SyncGraph(ctx context.Context, client *SPARQLClient, graph string, quads []Quad) (*GraphDiff, error) {
+	query := "CONSTRUCT { ?s ?p ?o } WHERE { ?s ?p ?o }"
+	if graph != "" {
+		query = "CONSTRUCT { ?s ?p ?o } WHERE { GRAPH " + NewIRI(graph).String() + " { ?s ?p ?o } }"
+	}
+	current, err := client.Construct(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch current graph: %w", err)
+	}
+
+	desired := make([]Quad, len(quads))
+	for i, quad := range quads {
+		quad.Graph = Term{}
+		desired[i] = quad
+	}
+	for i := range current {
+		current[i].Graph = Term{}
+	}
+
+	diff := DiffGraphs(current, desired)
+	if err := diff.Apply(ctx, client, graph); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// sparqlQuadData formats statements for an update block, wrapping them in
+// GRAPH clauses by graph name
+func sparqlQuadData(quads []Quad, graph string, term func(Term) string) string {
+	var graphs []string
+	byGraph := make(map[string][]Quad)
+	for _, quad := range quads {
+		name := graph
+		if !quad.Graph.IsZero() {
+			name = quad.Graph.Value
+		}
+		if _, ok := byGraph[name]; !ok {
+			graphs = append(graphs, name)
+		}
+		byGraph[name] = append(byGraph[name], quad)
+	}
+
+	var b strings.Builder
+	for _, name := range graphs {
+		indent := "  "
+		if name != "" {
+			b.WriteString("  GRAPH " + NewIRI(name).String() + " {\n")
+			indent = "    "
+		}
+		for _, quad := range byGraph[name] {
+			b.WriteString(indent + term(quad.Subject) + " " + term(quad.Predicate) + " " + term(quad.Object) + " .\n")
+		}
+		if name != "" {
+			b.WriteString("  }\n")
+		}
+	}
+	return b.String()
+}
+
+// sparqlPatternTerm formats a term for a pattern, turning blank nodes into variables
+func sparqlPatternTerm(t Term) string {
+	if t.IsBlankNode() {
+		return "?" + strings.Map(func(r rune) rune {
+			if r == '_' || isAlphaNum(byte(r)) && r < 0x80 {
+				return r
+			}
+			return '_'
+		}, t.Value)
+	}
+	return t.String()
+}
+
+// blankNodeFilters restricts the variables standing for blank nodes in a
+// pattern to distinct blank nodes without statements beyond the pattern
+func blankNodeFilters(quads []Quad, graph string) string {
+	labels := blankNodeLabels(quads)
+
+	var b strings.Builder
+	for _, label := range labels {
+		b.WriteString("  FILTER(isBlank(" + sparqlPatternTerm(NewBlankNode(label)) + "))\n")
+	}
+	for i := range labels {
+		for _, other := range labels[i+1:] {
+			b.WriteString("  FILTER(!sameTerm(" + sparqlPatternTerm(NewBlankNode(labels[i])) + ", " + sparqlPatternTerm(NewBlankNode(other)) + "))\n")
+		}
+	}
+	for _, label := range labels {
+		node := NewBlankNode(label)
+		var outgoing, incoming []Quad
+		for _, quad := range quads {
+			if quad.Subject == node {
+				outgoing = append(outgoing, quad)
+			}
+			if quad.Object == node {
+				incoming = append(incoming, quad)
+			}
+		}
+		v := sparqlPatternTerm(node)
+		graphs := quadGraphNames(append(append([]Quad{}, outgoing...), incoming...), graph)
+		b.WriteString(notExistsOtherStatements(v+" ?_p ?_o", outgoing, graphs, graph, func(q Quad) string {
+			return "sameTerm(?_p, " + sparqlPatternTerm(q.Predicate) + ") && sameTerm(?_o, " + sparqlPatternTerm(q.Object) + ")"
+		}))
+		b.WriteString(notExistsOtherStatements("?_s ?_p "+v, incoming, graphs, graph, func(q Quad) string {
+			return "sameTerm(?_s, " + sparqlPatternTerm(q.Subject) + ") && sameTerm(?_p, " + sparqlPatternTerm(q.Predicate) + ")"
+		}))
+	}
+	return b.String()
+}
+
+// notExistsOtherStatements returns filters rejecting matches for which the
+// triple pattern finds a statement other than the allowed ones in graphs
+func notExistsOtherStatements(triple string, allowed []Quad, graphs []string, graph string, match func(Quad) string) string {
+	var b strings.Builder
+	for _, name := range graphs {
+		var conditions []string
+		for _, quad := range allowed {
+			if quadGraphName(quad, graph) == name {
+				conditions = append(conditions, match(quad))
+			}
+		}
+		pattern := triple
+		if name != "" {
+			pattern = "GRAPH " + NewIRI(name).String() + " { " + triple + " }"
+		}
+		switch len(conditions) {
+		case 0:
+			b.WriteString("  FILTER NOT EXISTS { " + pattern + " }\n")
+		case 1:
+			b.WriteString("  FILTER NOT EXISTS { " + pattern + " FILTER(!(" + conditions[0] + ")) }\n")
+		default:
+			b.WriteString("  FILTER NOT EXISTS { " + pattern + " FILTER(!((" + strings.Join(conditions, ") || (") + "))) }\n")
+		}
+	}
+	return b.String()
+}
+
+// quadGraphName returns the graph a statement is written to
+func quadGraphName(quad Quad, graph string) string {
+	if !quad.Graph.IsZero() {
+		return quad.Graph.Value
+	}
+	return graph
+}
+
+// quadGraphNames returns the graphs statements are written to in order of appearance
+func quadGraphNames(quads []Quad, graph string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, quad := range quads {
+		name := quadGraphName(quad, graph)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// blankNodeLabels returns the blank node labels in statements in order of appearance
+func blankNodeLabels(quads []Quad) []string {
+	var labels []string
+	seen := make(map[string]bool)
+	for _, quad := range quads {
+		for _, term := range []Term{quad.Subject, quad.Object} {
+			if term.IsBlankNode() && !seen[term.Value] {
+				seen[term.Value] = true
+				labels = append(labels, term.Value)
+			}
+		}
+	}
+	return labels
+}
+
+// blankNodeComponents groups statements that share blank nodes, so that each
+// group can be matched by its own pattern
+func blankNodeComponents(quads []Quad) [][]Quad {
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(label string) string {
+		if parent[label] == "" || parent[label] == label {
+			parent[label] = label
+			return label
+		}
+		root := find(parent[label])
+		parent[label] = root
+		return root
+	}
+
+	for _, quad := range quads {
+		var labels []string
+		for _, term := range []Term{quad.Subject, quad.Object} {
+			if term.IsBlankNode() {
+				labels = append(labels, term.Value)
+			}
+		}
+		if len(labels) == 2 {
+			parent[find(labels[1])] = find(labels[0])
+		}
+	}
+
+	var roots []string
+	components := make(map[string][]Quad)
+	for _, quad := range quads {
+		label := quad.Subject.Value
+		if !quad.Subject.IsBlankNode() {
+			label = quad.Object.Value
+		}
+		root := find(label)
+		if _, ok := components[root]; !ok {
+			roots = append(roots, root)
+		}
+		components[root] = append(components[root], quad)
+	}
+
+	groups := make([][]Quad, len(roots))
+	for i, root := range roots {
+		groups[i] = components[root]
+	}
+	return groups
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseTurtle(t *testing.T, input string) []Quad {
+	t.Helper()
+	quads, err := ParseTurtle(strings.NewReader(input), "")
+	require.NoError(t, err)
+	return quads
+}
+
+// TestDiffGraphs tests added and removed statements, including blank nodes
+func TestDiffGraphs(t *testing.T) {
+	before := mustParseTurtle(t, `@prefix schema: <http://schema.org/> .
+<urn:app:nginx> schema:name "nginx" ; schema:version "1.24" ;
+    schema:author [ schema:name "Igor" ] .`)
+	after := mustParseTurtle(t, `@prefix schema: <http://schema.org/> .
+<urn:app:nginx> schema:name "nginx" ; schema:version "1.25" ;
+    schema:author [ schema:name "Igor" ] .`)
+
+	t.Run("equal graphs with different blank node labels", func(t *testing.T) {
+		relabeled := mustParseTurtle(t, `@prefix schema: <http://schema.org/> .
+_:someone schema:name "Igor" .
+<urn:app:nginx> schema:author _:someone ; schema:version "1.24" ; schema:name "nginx" .`)
+		assert.True(t, DiffGraphs(before, relabeled).IsEmpty())
+	})
+
+	diff := DiffGraphs(before, after)
+	require.Len(t, diff.Removed, 1)
+	require.Len(t, diff.Added, 1)
+	assert.Equal(t, NewLiteral("1.24", ""), diff.Removed[0].Object)
+	assert.Equal(t, NewLiteral("1.25", ""), diff.Added[0].Object)
+
+	update := diff.SPARQLUpdate("http://example.org/apps")
+	assert.Equal(t, `DELETE DATA {
+  GRAPH <http://example.org/apps> {
+    <urn:app:nginx> <http://schema.org/version> "1.24" .
+  }
+} ;
+INSERT DATA {
+  GRAPH <http://example.org/apps> {
+    <urn:app:nginx> <http://schema.org/version> "1.25" .
+  }
+}`, update)
+
+	t.Run("removed blank nodes", func(t *testing.T) {
+		changed := mustParseTurtle(t, `@prefix schema: <http://schema.org/> .
+<urn:app:nginx> schema:name "nginx" ; schema:version "1.24" ;
+    schema:author [ schema:name "Igor Sysoev" ] .`)
+		diff := DiffGraphs(before, changed)
+		assert.Len(t, diff.Removed, 2)
+		assert.Len(t, diff.Added, 2)
+
+		update := diff.SPARQLUpdate("")
+		assert.NotContains(t, update, "DELETE DATA")
+		assert.Contains(t, update, "DELETE {\n  <urn:app:nginx> <http://schema.org/author> ?c14n0 .\n  ?c14n0 <http://schema.org/name> \"Igor\" .\n} WHERE {")
+		assert.Contains(t, update, "INSERT DATA {\n")
+		assert.Contains(t, update, `} WHERE {
+  <urn:app:nginx> <http://schema.org/author> ?c14n0 .
+  ?c14n0 <http://schema.org/name> "Igor" .
+  FILTER(isBlank(?c14n0))
+  FILTER NOT EXISTS { ?c14n0 ?_p ?_o FILTER(!(sameTerm(?_p, <http://schema.org/name>) && sameTerm(?_o, "Igor"))) }
+  FILTER NOT EXISTS { ?_s ?_p ?c14n0 FILTER(!(sameTerm(?_s, <urn:app:nginx>) && sameTerm(?_p, <http://schema.org/author>))) }
+}`)
+	})
+
+	t.Run("removed blank node sharing a statement with an IRI", func(t *testing.T) {
+		withBlankNode := mustParseTurtle(t, `@prefix ex: <http://example.org/> .
+ex:s ex:label "x" .
+[] ex:label "x" .`)
+		withoutBlankNode := mustParseTurtle(t, `@prefix ex: <http://example.org/> .
+ex:s ex:label "x" .`)
+		diff := DiffGraphs(withBlankNode, withoutBlankNode)
+		require.Len(t, diff.Removed, 1)
+		assert.Empty(t, diff.Added)
+
+		// Without the filter the pattern would also match and delete ex:s ex:label "x"
+		assert.Equal(t, `DELETE {
+  ?c14n0 <http://example.org/label> "x" .
+} WHERE {
+  ?c14n0 <http://example.org/label> "x" .
+  FILTER(isBlank(?c14n0))
+  FILTER NOT EXISTS { ?c14n0 ?_p ?_o FILTER(!(sameTerm(?_p, <http://example.org/label>) && sameTerm(?_o, "x"))) }
+  FILTER NOT EXISTS { ?_s ?_p ?c14n0 }
+}`, diff.SPARQLUpdate(""))
+	})
+
+	t.Run("removed blank node sharing statements with a larger blank node", func(t *testing.T) {
+		current := mustParseTurtle(t, `@prefix ex: <http://example.org/> .
+[] ex:label "x" .
+[] ex:label "x" ; ex:note "kept" .`)
+		desired := mustParseTurtle(t, `@prefix ex: <http://example.org/> .
+[] ex:label "x" ; ex:note "kept" .`)
+		diff := DiffGraphs(current, desired)
+		require.Len(t, diff.Removed, 1)
+		assert.Empty(t, diff.Added)
+		assert.Equal(t, "x", diff.Removed[0].Object.Value)
+
+		// Only a blank node with nothing but ex:label "x" may match, so the
+		// label of the unchanged blank node is not deleted
+		update := diff.SPARQLUpdate("http://example.org/g")
+		assert.Equal(t, `DELETE {
+  GRAPH <http://example.org/g> {
+    ?c14n0 <http://example.org/label> "x" .
+  }
+} WHERE {
+  GRAPH <http://example.org/g> {
+    ?c14n0 <http://example.org/label> "x" .
+  }
+  FILTER(isBlank(?c14n0))
+  FILTER NOT EXISTS { GRAPH <http://example.org/g> { ?c14n0 ?_p ?_o } FILTER(!(sameTerm(?_p, <http://example.org/label>) && sameTerm(?_o, "x"))) }
+  FILTER NOT EXISTS { GRAPH <http://example.org/g> { ?_s ?_p ?c14n0 } }
+}`, update)
+	})
+
+	t.Run("linked blank nodes are matched as distinct nodes", func(t *testing.T) {
+		current := mustParseTurtle(t, `@prefix ex: <http://example.org/> .
+_:a ex:next _:b . _:b ex:next _:a .`)
+		diff := DiffGraphs(current, nil)
+		require.Len(t, diff.Removed, 2)
+
+		update := diff.SPARQLUpdate("")
+		assert.Contains(t, update, "  FILTER(!sameTerm(?c14n0, ?c14n1))\n")
+		assert.Contains(t, update, "  FILTER NOT EXISTS { ?c14n0 ?_p ?_o FILTER(!(sameTerm(?_p, <http://example.org/next>) && sameTerm(?_o, ?c14n1))) }\n")
+	})
+
+	t.Run("identical blank nodes are replaced together", func(t *testing.T) {
+		current := mustParseTurtle(t, `@prefix ex: <http://example.org/> .
+[] ex:label "x" .
+[] ex:label "x" .`)
+		desired := mustParseTurtle(t, `@prefix ex: <http://example.org/> .
+[] ex:label "x" .`)
+
+		// A pattern for one copy matches both, so both are deleted and one is added back
+		diff := DiffGraphs(current, desired)
+		assert.Len(t, diff.Removed, 2)
+		require.Len(t, diff.Added, 1)
+		assert.Equal(t, NewLiteral("x", ""), diff.Added[0].Object)
+	})
+}
+
+// TestSyncGraph tests that only the difference is sent to the store
+func TestSyncGraph(t *testing.T) {
+	current := `<urn:app:nginx> <http://schema.org/name> "nginx" .
+<urn:app:nginx> <http://schema.org/version> "1.24" .
+`
+	server, requests := fakeSPARQLServer(t, http.StatusOK, NTriplesMediaType, current)
+	client := RDF4JSPARQLClient(server.URL, "apps", "admin", "secret")
+
+	desired := mustParseTurtle(t, `<urn:app:nginx> <http://schema.org/name> "nginx" ; <http://schema.org/version> "1.25" .`)
+	diff, err := SyncGraph(context.Background(), client, "http://example.org/apps", desired)
+	require.NoError(t, err)
+	assert.Len(t, diff.Added, 1)
+	assert.Len(t, diff.Removed, 1)
+
+	require.Len(t, *requests, 2)
+	assert.Equal(t, "CONSTRUCT { ?s ?p ?o } WHERE { GRAPH <http://example.org/apps> { ?s ?p ?o } }", (*requests)[0].form.Get("query"))
+	assert.Equal(t, "/repositories/apps/statements", (*requests)[1].path)
+	assert.Equal(t, diff.SPARQLUpdate("http://example.org/apps"), (*requests)[1].form.Get("update"))
+
+	t.Run("unchanged graph sends no update", func(t *testing.T) {
+		*requests = nil
+		current, err := ParseNQuads(strings.NewReader(current))
+		require.NoError(t, err)
+		diff, err := SyncGraph(context.Background(), client, "http://example.org/apps", current)
+		require.NoError(t, err)
+		assert.True(t, diff.IsEmpty())
+		assert.Len(t, *requests, 1)
+	})
+}
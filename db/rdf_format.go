@@ -0,0 +1,200 @@
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RDFFormat identifies an RDF serialization by its media type
+type RDFFormat string
+
+// Supported RDF serializations
+const (
+	RDFNTriples RDFFormat = NTriplesMediaType
+	RDFNQuads   RDFFormat = NQuadsMediaType
+	RDFTurtle   RDFFormat = "text/turtle"
+	RDFXML      RDFFormat = "application/rdf+xml"
+)
+
+// rdfFormatAliases maps media types seen in the wild to supported formats
+var rdfFormatAliases = map[string]RDFFormat{
+	"application/n-triples": RDFNTriples,
+	"text/plain":            RDFNTriples,
+	"application/n-quads":   RDFNQuads,
+	"text/x-nquads":         RDFNQuads,
+	"text/turtle":           RDFTurtle,
+	"application/x-turtle":  RDFTurtle,
+	"application/turtle":    RDFTurtle,
+	"application/rdf+xml":   RDFXML,
+	"application/xml":       RDFXML,
+	"text/xml":              RDFXML,
+}
+
+// rdfFormatExtensions maps file extensions to formats
+var rdfFormatExtensions = map[string]RDFFormat{
+	".nt":  RDFNTriples,
+	".nq":  RDFNQuads,
+	".ttl": RDFTurtle,
+	".rdf": RDFXML,
+	".owl": RDFXML,
+	".xml": RDFXML,
+}
+
+// RDFFormatForContentType returns the format of a Content-Type header value,
+// ignoring parameters such as charset
+func RDFFormatForContentType(contentType string) (RDFFormat, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	format, ok := rdfFormatAliases[mediaType]
+	return format, ok
+}
+
+// RDFFormatForFile returns the format implied by a file's extension
+func RDFFormatForFile(path string) (RDFFormat, bool) {
+	format, ok := rdfFormatExtensions[strings.ToLower(filepath.Ext(path))]
+	return format, ok
+}
+
+// ParseRDF parses RDF in the given format into statements
+//
+// Parameters:
+//   - r: Source document
+//   - format: Serialization of the document
+//   - base: Base IRI for resolving relative IRIs (Turtle and RDF/XML only)
+//
+// Returns:
+//   - []Quad: Parsed statements; graph names are only set for N-Quads
+//   - error: *RDFSyntaxError for malformed input, or an unsupported format
+//
+// Example Usage:
+//
+//	quads, err := ParseRDF(resp.Body, RDFTurtle, "")
+//	if err != nil {
+//	    return fmt.Errorf("invalid RDF: %w", err)
+//	}
+func ParseRDF(r io.Reader, format RDFFormat, base string) ([]Quad, error) {
+	switch format {
+	case RDFNTriples, RDFNQuads:
+		quads, err := ParseNQuads(r)
+		if err != nil {
+			return nil, err
+		}
+		if format == RDFNTriples {
+			for _, quad := range quads {
+				if !quad.Graph.IsZero() {
+					return nil, fmt.Errorf("N-Triples document contains a graph name: %s", quad)
+				}
+			}
+		}
+		return quads, nil
+	case RDFTurtle:
+		return ParseTurtle(r, base)
+	case RDFXML:
+		return ParseRDFXML(r, base)
+	}
+	return nil, fmt.Errorf("unsupported RDF format %q", format)
+}
+
+// WriteRDF writes statements in the given format. Formats other than N-Quads
+// drop graph names. Turtle and RDF/XML abbreviate IRIs with prefixes
+// (nil for DefaultRDFPrefixes).
+func WriteRDF(w io.Writer, quads []Quad, format RDFFormat, prefixes map[string]string) error {
+	switch format {
+	case RDFNQuads:
+		bw := bufio.NewWriter(w)
+		bw.WriteString(FormatNQuads(quads))
+		return bw.Flush()
+	case RDFNTriples:
+		triples := make([]Quad, 0, len(quads))
+		seen := make(map[string]bool, len(quads))
+		for _, quad := range quads {
+			quad.Graph = Term{}
+			if line := quad.String(); !seen[line] {
+				seen[line] = true
+				triples = append(triples, quad)
+			}
+		}
+		bw := bufio.NewWriter(w)
+		bw.WriteString(FormatNQuads(triples))
+		return bw.Flush()
+	case RDFTurtle:
+		return WriteTurtle(w, quads, prefixes)
+	case RDFXML:
+		return WriteRDFXML(w, quads, prefixes)
+	}
+	return fmt.Errorf("unsupported RDF format %q", format)
+}
+
+// ConvertRDF reads RDF in one format and writes it in another
+//
+// Example Usage:
+//
+//	var out bytes.Buffer
+//	err := ConvertRDF(strings.NewReader(turtle), RDFTurtle, &out, RDFXML)
+func ConvertRDF(r io.Reader, from RDFFormat, w io.Writer, to RDFFormat) error {
+	quads, err := ParseRDF(r, from, "")
+	if err != nil {
+		return err
+	}
+	return WriteRDF(w, quads, to, nil)
+}
+
+// ParseRDFFile parses an RDF file whose format is given by its extension
+//
+// Parameters:
+//   - path: File ending in .nt, .nq, .ttl, .rdf, .owl or .xml
+//
+// Returns:
+//   - []Quad: Parsed statements
+//   - error: Unknown extension, read or syntax errors
+func ParseRDFFile(path string) ([]Quad, error) {
+	format, ok := RDFFormatForFile(path)
+	if !ok {
+		return nil, fmt.Errorf("cannot determine RDF format of %s", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RDF file: %w", err)
+	}
+	defer file.Close()
+
+	quads, err := ParseRDF(file, format, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return quads, nil
+}
+
+// ConvertRDFFile converts an RDF file to another file, choosing both formats
+// by extension
+//
+// Example Usage:
+//
+//	err := ConvertRDFFile("ontology.ttl", "ontology.rdf")
+func ConvertRDFFile(inputPath, outputPath string) error {
+	quads, err := ParseRDFFile(inputPath)
+	if err != nil {
+		return err
+	}
+	format, ok := RDFFormatForFile(outputPath)
+	if !ok {
+		return fmt.Errorf("cannot determine RDF format of %s", outputPath)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := WriteRDF(file, quads, format, nil); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
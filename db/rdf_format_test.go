@@ -0,0 +1,180 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const appsTurtle = `@base <http://example.org/> .
+@prefix schema: <http://schema.org/> .
+PREFIX xsd: <http://www.w3.org/2001/XMLSchema#>
+
+# Applications
+<apps/nginx> a schema:SoftwareApplication ;
+    schema:name "nginx", "engine x"@en ;
+    schema:version 1.25 ;
+    schema:downloads 1200 ;
+    schema:rating 4.5e0 ;
+    schema:isFree true ;
+    schema:releaseDate "2024-01-01"^^xsd:date ;
+    schema:description """Web server
+and "reverse" proxy""" ;
+    schema:author [ schema:name "Igor" ] ;
+    schema:keywords ( "http" "proxy" ) .
+`
+
+// TestParseTurtle tests Turtle parsing of the common abbreviations
+func TestParseTurtle(t *testing.T) {
+	quads, err := ParseTurtle(strings.NewReader(appsTurtle), "")
+	require.NoError(t, err)
+
+	app := NewIRI("http://example.org/apps/nginx")
+	objects := func(predicate string) []Term {
+		var terms []Term
+		for _, quad := range quads {
+			if quad.Subject == app && quad.Predicate.Value == predicate {
+				terms = append(terms, quad.Object)
+			}
+		}
+		return terms
+	}
+
+	assert.Equal(t, []Term{NewIRI("http://schema.org/SoftwareApplication")}, objects(RDFType))
+	assert.Equal(t, []Term{NewLiteral("nginx", ""), NewLangLiteral("engine x", "en")}, objects("http://schema.org/name"))
+	assert.Equal(t, []Term{NewLiteral("1.25", XSDDecimal)}, objects("http://schema.org/version"))
+	assert.Equal(t, []Term{NewLiteral("1200", XSDInteger)}, objects("http://schema.org/downloads"))
+	assert.Equal(t, []Term{NewLiteral("4.5e0", XSDDouble)}, objects("http://schema.org/rating"))
+	assert.Equal(t, []Term{NewLiteral("true", XSDBoolean)}, objects("http://schema.org/isFree"))
+	assert.Equal(t, []Term{NewLiteral("2024-01-01", XSDDate)}, objects("http://schema.org/releaseDate"))
+	assert.Equal(t, []Term{NewLiteral("Web server\nand \"reverse\" proxy", "")}, objects("http://schema.org/description"))
+
+	authors := objects("http://schema.org/author")
+	require.Len(t, authors, 1)
+	assert.True(t, authors[0].IsBlankNode())
+	keywords := objects("http://schema.org/keywords")
+	require.Len(t, keywords, 1)
+	assert.True(t, keywords[0].IsBlankNode())
+	assert.Len(t, quads, 16)
+
+	t.Run("syntax errors", func(t *testing.T) {
+		for _, input := range []string{
+			"<a> <b> .",
+			"<a> <b> <c>",
+			"ex:a <b> <c> .",
+			"<a> <b> \"unterminated .",
+		} {
+			_, err := ParseTurtle(strings.NewReader(input), "")
+			var syntaxErr *RDFSyntaxError
+			assert.True(t, errors.As(err, &syntaxErr), input)
+		}
+	})
+}
+
+const appsRDFXML = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+         xmlns:schema="http://schema.org/"
+         xml:base="http://example.org/">
+  <schema:SoftwareApplication rdf:about="apps/nginx" schema:name="nginx">
+    <schema:version rdf:datatype="http://www.w3.org/2001/XMLSchema#decimal">1.25</schema:version>
+    <schema:alternateName xml:lang="en">engine x</schema:alternateName>
+    <schema:author rdf:parseType="Resource">
+      <schema:name>Igor</schema:name>
+    </schema:author>
+    <schema:sameAs rdf:resource="https://nginx.org/"/>
+    <schema:keywords rdf:parseType="Collection">
+      <rdf:Description rdf:about="tags/http"/>
+    </schema:keywords>
+  </schema:SoftwareApplication>
+</rdf:RDF>`
+
+// TestParseRDFXML tests RDF/XML parsing
+func TestParseRDFXML(t *testing.T) {
+	quads, err := ParseRDFXML(strings.NewReader(appsRDFXML), "")
+	require.NoError(t, err)
+
+	app := NewIRI("http://example.org/apps/nginx")
+	assert.Contains(t, quads, Quad{Subject: app, Predicate: NewIRI(RDFType), Object: NewIRI("http://schema.org/SoftwareApplication")})
+	assert.Contains(t, quads, Quad{Subject: app, Predicate: NewIRI("http://schema.org/name"), Object: NewLiteral("nginx", "")})
+	assert.Contains(t, quads, Quad{Subject: app, Predicate: NewIRI("http://schema.org/version"), Object: NewLiteral("1.25", XSDDecimal)})
+	assert.Contains(t, quads, Quad{Subject: app, Predicate: NewIRI("http://schema.org/alternateName"), Object: NewLangLiteral("engine x", "en")})
+	assert.Contains(t, quads, Quad{Subject: app, Predicate: NewIRI("http://schema.org/sameAs"), Object: NewIRI("https://nginx.org/")})
+	assert.Len(t, quads, 10)
+
+	t.Run("undeclared prefix", func(t *testing.T) {
+		_, err := ParseRDFXML(strings.NewReader(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="http://example.org/a"><rdfs:label>A</rdfs:label></rdf:Description>
+</rdf:RDF>`), "")
+		var syntaxErr *RDFSyntaxError
+		require.True(t, errors.As(err, &syntaxErr))
+		assert.Equal(t, 2, syntaxErr.Line)
+	})
+}
+
+// TestConvertRDF tests that conversions between all formats preserve the graph
+func TestConvertRDF(t *testing.T) {
+	source, err := ParseTurtle(strings.NewReader(appsTurtle), "")
+	require.NoError(t, err)
+	expected := CanonicalNQuads(source)
+
+	for _, format := range []RDFFormat{RDFNTriples, RDFNQuads, RDFTurtle, RDFXML} {
+		t.Run(string(format), func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, WriteRDF(&out, source, format, nil))
+			parsed, err := ParseRDF(&out, format, "")
+			require.NoError(t, err, out.String())
+			assert.Equal(t, expected, CanonicalNQuads(parsed))
+		})
+	}
+
+	t.Run("turtle output", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, WriteTurtle(&out, source, nil))
+		assert.Contains(t, out.String(), "@prefix schema: <http://schema.org/> .")
+		assert.NotContains(t, out.String(), "@prefix owl:")
+		assert.Contains(t, out.String(), "<http://example.org/apps/nginx> a schema:SoftwareApplication")
+		assert.Contains(t, out.String(), "schema:downloads 1200")
+	})
+}
+
+// TestRDFFormatDetection tests format lookup by content type and file name
+func TestRDFFormatDetection(t *testing.T) {
+	format, ok := RDFFormatForContentType("text/turtle; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, RDFTurtle, format)
+	format, ok = RDFFormatForContentType("application/rdf+xml")
+	assert.True(t, ok)
+	assert.Equal(t, RDFXML, format)
+	_, ok = RDFFormatForContentType("application/json")
+	assert.False(t, ok)
+
+	format, ok = RDFFormatForFile("ontology.OWL")
+	assert.True(t, ok)
+	assert.Equal(t, RDFXML, format)
+	_, ok = RDFFormatForFile("data.csv")
+	assert.False(t, ok)
+}
+
+// TestConvertRDFFile tests file conversion by extension
+func TestConvertRDFFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "apps.ttl")
+	output := filepath.Join(dir, "apps.rdf")
+	require.NoError(t, os.WriteFile(input, []byte(appsTurtle), 0644))
+
+	require.NoError(t, ConvertRDFFile(input, output))
+	original, err := ParseRDFFile(input)
+	require.NoError(t, err)
+	converted, err := ParseRDFFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, CanonicalNQuads(original), CanonicalNQuads(converted))
+
+	_, err = ParseRDFFile(filepath.Join(dir, "apps.csv"))
+	assert.Error(t, err)
+}
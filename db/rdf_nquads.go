@@ -0,0 +1,375 @@
+package db
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxNQuadsLine is the longest statement line NQuadsReader accepts
+const maxNQuadsLine = 16 * 1024 * 1024
+
+// RDFSyntaxError reports malformed RDF input
+type RDFSyntaxError struct {
+	Format  string // Serialization format, e.g. "N-Quads"
+	Line    int    // 1-based line number
+	Message string
+}
+
+func (e *RDFSyntaxError) Error() string {
+	return fmt.Sprintf("%s syntax error on line %d: %s", e.Format, e.Line, e.Message)
+}
+
+// NQuadsReader reads statements from N-Quads or N-Triples input one at a time,
+// so arbitrarily large documents can be processed without loading them
+type NQuadsReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewNQuadsReader creates a reader for N-Quads or N-Triples input
+//
+// Example Usage:
+//
+//	reader := NewNQuadsReader(file)
+//	for {
+//	    quad, err := reader.Read()
+//	    if err == io.EOF {
+//	        break
+//	    }
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(quad.Subject, quad.Predicate, quad.Object)
+//	}
+func NewNQuadsReader(r io.Reader) *NQuadsReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxNQuadsLine)
+	return &NQuadsReader{scanner: scanner}
+}
+
+// Read returns the next statement or io.EOF at the end of the input
+func (r *NQuadsReader) Read() (Quad, error) {
+	for r.scanner.Scan() {
+		r.line++
+		p := &ntParser{input: r.scanner.Text(), line: r.line}
+		quad, ok, err := p.statement()
+		if err != nil {
+			return Quad{}, err
+		}
+		if ok {
+			return quad, nil
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return Quad{}, &RDFSyntaxError{Format: "N-Quads", Line: r.line + 1, Message: "line too long"}
+		}
+		return Quad{}, err
+	}
+	return Quad{}, io.EOF
+}
+
+// ParseNQuads reads all statements of an N-Quads or N-Triples document
+func ParseNQuads(r io.Reader) ([]Quad, error) {
+	reader := NewNQuadsReader(r)
+	var quads []Quad
+	for {
+		quad, err := reader.Read()
+		if err == io.EOF {
+			return quads, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		quads = append(quads, quad)
+	}
+}
+
+// ntParser parses a single N-Quads line
+type ntParser struct {
+	input string
+	pos   int
+	line  int
+}
+
+func (p *ntParser) errorf(format string, args ...interface{}) error {
+	return &RDFSyntaxError{Format: "N-Quads", Line: p.line, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *ntParser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t' || p.input[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+func (p *ntParser) atEnd() bool {
+	p.skipSpace()
+	return p.pos >= len(p.input) || p.input[p.pos] == '#'
+}
+
+// statement parses one statement; ok is false for blank and comment lines
+func (p *ntParser) statement() (quad Quad, ok bool, err error) {
+	if p.atEnd() {
+		return Quad{}, false, nil
+	}
+
+	if quad.Subject, err = p.term(); err != nil {
+		return Quad{}, false, err
+	}
+	if quad.Subject.IsLiteral() {
+		return Quad{}, false, p.errorf("literal as subject")
+	}
+	p.skipSpace()
+	if quad.Predicate, err = p.term(); err != nil {
+		return Quad{}, false, err
+	}
+	if !quad.Predicate.IsIRI() {
+		return Quad{}, false, p.errorf("predicate must be an IRI")
+	}
+	p.skipSpace()
+	if quad.Object, err = p.term(); err != nil {
+		return Quad{}, false, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] != '.' {
+		if quad.Graph, err = p.term(); err != nil {
+			return Quad{}, false, err
+		}
+		if quad.Graph.IsLiteral() {
+			return Quad{}, false, p.errorf("literal as graph name")
+		}
+		p.skipSpace()
+	}
+	if p.pos >= len(p.input) || p.input[p.pos] != '.' {
+		return Quad{}, false, p.errorf("expected '.' at column %d", p.pos+1)
+	}
+	p.pos++
+	if !p.atEnd() {
+		return Quad{}, false, p.errorf("unexpected content after '.' at column %d", p.pos+1)
+	}
+	return quad, true, nil
+}
+
+// term parses an IRI, blank node or literal
+func (p *ntParser) term() (Term, error) {
+	if p.pos >= len(p.input) {
+		return Term{}, p.errorf("unexpected end of line")
+	}
+	switch p.input[p.pos] {
+	case '<':
+		iri, err := p.absoluteIRI()
+		if err != nil {
+			return Term{}, err
+		}
+		return NewIRI(iri), nil
+	case '_':
+		return p.blankNode()
+	case '"':
+		return p.literal()
+	}
+	return Term{}, p.errorf("unexpected character %q at column %d", p.input[p.pos], p.pos+1)
+}
+
+func (p *ntParser) iri() (string, error) {
+	p.pos++ // <
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == '>':
+			p.pos++
+			return b.String(), nil
+		case c == '\\':
+			r, err := p.unicodeEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		case c <= ' ' || c == '<' || c == '"' || c == '{' || c == '}' || c == '|' || c == '^' || c == '`':
+			return "", p.errorf("invalid character %q in IRI", c)
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated IRI")
+}
+
+// absoluteIRI parses an IRI and rejects relative references, which
+// N-Quads does not allow
+func (p *ntParser) absoluteIRI() (string, error) {
+	start := p.pos
+	iri, err := p.iri()
+	if err != nil {
+		return "", err
+	}
+	if !hasIRIScheme(iri) {
+		return "", p.errorf("relative IRI <%s> at column %d", iri, start+1)
+	}
+	return iri, nil
+}
+
+// hasIRIScheme reports whether iri starts with a scheme as in RFC 3987
+func hasIRIScheme(iri string) bool {
+	colon := strings.IndexByte(iri, ':')
+	if colon < 1 {
+		return false
+	}
+	for i := 0; i < colon; i++ {
+		c := iri[i]
+		switch {
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case i > 0 && ((c >= '0' && c <= '9') || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (p *ntParser) blankNode() (Term, error) {
+	if !strings.HasPrefix(p.input[p.pos:], "_:") {
+		return Term{}, p.errorf("invalid blank node at column %d", p.pos+1)
+	}
+	p.pos += 2
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune(" \t\r<\"", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	// A label cannot end with '.', which belongs to the statement
+	for p.pos > start && p.input[p.pos-1] == '.' {
+		p.pos--
+	}
+	if p.pos == start {
+		return Term{}, p.errorf("empty blank node label")
+	}
+	return NewBlankNode(p.input[start:p.pos]), nil
+}
+
+func (p *ntParser) literal() (Term, error) {
+	value, err := p.quotedString()
+	if err != nil {
+		return Term{}, err
+	}
+
+	if p.pos < len(p.input) && p.input[p.pos] == '@' {
+		p.pos++
+		start := p.pos
+		for p.pos < len(p.input) && (isAlphaNum(p.input[p.pos]) || p.input[p.pos] == '-') {
+			p.pos++
+		}
+		tag := p.input[start:p.pos]
+		if !validLanguageTag(tag) {
+			return Term{}, p.errorf("invalid language tag %q", tag)
+		}
+		return NewLangLiteral(value, strings.ToLower(tag)), nil
+	}
+	if strings.HasPrefix(p.input[p.pos:], "^^") {
+		p.pos += 2
+		if p.pos >= len(p.input) || p.input[p.pos] != '<' {
+			return Term{}, p.errorf("expected datatype IRI")
+		}
+		datatype, err := p.absoluteIRI()
+		if err != nil {
+			return Term{}, err
+		}
+		return NewLiteral(value, datatype), nil
+	}
+	return NewLiteral(value, ""), nil
+}
+
+// quotedString parses a double quoted string with N-Triples escapes
+func (p *ntParser) quotedString() (string, error) {
+	p.pos++ // "
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if p.pos+1 >= len(p.input) {
+				return "", p.errorf("unterminated escape")
+			}
+			switch esc := p.input[p.pos+1]; esc {
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 'f':
+				b.WriteByte('\f')
+			case '"', '\'', '\\':
+				b.WriteByte(esc)
+			case 'u', 'U':
+				r, err := p.unicodeEscape()
+				if err != nil {
+					return "", err
+				}
+				b.WriteRune(r)
+				continue
+			default:
+				return "", p.errorf("invalid escape \\%c", esc)
+			}
+			p.pos += 2
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// unicodeEscape parses \uXXXX or \UXXXXXXXX at the current position
+func (p *ntParser) unicodeEscape() (rune, error) {
+	if p.pos+1 >= len(p.input) {
+		return 0, p.errorf("unterminated escape")
+	}
+	digits := 0
+	switch p.input[p.pos+1] {
+	case 'u':
+		digits = 4
+	case 'U':
+		digits = 8
+	default:
+		return 0, p.errorf("invalid escape \\%c", p.input[p.pos+1])
+	}
+	start := p.pos + 2
+	if start+digits > len(p.input) {
+		return 0, p.errorf("truncated unicode escape")
+	}
+	code, err := strconv.ParseUint(p.input[start:start+digits], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return 0, p.errorf("invalid unicode escape %q", p.input[p.pos:start+digits])
+	}
+	p.pos = start + digits
+	return rune(code), nil
+}
+
+// validLanguageTag checks the N-Quads LANGTAG production [a-zA-Z]+ ('-' [a-zA-Z0-9]+)*
+func validLanguageTag(tag string) bool {
+	for i, part := range strings.Split(tag, "-") {
+		if part == "" {
+			return false
+		}
+		for j := 0; j < len(part); j++ {
+			if !isAlphaNum(part[j]) || (i == 0 && part[j] >= '0' && part[j] <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isAlphaNum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
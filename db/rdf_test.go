@@ -0,0 +1,96 @@
+package db
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestURDNA2015Manifest runs the W3C URDNA2015 evaluation tests in testdata/rdf-canon
+func TestURDNA2015Manifest(t *testing.T) {
+	dir := filepath.Join("testdata", "rdf-canon")
+	data, err := os.ReadFile(filepath.Join(dir, "manifest-urdna2015.jsonld"))
+	require.NoError(t, err)
+
+	var manifest struct {
+		Entries []struct {
+			ID     string `json:"id"`
+			Type   string `json:"type"`
+			Name   string `json:"name"`
+			Action string `json:"action"`
+			Result string `json:"result"`
+		} `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	require.NotEmpty(t, manifest.Entries)
+
+	for _, entry := range manifest.Entries {
+		entry := entry
+		t.Run(strings.TrimPrefix(entry.ID, "manifest-urdna2015#")+" "+entry.Name, func(t *testing.T) {
+			require.Equal(t, "rdfn:Urdna2015EvalTest", entry.Type)
+
+			input, err := os.Open(filepath.Join(dir, entry.Action))
+			require.NoError(t, err)
+			defer input.Close()
+			quads, err := ParseNQuads(input)
+			require.NoError(t, err)
+
+			expected, err := os.ReadFile(filepath.Join(dir, entry.Result))
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), CanonicalNQuads(quads))
+		})
+	}
+}
+
+// TestNQuadsSyntaxSuite runs the W3C N-Quads syntax tests in testdata/nquads.
+// Files named *-bad-* are negative tests, all others must parse.
+func TestNQuadsSyntaxSuite(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "nquads", "*.nq"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		file := file
+		name := strings.TrimSuffix(filepath.Base(file), ".nq")
+		t.Run(name, func(t *testing.T) {
+			input, err := os.Open(file)
+			require.NoError(t, err)
+			defer input.Close()
+
+			quads, err := ParseNQuads(input)
+			if strings.Contains(name, "-bad-") {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// Serializing and parsing again must give the same dataset
+			reparsed, err := ParseNQuads(strings.NewReader(FormatNQuads(quads)))
+			require.NoError(t, err)
+			assert.Equal(t, SortQuads(quads), SortQuads(reparsed))
+		})
+	}
+}
+
+// TestEscapeLiteral tests canonical N-Quads literal escaping
+func TestEscapeLiteral(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{`say "hi"`, `say \"hi\"`},
+		{`back\slash`, `back\\slash`},
+		{"line\nbreak\r", `line\nbreak\r`},
+		{"tab\tbackspace\bformfeed\f", "tab\tbackspace\bformfeed\f"},
+		{"\u0001\u007f", "\u0001\u007f"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, escapeLiteral(tt.in), "escapeLiteral(%q)", tt.in)
+	}
+}
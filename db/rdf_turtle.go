@@ -0,0 +1,733 @@
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultRDFPrefixes are the namespace prefixes writers use when no others are
+// given. Only prefixes that occur in the output are declared.
+var DefaultRDFPrefixes = map[string]string{
+	"rdf":     RDFNamespace,
+	"rdfs":    RDFSNamespace,
+	"xsd":     XSDNamespace,
+	"owl":     "http://www.w3.org/2002/07/owl#",
+	"skos":    "http://www.w3.org/2004/02/skos/core#",
+	"schema":  "http://schema.org/",
+	"dcterms": "http://purl.org/dc/terms/",
+	"foaf":    "http://xmlns.com/foaf/0.1/",
+	"sh":      "http://www.w3.org/ns/shacl#",
+}
+
+// ParseTurtle parses a Turtle document. Relative IRIs are resolved against
+// base; blank nodes are given fresh labels.
+//
+// Example Usage:
+//
+//	quads, err := ParseTurtle(strings.NewReader(`
+//	    @prefix schema: <http://schema.org/> .
+//	    <urn:app:nginx> a schema:SoftwareApplication ; schema:name "nginx" .`), "")
+func ParseTurtle(r io.Reader, base string) ([]Quad, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &turtleParser{
+		input:    string(stripBOM(data)),
+		line:     1,
+		base:     base,
+		prefixes: make(map[string]string),
+		labels:   make(map[string]string),
+		issuer:   newBlankNodeIssuer("b"),
+	}
+	if err := p.document(); err != nil {
+		return nil, err
+	}
+	return p.quads, nil
+}
+
+// turtleParser is a recursive descent parser for Turtle
+type turtleParser struct {
+	input    string
+	pos      int
+	line     int
+	base     string
+	prefixes map[string]string
+	labels   map[string]string // Document blank node labels to fresh labels
+	issuer   *blankNodeIssuer
+	quads    []Quad
+}
+
+func (p *turtleParser) errorf(format string, args ...interface{}) error {
+	return &RDFSyntaxError{Format: "Turtle", Line: p.line, Message: fmt.Sprintf(format, args...)}
+}
+
+// skip skips whitespace and comments
+func (p *turtleParser) skip() {
+	for p.pos < len(p.input) {
+		switch c := p.input[p.pos]; c {
+		case '\n':
+			p.line++
+			p.pos++
+		case ' ', '\t', '\r':
+			p.pos++
+		case '#':
+			for p.pos < len(p.input) && p.input[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *turtleParser) peek() byte {
+	p.skip()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *turtleParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected %q, found %s", c, p.found())
+	}
+	p.pos++
+	return nil
+}
+
+// found describes the input at the current position for error messages
+func (p *turtleParser) found() string {
+	if p.pos >= len(p.input) {
+		return "end of input"
+	}
+	end := p.pos + 10
+	if end > len(p.input) {
+		end = len(p.input)
+	}
+	return strconv.Quote(p.input[p.pos:end])
+}
+
+// keyword reports whether the input continues with word (case-insensitively
+// if fold) followed by a non-name character
+func (p *turtleParser) keyword(word string, fold bool) bool {
+	p.skip()
+	end := p.pos + len(word)
+	if end > len(p.input) {
+		return false
+	}
+	candidate := p.input[p.pos:end]
+	if candidate != word && !(fold && strings.EqualFold(candidate, word)) {
+		return false
+	}
+	if end == len(p.input) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(p.input[end:])
+	if r == '.' && end+1 < len(p.input) {
+		// A dot continues a name only if more name characters follow
+		r, _ = utf8.DecodeRuneInString(p.input[end+1:])
+	}
+	return !isPNChar(r) && r != ':'
+}
+
+func (p *turtleParser) add(s, pred, o Term) {
+	p.quads = append(p.quads, Quad{Subject: s, Predicate: pred, Object: o})
+}
+
+func (p *turtleParser) document() error {
+	for p.peek() != 0 {
+		if err := p.statement(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *turtleParser) statement() error {
+	switch {
+	case p.keyword("@prefix", false):
+		p.pos += len("@prefix")
+		if err := p.prefixDirective(); err != nil {
+			return err
+		}
+		return p.expect('.')
+	case p.keyword("@base", false):
+		p.pos += len("@base")
+		if err := p.baseDirective(); err != nil {
+			return err
+		}
+		return p.expect('.')
+	case p.keyword("PREFIX", true):
+		p.pos += len("PREFIX")
+		return p.prefixDirective()
+	case p.keyword("BASE", true):
+		p.pos += len("BASE")
+		return p.baseDirective()
+	}
+
+	if err := p.triples(); err != nil {
+		return err
+	}
+	return p.expect('.')
+}
+
+func (p *turtleParser) prefixDirective() error {
+	p.skip()
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] != ':' {
+		r, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		if !isPNChar(r) && r != '.' {
+			return p.errorf("invalid prefix name")
+		}
+		p.pos += size
+	}
+	if p.pos >= len(p.input) {
+		return p.errorf("expected ':' in prefix declaration")
+	}
+	prefix := p.input[start:p.pos]
+	p.pos++
+	if p.peek() != '<' {
+		return p.errorf("expected IRI in prefix declaration")
+	}
+	iri, err := p.iriRef()
+	if err != nil {
+		return err
+	}
+	p.prefixes[prefix] = iri
+	return nil
+}
+
+func (p *turtleParser) baseDirective() error {
+	if p.peek() != '<' {
+		return p.errorf("expected IRI in base declaration")
+	}
+	iri, err := p.iriRef()
+	if err != nil {
+		return err
+	}
+	p.base = iri
+	return nil
+}
+
+func (p *turtleParser) triples() error {
+	if p.peek() == '[' {
+		subject, hasProperties, err := p.blankNodePropertyList()
+		if err != nil {
+			return err
+		}
+		if hasProperties && p.peek() == '.' {
+			return nil
+		}
+		return p.predicateObjectList(subject)
+	}
+
+	subject, err := p.subject()
+	if err != nil {
+		return err
+	}
+	return p.predicateObjectList(subject)
+}
+
+func (p *turtleParser) subject() (Term, error) {
+	switch p.peek() {
+	case '<':
+		iri, err := p.iriRef()
+		return NewIRI(iri), err
+	case '_':
+		return p.blankNodeLabel()
+	case '(':
+		return p.collection()
+	}
+	iri, err := p.prefixedName()
+	return NewIRI(iri), err
+}
+
+func (p *turtleParser) predicateObjectList(subject Term) error {
+	for {
+		predicate, err := p.verb()
+		if err != nil {
+			return err
+		}
+		if err := p.objectList(subject, predicate); err != nil {
+			return err
+		}
+		if p.peek() != ';' {
+			return nil
+		}
+		for p.peek() == ';' {
+			p.pos++
+		}
+		if c := p.peek(); c == '.' || c == ']' || c == 0 {
+			return nil
+		}
+	}
+}
+
+func (p *turtleParser) verb() (Term, error) {
+	if p.keyword("a", false) {
+		p.pos++
+		return NewIRI(RDFType), nil
+	}
+	if p.peek() == '<' {
+		iri, err := p.iriRef()
+		return NewIRI(iri), err
+	}
+	iri, err := p.prefixedName()
+	return NewIRI(iri), err
+}
+
+func (p *turtleParser) objectList(subject, predicate Term) error {
+	for {
+		object, err := p.object()
+		if err != nil {
+			return err
+		}
+		p.add(subject, predicate, object)
+		if p.peek() != ',' {
+			return nil
+		}
+		p.pos++
+	}
+}
+
+func (p *turtleParser) object() (Term, error) {
+	switch c := p.peek(); {
+	case c == '<':
+		iri, err := p.iriRef()
+		return NewIRI(iri), err
+	case c == '_':
+		return p.blankNodeLabel()
+	case c == '[':
+		term, _, err := p.blankNodePropertyList()
+		return term, err
+	case c == '(':
+		return p.collection()
+	case c == '"' || c == '\'':
+		return p.rdfLiteral()
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.numericLiteral()
+	case p.keyword("true", false):
+		p.pos += 4
+		return NewLiteral("true", XSDBoolean), nil
+	case p.keyword("false", false):
+		p.pos += 5
+		return NewLiteral("false", XSDBoolean), nil
+	}
+	iri, err := p.prefixedName()
+	return NewIRI(iri), err
+}
+
+// blankNodePropertyList parses [ ... ]; hasProperties is false for []
+func (p *turtleParser) blankNodePropertyList() (Term, bool, error) {
+	p.pos++ // [
+	node := NewBlankNode(p.issuer.next())
+	if p.peek() == ']' {
+		p.pos++
+		return node, false, nil
+	}
+	if err := p.predicateObjectList(node); err != nil {
+		return Term{}, false, err
+	}
+	if err := p.expect(']'); err != nil {
+		return Term{}, false, err
+	}
+	return node, true, nil
+}
+
+func (p *turtleParser) collection() (Term, error) {
+	p.pos++ // (
+	var items []Term
+	for p.peek() != ')' {
+		if p.peek() == 0 {
+			return Term{}, p.errorf("unterminated collection")
+		}
+		item, err := p.object()
+		if err != nil {
+			return Term{}, err
+		}
+		items = append(items, item)
+	}
+	p.pos++
+
+	head := NewIRI(RDFNil)
+	for i := len(items) - 1; i >= 0; i-- {
+		node := NewBlankNode(p.issuer.next())
+		p.add(node, NewIRI(RDFFirst), items[i])
+		p.add(node, NewIRI(RDFRest), head)
+		head = node
+	}
+	return head, nil
+}
+
+func (p *turtleParser) blankNodeLabel() (Term, error) {
+	if !strings.HasPrefix(p.input[p.pos:], "_:") {
+		return Term{}, p.errorf("invalid blank node, found %s", p.found())
+	}
+	p.pos += 2
+	start := p.pos
+	for p.pos < len(p.input) {
+		r, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		if !isPNChar(r) && r != '.' {
+			break
+		}
+		p.pos += size
+	}
+	for p.pos > start && p.input[p.pos-1] == '.' {
+		p.pos--
+	}
+	if p.pos == start {
+		return Term{}, p.errorf("empty blank node label")
+	}
+	label := p.input[start:p.pos]
+	fresh, ok := p.labels[label]
+	if !ok {
+		fresh = p.issuer.next()
+		p.labels[label] = fresh
+	}
+	return NewBlankNode(fresh), nil
+}
+
+// iriRef parses <...> and resolves it against the base IRI
+func (p *turtleParser) iriRef() (string, error) {
+	p.pos++ // <
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == '>':
+			p.pos++
+			return resolveIRI(p.base, b.String()), nil
+		case c == '\\':
+			r, err := p.unicodeEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		case c <= ' ' || c == '<' || c == '"' || c == '{' || c == '}' || c == '|' || c == '^' || c == '`':
+			return "", p.errorf("invalid character %q in IRI", c)
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated IRI")
+}
+
+// prefixedName parses prefix:local and expands it
+func (p *turtleParser) prefixedName() (string, error) {
+	p.skip()
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] != ':' {
+		r, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		if !isPNChar(r) && r != '.' {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos >= len(p.input) || p.input[p.pos] != ':' {
+		p.pos = start
+		return "", p.errorf("unexpected %s", p.found())
+	}
+	prefix := p.input[start:p.pos]
+	namespace, ok := p.prefixes[prefix]
+	if !ok {
+		return "", p.errorf("undefined prefix %q", prefix)
+	}
+	p.pos++
+
+	var local strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if c == '\\' && p.pos+1 < len(p.input) && strings.IndexByte("_~.-!$&'()*+,;=/?#@%", p.input[p.pos+1]) >= 0 {
+			local.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		if !isPNChar(r) && r != ':' && r != '.' && r != '%' {
+			break
+		}
+		local.WriteRune(r)
+		p.pos += size
+	}
+	name := local.String()
+	// A trailing '.' ends the statement
+	for strings.HasSuffix(name, ".") {
+		name = name[:len(name)-1]
+		p.pos--
+	}
+	return namespace + name, nil
+}
+
+func (p *turtleParser) rdfLiteral() (Term, error) {
+	value, err := p.stringLiteral()
+	if err != nil {
+		return Term{}, err
+	}
+	if p.pos < len(p.input) && p.input[p.pos] == '@' {
+		p.pos++
+		start := p.pos
+		for p.pos < len(p.input) && (isAlphaNum(p.input[p.pos]) || p.input[p.pos] == '-') {
+			p.pos++
+		}
+		if p.pos == start {
+			return Term{}, p.errorf("empty language tag")
+		}
+		return NewLangLiteral(value, strings.ToLower(p.input[start:p.pos])), nil
+	}
+	if strings.HasPrefix(p.input[p.pos:], "^^") {
+		p.pos += 2
+		var datatype string
+		if p.peek() == '<' {
+			datatype, err = p.iriRef()
+		} else {
+			datatype, err = p.prefixedName()
+		}
+		if err != nil {
+			return Term{}, err
+		}
+		return NewLiteral(value, datatype), nil
+	}
+	return NewLiteral(value, ""), nil
+}
+
+// stringLiteral parses a short or long string quoted with ' or "
+func (p *turtleParser) stringLiteral() (string, error) {
+	quote := p.input[p.pos]
+	long := strings.HasPrefix(p.input[p.pos:], strings.Repeat(string(quote), 3))
+	if long {
+		p.pos += 3
+	} else {
+		p.pos++
+	}
+
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == quote && !long:
+			p.pos++
+			return b.String(), nil
+		case c == quote && strings.HasPrefix(p.input[p.pos:], strings.Repeat(string(quote), 3)):
+			// Quotes directly before the closing delimiter belong to the string
+			for strings.HasPrefix(p.input[p.pos+1:], strings.Repeat(string(quote), 3)) {
+				b.WriteByte(quote)
+				p.pos++
+			}
+			p.pos += 3
+			return b.String(), nil
+		case c == '\n' && !long:
+			return "", p.errorf("newline in string")
+		case c == '\\':
+			if p.pos+1 >= len(p.input) {
+				return "", p.errorf("unterminated escape")
+			}
+			switch esc := p.input[p.pos+1]; esc {
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 'f':
+				b.WriteByte('\f')
+			case '"', '\'', '\\':
+				b.WriteByte(esc)
+			case 'u', 'U':
+				r, err := p.unicodeEscape()
+				if err != nil {
+					return "", err
+				}
+				b.WriteRune(r)
+				continue
+			default:
+				return "", p.errorf("invalid escape \\%c", esc)
+			}
+			p.pos += 2
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+var turtleNumber = regexp.MustCompile(`^[+-]?(?:[0-9]+(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?|\.[0-9]+(?:[eE][+-]?[0-9]+)?)`)
+
+func (p *turtleParser) numericLiteral() (Term, error) {
+	match := turtleNumber.FindString(p.input[p.pos:])
+	if match == "" {
+		return Term{}, p.errorf("invalid number %s", p.found())
+	}
+	p.pos += len(match)
+	switch {
+	case strings.ContainsAny(match, "eE"):
+		return NewLiteral(match, XSDDouble), nil
+	case strings.Contains(match, "."):
+		return NewLiteral(match, XSDDecimal), nil
+	}
+	return NewLiteral(match, XSDInteger), nil
+}
+
+func (p *turtleParser) unicodeEscape() (rune, error) {
+	nt := &ntParser{input: p.input, pos: p.pos, line: p.line}
+	r, err := nt.unicodeEscape()
+	if err != nil {
+		return 0, p.errorf("%s", err.(*RDFSyntaxError).Message)
+	}
+	p.pos = nt.pos
+	return r, nil
+}
+
+// isPNChar reports whether r may appear in Turtle prefix and local names
+func isPNChar(r rune) bool {
+	return r == '_' || r == '-' || r == 0xB7 || unicode.IsLetter(r) || unicode.IsDigit(r) ||
+		(r >= 0x300 && r <= 0x36F) || (r >= 0x203F && r <= 0x2040)
+}
+
+// WriteTurtle writes the statements as Turtle, grouped by subject. Graph
+// names are dropped, as Turtle describes a single graph.
+//
+// Parameters:
+//   - w: Destination
+//   - quads: Statements to write
+//   - prefixes: Namespace prefixes to abbreviate IRIs with (nil for DefaultRDFPrefixes)
+//
+// Returns:
+//   - error: Write errors
+func WriteTurtle(w io.Writer, quads []Quad, prefixes map[string]string) error {
+	if prefixes == nil {
+		prefixes = DefaultRDFPrefixes
+	}
+	tw := &turtleWriter{prefixes: prefixes, used: make(map[string]bool)}
+
+	triples := make([]Quad, len(quads))
+	for i, quad := range quads {
+		quad.Graph = Term{}
+		triples[i] = quad
+	}
+	triples = SortQuads(triples)
+
+	var body strings.Builder
+	for i := 0; i < len(triples); {
+		end := i
+		for end < len(triples) && triples[end].Subject == triples[i].Subject {
+			end++
+		}
+		group := triples[i:end]
+		// rdf:type is written first, as "a"
+		sort.SliceStable(group, func(a, b int) bool {
+			return group[a].Predicate.Value == RDFType && group[b].Predicate.Value != RDFType
+		})
+
+		body.WriteString(tw.term(group[0].Subject))
+		for j := 0; j < len(group); {
+			predicate := group[j].Predicate
+			if j > 0 {
+				body.WriteString(" ;\n   ")
+			}
+			if predicate.Value == RDFType {
+				body.WriteString(" a ")
+			} else {
+				body.WriteString(" " + tw.term(predicate) + " ")
+			}
+			k := j
+			for ; k < len(group) && group[k].Predicate == predicate; k++ {
+				if k > j {
+					body.WriteString(", ")
+				}
+				body.WriteString(tw.term(group[k].Object))
+			}
+			j = k
+		}
+		body.WriteString(" .\n\n")
+		i = end
+	}
+
+	bw := bufio.NewWriter(w)
+	var used []string
+	for prefix := range tw.used {
+		used = append(used, prefix)
+	}
+	sort.Strings(used)
+	for _, prefix := range used {
+		fmt.Fprintf(bw, "@prefix %s: <%s> .\n", prefix, escapeIRI(prefixes[prefix]))
+	}
+	if len(used) > 0 && body.Len() > 0 {
+		bw.WriteString("\n")
+	}
+	bw.WriteString(strings.TrimSuffix(body.String(), "\n"))
+	return bw.Flush()
+}
+
+// turtleWriter formats terms and records the prefixes it uses
+type turtleWriter struct {
+	prefixes map[string]string
+	used     map[string]bool
+}
+
+var (
+	turtleLocalName = regexp.MustCompile(`^(?:[A-Za-z0-9_](?:[A-Za-z0-9_.\-]*[A-Za-z0-9_\-])?)?$`)
+	turtleInteger   = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	turtleDecimal   = regexp.MustCompile(`^[+-]?[0-9]*\.[0-9]+$`)
+	turtleDouble    = regexp.MustCompile(`^[+-]?(?:[0-9]+\.?[0-9]*|\.[0-9]+)[eE][+-]?[0-9]+$`)
+)
+
+func (tw *turtleWriter) term(t Term) string {
+	switch t.Kind {
+	case TermIRI:
+		return tw.iri(t.Value)
+	case TermLiteral:
+		switch {
+		case t.Datatype == XSDInteger && turtleInteger.MatchString(t.Value),
+			t.Datatype == XSDDecimal && turtleDecimal.MatchString(t.Value),
+			t.Datatype == XSDDouble && turtleDouble.MatchString(t.Value),
+			t.Datatype == XSDBoolean && (t.Value == "true" || t.Value == "false"):
+			return t.Value
+		}
+		literal := `"` + escapeLiteral(t.Value) + `"`
+		switch {
+		case t.Language != "":
+			return literal + "@" + t.Language
+		case t.Datatype != "" && t.Datatype != XSDString:
+			return literal + "^^" + tw.iri(t.Datatype)
+		}
+		return literal
+	}
+	return t.String()
+}
+
+// iri abbreviates an IRI with the longest matching prefix
+func (tw *turtleWriter) iri(iri string) string {
+	best, bestNamespace := "", ""
+	for prefix, namespace := range tw.prefixes {
+		if namespace == "" || !strings.HasPrefix(iri, namespace) || len(namespace) < len(bestNamespace) {
+			continue
+		}
+		if !turtleLocalName.MatchString(iri[len(namespace):]) {
+			continue
+		}
+		if len(namespace) > len(bestNamespace) || prefix < best {
+			best, bestNamespace = prefix, namespace
+		}
+	}
+	if bestNamespace == "" {
+		return "<" + escapeIRI(iri) + ">"
+	}
+	tw.used[best] = true
+	return best + ":" + iri[len(bestNamespace):]
+}
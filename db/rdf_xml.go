@@ -0,0 +1,480 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	xmlNamespace  = "http://www.w3.org/XML/1998/namespace"
+	rdfXMLLiteral = RDFNamespace + "XMLLiteral"
+)
+
+// ParseRDFXML parses an RDF/XML document. Relative IRIs are resolved against
+// xml:base or, failing that, base; blank nodes are given fresh labels.
+//
+// Example Usage:
+//
+//	file, _ := os.Open("ontology.rdf")
+//	defer file.Close()
+//	quads, err := ParseRDFXML(file, "http://example.org/")
+func ParseRDFXML(r io.Reader, base string) ([]Quad, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := readXMLTree(stripBOM(data), base)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &rdfXMLParser{labels: make(map[string]string), issuer: newBlankNodeIssuer("b")}
+	if root.is(RDFNamespace, "RDF") {
+		for _, child := range root.children {
+			if _, err := p.nodeElement(child); err != nil {
+				return nil, err
+			}
+		}
+	} else if _, err := p.nodeElement(root); err != nil {
+		return nil, err
+	}
+	return p.quads, nil
+}
+
+// xmlElement is an element of a parsed XML document with inherited
+// xml:base and xml:lang applied
+type xmlElement struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     strings.Builder
+	inner    string // Raw content, used for rdf:parseType="Literal"
+	base     string
+	lang     string
+	line     int
+}
+
+func (e *xmlElement) is(space, local string) bool {
+	return e.name.Space == space && e.name.Local == local
+}
+
+// rdfAttr returns an rdf: attribute, also accepting the unqualified form
+// older documents use
+func (e *xmlElement) rdfAttr(local string) (string, bool) {
+	for _, attr := range e.attrs {
+		if attr.Name.Local == local && (attr.Name.Space == RDFNamespace || attr.Name.Space == "") {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// readXMLTree decodes data into an element tree
+func readXMLTree(data []byte, base string) (*xmlElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var (
+		stack  []*xmlElement
+		starts []int64
+		root   *xmlElement
+	)
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &RDFSyntaxError{Format: "RDF/XML", Line: bytes.Count(data[:offset], []byte("\n")) + 1, Message: err.Error()}
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			line := bytes.Count(data[:offset], []byte("\n")) + 1
+			element := &xmlElement{name: t.Name, base: base, line: line}
+			if t.Name.Space != "" && !strings.Contains(t.Name.Space, ":") {
+				return nil, &RDFSyntaxError{Format: "RDF/XML", Line: line, Message: fmt.Sprintf("undeclared namespace prefix %q", t.Name.Space)}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				element.base, element.lang = parent.base, parent.lang
+				parent.children = append(parent.children, element)
+			} else if root == nil {
+				root = element
+			}
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns"):
+				case attr.Name.Space == xmlNamespace && attr.Name.Local == "base":
+					element.base = resolveIRI(element.base, attr.Value)
+				case attr.Name.Space == xmlNamespace && attr.Name.Local == "lang":
+					element.lang = strings.ToLower(attr.Value)
+				case attr.Name.Space == xmlNamespace:
+				case attr.Name.Space != "" && !strings.Contains(attr.Name.Space, ":"):
+					return nil, &RDFSyntaxError{Format: "RDF/XML", Line: line, Message: fmt.Sprintf("undeclared namespace prefix %q", attr.Name.Space)}
+				default:
+					element.attrs = append(element.attrs, attr)
+				}
+			}
+			stack = append(stack, element)
+			starts = append(starts, decoder.InputOffset())
+		case xml.EndElement:
+			element := stack[len(stack)-1]
+			element.inner = string(data[starts[len(starts)-1]:offset])
+			stack, starts = stack[:len(stack)-1], starts[:len(starts)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, &RDFSyntaxError{Format: "RDF/XML", Line: 1, Message: "no root element"}
+	}
+	return root, nil
+}
+
+// rdfXMLParser converts an element tree to statements following the RDF/XML grammar
+type rdfXMLParser struct {
+	labels map[string]string // Document node IDs to fresh labels
+	issuer *blankNodeIssuer
+	quads  []Quad
+}
+
+func (p *rdfXMLParser) errorf(e *xmlElement, format string, args ...interface{}) error {
+	return &RDFSyntaxError{Format: "RDF/XML", Line: e.line, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *rdfXMLParser) add(s, pred, o Term) {
+	p.quads = append(p.quads, Quad{Subject: s, Predicate: pred, Object: o})
+}
+
+func (p *rdfXMLParser) blankNode(nodeID string) Term {
+	if nodeID == "" {
+		return NewBlankNode(p.issuer.next())
+	}
+	label, ok := p.labels[nodeID]
+	if !ok {
+		label = p.issuer.next()
+		p.labels[nodeID] = label
+	}
+	return NewBlankNode(label)
+}
+
+// isSyntaxAttr reports whether attr is an RDF/XML syntax attribute rather
+// than a property attribute
+func isSyntaxAttr(attr xml.Attr) bool {
+	if attr.Name.Space != RDFNamespace && attr.Name.Space != "" {
+		return false
+	}
+	switch attr.Name.Local {
+	case "about", "ID", "nodeID", "resource", "parseType", "datatype", "aboutEach", "aboutEachPrefix", "bagID":
+		return true
+	}
+	return attr.Name.Space == ""
+}
+
+// nodeElement handles an element describing a resource and returns the resource
+func (p *rdfXMLParser) nodeElement(e *xmlElement) (Term, error) {
+	if e.name.Space == "" {
+		return Term{}, p.errorf(e, "element <%s> has no namespace", e.name.Local)
+	}
+
+	var subject Term
+	if about, ok := e.rdfAttr("about"); ok {
+		subject = NewIRI(resolveIRI(e.base, about))
+	} else if id, ok := e.rdfAttr("ID"); ok {
+		subject = NewIRI(resolveIRI(e.base, "#"+id))
+	} else if nodeID, ok := e.rdfAttr("nodeID"); ok {
+		subject = p.blankNode(nodeID)
+	} else {
+		subject = p.blankNode("")
+	}
+
+	if !e.is(RDFNamespace, "Description") {
+		p.add(subject, NewIRI(RDFType), NewIRI(e.name.Space+e.name.Local))
+	}
+	p.propertyAttrs(subject, e)
+
+	li := 0
+	for _, child := range e.children {
+		if err := p.propertyElement(subject, child, &li); err != nil {
+			return Term{}, err
+		}
+	}
+	return subject, nil
+}
+
+// propertyAttrs adds the statements abbreviated as attributes of e
+func (p *rdfXMLParser) propertyAttrs(subject Term, e *xmlElement) {
+	for _, attr := range e.attrs {
+		if isSyntaxAttr(attr) {
+			continue
+		}
+		predicate := attr.Name.Space + attr.Name.Local
+		if predicate == RDFType {
+			p.add(subject, NewIRI(RDFType), NewIRI(resolveIRI(e.base, attr.Value)))
+		} else if e.lang != "" {
+			p.add(subject, NewIRI(predicate), NewLangLiteral(attr.Value, e.lang))
+		} else {
+			p.add(subject, NewIRI(predicate), NewLiteral(attr.Value, ""))
+		}
+	}
+}
+
+// propertyElement handles a property of subject; li numbers rdf:li elements
+func (p *rdfXMLParser) propertyElement(subject Term, e *xmlElement, li *int) error {
+	if e.name.Space == "" {
+		return p.errorf(e, "element <%s> has no namespace", e.name.Local)
+	}
+	predicate := NewIRI(e.name.Space + e.name.Local)
+	if e.is(RDFNamespace, "li") {
+		*li++
+		predicate = NewIRI(RDFNamespace + "_" + strconv.Itoa(*li))
+	}
+
+	parseType, _ := e.rdfAttr("parseType")
+	switch parseType {
+	case "Resource":
+		object := p.blankNode("")
+		p.add(subject, predicate, object)
+		li := 0
+		for _, child := range e.children {
+			if err := p.propertyElement(object, child, &li); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "Literal":
+		p.add(subject, predicate, NewLiteral(e.inner, rdfXMLLiteral))
+		return nil
+	case "Collection":
+		items := make([]Term, 0, len(e.children))
+		for _, child := range e.children {
+			item, err := p.nodeElement(child)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		head := NewIRI(RDFNil)
+		for i := len(items) - 1; i >= 0; i-- {
+			node := p.blankNode("")
+			p.add(node, NewIRI(RDFFirst), items[i])
+			p.add(node, NewIRI(RDFRest), head)
+			head = node
+		}
+		p.add(subject, predicate, head)
+		return nil
+	case "":
+	default:
+		return p.errorf(e, "unsupported rdf:parseType %q", parseType)
+	}
+
+	switch len(e.children) {
+	case 0:
+	case 1:
+		object, err := p.nodeElement(e.children[0])
+		if err != nil {
+			return err
+		}
+		p.add(subject, predicate, object)
+		return nil
+	default:
+		return p.errorf(e, "property element <%s> has more than one child", e.name.Local)
+	}
+
+	resource, hasResource := e.rdfAttr("resource")
+	nodeID, hasNodeID := e.rdfAttr("nodeID")
+	hasPropertyAttrs := false
+	for _, attr := range e.attrs {
+		if !isSyntaxAttr(attr) {
+			hasPropertyAttrs = true
+		}
+	}
+
+	if hasResource || hasNodeID || hasPropertyAttrs {
+		var object Term
+		switch {
+		case hasResource:
+			object = NewIRI(resolveIRI(e.base, resource))
+		case hasNodeID:
+			object = p.blankNode(nodeID)
+		default:
+			object = p.blankNode("")
+		}
+		p.add(subject, predicate, object)
+		p.propertyAttrs(object, e)
+		return nil
+	}
+
+	text := e.text.String()
+	if datatype, ok := e.rdfAttr("datatype"); ok {
+		p.add(subject, predicate, NewLiteral(text, resolveIRI(e.base, datatype)))
+	} else if e.lang != "" {
+		p.add(subject, predicate, NewLangLiteral(text, e.lang))
+	} else {
+		p.add(subject, predicate, NewLiteral(text, ""))
+	}
+	return nil
+}
+
+// WriteRDFXML writes the statements as RDF/XML with one rdf:Description per
+// subject. Graph names are dropped.
+//
+// Parameters:
+//   - w: Destination
+//   - quads: Statements to write
+//   - prefixes: Namespace prefixes for predicates (nil for DefaultRDFPrefixes);
+//     namespaces without a prefix are declared as ns0, ns1, ...
+//
+// Returns:
+//   - error: A predicate that cannot be written as an XML element name, or write errors
+func WriteRDFXML(w io.Writer, quads []Quad, prefixes map[string]string) error {
+	if prefixes == nil {
+		prefixes = DefaultRDFPrefixes
+	}
+	byNamespace := map[string]string{RDFNamespace: "rdf"}
+	for _, prefix := range sortedKeys(prefixes) {
+		if _, ok := byNamespace[prefixes[prefix]]; !ok && prefix != "" && prefix != "xml" {
+			byNamespace[prefixes[prefix]] = prefix
+		}
+	}
+
+	triples := make([]Quad, len(quads))
+	for i, quad := range quads {
+		quad.Graph = Term{}
+		triples[i] = quad
+	}
+	triples = SortQuads(triples)
+
+	used := map[string]string{"rdf": RDFNamespace}
+	generated := 0
+	var body strings.Builder
+	for i := 0; i < len(triples); {
+		subject := triples[i].Subject
+		body.WriteString("  <rdf:Description ")
+		body.WriteString(rdfXMLNodeAttr("about", subject))
+		body.WriteString(">\n")
+		for ; i < len(triples) && triples[i].Subject == subject; i++ {
+			namespace, local, ok := splitQName(triples[i].Predicate.Value)
+			if !ok {
+				return fmt.Errorf("cannot write predicate %s as RDF/XML", triples[i].Predicate)
+			}
+			prefix, ok := byNamespace[namespace]
+			if !ok {
+				for {
+					prefix = "ns" + strconv.Itoa(generated)
+					generated++
+					if _, taken := prefixes[prefix]; !taken {
+						break
+					}
+				}
+				byNamespace[namespace] = prefix
+			}
+			used[prefix] = namespace
+
+			name := prefix + ":" + local
+			object := triples[i].Object
+			switch {
+			case object.IsLiteral():
+				body.WriteString("    <" + name)
+				if object.Language != "" {
+					body.WriteString(` xml:lang="` + xmlEscape(object.Language) + `"`)
+				} else if object.Datatype != "" && object.Datatype != XSDString {
+					body.WriteString(` rdf:datatype="` + xmlEscape(object.Datatype) + `"`)
+				}
+				body.WriteString(">" + xmlEscape(object.Value) + "</" + name + ">\n")
+			default:
+				body.WriteString("    <" + name + " " + rdfXMLNodeAttr("resource", object) + "/>\n")
+			}
+		}
+		body.WriteString("  </rdf:Description>\n")
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	bw.WriteString("<rdf:RDF")
+	for _, prefix := range sortedKeys(used) {
+		fmt.Fprintf(bw, "\n    xmlns:%s=\"%s\"", prefix, xmlEscape(used[prefix]))
+	}
+	bw.WriteString(">\n")
+	bw.WriteString(body.String())
+	bw.WriteString("</rdf:RDF>\n")
+	return bw.Flush()
+}
+
+// rdfXMLNodeAttr returns the attribute referring to an IRI or blank node
+func rdfXMLNodeAttr(iriAttr string, t Term) string {
+	if t.IsBlankNode() {
+		return `rdf:nodeID="` + xmlNodeID(t.Value) + `"`
+	}
+	return "rdf:" + iriAttr + `="` + xmlEscape(t.Value) + `"`
+}
+
+// xmlNodeID turns a blank node label into a valid rdf:nodeID
+func xmlNodeID(label string) string {
+	var b strings.Builder
+	for i, r := range label {
+		switch {
+		case isNCNameStart(r):
+			b.WriteRune(r)
+		case i > 0 && isNCNameChar(r):
+			b.WriteRune(r)
+		case i == 0 && isNCNameChar(r):
+			b.WriteString("b")
+			b.WriteRune(r)
+		default:
+			b.WriteString("_" + strconv.FormatInt(int64(r), 16) + "_")
+		}
+	}
+	if b.Len() == 0 {
+		return "b"
+	}
+	return b.String()
+}
+
+// splitQName splits an IRI into a namespace and the longest local part that
+// is a valid XML name
+func splitQName(iri string) (namespace, local string, ok bool) {
+	start := len(iri)
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(iri[:start])
+		if !isNCNameChar(r) {
+			break
+		}
+		start -= size
+	}
+	for start < len(iri) {
+		r, size := utf8.DecodeRuneInString(iri[start:])
+		if isNCNameStart(r) {
+			break
+		}
+		start += size
+	}
+	if start >= len(iri) || start == 0 {
+		return "", "", false
+	}
+	return iri[:start], iri[start:], true
+}
+
+func isNCNameStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isNCNameChar(r rune) bool {
+	return isNCNameStart(r) || r == '-' || r == '.' || r == 0xB7 || unicode.IsDigit(r) ||
+		unicode.Is(unicode.Mn, r)
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
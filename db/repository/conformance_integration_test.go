@@ -0,0 +1,110 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	evedb "eve.evalgo.org/db"
+	"eve.evalgo.org/db/repository"
+	"eve.evalgo.org/db/repository/repotest"
+)
+
+// startContainer starts a container and returns the endpoint of its exposed port
+func startContainer(t *testing.T, req testcontainers.ContainerRequest, proto string) string {
+	ctx := context.Background()
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	require.NoError(t, err, "Failed to start %s container", req.Image)
+	t.Cleanup(func() {
+		if err := container.Terminate(ctx); err != nil {
+			t.Logf("Failed to terminate container: %v", err)
+		}
+	})
+
+	endpoint, err := container.Endpoint(ctx, proto)
+	require.NoError(t, err)
+	return endpoint
+}
+
+// TestCouchDBRepository_Integration_Conformance tests CouchDBRepository against the conformance suite
+func TestCouchDBRepository_Integration_Conformance(t *testing.T) {
+	url := startContainer(t, testcontainers.ContainerRequest{
+		Image:        "couchdb:3.3",
+		ExposedPorts: []string{"5984/tcp"},
+		Env:          map[string]string{"COUCHDB_USER": "admin", "COUCHDB_PASSWORD": "secret"},
+		WaitingFor:   wait.ForHTTP("/_up").WithPort("5984/tcp").WithStartupTimeout(60 * time.Second),
+	}, "http")
+
+	repo, err := repository.NewCouchDBRepository(url, "admin", "secret")
+	require.NoError(t, err)
+	repotest.TestDocumentRepository(t, repo)
+}
+
+// TestNeo4jRepository_Integration_Conformance tests Neo4jRepository against the conformance suite
+func TestNeo4jRepository_Integration_Conformance(t *testing.T) {
+	url := startContainer(t, testcontainers.ContainerRequest{
+		Image:        "neo4j:5",
+		ExposedPorts: []string{"7687/tcp"},
+		Env:          map[string]string{"NEO4J_AUTH": "neo4j/testpassword"},
+		WaitingFor:   wait.ForLog("Started.").WithStartupTimeout(120 * time.Second),
+	}, "bolt")
+
+	repo, err := repository.NewNeo4jRepository(url, "neo4j", "testpassword")
+	require.NoError(t, err)
+	defer repo.Close()
+	repotest.TestGraphRepository(t, repo)
+}
+
+// TestPostgresMetricsRepository_Integration_Conformance tests PostgresMetricsRepository against the conformance suite
+func TestPostgresMetricsRepository_Integration_Conformance(t *testing.T) {
+	endpoint := startContainer(t, testcontainers.ContainerRequest{
+		Image:        "postgres:16-alpine",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_USER":     "testuser",
+			"POSTGRES_PASSWORD": "testpass",
+			"POSTGRES_DB":       "testdb",
+		},
+		WaitingFor: wait.ForLog("database system is ready to accept connections").
+			WithOccurrence(2).
+			WithStartupTimeout(60 * time.Second),
+	}, "")
+
+	pg, err := evedb.NewPostgresDB("postgresql://testuser:testpass@" + endpoint + "/testdb?sslmode=disable")
+	require.NoError(t, err)
+	defer pg.Close()
+
+	require.NoError(t, pg.Exec(context.Background(), `
+		CREATE TABLE action_runs (
+			run_id TEXT PRIMARY KEY,
+			action_id TEXT NOT NULL,
+			run_data JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL
+		)
+	`))
+	repotest.TestMetricsRepository(t, repository.NewPostgresMetricsRepository(pg))
+}
+
+// TestRedisRepository_Integration_Conformance tests RedisRepository against the conformance suite
+func TestRedisRepository_Integration_Conformance(t *testing.T) {
+	url := startContainer(t, testcontainers.ContainerRequest{
+		Image:        "redis:7-alpine",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("Ready to accept connections").WithStartupTimeout(30 * time.Second),
+	}, "redis")
+
+	repo, err := repository.NewRedisRepository(url)
+	require.NoError(t, err)
+	defer repo.Close()
+	repotest.TestCacheRepository(t, repo)
+}
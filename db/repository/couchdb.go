@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	kivik "github.com/go-kivik/kivik/v4"
 	_ "github.com/go-kivik/kivik/v4/couchdb"
 
+	evedb "eve.evalgo.org/db"
 	"eve.evalgo.org/semantic"
 )
 
@@ -18,6 +20,8 @@ type CouchDBRepository struct {
 	client      *kivik.Client
 	workflowsDB *kivik.DB
 	actionsDB   *kivik.DB
+	instancesDB *kivik.DB
+	templatesDB *kivik.DB
 	ctx         context.Context
 }
 
@@ -63,10 +67,32 @@ func NewCouchDBRepository(url, user, password string) (*CouchDBRepository, error
 		actionsDB = client.DB("when_actions")
 	}
 
+	// Get or create workflow instances database (sub-workflow lineage)
+	instancesDB := client.DB("when_workflow_instances")
+	if err := instancesDB.Err(); err != nil {
+		// Try to create it
+		if err := client.CreateDB(ctx, "when_workflow_instances"); err != nil {
+			return nil, fmt.Errorf("failed to create workflow instances database: %w", err)
+		}
+		instancesDB = client.DB("when_workflow_instances")
+	}
+
+	// Get or create workflow templates database (versioned templates)
+	templatesDB := client.DB("when_workflow_templates")
+	if err := templatesDB.Err(); err != nil {
+		// Try to create it
+		if err := client.CreateDB(ctx, "when_workflow_templates"); err != nil {
+			return nil, fmt.Errorf("failed to create workflow templates database: %w", err)
+		}
+		templatesDB = client.DB("when_workflow_templates")
+	}
+
 	return &CouchDBRepository{
 		client:      client,
 		workflowsDB: workflowsDB,
 		actionsDB:   actionsDB,
+		instancesDB: instancesDB,
+		templatesDB: templatesDB,
 		ctx:         ctx,
 	}, nil
 }
@@ -330,68 +356,203 @@ func (r *CouchDBRepository) BulkSaveActions(ctx context.Context, actions []*sema
 	return err
 }
 
-// WatchChanges watches for document changes (real-time updates)
+// WatchChanges watches for document changes (real-time updates).
+// The feeds reconnect after network errors; the channel closes when ctx is done.
 func (r *CouchDBRepository) WatchChanges(ctx context.Context) (<-chan ChangeEvent, error) {
 	out := make(chan ChangeEvent)
 
-	// Watch both workflows and actions
+	consumers, err := r.changesConsumers("watch", evedb.NewMemoryCheckpointStore(),
+		func(ctx context.Context, events []ChangeEvent) error {
+			for _, event := range events {
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
 	go func() {
 		defer close(out)
+		_ = evedb.RunConsumers(ctx, consumers...)
+	}()
 
-		// Start watching workflows
-		workflowChanges := r.workflowsDB.Changes(ctx,
-			kivik.Param("feed", "continuous"),
-			kivik.Param("include_docs", true),
-		)
+	return out, nil
+}
 
-		for workflowChanges.Next() {
-			var doc map[string]interface{}
-			if err := workflowChanges.ScanDoc(&doc); err != nil {
-				continue
-			}
+// ConsumeChanges delivers workflow and action changes to handler in batches
+// until ctx is done. Each database keeps a checkpoint under name, so a restarted
+// consumer resumes where it stopped; a failing batch is redelivered.
+func (r *CouchDBRepository) ConsumeChanges(ctx context.Context, name string, handler func(ctx context.Context, events []ChangeEvent) error) error {
+	consumers, err := r.changesConsumers(name, nil, handler)
+	if err != nil {
+		return err
+	}
+	return evedb.RunConsumers(ctx, consumers...)
+}
 
-			operation := "updated"
-			if deleted, ok := doc["_deleted"].(bool); ok && deleted {
-				operation = "deleted"
-			}
+// changesConsumers creates consumers for the workflows and actions databases
+func (r *CouchDBRepository) changesConsumers(name string, checkpoints evedb.CheckpointStore, handler func(ctx context.Context, events []ChangeEvent) error) ([]*evedb.ChangesConsumer, error) {
+	sources := []struct {
+		docType  string
+		database *kivik.DB
+	}{
+		{"workflow", r.workflowsDB},
+		{"action", r.actionsDB},
+	}
 
-			out <- ChangeEvent{
-				Type:      "workflow",
-				Operation: operation,
-				ID:        workflowChanges.ID(),
-				Document:  doc,
-			}
+	consumers := make([]*evedb.ChangesConsumer, 0, len(sources))
+	for _, source := range sources {
+		docType := source.docType
+		consumer, err := evedb.NewChangesConsumer(source.database, evedb.ChangesConsumerConfig{
+			Name:        name,
+			IncludeDocs: true,
+			Checkpoints: checkpoints,
+		}, func(ctx context.Context, changes []evedb.Change) error {
+			return handler(ctx, changeEvents(docType, changes))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s changes consumer: %w", docType, err)
 		}
-	}()
+		consumers = append(consumers, consumer)
+	}
+	return consumers, nil
+}
 
-	// Watch actions in separate goroutine
-	go func() {
-		actionChanges := r.actionsDB.Changes(ctx,
-			kivik.Param("feed", "continuous"),
-			kivik.Param("include_docs", true),
-		)
-
-		for actionChanges.Next() {
-			var doc map[string]interface{}
-			if err := actionChanges.ScanDoc(&doc); err != nil {
-				continue
-			}
+// changeEvents converts feed changes to change events
+func changeEvents(docType string, changes []evedb.Change) []ChangeEvent {
+	events := make([]ChangeEvent, 0, len(changes))
+	for _, change := range changes {
+		event := ChangeEvent{
+			Type:      docType,
+			Operation: "updated",
+			ID:        change.ID,
+		}
+		if change.Deleted {
+			event.Operation = "deleted"
+		}
+		if len(change.Doc) > 0 {
+			_ = json.Unmarshal(change.Doc, &event.Document)
+		}
+		events = append(events, event)
+	}
+	return events
+}
 
-			operation := "updated"
-			if deleted, ok := doc["_deleted"].(bool); ok && deleted {
-				operation = "deleted"
-			}
+// Workflow lineage operations
+
+// LinkWorkflowLineage stores the parent/root lineage of a workflow instance
+func (r *CouchDBRepository) LinkWorkflowLineage(ctx context.Context, lineage WorkflowLineage) error {
+	if lineage.InstanceID == "" {
+		return fmt.Errorf("lineage requires an instance ID")
+	}
+	if lineage.RootWorkflowID == "" {
+		lineage.RootWorkflowID = lineage.InstanceID
+	}
+	if lineage.CreatedAt.IsZero() {
+		lineage.CreatedAt = time.Now()
+	}
+
+	doc := map[string]interface{}{
+		"_id":            lineage.InstanceID,
+		"@type":          "WorkflowInstance",
+		"identifier":     lineage.InstanceID,
+		"exampleOfWork":  lineage.TemplateID,
+		"isPartOf":       lineage.ParentWorkflowID,
+		"rootWorkflowID": lineage.RootWorkflowID,
+		"parentActionID": lineage.ActionID,
+		"dateCreated":    lineage.CreatedAt,
+	}
+
+	// Check if document exists to get revision
+	var existing map[string]interface{}
+	if err := r.instancesDB.Get(ctx, lineage.InstanceID).ScanDoc(&existing); err == nil {
+		if rev, ok := existing["_rev"].(string); ok {
+			doc["_rev"] = rev
+		}
+	}
+
+	_, err := r.instancesDB.Put(ctx, lineage.InstanceID, doc)
+	return err
+}
+
+// GetWorkflowLineage returns the lineage of a workflow instance
+func (r *CouchDBRepository) GetWorkflowLineage(ctx context.Context, instanceID string) (*WorkflowLineage, error) {
+	var doc struct {
+		Template string    `json:"exampleOfWork"`
+		Parent   string    `json:"isPartOf"`
+		Root     string    `json:"rootWorkflowID"`
+		ActionID string    `json:"parentActionID"`
+		Created  time.Time `json:"dateCreated"`
+	}
+	if err := r.instancesDB.Get(ctx, instanceID).ScanDoc(&doc); err != nil {
+		return nil, fmt.Errorf("workflow instance not found: %w", err)
+	}
+
+	return &WorkflowLineage{
+		InstanceID:       instanceID,
+		TemplateID:       doc.Template,
+		ParentWorkflowID: doc.Parent,
+		RootWorkflowID:   doc.Root,
+		ActionID:         doc.ActionID,
+		CreatedAt:        doc.Created,
+	}, nil
+}
 
-			out <- ChangeEvent{
-				Type:      "action",
-				Operation: operation,
-				ID:        actionChanges.ID(),
-				Document:  doc,
+// GetChildWorkflows returns the instances directly invoked by a workflow instance
+func (r *CouchDBRepository) GetChildWorkflows(ctx context.Context, instanceID string) ([]string, error) {
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"isPartOf": instanceID,
+		},
+		"fields": []string{"_id"},
+	}
+	rows := r.instancesDB.Find(ctx, query)
+	defer rows.Close()
+
+	var children []string
+	for rows.Next() {
+		var doc struct {
+			ID string `json:"_id"`
+		}
+		if err := rows.ScanDoc(&doc); err != nil {
+			continue
+		}
+		children = append(children, doc.ID)
+	}
+
+	return children, rows.Err()
+}
+
+// GetDescendantWorkflows returns all instances below a workflow instance, parents first
+func (r *CouchDBRepository) GetDescendantWorkflows(ctx context.Context, instanceID string) ([]string, error) {
+	var descendants []string
+	seen := map[string]bool{instanceID: true}
+	queue := []string{instanceID}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		children, err := r.GetChildWorkflows(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			if seen[child] {
+				continue
 			}
+			seen[child] = true
+			descendants = append(descendants, child)
+			queue = append(queue, child)
 		}
-	}()
+	}
 
-	return out, nil
+	return descendants, nil
 }
 
 // Close closes the CouchDB connection
@@ -0,0 +1,177 @@
+package repository_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eve.evalgo.org/db/repository"
+)
+
+// fakeInstancesServer serves the when_workflow_instances documents and the
+// isPartOf Mango query used for lineage lookups
+type fakeInstancesServer struct {
+	mu      sync.Mutex
+	docs    map[string]map[string]interface{}
+	queries []map[string]interface{}
+}
+
+func (f *fakeInstancesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] != "when_workflow_instances" {
+		// Database existence checks and other databases
+		fmt.Fprint(w, `{"ok":true}`)
+		return
+	}
+	id := parts[1]
+
+	switch {
+	case id == "_find" && r.Method == http.MethodPost:
+		var query map[string]interface{}
+		if err := decodeBody(r, &query); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.queries = append(f.queries, query)
+		parent := query["selector"].(map[string]interface{})["isPartOf"]
+		docs := []map[string]interface{}{}
+		for docID, doc := range f.docs {
+			if doc["isPartOf"] == parent {
+				docs = append(docs, map[string]interface{}{"_id": docID})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"docs": docs})
+	case r.Method == http.MethodPut:
+		var doc map[string]interface{}
+		if err := decodeBody(r, &doc); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rev := "1-a"
+		if existing, ok := f.docs[id]; ok {
+			if doc["_rev"] != existing["_rev"] {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `{"error":"conflict","reason":"Document update conflict."}`)
+				return
+			}
+			rev = "2-b"
+		}
+		doc["_rev"] = rev
+		f.docs[id] = doc
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"ok":true,"id":%q,"rev":%q}`, id, rev)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		doc, ok := f.docs[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"not_found","reason":"missing"}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(doc)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeBody decodes a JSON request body, which kivik may gzip
+func decodeBody(r *http.Request, v interface{}) error {
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return err
+		}
+		body = gz
+	}
+	return json.NewDecoder(body).Decode(v)
+}
+
+// TestCouchDBRepository_WorkflowLineage tests lineage storage and the isPartOf child queries
+func TestCouchDBRepository_WorkflowLineage(t *testing.T) {
+	fake := &fakeInstancesServer{docs: make(map[string]map[string]interface{})}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	repo, err := repository.NewCouchDBRepository(server.URL, "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, lineage := range []repository.WorkflowLineage{
+		{InstanceID: "root", TemplateID: "nightly"},
+		{InstanceID: "child-a", TemplateID: "backup", ParentWorkflowID: "root", RootWorkflowID: "root", ActionID: "root--backup"},
+		{InstanceID: "child-b", TemplateID: "report", ParentWorkflowID: "root", RootWorkflowID: "root"},
+		{InstanceID: "grandchild", TemplateID: "upload", ParentWorkflowID: "child-a", RootWorkflowID: "root", CreatedAt: created},
+	} {
+		require.NoError(t, repo.LinkWorkflowLineage(ctx, lineage))
+	}
+
+	t.Run("lineage", func(t *testing.T) {
+		lineage, err := repo.GetWorkflowLineage(ctx, "grandchild")
+		require.NoError(t, err)
+		assert.Equal(t, &repository.WorkflowLineage{
+			InstanceID:       "grandchild",
+			TemplateID:       "upload",
+			ParentWorkflowID: "child-a",
+			RootWorkflowID:   "root",
+			CreatedAt:        created,
+		}, lineage)
+
+		root, err := repo.GetWorkflowLineage(ctx, "root")
+		require.NoError(t, err)
+		assert.Equal(t, "root", root.RootWorkflowID, "root defaults to the instance itself")
+
+		_, err = repo.GetWorkflowLineage(ctx, "missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("relink updates revision", func(t *testing.T) {
+		require.NoError(t, repo.LinkWorkflowLineage(ctx, repository.WorkflowLineage{
+			InstanceID: "child-b", TemplateID: "report-v2", ParentWorkflowID: "root", RootWorkflowID: "root",
+		}))
+		lineage, err := repo.GetWorkflowLineage(ctx, "child-b")
+		require.NoError(t, err)
+		assert.Equal(t, "report-v2", lineage.TemplateID)
+	})
+
+	t.Run("children", func(t *testing.T) {
+		children, err := repo.GetChildWorkflows(ctx, "root")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"child-a", "child-b"}, children)
+
+		last := fake.queries[len(fake.queries)-1]
+		assert.Equal(t, map[string]interface{}{"isPartOf": "root"}, last["selector"])
+		assert.Equal(t, []interface{}{"_id"}, last["fields"])
+
+		children, err = repo.GetChildWorkflows(ctx, "grandchild")
+		require.NoError(t, err)
+		assert.Empty(t, children)
+	})
+
+	t.Run("descendants", func(t *testing.T) {
+		descendants, err := repo.GetDescendantWorkflows(ctx, "root")
+		require.NoError(t, err)
+		require.Len(t, descendants, 3)
+		assert.ElementsMatch(t, []string{"child-a", "child-b"}, descendants[:2])
+		assert.Equal(t, "grandchild", descendants[2])
+	})
+
+	t.Run("requires instance ID", func(t *testing.T) {
+		assert.Error(t, repo.LinkWorkflowLineage(ctx, repository.WorkflowLineage{TemplateID: "x"}))
+	})
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	kivik "github.com/go-kivik/kivik/v4"
+
+	"eve.evalgo.org/semantic"
+)
+
+// maxTemplateSaveAttempts bounds retries when concurrent saves race for a version number
+const maxTemplateSaveAttempts = 5
+
+// Workflow template operations
+
+// SaveTemplateVersion stores template as the next version of its identifier.
+// The assigned version is written to template.Version and returned.
+// Existing versions are never overwritten.
+func (r *CouchDBRepository) SaveTemplateVersion(ctx context.Context, template *semantic.SemanticWorkflowTemplate) (int, error) {
+	if template == nil {
+		return 0, fmt.Errorf("template is nil")
+	}
+	if template.Identifier == "" {
+		return 0, fmt.Errorf("template requires an identifier")
+	}
+	if strings.Contains(template.Identifier, "@") {
+		return 0, fmt.Errorf("template identifier must not contain '@'")
+	}
+	if len(template.Workflow) == 0 {
+		return 0, fmt.Errorf("template %s has no workflow", template.Identifier)
+	}
+
+	for attempt := 0; attempt < maxTemplateSaveAttempts; attempt++ {
+		latest, err := r.latestTemplateVersion(ctx, template.Identifier)
+		if err != nil {
+			return 0, err
+		}
+
+		version := latest + 1
+		doc, err := templateToDoc(template, version)
+		if err != nil {
+			return 0, err
+		}
+
+		// No _rev: CouchDB rejects the write with 409 if the version already exists
+		_, err = r.templatesDB.Put(ctx, semantic.TemplateRef(template.Identifier, version), doc)
+		if err == nil {
+			template.Version = version
+			return version, nil
+		}
+		if kivik.HTTPStatus(err) != http.StatusConflict {
+			return 0, fmt.Errorf("failed to save template %s: %w", template.Identifier, err)
+		}
+	}
+
+	return 0, fmt.Errorf("failed to save template %s: too many concurrent version updates", template.Identifier)
+}
+
+// GetTemplate returns one version of a template (version 0 returns the latest)
+func (r *CouchDBRepository) GetTemplate(ctx context.Context, templateID string, version int) (*semantic.SemanticWorkflowTemplate, error) {
+	if version == 0 {
+		latest, err := r.latestTemplateVersion(ctx, templateID)
+		if err != nil {
+			return nil, err
+		}
+		if latest == 0 {
+			return nil, fmt.Errorf("template not found: %s", templateID)
+		}
+		version = latest
+	}
+
+	var template semantic.SemanticWorkflowTemplate
+	if err := r.templatesDB.Get(ctx, semantic.TemplateRef(templateID, version)).ScanDoc(&template); err != nil {
+		return nil, fmt.Errorf("template not found: %s: %w", semantic.TemplateRef(templateID, version), err)
+	}
+
+	return &template, nil
+}
+
+// ListTemplateVersions returns all versions of a template in ascending order
+func (r *CouchDBRepository) ListTemplateVersions(ctx context.Context, templateID string) ([]*semantic.SemanticWorkflowTemplate, error) {
+	rows := r.templatesDB.AllDocs(ctx,
+		kivik.Param("include_docs", true),
+		kivik.Param("startkey", templateID+"@"),
+		kivik.Param("endkey", templateID+"@\ufff0"),
+	)
+	defer rows.Close()
+
+	var templates []*semantic.SemanticWorkflowTemplate
+	for rows.Next() {
+		var template semantic.SemanticWorkflowTemplate
+		if err := rows.ScanDoc(&template); err != nil {
+			continue
+		}
+		if template.Identifier != templateID {
+			continue
+		}
+		templates = append(templates, &template)
+	}
+
+	// Document IDs sort lexically ("x@10" < "x@2"), so order by version number
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Version < templates[j].Version
+	})
+
+	return templates, rows.Err()
+}
+
+// latestTemplateVersion returns the highest stored version of a template (0 if none)
+func (r *CouchDBRepository) latestTemplateVersion(ctx context.Context, templateID string) (int, error) {
+	rows := r.templatesDB.AllDocs(ctx,
+		kivik.Param("startkey", templateID+"@"),
+		kivik.Param("endkey", templateID+"@\ufff0"),
+	)
+	defer rows.Close()
+
+	latest := 0
+	for rows.Next() {
+		id, _ := rows.ID()
+		name, version, err := semantic.ParseTemplateRef(id)
+		if err != nil || name != templateID {
+			continue
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+
+	return latest, rows.Err()
+}
+
+// templateToDoc converts a template version into a CouchDB document
+func templateToDoc(template *semantic.SemanticWorkflowTemplate, version int) (map[string]interface{}, error) {
+	stored := *template
+	stored.Version = version
+	if stored.Type == "" {
+		stored.Type = semantic.WorkflowTemplateType
+	}
+	if stored.Context == "" {
+		stored.Context = "https://schema.org"
+	}
+	if stored.Created.IsZero() {
+		stored.Created = time.Now()
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	doc["_id"] = semantic.TemplateRef(template.Identifier, version)
+
+	return doc, nil
+}
@@ -112,6 +112,37 @@ type GraphRepository interface {
 	DeleteWorkflowGraph(ctx context.Context, workflowID string) error
 }
 
+// WorkflowTemplateRepository stores immutable, versioned workflow templates.
+//
+// Implementation: CouchDB (one document per version, ID "identifier@version")
+//
+// Versioning:
+//   - SaveTemplateVersion assigns the next version number; stored versions are never modified
+//   - Version 0 in lookups means "latest"
+//   - Rollback creates a new version with the content of an older one
+type WorkflowTemplateRepository interface {
+	SaveTemplateVersion(ctx context.Context, template *semantic.SemanticWorkflowTemplate) (int, error)
+	GetTemplate(ctx context.Context, templateID string, version int) (*semantic.SemanticWorkflowTemplate, error)
+	ListTemplateVersions(ctx context.Context, templateID string) ([]*semantic.SemanticWorkflowTemplate, error)
+}
+
+// WorkflowLineageRepository records parent/child relationships between workflow
+// instances created by sub-workflow invocation.
+//
+// Implementations: CouchDBRepository (instance documents), Neo4jRepository
+// (CHILD_OF relationships between Workflow nodes)
+//
+// Lineage Model:
+//   - Every instance knows its direct parent and the root of its invocation tree
+//   - Top-level instances have an empty parent and are their own root
+//   - Descendants are used to propagate cancellation from parent to children
+type WorkflowLineageRepository interface {
+	LinkWorkflowLineage(ctx context.Context, lineage WorkflowLineage) error
+	GetWorkflowLineage(ctx context.Context, instanceID string) (*WorkflowLineage, error)
+	GetChildWorkflows(ctx context.Context, instanceID string) ([]string, error)      // Direct children
+	GetDescendantWorkflows(ctx context.Context, instanceID string) ([]string, error) // Transitive closure
+}
+
 // MetricsRepository manages time-series execution data in PostgreSQL.
 // This interface provides operations for recording action executions,
 // querying historical data, and computing aggregate metrics.
@@ -219,6 +250,16 @@ type DataPoint struct {
 	Value     float64   // Metric value
 }
 
+// WorkflowLineage links a workflow instance to the instance that invoked it.
+type WorkflowLineage struct {
+	InstanceID       string    // Workflow instance ID
+	TemplateID       string    // Workflow template the instance was expanded from
+	ParentWorkflowID string    // Invoking instance (empty for top-level runs)
+	RootWorkflowID   string    // Top-level instance of the invocation tree
+	ActionID         string    // Sub-workflow step in the parent that created the instance
+	CreatedAt        time.Time // When the link was recorded
+}
+
 // ChangeEvent represents a document change notification from CouchDB.
 type ChangeEvent struct {
 	Type      string                 // "workflow" or "action"
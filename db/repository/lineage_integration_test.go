@@ -0,0 +1,85 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"eve.evalgo.org/db/repository"
+)
+
+// testWorkflowLineage runs the lineage queries against a WorkflowLineageRepository
+func testWorkflowLineage(t *testing.T, repo repository.WorkflowLineageRepository) {
+	ctx := context.Background()
+	for _, lineage := range []repository.WorkflowLineage{
+		{InstanceID: "root", TemplateID: "nightly"},
+		{InstanceID: "child-a", TemplateID: "backup", ParentWorkflowID: "root", RootWorkflowID: "root", ActionID: "root--backup"},
+		{InstanceID: "child-b", TemplateID: "report", ParentWorkflowID: "root", RootWorkflowID: "root"},
+		{InstanceID: "grandchild", TemplateID: "upload", ParentWorkflowID: "child-a", RootWorkflowID: "root"},
+	} {
+		require.NoError(t, repo.LinkWorkflowLineage(ctx, lineage))
+	}
+
+	lineage, err := repo.GetWorkflowLineage(ctx, "child-a")
+	require.NoError(t, err)
+	assert.Equal(t, "backup", lineage.TemplateID)
+	assert.Equal(t, "root", lineage.ParentWorkflowID)
+	assert.Equal(t, "root", lineage.RootWorkflowID)
+	assert.Equal(t, "root--backup", lineage.ActionID)
+
+	root, err := repo.GetWorkflowLineage(ctx, "root")
+	require.NoError(t, err)
+	assert.Empty(t, root.ParentWorkflowID)
+	assert.Equal(t, "root", root.RootWorkflowID)
+
+	children, err := repo.GetChildWorkflows(ctx, "root")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"child-a", "child-b"}, children)
+
+	children, err = repo.GetChildWorkflows(ctx, "grandchild")
+	require.NoError(t, err)
+	assert.Empty(t, children)
+
+	descendants, err := repo.GetDescendantWorkflows(ctx, "root")
+	require.NoError(t, err)
+	require.Len(t, descendants, 3)
+	assert.ElementsMatch(t, []string{"child-a", "child-b"}, descendants[:2])
+	assert.Equal(t, "grandchild", descendants[2])
+}
+
+// TestCouchDBRepository_Integration_WorkflowLineage tests the CouchDB lineage queries
+func TestCouchDBRepository_Integration_WorkflowLineage(t *testing.T) {
+	url := startContainer(t, testcontainers.ContainerRequest{
+		Image:        "couchdb:3.3",
+		ExposedPorts: []string{"5984/tcp"},
+		Env:          map[string]string{"COUCHDB_USER": "admin", "COUCHDB_PASSWORD": "secret"},
+		WaitingFor:   wait.ForHTTP("/_up").WithPort("5984/tcp").WithStartupTimeout(60 * time.Second),
+	}, "http")
+
+	repo, err := repository.NewCouchDBRepository(url, "admin", "secret")
+	require.NoError(t, err)
+	defer repo.Close()
+	testWorkflowLineage(t, repo)
+}
+
+// TestNeo4jRepository_Integration_WorkflowLineage tests the Neo4j CHILD_OF lineage queries
+func TestNeo4jRepository_Integration_WorkflowLineage(t *testing.T) {
+	url := startContainer(t, testcontainers.ContainerRequest{
+		Image:        "neo4j:5",
+		ExposedPorts: []string{"7687/tcp"},
+		Env:          map[string]string{"NEO4J_AUTH": "neo4j/testpassword"},
+		WaitingFor:   wait.ForLog("Started.").WithStartupTimeout(120 * time.Second),
+	}, "bolt")
+
+	repo, err := repository.NewNeo4jRepository(url, "neo4j", "testpassword")
+	require.NoError(t, err)
+	defer repo.Close()
+	testWorkflowLineage(t, repo)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// NewMemoryCompositeRepository creates a composite repository backed entirely
+// by in-memory implementations. It needs no external services and is intended
+// for unit tests and single-node deployments; all data is lost on exit.
+//
+// Usage:
+//
+//	repo := repository.NewMemoryCompositeRepository()
+//	repo.SaveAction(ctx, action, workflowID)
+//	deps, _ := repo.Graph.GetAllDependencies(ctx, action.Identifier)
+func NewMemoryCompositeRepository() *CompositeRepository {
+	return &CompositeRepository{
+		Documents: NewMemoryDocumentRepository(),
+		Graph:     NewMemoryGraphRepository(),
+		Metrics:   NewMemoryMetricsRepository(),
+		Cache:     NewMemoryCacheRepository(),
+	}
+}
+
+// memorySubscription delivers values to a subscriber in order without ever
+// blocking the sender. Values are queued until the subscriber reads them; the
+// output channel closes when ctx is done.
+type memorySubscription[T any] struct {
+	mu    sync.Mutex
+	queue []T
+	wake  chan struct{}
+	out   chan T
+}
+
+// newMemorySubscription starts a subscription; onClose runs after ctx is done
+// so the owner can stop sending to it
+func newMemorySubscription[T any](ctx context.Context, onClose func()) *memorySubscription[T] {
+	s := &memorySubscription[T]{
+		wake: make(chan struct{}, 1),
+		out:  make(chan T),
+	}
+	go s.run(ctx, onClose)
+	return s
+}
+
+// send queues a value for delivery
+func (s *memorySubscription[T]) send(value T) {
+	s.mu.Lock()
+	s.queue = append(s.queue, value)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run forwards queued values to the output channel until ctx is done
+func (s *memorySubscription[T]) run(ctx context.Context, onClose func()) {
+	defer close(s.out)
+	defer onClose()
+
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		value := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.out <- value:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// jsonCopy deep-copies src into dst through JSON, so stored values behave
+// like values read back from a database (numbers become float64, unexported
+// fields are dropped) and callers cannot modify them afterwards
+func jsonCopy(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
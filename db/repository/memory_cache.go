@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryCacheRepository implements CacheRepository in memory with the
+// semantics of RedisRepository: locks and cache entries expire after their
+// TTL (zero means never), values and messages are JSON encoded, and
+// subscribers only receive messages published after they subscribed.
+// It is safe for concurrent use within a single process.
+type MemoryCacheRepository struct {
+	mu          sync.Mutex
+	locks       map[string]time.Time // key -> expiry (zero for none)
+	entries     map[string]memoryCacheEntry
+	counters    map[string]int64
+	subscribers map[string]map[*memorySubscription[interface{}]]struct{}
+	now         func() time.Time
+	lastSweep   time.Time
+}
+
+// memoryCacheEntry is a JSON encoded cache value
+type memoryCacheEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// NewMemoryCacheRepository creates an empty in-memory cache repository
+func NewMemoryCacheRepository() *MemoryCacheRepository {
+	return &MemoryCacheRepository{
+		locks:       make(map[string]time.Time),
+		entries:     make(map[string]memoryCacheEntry),
+		counters:    make(map[string]int64),
+		subscribers: make(map[string]map[*memorySubscription[interface{}]]struct{}),
+		now:         time.Now,
+	}
+}
+
+// Lock operations
+
+func (r *MemoryCacheRepository) AcquireLock(ctx context.Context, actionID string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isLockHeld(actionID) {
+		return false, nil
+	}
+	r.sweep()
+	r.locks[actionID] = r.expiry(ttl)
+	return true, nil
+}
+
+func (r *MemoryCacheRepository) ReleaseLock(ctx context.Context, actionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.locks, actionID)
+	return nil
+}
+
+func (r *MemoryCacheRepository) IsLocked(ctx context.Context, actionID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.isLockHeld(actionID), nil
+}
+
+// Cache operations
+
+func (r *MemoryCacheRepository) SetCache(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep()
+	r.entries[key] = memoryCacheEntry{data: data, expires: r.expiry(ttl)}
+	return nil
+}
+
+func (r *MemoryCacheRepository) GetCache(ctx context.Context, key string, value interface{}) error {
+	r.mu.Lock()
+	entry, ok := r.entries[key]
+	if ok && r.expired(entry.expires) {
+		delete(r.entries, key)
+		ok = false
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("cache miss: key not found")
+	}
+	return json.Unmarshal(entry.data, value)
+}
+
+func (r *MemoryCacheRepository) DeleteCache(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.entries, key)
+	return nil
+}
+
+// Pub/Sub operations
+
+func (r *MemoryCacheRepository) Publish(ctx context.Context, channel string, message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for sub := range r.subscribers[channel] {
+		var decoded interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return err
+		}
+		sub.send(decoded)
+	}
+	return nil
+}
+
+// Subscribe receives messages published to channel after the call. Messages
+// are queued, so a slow reader never blocks publishers; the channel closes
+// when ctx is done.
+func (r *MemoryCacheRepository) Subscribe(ctx context.Context, channel string) (<-chan interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sub *memorySubscription[interface{}]
+	sub = newMemorySubscription[interface{}](ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subscribers[channel], sub)
+		if len(r.subscribers[channel]) == 0 {
+			delete(r.subscribers, channel)
+		}
+	})
+	if r.subscribers[channel] == nil {
+		r.subscribers[channel] = make(map[*memorySubscription[interface{}]]struct{})
+	}
+	r.subscribers[channel][sub] = struct{}{}
+	return sub.out, nil
+}
+
+// Counter operations
+
+func (r *MemoryCacheRepository) Increment(ctx context.Context, key string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counters[key]++
+	return r.counters[key], nil
+}
+
+func (r *MemoryCacheRepository) Decrement(ctx context.Context, key string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counters[key]--
+	return r.counters[key], nil
+}
+
+// isLockHeld reports whether a lock is held, dropping it if it expired.
+// The caller must hold r.mu.
+func (r *MemoryCacheRepository) isLockHeld(actionID string) bool {
+	expires, ok := r.locks[actionID]
+	if ok && r.expired(expires) {
+		delete(r.locks, actionID)
+		return false
+	}
+	return ok
+}
+
+// sweep drops expired locks and cache entries that were never read again.
+// It runs at most once a minute; the caller must hold r.mu.
+func (r *MemoryCacheRepository) sweep() {
+	now := r.now()
+	if now.Sub(r.lastSweep) < time.Minute {
+		return
+	}
+	r.lastSweep = now
+
+	for key, expires := range r.locks {
+		if r.expired(expires) {
+			delete(r.locks, key)
+		}
+	}
+	for key, entry := range r.entries {
+		if r.expired(entry.expires) {
+			delete(r.entries, key)
+		}
+	}
+}
+
+// expiry returns the expiry time for a TTL, or zero for no expiry
+func (r *MemoryCacheRepository) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return r.now().Add(ttl)
+}
+
+// expired reports whether an expiry time has passed
+func (r *MemoryCacheRepository) expired(expires time.Time) bool {
+	return !expires.IsZero() && !r.now().Before(expires)
+}
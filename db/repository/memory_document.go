@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"eve.evalgo.org/semantic"
+)
+
+// MemoryDocumentRepository implements DocumentRepository in memory.
+// Documents are stored the way CouchDBRepository stores them (with _id, _rev
+// and the partOf workflow reference) and are copied on every read and write.
+// It is safe for concurrent use.
+type MemoryDocumentRepository struct {
+	mu        sync.RWMutex
+	workflows map[string][]byte
+	actions   map[string][]byte
+	watchers  map[*memorySubscription[ChangeEvent]]struct{}
+}
+
+// NewMemoryDocumentRepository creates an empty in-memory document repository
+func NewMemoryDocumentRepository() *MemoryDocumentRepository {
+	return &MemoryDocumentRepository{
+		workflows: make(map[string][]byte),
+		actions:   make(map[string][]byte),
+		watchers:  make(map[*memorySubscription[ChangeEvent]]struct{}),
+	}
+}
+
+// Workflow operations
+
+func (r *MemoryDocumentRepository) SaveWorkflow(ctx context.Context, workflowID string, workflow map[string]interface{}) error {
+	var doc map[string]interface{}
+	if err := jsonCopy(workflow, &doc); err != nil {
+		return fmt.Errorf("failed to encode workflow: %w", err)
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	return r.put("workflow", r.workflows, workflowID, doc)
+}
+
+func (r *MemoryDocumentRepository) GetWorkflow(ctx context.Context, workflowID string) (map[string]interface{}, error) {
+	var workflow map[string]interface{}
+	if err := r.get(r.workflows, workflowID, &workflow); err != nil {
+		return nil, fmt.Errorf("workflow not found: %w", err)
+	}
+	return workflow, nil
+}
+
+func (r *MemoryDocumentRepository) ListWorkflows(ctx context.Context) ([]map[string]interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var workflows []map[string]interface{}
+	for _, id := range sortedKeys(r.workflows) {
+		var workflow map[string]interface{}
+		if err := json.Unmarshal(r.workflows[id], &workflow); err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, workflow)
+	}
+	return workflows, nil
+}
+
+func (r *MemoryDocumentRepository) DeleteWorkflow(ctx context.Context, workflowID string) error {
+	return r.delete("workflow", r.workflows, workflowID)
+}
+
+// Action operations
+
+func (r *MemoryDocumentRepository) SaveAction(ctx context.Context, actionID string, action *semantic.SemanticScheduledAction, workflowID string) error {
+	var doc map[string]interface{}
+	if err := jsonCopy(action, &doc); err != nil {
+		return fmt.Errorf("failed to encode action: %w", err)
+	}
+	if workflowID != "" {
+		doc["partOf"] = workflowID
+	}
+	return r.put("action", r.actions, actionID, doc)
+}
+
+func (r *MemoryDocumentRepository) GetAction(ctx context.Context, actionID string) (*semantic.SemanticScheduledAction, error) {
+	var action semantic.SemanticScheduledAction
+	if err := r.get(r.actions, actionID, &action); err != nil {
+		return nil, fmt.Errorf("action not found: %w", err)
+	}
+	return &action, nil
+}
+
+func (r *MemoryDocumentRepository) ListActions(ctx context.Context, workflowID string) ([]*semantic.SemanticScheduledAction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var actions []*semantic.SemanticScheduledAction
+	for _, id := range sortedKeys(r.actions) {
+		data := r.actions[id]
+		if workflowID != "" {
+			var ref struct {
+				PartOf string `json:"partOf"`
+			}
+			if err := json.Unmarshal(data, &ref); err != nil || ref.PartOf != workflowID {
+				continue
+			}
+		}
+
+		var action semantic.SemanticScheduledAction
+		if err := json.Unmarshal(data, &action); err != nil {
+			return nil, err
+		}
+		actions = append(actions, &action)
+	}
+	return actions, nil
+}
+
+func (r *MemoryDocumentRepository) DeleteAction(ctx context.Context, actionID string) error {
+	return r.delete("action", r.actions, actionID)
+}
+
+// Bulk operations
+
+// BulkSaveActions saves actions under their identifiers. Like the CouchDB
+// bulk API, existing documents are replaced and workflow references dropped.
+func (r *MemoryDocumentRepository) BulkSaveActions(ctx context.Context, actions []*semantic.SemanticScheduledAction) error {
+	for _, action := range actions {
+		if action.Identifier == "" {
+			return fmt.Errorf("action has no identifier")
+		}
+	}
+	for _, action := range actions {
+		var doc map[string]interface{}
+		if err := jsonCopy(action, &doc); err != nil {
+			return fmt.Errorf("failed to encode action %s: %w", action.Identifier, err)
+		}
+		if err := r.put("action", r.actions, action.Identifier, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchChanges streams changes made after the call. Events are queued, so a
+// slow reader never blocks writers; the channel closes when ctx is done.
+func (r *MemoryDocumentRepository) WatchChanges(ctx context.Context) (<-chan ChangeEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sub *memorySubscription[ChangeEvent]
+	sub = newMemorySubscription[ChangeEvent](ctx, func() {
+		r.mu.Lock()
+		delete(r.watchers, sub)
+		r.mu.Unlock()
+	})
+	r.watchers[sub] = struct{}{}
+	return sub.out, nil
+}
+
+// put stores a document with a new revision and notifies watchers
+func (r *MemoryDocumentRepository) put(docType string, docs map[string][]byte, id string, doc map[string]interface{}) error {
+	if id == "" {
+		return fmt.Errorf("%s ID is required", docType)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	generation := 1
+	if existing, ok := docs[id]; ok {
+		generation = revisionGeneration(existing) + 1
+	}
+
+	delete(doc, "_rev")
+	doc["_id"] = id
+	content, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	doc["_rev"] = fmt.Sprintf("%d-%x", generation, md5.Sum(content))
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	docs[id] = data
+	r.notify(ChangeEvent{Type: docType, Operation: "updated", ID: id}, data)
+	return nil
+}
+
+// get decodes a stored document into value
+func (r *MemoryDocumentRepository) get(docs map[string][]byte, id string, value interface{}) error {
+	r.mu.RLock()
+	data, ok := docs[id]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("missing document %q", id)
+	}
+	return json.Unmarshal(data, value)
+}
+
+// delete removes a document and notifies watchers
+func (r *MemoryDocumentRepository) delete(docType string, docs map[string][]byte, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, ok := docs[id]
+	if !ok {
+		return fmt.Errorf("%s not found: missing document %q", docType, id)
+	}
+	tombstone, err := json.Marshal(map[string]interface{}{
+		"_id":      id,
+		"_rev":     fmt.Sprintf("%d-deleted", revisionGeneration(data)+1),
+		"_deleted": true,
+	})
+	if err != nil {
+		return err
+	}
+	delete(docs, id)
+	r.notify(ChangeEvent{Type: docType, Operation: "deleted", ID: id}, tombstone)
+	return nil
+}
+
+// notify sends an event with its own copy of the document to every watcher.
+// It is called with the write lock held so events arrive in commit order.
+func (r *MemoryDocumentRepository) notify(event ChangeEvent, data []byte) {
+	for sub := range r.watchers {
+		event := event
+		_ = json.Unmarshal(data, &event.Document)
+		sub.send(event)
+	}
+}
+
+// revisionGeneration returns the numeric prefix of a stored document's _rev
+func revisionGeneration(data []byte) int {
+	var doc struct {
+		Rev string `json:"_rev"`
+	}
+	_ = json.Unmarshal(data, &doc)
+	generation, _ := strconv.Atoi(strings.SplitN(doc.Rev, "-", 2)[0])
+	return generation
+}
+
+// sortedKeys returns document IDs in CouchDB _all_docs order
+func sortedKeys(docs map[string][]byte) []string {
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"eve.evalgo.org/semantic"
+)
+
+// MemoryGraphRepository implements GraphRepository in memory with adjacency
+// sets. It follows Neo4jRepository: storing an action merges its REQUIRES
+// edges into the graph and deleting a node removes all of its edges.
+// It is safe for concurrent use.
+type MemoryGraphRepository struct {
+	mu         sync.RWMutex
+	requires   map[string]map[string]bool // action -> dependencies
+	requiredBy map[string]map[string]bool // dependency -> dependents
+	partOf     map[string]map[string]bool // workflow -> actions
+}
+
+// NewMemoryGraphRepository creates an empty in-memory graph repository
+func NewMemoryGraphRepository() *MemoryGraphRepository {
+	return &MemoryGraphRepository{
+		requires:   make(map[string]map[string]bool),
+		requiredBy: make(map[string]map[string]bool),
+		partOf:     make(map[string]map[string]bool),
+	}
+}
+
+// StoreActionGraph stores an action and its dependencies in the graph
+func (r *MemoryGraphRepository) StoreActionGraph(ctx context.Context, action *semantic.SemanticScheduledAction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, depID := range action.Requires {
+		addEdge(r.requires, action.Identifier, depID)
+		addEdge(r.requiredBy, depID, action.Identifier)
+	}
+	return nil
+}
+
+// GetDependencies gets direct dependencies (immediate requires)
+func (r *MemoryGraphRepository) GetDependencies(ctx context.Context, actionID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedSet(r.requires[actionID]), nil
+}
+
+// GetAllDependencies gets all transitive dependencies. The action itself is
+// included only if it depends on itself through a cycle.
+func (r *MemoryGraphRepository) GetAllDependencies(ctx context.Context, actionID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	queue := sortedSet(r.requires[actionID])
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		queue = append(queue, sortedSet(r.requires[id])...)
+	}
+	return sortedSet(seen), nil
+}
+
+// GetDependents gets actions that directly depend on this action
+func (r *MemoryGraphRepository) GetDependents(ctx context.Context, actionID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedSet(r.requiredBy[actionID]), nil
+}
+
+// WouldCreateCycle reports whether adding actionID -> dependencyID would
+// create a cycle, i.e. whether dependencyID already requires actionID
+func (r *MemoryGraphRepository) WouldCreateCycle(ctx context.Context, actionID, dependencyID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.shortestPath(dependencyID, actionID)) > 0, nil
+}
+
+// FindPath finds the shortest dependency path between two actions, including
+// both ends. It returns an empty slice if there is no path.
+func (r *MemoryGraphRepository) FindPath(ctx context.Context, fromAction, toAction string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.shortestPath(fromAction, toAction), nil
+}
+
+// GetWorkflowActions gets all actions in a workflow
+func (r *MemoryGraphRepository) GetWorkflowActions(ctx context.Context, workflowID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedSet(r.partOf[workflowID]), nil
+}
+
+// LinkActionToWorkflow creates a PART_OF relationship
+func (r *MemoryGraphRepository) LinkActionToWorkflow(ctx context.Context, actionID, workflowID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	addEdge(r.partOf, workflowID, actionID)
+	return nil
+}
+
+// DeleteActionGraph deletes an action node and all its relationships
+func (r *MemoryGraphRepository) DeleteActionGraph(ctx context.Context, actionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for depID := range r.requires[actionID] {
+		removeEdge(r.requiredBy, depID, actionID)
+	}
+	for dependentID := range r.requiredBy[actionID] {
+		removeEdge(r.requires, dependentID, actionID)
+	}
+	for workflowID := range r.partOf {
+		removeEdge(r.partOf, workflowID, actionID)
+	}
+	delete(r.requires, actionID)
+	delete(r.requiredBy, actionID)
+	return nil
+}
+
+// DeleteWorkflowGraph deletes a workflow node and its PART_OF relationships;
+// the actions themselves are kept
+func (r *MemoryGraphRepository) DeleteWorkflowGraph(ctx context.Context, workflowID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.partOf, workflowID)
+	return nil
+}
+
+// shortestPath returns the node IDs of the shortest path of at least one
+// REQUIRES edge from one action to another, or an empty slice if none exists
+func (r *MemoryGraphRepository) shortestPath(from, to string) []string {
+	previous := make(map[string]string)
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range sortedSet(r.requires[id]) {
+			if _, seen := previous[next]; seen {
+				continue
+			}
+			previous[next] = id
+			if next == to {
+				path := []string{to}
+				for node := id; node != from; node = previous[node] {
+					path = append(path, node)
+				}
+				path = append(path, from)
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			queue = append(queue, next)
+		}
+	}
+	return []string{}
+}
+
+// addEdge adds to to the adjacency set of from
+func addEdge(edges map[string]map[string]bool, from, to string) {
+	if edges[from] == nil {
+		edges[from] = make(map[string]bool)
+	}
+	edges[from][to] = true
+}
+
+// removeEdge removes to from the adjacency set of from
+func removeEdge(edges map[string]map[string]bool, from, to string) {
+	delete(edges[from], to)
+	if len(edges[from]) == 0 {
+		delete(edges, from)
+	}
+}
+
+// sortedSet returns the members of a set in sorted order, or nil if it is empty
+func sortedSet(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
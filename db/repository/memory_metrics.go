@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryMetricsRepository implements MetricsRepository in memory.
+// Runs are keyed by their StartTime like the created_at column of
+// PostgresMetricsRepository. It is safe for concurrent use.
+type MemoryMetricsRepository struct {
+	mu   sync.RWMutex
+	runs map[string][]*ActionRun // action -> runs, ordered by StartTime
+}
+
+// NewMemoryMetricsRepository creates an empty in-memory metrics repository
+func NewMemoryMetricsRepository() *MemoryMetricsRepository {
+	return &MemoryMetricsRepository{
+		runs: make(map[string][]*ActionRun),
+	}
+}
+
+// SaveRun saves an action execution result
+func (r *MemoryMetricsRepository) SaveRun(ctx context.Context, run *ActionRun) error {
+	stored := *run
+	stored.Result = nil
+	if run.Result != nil {
+		if err := jsonCopy(run.Result, &stored.Result); err != nil {
+			return fmt.Errorf("failed to marshal run data: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := r.runs[run.ActionID]
+	i := sort.Search(len(runs), func(i int) bool { return runs[i].StartTime.After(stored.StartTime) })
+	runs = append(runs, nil)
+	copy(runs[i+1:], runs[i:])
+	runs[i] = &stored
+	r.runs[run.ActionID] = runs
+	return nil
+}
+
+// GetRunHistory retrieves execution history for an action, newest first.
+// A limit of zero or less returns all runs.
+func (r *MemoryMetricsRepository) GetRunHistory(ctx context.Context, actionID string, limit int) ([]*ActionRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	runs := r.runs[actionID]
+	if limit <= 0 || limit > len(runs) {
+		limit = len(runs)
+	}
+
+	var history []*ActionRun
+	for i := len(runs) - 1; i >= len(runs)-limit; i-- {
+		run := *runs[i]
+		if runs[i].Result != nil {
+			if err := jsonCopy(runs[i].Result, &run.Result); err != nil {
+				return nil, err
+			}
+		}
+		history = append(history, &run)
+	}
+	return history, nil
+}
+
+// GetMetrics retrieves metrics for an action over a time window (inclusive)
+func (r *MemoryMetricsRepository) GetMetrics(ctx context.Context, actionID string, from, to time.Time) (*ActionMetrics, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	metrics := &ActionMetrics{ActionID: actionID}
+	var total time.Duration
+	for _, run := range r.runs[actionID] {
+		if run.StartTime.Before(from) || run.StartTime.After(to) {
+			continue
+		}
+
+		metrics.TotalRuns++
+		switch run.Status {
+		case "CompletedActionStatus":
+			metrics.SuccessfulRuns++
+		case "FailedActionStatus":
+			metrics.FailedRuns++
+		}
+
+		total += run.Duration
+		if metrics.TotalRuns == 1 || run.Duration < metrics.MinDuration {
+			metrics.MinDuration = run.Duration
+		}
+		if run.Duration > metrics.MaxDuration {
+			metrics.MaxDuration = run.Duration
+		}
+		metrics.LastRun = run.StartTime
+	}
+	if metrics.TotalRuns > 0 {
+		metrics.AvgDuration = total / time.Duration(metrics.TotalRuns)
+	}
+
+	return metrics, nil
+}
+
+// GetAggregatedMetrics aggregates run durations in milliseconds over time
+// buckets of the given window (one hour if zero), newest bucket first.
+// Supported aggregations are "avg" (default), "sum", "min", "max" and "count".
+// At most 100 buckets are returned.
+func (r *MemoryMetricsRepository) GetAggregatedMetrics(ctx context.Context, actionID string, window time.Duration, aggregation string) ([]DataPoint, error) {
+	if window <= 0 {
+		window = time.Hour
+	}
+
+	switch aggregation {
+	case "", "avg", "sum", "min", "max", "count":
+	default:
+		return nil, fmt.Errorf("unsupported aggregation: %s", aggregation)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var dataPoints []DataPoint
+	runs := r.runs[actionID]
+	for end := len(runs); end > 0 && len(dataPoints) < 100; {
+		bucket := runs[end-1].StartTime.Truncate(window)
+		start := end - 1
+		for start > 0 && !runs[start-1].StartTime.Before(bucket) {
+			start--
+		}
+
+		values := make([]float64, 0, end-start)
+		for _, run := range runs[start:end] {
+			values = append(values, float64(run.Duration.Milliseconds()))
+		}
+		dataPoints = append(dataPoints, DataPoint{Timestamp: bucket, Value: aggregate(values, aggregation)})
+		end = start
+	}
+
+	return dataPoints, nil
+}
+
+// DeleteOldRuns deletes runs that started before the specified time
+func (r *MemoryMetricsRepository) DeleteOldRuns(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for actionID, runs := range r.runs {
+		i := sort.Search(len(runs), func(i int) bool { return !runs[i].StartTime.Before(before) })
+		deleted += int64(i)
+		if i == len(runs) {
+			delete(r.runs, actionID)
+		} else {
+			r.runs[actionID] = append([]*ActionRun(nil), runs[i:]...)
+		}
+	}
+
+	return deleted, nil
+}
+
+// aggregate applies an aggregation function to a non-empty set of values
+func aggregate(values []float64, aggregation string) float64 {
+	result := values[0]
+	switch aggregation {
+	case "count":
+		return float64(len(values))
+	case "min":
+		for _, v := range values[1:] {
+			if v < result {
+				result = v
+			}
+		}
+	case "max":
+		for _, v := range values[1:] {
+			if v > result {
+				result = v
+			}
+		}
+	default:
+		for _, v := range values[1:] {
+			result += v
+		}
+		if aggregation != "sum" {
+			result /= float64(len(values))
+		}
+	}
+	return result
+}
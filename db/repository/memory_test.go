@@ -0,0 +1,117 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eve.evalgo.org/db/repository"
+	"eve.evalgo.org/db/repository/repotest"
+	"eve.evalgo.org/semantic"
+)
+
+// TestMemoryDocumentRepository tests the in-memory document repository against the conformance suite
+func TestMemoryDocumentRepository(t *testing.T) {
+	repotest.TestDocumentRepository(t, repository.NewMemoryDocumentRepository())
+}
+
+// TestMemoryGraphRepository tests the in-memory graph repository against the conformance suite
+func TestMemoryGraphRepository(t *testing.T) {
+	repotest.TestGraphRepository(t, repository.NewMemoryGraphRepository())
+}
+
+// TestMemoryMetricsRepository tests the in-memory metrics repository against the conformance suite
+func TestMemoryMetricsRepository(t *testing.T) {
+	repotest.TestMetricsRepository(t, repository.NewMemoryMetricsRepository())
+}
+
+// TestMemoryCacheRepository tests the in-memory cache repository against the conformance suite
+func TestMemoryCacheRepository(t *testing.T) {
+	repotest.TestCacheRepository(t, repository.NewMemoryCacheRepository())
+}
+
+// TestMemoryGraphCycles tests cyclic graphs, which the conformance suite avoids
+func TestMemoryGraphCycles(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryGraphRepository()
+	require.NoError(t, repo.StoreActionGraph(ctx, &semantic.SemanticScheduledAction{
+		SemanticAction: semantic.SemanticAction{Identifier: "a"}, Requires: []string{"b"},
+	}))
+	require.NoError(t, repo.StoreActionGraph(ctx, &semantic.SemanticScheduledAction{
+		SemanticAction: semantic.SemanticAction{Identifier: "b"}, Requires: []string{"a", "c"},
+	}))
+
+	deps, err := repo.GetAllDependencies(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, deps)
+
+	path, err := repo.FindPath(ctx, "a", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "a"}, path)
+
+	path, err = repo.FindPath(ctx, "a", "c")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, path)
+}
+
+// TestMemoryMetricsAggregations tests aggregations and deletion counts only the in-memory backend supports
+func TestMemoryMetricsAggregations(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryMetricsRepository()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, duration := range []time.Duration{100, 300, 200} {
+		require.NoError(t, repo.SaveRun(ctx, &repository.ActionRun{
+			RunID:     string(rune('a' + i)),
+			ActionID:  "backup",
+			StartTime: base.Add(time.Duration(i) * 10 * time.Minute),
+			Duration:  duration * time.Millisecond,
+		}))
+	}
+
+	for aggregation, want := range map[string]float64{"min": 100, "max": 300, "count": 2} {
+		points, err := repo.GetAggregatedMetrics(ctx, "backup", 15*time.Minute, aggregation)
+		require.NoError(t, err)
+		require.Len(t, points, 2, aggregation)
+		assert.Equal(t, base, points[1].Timestamp)
+		assert.Equal(t, want, points[1].Value, aggregation)
+	}
+
+	_, err := repo.GetAggregatedMetrics(ctx, "backup", time.Hour, "median")
+	assert.Error(t, err)
+
+	deleted, err := repo.DeleteOldRuns(ctx, base.Add(15*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
+
+// TestMemoryCompositeRepository tests the composite repository wired to in-memory backends
+func TestMemoryCompositeRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryCompositeRepository()
+	defer repo.Close()
+
+	action := &semantic.SemanticScheduledAction{
+		SemanticAction: semantic.SemanticAction{Type: "SearchAction", Identifier: "search", Name: "Search"},
+		Requires:       []string{"index"},
+	}
+	require.NoError(t, repo.SaveAction(ctx, action, "nightly"))
+
+	stored, err := repo.GetAction(ctx, "search")
+	require.NoError(t, err)
+	assert.Equal(t, "Search", stored.Name)
+
+	deps, err := repo.Graph.GetDependencies(ctx, "search")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"index"}, deps)
+
+	actions, err := repo.Graph.GetWorkflowActions(ctx, "nightly")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"search"}, actions)
+
+	require.NoError(t, repo.DeleteAction(ctx, "search"))
+	_, err = repo.GetAction(ctx, "search")
+	assert.Error(t, err)
+}
@@ -370,6 +370,153 @@ func (r *Neo4jRepository) DeleteWorkflowGraph(ctx context.Context, workflowID st
 	return err
 }
 
+// LinkWorkflowLineage creates child -> parent relationships between workflow instances
+func (r *Neo4jRepository) LinkWorkflowLineage(ctx context.Context, lineage WorkflowLineage) error {
+	if lineage.InstanceID == "" {
+		return fmt.Errorf("lineage requires an instance ID")
+	}
+	if lineage.RootWorkflowID == "" {
+		lineage.RootWorkflowID = lineage.InstanceID
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MERGE (w:Workflow {id: $id})
+			SET w.template = $template,
+			    w.rootId = $rootId,
+			    w.parentActionId = $actionId
+		`
+		params := map[string]interface{}{
+			"id":       lineage.InstanceID,
+			"template": lineage.TemplateID,
+			"rootId":   lineage.RootWorkflowID,
+			"actionId": lineage.ActionID,
+		}
+		if _, err := tx.Run(ctx, query, params); err != nil {
+			return nil, err
+		}
+
+		if lineage.ParentWorkflowID == "" {
+			return nil, nil
+		}
+
+		parentQuery := `
+			MATCH (w:Workflow {id: $id})
+			MERGE (p:Workflow {id: $parentId})
+			MERGE (w)-[:CHILD_OF]->(p)
+		`
+		parentParams := map[string]interface{}{
+			"id":       lineage.InstanceID,
+			"parentId": lineage.ParentWorkflowID,
+		}
+		_, err := tx.Run(ctx, parentQuery, parentParams)
+		return nil, err
+	})
+
+	return err
+}
+
+// GetWorkflowLineage returns the lineage of a workflow instance
+func (r *Neo4jRepository) GetWorkflowLineage(ctx context.Context, instanceID string) (*WorkflowLineage, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MATCH (w:Workflow {id: $id})
+			OPTIONAL MATCH (w)-[:CHILD_OF]->(p:Workflow)
+			RETURN w.template as template, w.rootId as rootId, w.parentActionId as actionId, p.id as parentId
+		`
+		params := map[string]interface{}{"id": instanceID}
+
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Next(ctx) {
+			if err := result.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("workflow instance not found: %s", instanceID)
+		}
+
+		record := result.Record()
+		lineage := &WorkflowLineage{InstanceID: instanceID}
+		if v, ok := record.Get("template"); ok && v != nil {
+			lineage.TemplateID = v.(string)
+		}
+		if v, ok := record.Get("rootId"); ok && v != nil {
+			lineage.RootWorkflowID = v.(string)
+		}
+		if v, ok := record.Get("actionId"); ok && v != nil {
+			lineage.ActionID = v.(string)
+		}
+		if v, ok := record.Get("parentId"); ok && v != nil {
+			lineage.ParentWorkflowID = v.(string)
+		}
+		return lineage, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*WorkflowLineage), nil
+}
+
+// GetChildWorkflows gets the instances directly invoked by a workflow instance
+func (r *Neo4jRepository) GetChildWorkflows(ctx context.Context, instanceID string) ([]string, error) {
+	return r.queryWorkflowIDs(ctx, `
+		MATCH (c:Workflow)-[:CHILD_OF]->(w:Workflow {id: $id})
+		RETURN c.id as workflowId
+	`, instanceID)
+}
+
+// GetDescendantWorkflows gets all instances below a workflow instance (recursive)
+func (r *Neo4jRepository) GetDescendantWorkflows(ctx context.Context, instanceID string) ([]string, error) {
+	return r.queryWorkflowIDs(ctx, `
+		MATCH path = (c:Workflow)-[:CHILD_OF*]->(w:Workflow {id: $id})
+		WITH c, min(length(path)) as depth
+		RETURN c.id as workflowId
+		ORDER BY depth
+	`, instanceID)
+}
+
+// queryWorkflowIDs runs a read query returning workflowId rows
+func (r *Neo4jRepository) queryWorkflowIDs(ctx context.Context, query, instanceID string) ([]string, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		params := map[string]interface{}{"id": instanceID}
+
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		var ids []string
+		for result.Next(ctx) {
+			record := result.Record()
+			if id, ok := record.Get("workflowId"); ok {
+				ids = append(ids, id.(string))
+			}
+		}
+
+		return ids, result.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]string), nil
+}
+
 // Close closes the Neo4j driver
 func (r *Neo4jRepository) Close() error {
 	return r.driver.Close(r.ctx)
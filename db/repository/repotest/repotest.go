@@ -0,0 +1,607 @@
+// Package repotest provides conformance tests for implementations of the
+// repository interfaces. Every backend, in-memory or database-backed, runs the
+// same suite so code tested against the in-memory repositories behaves the
+// same in production.
+//
+// The suites only create documents, nodes and keys with unique IDs and only
+// assert on those, so they can run against shared, non-empty databases.
+//
+// Usage:
+//
+//	func TestRedisRepository(t *testing.T) {
+//	    repo, err := repository.NewRedisRepository(redisURL)
+//	    require.NoError(t, err)
+//	    repotest.TestCacheRepository(t, repo)
+//	}
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eve.evalgo.org/db/repository"
+	"eve.evalgo.org/semantic"
+)
+
+// eventTimeout bounds waiting for change events and pub/sub messages
+const eventTimeout = 10 * time.Second
+
+// idCounter makes IDs unique within a process
+var idCounter int64
+
+// newIDs returns a function that creates IDs unique to the current test run
+func newIDs(t *testing.T) func(name string) string {
+	prefix := fmt.Sprintf("repotest-%d-%d-%s", time.Now().UnixNano(), atomic.AddInt64(&idCounter, 1),
+		strings.NewReplacer("/", "-", " ", "_").Replace(t.Name()))
+	return func(name string) string {
+		return prefix + "-" + name
+	}
+}
+
+// receive waits for the first value on ch matching accept
+func receive[T any](t *testing.T, ch <-chan T, accept func(T) bool) T {
+	t.Helper()
+	timeout := time.After(eventTimeout)
+	for {
+		select {
+		case value, ok := <-ch:
+			require.True(t, ok, "channel closed before the expected value arrived")
+			if accept(value) {
+				return value
+			}
+		case <-timeout:
+			require.FailNow(t, "timed out waiting for value")
+		}
+	}
+}
+
+// requireClosed waits for ch to be closed, discarding pending values
+func requireClosed[T any](t *testing.T, ch <-chan T) {
+	t.Helper()
+	timeout := time.After(eventTimeout)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			require.FailNow(t, "channel was not closed after the context was cancelled")
+		}
+	}
+}
+
+// actionIDs returns the identifiers of actions
+func actionIDs(actions []*semantic.SemanticScheduledAction) []string {
+	ids := make([]string, 0, len(actions))
+	for _, action := range actions {
+		ids = append(ids, action.Identifier)
+	}
+	return ids
+}
+
+// newAction creates a scheduled action with dependencies
+func newAction(id string, requires ...string) *semantic.SemanticScheduledAction {
+	return &semantic.SemanticScheduledAction{
+		SemanticAction: semantic.SemanticAction{
+			Context:    "https://schema.org",
+			Type:       "SearchAction",
+			Identifier: id,
+			Name:       "Action " + id,
+		},
+		Requires: requires,
+	}
+}
+
+// TestDocumentRepository tests a DocumentRepository implementation
+func TestDocumentRepository(t *testing.T, repo repository.DocumentRepository) {
+	ctx := context.Background()
+
+	t.Run("workflows", func(t *testing.T) {
+		id := newIDs(t)
+		workflowID := id("workflow")
+
+		require.NoError(t, repo.SaveWorkflow(ctx, workflowID, map[string]interface{}{
+			"@type": "HowTo",
+			"name":  "Deploy",
+			"step":  []interface{}{map[string]interface{}{"name": "build"}},
+		}))
+		workflow, err := repo.GetWorkflow(ctx, workflowID)
+		require.NoError(t, err)
+		assert.Equal(t, workflowID, workflow["_id"])
+		assert.Equal(t, "Deploy", workflow["name"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "build"}}, workflow["step"])
+
+		require.NoError(t, repo.SaveWorkflow(ctx, workflowID, map[string]interface{}{"@type": "HowTo", "name": "Redeploy"}))
+		workflow, err = repo.GetWorkflow(ctx, workflowID)
+		require.NoError(t, err)
+		assert.Equal(t, "Redeploy", workflow["name"])
+
+		workflows, err := repo.ListWorkflows(ctx)
+		require.NoError(t, err)
+		var listed []string
+		for _, workflow := range workflows {
+			if docID, ok := workflow["_id"].(string); ok {
+				listed = append(listed, docID)
+			}
+		}
+		assert.Contains(t, listed, workflowID)
+
+		require.NoError(t, repo.DeleteWorkflow(ctx, workflowID))
+		_, err = repo.GetWorkflow(ctx, workflowID)
+		assert.Error(t, err)
+		assert.Error(t, repo.DeleteWorkflow(ctx, workflowID))
+	})
+
+	t.Run("actions", func(t *testing.T) {
+		id := newIDs(t)
+		workflowID := id("workflow")
+		first, second, standalone := id("first"), id("second"), id("standalone")
+
+		require.NoError(t, repo.SaveAction(ctx, first, newAction(first, second), workflowID))
+		require.NoError(t, repo.SaveAction(ctx, second, newAction(second), workflowID))
+		require.NoError(t, repo.SaveAction(ctx, standalone, newAction(standalone), ""))
+
+		action, err := repo.GetAction(ctx, first)
+		require.NoError(t, err)
+		assert.Equal(t, first, action.Identifier)
+		assert.Equal(t, "SearchAction", action.Type)
+		assert.Equal(t, "Action "+first, action.Name)
+		assert.Equal(t, []string{second}, action.Requires)
+
+		updated := newAction(first, second)
+		updated.Name = "Renamed"
+		require.NoError(t, repo.SaveAction(ctx, first, updated, workflowID))
+		action, err = repo.GetAction(ctx, first)
+		require.NoError(t, err)
+		assert.Equal(t, "Renamed", action.Name)
+
+		actions, err := repo.ListActions(ctx, workflowID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{first, second}, actionIDs(actions))
+
+		actions, err = repo.ListActions(ctx, "")
+		require.NoError(t, err)
+		assert.Subset(t, actionIDs(actions), []string{first, second, standalone})
+
+		require.NoError(t, repo.DeleteAction(ctx, first))
+		_, err = repo.GetAction(ctx, first)
+		assert.Error(t, err)
+		assert.Error(t, repo.DeleteAction(ctx, first))
+
+		actions, err = repo.ListActions(ctx, workflowID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{second}, actionIDs(actions))
+	})
+
+	t.Run("bulk save", func(t *testing.T) {
+		id := newIDs(t)
+		first, second := id("first"), id("second")
+
+		require.NoError(t, repo.BulkSaveActions(ctx, []*semantic.SemanticScheduledAction{newAction(first), newAction(second, first)}))
+		for _, actionID := range []string{first, second} {
+			action, err := repo.GetAction(ctx, actionID)
+			require.NoError(t, err)
+			assert.Equal(t, actionID, action.Identifier)
+		}
+	})
+
+	t.Run("watch changes", func(t *testing.T) {
+		id := newIDs(t)
+		workflowID, actionID := id("workflow"), id("action")
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		changes, err := repo.WatchChanges(watchCtx)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.SaveWorkflow(ctx, workflowID, map[string]interface{}{"name": "Watched"}))
+		event := receive(t, changes, func(event repository.ChangeEvent) bool { return event.ID == workflowID })
+		assert.Equal(t, "workflow", event.Type)
+		assert.Equal(t, "updated", event.Operation)
+		assert.Equal(t, "Watched", event.Document["name"])
+
+		require.NoError(t, repo.SaveAction(ctx, actionID, newAction(actionID), workflowID))
+		event = receive(t, changes, func(event repository.ChangeEvent) bool { return event.ID == actionID })
+		assert.Equal(t, "action", event.Type)
+		assert.Equal(t, "updated", event.Operation)
+
+		require.NoError(t, repo.DeleteWorkflow(ctx, workflowID))
+		event = receive(t, changes, func(event repository.ChangeEvent) bool {
+			return event.ID == workflowID && event.Operation == "deleted"
+		})
+		assert.Equal(t, "workflow", event.Type)
+
+		cancel()
+		requireClosed(t, changes)
+	})
+}
+
+// TestGraphRepository tests a GraphRepository implementation
+func TestGraphRepository(t *testing.T, repo repository.GraphRepository) {
+	ctx := context.Background()
+
+	// storeChain stores deploy -> build -> fetch and deploy -> test
+	storeChain := func(t *testing.T, id func(string) string) (deploy, build, fetch, test string) {
+		deploy, build, fetch, test = id("deploy"), id("build"), id("fetch"), id("test")
+		for _, action := range []*semantic.SemanticScheduledAction{
+			newAction(fetch),
+			newAction(build, fetch),
+			newAction(test),
+			newAction(deploy, build, test),
+		} {
+			require.NoError(t, repo.StoreActionGraph(ctx, action))
+		}
+		return deploy, build, fetch, test
+	}
+
+	t.Run("dependencies", func(t *testing.T) {
+		deploy, build, fetch, test := storeChain(t, newIDs(t))
+
+		deps, err := repo.GetDependencies(ctx, deploy)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{build, test}, deps)
+
+		deps, err = repo.GetAllDependencies(ctx, deploy)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{build, fetch, test}, deps)
+
+		deps, err = repo.GetDependencies(ctx, fetch)
+		require.NoError(t, err)
+		assert.Empty(t, deps)
+
+		dependents, err := repo.GetDependents(ctx, fetch)
+		require.NoError(t, err)
+		assert.Equal(t, []string{build}, dependents)
+
+		dependents, err = repo.GetDependents(ctx, deploy)
+		require.NoError(t, err)
+		assert.Empty(t, dependents)
+	})
+
+	t.Run("storing again merges dependencies", func(t *testing.T) {
+		id := newIDs(t)
+		deploy, build, _, test := storeChain(t, id)
+		lint := id("lint")
+
+		require.NoError(t, repo.StoreActionGraph(ctx, newAction(deploy, lint)))
+		deps, err := repo.GetDependencies(ctx, deploy)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{build, test, lint}, deps)
+	})
+
+	t.Run("paths and cycles", func(t *testing.T) {
+		deploy, build, fetch, test := storeChain(t, newIDs(t))
+
+		path, err := repo.FindPath(ctx, deploy, fetch)
+		require.NoError(t, err)
+		assert.Equal(t, []string{deploy, build, fetch}, path)
+
+		path, err = repo.FindPath(ctx, fetch, deploy)
+		require.NoError(t, err)
+		assert.Empty(t, path)
+
+		path, err = repo.FindPath(ctx, test, fetch)
+		require.NoError(t, err)
+		assert.Empty(t, path)
+
+		cycle, err := repo.WouldCreateCycle(ctx, fetch, deploy)
+		require.NoError(t, err)
+		assert.True(t, cycle, "fetch requiring deploy closes deploy -> build -> fetch")
+
+		cycle, err = repo.WouldCreateCycle(ctx, build, deploy)
+		require.NoError(t, err)
+		assert.True(t, cycle)
+
+		cycle, err = repo.WouldCreateCycle(ctx, deploy, fetch)
+		require.NoError(t, err)
+		assert.False(t, cycle)
+
+		cycle, err = repo.WouldCreateCycle(ctx, test, fetch)
+		require.NoError(t, err)
+		assert.False(t, cycle)
+	})
+
+	t.Run("delete action", func(t *testing.T) {
+		deploy, build, fetch, test := storeChain(t, newIDs(t))
+
+		require.NoError(t, repo.DeleteActionGraph(ctx, build))
+
+		deps, err := repo.GetAllDependencies(ctx, deploy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{test}, deps)
+
+		dependents, err := repo.GetDependents(ctx, fetch)
+		require.NoError(t, err)
+		assert.Empty(t, dependents)
+
+		path, err := repo.FindPath(ctx, deploy, fetch)
+		require.NoError(t, err)
+		assert.Empty(t, path)
+	})
+
+	t.Run("workflows", func(t *testing.T) {
+		id := newIDs(t)
+		deploy, build, _, _ := storeChain(t, id)
+		workflowID := id("workflow")
+
+		require.NoError(t, repo.LinkActionToWorkflow(ctx, deploy, workflowID))
+		require.NoError(t, repo.LinkActionToWorkflow(ctx, build, workflowID))
+		require.NoError(t, repo.LinkActionToWorkflow(ctx, build, workflowID))
+
+		actions, err := repo.GetWorkflowActions(ctx, workflowID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{deploy, build}, actions)
+
+		require.NoError(t, repo.DeleteActionGraph(ctx, deploy))
+		actions, err = repo.GetWorkflowActions(ctx, workflowID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{build}, actions)
+
+		require.NoError(t, repo.DeleteWorkflowGraph(ctx, workflowID))
+		actions, err = repo.GetWorkflowActions(ctx, workflowID)
+		require.NoError(t, err)
+		assert.Empty(t, actions)
+
+		deps, err := repo.GetDependencies(ctx, build)
+		require.NoError(t, err)
+		assert.Len(t, deps, 1, "deleting a workflow keeps its actions")
+	})
+}
+
+// TestMetricsRepository tests a MetricsRepository implementation. Runs are
+// recorded two days in the past with whole-millisecond durations, the
+// precision of the PostgreSQL backend.
+func TestMetricsRepository(t *testing.T, repo repository.MetricsRepository) {
+	ctx := context.Background()
+	base := time.Now().UTC().Truncate(time.Hour).Add(-48 * time.Hour)
+
+	saveRuns := func(t *testing.T, actionID string) {
+		runs := []*repository.ActionRun{
+			{RunID: actionID + "-1", StartTime: base.Add(10 * time.Minute), Duration: 100 * time.Millisecond, Status: "CompletedActionStatus"},
+			{RunID: actionID + "-2", StartTime: base.Add(20 * time.Minute), Duration: 600 * time.Millisecond, Status: "FailedActionStatus", Error: "timeout", Attempt: 1},
+			{RunID: actionID + "-3", StartTime: base.Add(70 * time.Minute), Duration: 200 * time.Millisecond, Status: "CompletedActionStatus",
+				Result: map[string]interface{}{"rows": 3, "table": "users"}},
+		}
+		for _, run := range runs {
+			run.ActionID = actionID
+			run.WorkflowID = actionID + "-workflow"
+			run.EndTime = run.StartTime.Add(run.Duration)
+			require.NoError(t, repo.SaveRun(ctx, run))
+		}
+	}
+
+	t.Run("run history", func(t *testing.T) {
+		actionID := newIDs(t)("action")
+		saveRuns(t, actionID)
+
+		history, err := repo.GetRunHistory(ctx, actionID, 2)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, actionID+"-3", history[0].RunID)
+		assert.Equal(t, actionID+"-2", history[1].RunID)
+
+		latest := history[0]
+		assert.Equal(t, actionID, latest.ActionID)
+		assert.Equal(t, actionID+"-workflow", latest.WorkflowID)
+		assert.True(t, latest.StartTime.Equal(base.Add(70*time.Minute)), "start time %s", latest.StartTime)
+		assert.Equal(t, 200*time.Millisecond, latest.Duration)
+		assert.Equal(t, "CompletedActionStatus", latest.Status)
+		assert.Equal(t, map[string]interface{}{"rows": float64(3), "table": "users"}, latest.Result)
+		assert.Equal(t, "timeout", history[1].Error)
+		assert.Equal(t, 1, history[1].Attempt)
+
+		history, err = repo.GetRunHistory(ctx, newIDs(t)("unknown"), 10)
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		actionID := newIDs(t)("action")
+		saveRuns(t, actionID)
+
+		metrics, err := repo.GetMetrics(ctx, actionID, base, base.Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, actionID, metrics.ActionID)
+		assert.Equal(t, int64(3), metrics.TotalRuns)
+		assert.Equal(t, int64(2), metrics.SuccessfulRuns)
+		assert.Equal(t, int64(1), metrics.FailedRuns)
+		assert.Equal(t, 300*time.Millisecond, metrics.AvgDuration)
+		assert.Equal(t, 100*time.Millisecond, metrics.MinDuration)
+		assert.Equal(t, 600*time.Millisecond, metrics.MaxDuration)
+		assert.True(t, metrics.LastRun.Equal(base.Add(70*time.Minute)), "last run %s", metrics.LastRun)
+
+		metrics, err = repo.GetMetrics(ctx, actionID, base, base.Add(30*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), metrics.TotalRuns)
+		assert.Equal(t, 600*time.Millisecond, metrics.MaxDuration)
+
+		metrics, err = repo.GetMetrics(ctx, actionID, base.Add(-time.Hour), base)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), metrics.TotalRuns)
+	})
+
+	t.Run("aggregated metrics", func(t *testing.T) {
+		actionID := newIDs(t)("action")
+		saveRuns(t, actionID)
+
+		for aggregation, want := range map[string][]float64{"avg": {200, 350}, "sum": {200, 700}} {
+			points, err := repo.GetAggregatedMetrics(ctx, actionID, time.Hour, aggregation)
+			require.NoError(t, err)
+			require.Len(t, points, 2, aggregation)
+			assert.True(t, points[0].Timestamp.Equal(base.Add(time.Hour)), "%s bucket %s", aggregation, points[0].Timestamp)
+			assert.True(t, points[1].Timestamp.Equal(base), "%s bucket %s", aggregation, points[1].Timestamp)
+			assert.InDelta(t, want[0], points[0].Value, 0.001, aggregation)
+			assert.InDelta(t, want[1], points[1].Value, 0.001, aggregation)
+		}
+	})
+
+	t.Run("delete old runs", func(t *testing.T) {
+		actionID := newIDs(t)("action")
+		saveRuns(t, actionID)
+
+		_, err := repo.DeleteOldRuns(ctx, base.Add(time.Hour))
+		require.NoError(t, err)
+
+		history, err := repo.GetRunHistory(ctx, actionID, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, actionID+"-3", history[0].RunID)
+	})
+}
+
+// TestCacheRepository tests a CacheRepository implementation
+func TestCacheRepository(t *testing.T, repo repository.CacheRepository) {
+	ctx := context.Background()
+
+	t.Run("locks", func(t *testing.T) {
+		key := newIDs(t)("lock")
+
+		acquired, err := repo.AcquireLock(ctx, key, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, acquired)
+
+		acquired, err = repo.AcquireLock(ctx, key, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, acquired, "a held lock cannot be acquired again")
+
+		locked, err := repo.IsLocked(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, locked)
+
+		require.NoError(t, repo.ReleaseLock(ctx, key))
+		locked, err = repo.IsLocked(ctx, key)
+		require.NoError(t, err)
+		assert.False(t, locked)
+
+		acquired, err = repo.AcquireLock(ctx, key, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, acquired)
+		require.NoError(t, repo.ReleaseLock(ctx, key))
+	})
+
+	t.Run("lock expiry", func(t *testing.T) {
+		key := newIDs(t)("lock")
+
+		acquired, err := repo.AcquireLock(ctx, key, 200*time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		assert.Eventually(t, func() bool {
+			locked, err := repo.IsLocked(ctx, key)
+			return err == nil && !locked
+		}, eventTimeout, 50*time.Millisecond)
+
+		acquired, err = repo.AcquireLock(ctx, key, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, acquired, "an expired lock can be acquired")
+		require.NoError(t, repo.ReleaseLock(ctx, key))
+	})
+
+	t.Run("concurrent lock acquisition", func(t *testing.T) {
+		key := newIDs(t)("lock")
+
+		var (
+			wg       sync.WaitGroup
+			acquired int64
+		)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if ok, err := repo.AcquireLock(ctx, key, time.Minute); err == nil && ok {
+					atomic.AddInt64(&acquired, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int64(1), acquired)
+		require.NoError(t, repo.ReleaseLock(ctx, key))
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		key := newIDs(t)("entry")
+		type entry struct {
+			Name  string   `json:"name"`
+			Count int      `json:"count"`
+			Tags  []string `json:"tags"`
+		}
+
+		var value entry
+		assert.Error(t, repo.GetCache(ctx, key, &value), "missing keys are a cache miss")
+
+		require.NoError(t, repo.SetCache(ctx, key, entry{Name: "nginx", Count: 2, Tags: []string{"web"}}, time.Minute))
+		require.NoError(t, repo.GetCache(ctx, key, &value))
+		assert.Equal(t, entry{Name: "nginx", Count: 2, Tags: []string{"web"}}, value)
+
+		var generic map[string]interface{}
+		require.NoError(t, repo.GetCache(ctx, key, &generic))
+		assert.Equal(t, float64(2), generic["count"])
+
+		require.NoError(t, repo.DeleteCache(ctx, key))
+		assert.Error(t, repo.GetCache(ctx, key, &value))
+		require.NoError(t, repo.DeleteCache(ctx, key))
+	})
+
+	t.Run("cache expiry", func(t *testing.T) {
+		key := newIDs(t)("entry")
+
+		require.NoError(t, repo.SetCache(ctx, key, "short-lived", 200*time.Millisecond))
+		assert.Eventually(t, func() bool {
+			var value string
+			return repo.GetCache(ctx, key, &value) != nil
+		}, eventTimeout, 50*time.Millisecond)
+	})
+
+	t.Run("pub/sub", func(t *testing.T) {
+		channel := newIDs(t)("channel")
+
+		subCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		first, err := repo.Subscribe(subCtx, channel)
+		require.NoError(t, err)
+		second, err := repo.Subscribe(subCtx, channel)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.Publish(ctx, channel+"-other", "ignored"))
+		require.NoError(t, repo.Publish(ctx, channel, map[string]interface{}{"event": "completed", "run": 1}))
+		require.NoError(t, repo.Publish(ctx, channel, "done"))
+
+		for _, messages := range []<-chan interface{}{first, second} {
+			message := receive(t, messages, func(interface{}) bool { return true })
+			assert.Equal(t, map[string]interface{}{"event": "completed", "run": float64(1)}, message)
+			message = receive(t, messages, func(interface{}) bool { return true })
+			assert.Equal(t, "done", message)
+		}
+
+		cancel()
+		requireClosed(t, first)
+		requireClosed(t, second)
+		require.NoError(t, repo.Publish(ctx, channel, "after unsubscribe"))
+	})
+
+	t.Run("counters", func(t *testing.T) {
+		key := newIDs(t)("counter")
+
+		for want := int64(1); want <= 3; want++ {
+			value, err := repo.Increment(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, want, value)
+		}
+		value, err := repo.Decrement(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), value)
+
+		value, err = repo.Decrement(ctx, newIDs(t)("fresh"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), value)
+	})
+}
@@ -0,0 +1,326 @@
+package db
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"eve.evalgo.org/semantic"
+)
+
+// shNS is the SHACL namespace
+const shNS = "http://www.w3.org/ns/shacl#"
+
+// SHACL result severities
+const (
+	SHACLViolation = shNS + "Violation"
+	SHACLWarning   = shNS + "Warning"
+	SHACLInfo      = shNS + "Info"
+)
+
+// shapeTargets are the predicates that give a shape focus nodes
+var shapeTargets = []string{"targetClass", "targetNode", "targetSubjectsOf", "targetObjectsOf"}
+
+// SHACLShapes is a parsed SHACL shapes graph. It implements the SHACL Core
+// constraint components and is safe for concurrent use.
+type SHACLShapes struct {
+	graph  *rdfGraph
+	shapes []Term // Shapes with targets, in a stable order
+}
+
+// NewSHACLShapes creates a shapes graph from statements
+func NewSHACLShapes(quads []Quad) *SHACLShapes {
+	s := &SHACLShapes{graph: newRDFGraph(quads)}
+
+	seen := make(map[Term]bool)
+	for subject, predicates := range s.graph.out {
+		targeted := false
+		for _, target := range shapeTargets {
+			if len(predicates[shNS+target]) > 0 {
+				targeted = true
+			}
+		}
+		// Shapes that are also classes target their instances implicitly
+		isShape := s.graph.has(subject, RDFType, NewIRI(shNS+"NodeShape")) || s.graph.has(subject, RDFType, NewIRI(shNS+"PropertyShape"))
+		if isShape && s.graph.has(subject, RDFType, NewIRI(RDFSNamespace+"Class")) {
+			targeted = true
+		}
+		if targeted && !seen[subject] {
+			seen[subject] = true
+			s.shapes = append(s.shapes, subject)
+		}
+	}
+	sortTerms(s.shapes)
+	return s
+}
+
+// ParseSHACLShapes parses a shapes graph in any supported RDF format
+//
+// Parameters:
+//   - r: Shapes document
+//   - format: Serialization, usually RDFTurtle
+//
+// Returns:
+//   - *SHACLShapes: Shapes ready for validation
+//   - error: Syntax errors in the shapes document
+func ParseSHACLShapes(r io.Reader, format RDFFormat) (*SHACLShapes, error) {
+	quads, err := ParseRDF(r, format, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse shapes: %w", err)
+	}
+	return NewSHACLShapes(quads), nil
+}
+
+// LoadSHACLShapes parses shapes written in Turtle
+//
+// Example Usage:
+//
+//	shapes, err := LoadSHACLShapes(`
+//	    @prefix sh: <http://www.w3.org/ns/shacl#> .
+//	    @prefix schema: <http://schema.org/> .
+//
+//	    schema:ActionShape a sh:NodeShape ;
+//	        sh:targetClass schema:SearchAction ;
+//	        sh:property [ sh:path schema:query ; sh:minCount 1 ; sh:datatype xsd:string ] .`)
+func LoadSHACLShapes(turtle string) (*SHACLShapes, error) {
+	return ParseSHACLShapes(strings.NewReader(turtle), RDFTurtle)
+}
+
+// Validate checks a data graph against the shapes
+//
+// Parameters:
+//   - data: Statements of the data graph; graph names are ignored
+//
+// Returns:
+//   - *SHACLReport: Validation report; Conforms is true if there are no results
+//
+// Example Usage:
+//
+//	report := shapes.Validate(quads)
+//	for _, result := range report.Results {
+//	    fmt.Printf("%s %s: %s\n", result.FocusNode, result.ResultPath, result.Message)
+//	}
+func (s *SHACLShapes) Validate(data []Quad) *SHACLReport {
+	v := &shaclValidator{shapes: s.graph, data: newRDFGraph(data), inProgress: make(map[[2]Term]bool)}
+	report := &SHACLReport{Conforms: true}
+	for _, shape := range s.shapes {
+		for _, focus := range v.targetNodes(shape) {
+			report.Results = append(report.Results, v.validateShape(focus, shape)...)
+		}
+	}
+	report.Conforms = len(report.Results) == 0
+	return report
+}
+
+// ValidateJSONLD expands a JSON-LD document, converts it to RDF and
+// validates it against the shapes
+//
+// Parameters:
+//   - doc: JSON-LD document (map, struct or raw JSON)
+//   - opts: Base IRI and document loader (nil for defaults)
+//
+// Returns:
+//   - *SHACLReport: Validation report
+//   - error: JSON-LD processing errors
+func (s *SHACLShapes) ValidateJSONLD(doc interface{}, opts *JSONLDOptions) (*SHACLReport, error) {
+	quads, err := JSONLDToRDF(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.Validate(quads), nil
+}
+
+// ValidateRDFFile parses an RDF file and validates it against the shapes.
+// An empty format selects the format by file extension.
+func (s *SHACLShapes) ValidateRDFFile(path string, format RDFFormat) (*SHACLReport, error) {
+	if format == "" {
+		quads, err := ParseRDFFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return s.Validate(quads), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RDF file: %w", err)
+	}
+	defer file.Close()
+	quads, err := ParseRDF(file, format, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return s.Validate(quads), nil
+}
+
+// SHACLResult is a single validation result
+type SHACLResult struct {
+	FocusNode                 Term   `json:"focusNode"`
+	ResultPath                Term   `json:"resultPath,omitempty"` // Zero for node shapes
+	Value                     Term   `json:"value,omitempty"`      // Zero for constraints on all values, e.g. sh:minCount
+	SourceShape               Term   `json:"sourceShape"`
+	SourceConstraintComponent string `json:"sourceConstraintComponent"`
+	Severity                  string `json:"resultSeverity"`
+	Message                   string `json:"resultMessage,omitempty"`
+
+	pathQuads []Quad // Description of a complex ResultPath
+}
+
+// SHACLReport is a SHACL validation report
+type SHACLReport struct {
+	Conforms bool          `json:"conforms"`
+	Results  []SHACLResult `json:"results,omitempty"`
+}
+
+// Err returns a *SHACLValidationError if the data does not conform, else nil
+func (r *SHACLReport) Err() error {
+	if r.Conforms {
+		return nil
+	}
+	return &SHACLValidationError{Report: r}
+}
+
+// Violations returns the results with severity sh:Violation
+func (r *SHACLReport) Violations() []SHACLResult {
+	var violations []SHACLResult
+	for _, result := range r.Results {
+		if result.Severity == SHACLViolation {
+			violations = append(violations, result)
+		}
+	}
+	return violations
+}
+
+// Quads returns the report as an RDF graph using the SHACL vocabulary
+// (sh:ValidationReport, sh:result, ...), as defined by the W3C recommendation.
+// Write it with WriteRDF to exchange it with other SHACL tools.
+func (r *SHACLReport) Quads() []Quad {
+	report := NewBlankNode("report")
+	quads := []Quad{
+		{Subject: report, Predicate: NewIRI(RDFType), Object: NewIRI(shNS + "ValidationReport")},
+		{Subject: report, Predicate: NewIRI(shNS + "conforms"), Object: NewLiteral(strconv.FormatBool(r.Conforms), XSDBoolean)},
+	}
+
+	// Shapes graph blank nodes are renamed so they cannot clash with data graph ones
+	shapeTerm := func(t Term) Term {
+		if t.IsBlankNode() {
+			return NewBlankNode("shape_" + t.Value)
+		}
+		return t
+	}
+	for i, result := range r.Results {
+		node := NewBlankNode("result" + strconv.Itoa(i))
+		add := func(predicate string, object Term) {
+			quads = append(quads, Quad{Subject: node, Predicate: NewIRI(shNS + predicate), Object: object})
+		}
+		quads = append(quads, Quad{Subject: report, Predicate: NewIRI(shNS + "result"), Object: node})
+		add("focusNode", result.FocusNode)
+		quads = append(quads, Quad{Subject: node, Predicate: NewIRI(RDFType), Object: NewIRI(shNS + "ValidationResult")})
+		if !result.ResultPath.IsZero() {
+			add("resultPath", shapeTerm(result.ResultPath))
+			for _, quad := range result.pathQuads {
+				quads = append(quads, Quad{Subject: shapeTerm(quad.Subject), Predicate: quad.Predicate, Object: shapeTerm(quad.Object)})
+			}
+		}
+		if !result.Value.IsZero() {
+			add("value", result.Value)
+		}
+		add("sourceShape", shapeTerm(result.SourceShape))
+		add("sourceConstraintComponent", NewIRI(result.SourceConstraintComponent))
+		add("resultSeverity", NewIRI(result.Severity))
+		if result.Message != "" {
+			add("resultMessage", NewLiteral(result.Message, ""))
+		}
+	}
+	return quads
+}
+
+// SHACLValidationError is returned when data does not conform to the shapes
+type SHACLValidationError struct {
+	Report *SHACLReport
+}
+
+func (e *SHACLValidationError) Error() string {
+	if len(e.Report.Results) == 0 {
+		return "SHACL validation failed"
+	}
+	first := e.Report.Results[0]
+	message := fmt.Sprintf("SHACL validation failed with %d results: %s", len(e.Report.Results), first.FocusNode)
+	if !first.ResultPath.IsZero() {
+		message += " " + first.ResultPath.String()
+	}
+	return message + ": " + first.Message
+}
+
+// GraphDBImportGraphRdfValidated validates an RDF/XML file against SHACL
+// shapes and imports it with GraphDBImportGraphRdf only if it conforms.
+//
+// Parameters:
+//   - url, user, pass, repo, graph, restoreFile: As for GraphDBImportGraphRdf
+//   - shapes: Shapes the file must conform to
+//
+// Returns:
+//   - error: *SHACLValidationError carrying the report if the data does not
+//     conform, parse errors, or import errors
+//
+// Example Usage:
+//
+//	err := GraphDBImportGraphRdfValidated(url, user, pass, "kb", graph, "apps.rdf", shapes)
+//	var invalid *SHACLValidationError
+//	if errors.As(err, &invalid) {
+//	    for _, result := range invalid.Report.Results {
+//	        log.Println(result.Message)
+//	    }
+//	}
+func GraphDBImportGraphRdfValidated(url, user, pass, repo, graph, restoreFile string, shapes *SHACLShapes) error {
+	report, err := shapes.ValidateRDFFile(restoreFile, RDFXML)
+	if err != nil {
+		return err
+	}
+	if err := report.Err(); err != nil {
+		return err
+	}
+	return GraphDBImportGraphRdf(url, user, pass, repo, graph, restoreFile)
+}
+
+// ImportRDFValidated validates an RDF file against SHACL shapes and imports it
+// with ImportRDF only if it conforms. The format is taken from contentType,
+// falling back to the file extension.
+//
+// Returns:
+//   - []byte: Server response of the import
+//   - error: *SHACLValidationError carrying the report if the data does not
+//     conform, parse errors, or import errors
+func ImportRDFValidated(serverURL, repositoryID, username, password, rdfFilePath, contentType string, shapes *SHACLShapes) ([]byte, error) {
+	format, _ := RDFFormatForContentType(contentType)
+	report, err := shapes.ValidateRDFFile(rdfFilePath, format)
+	if err != nil {
+		return nil, err
+	}
+	if err := report.Err(); err != nil {
+		return nil, err
+	}
+	return ImportRDF(serverURL, repositoryID, username, password, rdfFilePath, contentType)
+}
+
+// SHACLActionValidator returns a semantic.ActionValidator that validates
+// actions against SHACL shapes before their handlers run. Actions are
+// serialized to JSON-LD, expanded with the offline schema.org context and
+// converted to RDF; shapes usually target schema.org action classes.
+//
+// Example Usage:
+//
+//	shapes, _ := LoadSHACLShapes(actionShapes)
+//	registry := semantic.NewActionRegistry()
+//	registry.AddValidator(SHACLActionValidator(shapes))
+func SHACLActionValidator(shapes *SHACLShapes) semantic.ActionValidator {
+	return func(action interface{}) error {
+		report, err := shapes.ValidateJSONLD(action, nil)
+		if err != nil {
+			return err
+		}
+		return report.Err()
+	}
+}
@@ -0,0 +1,658 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eve.evalgo.org/semantic"
+)
+
+const appShapes = `@prefix sh: <http://www.w3.org/ns/shacl#> .
+@prefix schema: <http://schema.org/> .
+@prefix xsd: <http://www.w3.org/2001/XMLSchema#> .
+@prefix ex: <http://example.org/shapes#> .
+
+ex:AppShape a sh:NodeShape ;
+    sh:targetClass schema:SoftwareApplication ;
+    sh:property [
+        sh:path schema:name ;
+        sh:minCount 1 ; sh:maxCount 1 ;
+        sh:datatype xsd:string ;
+        sh:minLength 2
+    ] ;
+    sh:property [
+        sh:path schema:softwareVersion ;
+        sh:pattern "^[0-9]+\\.[0-9]+" ;
+        sh:severity sh:Warning
+    ] ;
+    sh:property [
+        sh:path schema:applicationCategory ;
+        sh:in ( "WebServer" "Database" )
+    ] ;
+    sh:property [
+        sh:path schema:author ;
+        sh:class schema:Person ;
+        sh:node ex:PersonShape
+    ] ;
+    sh:property [
+        sh:path ( schema:offers schema:price ) ;
+        sh:minInclusive 0 ;
+        sh:message "Price must not be negative"
+    ] .
+
+ex:PersonShape a sh:NodeShape ;
+    sh:closed true ;
+    sh:ignoredProperties ( <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> ) ;
+    sh:property [ sh:path schema:name ; sh:minCount 1 ] ;
+    sh:or ( [ sh:nodeKind sh:IRI ] [ sh:property [ sh:path schema:email ; sh:minCount 1 ] ] ) .
+`
+
+// TestSHACLValidate tests SHACL Core constraints and report contents
+func TestSHACLValidate(t *testing.T) {
+	shapes, err := LoadSHACLShapes(appShapes)
+	require.NoError(t, err)
+
+	t.Run("conforming data", func(t *testing.T) {
+		data := mustParseTurtle(t, `@prefix schema: <http://schema.org/> .
+<urn:app:nginx> a schema:SoftwareApplication ;
+    schema:name "nginx" ;
+    schema:softwareVersion "1.25" ;
+    schema:applicationCategory "WebServer" ;
+    schema:author <urn:person:igor> ;
+    schema:offers [ schema:price 0 ] .
+<urn:person:igor> a schema:Person ; schema:name "Igor" .`)
+		report := shapes.Validate(data)
+		assert.True(t, report.Conforms, "%+v", report.Results)
+		assert.NoError(t, report.Err())
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		data := mustParseTurtle(t, `@prefix schema: <http://schema.org/> .
+<urn:app:nginx> a schema:SoftwareApplication ;
+    schema:name "n", "nginx" ;
+    schema:softwareVersion "latest" ;
+    schema:applicationCategory "Game" ;
+    schema:author [ a schema:Person ; schema:name "Igor" ; schema:age 60 ] ;
+    schema:offers [ schema:price -1 ] .
+<urn:app:empty> a schema:SoftwareApplication .`)
+		report := shapes.Validate(data)
+		require.False(t, report.Conforms)
+
+		components := make(map[string]int)
+		for _, result := range report.Results {
+			components[strings.TrimPrefix(result.SourceConstraintComponent, shNS)]++
+		}
+		assert.Equal(t, map[string]int{
+			"MaxCountConstraintComponent":     1,
+			"MinLengthConstraintComponent":    1,
+			"PatternConstraintComponent":      1,
+			"InConstraintComponent":           1,
+			"NodeConstraintComponent":         1,
+			"MinInclusiveConstraintComponent": 1,
+			"MinCountConstraintComponent":     1,
+		}, components)
+
+		for _, result := range report.Results {
+			switch result.SourceConstraintComponent {
+			case shNS + "PatternConstraintComponent":
+				assert.Equal(t, SHACLWarning, result.Severity)
+				assert.Equal(t, NewLiteral("latest", ""), result.Value)
+			case shNS + "MinInclusiveConstraintComponent":
+				assert.Equal(t, "Price must not be negative", result.Message)
+				assert.True(t, result.ResultPath.IsBlankNode())
+			case shNS + "MinCountConstraintComponent":
+				assert.Equal(t, NewIRI("urn:app:empty"), result.FocusNode)
+				assert.Equal(t, NewIRI("http://schema.org/name"), result.ResultPath)
+				assert.True(t, result.Value.IsZero())
+			}
+		}
+		assert.Len(t, report.Violations(), 6)
+
+		var validationErr *SHACLValidationError
+		require.True(t, errors.As(report.Err(), &validationErr))
+		assert.Contains(t, validationErr.Error(), "SHACL validation failed with 7 results")
+	})
+
+	t.Run("report graph", func(t *testing.T) {
+		data := mustParseTurtle(t, `@prefix schema: <http://schema.org/> .
+<urn:app:nginx> a schema:SoftwareApplication ; schema:offers [ schema:price -1 ] ; schema:name "nginx" .`)
+		report := shapes.Validate(data)
+		require.Len(t, report.Results, 1)
+
+		var out bytes.Buffer
+		require.NoError(t, WriteRDF(&out, report.Quads(), RDFTurtle, nil))
+		parsed := mustParseTurtle(t, out.String())
+		graph := newRDFGraph(parsed)
+		reports := graph.subjects(RDFType, NewIRI(shNS+"ValidationReport"))
+		require.Len(t, reports, 1)
+		assert.Equal(t, []Term{NewLiteral("false", XSDBoolean)}, graph.objects(reports[0], shNS+"conforms"))
+		results := graph.objects(reports[0], shNS+"result")
+		require.Len(t, results, 1)
+		path, ok := graph.object(results[0], shNS+"resultPath")
+		require.True(t, ok)
+		assert.Equal(t, []Term{NewIRI("http://schema.org/offers"), NewIRI("http://schema.org/price")}, graph.list(path))
+	})
+}
+
+// TestSHACLPaths tests property path evaluation
+func TestSHACLPaths(t *testing.T) {
+	shapes, err := LoadSHACLShapes(`@prefix sh: <http://www.w3.org/ns/shacl#> .
+@prefix ex: <http://example.org/> .
+ex:RootShape sh:targetNode ex:root ;
+    sh:property [ sh:path [ sh:oneOrMorePath ex:child ] ; sh:minCount 3 ; sh:maxCount 3 ] ;
+    sh:property [ sh:path [ sh:inversePath ex:child ] ; sh:maxCount 0 ] ;
+    sh:property [ sh:path [ sh:alternativePath ( ex:child ex:sibling ) ] ; sh:minCount 2 ] .`)
+	require.NoError(t, err)
+
+	data := mustParseTurtle(t, `@prefix ex: <http://example.org/> .
+ex:root ex:child ex:a ; ex:sibling ex:s .
+ex:a ex:child ex:b .
+ex:b ex:child ex:c .`)
+	report := shapes.Validate(data)
+	assert.True(t, report.Conforms, "%+v", report.Results)
+
+	data = append(data, Quad{Subject: NewIRI("http://example.org/parent"), Predicate: NewIRI("http://example.org/child"), Object: NewIRI("http://example.org/root")})
+	report = shapes.Validate(data)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, shNS+"MaxCountConstraintComponent", report.Results[0].SourceConstraintComponent)
+}
+
+// TestSHACLValidateJSONLD tests validation of JSON-LD documents after expansion
+func TestSHACLValidateJSONLD(t *testing.T) {
+	shapes, err := LoadSHACLShapes(appShapes)
+	require.NoError(t, err)
+
+	report, err := shapes.ValidateJSONLD(map[string]interface{}{
+		"@context":            "https://schema.org",
+		"@type":               "SoftwareApplication",
+		"@id":                 "urn:app:nginx",
+		"name":                "nginx",
+		"applicationCategory": "Spreadsheet",
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, NewIRI("urn:app:nginx"), report.Results[0].FocusNode)
+	assert.Equal(t, shNS+"InConstraintComponent", report.Results[0].SourceConstraintComponent)
+}
+
+// TestImportRDFValidated tests that only conforming files are uploaded
+func TestImportRDFValidated(t *testing.T) {
+	shapes, err := LoadSHACLShapes(appShapes)
+	require.NoError(t, err)
+
+	uploads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.ttl")
+	require.NoError(t, os.WriteFile(invalid, []byte(`<urn:app:x> a <http://schema.org/SoftwareApplication> .`), 0644))
+	valid := filepath.Join(dir, "valid.ttl")
+	require.NoError(t, os.WriteFile(valid, []byte(`<urn:app:x> a <http://schema.org/SoftwareApplication> ; <http://schema.org/name> "x1" .`), 0644))
+
+	_, err = ImportRDFValidated(server.URL, "apps", "admin", "secret", invalid, "text/turtle", shapes)
+	var validationErr *SHACLValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Report.Results, 1)
+	assert.Equal(t, 0, uploads)
+
+	_, err = ImportRDFValidated(server.URL, "apps", "admin", "secret", valid, "text/turtle", shapes)
+	require.NoError(t, err)
+	assert.Equal(t, 1, uploads)
+}
+
+// TestSHACLActionValidator tests rejecting actions in the semantic ActionRegistry
+func TestSHACLActionValidator(t *testing.T) {
+	shapes, err := LoadSHACLShapes(`@prefix sh: <http://www.w3.org/ns/shacl#> .
+@prefix schema: <http://schema.org/> .
+schema:SearchActionShape sh:targetClass schema:SearchAction ;
+    sh:property [ sh:path schema:query ; sh:minCount 1 ] .`)
+	require.NoError(t, err)
+
+	registry := semantic.NewActionRegistry()
+	handled := 0
+	registry.MustRegister("SearchAction", func(c echo.Context, action interface{}) error {
+		handled++
+		return c.NoContent(http.StatusOK)
+	})
+	registry.AddValidator(SHACLActionValidator(shapes))
+
+	handle := func(action *semantic.SemanticAction) int {
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/v1/api/semantic/action", nil), rec)
+		require.NoError(t, registry.Handle(c, action))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, handle(&semantic.SemanticAction{Context: "https://schema.org", Type: "SearchAction"}))
+	assert.Equal(t, 0, handled)
+
+	assert.Equal(t, http.StatusOK, handle(&semantic.SemanticAction{Context: "https://schema.org", Type: "SearchAction", Query: "nginx"}))
+	assert.Equal(t, 1, handled)
+}
+
+// shaclResultKeys summarizes results as "focus component path value" with
+// the ex: namespace shortened, in a stable order
+func shaclResultKeys(report *SHACLReport) []string {
+	short := func(t Term) string {
+		switch {
+		case t.IsZero():
+			return "-"
+		case t.IsBlankNode():
+			return "_"
+		}
+		if t.IsIRI() && strings.HasPrefix(t.Value, "http://example.org/") {
+			return "ex:" + strings.TrimPrefix(t.Value, "http://example.org/")
+		}
+		return t.String()
+	}
+	keys := make([]string, 0, len(report.Results))
+	for _, result := range report.Results {
+		component := strings.TrimSuffix(strings.TrimPrefix(result.SourceConstraintComponent, shNS), "ConstraintComponent")
+		keys = append(keys, strings.Join([]string{short(result.FocusNode), component, short(result.ResultPath), short(result.Value)}, " "))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TestSHACLConstraintComponents checks each SHACL Core constraint component
+// with a conforming and a violating focus node, following the layout of the
+// W3C data-shapes core tests
+func TestSHACLConstraintComponents(t *testing.T) {
+	const prefixes = `@prefix sh: <http://www.w3.org/ns/shacl#> .
+@prefix xsd: <http://www.w3.org/2001/XMLSchema#> .
+@prefix rdf: <http://www.w3.org/1999/02/22-rdf-syntax-ns#> .
+@prefix rdfs: <http://www.w3.org/2000/01/rdf-schema#> .
+@prefix ex: <http://example.org/> .
+`
+	tests := []struct {
+		name     string
+		shapes   string
+		data     string
+		expected []string
+	}{
+		// Value type constraint components
+		{
+			name: "sh:class with subclasses",
+			shapes: `ex:S sh:targetNode ex:ok, ex:bad ;
+			    sh:property [ sh:path ex:p ; sh:class ex:Person ] .`,
+			data: `ex:Student rdfs:subClassOf ex:Person .
+ex:ok ex:p ex:alice, ex:bob . ex:alice a ex:Person . ex:bob a ex:Student .
+ex:bad ex:p ex:carol, "literal" . ex:carol a ex:Robot .`,
+			expected: []string{`ex:bad Class ex:p "literal"`, `ex:bad Class ex:p ex:carol`},
+		},
+		{
+			name: "sh:datatype rejects other and ill-formed literals",
+			shapes: `ex:S sh:targetNode ex:ok, ex:bad ;
+			    sh:property [ sh:path ex:p ; sh:datatype xsd:integer ] .`,
+			data: `ex:ok ex:p 1, "2"^^xsd:integer .
+ex:bad ex:p "3", "x"^^xsd:integer, 1.5 .`,
+			expected: []string{
+				`ex:bad Datatype ex:p "1.5"^^<http://www.w3.org/2001/XMLSchema#decimal>`,
+				`ex:bad Datatype ex:p "3"`,
+				`ex:bad Datatype ex:p "x"^^<http://www.w3.org/2001/XMLSchema#integer>`,
+			},
+		},
+		{
+			name:     "sh:datatype xsd:string accepts simple literals only",
+			shapes:   `ex:S sh:targetNode ex:ok, ex:bad ; sh:property [ sh:path ex:p ; sh:datatype xsd:string ] .`,
+			data:     `ex:ok ex:p "a" . ex:bad ex:p "b"@en .`,
+			expected: []string{`ex:bad Datatype ex:p "b"@en`},
+		},
+		{
+			name: "sh:nodeKind",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:iri ; sh:nodeKind sh:IRI ] ;
+			    sh:property [ sh:path ex:blank ; sh:nodeKind sh:BlankNode ] ;
+			    sh:property [ sh:path ex:literal ; sh:nodeKind sh:Literal ] ;
+			    sh:property [ sh:path ex:node ; sh:nodeKind sh:BlankNodeOrIRI ] ;
+			    sh:property [ sh:path ex:notIRI ; sh:nodeKind sh:BlankNodeOrLiteral ] ;
+			    sh:property [ sh:path ex:notBlank ; sh:nodeKind sh:IRIOrLiteral ] .`,
+			data: `ex:a ex:iri ex:x, "x" ;
+			    ex:blank [], ex:x ;
+			    ex:literal "x", ex:x ;
+			    ex:node ex:x, [], "x" ;
+			    ex:notIRI [], "x", ex:x ;
+			    ex:notBlank ex:x, "x", [] .`,
+			expected: []string{
+				`ex:a NodeKind ex:blank ex:x`,
+				`ex:a NodeKind ex:iri "x"`,
+				`ex:a NodeKind ex:literal ex:x`,
+				`ex:a NodeKind ex:node "x"`,
+				`ex:a NodeKind ex:notBlank _`,
+				`ex:a NodeKind ex:notIRI ex:x`,
+			},
+		},
+
+		// Cardinality constraint components
+		{
+			name: "sh:minCount and sh:maxCount",
+			shapes: `ex:S sh:targetNode ex:none, ex:one, ex:two ;
+			    sh:property [ sh:path ex:p ; sh:minCount 1 ; sh:maxCount 1 ] .`,
+			data:     `ex:one ex:p 1 . ex:two ex:p 1, 2 .`,
+			expected: []string{`ex:none MinCount ex:p -`, `ex:two MaxCount ex:p -`},
+		},
+
+		// Value range constraint components
+		{
+			name: "value ranges",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:exclusive ; sh:minExclusive 1 ; sh:maxExclusive 3 ] ;
+			    sh:property [ sh:path ex:inclusive ; sh:minInclusive 1 ; sh:maxInclusive 3 ] ;
+			    sh:property [ sh:path ex:date ; sh:minInclusive "2024-01-01"^^xsd:date ] .`,
+			data: `ex:a ex:exclusive 1, 2, 3 ;
+			    ex:inclusive 0, 1, 3, 3.5, "2" ;
+			    ex:date "2023-12-31"^^xsd:date, "2024-01-01"^^xsd:date .`,
+			expected: []string{
+				`ex:a MaxExclusive ex:exclusive "3"^^<http://www.w3.org/2001/XMLSchema#integer>`,
+				`ex:a MaxInclusive ex:inclusive "2"`,
+				`ex:a MaxInclusive ex:inclusive "3.5"^^<http://www.w3.org/2001/XMLSchema#decimal>`,
+				`ex:a MinExclusive ex:exclusive "1"^^<http://www.w3.org/2001/XMLSchema#integer>`,
+				`ex:a MinInclusive ex:date "2023-12-31"^^<http://www.w3.org/2001/XMLSchema#date>`,
+				`ex:a MinInclusive ex:inclusive "0"^^<http://www.w3.org/2001/XMLSchema#integer>`,
+				`ex:a MinInclusive ex:inclusive "2"`,
+			},
+		},
+
+		// String based constraint components
+		{
+			name: "sh:minLength and sh:maxLength",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:p ; sh:minLength 2 ; sh:maxLength 3 ] .`,
+			data: `ex:a ex:p "a", "ab", "äöü", "abcd", [] .`,
+			expected: []string{
+				`ex:a MaxLength ex:p "abcd"`,
+				`ex:a MaxLength ex:p _`,
+				`ex:a MinLength ex:p "a"`,
+				`ex:a MinLength ex:p _`,
+			},
+		},
+		{
+			name: "sh:pattern with flags",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:p ; sh:pattern "^ab" ; sh:flags "i" ] .`,
+			data:     `ex:a ex:p "ABC", "abc", "cab", ex:abc .`,
+			expected: []string{`ex:a Pattern ex:p "cab"`, `ex:a Pattern ex:p ex:abc`},
+		},
+		{
+			name: "sh:languageIn",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:p ; sh:languageIn ( "en" "de" ) ] .`,
+			data:     `ex:a ex:p "a"@en, "b"@en-GB, "c"@fr, "d" .`,
+			expected: []string{`ex:a LanguageIn ex:p "c"@fr`, `ex:a LanguageIn ex:p "d"`},
+		},
+		{
+			name: "sh:uniqueLang",
+			shapes: `ex:S sh:targetNode ex:ok, ex:bad ;
+			    sh:property [ sh:path ex:p ; sh:uniqueLang true ] .`,
+			data:     `ex:ok ex:p "a"@en, "b"@de, "c", "d" . ex:bad ex:p "a"@en, "b"@EN .`,
+			expected: []string{`ex:bad UniqueLang ex:p -`},
+		},
+
+		// Property pair constraint components
+		{
+			name: "sh:equals and sh:disjoint",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:p ; sh:equals ex:q ] ;
+			    sh:property [ sh:path ex:p ; sh:disjoint ex:r ] .`,
+			data: `ex:a ex:p 1, 2 ; ex:q 2, 3 ; ex:r 2 .`,
+			expected: []string{
+				`ex:a Disjoint ex:p "2"^^<http://www.w3.org/2001/XMLSchema#integer>`,
+				`ex:a Equals ex:p "1"^^<http://www.w3.org/2001/XMLSchema#integer>`,
+				`ex:a Equals ex:p "3"^^<http://www.w3.org/2001/XMLSchema#integer>`,
+			},
+		},
+		{
+			name: "sh:lessThan and sh:lessThanOrEquals",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:start ; sh:lessThan ex:end ] ;
+			    sh:property [ sh:path ex:low ; sh:lessThanOrEquals ex:high ] .`,
+			data: `ex:a ex:start 1, 5 ; ex:end 5 ; ex:low 1, 5, 6 ; ex:high 5 .`,
+			expected: []string{
+				`ex:a LessThan ex:start "5"^^<http://www.w3.org/2001/XMLSchema#integer>`,
+				`ex:a LessThanOrEquals ex:low "6"^^<http://www.w3.org/2001/XMLSchema#integer>`,
+			},
+		},
+
+		// Logical constraint components
+		{
+			name: "sh:not",
+			shapes: `ex:S sh:targetNode ex:ok, ex:bad ;
+			    sh:not [ sh:property [ sh:path ex:deleted ; sh:minCount 1 ] ] .`,
+			data:     `ex:ok ex:name "x" . ex:bad ex:deleted true .`,
+			expected: []string{`ex:bad Not - ex:bad`},
+		},
+		{
+			name: "sh:and",
+			shapes: `ex:S sh:targetNode ex:ok, ex:bad ;
+			    sh:and ( [ sh:property [ sh:path ex:a ; sh:minCount 1 ] ] [ sh:property [ sh:path ex:b ; sh:minCount 1 ] ] ) .`,
+			data:     `ex:ok ex:a 1 ; ex:b 1 . ex:bad ex:a 1 .`,
+			expected: []string{`ex:bad And - ex:bad`},
+		},
+		{
+			name: "sh:or",
+			shapes: `ex:S sh:targetNode ex:a, ex:b, ex:none ;
+			    sh:or ( [ sh:property [ sh:path ex:a ; sh:minCount 1 ] ] [ sh:property [ sh:path ex:b ; sh:minCount 1 ] ] ) .`,
+			data:     `ex:a ex:a 1 . ex:b ex:b 1 . ex:none ex:c 1 .`,
+			expected: []string{`ex:none Or - ex:none`},
+		},
+		{
+			name: "sh:xone",
+			shapes: `ex:S sh:targetNode ex:one, ex:both, ex:none ;
+			    sh:xone ( [ sh:property [ sh:path ex:a ; sh:minCount 1 ] ] [ sh:property [ sh:path ex:b ; sh:minCount 1 ] ] ) .`,
+			data:     `ex:one ex:a 1 . ex:both ex:a 1 ; ex:b 1 . ex:none ex:c 1 .`,
+			expected: []string{`ex:both Xone - ex:both`, `ex:none Xone - ex:none`},
+		},
+
+		// Shape-based constraint components
+		{
+			name: "sh:node",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:address ; sh:node ex:AddressShape ] .
+ex:AddressShape sh:property [ sh:path ex:city ; sh:minCount 1 ; sh:datatype xsd:string ] .`,
+			data: `ex:a ex:address ex:home, ex:work, ex:nowhere .
+ex:home ex:city "Berlin" .
+ex:work ex:city 42 .`,
+			// Results of the nested shape are not reported, only the sh:node result
+			expected: []string{`ex:a Node ex:address ex:nowhere`, `ex:a Node ex:address ex:work`},
+		},
+		{
+			name: "sh:node on a node shape",
+			shapes: `ex:S sh:targetClass ex:Person ; sh:node ex:NamedShape .
+ex:NamedShape sh:property [ sh:path ex:name ; sh:minCount 1 ] .`,
+			data:     `ex:alice a ex:Person ; ex:name "Alice" . ex:bob a ex:Person .`,
+			expected: []string{`ex:bob Node - ex:bob`},
+		},
+		{
+			name: "recursive sh:node",
+			shapes: `ex:S sh:targetNode ex:a ; sh:node ex:ChainShape .
+ex:ChainShape sh:property [ sh:path ex:next ; sh:node ex:ChainShape ] ;
+    sh:property [ sh:path ex:name ; sh:minCount 1 ] .`,
+			data:     `ex:a ex:name "a" ; ex:next ex:b . ex:b ex:name "b" ; ex:next ex:a .`,
+			expected: []string{},
+		},
+		{
+			name: "sh:property nested in a property shape",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:child ; sh:property [ sh:path ex:name ; sh:minCount 1 ] ] .`,
+			data:     `ex:a ex:child ex:b, ex:c . ex:b ex:name "b" .`,
+			expected: []string{`ex:c MinCount ex:name -`},
+		},
+		{
+			name: "sh:qualifiedValueShape",
+			shapes: `ex:S sh:targetNode ex:ok, ex:tooFew, ex:tooMany ;
+			    sh:property [
+			        sh:path ex:parent ;
+			        sh:qualifiedValueShape [ sh:class ex:Female ] ;
+			        sh:qualifiedMinCount 1 ;
+			        sh:qualifiedMaxCount 1
+			    ] .`,
+			data: `ex:mother a ex:Female . ex:aunt a ex:Female . ex:father a ex:Male .
+ex:ok ex:parent ex:mother, ex:father .
+ex:tooFew ex:parent ex:father .
+ex:tooMany ex:parent ex:mother, ex:aunt .`,
+			expected: []string{`ex:tooFew QualifiedMinCount ex:parent -`, `ex:tooMany QualifiedMaxCount ex:parent -`},
+		},
+		{
+			name: "sh:qualifiedValueShapesDisjoint",
+			shapes: `ex:HandShape sh:targetClass ex:Hand ;
+			    sh:property [
+			        sh:path ex:digit ;
+			        sh:qualifiedValueShape [ sh:class ex:Thumb ] ;
+			        sh:qualifiedValueShapesDisjoint true ;
+			        sh:qualifiedMinCount 1 ; sh:qualifiedMaxCount 1
+			    ] ;
+			    sh:property [
+			        sh:path ex:digit ;
+			        sh:qualifiedValueShape [ sh:class ex:Finger ] ;
+			        sh:qualifiedValueShapesDisjoint true ;
+			        sh:qualifiedMinCount 4 ; sh:qualifiedMaxCount 4
+			    ] .`,
+			data: `ex:ok a ex:Hand ; ex:digit ex:thumb, ex:f1, ex:f2, ex:f3, ex:f4 .
+ex:thumb a ex:Thumb . ex:f1 a ex:Finger . ex:f2 a ex:Finger . ex:f3 a ex:Finger . ex:f4 a ex:Finger .
+ex:bad a ex:Hand ; ex:digit ex:both, ex:f1, ex:f2, ex:f3, ex:f4 .
+ex:both a ex:Thumb, ex:Finger .`,
+			// ex:both conforms to both qualified shapes, so it counts for neither
+			expected: []string{`ex:bad QualifiedMinCount ex:digit -`},
+		},
+
+		// Other constraint components
+		{
+			name: "sh:closed with sh:ignoredProperties",
+			shapes: `ex:S sh:targetNode ex:ok, ex:bad ;
+			    sh:closed true ;
+			    sh:ignoredProperties ( rdf:type ) ;
+			    sh:property [ sh:path ex:name ] ;
+			    sh:property [ sh:path [ sh:inversePath ex:knows ] ] .`,
+			data: `ex:ok a ex:Person ; ex:name "ok" .
+ex:bad a ex:Person ; ex:name "bad" ; ex:age 3 ; ex:knows ex:ok .`,
+			// Only IRI paths declare allowed properties
+			expected: []string{`ex:bad Closed ex:age "3"^^<http://www.w3.org/2001/XMLSchema#integer>`, `ex:bad Closed ex:knows ex:ok`},
+		},
+		{
+			name: "sh:hasValue",
+			shapes: `ex:S sh:targetNode ex:ok, ex:bad ;
+			    sh:property [ sh:path ex:status ; sh:hasValue "active" ] .`,
+			data:     `ex:ok ex:status "active", "new" . ex:bad ex:status "inactive" .`,
+			expected: []string{`ex:bad HasValue ex:status -`},
+		},
+		{
+			name:     "sh:hasValue on a node shape",
+			shapes:   `ex:S sh:targetNode ex:a, ex:b ; sh:hasValue ex:a .`,
+			expected: []string{`ex:b HasValue - -`},
+		},
+		{
+			name: "sh:in",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:color ; sh:in ( ex:Red "green" 1 ) ] .`,
+			data:     `ex:a ex:color ex:Red, "green", 1, "1", ex:Blue .`,
+			expected: []string{`ex:a In ex:color "1"`, `ex:a In ex:color ex:Blue`},
+		},
+
+		// Targets, severities and deactivation
+		{
+			name: "targets",
+			shapes: `ex:ByClass sh:targetClass ex:Thing ; sh:nodeKind sh:BlankNode .
+ex:BySubject sh:targetSubjectsOf ex:subjectOf ; sh:nodeKind sh:BlankNode .
+ex:ByObject sh:targetObjectsOf ex:objectOf ; sh:nodeKind sh:BlankNode .
+ex:Implicit a sh:NodeShape, rdfs:Class ; sh:nodeKind sh:BlankNode .`,
+			data: `ex:SubThing rdfs:subClassOf ex:Thing .
+ex:t a ex:SubThing .
+ex:s ex:subjectOf 1 .
+ex:x ex:objectOf ex:o .
+ex:i a ex:Implicit .`,
+			expected: []string{`ex:i NodeKind - ex:i`, `ex:o NodeKind - ex:o`, `ex:s NodeKind - ex:s`, `ex:t NodeKind - ex:t`},
+		},
+		{
+			name: "sh:deactivated",
+			shapes: `ex:S sh:targetNode ex:a ;
+			    sh:property [ sh:path ex:p ; sh:minCount 1 ; sh:deactivated true ] ;
+			    sh:property [ sh:path ex:q ; sh:minCount 1 ] .`,
+			expected: []string{`ex:a MinCount ex:q -`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shapes, err := LoadSHACLShapes(prefixes + tt.shapes)
+			require.NoError(t, err)
+			report := shapes.Validate(mustParseTurtle(t, prefixes+tt.data))
+
+			expected := append([]string{}, tt.expected...)
+			sort.Strings(expected)
+			assert.Equal(t, expected, shaclResultKeys(report))
+			assert.Equal(t, len(expected) == 0, report.Conforms)
+		})
+	}
+}
+
+// TestSHACLPropertyPaths checks each kind of SHACL property path against the
+// value nodes it selects
+func TestSHACLPropertyPaths(t *testing.T) {
+	data := mustParseTurtle(t, `@prefix ex: <http://example.org/> .
+ex:a ex:child ex:b ; ex:friend ex:f .
+ex:b ex:child ex:c ; ex:friend ex:g .
+ex:c ex:child ex:d .
+ex:f ex:name "f" .
+ex:g ex:name "g" .`)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{"predicate", `ex:child`, []string{"b"}},
+		{"sequence", `( ex:child ex:friend ex:name )`, []string{`"g"`}},
+		{"alternative", `[ sh:alternativePath ( ex:child ex:friend ) ]`, []string{"b", "f"}},
+		{"inverse", `[ sh:inversePath ex:child ]`, nil},
+		{"inverse of sequence", `[ sh:inversePath ( ex:child ex:child ) ]`, nil},
+		{"zero or more", `[ sh:zeroOrMorePath ex:child ]`, []string{"a", "b", "c", "d"}},
+		{"one or more", `[ sh:oneOrMorePath ex:child ]`, []string{"b", "c", "d"}},
+		{"zero or one", `[ sh:zeroOrOnePath ex:child ]`, []string{"a", "b"}},
+		{"sequence of closure and predicate", `( [ sh:oneOrMorePath ex:child ] ex:friend )`, []string{"g"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// sh:in ( ) rejects every value node, so the results list the values
+			shapes, err := LoadSHACLShapes(`@prefix sh: <http://www.w3.org/ns/shacl#> .
+@prefix ex: <http://example.org/> .
+ex:S sh:targetNode ex:a ; sh:property [ sh:path ` + tt.path + ` ; sh:in ( ) ] .`)
+			require.NoError(t, err)
+
+			var values []string
+			for _, result := range shapes.Validate(data).Results {
+				values = append(values, strings.TrimSuffix(strings.TrimPrefix(result.Value.String(), "<http://example.org/"), ">"))
+			}
+			sort.Strings(values)
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+
+	t.Run("inverse paths from a child", func(t *testing.T) {
+		shapes, err := LoadSHACLShapes(`@prefix sh: <http://www.w3.org/ns/shacl#> .
+@prefix ex: <http://example.org/> .
+ex:S sh:targetNode ex:c ;
+    sh:property [ sh:path [ sh:inversePath ex:child ] ; sh:in ( ) ] ;
+    sh:property [ sh:path [ sh:inversePath ( ex:child ex:child ) ] ; sh:in ( ) ] .`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			`ex:c In _ ex:a`,
+			`ex:c In _ ex:b`,
+		}, shaclResultKeys(shapes.Validate(data)))
+	})
+}
@@ -0,0 +1,781 @@
+package db
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// rdfGraph indexes statements by subject and by object. Graph names are ignored.
+type rdfGraph struct {
+	out map[Term]map[string][]Term // Subject -> predicate -> objects
+	in  map[Term]map[string][]Term // Object -> predicate -> subjects
+}
+
+func newRDFGraph(quads []Quad) *rdfGraph {
+	g := &rdfGraph{out: make(map[Term]map[string][]Term), in: make(map[Term]map[string][]Term)}
+	seen := make(map[[3]Term]bool, len(quads))
+	for _, quad := range quads {
+		key := [3]Term{quad.Subject, quad.Predicate, quad.Object}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if g.out[quad.Subject] == nil {
+			g.out[quad.Subject] = make(map[string][]Term)
+		}
+		g.out[quad.Subject][quad.Predicate.Value] = append(g.out[quad.Subject][quad.Predicate.Value], quad.Object)
+		if g.in[quad.Object] == nil {
+			g.in[quad.Object] = make(map[string][]Term)
+		}
+		g.in[quad.Object][quad.Predicate.Value] = append(g.in[quad.Object][quad.Predicate.Value], quad.Subject)
+	}
+	return g
+}
+
+func (g *rdfGraph) objects(subject Term, predicate string) []Term {
+	return g.out[subject][predicate]
+}
+
+func (g *rdfGraph) subjects(predicate string, object Term) []Term {
+	return g.in[object][predicate]
+}
+
+func (g *rdfGraph) object(subject Term, predicate string) (Term, bool) {
+	objects := g.out[subject][predicate]
+	if len(objects) == 0 {
+		return Term{}, false
+	}
+	return objects[0], true
+}
+
+func (g *rdfGraph) has(subject Term, predicate string, object Term) bool {
+	for _, o := range g.out[subject][predicate] {
+		if o == object {
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the members of an RDF collection
+func (g *rdfGraph) list(head Term) []Term {
+	var items []Term
+	seen := make(map[Term]bool)
+	for head.Value != RDFNil && !seen[head] {
+		seen[head] = true
+		first, ok := g.object(head, RDFFirst)
+		if !ok {
+			break
+		}
+		items = append(items, first)
+		if head, ok = g.object(head, RDFRest); !ok {
+			break
+		}
+	}
+	return items
+}
+
+// describe returns the statements reachable from a blank node, used to copy
+// complex paths into validation reports
+func (g *rdfGraph) describe(node Term) []Quad {
+	var quads []Quad
+	seen := make(map[Term]bool)
+	var walk func(Term)
+	walk = func(n Term) {
+		if !n.IsBlankNode() || seen[n] {
+			return
+		}
+		seen[n] = true
+		for _, predicate := range sortedKeys(g.out[n]) {
+			for _, object := range g.out[n][predicate] {
+				quads = append(quads, Quad{Subject: n, Predicate: NewIRI(predicate), Object: object})
+				walk(object)
+			}
+		}
+	}
+	walk(node)
+	return quads
+}
+
+// shaclValidator validates a data graph against a shapes graph
+type shaclValidator struct {
+	shapes     *rdfGraph
+	data       *rdfGraph
+	inProgress map[[2]Term]bool // Node and shape pairs being checked, to stop recursive shapes
+}
+
+// validateShape returns the results of checking focus against shape
+func (v *shaclValidator) validateShape(focus, shape Term) []SHACLResult {
+	if deactivated, ok := v.shapes.object(shape, shNS+"deactivated"); ok && deactivated.Value == "true" {
+		return nil
+	}
+
+	path, isPropertyShape := v.shapes.object(shape, shNS+"path")
+	values := []Term{focus}
+	if isPropertyShape {
+		values = v.evalPath(focus, path)
+	}
+
+	c := &shaclConstraintCheck{v: v, focus: focus, shape: shape, values: values}
+	if isPropertyShape {
+		c.path = path
+	}
+	c.run()
+	return c.results
+}
+
+// conforms reports whether node conforms to shape
+func (v *shaclValidator) conforms(node, shape Term) bool {
+	key := [2]Term{node, shape}
+	if v.inProgress[key] {
+		return true
+	}
+	v.inProgress[key] = true
+	defer delete(v.inProgress, key)
+	return len(v.validateShape(node, shape)) == 0
+}
+
+// targetNodes returns the focus nodes selected by a shape's targets
+func (v *shaclValidator) targetNodes(shape Term) []Term {
+	var nodes []Term
+	seen := make(map[Term]bool)
+	add := func(terms ...Term) {
+		for _, t := range terms {
+			if !seen[t] {
+				seen[t] = true
+				nodes = append(nodes, t)
+			}
+		}
+	}
+
+	add(v.shapes.objects(shape, shNS+"targetNode")...)
+	classes := v.shapes.objects(shape, shNS+"targetClass")
+	if v.shapes.has(shape, RDFType, NewIRI(RDFSNamespace+"Class")) {
+		classes = append(classes, shape)
+	}
+	for _, class := range classes {
+		for _, c := range v.subClasses(class) {
+			add(v.data.subjects(RDFType, c)...)
+		}
+	}
+	for _, predicate := range v.shapes.objects(shape, shNS+"targetSubjectsOf") {
+		for subject, predicates := range v.data.out {
+			if len(predicates[predicate.Value]) > 0 {
+				add(subject)
+			}
+		}
+	}
+	for _, predicate := range v.shapes.objects(shape, shNS+"targetObjectsOf") {
+		for _, objects := range v.data.out {
+			add(objects[predicate.Value]...)
+		}
+	}
+	sortTerms(nodes)
+	return nodes
+}
+
+// subClasses returns class and its transitive subclasses in the data graph
+func (v *shaclValidator) subClasses(class Term) []Term {
+	classes := []Term{class}
+	seen := map[Term]bool{class: true}
+	for i := 0; i < len(classes); i++ {
+		for _, sub := range v.data.subjects(RDFSNamespace+"subClassOf", classes[i]) {
+			if !seen[sub] {
+				seen[sub] = true
+				classes = append(classes, sub)
+			}
+		}
+	}
+	return classes
+}
+
+// isInstance reports whether node is a SHACL instance of class
+func (v *shaclValidator) isInstance(node, class Term) bool {
+	for _, c := range v.subClasses(class) {
+		if v.data.has(node, RDFType, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// evalPath returns the value nodes reachable from focus via a SHACL path
+func (v *shaclValidator) evalPath(focus, path Term) []Term {
+	return uniqueTerms(v.evalPathFrom([]Term{focus}, path))
+}
+
+func (v *shaclValidator) evalPathFrom(nodes []Term, path Term) []Term {
+	if path.IsIRI() {
+		var result []Term
+		for _, n := range nodes {
+			result = append(result, v.data.objects(n, path.Value)...)
+		}
+		return result
+	}
+
+	if _, ok := v.shapes.object(path, RDFFirst); ok {
+		for _, step := range v.shapes.list(path) {
+			nodes = uniqueTerms(v.evalPathFrom(nodes, step))
+		}
+		return nodes
+	}
+	if alternatives, ok := v.shapes.object(path, shNS+"alternativePath"); ok {
+		var result []Term
+		for _, alternative := range v.shapes.list(alternatives) {
+			result = append(result, v.evalPathFrom(nodes, alternative)...)
+		}
+		return result
+	}
+	if inverse, ok := v.shapes.object(path, shNS+"inversePath"); ok {
+		var result []Term
+		for _, n := range nodes {
+			if inverse.IsIRI() {
+				result = append(result, v.data.subjects(inverse.Value, n)...)
+				continue
+			}
+			for candidate := range v.data.out {
+				for _, reached := range v.evalPath(candidate, inverse) {
+					if reached == n {
+						result = append(result, candidate)
+						break
+					}
+				}
+			}
+		}
+		return result
+	}
+	if inner, ok := v.shapes.object(path, shNS+"zeroOrMorePath"); ok {
+		return v.closure(nodes, inner, true)
+	}
+	if inner, ok := v.shapes.object(path, shNS+"oneOrMorePath"); ok {
+		return v.closure(nodes, inner, false)
+	}
+	if inner, ok := v.shapes.object(path, shNS+"zeroOrOnePath"); ok {
+		return append(append([]Term{}, nodes...), v.evalPathFrom(nodes, inner)...)
+	}
+	return nil
+}
+
+// closure follows path repeatedly, including the start nodes if reflexive
+func (v *shaclValidator) closure(nodes []Term, path Term, reflexive bool) []Term {
+	var result []Term
+	seen := make(map[Term]bool)
+	if reflexive {
+		for _, n := range nodes {
+			seen[n] = true
+			result = append(result, n)
+		}
+	}
+	frontier := nodes
+	for len(frontier) > 0 {
+		var next []Term
+		for _, n := range v.evalPathFrom(frontier, path) {
+			if !seen[n] {
+				seen[n] = true
+				result = append(result, n)
+				next = append(next, n)
+			}
+		}
+		frontier = next
+	}
+	return result
+}
+
+// shaclConstraintCheck evaluates the constraints of one shape for one focus node
+type shaclConstraintCheck struct {
+	v       *shaclValidator
+	focus   Term
+	shape   Term
+	path    Term // Zero for node shapes
+	values  []Term
+	results []SHACLResult
+}
+
+func (c *shaclConstraintCheck) param(name string) (Term, bool) {
+	return c.v.shapes.object(c.shape, shNS+name)
+}
+
+func (c *shaclConstraintCheck) params(name string) []Term {
+	return c.v.shapes.objects(c.shape, shNS+name)
+}
+
+// fail records a result for a constraint component
+func (c *shaclConstraintCheck) fail(component string, value Term, message string) {
+	severity := shNS + "Violation"
+	if s, ok := c.param("severity"); ok {
+		severity = s.Value
+	}
+	if messages := c.params("message"); len(messages) > 0 {
+		message = messages[0].Value
+		for _, m := range messages {
+			if m.Language == "" || m.Language == "en" {
+				message = m.Value
+				break
+			}
+		}
+	}
+
+	result := SHACLResult{
+		FocusNode:                 c.focus,
+		ResultPath:                c.path,
+		Value:                     value,
+		SourceShape:               c.shape,
+		SourceConstraintComponent: shNS + component + "ConstraintComponent",
+		Severity:                  severity,
+		Message:                   message,
+	}
+	if c.path.IsBlankNode() {
+		result.pathQuads = c.v.shapes.describe(c.path)
+	}
+	c.results = append(c.results, result)
+}
+
+// eachValue records a result for every value node failing check
+func (c *shaclConstraintCheck) eachValue(component string, check func(Term) bool, message func(Term) string) {
+	for _, value := range c.values {
+		if !check(value) {
+			c.fail(component, value, message(value))
+		}
+	}
+}
+
+func (c *shaclConstraintCheck) run() {
+	for _, class := range c.params("class") {
+		c.eachValue("Class", func(value Term) bool { return c.v.isInstance(value, class) },
+			func(value Term) string { return fmt.Sprintf("Value %s is not an instance of %s", value, class) })
+	}
+	for _, datatype := range c.params("datatype") {
+		c.eachValue("Datatype", func(value Term) bool { return hasDatatype(value, datatype.Value) },
+			func(value Term) string { return fmt.Sprintf("Value %s does not have datatype %s", value, datatype) })
+	}
+	for _, kind := range c.params("nodeKind") {
+		c.eachValue("NodeKind", func(value Term) bool { return hasNodeKind(value, kind.Value) },
+			func(value Term) string { return fmt.Sprintf("Value %s is not of node kind %s", value, kind) })
+	}
+
+	if !c.path.IsZero() {
+		if minCount, ok := c.param("minCount"); ok {
+			if n, err := strconv.Atoi(minCount.Value); err == nil && len(c.values) < n {
+				c.fail("MinCount", Term{}, fmt.Sprintf("Less than %d values", n))
+			}
+		}
+		if maxCount, ok := c.param("maxCount"); ok {
+			if n, err := strconv.Atoi(maxCount.Value); err == nil && len(c.values) > n {
+				c.fail("MaxCount", Term{}, fmt.Sprintf("More than %d values", n))
+			}
+		}
+	}
+
+	ranges := []struct {
+		name    string
+		allowed func(int) bool
+		op      string
+	}{
+		{"minExclusive", func(cmp int) bool { return cmp > 0 }, ">"},
+		{"minInclusive", func(cmp int) bool { return cmp >= 0 }, ">="},
+		{"maxExclusive", func(cmp int) bool { return cmp < 0 }, "<"},
+		{"maxInclusive", func(cmp int) bool { return cmp <= 0 }, "<="},
+	}
+	for _, r := range ranges {
+		for _, bound := range c.params(r.name) {
+			component := strings.ToUpper(r.name[:1]) + r.name[1:]
+			c.eachValue(component, func(value Term) bool {
+				cmp, ok := compareLiterals(value, bound)
+				return ok && r.allowed(cmp)
+			}, func(value Term) string { return fmt.Sprintf("Value is not %s %s", r.op, bound.Value) })
+		}
+	}
+
+	for _, minLength := range c.params("minLength") {
+		n, _ := strconv.Atoi(minLength.Value)
+		c.eachValue("MinLength", func(value Term) bool {
+			return !value.IsBlankNode() && utf8.RuneCountInString(value.Value) >= n
+		}, func(Term) string { return fmt.Sprintf("Value has less than %d characters", n) })
+	}
+	for _, maxLength := range c.params("maxLength") {
+		n, _ := strconv.Atoi(maxLength.Value)
+		c.eachValue("MaxLength", func(value Term) bool {
+			return !value.IsBlankNode() && utf8.RuneCountInString(value.Value) <= n
+		}, func(Term) string { return fmt.Sprintf("Value has more than %d characters", n) })
+	}
+	for _, pattern := range c.params("pattern") {
+		re, err := shaclRegexp(pattern.Value, c.params("flags"))
+		c.eachValue("Pattern", func(value Term) bool {
+			return err == nil && !value.IsBlankNode() && re.MatchString(value.Value)
+		}, func(Term) string { return fmt.Sprintf("Value does not match pattern %q", pattern.Value) })
+	}
+	for _, languages := range c.params("languageIn") {
+		ranges := c.v.shapes.list(languages)
+		c.eachValue("LanguageIn", func(value Term) bool {
+			for _, r := range ranges {
+				if value.Language != "" && langMatches(value.Language, r.Value) {
+					return true
+				}
+			}
+			return false
+		}, func(Term) string { return "Language tag not allowed" })
+	}
+	if uniqueLang, ok := c.param("uniqueLang"); ok && uniqueLang.Value == "true" && !c.path.IsZero() {
+		counts := make(map[string]int)
+		var order []string
+		for _, value := range c.values {
+			if value.Language == "" {
+				continue
+			}
+			tag := strings.ToLower(value.Language)
+			if counts[tag] == 0 {
+				order = append(order, tag)
+			}
+			counts[tag]++
+		}
+		for _, tag := range order {
+			if counts[tag] > 1 {
+				c.fail("UniqueLang", Term{}, fmt.Sprintf("Language %q used more than once", tag))
+			}
+		}
+	}
+
+	c.propertyPairs()
+	c.logical()
+
+	for _, node := range c.params("node") {
+		c.eachValue("Node", func(value Term) bool { return c.v.conforms(value, node) },
+			func(value Term) string { return fmt.Sprintf("Value does not conform to shape %s", node) })
+	}
+	for _, property := range c.params("property") {
+		for _, value := range c.values {
+			c.results = append(c.results, c.v.validateShape(value, property)...)
+		}
+	}
+	c.qualified()
+	c.closed()
+
+	for _, hasValue := range c.params("hasValue") {
+		if !containsTerm(c.values, hasValue) {
+			c.fail("HasValue", Term{}, fmt.Sprintf("Missing expected value %s", hasValue))
+		}
+	}
+	for _, in := range c.params("in") {
+		allowed := c.v.shapes.list(in)
+		c.eachValue("In", func(value Term) bool { return containsTerm(allowed, value) },
+			func(value Term) string { return fmt.Sprintf("Value %s is not in the list of allowed values", value) })
+	}
+}
+
+// propertyPairs evaluates sh:equals, sh:disjoint, sh:lessThan and sh:lessThanOrEquals
+func (c *shaclConstraintCheck) propertyPairs() {
+	for _, property := range c.params("equals") {
+		others := c.v.data.objects(c.focus, property.Value)
+		for _, value := range c.values {
+			if !containsTerm(others, value) {
+				c.fail("Equals", value, fmt.Sprintf("Value is not a value of %s", property))
+			}
+		}
+		for _, other := range others {
+			if !containsTerm(c.values, other) {
+				c.fail("Equals", other, fmt.Sprintf("Value of %s is missing", property))
+			}
+		}
+	}
+	for _, property := range c.params("disjoint") {
+		others := c.v.data.objects(c.focus, property.Value)
+		c.eachValue("Disjoint", func(value Term) bool { return !containsTerm(others, value) },
+			func(Term) string { return fmt.Sprintf("Value is also a value of %s", property) })
+	}
+	for _, name := range []string{"lessThan", "lessThanOrEquals"} {
+		for _, property := range c.params(name) {
+			others := c.v.data.objects(c.focus, property.Value)
+			component := strings.ToUpper(name[:1]) + name[1:]
+			for _, value := range c.values {
+				for _, other := range others {
+					cmp, ok := compareLiterals(value, other)
+					if !ok || cmp > 0 || (cmp == 0 && name == "lessThan") {
+						c.fail(component, value, fmt.Sprintf("Value is not %s value %s of %s", map[string]string{"lessThan": "less than", "lessThanOrEquals": "less than or equal to"}[name], other, property))
+					}
+				}
+			}
+		}
+	}
+}
+
+// logical evaluates sh:not, sh:and, sh:or and sh:xone
+func (c *shaclConstraintCheck) logical() {
+	for _, shape := range c.params("not") {
+		c.eachValue("Not", func(value Term) bool { return !c.v.conforms(value, shape) },
+			func(Term) string { return fmt.Sprintf("Value conforms to shape %s", shape) })
+	}
+	for _, list := range c.params("and") {
+		shapes := c.v.shapes.list(list)
+		c.eachValue("And", func(value Term) bool {
+			for _, shape := range shapes {
+				if !c.v.conforms(value, shape) {
+					return false
+				}
+			}
+			return true
+		}, func(Term) string { return "Value does not conform to all shapes" })
+	}
+	for _, list := range c.params("or") {
+		shapes := c.v.shapes.list(list)
+		c.eachValue("Or", func(value Term) bool {
+			for _, shape := range shapes {
+				if c.v.conforms(value, shape) {
+					return true
+				}
+			}
+			return false
+		}, func(Term) string { return "Value does not conform to any shape" })
+	}
+	for _, list := range c.params("xone") {
+		shapes := c.v.shapes.list(list)
+		c.eachValue("Xone", func(value Term) bool {
+			matches := 0
+			for _, shape := range shapes {
+				if c.v.conforms(value, shape) {
+					matches++
+				}
+			}
+			return matches == 1
+		}, func(Term) string { return "Value does not conform to exactly one shape" })
+	}
+}
+
+// qualified evaluates sh:qualifiedValueShape with its min and max counts
+func (c *shaclConstraintCheck) qualified() {
+	if c.path.IsZero() {
+		return
+	}
+	for _, qualifiedShape := range c.params("qualifiedValueShape") {
+		// With sh:qualifiedValueShapesDisjoint, values conforming to a sibling
+		// qualified shape of the same parent do not count
+		var siblings []Term
+		if disjoint, ok := c.param("qualifiedValueShapesDisjoint"); ok && disjoint.Value == "true" {
+			for _, parent := range c.v.shapes.subjects(shNS+"property", c.shape) {
+				for _, property := range c.v.shapes.objects(parent, shNS+"property") {
+					for _, sibling := range c.v.shapes.objects(property, shNS+"qualifiedValueShape") {
+						if sibling != qualifiedShape {
+							siblings = append(siblings, sibling)
+						}
+					}
+				}
+			}
+		}
+
+		count := 0
+		for _, value := range c.values {
+			if !c.v.conforms(value, qualifiedShape) {
+				continue
+			}
+			conformsToSibling := false
+			for _, sibling := range siblings {
+				if c.v.conforms(value, sibling) {
+					conformsToSibling = true
+					break
+				}
+			}
+			if !conformsToSibling {
+				count++
+			}
+		}
+		if min, ok := c.param("qualifiedMinCount"); ok {
+			if n, err := strconv.Atoi(min.Value); err == nil && count < n {
+				c.fail("QualifiedMinCount", Term{}, fmt.Sprintf("Less than %d values conform to %s", n, qualifiedShape))
+			}
+		}
+		if max, ok := c.param("qualifiedMaxCount"); ok {
+			if n, err := strconv.Atoi(max.Value); err == nil && count > n {
+				c.fail("QualifiedMaxCount", Term{}, fmt.Sprintf("More than %d values conform to %s", n, qualifiedShape))
+			}
+		}
+	}
+}
+
+// closed evaluates sh:closed: value nodes may only use the properties
+// declared by the shape's property shapes and sh:ignoredProperties
+func (c *shaclConstraintCheck) closed() {
+	closed, ok := c.param("closed")
+	if !ok || closed.Value != "true" {
+		return
+	}
+	allowed := make(map[string]bool)
+	for _, property := range c.params("property") {
+		if path, ok := c.v.shapes.object(property, shNS+"path"); ok && path.IsIRI() {
+			allowed[path.Value] = true
+		}
+	}
+	for _, ignored := range c.params("ignoredProperties") {
+		for _, property := range c.v.shapes.list(ignored) {
+			allowed[property.Value] = true
+		}
+	}
+
+	for _, value := range c.values {
+		for _, predicate := range sortedKeys(c.v.data.out[value]) {
+			if allowed[predicate] {
+				continue
+			}
+			for _, object := range c.v.data.out[value][predicate] {
+				result := len(c.results)
+				c.fail("Closed", object, fmt.Sprintf("Property %s is not allowed", NewIRI(predicate)))
+				c.results[result].FocusNode = value
+				c.results[result].ResultPath = NewIRI(predicate)
+				c.results[result].pathQuads = nil
+			}
+		}
+	}
+}
+
+// hasDatatype reports whether value is a well-formed literal of datatype
+func hasDatatype(value Term, datatype string) bool {
+	if !value.IsLiteral() {
+		return false
+	}
+	if value.Datatype != datatype && !(datatype == XSDString && value.Datatype == "") {
+		return false
+	}
+	return isValidLexical(value)
+}
+
+var (
+	xsdIntegerLexical = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	xsdDecimalLexical = regexp.MustCompile(`^[+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)$`)
+	xsdDoubleLexical  = regexp.MustCompile(`^(?:[+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)(?:[eE][+-]?[0-9]+)?|[+-]?INF|NaN)$`)
+)
+
+// isValidLexical checks the lexical form of common XSD datatypes; other
+// datatypes are accepted as is
+func isValidLexical(value Term) bool {
+	switch {
+	case xsdIntegerTypes[value.Datatype]:
+		return xsdIntegerLexical.MatchString(value.Value)
+	case value.Datatype == XSDDecimal:
+		return xsdDecimalLexical.MatchString(value.Value)
+	case value.Datatype == XSDDouble || value.Datatype == XSDFloat:
+		return xsdDoubleLexical.MatchString(value.Value)
+	case value.Datatype == XSDBoolean:
+		switch value.Value {
+		case "true", "false", "1", "0":
+			return true
+		}
+		return false
+	case value.Datatype == XSDDate:
+		_, err := time.Parse("2006-01-02", strings.TrimSuffix(value.Value, "Z"))
+		return err == nil
+	case value.Datatype == XSDDateTime:
+		_, ok := value.Native().(time.Time)
+		return ok
+	}
+	return true
+}
+
+// hasNodeKind reports whether value matches an sh:nodeKind value
+func hasNodeKind(value Term, kind string) bool {
+	switch strings.TrimPrefix(kind, shNS) {
+	case "IRI":
+		return value.IsIRI()
+	case "BlankNode":
+		return value.IsBlankNode()
+	case "Literal":
+		return value.IsLiteral()
+	case "BlankNodeOrIRI":
+		return value.IsBlankNode() || value.IsIRI()
+	case "BlankNodeOrLiteral":
+		return value.IsBlankNode() || value.IsLiteral()
+	case "IRIOrLiteral":
+		return value.IsIRI() || value.IsLiteral()
+	}
+	return false
+}
+
+// compareLiterals orders two literals of comparable datatypes
+func compareLiterals(a, b Term) (int, bool) {
+	if !a.IsLiteral() || !b.IsLiteral() || !isValidLexical(a) || !isValidLexical(b) {
+		return 0, false
+	}
+	numeric := func(t Term) bool {
+		return xsdIntegerTypes[t.Datatype] || t.Datatype == XSDDecimal || t.Datatype == XSDDouble || t.Datatype == XSDFloat
+	}
+	switch {
+	case numeric(a) && numeric(b):
+		x, okA := new(big.Float).SetString(strings.TrimPrefix(a.Value, "+"))
+		y, okB := new(big.Float).SetString(strings.TrimPrefix(b.Value, "+"))
+		if !okA || !okB {
+			return 0, false
+		}
+		return x.Cmp(y), true
+	case (a.Datatype == XSDDateTime || a.Datatype == XSDDate) && a.Datatype == b.Datatype:
+		x, errA := parseXSDTime(a)
+		y, errB := parseXSDTime(b)
+		if errA != nil || errB != nil {
+			return 0, false
+		}
+		return x.Compare(y), true
+	case a.Datatype == b.Datatype && a.Language == b.Language:
+		return strings.Compare(a.Value, b.Value), true
+	}
+	return 0, false
+}
+
+func parseXSDTime(t Term) (time.Time, error) {
+	if t.Datatype == XSDDate {
+		return time.Parse("2006-01-02", strings.TrimSuffix(t.Value, "Z"))
+	}
+	if native, ok := t.Native().(time.Time); ok {
+		return native, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid dateTime %q", t.Value)
+}
+
+// shaclRegexp compiles an sh:pattern with its sh:flags
+func shaclRegexp(pattern string, flags []Term) (*regexp.Regexp, error) {
+	prefix := ""
+	for _, flag := range flags {
+		for _, f := range flag.Value {
+			if strings.ContainsRune("ims", f) && !strings.ContainsRune(prefix, f) {
+				prefix += string(f)
+			}
+		}
+	}
+	if prefix != "" {
+		pattern = "(?" + prefix + ")" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// langMatches implements basic language range matching
+func langMatches(tag, languageRange string) bool {
+	tag, languageRange = strings.ToLower(tag), strings.ToLower(languageRange)
+	return languageRange == "*" || tag == languageRange || strings.HasPrefix(tag, languageRange+"-")
+}
+
+func containsTerm(terms []Term, t Term) bool {
+	for _, candidate := range terms {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueTerms(terms []Term) []Term {
+	seen := make(map[Term]bool, len(terms))
+	unique := terms[:0:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+// sortTerms orders terms by their N-Triples form
+func sortTerms(terms []Term) {
+	sort.Slice(terms, func(i, j int) bool { return terms[i].String() < terms[j].String() })
+}
@@ -0,0 +1,628 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SPARQL protocol media types
+const (
+	SPARQLResultsJSON = "application/sparql-results+json"
+	NTriplesMediaType = "application/n-triples"
+	NQuadsMediaType   = "application/n-quads"
+)
+
+// maxSPARQLErrorBody limits how much of an error response is kept in SPARQLError
+const maxSPARQLErrorBody = 64 * 1024
+
+// ErrSPARQLUpdateUnsupported is returned by SPARQLClient.Update for stores
+// without an update endpoint
+var ErrSPARQLUpdateUnsupported = errors.New("store does not support SPARQL updates")
+
+// SPARQLError is returned when a SPARQL endpoint rejects a request
+type SPARQLError struct {
+	StatusCode int    // HTTP status code
+	Endpoint   string // Endpoint URL
+	Message    string // Response body returned by the store
+}
+
+func (e *SPARQLError) Error() string {
+	return fmt.Sprintf("SPARQL request to %s failed: %d %s: %s", e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// SPARQLStore adapts a triple store to the SPARQL 1.1 protocol by providing
+// its endpoint URLs and authentication. Stores may also implement
+// interface{ HTTPClient() *http.Client } to supply their HTTP client.
+type SPARQLStore interface {
+	// QueryEndpoint returns the URL queries are posted to
+	QueryEndpoint() string
+	// UpdateEndpoint returns the URL updates are posted to, or "" if the
+	// store does not accept updates
+	UpdateEndpoint() string
+	// PrepareRequest adds authentication and store specific headers
+	PrepareRequest(req *http.Request)
+}
+
+// SPARQLEndpointStore is a SPARQLStore for any SPARQL 1.1 protocol endpoint
+type SPARQLEndpointStore struct {
+	QueryURL  string            // Query endpoint
+	UpdateURL string            // Update endpoint ("" if read-only)
+	Username  string            // HTTP Basic Authentication user (optional)
+	Password  string            // HTTP Basic Authentication password
+	Headers   map[string]string // Additional request headers, e.g. bearer tokens
+}
+
+// QueryEndpoint implements SPARQLStore
+func (s *SPARQLEndpointStore) QueryEndpoint() string { return s.QueryURL }
+
+// UpdateEndpoint implements SPARQLStore
+func (s *SPARQLEndpointStore) UpdateEndpoint() string { return s.UpdateURL }
+
+// PrepareRequest implements SPARQLStore
+func (s *SPARQLEndpointStore) PrepareRequest(req *http.Request) {
+	if s.Username != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+	for key, value := range s.Headers {
+		req.Header.Set(key, value)
+	}
+}
+
+// GraphDBStore is the SPARQLStore of a GraphDB repository. Requests use the
+// package level HttpClient, so a Ziti client set up with GraphDBZitiClient
+// applies to SPARQL requests as well.
+type GraphDBStore struct {
+	URL        string // GraphDB server URL, e.g. "http://localhost:7200"
+	Repository string // Repository ID
+	Username   string
+	Password   string
+}
+
+// QueryEndpoint implements SPARQLStore
+func (s *GraphDBStore) QueryEndpoint() string {
+	return strings.TrimSuffix(s.URL, "/") + "/repositories/" + url.PathEscape(s.Repository)
+}
+
+// UpdateEndpoint implements SPARQLStore
+func (s *GraphDBStore) UpdateEndpoint() string {
+	return s.QueryEndpoint() + "/statements"
+}
+
+// PrepareRequest implements SPARQLStore
+func (s *GraphDBStore) PrepareRequest(req *http.Request) {
+	if s.Username != "" && s.Password != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+}
+
+// HTTPClient returns the GraphDB HttpClient
+func (s *GraphDBStore) HTTPClient() *http.Client {
+	return HttpClient
+}
+
+// RDF4JStore is the SPARQLStore of an RDF4J server repository
+type RDF4JStore struct {
+	ServerURL  string // RDF4J server URL, e.g. "http://localhost:8080/rdf4j-server"
+	Repository string // Repository ID
+	Username   string
+	Password   string
+}
+
+// QueryEndpoint implements SPARQLStore
+func (s *RDF4JStore) QueryEndpoint() string {
+	return strings.TrimSuffix(s.ServerURL, "/") + "/repositories/" + url.PathEscape(s.Repository)
+}
+
+// UpdateEndpoint implements SPARQLStore
+func (s *RDF4JStore) UpdateEndpoint() string {
+	return s.QueryEndpoint() + "/statements"
+}
+
+// PrepareRequest implements SPARQLStore
+func (s *RDF4JStore) PrepareRequest(req *http.Request) {
+	if s.Username != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+}
+
+// PoolPartyStore is the SPARQLStore of a PoolParty project. PoolParty's
+// project endpoints are read-only, so updates are not supported.
+type PoolPartyStore struct {
+	BaseURL   string // PoolParty server URL
+	ProjectID string // Project (thesaurus) ID
+	Username  string
+	Password  string
+	Client    *http.Client // HTTP client (nil for http.DefaultClient)
+}
+
+// QueryEndpoint implements SPARQLStore
+func (s *PoolPartyStore) QueryEndpoint() string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/PoolParty/sparql/" + url.PathEscape(s.ProjectID)
+}
+
+// UpdateEndpoint implements SPARQLStore
+func (s *PoolPartyStore) UpdateEndpoint() string { return "" }
+
+// PrepareRequest implements SPARQLStore
+func (s *PoolPartyStore) PrepareRequest(req *http.Request) {
+	req.SetBasicAuth(s.Username, s.Password)
+}
+
+// HTTPClient returns the configured client
+func (s *PoolPartyStore) HTTPClient() *http.Client {
+	return s.Client
+}
+
+// SPARQLClient executes SPARQL 1.1 queries and updates against a SPARQLStore.
+// Requests are form-encoded POSTs as defined by the SPARQL 1.1 protocol.
+type SPARQLClient struct {
+	Store SPARQLStore
+
+	// HTTPClient overrides the store's HTTP client; without either
+	// http.DefaultClient is used. Use the request context for timeouts so
+	// streamed results are not cut off.
+	HTTPClient *http.Client
+
+	// DefaultGraphs and NamedGraphs set the RDF dataset of requests. They
+	// are sent as default-graph-uri and named-graph-uri with queries and as
+	// using-graph-uri and using-named-graph-uri with updates.
+	DefaultGraphs []string
+	NamedGraphs   []string
+}
+
+// NewSPARQLClient creates a client for store
+//
+// Example Usage:
+//
+//	client := NewSPARQLClient(&GraphDBStore{URL: "http://localhost:7200", Repository: "kb"})
+//	results, err := client.Select(ctx, `SELECT ?s ?label WHERE { ?s rdfs:label ?label } LIMIT 10`)
+//	if err != nil {
+//	    return err
+//	}
+//	for _, row := range results.Bindings {
+//	    fmt.Println(row["s"].Value, row.Value("label"))
+//	}
+func NewSPARQLClient(store SPARQLStore) *SPARQLClient {
+	return &SPARQLClient{Store: store}
+}
+
+// WithGraphs returns a copy of the client restricted to the given dataset
+//
+// Example Usage:
+//
+//	graphClient := client.WithGraphs([]string{"http://example.org/graph/people"}, nil)
+//	count, err := graphClient.Select(ctx, `SELECT (COUNT(*) AS ?n) WHERE { ?s ?p ?o }`)
+func (c *SPARQLClient) WithGraphs(defaultGraphs, namedGraphs []string) *SPARQLClient {
+	clone := *c
+	clone.DefaultGraphs = defaultGraphs
+	clone.NamedGraphs = namedGraphs
+	return &clone
+}
+
+func (c *SPARQLClient) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	if provider, ok := c.Store.(interface{ HTTPClient() *http.Client }); ok {
+		if client := provider.HTTPClient(); client != nil {
+			return client
+		}
+	}
+	return http.DefaultClient
+}
+
+// post sends a form-encoded protocol request and returns the response of a
+// successful request; the caller closes its body
+func (c *SPARQLClient) post(ctx context.Context, endpoint string, form url.Values, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SPARQL request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	c.Store.PrepareRequest(req)
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send SPARQL request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxSPARQLErrorBody))
+		return nil, &SPARQLError{StatusCode: resp.StatusCode, Endpoint: endpoint, Message: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
+
+// Query executes a query and returns the raw response body in the format
+// negotiated with accept. The caller must close the body.
+//
+// Example Usage:
+//
+//	body, err := client.Query(ctx, `CONSTRUCT WHERE { ?s ?p ?o }`, "text/turtle")
+//	if err != nil {
+//	    return err
+//	}
+//	defer body.Close()
+//	io.Copy(os.Stdout, body)
+func (c *SPARQLClient) Query(ctx context.Context, query, accept string) (io.ReadCloser, error) {
+	form := url.Values{"query": {query}}
+	for _, graph := range c.DefaultGraphs {
+		form.Add("default-graph-uri", graph)
+	}
+	for _, graph := range c.NamedGraphs {
+		form.Add("named-graph-uri", graph)
+	}
+	resp, err := c.post(ctx, c.Store.QueryEndpoint(), form, accept)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Select executes a SELECT query and decodes all solutions.
+// Use SelectRows to stream large result sets.
+//
+// Parameters:
+//   - ctx: Request context
+//   - query: SPARQL SELECT query
+//
+// Returns:
+//   - *SPARQLResultSet: Projected variables and typed solutions
+//   - error: *SPARQLError for rejected queries, or transport and decoding errors
+func (c *SPARQLClient) Select(ctx context.Context, query string) (*SPARQLResultSet, error) {
+	rows, err := c.SelectRows(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &SPARQLResultSet{Bindings: []SPARQLBinding{}}
+	for rows.Next() {
+		result.Bindings = append(result.Bindings, rows.Binding())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result.Vars = rows.Vars()
+	return result, nil
+}
+
+// SelectRows executes a SELECT query and streams its solutions, decoding
+// one binding at a time. The rows must be closed.
+//
+// Example Usage:
+//
+//	rows, err := client.SelectRows(ctx, `SELECT ?s WHERE { ?s a skos:Concept }`)
+//	if err != nil {
+//	    return err
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//	    fmt.Println(rows.Binding()["s"].Value)
+//	}
+//	return rows.Err()
+func (c *SPARQLClient) SelectRows(ctx context.Context, query string) (*SPARQLRows, error) {
+	body, err := c.Query(ctx, query, SPARQLResultsJSON)
+	if err != nil {
+		return nil, err
+	}
+	rows := newSPARQLRows(body)
+	if rows.err != nil {
+		body.Close()
+		return nil, rows.err
+	}
+	return rows, nil
+}
+
+// Ask executes an ASK query
+func (c *SPARQLClient) Ask(ctx context.Context, query string) (bool, error) {
+	body, err := c.Query(ctx, query, SPARQLResultsJSON)
+	if err != nil {
+		return false, err
+	}
+	defer body.Close()
+
+	var result struct {
+		Boolean *bool `json:"boolean"`
+	}
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode ASK result: %w", err)
+	}
+	if result.Boolean == nil {
+		return false, errors.New("response is not an ASK result")
+	}
+	return *result.Boolean, nil
+}
+
+// Construct executes a CONSTRUCT query and returns the resulting statements
+func (c *SPARQLClient) Construct(ctx context.Context, query string) ([]Quad, error) {
+	var quads []Quad
+	err := c.ConstructEach(ctx, query, func(quad Quad) error {
+		quads = append(quads, quad)
+		return nil
+	})
+	return quads, err
+}
+
+// Describe executes a DESCRIBE query and returns the resulting statements
+func (c *SPARQLClient) Describe(ctx context.Context, query string) ([]Quad, error) {
+	return c.Construct(ctx, query)
+}
+
+// ConstructEach executes a CONSTRUCT or DESCRIBE query and calls fn for each
+// statement while the response is streamed. An error returned by fn stops
+// the iteration and is returned.
+//
+// Example Usage:
+//
+//	err := client.ConstructEach(ctx, `CONSTRUCT WHERE { ?s ?p ?o }`, func(q Quad) error {
+//	    _, err := fmt.Fprintln(out, q.String())
+//	    return err
+//	})
+func (c *SPARQLClient) ConstructEach(ctx context.Context, query string, fn func(Quad) error) error {
+	body, err := c.Query(ctx, query, NTriplesMediaType+", "+NQuadsMediaType+";q=0.9, text/plain;q=0.8")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	reader := NewNQuadsReader(body)
+	for {
+		quad, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(quad); err != nil {
+			return err
+		}
+	}
+}
+
+// Update executes a SPARQL 1.1 update request
+//
+// Example Usage:
+//
+//	err := client.Update(ctx, `
+//	    PREFIX schema: <http://schema.org/>
+//	    INSERT DATA { GRAPH <http://example.org/apps> { <urn:app:nginx> schema:name "nginx" } }`)
+func (c *SPARQLClient) Update(ctx context.Context, update string) error {
+	endpoint := c.Store.UpdateEndpoint()
+	if endpoint == "" {
+		return ErrSPARQLUpdateUnsupported
+	}
+
+	form := url.Values{"update": {update}}
+	for _, graph := range c.DefaultGraphs {
+		form.Add("using-graph-uri", graph)
+	}
+	for _, graph := range c.NamedGraphs {
+		form.Add("using-named-graph-uri", graph)
+	}
+	resp, err := c.post(ctx, endpoint, form, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// SPARQLBinding is one solution of a SELECT query, mapping variable names
+// (without "?") to typed terms. Unbound variables are absent.
+type SPARQLBinding map[string]Term
+
+// Value returns the value of a variable, or "" if it is unbound
+func (b SPARQLBinding) Value(name string) string {
+	return b[name].Value
+}
+
+// Native returns the value of a variable converted with Term.Native, or nil
+// if it is unbound
+func (b SPARQLBinding) Native(name string) interface{} {
+	term, ok := b[name]
+	if !ok {
+		return nil
+	}
+	return term.Native()
+}
+
+// SPARQLResultSet holds the decoded solutions of a SELECT query
+type SPARQLResultSet struct {
+	Vars     []string        // Projected variables in query order
+	Bindings []SPARQLBinding // Solutions
+}
+
+// sparqlJSONTerm is a term in the SPARQL 1.1 Query Results JSON format
+type sparqlJSONTerm struct {
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	Datatype string `json:"datatype,omitempty"`
+	Lang     string `json:"xml:lang,omitempty"`
+}
+
+// sparqlTerm converts a SPARQL JSON result term to a Term
+func sparqlTerm(termType, value, datatype, lang string) Term {
+	switch termType {
+	case "uri":
+		return NewIRI(value)
+	case "bnode":
+		return NewBlankNode(value)
+	}
+	if lang != "" {
+		return NewLangLiteral(value, lang)
+	}
+	return NewLiteral(value, datatype)
+}
+
+// SPARQLRows streams the solutions of a SELECT query
+type SPARQLRows struct {
+	body    io.ReadCloser
+	dec     *json.Decoder
+	vars    []string
+	current SPARQLBinding
+	err     error
+	done    bool
+}
+
+// newSPARQLRows reads the response up to the first solution
+func newSPARQLRows(body io.ReadCloser) *SPARQLRows {
+	rows := &SPARQLRows{body: body, dec: json.NewDecoder(body)}
+	rows.err = rows.seekBindings()
+	return rows
+}
+
+// seekBindings advances the decoder into the results.bindings array,
+// reading the head on the way
+func (r *SPARQLRows) seekBindings() error {
+	if err := expectDelim(r.dec, '{'); err != nil {
+		return err
+	}
+	for r.dec.More() {
+		key, err := r.dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode SPARQL results: %w", err)
+		}
+		switch key {
+		case "head":
+			var head struct {
+				Vars []string `json:"vars"`
+			}
+			if err := r.dec.Decode(&head); err != nil {
+				return fmt.Errorf("failed to decode SPARQL results head: %w", err)
+			}
+			r.vars = head.Vars
+		case "boolean":
+			return errors.New("response is an ASK result, not a SELECT result")
+		case "results":
+			if err := expectDelim(r.dec, '{'); err != nil {
+				return err
+			}
+			for r.dec.More() {
+				key, err := r.dec.Token()
+				if err != nil {
+					return fmt.Errorf("failed to decode SPARQL results: %w", err)
+				}
+				if key == "bindings" {
+					return expectDelim(r.dec, '[')
+				}
+				var skip json.RawMessage
+				if err := r.dec.Decode(&skip); err != nil {
+					return fmt.Errorf("failed to decode SPARQL results: %w", err)
+				}
+			}
+			r.done = true
+			return nil
+		default:
+			var skip json.RawMessage
+			if err := r.dec.Decode(&skip); err != nil {
+				return fmt.Errorf("failed to decode SPARQL results: %w", err)
+			}
+		}
+	}
+	r.done = true
+	return nil
+}
+
+// trailingHead reads the rest of the document after the bindings, picking
+// up a head that follows the results
+func (r *SPARQLRows) trailingHead() error {
+	if _, err := r.dec.Token(); err != nil { // ] of bindings
+		return err
+	}
+	// The remaining entries of results, then those of the document
+	for _, topLevel := range []bool{false, true} {
+		for r.dec.More() {
+			key, err := r.dec.Token()
+			if err != nil {
+				return err
+			}
+			var value json.RawMessage
+			if err := r.dec.Decode(&value); err != nil {
+				return err
+			}
+			if topLevel && key == "head" && r.vars == nil {
+				var head struct {
+					Vars []string `json:"vars"`
+				}
+				if err := json.Unmarshal(value, &head); err == nil {
+					r.vars = head.Vars
+				}
+			}
+		}
+		if _, err := r.dec.Token(); err != nil { // }
+			return err
+		}
+	}
+	return nil
+}
+
+// Next advances to the next solution, returning false at the end of the
+// results or on error
+func (r *SPARQLRows) Next() bool {
+	if r.done || r.err != nil {
+		return false
+	}
+	if !r.dec.More() {
+		r.done = true
+		if err := r.trailingHead(); err != nil {
+			r.err = fmt.Errorf("failed to decode SPARQL results: %w", err)
+		}
+		return false
+	}
+
+	var raw map[string]sparqlJSONTerm
+	if err := r.dec.Decode(&raw); err != nil {
+		r.err = fmt.Errorf("failed to decode SPARQL binding: %w", err)
+		return false
+	}
+	r.current = make(SPARQLBinding, len(raw))
+	for name, term := range raw {
+		r.current[name] = sparqlTerm(term.Type, term.Value, term.Datatype, term.Lang)
+	}
+	return true
+}
+
+// Binding returns the current solution
+func (r *SPARQLRows) Binding() SPARQLBinding {
+	return r.current
+}
+
+// Vars returns the projected variables. They are known before the first
+// solution unless the store sends the head after the results.
+func (r *SPARQLRows) Vars() []string {
+	return r.vars
+}
+
+// Err returns the error that stopped the iteration, if any
+func (r *SPARQLRows) Err() error {
+	return r.err
+}
+
+// Close releases the response; it is safe to call more than once
+func (r *SPARQLRows) Close() error {
+	r.done = true
+	return r.body.Close()
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode SPARQL results: %w", err)
+	}
+	if token != delim {
+		return fmt.Errorf("failed to decode SPARQL results: expected %v, got %v", delim, token)
+	}
+	return nil
+}
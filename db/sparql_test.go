@@ -0,0 +1,283 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const selectResponse = `{
+  "head": {"vars": ["s", "label", "count", "node"]},
+  "results": {"bindings": [
+    {
+      "s": {"type": "uri", "value": "http://example.org/alice"},
+      "label": {"type": "literal", "value": "Alice", "xml:lang": "en"},
+      "count": {"type": "literal", "value": "42", "datatype": "http://www.w3.org/2001/XMLSchema#integer"},
+      "node": {"type": "bnode", "value": "b0"}
+    },
+    {
+      "s": {"type": "uri", "value": "http://example.org/bob"},
+      "count": {"type": "typed-literal", "value": "1.5", "datatype": "http://www.w3.org/2001/XMLSchema#decimal"}
+    }
+  ]}
+}`
+
+// sparqlRequest is a request received by the fake SPARQL store
+type sparqlRequest struct {
+	path   string
+	accept string
+	user   string
+	form   url.Values
+}
+
+// fakeSPARQLServer serves the given response body and records requests
+func fakeSPARQLServer(t *testing.T, status int, contentType, body string) (*httptest.Server, *[]sparqlRequest) {
+	t.Helper()
+	var requests []sparqlRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		user, _, _ := r.BasicAuth()
+		requests = append(requests, sparqlRequest{path: r.URL.Path, accept: r.Header.Get("Accept"), user: user, form: r.PostForm})
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// TestSPARQLClientSelect tests SELECT requests and typed result decoding
+func TestSPARQLClientSelect(t *testing.T) {
+	server, requests := fakeSPARQLServer(t, http.StatusOK, SPARQLResultsJSON, selectResponse)
+	client := GraphDBSPARQLClient(server.URL, "admin", "secret", "kb").
+		WithGraphs([]string{"http://example.org/g1"}, []string{"http://example.org/g2"})
+
+	results, err := client.Select(context.Background(), "SELECT * WHERE { ?s ?p ?o }")
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 1)
+	req := (*requests)[0]
+	assert.Equal(t, "/repositories/kb", req.path)
+	assert.Equal(t, SPARQLResultsJSON, req.accept)
+	assert.Equal(t, "admin", req.user)
+	assert.Equal(t, "SELECT * WHERE { ?s ?p ?o }", req.form.Get("query"))
+	assert.Equal(t, []string{"http://example.org/g1"}, req.form["default-graph-uri"])
+	assert.Equal(t, []string{"http://example.org/g2"}, req.form["named-graph-uri"])
+
+	assert.Equal(t, []string{"s", "label", "count", "node"}, results.Vars)
+	require.Len(t, results.Bindings, 2)
+	first := results.Bindings[0]
+	assert.Equal(t, NewIRI("http://example.org/alice"), first["s"])
+	assert.Equal(t, NewLangLiteral("Alice", "en"), first["label"])
+	assert.Equal(t, int64(42), first.Native("count"))
+	assert.Equal(t, NewBlankNode("b0"), first["node"])
+
+	second := results.Bindings[1]
+	assert.Equal(t, 1.5, second.Native("count"))
+	assert.Equal(t, "", second.Value("label"))
+	assert.Nil(t, second.Native("label"))
+}
+
+// TestSPARQLRows tests streaming of solutions
+func TestSPARQLRows(t *testing.T) {
+	t.Run("head after results", func(t *testing.T) {
+		body := `{"results": {"bindings": [{"x": {"type": "literal", "value": "1"}}, {"x": {"type": "literal", "value": "2"}}]}, "head": {"vars": ["x"]}}`
+		server, _ := fakeSPARQLServer(t, http.StatusOK, SPARQLResultsJSON, body)
+		client := NewSPARQLClient(&SPARQLEndpointStore{QueryURL: server.URL})
+
+		rows, err := client.SelectRows(context.Background(), "SELECT ?x WHERE {}")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var values []string
+		for rows.Next() {
+			values = append(values, rows.Binding().Value("x"))
+		}
+		require.NoError(t, rows.Err())
+		assert.Equal(t, []string{"1", "2"}, values)
+		assert.Equal(t, []string{"x"}, rows.Vars())
+	})
+
+	t.Run("truncated response", func(t *testing.T) {
+		body := `{"head": {"vars": ["x"]}, "results": {"bindings": [{"x": {"type": "literal", "value": "1"}}, {"x": `
+		server, _ := fakeSPARQLServer(t, http.StatusOK, SPARQLResultsJSON, body)
+		client := NewSPARQLClient(&SPARQLEndpointStore{QueryURL: server.URL})
+
+		rows, err := client.SelectRows(context.Background(), "SELECT ?x WHERE {}")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		assert.True(t, rows.Next())
+		assert.False(t, rows.Next())
+		assert.Error(t, rows.Err())
+	})
+
+	t.Run("ASK response", func(t *testing.T) {
+		server, _ := fakeSPARQLServer(t, http.StatusOK, SPARQLResultsJSON, `{"head": {}, "boolean": true}`)
+		client := NewSPARQLClient(&SPARQLEndpointStore{QueryURL: server.URL})
+
+		_, err := client.Select(context.Background(), "ASK {}")
+		assert.ErrorContains(t, err, "ASK result")
+	})
+}
+
+// TestSPARQLClientAsk tests ASK queries
+func TestSPARQLClientAsk(t *testing.T) {
+	server, _ := fakeSPARQLServer(t, http.StatusOK, SPARQLResultsJSON, `{"head": {}, "boolean": true}`)
+	client := RDF4JSPARQLClient(server.URL, "repo", "", "")
+
+	ok, err := client.Ask(context.Background(), "ASK { ?s ?p ?o }")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// TestSPARQLClientConstruct tests CONSTRUCT and DESCRIBE queries
+func TestSPARQLClientConstruct(t *testing.T) {
+	body := `# generated
+<http://example.org/alice> <http://schema.org/name> "Alice \"A\"\n"@en .
+<http://example.org/alice> <http://schema.org/age> "42"^^<http://www.w3.org/2001/XMLSchema#integer> .
+_:b0 <http://schema.org/knows> <http://example.org/alice> .
+`
+	server, requests := fakeSPARQLServer(t, http.StatusOK, NTriplesMediaType, body)
+	client := RDF4JSPARQLClient(server.URL, "repo", "", "")
+
+	quads, err := client.Construct(context.Background(), "CONSTRUCT WHERE { ?s ?p ?o }")
+	require.NoError(t, err)
+	require.Len(t, quads, 3)
+	assert.Contains(t, (*requests)[0].accept, NTriplesMediaType)
+	assert.Equal(t, NewLangLiteral("Alice \"A\"\n", "en"), quads[0].Object)
+	assert.Equal(t, int64(42), quads[1].Object.Native())
+	assert.Equal(t, NewBlankNode("b0"), quads[2].Subject)
+
+	stop := errors.New("stop")
+	count := 0
+	err = client.ConstructEach(context.Background(), "DESCRIBE <http://example.org/alice>", func(Quad) error {
+		count++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, count)
+}
+
+// TestSPARQLClientUpdate tests SPARQL updates
+func TestSPARQLClientUpdate(t *testing.T) {
+	t.Run("update with dataset", func(t *testing.T) {
+		server, requests := fakeSPARQLServer(t, http.StatusNoContent, "", "")
+		client := RDF4JSPARQLClient(server.URL, "repo", "admin", "secret").
+			WithGraphs([]string{"http://example.org/g"}, nil)
+
+		err := client.Update(context.Background(), "DELETE WHERE { ?s ?p ?o }")
+		require.NoError(t, err)
+		req := (*requests)[0]
+		assert.Equal(t, "/repositories/repo/statements", req.path)
+		assert.Equal(t, "DELETE WHERE { ?s ?p ?o }", req.form.Get("update"))
+		assert.Equal(t, []string{"http://example.org/g"}, req.form["using-graph-uri"])
+	})
+
+	t.Run("rejected update", func(t *testing.T) {
+		server, _ := fakeSPARQLServer(t, http.StatusBadRequest, "text/plain", "MALFORMED QUERY: unexpected token")
+
+		err := RDF4JUpdate(server.URL, "repo", "", "", "INSERT nonsense")
+		var sparqlErr *SPARQLError
+		require.True(t, errors.As(err, &sparqlErr))
+		assert.Equal(t, http.StatusBadRequest, sparqlErr.StatusCode)
+		assert.Contains(t, sparqlErr.Message, "MALFORMED QUERY")
+	})
+
+	t.Run("read-only store", func(t *testing.T) {
+		client := NewPoolPartyClient("http://localhost", "user", "pass", "").SPARQLClient("project")
+		err := client.Update(context.Background(), "CLEAR ALL")
+		assert.ErrorIs(t, err, ErrSPARQLUpdateUnsupported)
+	})
+}
+
+// TestSPARQLStores tests the endpoint URLs of the store adapters
+func TestSPARQLStores(t *testing.T) {
+	graphdb := &GraphDBStore{URL: "http://localhost:7200/", Repository: "kb"}
+	assert.Equal(t, "http://localhost:7200/repositories/kb", graphdb.QueryEndpoint())
+	assert.Equal(t, "http://localhost:7200/repositories/kb/statements", graphdb.UpdateEndpoint())
+
+	rdf4j := &RDF4JStore{ServerURL: "http://localhost:8080/rdf4j-server", Repository: "my repo"}
+	assert.Equal(t, "http://localhost:8080/rdf4j-server/repositories/my%20repo", rdf4j.QueryEndpoint())
+
+	poolparty := &PoolPartyStore{BaseURL: "https://pp.example.com", ProjectID: "thesaurus"}
+	assert.Equal(t, "https://pp.example.com/PoolParty/sparql/thesaurus", poolparty.QueryEndpoint())
+	assert.Empty(t, poolparty.UpdateEndpoint())
+
+	server, requests := fakeSPARQLServer(t, http.StatusOK, SPARQLResultsJSON, `{"head": {"vars": []}, "results": {"bindings": []}}`)
+	client := NewSPARQLClient(&SPARQLEndpointStore{QueryURL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+	results, err := client.Select(context.Background(), "SELECT * WHERE {}")
+	require.NoError(t, err)
+	assert.Empty(t, results.Bindings)
+	assert.Len(t, *requests, 1)
+}
+
+// TestParseNQuads tests the N-Quads reader
+func TestParseNQuads(t *testing.T) {
+	t.Run("valid input", func(t *testing.T) {
+		input := `<http://a> <http://p> <http://b> <http://g> .
+# comment
+
+_:x.y <http://p> "café\t"^^<http://www.w3.org/2001/XMLSchema#string> . # trailing
+<http://a> <http://p> "Hallo"@DE-at _:g .
+<http://a> <http://p> _:b1.
+`
+		quads, err := ParseNQuads(strings.NewReader(input))
+		require.NoError(t, err)
+		require.Len(t, quads, 4)
+		assert.Equal(t, NewIRI("http://g"), quads[0].Graph)
+		assert.Equal(t, NewBlankNode("x.y"), quads[1].Subject)
+		assert.Equal(t, NewLiteral("café\t", ""), quads[1].Object)
+		assert.Equal(t, NewLangLiteral("Hallo", "de-at"), quads[2].Object)
+		assert.Equal(t, NewBlankNode("g"), quads[2].Graph)
+		assert.Equal(t, NewBlankNode("b1"), quads[3].Object)
+
+		// Serialization round trip
+		again, err := ParseNQuads(strings.NewReader(FormatNQuads(quads)))
+		require.NoError(t, err)
+		assert.Equal(t, quads, again)
+	})
+
+	t.Run("syntax errors", func(t *testing.T) {
+		for _, input := range []string{
+			"<http://a> <http://p> <http://b>\n",
+			"\n\"lit\" <http://p> <http://b> .",
+			"<http://a> _:p <http://b> .",
+			"<http://a> <http://p> \"open .",
+			"<http://a> <http://p> <http://b> . extra",
+		} {
+			_, err := ParseNQuads(strings.NewReader(input))
+			var syntaxErr *RDFSyntaxError
+			require.True(t, errors.As(err, &syntaxErr), input)
+		}
+
+		_, err := ParseNQuads(strings.NewReader("\n\"lit\" <http://p> <http://b> ."))
+		assert.ErrorContains(t, err, "line 2")
+	})
+}
+
+// TestTermNative tests conversion of literals to Go values
+func TestTermNative(t *testing.T) {
+	assert.Equal(t, "http://example.org", NewIRI("http://example.org").Native())
+	assert.Equal(t, "text", NewLiteral("text", "").Native())
+	assert.Equal(t, int64(-7), NewLiteral("-7", XSDNamespace+"int").Native())
+	assert.Equal(t, 2.5e3, NewLiteral("2.5E3", XSDDouble).Native())
+	assert.Equal(t, math.Inf(1), NewLiteral("INF", XSDFloat).Native())
+	assert.Equal(t, true, NewLiteral("1", XSDBoolean).Native())
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC), NewLiteral("2024-05-01T12:30:00Z", XSDDateTime).Native())
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), NewLiteral("2024-05-01", XSDDate).Native())
+	assert.Equal(t, "not a number", NewLiteral("not a number", XSDInteger).Native())
+
+	legacy := SPARQLValue{Type: "literal", Value: "3", Datatype: XSDInteger}
+	assert.Equal(t, int64(3), legacy.Term().Native())
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"embed"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"eve.evalgo.org/db/migrate"
+)
+
+// StateStoreSchemaComponent identifies the state store schema in schema_migrations.
+const StateStoreSchemaComponent = "state_store"
+
+//go:embed migrations/*.sql
+var stateStoreMigrationFS embed.FS
+
+func init() {
+	migrate.Register(migrate.MustLoad(StateStoreSchemaComponent, stateStoreMigrationFS, "migrations"))
+}
+
+// MigrateStateStore creates or upgrades the service_action_executions table.
+func MigrateStateStore(ctx context.Context, pool *pgxpool.Pool) error {
+	migrator := migrate.NewFromPool(pool)
+	defer migrator.Close()
+	return migrator.Up(ctx, StateStoreSchemaComponent)
+}
+
+// Migrate creates or upgrades the tables used by the state store.
+func (s *StateStore) Migrate(ctx context.Context) error {
+	return MigrateStateStore(ctx, s.pool)
+}
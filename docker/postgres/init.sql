@@ -13,6 +13,9 @@
 -- Usage:
 --   psql -U claude -d action_traces -f action_tracing_schema.sql
 --
+-- The same schema ships as embedded migrations in tracing/migrations
+-- (tracing.Migrate); keep both in sync when changing it.
+--
 -- Purpose:
 --   Track distributed actions across ALL EVE services with:
 --   - Correlation IDs linking actions in workflows
@@ -802,9 +805,195 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
+-- ============================================================================
+-- Dependency Graph Snapshots (mirrors tracing/migrations/0003)
+-- ============================================================================
+
+-- Service dependency graph snapshots, one row per refresh and time window,
+-- used to compare the call graph between deployments.
+CREATE TABLE IF NOT EXISTS dependency_graph_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    window_hours INTEGER NOT NULL,
+    captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    service_count INTEGER NOT NULL,
+    edge_count INTEGER NOT NULL,
+    graph JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_dependency_snapshots_window
+    ON dependency_graph_snapshots (window_hours, captured_at DESC);
+
+-- ============================================================================
+-- Retention Policies and Legal Holds (mirrors tracing/migrations/0004)
+-- ============================================================================
+
+-- Archival bookkeeping columns, legal holds on correlation IDs, and
+-- hold-aware erasure and retention functions.
+
+ALTER TABLE action_executions
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS archived_s3_key TEXT;
+
+-- Index for archival queries
+CREATE INDEX IF NOT EXISTS idx_action_exec_archived
+    ON action_executions (archived_at)
+    WHERE archived_at IS NOT NULL;
+
+-- ============================================================================
+-- Legal Holds
+-- ============================================================================
+
+-- A hold exempts every trace of a correlation ID from retention deletion and
+-- GDPR erasure until it is released. Released holds are kept for the audit trail.
+CREATE TABLE IF NOT EXISTS trace_legal_holds (
+    id BIGSERIAL PRIMARY KEY,
+    correlation_id TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    placed_by TEXT NOT NULL,
+    placed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    released_by TEXT,
+    released_at TIMESTAMPTZ
+);
+
+-- At most one active hold per correlation ID
+CREATE UNIQUE INDEX IF NOT EXISTS idx_legal_holds_active
+    ON trace_legal_holds (correlation_id)
+    WHERE released_at IS NULL;
+
+-- ============================================================================
+-- Hold-aware Erasure and Retention
+-- ============================================================================
+
+-- The result gains held_actions, so the function is recreated rather than replaced
+DROP FUNCTION IF EXISTS gdpr_erase_traces(TEXT, TEXT, TEXT, TEXT);
+
+-- Full erasure: Delete all traces for a data subject or correlation ID,
+-- except traces of correlation IDs under an active legal hold
+CREATE OR REPLACE FUNCTION gdpr_erase_traces(
+    p_data_subject_id TEXT DEFAULT NULL,
+    p_correlation_id TEXT DEFAULT NULL,
+    p_user_id TEXT DEFAULT 'system',
+    p_purpose TEXT DEFAULT 'GDPR Right to Erasure'
+)
+RETURNS TABLE (
+    deleted_actions INTEGER,
+    deleted_pii INTEGER,
+    s3_urls_to_delete TEXT[],
+    held_actions INTEGER
+) AS $$
+DECLARE
+    v_deleted_actions INTEGER;
+    v_deleted_pii INTEGER;
+    v_held_actions INTEGER;
+    v_s3_urls TEXT[];
+BEGIN
+    -- Validate input
+    IF p_data_subject_id IS NULL AND p_correlation_id IS NULL THEN
+        RAISE EXCEPTION 'Must provide either data_subject_id or correlation_id';
+    END IF;
+
+    -- Count traces exempt from erasure
+    SELECT COUNT(*)
+    INTO v_held_actions
+    FROM action_executions ae
+    WHERE (p_data_subject_id IS NULL OR ae.data_subject_id = p_data_subject_id)
+      AND (p_correlation_id IS NULL OR ae.correlation_id = p_correlation_id)
+      AND EXISTS (
+          SELECT 1 FROM trace_legal_holds h
+          WHERE h.correlation_id = ae.correlation_id AND h.released_at IS NULL
+      );
+
+    -- Collect S3 URLs that need to be deleted
+    SELECT ARRAY_AGG(DISTINCT url)
+    INTO v_s3_urls
+    FROM (
+        SELECT unnest(ARRAY[ae.request_url, ae.response_url, ae.logs_url]) AS url
+        FROM action_executions ae
+        WHERE (p_data_subject_id IS NULL OR ae.data_subject_id = p_data_subject_id)
+          AND (p_correlation_id IS NULL OR ae.correlation_id = p_correlation_id)
+          AND NOT EXISTS (
+              SELECT 1 FROM trace_legal_holds h
+              WHERE h.correlation_id = ae.correlation_id AND h.released_at IS NULL
+          )
+    ) urls
+    WHERE url IS NOT NULL
+      AND url NOT LIKE '[REDACTED%';
+
+    -- Log the erasure action in audit table
+    INSERT INTO trace_access_audit (
+        user_id, access_type, resource_type,
+        correlation_id, data_subject_id,
+        purpose, legal_basis,
+        query_parameters
+    ) VALUES (
+        p_user_id, 'delete', 'gdpr_erasure',
+        p_correlation_id, p_data_subject_id,
+        p_purpose, 'GDPR Article 17',
+        jsonb_build_object(
+            'data_subject_id', p_data_subject_id,
+            'correlation_id', p_correlation_id,
+            'held_actions', v_held_actions
+        )
+    );
+
+    -- Delete PII detections
+    DELETE FROM pii_detections pd
+    WHERE (p_data_subject_id IS NULL OR pd.data_subject_id = p_data_subject_id)
+      AND (p_correlation_id IS NULL OR pd.correlation_id IN (
+          SELECT correlation_id FROM action_executions
+          WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+            AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id)
+      ))
+      AND NOT EXISTS (
+          SELECT 1 FROM trace_legal_holds h
+          WHERE h.correlation_id = pd.correlation_id AND h.released_at IS NULL
+      );
+
+    GET DIAGNOSTICS v_deleted_pii = ROW_COUNT;
+
+    -- Delete action executions
+    DELETE FROM action_executions ae
+    WHERE (p_data_subject_id IS NULL OR ae.data_subject_id = p_data_subject_id)
+      AND (p_correlation_id IS NULL OR ae.correlation_id = p_correlation_id)
+      AND NOT EXISTS (
+          SELECT 1 FROM trace_legal_holds h
+          WHERE h.correlation_id = ae.correlation_id AND h.released_at IS NULL
+      );
+
+    GET DIAGNOSTICS v_deleted_actions = ROW_COUNT;
+
+    -- Return results
+    RETURN QUERY SELECT v_deleted_actions, v_deleted_pii, v_s3_urls, v_held_actions;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Auto-delete expired traces, except traces under an active legal hold
+CREATE OR REPLACE FUNCTION delete_expired_traces()
+RETURNS INTEGER AS $$
+DECLARE
+    v_deleted INTEGER;
+BEGIN
+    DELETE FROM action_executions ae
+    WHERE ae.retention_until IS NOT NULL
+      AND ae.retention_until < NOW()
+      AND NOT EXISTS (
+          SELECT 1 FROM trace_legal_holds h
+          WHERE h.correlation_id = ae.correlation_id AND h.released_at IS NULL
+      );
+
+    GET DIAGNOSTICS v_deleted = ROW_COUNT;
+
+    RETURN v_deleted;
+END;
+$$ LANGUAGE plpgsql;
+
 -- Grant permissions
 GRANT ALL ON action_executions TO claude;
 GRANT ALL ON action_metadata_schemas TO claude;
 GRANT ALL ON trace_access_audit TO claude;
 GRANT ALL ON pii_detections TO claude;
+GRANT ALL ON dependency_graph_snapshots TO claude;
+GRANT USAGE ON SEQUENCE dependency_graph_snapshots_id_seq TO claude;
+GRANT ALL ON trace_legal_holds TO claude;
+GRANT USAGE ON SEQUENCE trace_legal_holds_id_seq TO claude;
 GRANT EXECUTE ON ALL FUNCTIONS IN SCHEMA public TO claude;
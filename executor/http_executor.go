@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"eve.evalgo.org/semantic"
+	"eve.evalgo.org/tracing"
 )
 
 // HTTPExecutor executes HTTP-based semantic actions
@@ -16,11 +17,13 @@ type HTTPExecutor struct {
 	Client *http.Client
 }
 
-// NewHTTPExecutor creates a new HTTP executor with default settings
+// NewHTTPExecutor creates a new HTTP executor with default settings.
+// The client propagates trace context from the execution context (see tracing.Transport).
 func NewHTTPExecutor() *HTTPExecutor {
 	return &HTTPExecutor{
 		Client: &http.Client{
-			Timeout: 30 * time.Second,
+			Transport: tracing.NewTransport(nil),
+			Timeout:   30 * time.Second,
 		},
 	}
 }
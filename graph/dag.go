@@ -201,3 +201,61 @@ func CheckDependencies(repo ActionRepository, action *semantic.SemanticScheduled
 
 	return true, nil // All dependencies met
 }
+
+// FindCycle returns the first dependency cycle found in actions as a path of
+// identifiers that starts and ends with the same action (e.g. [a b c a]).
+// Dependencies on identifiers not present in actions are ignored.
+// Returns nil if the graph is acyclic.
+func FindCycle(actions []*semantic.SemanticScheduledAction) []string {
+	actionMap := make(map[string]*semantic.SemanticScheduledAction, len(actions))
+	for _, action := range actions {
+		actionMap[action.Identifier] = action
+	}
+
+	const (
+		unvisited = iota
+		inStack
+		done
+	)
+	state := make(map[string]int, len(actions))
+	var stack []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = inStack
+		stack = append(stack, id)
+
+		for _, depID := range actionMap[id].Requires {
+			if _, ok := actionMap[depID]; !ok {
+				continue
+			}
+			switch state[depID] {
+			case inStack:
+				// Cycle: slice the stack from the first occurrence of depID
+				for i, stackID := range stack {
+					if stackID == depID {
+						cycle := append([]string{}, stack[i:]...)
+						return append(cycle, depID)
+					}
+				}
+			case unvisited:
+				if cycle := visit(depID); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		return nil
+	}
+
+	for _, action := range actions {
+		if state[action.Identifier] == unvisited {
+			if cycle := visit(action.Identifier); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
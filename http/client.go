@@ -10,6 +10,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"eve.evalgo.org/tracing"
 )
 
 // Execute performs an HTTP request and returns the response
@@ -98,6 +100,9 @@ func executeOnce(req *Request) (*Response, error) {
 		}
 	}
 
+	// Propagate correlation IDs and trace context to the target service
+	client.Transport = tracing.NewTransport(client.Transport)
+
 	// Configure redirects
 	if !req.FollowRedirect {
 		client.CheckRedirect = func(httpReq *http.Request, via []*http.Request) error {
@@ -160,7 +165,7 @@ func executeOnce(req *Request) (*Response, error) {
 
 // buildSimpleRequest builds a request without a body (GET, HEAD, DELETE, OPTIONS)
 func buildSimpleRequest(req *Request) (*http.Request, error) {
-	httpReq, err := http.NewRequest(req.Method, req.URL, nil)
+	httpReq, err := http.NewRequestWithContext(req.context(), req.Method, req.URL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -204,7 +209,7 @@ func buildBodyRequest(req *Request) (*http.Request, error) {
 		return nil, fmt.Errorf("%s request requires a body (JSON, form data, or raw bytes)", req.Method)
 	}
 
-	httpReq, err := http.NewRequest(req.Method, req.URL, body)
+	httpReq, err := http.NewRequestWithContext(req.context(), req.Method, req.URL, body)
 	if err != nil {
 		return nil, err
 	}
@@ -1,11 +1,17 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"eve.evalgo.org/tracing"
 )
 
 func TestNewRequest(t *testing.T) {
@@ -53,6 +59,36 @@ func TestExecuteGET(t *testing.T) {
 	}
 }
 
+func TestExecutePropagatesTraceContext(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	req := NewRequest("GET", server.URL)
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "parent")
+	defer span.End()
+	req.Context = tracing.ContextWithTraceIDs(ctx, "wf-123", "op-parent")
+	if _, err := Execute(req); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if got := headers.Get("X-Correlation-ID"); got != "wf-123" {
+		t.Errorf("Expected X-Correlation-ID wf-123, got %q", got)
+	}
+	if got := headers.Get("X-Parent-Operation-ID"); got != "op-parent" {
+		t.Errorf("Expected X-Parent-Operation-ID op-parent, got %q", got)
+	}
+	if headers.Get("X-Operation-ID") == "" {
+		t.Error("Expected a downstream X-Operation-ID")
+	}
+	if got := headers.Get("Traceparent"); !strings.Contains(got, span.SpanContext().TraceID().String()) {
+		t.Errorf("Expected traceparent with trace ID %s, got %q", span.SpanContext().TraceID(), got)
+	}
+}
+
 func TestExecutePOSTJSON(t *testing.T) {
 	// Create test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1,6 +1,9 @@
 package http
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Request represents an HTTP operation with all configuration options
 type Request struct {
@@ -40,6 +43,10 @@ type Request struct {
 	// Advanced
 	UserAgent string // Custom User-Agent header
 	Proxy     string // HTTP proxy URL
+
+	// Context carries cancellation and trace IDs (tracing.ContextWithTraceIDs) to the request.
+	// Defaults to context.Background().
+	Context context.Context
 }
 
 // NewRequest creates a new Request with sensible defaults
@@ -61,6 +68,14 @@ func NewRequest(method, url string) *Request {
 	}
 }
 
+// context returns the request context, defaulting to context.Background()
+func (r *Request) context() context.Context {
+	if r.Context != nil {
+		return r.Context
+	}
+	return context.Background()
+}
+
 // Response represents an HTTP response with metadata
 type Response struct {
 	StatusCode int               // HTTP status code
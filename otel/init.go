@@ -3,6 +3,7 @@ package otel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -13,6 +14,8 @@ import (
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 )
@@ -36,11 +39,24 @@ type Config struct {
 
 	// Environment (production, staging, development)
 	Environment string
+
+	// EnableMetrics exports metrics over OTLP, including the Prometheus
+	// instruments registered by tracing.Metrics
+	EnableMetrics bool
+
+	// MetricsInterval is the metrics export interval (default: 60s)
+	MetricsInterval time.Duration
+
+	// EnableLogs installs an OTLP LoggerProvider used by ZerologHook and LogrusHook
+	EnableLogs bool
 }
 
-// Provider wraps the OpenTelemetry TracerProvider
+// Provider wraps the OpenTelemetry tracer, meter and logger providers.
+// All three share the same resource attributes.
 type Provider struct {
 	tp *sdktrace.TracerProvider
+	mp *sdkmetric.MeterProvider
+	lp *sdklog.LoggerProvider
 }
 
 // Init initializes OpenTelemetry from environment variables
@@ -50,7 +66,32 @@ type Provider struct {
 //   - OTEL_SERVICE_NAME: Service name (override serviceID)
 //   - OTEL_SAMPLING_RATIO: Sampling ratio 0.0-1.0 (default: 1.0)
 //   - OTEL_ENVIRONMENT: Environment name (default: development)
+//   - OTEL_METRICS_ENABLED: Export metrics over OTLP when "true" (default: false)
+//   - OTEL_METRIC_EXPORT_INTERVAL: Metrics export interval in milliseconds (default: 60000)
+//   - OTEL_LOGS_ENABLED: Export logs over OTLP when "true" (default: false)
 func Init(serviceID, version string) *Provider {
+	config := configFromEnv(serviceID, version)
+	if !config.Enabled {
+		log.Println("⚠️  OpenTelemetry explicitly disabled via OTEL_ENABLED=false")
+		return nil
+	}
+
+	// Initialize provider
+	provider, err := NewProvider(config)
+	if err != nil {
+		log.Printf("⚠️  OpenTelemetry initialization failed: %v", err)
+		return nil
+	}
+
+	log.Printf("✓ OpenTelemetry initialized for %s (endpoint: %s, sampling: %.2f, metrics: %t, logs: %t)",
+		config.ServiceName, config.OTLPEndpoint, config.SamplingRatio, config.EnableMetrics, config.EnableLogs)
+
+	return provider
+}
+
+// configFromEnv builds the Init configuration from the environment variables
+// documented on Init
+func configFromEnv(serviceID, version string) Config {
 	config := Config{
 		ServiceID:   serviceID,
 		ServiceName: serviceID,
@@ -60,8 +101,7 @@ func Init(serviceID, version string) *Provider {
 	// Parse environment variables
 	config.Enabled = os.Getenv("OTEL_ENABLED") != "false"
 	if !config.Enabled {
-		log.Println("⚠️  OpenTelemetry explicitly disabled via OTEL_ENABLED=false")
-		return nil
+		return config
 	}
 
 	// OTLP endpoint
@@ -89,17 +129,19 @@ func Init(serviceID, version string) *Provider {
 		config.Environment = "development"
 	}
 
-	// Initialize provider
-	provider, err := NewProvider(config)
-	if err != nil {
-		log.Printf("⚠️  OpenTelemetry initialization failed: %v", err)
-		return nil
+	// Metrics and logs are opt-in: not every collector accepts them
+	config.EnableMetrics = os.Getenv("OTEL_METRICS_ENABLED") == "true"
+	if interval := os.Getenv("OTEL_METRIC_EXPORT_INTERVAL"); interval != "" {
+		var ms int
+		if _, err := fmt.Sscanf(interval, "%d", &ms); err != nil || ms <= 0 {
+			log.Printf("⚠️  Invalid OTEL_METRIC_EXPORT_INTERVAL: %s, using 60000", interval)
+		} else {
+			config.MetricsInterval = time.Duration(ms) * time.Millisecond
+		}
 	}
+	config.EnableLogs = os.Getenv("OTEL_LOGS_ENABLED") == "true"
 
-	log.Printf("✓ OpenTelemetry initialized for %s (endpoint: %s, sampling: %.2f)",
-		config.ServiceName, config.OTLPEndpoint, config.SamplingRatio)
-
-	return provider
+	return config
 }
 
 // NewProvider creates a new OpenTelemetry provider with the given configuration
@@ -118,18 +160,10 @@ func NewProvider(config Config) (*Provider, error) {
 		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
 	}
 
-	// Create resource with service information
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceNameKey.String(config.ServiceName),
-			semconv.ServiceVersionKey.String(config.Version),
-			semconv.DeploymentEnvironmentKey.String(config.Environment),
-		),
-		resource.WithProcess(),
-		resource.WithHost(),
-	)
+	// Create resource with service information (shared by all signals)
+	res, err := newResource(ctx, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+		return nil, err
 	}
 
 	// Create sampler
@@ -158,20 +192,79 @@ func NewProvider(config Config) (*Provider, error) {
 		propagation.Baggage{},
 	))
 
-	return &Provider{tp: tp}, nil
+	provider := &Provider{tp: tp}
+
+	if config.EnableMetrics {
+		if provider.mp, err = newMeterProvider(ctx, config, res); err != nil {
+			_ = provider.Shutdown(ctx)
+			return nil, err
+		}
+	}
+
+	if config.EnableLogs {
+		if provider.lp, err = newLoggerProvider(ctx, config, res); err != nil {
+			_ = provider.Shutdown(ctx)
+			return nil, err
+		}
+	}
+
+	return provider, nil
 }
 
-// Shutdown gracefully shuts down the provider
+// newResource describes the service for all signals
+func newResource(ctx context.Context, config Config) (*resource.Resource, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceNameKey.String(config.ServiceName),
+			semconv.ServiceVersionKey.String(config.Version),
+			semconv.DeploymentEnvironmentKey.String(config.Environment),
+		),
+		resource.WithProcess(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+	return res, nil
+}
+
+// MeterProvider returns the OTLP meter provider (nil if metrics are disabled)
+func (p *Provider) MeterProvider() *sdkmetric.MeterProvider {
+	if p == nil {
+		return nil
+	}
+	return p.mp
+}
+
+// LoggerProvider returns the OTLP logger provider (nil if logs are disabled)
+func (p *Provider) LoggerProvider() *sdklog.LoggerProvider {
+	if p == nil {
+		return nil
+	}
+	return p.lp
+}
+
+// Shutdown gracefully shuts down all providers, flushing pending traces, metrics and logs
 func (p *Provider) Shutdown(ctx context.Context) error {
-	if p == nil || p.tp == nil {
+	if p == nil {
 		return nil
 	}
 
-	// Give traces 5 seconds to flush
+	// Give all signals 5 seconds to flush
 	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	return p.tp.Shutdown(shutdownCtx)
+	var errs []error
+	if p.lp != nil {
+		errs = append(errs, p.lp.Shutdown(shutdownCtx))
+	}
+	if p.mp != nil {
+		errs = append(errs, p.mp.Shutdown(shutdownCtx))
+	}
+	if p.tp != nil {
+		errs = append(errs, p.tp.Shutdown(shutdownCtx))
+	}
+	return errors.Join(errs...)
 }
 
 // stripProtocol removes http:// or https:// from endpoint
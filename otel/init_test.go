@@ -0,0 +1,89 @@
+package otel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// otelEnv lists the environment variables read by configFromEnv
+var otelEnv = []string{
+	"OTEL_ENABLED", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_SERVICE_NAME", "OTEL_SAMPLING_RATIO",
+	"OTEL_ENVIRONMENT", "OTEL_METRICS_ENABLED", "OTEL_METRIC_EXPORT_INTERVAL", "OTEL_LOGS_ENABLED",
+}
+
+// TestConfigFromEnv tests the defaults and overrides of the environment-driven configuration
+func TestConfigFromEnv(t *testing.T) {
+	defaults := Config{
+		ServiceID:     "svc",
+		ServiceName:   "svc",
+		Version:       "1.0.0",
+		OTLPEndpoint:  "http://localhost:4318",
+		Enabled:       true,
+		SamplingRatio: 1.0,
+		Environment:   "development",
+	}
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		modify func(*Config)
+	}{
+		{
+			name:   "defaults keep metrics and logs off",
+			modify: func(c *Config) {},
+		},
+		{
+			name:   "disabled",
+			env:    map[string]string{"OTEL_ENABLED": "false", "OTEL_METRICS_ENABLED": "true"},
+			modify: func(c *Config) { *c = Config{ServiceID: "svc", ServiceName: "svc", Version: "1.0.0"} },
+		},
+		{
+			name: "metrics and logs opt in",
+			env:  map[string]string{"OTEL_METRICS_ENABLED": "true", "OTEL_LOGS_ENABLED": "true"},
+			modify: func(c *Config) {
+				c.EnableMetrics = true
+				c.EnableLogs = true
+			},
+		},
+		{
+			name:   "only the literal true opts in",
+			env:    map[string]string{"OTEL_METRICS_ENABLED": "1", "OTEL_LOGS_ENABLED": "yes"},
+			modify: func(c *Config) {},
+		},
+		{
+			name: "overrides",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318",
+				"OTEL_SERVICE_NAME":           "renamed",
+				"OTEL_SAMPLING_RATIO":         "0.25",
+				"OTEL_ENVIRONMENT":            "production",
+				"OTEL_METRIC_EXPORT_INTERVAL": "5000",
+			},
+			modify: func(c *Config) {
+				c.OTLPEndpoint = "http://collector:4318"
+				c.ServiceName = "renamed"
+				c.SamplingRatio = 0.25
+				c.Environment = "production"
+				c.MetricsInterval = 5 * time.Second
+			},
+		},
+		{
+			name:   "invalid values fall back",
+			env:    map[string]string{"OTEL_SAMPLING_RATIO": "half", "OTEL_METRIC_EXPORT_INTERVAL": "-1"},
+			modify: func(c *Config) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range otelEnv {
+				t.Setenv(name, tt.env[name])
+			}
+
+			want := defaults
+			tt.modify(&want)
+			assert.Equal(t, want, configFromEnv("svc", "1.0.0"))
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Package otel - OTLP log export and bridges for zerolog and logrus
+package otel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newLoggerProvider creates an OTLP logger provider and installs it globally
+func newLoggerProvider(ctx context.Context, config Config, res *resource.Resource) (*sdklog.LoggerProvider, error) {
+	exporter, err := otlploghttp.New(ctx,
+		otlploghttp.WithEndpoint(stripProtocol(config.OTLPEndpoint)),
+		otlploghttp.WithInsecure(), // Use HTTPS in production
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP log exporter: %w", err)
+	}
+
+	lp := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+	)
+
+	// Set global provider (used by ZerologHook and LogrusHook)
+	global.SetLoggerProvider(lp)
+
+	return lp, nil
+}
+
+// ZerologHook forwards zerolog events to the global OTLP LoggerProvider.
+//
+// Events logged with a context (zerolog.Event.Ctx or Logger.WithContext) carry the
+// trace and span IDs of the active span, both in the exported record and as
+// trace_id/span_id fields in the local output. zerolog does not expose fields added
+// to an event, so only the message, level and trace context are exported.
+//
+//	logger := zerolog.New(os.Stdout).Hook(otel.NewZerologHook("containerservice"))
+//	logger.Info().Ctx(ctx).Msg("container started")
+type ZerologHook struct {
+	logger log.Logger
+}
+
+// NewZerologHook creates a zerolog hook emitting to the instrumentation scope name
+func NewZerologHook(name string) *ZerologHook {
+	return &ZerologHook{logger: global.Logger(name)}
+}
+
+// Run implements zerolog.Hook
+func (h *ZerologHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level == zerolog.Disabled {
+		return
+	}
+	ctx := e.GetCtx()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		e.Str("trace_id", sc.TraceID().String())
+		e.Str("span_id", sc.SpanID().String())
+	}
+
+	var record log.Record
+	record.SetTimestamp(time.Now())
+	record.SetSeverity(zerologSeverity(level))
+	record.SetSeverityText(level.String())
+	record.SetBody(log.StringValue(msg))
+	record.AddAttributes(correlationAttributes(ctx)...)
+	h.logger.Emit(ctx, record)
+}
+
+// LogrusHook forwards logrus entries to the global OTLP LoggerProvider.
+//
+// Entry fields are exported as attributes. Entries with a context (WithContext)
+// carry the trace and span IDs of the active span, which are also added to the
+// entry as trace_id/span_id fields.
+//
+//	logger := common.NewLogger(common.DefaultLoggerConfig())
+//	logger.AddHook(otel.NewLogrusHook("containerservice"))
+type LogrusHook struct {
+	logger log.Logger
+}
+
+// NewLogrusHook creates a logrus hook emitting to the instrumentation scope name
+func NewLogrusHook(name string) *LogrusHook {
+	return &LogrusHook{logger: global.Logger(name)}
+}
+
+// Levels implements logrus.Hook
+func (h *LogrusHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h *LogrusHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		entry.Data["trace_id"] = sc.TraceID().String()
+		entry.Data["span_id"] = sc.SpanID().String()
+	}
+
+	var record log.Record
+	record.SetTimestamp(entry.Time)
+	record.SetSeverity(logrusSeverity(entry.Level))
+	record.SetSeverityText(entry.Level.String())
+	record.SetBody(log.StringValue(entry.Message))
+	for key, value := range entry.Data {
+		record.AddAttributes(logAttribute(key, value))
+	}
+	if _, ok := entry.Data["correlation_id"]; !ok {
+		record.AddAttributes(correlationAttributes(ctx)...)
+	}
+	h.logger.Emit(ctx, record)
+	return nil
+}
+
+// correlationAttributes returns the semantic workflow IDs stored in ctx by tracing.ContextWithTraceIDs
+func correlationAttributes(ctx context.Context) []log.KeyValue {
+	var attrs []log.KeyValue
+	for _, key := range []string{"correlation_id", "operation_id"} {
+		if id, ok := ctx.Value(key).(string); ok && id != "" {
+			attrs = append(attrs, log.String(key, id))
+		}
+	}
+	return attrs
+}
+
+// logAttribute converts a logrus field to a log attribute
+func logAttribute(key string, value interface{}) log.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return log.String(key, v)
+	case bool:
+		return log.Bool(key, v)
+	case int:
+		return log.Int(key, v)
+	case int64:
+		return log.Int64(key, v)
+	case float64:
+		return log.Float64(key, v)
+	case time.Duration:
+		return log.String(key, v.String())
+	case error:
+		return log.String(key, v.Error())
+	default:
+		return log.String(key, fmt.Sprint(v))
+	}
+}
+
+// zerologSeverity maps zerolog levels to OpenTelemetry severities
+func zerologSeverity(level zerolog.Level) log.Severity {
+	switch level {
+	case zerolog.TraceLevel:
+		return log.SeverityTrace
+	case zerolog.DebugLevel:
+		return log.SeverityDebug
+	case zerolog.InfoLevel:
+		return log.SeverityInfo
+	case zerolog.WarnLevel:
+		return log.SeverityWarn
+	case zerolog.ErrorLevel:
+		return log.SeverityError
+	case zerolog.FatalLevel:
+		return log.SeverityFatal
+	case zerolog.PanicLevel:
+		return log.SeverityFatal4
+	default:
+		return log.SeverityUndefined
+	}
+}
+
+// logrusSeverity maps logrus levels to OpenTelemetry severities
+func logrusSeverity(level logrus.Level) log.Severity {
+	switch level {
+	case logrus.TraceLevel:
+		return log.SeverityTrace
+	case logrus.DebugLevel:
+		return log.SeverityDebug
+	case logrus.InfoLevel:
+		return log.SeverityInfo
+	case logrus.WarnLevel:
+		return log.SeverityWarn
+	case logrus.ErrorLevel:
+		return log.SeverityError
+	case logrus.FatalLevel:
+		return log.SeverityFatal
+	case logrus.PanicLevel:
+		return log.SeverityFatal4
+	default:
+		return log.SeverityUndefined
+	}
+}
@@ -0,0 +1,145 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// memoryExporter keeps exported log records
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, record := range records {
+		e.records = append(e.records, record.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(ctx context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(ctx context.Context) error { return nil }
+
+// newTestLogger returns a logger exporting synchronously to a memoryExporter
+func newTestLogger(t *testing.T) (log.Logger, *memoryExporter) {
+	exporter := &memoryExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return provider.Logger("test"), exporter
+}
+
+// spanContext returns a context carrying a valid remote span context
+func spanContext() (context.Context, trace.SpanContext) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x0a, 0x0b},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	ctx = context.WithValue(ctx, "correlation_id", "corr-1") //nolint:staticcheck // key used by tracing.ContextWithTraceIDs
+	return ctx, sc
+}
+
+// attributes collects the attributes of a record
+func attributes(record sdklog.Record) map[string]string {
+	attrs := make(map[string]string)
+	record.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = kv.Value.String()
+		return true
+	})
+	return attrs
+}
+
+// TestZerologHook tests that zerolog events are exported with severity and trace context
+func TestZerologHook(t *testing.T) {
+	otelLogger, exporter := newTestLogger(t)
+	var out bytes.Buffer
+	logger := zerolog.New(&out).Hook(&ZerologHook{logger: otelLogger})
+
+	ctx, sc := spanContext()
+	logger.Warn().Ctx(ctx).Msg("disk almost full")
+	logger.Info().Msg("no context")
+	logger.Debug().Msg("debug")
+
+	require.Len(t, exporter.records, 3)
+	record := exporter.records[0]
+	assert.Equal(t, "disk almost full", record.Body().AsString())
+	assert.Equal(t, log.SeverityWarn, record.Severity())
+	assert.Equal(t, "warn", record.SeverityText())
+	assert.Equal(t, sc.TraceID(), record.TraceID())
+	assert.Equal(t, sc.SpanID(), record.SpanID())
+	assert.Equal(t, map[string]string{"correlation_id": "corr-1"}, attributes(record))
+
+	assert.Contains(t, out.String(), `"trace_id":"`+sc.TraceID().String()+`"`, "local output carries the trace ID")
+	assert.Contains(t, out.String(), `"span_id":"`+sc.SpanID().String()+`"`)
+
+	assert.False(t, exporter.records[1].TraceID().IsValid())
+	assert.Equal(t, log.SeverityInfo, exporter.records[1].Severity())
+	assert.Equal(t, log.SeverityDebug, exporter.records[2].Severity())
+}
+
+// TestLogrusHook tests that logrus entries are exported with fields as attributes
+func TestLogrusHook(t *testing.T) {
+	otelLogger, exporter := newTestLogger(t)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetLevel(logrus.DebugLevel)
+	logger.AddHook(&LogrusHook{logger: otelLogger})
+
+	ctx, sc := spanContext()
+	logger.WithContext(ctx).WithFields(logrus.Fields{
+		"container": "web",
+		"restarts":  3,
+		"healthy":   false,
+		"err":       errors.New("exit 137"),
+	}).Error("container crashed")
+
+	logger.WithField("correlation_id", "explicit").Debug("explicit correlation")
+
+	require.Len(t, exporter.records, 2)
+	record := exporter.records[0]
+	assert.Equal(t, "container crashed", record.Body().AsString())
+	assert.Equal(t, log.SeverityError, record.Severity())
+	assert.Equal(t, "error", record.SeverityText())
+	assert.Equal(t, sc.TraceID(), record.TraceID())
+	assert.Equal(t, map[string]string{
+		"container":      "web",
+		"restarts":       "3",
+		"healthy":        "false",
+		"err":            "exit 137",
+		"trace_id":       sc.TraceID().String(),
+		"span_id":        sc.SpanID().String(),
+		"correlation_id": "corr-1",
+	}, attributes(record))
+
+	assert.Equal(t, map[string]string{"correlation_id": "explicit"}, attributes(exporter.records[1]),
+		"explicit correlation fields are not overridden by the context")
+	assert.Equal(t, logrus.AllLevels, (&LogrusHook{}).Levels())
+}
+
+// TestSeverityMapping tests the zerolog and logrus level mappings
+func TestSeverityMapping(t *testing.T) {
+	assert.Equal(t, log.SeverityTrace, zerologSeverity(zerolog.TraceLevel))
+	assert.Equal(t, log.SeverityError, zerologSeverity(zerolog.ErrorLevel))
+	assert.Equal(t, log.SeverityFatal4, zerologSeverity(zerolog.PanicLevel))
+	assert.Equal(t, log.SeverityUndefined, zerologSeverity(zerolog.NoLevel))
+
+	assert.Equal(t, log.SeverityTrace, logrusSeverity(logrus.TraceLevel))
+	assert.Equal(t, log.SeverityWarn, logrusSeverity(logrus.WarnLevel))
+	assert.Equal(t, log.SeverityFatal4, logrusSeverity(logrus.PanicLevel))
+}
@@ -0,0 +1,51 @@
+// Package otel - OTLP metrics export
+package otel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	promb "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// DefaultMetricsInterval is the default OTLP metrics export interval
+const DefaultMetricsInterval = 60 * time.Second
+
+// newMeterProvider creates an OTLP meter provider and installs it globally.
+//
+// The reader also collects the default Prometheus registry, so the instruments of
+// tracing.Metrics (action durations, exporter queue, GDPR, storage and sampling
+// metrics) are exported over OTLP under their Prometheus names without being
+// declared twice. Instruments created through otel.Meter are exported as well.
+func newMeterProvider(ctx context.Context, config Config, res *resource.Resource) (*metric.MeterProvider, error) {
+	exporter, err := otlpmetrichttp.New(ctx,
+		otlpmetrichttp.WithEndpoint(stripProtocol(config.OTLPEndpoint)),
+		otlpmetrichttp.WithInsecure(), // Use HTTPS in production
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	interval := config.MetricsInterval
+	if interval <= 0 {
+		interval = DefaultMetricsInterval
+	}
+
+	mp := metric.NewMeterProvider(
+		metric.WithResource(res),
+		metric.WithReader(metric.NewPeriodicReader(exporter,
+			metric.WithInterval(interval),
+			metric.WithProducer(promb.NewMetricProducer()),
+		)),
+	)
+
+	// Set global provider
+	otel.SetMeterProvider(mp)
+
+	return mp, nil
+}
@@ -2,6 +2,7 @@ package semantic
 
 import (
 	"fmt"
+	"net/http"
 	"sync"
 
 	"github.com/labstack/echo/v4"
@@ -11,10 +12,16 @@ import (
 // The action parameter can be either *SemanticAction or *SemanticScheduledAction
 type ActionHandler func(c echo.Context, action interface{}) error
 
+// ActionValidator checks an action before it is dispatched to its handler.
+// The action is either *SemanticAction or *SemanticScheduledAction; a non-nil
+// error rejects the action with 400 Bad Request.
+type ActionValidator func(action interface{}) error
+
 // ActionRegistry manages action handlers
 type ActionRegistry struct {
-	handlers map[string]ActionHandler
-	mu       sync.RWMutex
+	handlers   map[string]ActionHandler
+	validators []ActionValidator
+	mu         sync.RWMutex
 }
 
 // NewActionRegistry creates a new action registry
@@ -53,6 +60,14 @@ func (r *ActionRegistry) Unregister(actionType string) {
 	delete(r.handlers, actionType)
 }
 
+// AddValidator registers a validator that runs before every handler,
+// e.g. a SHACL shape check. Validators run in the order they were added.
+func (r *ActionRegistry) AddValidator(validator ActionValidator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.validators = append(r.validators, validator)
+}
+
 // Handle dispatches an action to the appropriate handler
 // Accepts either *SemanticAction or *SemanticScheduledAction
 func (r *ActionRegistry) Handle(c echo.Context, actionInterface interface{}) error {
@@ -72,12 +87,19 @@ func (r *ActionRegistry) Handle(c echo.Context, actionInterface interface{}) err
 
 	r.mu.RLock()
 	handler, exists := r.handlers[actionType]
+	validators := r.validators
 	r.mu.RUnlock()
 
 	if !exists {
 		return ReturnActionError(c, baseAction, fmt.Sprintf("Unsupported action type: %s", actionType), nil)
 	}
 
+	for _, validate := range validators {
+		if err := validate(actionInterface); err != nil {
+			return ReturnActionErrorWithStatus(c, baseAction, http.StatusBadRequest, "Action validation failed", err)
+		}
+	}
+
 	return handler(c, actionInterface)
 }
 
@@ -120,6 +142,11 @@ func Handle(c echo.Context, action interface{}) error {
 	return DefaultRegistry.Handle(c, action)
 }
 
+// AddValidator is a convenience function that adds a validator to the default registry
+func AddValidator(validator ActionValidator) {
+	DefaultRegistry.AddValidator(validator)
+}
+
 // GetRegisteredActions returns registered actions from the default registry
 func GetRegisteredActions() []string {
 	return DefaultRegistry.GetRegisteredActions()
@@ -0,0 +1,179 @@
+package semantic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CouchDB Semantic Types for Replication
+// These types map CouchDB replication to Schema.org vocabulary for semantic orchestration
+
+// ============================================================================
+// CouchDB Database Types (Schema.org: DataCatalog)
+// ============================================================================
+
+// CouchDBDatabase represents a CouchDB database as Schema.org DataCatalog
+type CouchDBDatabase struct {
+	Context    string                 `json:"@context,omitempty"`
+	Type       string                 `json:"@type"`      // "DataCatalog"
+	Identifier string                 `json:"identifier"` // Database name
+	Name       string                 `json:"name,omitempty"`
+	URL        string                 `json:"url,omitempty"` // CouchDB server URL, empty for the service's own server
+	Properties map[string]interface{} `json:"additionalProperty,omitempty"`
+}
+
+// CouchDBReplicationOptions are the replication settings of a replicate action
+type CouchDBReplicationOptions struct {
+	Continuous   bool                   `json:"continuous,omitempty"`
+	CreateTarget bool                   `json:"createTarget,omitempty"`
+	Selector     map[string]interface{} `json:"selector,omitempty"`
+	DocIDs       []string               `json:"docIds,omitempty"`
+	Wait         bool                   `json:"wait,omitempty"` // Wait for one-shot replications to complete
+}
+
+// ============================================================================
+// Helper Functions
+// ============================================================================
+
+// NewCouchDBDatabase creates a new semantic CouchDB database representation.
+// serverURL may be empty for a database on the executing service's server.
+func NewCouchDBDatabase(name, serverURL, username, password string) *CouchDBDatabase {
+	database := &CouchDBDatabase{
+		Context:    "https://schema.org",
+		Type:       "DataCatalog",
+		Identifier: name,
+		Name:       name,
+		URL:        serverURL,
+		Properties: make(map[string]interface{}),
+	}
+
+	if username != "" {
+		database.Properties["username"] = username
+	}
+	if password != "" {
+		database.Properties["password"] = password
+	}
+
+	return database
+}
+
+// NewSemanticCouchDBReplicateAction creates a CouchDB replication as a TransferAction
+func NewSemanticCouchDBReplicateAction(id, name string, source, target *CouchDBDatabase, options CouchDBReplicationOptions) *SemanticAction {
+	action := NewSemanticTransferAction(id, name, nil, nil, nil)
+
+	if source != nil {
+		action.Properties["fromLocation"] = source
+	}
+	if target != nil {
+		action.Properties["toLocation"] = target
+	}
+	if options.Continuous {
+		action.Properties["continuous"] = true
+	}
+	if options.CreateTarget {
+		action.Properties["createTarget"] = true
+	}
+	if len(options.Selector) > 0 {
+		action.Properties["selector"] = options.Selector
+	}
+	if len(options.DocIDs) > 0 {
+		action.Properties["docIds"] = options.DocIDs
+	}
+	if options.Wait {
+		action.Properties["wait"] = true
+	}
+
+	return action
+}
+
+// ExtractCouchDBCredentials extracts connection details from a CouchDBDatabase
+// Returns: databaseURL (the database name if no server URL is set), username, password, error
+func ExtractCouchDBCredentials(database *CouchDBDatabase) (string, string, string, error) {
+	if database == nil {
+		return "", "", "", fmt.Errorf("database is nil")
+	}
+	if database.Identifier == "" {
+		return "", "", "", fmt.Errorf("database identifier is empty")
+	}
+
+	databaseURL := database.Identifier
+	if database.URL != "" {
+		databaseURL = strings.TrimSuffix(database.URL, "/") + "/" + database.Identifier
+	}
+
+	var username, password string
+	if database.Properties != nil {
+		username, _ = database.Properties["username"].(string)
+		password, _ = database.Properties["password"].(string)
+	}
+
+	return databaseURL, username, password, nil
+}
+
+// GetCouchDBDatabaseFromAction extracts a CouchDBDatabase from SemanticAction properties
+// or from its fromLocation/toLocation fields
+func GetCouchDBDatabaseFromAction(action *SemanticAction, propertyKey string) (*CouchDBDatabase, error) {
+	if action == nil {
+		return nil, fmt.Errorf("action is nil")
+	}
+
+	var prop interface{}
+	if action.Properties != nil {
+		prop = action.Properties[propertyKey]
+	}
+	if prop == nil {
+		switch propertyKey {
+		case "fromLocation":
+			prop = action.FromLocation
+		case "toLocation":
+			prop = action.ToLocation
+		}
+	}
+	if prop == nil {
+		return nil, fmt.Errorf("no %s found in action properties", propertyKey)
+	}
+
+	switch v := prop.(type) {
+	case *CouchDBDatabase:
+		return v, nil
+	case CouchDBDatabase:
+		return &v, nil
+	case map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal CouchDBDatabase: %w", err)
+		}
+		var database CouchDBDatabase
+		if err := json.Unmarshal(data, &database); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal CouchDBDatabase: %w", err)
+		}
+		return &database, nil
+	default:
+		return nil, fmt.Errorf("unexpected %s type: %T", propertyKey, prop)
+	}
+}
+
+// GetCouchDBReplicationOptionsFromAction extracts replication settings from SemanticAction properties
+func GetCouchDBReplicationOptionsFromAction(action *SemanticAction) (CouchDBReplicationOptions, error) {
+	var options CouchDBReplicationOptions
+	if action == nil || action.Properties == nil {
+		return options, nil
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"continuous":   action.Properties["continuous"],
+		"createTarget": action.Properties["createTarget"],
+		"selector":     action.Properties["selector"],
+		"docIds":       action.Properties["docIds"],
+		"wait":         action.Properties["wait"],
+	})
+	if err != nil {
+		return options, fmt.Errorf("failed to marshal replication options: %w", err)
+	}
+	if err := json.Unmarshal(data, &options); err != nil {
+		return options, fmt.Errorf("invalid replication options: %w", err)
+	}
+
+	return options, nil
+}
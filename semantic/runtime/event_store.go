@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"eve.evalgo.org/db"
+	"eve.evalgo.org/db/migrate"
 )
 
 // EventStore manages Event storage in PostgreSQL for audit trails
@@ -197,30 +198,13 @@ func (s *EventStore) GetEventStats(ctx context.Context, workflowID string, from,
 	return stats, rows.Err()
 }
 
-// CreateTables creates the necessary database tables if they don't exist
+// CreateTables creates or upgrades the event store tables.
+// Applies the embedded migrations registered as EventStoreSchemaComponent.
 func (s *EventStore) CreateTables(ctx context.Context) error {
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS workflow_events (
-		id BIGSERIAL PRIMARY KEY,
-		event_id VARCHAR(255) NOT NULL,
-		workflow_id VARCHAR(255),
-		action_id VARCHAR(255),
-		event_type VARCHAR(100),
-		event_data JSONB NOT NULL,
-		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
-		UNIQUE(event_id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_workflow_events_workflow_id ON workflow_events(workflow_id);
-	CREATE INDEX IF NOT EXISTS idx_workflow_events_action_id ON workflow_events(action_id);
-	CREATE INDEX IF NOT EXISTS idx_workflow_events_event_type ON workflow_events(event_type);
-	CREATE INDEX IF NOT EXISTS idx_workflow_events_created_at ON workflow_events(created_at);
-	`
-
-	err := s.db.Exec(ctx, createTableSQL)
-	if err != nil {
+	migrator := migrate.NewFromPool(s.db.Pool())
+	defer migrator.Close()
+	if err := migrator.Up(ctx, EventStoreSchemaComponent); err != nil {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
-
 	return nil
 }
@@ -0,0 +1,17 @@
+package runtime
+
+import (
+	"embed"
+
+	"eve.evalgo.org/db/migrate"
+)
+
+// EventStoreSchemaComponent identifies the event store schema in schema_migrations
+const EventStoreSchemaComponent = "event_store"
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+func init() {
+	migrate.Register(migrate.MustLoad(EventStoreSchemaComponent, migrationFS, "migrations"))
+}
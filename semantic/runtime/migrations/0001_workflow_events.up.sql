@@ -0,0 +1,17 @@
+-- Workflow event audit trail used by EventStore.
+
+CREATE TABLE IF NOT EXISTS workflow_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL,
+    workflow_id VARCHAR(255),
+    action_id VARCHAR(255),
+    event_type VARCHAR(100),
+    event_data JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE(event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_events_workflow_id ON workflow_events(workflow_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_events_action_id ON workflow_events(action_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_events_event_type ON workflow_events(event_type);
+CREATE INDEX IF NOT EXISTS idx_workflow_events_created_at ON workflow_events(created_at);
@@ -0,0 +1,245 @@
+package semantic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Workflow template types
+// A template is an immutable, versioned workflow definition together with the
+// parameters it accepts. Instances are created from a specific template version.
+
+// SemanticWorkflowTemplate represents one immutable version of a stored workflow
+type SemanticWorkflowTemplate struct {
+	Context     string                  `json:"@context"`
+	Type        string                  `json:"@type"` // Must be "WorkflowTemplate"
+	Identifier  string                  `json:"identifier"`
+	Version     int                     `json:"version"`
+	Name        string                  `json:"name,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	VersionNote string                  `json:"versionNote,omitempty"` // What changed in this version
+	Parameters  []WorkflowParameterSpec `json:"parameterSpec,omitempty"`
+	Workflow    json.RawMessage         `json:"workEncoded"`         // Original workflow JSON-LD (ItemList, HowTo, ...)
+	BasedOn     int                     `json:"isBasedOn,omitempty"` // Version this one was derived from (e.g. by rollback)
+	Created     time.Time               `json:"dateCreated"`
+}
+
+// WorkflowParameterSpec declares a parameter accepted by a workflow template
+// Mirrors Schema.org PropertyValueSpecification on top of PropertyValueSpec
+type WorkflowParameterSpec struct {
+	PropertyValueSpec
+	Required     bool        `json:"valueRequired,omitempty"`
+	DefaultValue interface{} `json:"defaultValue,omitempty"`
+}
+
+// WorkflowTemplateType is the @type of stored workflow templates
+const WorkflowTemplateType = "WorkflowTemplate"
+
+// TemplateRef formats a template reference as "identifier@version"
+func TemplateRef(identifier string, version int) string {
+	return fmt.Sprintf("%s@%d", identifier, version)
+}
+
+// ParseTemplateRef splits "identifier@version" into its parts
+// A reference without a version returns version 0 (latest)
+func ParseTemplateRef(ref string) (string, int, error) {
+	idx := strings.LastIndex(ref, "@")
+	if idx <= 0 {
+		return ref, 0, nil
+	}
+	version, err := strconv.Atoi(ref[idx+1:])
+	if err != nil || version < 1 {
+		return "", 0, fmt.Errorf("invalid template version in reference '%s'", ref)
+	}
+	return ref[:idx], version, nil
+}
+
+// ParameterError describes a single invalid parameter
+type ParameterError struct {
+	Name    string
+	Message string
+}
+
+func (e ParameterError) Error() string {
+	return fmt.Sprintf("parameter '%s': %s", e.Name, e.Message)
+}
+
+// ParameterValidationError collects all parameter errors of one instantiation
+type ParameterValidationError struct {
+	Errors []ParameterError
+}
+
+func (e *ParameterValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, pe := range e.Errors {
+		msgs[i] = pe.Error()
+	}
+	return "invalid workflow parameters: " + strings.Join(msgs, "; ")
+}
+
+// ValidateParameters checks supplied values against the template parameter schema.
+// It returns the effective parameters with defaults applied and values coerced
+// to their declared valueType. Unknown parameters are rejected.
+func (t *SemanticWorkflowTemplate) ValidateParameters(supplied map[string]interface{}) (map[string]interface{}, error) {
+	return ValidateParameters(t.Parameters, supplied)
+}
+
+// ValidateParameters checks supplied values against a parameter schema.
+// See SemanticWorkflowTemplate.ValidateParameters.
+func ValidateParameters(specs []WorkflowParameterSpec, supplied map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(specs))
+	var errs []ParameterError
+
+	declared := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		declared[spec.Name] = true
+
+		value, ok := supplied[spec.Name]
+		if !ok || value == nil {
+			if spec.DefaultValue != nil {
+				value = spec.DefaultValue
+			} else if spec.Required {
+				errs = append(errs, ParameterError{Name: spec.Name, Message: "required parameter missing"})
+				continue
+			} else {
+				continue
+			}
+		}
+
+		coerced, err := coerceParameter(spec.ValueType, value)
+		if err != nil {
+			errs = append(errs, ParameterError{Name: spec.Name, Message: err.Error()})
+			continue
+		}
+		result[spec.Name] = coerced
+	}
+
+	var unknown []string
+	for name := range supplied {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, ParameterError{Name: name, Message: "unknown parameter"})
+	}
+
+	if len(errs) > 0 {
+		return nil, &ParameterValidationError{Errors: errs}
+	}
+	return result, nil
+}
+
+// coerceParameter converts a value to the given Schema.org valueType
+func coerceParameter(valueType string, value interface{}) (interface{}, error) {
+	switch valueType {
+	case "", "Text":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64, int, int64, bool:
+			return fmt.Sprint(v), nil
+		}
+		return nil, fmt.Errorf("expected Text, got %T", value)
+
+	case "Number", "Integer":
+		var n float64
+		switch v := value.(type) {
+		case float64:
+			n = v
+		case int:
+			n = float64(v)
+		case int64:
+			n = float64(v)
+		case json.Number:
+			f, err := v.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("expected %s, got '%s'", valueType, v)
+			}
+			n = f
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("expected %s, got '%s'", valueType, v)
+			}
+			n = f
+		default:
+			return nil, fmt.Errorf("expected %s, got %T", valueType, value)
+		}
+		if valueType == "Integer" {
+			if n != float64(int64(n)) {
+				return nil, fmt.Errorf("expected Integer, got %v", n)
+			}
+			return int64(n), nil
+		}
+		return n, nil
+
+	case "Boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("expected Boolean, got '%s'", v)
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected Boolean, got %T", value)
+
+	case "URL":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected URL, got %T", value)
+		}
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" {
+			return nil, fmt.Errorf("expected absolute URL, got '%s'", s)
+		}
+		return s, nil
+
+	case "Date", "DateTime":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected %s, got %T", valueType, value)
+		}
+		layout := time.RFC3339
+		if valueType == "Date" {
+			layout = "2006-01-02"
+		}
+		if _, err := time.Parse(layout, s); err != nil {
+			return nil, fmt.Errorf("expected %s, got '%s'", valueType, s)
+		}
+		return s, nil
+
+	case "StructuredValue":
+		return value, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported valueType '%s'", valueType)
+	}
+}
+
+// StringParameters converts effective parameters to strings, e.g. for
+// runtime.NewWorkflowStartedEvent
+func StringParameters(params map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(params))
+	for k, v := range params {
+		switch val := v.(type) {
+		case string:
+			out[k] = val
+		case map[string]interface{}, []interface{}:
+			data, _ := json.Marshal(val)
+			out[k] = string(data)
+		default:
+			out[k] = fmt.Sprint(val)
+		}
+	}
+	return out
+}
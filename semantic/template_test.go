@@ -0,0 +1,68 @@
+package semantic
+
+import (
+	"errors"
+	"testing"
+)
+
+func testParameterSpecs() []WorkflowParameterSpec {
+	return []WorkflowParameterSpec{
+		{PropertyValueSpec: PropertyValueSpec{Type: "PropertyValue", Name: "repository", ValueType: "URL"}, Required: true},
+		{PropertyValueSpec: PropertyValueSpec{Type: "PropertyValue", Name: "retries", ValueType: "Integer"}, DefaultValue: float64(3)},
+		{PropertyValueSpec: PropertyValueSpec{Type: "PropertyValue", Name: "dryRun", ValueType: "Boolean"}},
+	}
+}
+
+func TestValidateParameters_AppliesDefaultsAndCoerces(t *testing.T) {
+	params, err := ValidateParameters(testParameterSpecs(), map[string]interface{}{
+		"repository": "https://git.example.com/repo.git",
+		"dryRun":     "true",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if params["retries"] != int64(3) {
+		t.Errorf("expected default retries 3, got %#v", params["retries"])
+	}
+	if params["dryRun"] != true {
+		t.Errorf("expected dryRun coerced to true, got %#v", params["dryRun"])
+	}
+	if params["repository"] != "https://git.example.com/repo.git" {
+		t.Errorf("unexpected repository: %#v", params["repository"])
+	}
+}
+
+func TestValidateParameters_ReportsAllErrors(t *testing.T) {
+	_, err := ValidateParameters(testParameterSpecs(), map[string]interface{}{
+		"retries": 1.5,
+		"unknown": "x",
+	})
+
+	var verr *ParameterValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ParameterValidationError, got %v", err)
+	}
+	if len(verr.Errors) != 3 {
+		t.Fatalf("expected 3 errors (missing, non-integer, unknown), got %d: %v", len(verr.Errors), verr)
+	}
+	if verr.Errors[0].Name != "repository" || verr.Errors[1].Name != "retries" || verr.Errors[2].Name != "unknown" {
+		t.Errorf("unexpected error order: %v", verr)
+	}
+}
+
+func TestParseTemplateRef(t *testing.T) {
+	id, version, err := ParseTemplateRef(TemplateRef("deploy", 12))
+	if err != nil || id != "deploy" || version != 12 {
+		t.Errorf("round trip failed: %s %d %v", id, version, err)
+	}
+
+	id, version, err = ParseTemplateRef("deploy")
+	if err != nil || id != "deploy" || version != 0 {
+		t.Errorf("expected latest reference, got %s %d %v", id, version, err)
+	}
+
+	if _, _, err := ParseTemplateRef("deploy@latest"); err == nil {
+		t.Error("expected error for non-numeric version")
+	}
+}
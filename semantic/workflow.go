@@ -53,6 +53,24 @@ type SemanticHowToStep struct {
 	ItemListElement interface{} `json:"itemListElement"` // Can be ScheduledAction, ItemList, or HowTo
 }
 
+// SemanticWorkflowInvocation represents a step that runs another stored workflow
+// as a sub-workflow. The referenced template is expanded with its own instance ID
+// and linked to the calling workflow through parent/root lineage.
+type SemanticWorkflowInvocation struct {
+	Context     string                 `json:"@context,omitempty"`
+	Type        string                 `json:"@type"` // Must be "SubWorkflowAction"
+	ID          string                 `json:"@id,omitempty"`
+	Identifier  string                 `json:"identifier,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Workflow    string                 `json:"exampleOfWork"`                // Template to run ("identifier" or "identifier@version")
+	Parameters  map[string]interface{} `json:"additionalProperty,omitempty"` // Values substituted into ${name} references of the template
+	Requires    []string               `json:"requires,omitempty"`           // Dependencies (Action @id references in the calling workflow)
+}
+
+// SubWorkflowActionType is the @type of a step that invokes a stored workflow
+const SubWorkflowActionType = "SubWorkflowAction"
+
 // SemanticDigitalDocument represents the target of an HTTP action
 type SemanticDigitalDocument struct {
 	Type       string            `json:"@type"` // Must be "DigitalDocument"
@@ -87,12 +105,13 @@ const (
 // WorkflowAction is an internal representation of an action in a workflow
 // This is NOT a Schema.org type - it's for internal use
 type WorkflowAction struct {
-	Type      string                   // "action", "loop", "step"
-	Action    *SemanticScheduledAction // For single actions
-	Loop      *SemanticItemList        // For loops
-	DependsOn []string                 // Task dependencies
-	Variables map[string]interface{}   // For variable substitution
-	Position  int
+	Type        string                      // "action", "loop", "step", "subworkflow"
+	Action      *SemanticScheduledAction    // For single actions
+	Loop        *SemanticItemList           // For loops
+	SubWorkflow *SemanticWorkflowInvocation // For sub-workflow invocations
+	DependsOn   []string                    // Task dependencies
+	Variables   map[string]interface{}      // For variable substitution
+	Position    int
 }
 
 // LoopExecutionState tracks the state of a loop during execution
@@ -6,6 +6,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/url"
 	"time"
 
@@ -219,6 +220,63 @@ func (c *CouchDBClient) FindAll(ctx context.Context, selector map[string]interfa
 	return docs, nil
 }
 
+// PutAttachment uploads an attachment, streaming its content, and returns the
+// new document revision. rev may be empty to create the document.
+func (c *CouchDBClient) PutAttachment(ctx context.Context, docID, rev, filename, contentType string, content io.Reader) (string, error) {
+	var options []kivik.Option
+	if rev != "" {
+		options = append(options, kivik.Rev(rev))
+	}
+	newRev, err := c.database.PutAttachment(ctx, docID, &kivik.Attachment{
+		Filename:    url.PathEscape(filename),
+		ContentType: contentType,
+		Content:     io.NopCloser(content),
+	}, options...)
+	if err != nil {
+		return "", fmt.Errorf("failed to put attachment: %w", err)
+	}
+	return newRev, nil
+}
+
+// GetAttachment opens an attachment for streaming. The caller must close its Content.
+func (c *CouchDBClient) GetAttachment(ctx context.Context, docID, filename string) (*kivik.Attachment, error) {
+	att, err := c.database.GetAttachment(ctx, docID, url.PathEscape(filename))
+	if err != nil {
+		if kivik.HTTPStatus(err) == 404 {
+			return nil, fmt.Errorf("attachment not found: %s/%s", docID, filename)
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	att.Filename = filename
+	return att, nil
+}
+
+// ListAttachments returns the attachment stubs of a document
+func (c *CouchDBClient) ListAttachments(ctx context.Context, docID string) (map[string]*kivik.Attachment, error) {
+	var doc struct {
+		Attachments map[string]*kivik.Attachment `json:"_attachments"`
+	}
+	if err := c.GetDocument(ctx, docID, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Attachments == nil {
+		doc.Attachments = map[string]*kivik.Attachment{}
+	}
+	for name, att := range doc.Attachments {
+		att.Filename = name
+	}
+	return doc.Attachments, nil
+}
+
+// DeleteAttachment deletes an attachment and returns the new document revision
+func (c *CouchDBClient) DeleteAttachment(ctx context.Context, docID, rev, filename string) (string, error) {
+	newRev, err := c.database.DeleteAttachment(ctx, docID, rev, url.PathEscape(filename))
+	if err != nil {
+		return "", fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return newRev, nil
+}
+
 // DatabaseExists checks if a database exists
 func (c *CouchDBClient) DatabaseExists(ctx context.Context, dbName string) (bool, error) {
 	exists, err := c.client.DBExists(ctx, dbName)
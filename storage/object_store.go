@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrObjectNotFound is returned (wrapped) by ObjectStore implementations when a key does not exist.
+// Callers should test for it with errors.Is.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrPresignUnsupported is returned by Presigner implementations whose backend cannot sign URLs.
+var ErrPresignUnsupported = errors.New("presigned URLs not supported")
+
+// ObjectInfo describes a stored object.
+//
+// Fields:
+//   - Key: Object key relative to the store (backend prefixes and branches are not included)
+//   - Size: Object size in bytes
+//   - ContentType: MIME type recorded at upload time
+//   - ETag: Backend entity tag (an MD5 only for single-part S3 uploads)
+//   - LastModified: Time of the last write
+//   - Metadata: User metadata recorded at upload time
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+	Metadata     map[string]string
+}
+
+// PutOptions controls how an object is written.
+//
+// Size may be set to the exact content length when known; -1 or 0 with a
+// non-seekable reader lets the backend stream the content (S3 uses multipart
+// upload in that case).
+type PutOptions struct {
+	ContentType string
+	Metadata    map[string]string
+	Size        int64
+}
+
+// ListOptions filters object listings.
+//
+// Prefix limits results to keys starting with the prefix. Delimiter groups keys
+// like directories (only keys without the delimiter after the prefix are returned).
+// MaxKeys limits the number of results (0 = unlimited).
+type ListOptions struct {
+	Prefix    string
+	Delimiter string
+	MaxKeys   int
+}
+
+// ObjectStore is the unified abstraction over object storage backends.
+// It replaces the vendor-specific free functions (MinioGetObject, HetznerUploadFile,
+// LakeFSListObjects, ...) for new code, so S3 actions, tracing payload storage and
+// archival can share one implementation and be tested against a local directory.
+//
+// Implementations:
+//   - S3ObjectStore: AWS S3, MinIO, Hetzner and other S3-compatible endpoints
+//   - LakeFSObjectStore: LakeFS S3 gateway with branch-aware keys
+//   - LocalObjectStore: Local directory (tests and single-node deployments)
+//
+// All methods are safe for concurrent use. Get returns a stream that the caller
+// must close. Missing keys produce errors wrapping ErrObjectNotFound.
+type ObjectStore interface {
+	// Put writes the content of r to key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error)
+
+	// Get opens the object at key for reading
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+
+	// Stat returns object metadata without reading the content
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+
+	// List returns objects matching the options, ordered by key
+	List(ctx context.Context, opts ListOptions) ([]ObjectInfo, error)
+
+	// Delete removes the object at key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+
+	// Copy duplicates srcKey to dstKey within the same store
+	Copy(ctx context.Context, srcKey, dstKey string) (*ObjectInfo, error)
+}
+
+// Presigner is implemented by stores that can hand out temporary download URLs.
+// Callers should type-assert an ObjectStore and fall back to streaming through
+// Get when the store does not implement it or returns ErrPresignUnsupported.
+type Presigner interface {
+	// PresignGet returns a URL granting read access to key until expires elapses
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// GetBytes reads a whole object into memory.
+// Intended for small payloads such as JSON documents and logs.
+func GetBytes(ctx context.Context, store ObjectStore, key string) ([]byte, error) {
+	body, _, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// validateKey rejects keys that are empty, absolute or escape the store root
+func validateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("object key must not be empty")
+	}
+	if strings.HasPrefix(key, "/") {
+		return fmt.Errorf("object key %q must be relative", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return fmt.Errorf("object key %q must not contain '..'", key)
+		}
+	}
+	return nil
+}
+
+// joinKey joins a store prefix and a key with a single slash
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return path.Join(prefix, key)
+}
+
+// matchesDelimiter reports whether key (relative to prefix) has no delimiter,
+// i.e. it is a direct child when listing with a delimiter
+func matchesDelimiter(key, prefix, delimiter string) bool {
+	if delimiter == "" {
+		return true
+	}
+	return !strings.Contains(strings.TrimPrefix(key, prefix), delimiter)
+}
@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// localMetaDir holds per-object metadata sidecars inside the store root
+const localMetaDir = ".objectstore"
+
+// LocalObjectStore implements ObjectStore on a local directory.
+// Objects are regular files below the root; content type and user metadata are
+// kept in JSON sidecars under root/.objectstore so the files stay usable directly.
+// Writes go to a temporary file first and are renamed into place, so readers
+// never observe partial objects.
+type LocalObjectStore struct {
+	root string
+}
+
+// localObjectMeta is the sidecar content for one object
+type localObjectMeta struct {
+	ContentType string            `json:"contentType,omitempty"`
+	ETag        string            `json:"etag"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// NewLocalObjectStore creates a store rooted at dir, creating it if needed
+func NewLocalObjectStore(dir string) (*LocalObjectStore, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create object store directory %s: %w", abs, err)
+	}
+	return &LocalObjectStore{root: abs}, nil
+}
+
+// Root returns the directory backing the store
+func (l *LocalObjectStore) Root() string {
+	return l.root
+}
+
+// Put writes r to key atomically
+func (l *LocalObjectStore) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(key, localMetaDir+"/") || key == localMetaDir {
+		return nil, fmt.Errorf("object key %q uses the reserved %s prefix", key, localMetaDir)
+	}
+
+	target := l.objectPath(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file for %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := md5.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), &contextReader{ctx: ctx, r: r}); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", key, err)
+	}
+
+	meta := localObjectMeta{
+		ContentType: opts.ContentType,
+		ETag:        hex.EncodeToString(hash.Sum(nil)),
+		Metadata:    opts.Metadata,
+	}
+	if err := l.writeMeta(key, meta); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", key, err)
+	}
+
+	return l.Stat(ctx, key)
+}
+
+// Get opens the file backing key
+func (l *LocalObjectStore) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	info, err := l.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(l.objectPath(key))
+	if err != nil {
+		return nil, nil, l.wrapError("get", key, err)
+	}
+	return file, info, nil
+}
+
+// Stat returns file information merged with the metadata sidecar
+func (l *LocalObjectStore) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(l.objectPath(key))
+	if err != nil {
+		return nil, l.wrapError("stat", key, err)
+	}
+	if fi.IsDir() {
+		return nil, fmt.Errorf("stat %s: %w", key, ErrObjectNotFound)
+	}
+	return l.info(key, fi), nil
+}
+
+// List walks the directory tree and returns matching objects
+func (l *LocalObjectStore) List(ctx context.Context, opts ListOptions) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	err := filepath.WalkDir(l.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		rel, relErr := filepath.Rel(l.root, p)
+		if relErr != nil {
+			return relErr
+		}
+		key := filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if key == localMetaDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		if !strings.HasPrefix(key, opts.Prefix) || !matchesDelimiter(key, opts.Prefix, opts.Delimiter) {
+			return nil
+		}
+
+		fi, infoErr := d.Info()
+		if infoErr != nil {
+			return infoErr
+		}
+		objects = append(objects, *l.info(key, fi))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", l.root, err)
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	if opts.MaxKeys > 0 && len(objects) > opts.MaxKeys {
+		objects = objects[:opts.MaxKeys]
+	}
+	return objects, nil
+}
+
+// Delete removes the object and its sidecar
+func (l *LocalObjectStore) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(l.objectPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	if err := os.Remove(l.metaPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete metadata for %s: %w", key, err)
+	}
+	return nil
+}
+
+// Copy duplicates srcKey (content and metadata) to dstKey
+func (l *LocalObjectStore) Copy(ctx context.Context, srcKey, dstKey string) (*ObjectInfo, error) {
+	body, info, err := l.Get(ctx, srcKey)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return l.Put(ctx, dstKey, body, PutOptions{
+		ContentType: info.ContentType,
+		Metadata:    info.Metadata,
+		Size:        info.Size,
+	})
+}
+
+func (l *LocalObjectStore) objectPath(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(key))
+}
+
+func (l *LocalObjectStore) metaPath(key string) string {
+	return filepath.Join(l.root, localMetaDir, filepath.FromSlash(key)+".json")
+}
+
+func (l *LocalObjectStore) writeMeta(key string, meta localObjectMeta) error {
+	p := l.metaPath(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create metadata directory for %s: %w", key, err)
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return fmt.Errorf("failed to write metadata for %s: %w", key, err)
+	}
+	return nil
+}
+
+// info builds ObjectInfo from file info and the optional sidecar
+func (l *LocalObjectStore) info(key string, fi fs.FileInfo) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		LastModified: fi.ModTime(),
+	}
+	if data, err := os.ReadFile(l.metaPath(key)); err == nil {
+		var meta localObjectMeta
+		if json.Unmarshal(data, &meta) == nil {
+			info.ContentType = meta.ContentType
+			info.ETag = meta.ETag
+			info.Metadata = meta.Metadata
+		}
+	}
+	return info
+}
+
+func (l *LocalObjectStore) wrapError(op, key string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s %s: %w", op, key, ErrObjectNotFound)
+	}
+	return fmt.Errorf("failed to %s %s: %w", op, key, err)
+}
+
+// contextReader aborts long copies when the context is cancelled
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
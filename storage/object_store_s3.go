@@ -0,0 +1,502 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3ObjectAPI extends S3Client with the operations needed by S3ObjectStore.
+// Both *s3.Client and MockS3Client implement this interface.
+type S3ObjectAPI interface {
+	S3Client
+
+	// DeleteObject removes an object
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+
+	// CopyObject copies an object server-side
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+}
+
+// S3StoreConfig configures an S3-compatible ObjectStore.
+//
+// Fields:
+//   - Endpoint: Service URL (empty for AWS S3 with default endpoint resolution)
+//   - AccessKey/SecretKey: Static credentials
+//   - Region: Signing region (default "us-east-1")
+//   - Bucket: Target bucket
+//   - Prefix: Optional key prefix applied to every operation (e.g. "traces/")
+//   - PathStyle: Use path-style addressing (required for MinIO, Hetzner and LakeFS)
+type S3StoreConfig struct {
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Region    string
+	Bucket    string
+	Prefix    string
+	PathStyle bool
+}
+
+// S3ObjectStore implements ObjectStore on top of an S3-compatible API.
+// Streams of unknown length are uploaded with the multipart upload manager
+// when the underlying client supports it.
+type S3ObjectStore struct {
+	client   S3ObjectAPI
+	uploader *manager.Uploader
+	bucket   string
+	prefix   string
+}
+
+// NewS3ObjectStore creates an ObjectStore for an S3-compatible endpoint.
+// The client uses the shared HTTP client for connection pooling.
+func NewS3ObjectStore(ctx context.Context, cfg S3StoreConfig) (*S3ObjectStore, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	client, err := newS3Client(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewS3ObjectStoreWithClient(client, cfg.Bucket, cfg.Prefix), nil
+}
+
+// NewS3ObjectStoreWithClient wraps an existing client (e.g. tracing.Config.S3Client or MockS3Client)
+func NewS3ObjectStoreWithClient(client S3ObjectAPI, bucket, prefix string) *S3ObjectStore {
+	store := &S3ObjectStore{
+		client: client,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+	}
+	if uploadClient, ok := client.(manager.UploadAPIClient); ok {
+		store.uploader = manager.NewUploader(uploadClient)
+	}
+	return store
+}
+
+// newS3Client builds an S3 client for the given configuration
+func newS3Client(ctx context.Context, cfg S3StoreConfig) (*s3.Client, error) {
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	opts := []func(*config.LoadOptions) error{
+		config.WithRegion(region),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")),
+	}
+	if cfg.Endpoint != "" {
+		//nolint:staticcheck // AWS SDK endpoint resolution is deprecated; kept consistent with the other storage clients
+		opts = append(opts, config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(
+			func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+				return aws.Endpoint{
+					URL:               cfg.Endpoint,
+					SigningRegion:     region,
+					HostnameImmutable: true,
+				}, nil
+			})))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		o.UsePathStyle = cfg.PathStyle
+		o.HTTPClient = sharedHTTPClient
+	}), nil
+}
+
+// Bucket returns the bucket used by the store
+func (s *S3ObjectStore) Bucket() string {
+	return s.bucket
+}
+
+// Put uploads r to key
+func (s *S3ObjectStore) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	fullKey := joinKey(s.prefix, key)
+
+	input := &s3.PutObjectInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(fullKey),
+		Metadata: opts.Metadata,
+	}
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+
+	// The returned info is built from the upload response to avoid a HEAD request
+	info := &ObjectInfo{
+		Key:          key,
+		Size:         opts.Size,
+		ContentType:  opts.ContentType,
+		LastModified: time.Now().UTC(),
+		Metadata:     opts.Metadata,
+	}
+
+	// Only seekable bodies are sent directly: request signing and retries
+	// need to rewind the body, even when its length is known
+	seeker, seekable := r.(io.ReadSeeker)
+	switch {
+	case seekable:
+		input.Body = seeker
+		if opts.Size > 0 {
+			input.ContentLength = aws.Int64(opts.Size)
+		} else if size, err := remainingLength(seeker); err == nil {
+			info.Size = size
+		}
+		out, err := s.client.PutObject(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload %s to bucket %s: %w", fullKey, s.bucket, err)
+		}
+		info.ETag = strings.Trim(aws.ToString(out.ETag), `"`)
+	case s.uploader != nil:
+		// Streams: the upload manager buffers the body into seekable parts
+		counter := &countingReader{r: r}
+		input.Body = counter
+		out, err := s.uploader.Upload(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload %s to bucket %s: %w", fullKey, s.bucket, err)
+		}
+		info.Size = counter.n
+		info.ETag = strings.Trim(aws.ToString(out.ETag), `"`)
+	default:
+		// Streams without an upload manager are buffered in memory
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read content for %s: %w", fullKey, err)
+		}
+		input.Body = bytes.NewReader(data)
+		input.ContentLength = aws.Int64(int64(len(data)))
+		out, err := s.client.PutObject(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload %s to bucket %s: %w", fullKey, s.bucket, err)
+		}
+		info.Size = int64(len(data))
+		info.ETag = strings.Trim(aws.ToString(out.ETag), `"`)
+	}
+
+	return info, nil
+}
+
+// remainingLength returns the number of bytes left in rs without moving its offset
+func remainingLength(rs io.ReadSeeker) (int64, error) {
+	current, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := rs.Seek(current, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end - current, nil
+}
+
+// countingReader counts the bytes read from a stream of unknown length
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Get opens the object at key
+func (s *S3ObjectStore) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, nil, err
+	}
+	fullKey := joinKey(s.prefix, key)
+
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fullKey),
+	})
+	if err != nil {
+		return nil, nil, s.wrapError("get", fullKey, err)
+	}
+
+	info := &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		ETag:         strings.Trim(aws.ToString(out.ETag), `"`),
+		LastModified: aws.ToTime(out.LastModified),
+		Metadata:     out.Metadata,
+	}
+	return out.Body, info, nil
+}
+
+// PresignGet returns a presigned GET URL for key.
+// Only stores backed by *s3.Client can sign; other clients (e.g. MockS3Client)
+// return ErrPresignUnsupported.
+func (s *S3ObjectStore) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	client, ok := s.client.(*s3.Client)
+	if !ok {
+		return "", ErrPresignUnsupported
+	}
+	fullKey := joinKey(s.prefix, key)
+
+	req, err := s3.NewPresignClient(client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fullKey),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign %s in bucket %s: %w", fullKey, s.bucket, err)
+	}
+	return req.URL, nil
+}
+
+// Stat returns object metadata
+func (s *S3ObjectStore) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	fullKey := joinKey(s.prefix, key)
+
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fullKey),
+	})
+	if err != nil {
+		return nil, s.wrapError("stat", fullKey, err)
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		ETag:         strings.Trim(aws.ToString(out.ETag), `"`),
+		LastModified: aws.ToTime(out.LastModified),
+		Metadata:     out.Metadata,
+	}, nil
+}
+
+// List enumerates objects, following continuation tokens
+func (s *S3ObjectStore) List(ctx context.Context, opts ListOptions) ([]ObjectInfo, error) {
+	fullPrefix := s.prefix
+	if fullPrefix != "" {
+		fullPrefix += "/"
+	}
+	fullPrefix += opts.Prefix
+
+	var objects []ObjectInfo
+	var token *string
+	for {
+		input := &s3.ListObjectsV2Input{
+			Bucket:            aws.String(s.bucket),
+			ContinuationToken: token,
+		}
+		if fullPrefix != "" {
+			input.Prefix = aws.String(fullPrefix)
+		}
+		if opts.Delimiter != "" {
+			input.Delimiter = aws.String(opts.Delimiter)
+		}
+
+		out, err := s.client.ListObjectsV2(ctx, input)
+		if err != nil {
+			return nil, s.wrapError("list", fullPrefix, err)
+		}
+
+		for _, obj := range out.Contents {
+			fullKey := aws.ToString(obj.Key)
+			if !matchesDelimiter(fullKey, fullPrefix, opts.Delimiter) {
+				continue
+			}
+			key := fullKey
+			if s.prefix != "" {
+				key = strings.TrimPrefix(fullKey, s.prefix+"/")
+			}
+			objects = append(objects, ObjectInfo{
+				Key:          key,
+				Size:         aws.ToInt64(obj.Size),
+				ETag:         strings.Trim(aws.ToString(obj.ETag), `"`),
+				LastModified: aws.ToTime(obj.LastModified),
+			})
+		}
+
+		if !aws.ToBool(out.IsTruncated) || out.NextContinuationToken == nil {
+			break
+		}
+		token = out.NextContinuationToken
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	if opts.MaxKeys > 0 && len(objects) > opts.MaxKeys {
+		objects = objects[:opts.MaxKeys]
+	}
+	return objects, nil
+}
+
+// Delete removes the object at key
+func (s *S3ObjectStore) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	fullKey := joinKey(s.prefix, key)
+
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fullKey),
+	})
+	if err != nil && !isS3NotFound(err) {
+		return s.wrapError("delete", fullKey, err)
+	}
+	return nil
+}
+
+// Copy duplicates srcKey to dstKey server-side
+func (s *S3ObjectStore) Copy(ctx context.Context, srcKey, dstKey string) (*ObjectInfo, error) {
+	if err := validateKey(srcKey); err != nil {
+		return nil, err
+	}
+	if err := validateKey(dstKey); err != nil {
+		return nil, err
+	}
+	return s.copyFrom(ctx, joinKey(s.prefix, srcKey), dstKey)
+}
+
+// copyFrom copies a fully-qualified source key in the same bucket to dstKey
+func (s *S3ObjectStore) copyFrom(ctx context.Context, fullSrcKey, dstKey string) (*ObjectInfo, error) {
+	source := (&url.URL{Path: s.bucket + "/" + fullSrcKey}).EscapedPath()
+	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(s.bucket),
+		Key:        aws.String(joinKey(s.prefix, dstKey)),
+		CopySource: aws.String(source),
+	})
+	if err != nil {
+		return nil, s.wrapError("copy", fullSrcKey, err)
+	}
+	return s.Stat(ctx, dstKey)
+}
+
+// wrapError adds context and maps S3 not-found errors to ErrObjectNotFound
+func (s *S3ObjectStore) wrapError(op, key string, err error) error {
+	if isS3NotFound(err) {
+		return fmt.Errorf("%s %s in bucket %s: %w", op, key, s.bucket, ErrObjectNotFound)
+	}
+	return fmt.Errorf("failed to %s %s in bucket %s: %w", op, key, s.bucket, err)
+}
+
+// isS3NotFound detects missing-object errors across S3-compatible backends
+func isS3NotFound(err error) bool {
+	var noKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noKey) || errors.As(err, &notFound) {
+		return true
+	}
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		switch coded.ErrorCode() {
+		case "NoSuchKey", "NotFound", "404":
+			return true
+		}
+	}
+	return false
+}
+
+// LakeFSStoreConfig configures a LakeFS-backed ObjectStore.
+// LakeFS exposes repositories as buckets and branches as the first key segment.
+type LakeFSStoreConfig struct {
+	Endpoint   string
+	AccessKey  string
+	SecretKey  string
+	Repository string
+	Branch     string
+}
+
+// LakeFSObjectStore implements ObjectStore for one branch of a LakeFS repository.
+// Keys are relative to the branch; use WithBranch to address another branch with
+// the same connection and CopyFromBranch to promote objects between branches.
+type LakeFSObjectStore struct {
+	*S3ObjectStore
+	repository string
+	branch     string
+}
+
+// NewLakeFSObjectStore creates an ObjectStore for a LakeFS repository branch
+func NewLakeFSObjectStore(ctx context.Context, cfg LakeFSStoreConfig) (*LakeFSObjectStore, error) {
+	if cfg.Repository == "" || cfg.Branch == "" {
+		return nil, fmt.Errorf("repository and branch are required")
+	}
+	client, err := newS3Client(ctx, S3StoreConfig{
+		Endpoint:  cfg.Endpoint,
+		AccessKey: cfg.AccessKey,
+		SecretKey: cfg.SecretKey,
+		PathStyle: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewLakeFSObjectStoreWithClient(client, cfg.Repository, cfg.Branch), nil
+}
+
+// NewLakeFSObjectStoreWithClient wraps an existing client configured for the LakeFS S3 gateway
+func NewLakeFSObjectStoreWithClient(client S3ObjectAPI, repository, branch string) *LakeFSObjectStore {
+	return &LakeFSObjectStore{
+		S3ObjectStore: NewS3ObjectStoreWithClient(client, repository, branch),
+		repository:    repository,
+		branch:        branch,
+	}
+}
+
+// Repository returns the LakeFS repository name
+func (l *LakeFSObjectStore) Repository() string {
+	return l.repository
+}
+
+// Branch returns the branch addressed by this store
+func (l *LakeFSObjectStore) Branch() string {
+	return l.branch
+}
+
+// WithBranch returns a store for another branch sharing the same client
+func (l *LakeFSObjectStore) WithBranch(branch string) *LakeFSObjectStore {
+	return &LakeFSObjectStore{
+		S3ObjectStore: &S3ObjectStore{
+			client:   l.client,
+			uploader: l.uploader,
+			bucket:   l.bucket,
+			prefix:   strings.Trim(branch, "/"),
+		},
+		repository: l.repository,
+		branch:     branch,
+	}
+}
+
+// CopyFromBranch copies srcKey from another branch of the repository to dstKey on this branch
+func (l *LakeFSObjectStore) CopyFromBranch(ctx context.Context, srcBranch, srcKey, dstKey string) (*ObjectInfo, error) {
+	if err := validateKey(srcKey); err != nil {
+		return nil, err
+	}
+	if err := validateKey(dstKey); err != nil {
+		return nil, err
+	}
+	return l.copyFrom(ctx, joinKey(srcBranch, srcKey), dstKey)
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLocalObjectStoreRoundTrip tests put, get, stat and copy on the local backend
+func TestLocalObjectStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalObjectStore(t.TempDir())
+	require.NoError(t, err)
+
+	info, err := store.Put(ctx, "traces/a/request.json", strings.NewReader(`{"ok":true}`), PutOptions{
+		ContentType: "application/json",
+		Metadata:    map[string]string{"source": "test"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), info.Size)
+	assert.Equal(t, "application/json", info.ContentType)
+	assert.NotEmpty(t, info.ETag)
+
+	data, err := GetBytes(ctx, store, "traces/a/request.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, string(data))
+
+	copied, err := store.Copy(ctx, "traces/a/request.json", "traces/b/request.json")
+	require.NoError(t, err)
+	assert.Equal(t, "test", copied.Metadata["source"])
+	assert.Equal(t, info.ETag, copied.ETag)
+
+	// The object is a plain file below the root
+	raw, err := os.ReadFile(filepath.Join(store.Root(), "traces", "b", "request.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, string(raw))
+}
+
+// TestLocalObjectStoreList tests prefix and delimiter filtering
+func TestLocalObjectStoreList(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalObjectStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"a/1.txt", "a/2.txt", "a/sub/3.txt", "b/4.txt"} {
+		_, err := store.Put(ctx, key, strings.NewReader(key), PutOptions{})
+		require.NoError(t, err)
+	}
+
+	all, err := store.List(ctx, ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, all, 4, "metadata sidecars must not be listed")
+
+	direct, err := store.List(ctx, ListOptions{Prefix: "a/", Delimiter: "/"})
+	require.NoError(t, err)
+	require.Len(t, direct, 2)
+	assert.Equal(t, "a/1.txt", direct[0].Key)
+	assert.Equal(t, "a/2.txt", direct[1].Key)
+
+	limited, err := store.List(ctx, ListOptions{MaxKeys: 1})
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+}
+
+// TestLocalObjectStoreNotFound tests missing keys, deletes and key validation
+func TestLocalObjectStoreNotFound(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalObjectStore(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = store.Stat(ctx, "missing.txt")
+	assert.True(t, errors.Is(err, ErrObjectNotFound))
+
+	_, err = store.Put(ctx, "gone.txt", strings.NewReader("x"), PutOptions{})
+	require.NoError(t, err)
+	require.NoError(t, store.Delete(ctx, "gone.txt"))
+	require.NoError(t, store.Delete(ctx, "gone.txt"), "deleting a missing key is not an error")
+
+	_, _, err = store.Get(ctx, "gone.txt")
+	assert.True(t, errors.Is(err, ErrObjectNotFound))
+
+	for _, key := range []string{"", "/etc/passwd", "../escape.txt", "a/../../b"} {
+		_, err := store.Put(ctx, key, strings.NewReader("x"), PutOptions{})
+		assert.Error(t, err, "key %q should be rejected", key)
+	}
+}
+
+// TestS3ObjectStoreWithMock tests the S3 backend against MockS3Client
+func TestS3ObjectStoreWithMock(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockS3Client()
+	store := NewS3ObjectStoreWithClient(mock, "test-bucket", "prefix")
+
+	info, err := store.Put(ctx, "dir/file.txt", strings.NewReader("hello"), PutOptions{ContentType: "text/plain"})
+	require.NoError(t, err)
+	assert.True(t, mock.PutObjectCalled)
+	assert.False(t, mock.HeadObjectCalled, "put must not issue a HEAD request")
+	assert.Equal(t, "prefix/dir/file.txt", mock.LastObjectKey)
+	assert.Equal(t, int64(5), info.Size)
+	assert.Equal(t, "text/plain", info.ContentType)
+
+	data, err := GetBytes(ctx, store, "dir/file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	_, err = store.Copy(ctx, "dir/file.txt", "dir/copy.txt")
+	require.NoError(t, err)
+	assert.True(t, mock.CopyObjectCalled)
+
+	objects, err := store.List(ctx, ListOptions{Prefix: "dir/"})
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "dir/copy.txt", objects[0].Key)
+	assert.Equal(t, "dir/file.txt", objects[1].Key)
+
+	require.NoError(t, store.Delete(ctx, "dir/file.txt"))
+	assert.True(t, mock.DeleteObjectCalled)
+
+	_, err = store.Stat(ctx, "dir/file.txt")
+	assert.True(t, errors.Is(err, ErrObjectNotFound))
+}
+
+// bodyRecordingS3Client records whether uploaded bodies can be rewound
+type bodyRecordingS3Client struct {
+	*MockS3Client
+	seekable []bool
+}
+
+func (c *bodyRecordingS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	_, ok := params.Body.(io.ReadSeeker)
+	c.seekable = append(c.seekable, ok)
+	return c.MockS3Client.PutObject(ctx, params, optFns...)
+}
+
+// TestS3ObjectStorePutStream tests that non-seekable bodies are buffered before PutObject
+func TestS3ObjectStorePutStream(t *testing.T) {
+	ctx := context.Background()
+	client := &bodyRecordingS3Client{MockS3Client: NewMockS3Client()}
+	store := NewS3ObjectStoreWithClient(client, "test-bucket", "")
+
+	stream := io.MultiReader(strings.NewReader("hello "), strings.NewReader("world"))
+	info, err := store.Put(ctx, "stream.txt", stream, PutOptions{Size: 11})
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), info.Size)
+
+	info, err = store.Put(ctx, "seekable.txt", strings.NewReader("hello"), PutOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size)
+
+	assert.Equal(t, []bool{true, true}, client.seekable, "PutObject always receives a rewindable body")
+
+	data, err := GetBytes(ctx, store, "stream.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+}
+
+// TestLakeFSObjectStoreBranches tests branch-scoped keys on the LakeFS backend
+func TestLakeFSObjectStoreBranches(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockS3Client()
+	main := NewLakeFSObjectStoreWithClient(mock, "repo", "main")
+
+	_, err := main.Put(ctx, "data.csv", strings.NewReader("a,b"), PutOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "repo", mock.LastBucket)
+	assert.Equal(t, "main/data.csv", mock.LastObjectKey)
+
+	dev := main.WithBranch("dev")
+	assert.Equal(t, "dev", dev.Branch())
+	assert.Equal(t, "main", main.Branch())
+
+	_, err = dev.CopyFromBranch(ctx, "main", "data.csv", "data.csv")
+	require.NoError(t, err)
+
+	data, err := GetBytes(ctx, dev, "data.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "a,b", string(data))
+}
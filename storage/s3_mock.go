@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"io"
+	"net/url"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -25,6 +26,8 @@ type MockS3Client struct {
 	ListObjectsV2Called bool
 	GetObjectCalled     bool
 	HeadObjectCalled    bool
+	DeleteObjectCalled  bool
+	CopyObjectCalled    bool
 	// Store last call parameters
 	LastBucket    string
 	LastObjectKey string
@@ -33,10 +36,11 @@ type MockS3Client struct {
 
 // MockS3Object represents a mock S3 object with content and metadata
 type MockS3Object struct {
-	Key      string
-	Content  string
-	Metadata map[string]string
-	Size     int64
+	Key         string
+	Content     string
+	ContentType string
+	Metadata    map[string]string
+	Size        int64
 }
 
 // NewMockS3Client creates a new mock S3 client
@@ -94,10 +98,11 @@ func (m *MockS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput,
 	// Store the object
 	if params.Key != nil {
 		m.Objects[*params.Key] = &MockS3Object{
-			Key:      *params.Key,
-			Content:  content,
-			Metadata: params.Metadata,
-			Size:     int64(len(content)),
+			Key:         *params.Key,
+			Content:     content,
+			ContentType: aws.ToString(params.ContentType),
+			Metadata:    params.Metadata,
+			Size:        int64(len(content)),
 		}
 	}
 
@@ -171,8 +176,10 @@ func (m *MockS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput,
 	if params.Key != nil {
 		if obj, exists := m.Objects[*params.Key]; exists {
 			return &s3.GetObjectOutput{
-				Body:     io.NopCloser(strings.NewReader(obj.Content)),
-				Metadata: obj.Metadata,
+				Body:          io.NopCloser(strings.NewReader(obj.Content)),
+				Metadata:      obj.Metadata,
+				ContentLength: aws.Int64(obj.Size),
+				ContentType:   aws.String(obj.ContentType),
 			}, nil
 		}
 		return nil, &types.NoSuchKey{}
@@ -200,6 +207,7 @@ func (m *MockS3Client) HeadObject(ctx context.Context, params *s3.HeadObjectInpu
 			return &s3.HeadObjectOutput{
 				Metadata:      obj.Metadata,
 				ContentLength: aws.Int64(obj.Size),
+				ContentType:   aws.String(obj.ContentType),
 			}, nil
 		}
 		return nil, &types.NoSuchKey{}
@@ -207,3 +215,58 @@ func (m *MockS3Client) HeadObject(ctx context.Context, params *s3.HeadObjectInpu
 
 	return nil, &types.NoSuchKey{}
 }
+
+// DeleteObject mocks removing an object
+func (m *MockS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	m.DeleteObjectCalled = true
+	if params.Bucket != nil {
+		m.LastBucket = *params.Bucket
+	}
+	if params.Key != nil {
+		m.LastObjectKey = *params.Key
+	}
+
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	if params.Key != nil {
+		delete(m.Objects, *params.Key)
+	}
+
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+// CopyObject mocks a server-side copy within the mock bucket.
+// CopySource is expected in "bucket/key" form.
+func (m *MockS3Client) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	m.CopyObjectCalled = true
+	if params.Bucket != nil {
+		m.LastBucket = *params.Bucket
+	}
+	if params.Key != nil {
+		m.LastObjectKey = *params.Key
+	}
+
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	source, err := url.PathUnescape(aws.ToString(params.CopySource))
+	if err != nil {
+		return nil, err
+	}
+	if idx := strings.Index(source, "/"); idx >= 0 {
+		source = source[idx+1:]
+	}
+
+	obj, exists := m.Objects[source]
+	if !exists || params.Key == nil {
+		return nil, &types.NoSuchKey{}
+	}
+	copied := *obj
+	copied.Key = *params.Key
+	m.Objects[*params.Key] = &copied
+
+	return &s3.CopyObjectOutput{}, nil
+}
@@ -0,0 +1,718 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncMode selects the direction and deletion behaviour of a synchronization.
+//
+// Modes:
+//   - SyncPush: Copy new and changed objects from local to remote
+//   - SyncPull: Copy new and changed objects from remote to local
+//   - SyncMirror: Push, then delete remote objects that no longer exist locally
+type SyncMode string
+
+const (
+	SyncPush   SyncMode = "push"
+	SyncPull   SyncMode = "pull"
+	SyncMirror SyncMode = "mirror"
+)
+
+// SyncCompare selects how source and destination objects are compared.
+//
+// Strategies:
+//   - CompareChecksum: MD5 from "md5" metadata, a single-part ETag, or the content itself
+//   - CompareSizeMtime: Object size plus the source modification time recorded in "mtime" metadata
+type SyncCompare string
+
+const (
+	CompareChecksum  SyncCompare = "checksum"
+	CompareSizeMtime SyncCompare = "size-mtime"
+)
+
+// Metadata keys written by Sync so later runs can compare without reading content.
+// "md5" matches the key used by HetznerUploaderFile.
+const (
+	SyncMetadataMD5   = "md5"
+	SyncMetadataMtime = "mtime"
+)
+
+// SyncOp is the operation planned for a single key
+type SyncOp string
+
+const (
+	SyncOpUpload   SyncOp = "upload"
+	SyncOpDownload SyncOp = "download"
+	SyncOpDelete   SyncOp = "delete"
+	SyncOpSkip     SyncOp = "skip"
+)
+
+// SyncOptions configures PlanSync and Sync.
+//
+// Fields:
+//   - Mode: Sync direction and deletion behaviour (default SyncPush)
+//   - Compare: Change detection strategy (default CompareChecksum)
+//   - Prefix: Only keys below this prefix are considered (on both sides)
+//   - Include: Glob patterns a key must match (empty = all keys)
+//   - Exclude: Glob patterns that remove keys, evaluated after Include
+//   - DryRun: Only plan, never transfer or delete
+//   - ManifestPath: File recording completed operations so an interrupted sync resumes
+//   - Concurrency: Parallel transfers (default MaxConcurrentUploads)
+//
+// Glob patterns support "*", "?", "[...]" and "**" (any number of path segments).
+// Patterns without a slash are matched against the base name, as in rsync.
+type SyncOptions struct {
+	Mode         SyncMode
+	Compare      SyncCompare
+	Prefix       string
+	Include      []string
+	Exclude      []string
+	DryRun       bool
+	ManifestPath string
+	Concurrency  int
+}
+
+// SyncAction is one planned operation
+type SyncAction struct {
+	Op     SyncOp `json:"op"`
+	Key    string `json:"key"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason"`
+}
+
+// SyncPlan lists all operations of a synchronization in key order
+type SyncPlan struct {
+	Mode    SyncMode     `json:"mode"`
+	Actions []SyncAction `json:"actions"`
+}
+
+// Count returns the number of planned actions with the given operation
+func (p *SyncPlan) Count(op SyncOp) int {
+	n := 0
+	for _, a := range p.Actions {
+		if a.Op == op {
+			n++
+		}
+	}
+	return n
+}
+
+// TransferBytes returns the number of bytes that will be copied
+func (p *SyncPlan) TransferBytes() int64 {
+	var total int64
+	for _, a := range p.Actions {
+		if a.Op == SyncOpUpload || a.Op == SyncOpDownload {
+			total += a.Size
+		}
+	}
+	return total
+}
+
+// String renders the plan in a human-readable dry-run format
+func (p *SyncPlan) String() string {
+	var b strings.Builder
+	for _, a := range p.Actions {
+		if a.Op == SyncOpSkip {
+			continue
+		}
+		fmt.Fprintf(&b, "%-8s %s (%s)\n", a.Op, a.Key, a.Reason)
+	}
+	fmt.Fprintf(&b, "%s: %d upload, %d download, %d delete, %d unchanged, %d bytes to transfer\n",
+		p.Mode, p.Count(SyncOpUpload), p.Count(SyncOpDownload), p.Count(SyncOpDelete), p.Count(SyncOpSkip), p.TransferBytes())
+	return b.String()
+}
+
+// SyncResult reports the outcome of one executed action
+type SyncResult struct {
+	SyncAction
+	Success bool
+	Error   error
+}
+
+// SyncSummary aggregates the results of Sync
+type SyncSummary struct {
+	Plan         *SyncPlan
+	Results      []SyncResult
+	SuccessCount int
+	ErrorCount   int
+	SkippedCount int   // Unchanged keys and keys completed by a previous run
+	FirstError   error // First error encountered (for quick failure detection)
+}
+
+// syncManifest is persisted at SyncOptions.ManifestPath while a sync runs
+type syncManifest struct {
+	Mode      SyncMode                 `json:"mode"`
+	Prefix    string                   `json:"prefix"`
+	Completed map[string]manifestEntry `json:"completed"`
+	Updated   time.Time                `json:"updated"`
+}
+
+// The manifest is rewritten after manifestFlushBatch completed operations or once
+// manifestFlushInterval has passed, and at the end of a sync that leaves work behind.
+const (
+	manifestFlushBatch    = 100
+	manifestFlushInterval = 5 * time.Second
+)
+
+// manifestEntry identifies the source version an operation was completed for
+type manifestEntry struct {
+	Op           SyncOp    `json:"op"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// PlanSync compares local and remote and returns the operations Sync would perform.
+// Neither store is modified.
+func PlanSync(ctx context.Context, local, remote ObjectStore, opts SyncOptions) (*SyncPlan, error) {
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	src, dst := local, remote
+	if opts.Mode == SyncPull {
+		src, dst = remote, local
+	}
+
+	filters, err := newGlobFilter(opts.Include, opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	srcObjects, err := listForSync(ctx, src, opts.Prefix, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source: %w", err)
+	}
+	dstObjects, err := listForSync(ctx, dst, opts.Prefix, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list destination: %w", err)
+	}
+
+	// Keys completed by an interrupted run are not compared again
+	manifest, err := loadSyncManifest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	transferOp := SyncOpUpload
+	if opts.Mode == SyncPull {
+		transferOp = SyncOpDownload
+	}
+
+	plan := &SyncPlan{Mode: opts.Mode}
+	for key, srcInfo := range srcObjects {
+		action := SyncAction{Op: transferOp, Key: key, Size: srcInfo.Size}
+
+		dstInfo, exists := dstObjects[key]
+		switch {
+		case exists && manifest != nil && manifest.done(key, transferOp, &srcInfo):
+			action.Op = SyncOpSkip
+			action.Reason = "completed by previous run"
+		case !exists:
+			action.Reason = "new"
+		default:
+			same, reason, err := objectsEqual(ctx, src, dst, srcInfo, dstInfo, opts.Compare)
+			if err != nil {
+				return nil, err
+			}
+			action.Reason = reason
+			if same {
+				action.Op = SyncOpSkip
+			}
+		}
+		plan.Actions = append(plan.Actions, action)
+	}
+
+	if opts.Mode == SyncMirror {
+		for key, dstInfo := range dstObjects {
+			if _, exists := srcObjects[key]; !exists {
+				plan.Actions = append(plan.Actions, SyncAction{
+					Op:     SyncOpDelete,
+					Key:    key,
+					Size:   dstInfo.Size,
+					Reason: "not present locally",
+				})
+			}
+		}
+	}
+
+	sort.Slice(plan.Actions, func(i, j int) bool { return plan.Actions[i].Key < plan.Actions[j].Key })
+	return plan, nil
+}
+
+// Sync synchronizes local and remote according to opts.
+// Transfers run concurrently; individual failures do not stop the sync and are
+// reported in the summary. With ManifestPath set, completed operations are
+// recorded in batches as they finish and skipped by the next run as long as the source
+// object is unchanged. The manifest is removed once a sync completes without errors.
+func Sync(ctx context.Context, local, remote ObjectStore, opts SyncOptions) (*SyncSummary, error) {
+	opts = opts.withDefaults()
+	plan, err := PlanSync(ctx, local, remote, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &SyncSummary{Plan: plan}
+	if opts.DryRun {
+		return summary, nil
+	}
+
+	src, dst := local, remote
+	if opts.Mode == SyncPull {
+		src, dst = remote, local
+	}
+
+	manifest, err := loadSyncManifest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		pending   int
+		lastFlush = time.Now()
+	)
+
+	// flush writes the manifest; callers hold mu
+	flush := func() {
+		if manifest == nil || pending == 0 {
+			return
+		}
+		if err := manifest.save(opts.ManifestPath); err != nil && summary.FirstError == nil {
+			summary.FirstError = err
+		}
+		pending = 0
+		lastFlush = time.Now()
+	}
+
+	record := func(result SyncResult, entry *manifestEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		summary.Results = append(summary.Results, result)
+		if !result.Success {
+			summary.ErrorCount++
+			if summary.FirstError == nil {
+				summary.FirstError = result.Error
+			}
+			return
+		}
+		if result.Op == SyncOpSkip {
+			summary.SkippedCount++
+		} else {
+			summary.SuccessCount++
+		}
+		if entry != nil && manifest != nil {
+			manifest.Completed[result.Key] = *entry
+			pending++
+			if pending >= manifestFlushBatch || time.Since(lastFlush) >= manifestFlushInterval {
+				flush()
+			}
+		}
+	}
+
+	run := func(action SyncAction) {
+		result := SyncResult{SyncAction: action}
+		if err := ctx.Err(); err != nil {
+			result.Error = err
+			record(result, nil)
+			return
+		}
+
+		var entry *manifestEntry
+		var err error
+		if action.Op == SyncOpDelete {
+			err = dst.Delete(ctx, action.Key)
+			entry = &manifestEntry{Op: action.Op}
+		} else {
+			var srcInfo *ObjectInfo
+			if srcInfo, err = src.Stat(ctx, action.Key); err == nil {
+				err = transferObject(ctx, src, dst, srcInfo, opts.Compare)
+				entry = &manifestEntry{
+					Op:           action.Op,
+					Size:         srcInfo.Size,
+					ETag:         srcInfo.ETag,
+					LastModified: srcInfo.LastModified,
+				}
+			}
+		}
+
+		if err != nil {
+			result.Error = fmt.Errorf("failed to %s %s: %w", action.Op, action.Key, err)
+			entry = nil
+		} else {
+			result.Success = true
+		}
+		record(result, entry)
+	}
+
+	actions := make(chan SyncAction)
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for action := range actions {
+				run(action)
+			}
+		}()
+	}
+
+	for _, action := range plan.Actions {
+		if action.Op == SyncOpSkip {
+			record(SyncResult{SyncAction: action, Success: true}, nil)
+			continue
+		}
+		actions <- action
+	}
+	close(actions)
+
+	wg.Wait()
+
+	sort.Slice(summary.Results, func(i, j int) bool { return summary.Results[i].Key < summary.Results[j].Key })
+	if summary.ErrorCount > 0 || summary.FirstError != nil {
+		flush()
+	}
+	if summary.ErrorCount == 0 && summary.FirstError == nil && opts.ManifestPath != "" {
+		if err := os.Remove(opts.ManifestPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return summary, fmt.Errorf("failed to remove sync manifest: %w", err)
+		}
+	}
+	return summary, summary.FirstError
+}
+
+// withDefaults fills unset options
+func (o SyncOptions) withDefaults() SyncOptions {
+	if o.Mode == "" {
+		o.Mode = SyncPush
+	}
+	if o.Compare == "" {
+		o.Compare = CompareChecksum
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = MaxConcurrentUploads
+	}
+	return o
+}
+
+// validate rejects unknown modes and comparison strategies
+func (o SyncOptions) validate() error {
+	switch o.Mode {
+	case SyncPush, SyncPull, SyncMirror:
+	default:
+		return fmt.Errorf("unknown sync mode %q", o.Mode)
+	}
+	switch o.Compare {
+	case CompareChecksum, CompareSizeMtime:
+	default:
+		return fmt.Errorf("unknown sync comparison %q", o.Compare)
+	}
+	return nil
+}
+
+// listForSync lists objects below prefix that pass the filters, keyed by object key
+func listForSync(ctx context.Context, store ObjectStore, prefix string, filters *globFilter) (map[string]ObjectInfo, error) {
+	objects, err := store.List(ctx, ListOptions{Prefix: prefix})
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]ObjectInfo, len(objects))
+	for _, obj := range objects {
+		if filters.match(strings.TrimPrefix(strings.TrimPrefix(obj.Key, prefix), "/")) {
+			result[obj.Key] = obj
+		}
+	}
+	return result, nil
+}
+
+// objectsEqual compares source and destination using the configured strategy
+func objectsEqual(ctx context.Context, src, dst ObjectStore, srcInfo, dstInfo ObjectInfo, compare SyncCompare) (bool, string, error) {
+	if srcInfo.Size != dstInfo.Size {
+		return false, "size differs", nil
+	}
+
+	if compare == CompareSizeMtime {
+		srcMtime := sourceMtime(srcInfo)
+		if recorded, err := time.Parse(time.RFC3339Nano, dstInfo.Metadata[SyncMetadataMtime]); err == nil {
+			if recorded.Equal(srcMtime) {
+				return true, "unchanged (size and mtime match)", nil
+			}
+			return false, "modification time differs", nil
+		}
+		if !dstInfo.LastModified.Before(srcMtime) {
+			return true, "unchanged (size match, destination newer)", nil
+		}
+		return false, "source newer", nil
+	}
+
+	srcSum, err := objectChecksum(ctx, src, srcInfo)
+	if err != nil {
+		return false, "", err
+	}
+	dstSum, err := objectChecksum(ctx, dst, dstInfo)
+	if err != nil {
+		return false, "", err
+	}
+	if srcSum == dstSum {
+		return true, "unchanged (MD5 match)", nil
+	}
+	return false, "content differs", nil
+}
+
+// md5Pattern matches a plain MD5 hex digest; multipart ETags ("<md5>-<parts>") do not match
+var md5Pattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// objectChecksum returns the MD5 of an object, preferring stored metadata and
+// single-part ETags over reading the content
+func objectChecksum(ctx context.Context, store ObjectStore, info ObjectInfo) (string, error) {
+	if sum := info.Metadata[SyncMetadataMD5]; sum != "" {
+		return sum, nil
+	}
+	// Listings omit user metadata, so look at the full object metadata first
+	if full, err := store.Stat(ctx, info.Key); err == nil {
+		if sum := full.Metadata[SyncMetadataMD5]; sum != "" {
+			return sum, nil
+		}
+		info.ETag = full.ETag
+	}
+	if etag := strings.ToLower(info.ETag); md5Pattern.MatchString(etag) {
+		return etag, nil
+	}
+
+	body, _, err := store.Get(ctx, info.Key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", fmt.Errorf("failed to checksum %s: %w", info.Key, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// sourceMtime returns the recorded original mtime of an object, or its LastModified
+func sourceMtime(info ObjectInfo) time.Time {
+	if recorded, err := time.Parse(time.RFC3339Nano, info.Metadata[SyncMetadataMtime]); err == nil {
+		return recorded
+	}
+	return info.LastModified
+}
+
+// transferObject copies one object between stores, recording checksum and mtime metadata
+func transferObject(ctx context.Context, src, dst ObjectStore, srcInfo *ObjectInfo, compare SyncCompare) error {
+	metadata := make(map[string]string, len(srcInfo.Metadata)+2)
+	for k, v := range srcInfo.Metadata {
+		metadata[k] = v
+	}
+	metadata[SyncMetadataMtime] = sourceMtime(*srcInfo).UTC().Format(time.RFC3339Nano)
+
+	if metadata[SyncMetadataMD5] == "" && compare == CompareChecksum {
+		sum, err := objectChecksum(ctx, src, *srcInfo)
+		if err != nil {
+			return err
+		}
+		metadata[SyncMetadataMD5] = sum
+	}
+
+	body, _, err := src.Get(ctx, srcInfo.Key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	_, err = dst.Put(ctx, srcInfo.Key, body, PutOptions{
+		ContentType: srcInfo.ContentType,
+		Metadata:    metadata,
+		Size:        srcInfo.Size,
+	})
+	return err
+}
+
+// loadSyncManifest reads the resume manifest, starting a new one when the file
+// is missing or belongs to a different mode or prefix
+func loadSyncManifest(opts SyncOptions) (*syncManifest, error) {
+	if opts.ManifestPath == "" {
+		return nil, nil
+	}
+	fresh := &syncManifest{Mode: opts.Mode, Prefix: opts.Prefix, Completed: make(map[string]manifestEntry)}
+
+	data, err := os.ReadFile(opts.ManifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fresh, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync manifest: %w", err)
+	}
+
+	var manifest syncManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse sync manifest %s: %w", opts.ManifestPath, err)
+	}
+	if manifest.Mode != opts.Mode || manifest.Prefix != opts.Prefix || manifest.Completed == nil {
+		return fresh, nil
+	}
+	return &manifest, nil
+}
+
+// done reports whether op on key was completed for the current source version
+func (m *syncManifest) done(key string, op SyncOp, srcInfo *ObjectInfo) bool {
+	entry, ok := m.Completed[key]
+	if !ok || entry.Op != op {
+		return false
+	}
+	return entry.Size == srcInfo.Size && entry.ETag == srcInfo.ETag && entry.LastModified.Equal(srcInfo.LastModified)
+}
+
+// save writes the manifest atomically; callers hold the sync mutex
+func (m *syncManifest) save(p string) error {
+	m.Updated = time.Now().UTC()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write sync manifest: %w", err)
+	}
+	return os.Rename(tmp, p)
+}
+
+// globFilter applies include and exclude patterns to relative keys
+type globFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	baseInc []bool
+	baseExc []bool
+}
+
+// newGlobFilter compiles include and exclude patterns
+func newGlobFilter(include, exclude []string) (*globFilter, error) {
+	f := &globFilter{}
+	for _, pattern := range include {
+		re, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, re)
+		f.baseInc = append(f.baseInc, !strings.Contains(pattern, "/"))
+	}
+	for _, pattern := range exclude {
+		re, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, re)
+		f.baseExc = append(f.baseExc, !strings.Contains(pattern, "/"))
+	}
+	return f, nil
+}
+
+// match reports whether key passes the filters
+func (f *globFilter) match(key string) bool {
+	if len(f.include) > 0 && !matchAny(f.include, f.baseInc, key) {
+		return false
+	}
+	return !matchAny(f.exclude, f.baseExc, key)
+}
+
+func matchAny(patterns []*regexp.Regexp, base []bool, key string) bool {
+	for i, re := range patterns {
+		candidate := key
+		if base[i] {
+			candidate = path.Base(key)
+		}
+		if re.MatchString(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileGlob translates a glob pattern with "**" support into a regular expression
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid glob pattern %q: unterminated character class", pattern)
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// HetznerSync synchronizes a local directory with a Hetzner (or other S3-compatible)
+// bucket prefix using the given mode. Unlike HetznerSyncToRemote it can pull and
+// mirror, and compares via stored checksum metadata so multipart uploads are not
+// re-uploaded on every run.
+func HetznerSync(ctx context.Context, url, accessKey, secretKey, region, bucket, rootPath, objectKey string, opts SyncOptions) (*SyncSummary, error) {
+	local, err := NewLocalObjectStore(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := NewS3ObjectStore(ctx, S3StoreConfig{
+		Endpoint:  url,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Region:    region,
+		Bucket:    bucket,
+		Prefix:    objectKey,
+		PathStyle: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Sync(ctx, local, remote, opts)
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLocalFiles creates plain files (without metadata sidecars) below root
+func writeLocalFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+}
+
+// TestSyncPushAndMirror tests push, unchanged detection and mirror deletes
+func TestSyncPushAndMirror(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	writeLocalFiles(t, root, map[string]string{"a.txt": "alpha", "dir/b.txt": "beta"})
+
+	local, err := NewLocalObjectStore(root)
+	require.NoError(t, err)
+	mock := NewMockS3Client()
+	remote := NewS3ObjectStoreWithClient(mock, "bucket", "backup")
+
+	summary, err := Sync(ctx, local, remote, SyncOptions{Mode: SyncPush})
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.SuccessCount)
+	require.Contains(t, mock.Objects, "backup/a.txt")
+	assert.NotEmpty(t, mock.Objects["backup/a.txt"].Metadata[SyncMetadataMD5])
+
+	// Second run compares via stored checksum metadata
+	summary, err = Sync(ctx, local, remote, SyncOptions{Mode: SyncPush})
+	require.NoError(t, err)
+	assert.Equal(t, 0, summary.SuccessCount)
+	assert.Equal(t, 2, summary.SkippedCount)
+
+	// Orphans survive a push but not a mirror
+	_, err = remote.Put(ctx, "orphan.txt", strings.NewReader("x"), PutOptions{})
+	require.NoError(t, err)
+
+	plan, err := PlanSync(ctx, local, remote, SyncOptions{Mode: SyncPush})
+	require.NoError(t, err)
+	assert.Equal(t, 0, plan.Count(SyncOpDelete))
+
+	summary, err = Sync(ctx, local, remote, SyncOptions{Mode: SyncMirror, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Plan.Count(SyncOpDelete))
+	assert.Contains(t, summary.Plan.String(), "delete   orphan.txt")
+	assert.Contains(t, mock.Objects, "backup/orphan.txt", "dry run must not delete")
+
+	_, err = Sync(ctx, local, remote, SyncOptions{Mode: SyncMirror})
+	require.NoError(t, err)
+	assert.NotContains(t, mock.Objects, "backup/orphan.txt")
+}
+
+// TestSyncPull tests downloading changed objects into a local directory
+func TestSyncPull(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockS3Client()
+	remote := NewS3ObjectStoreWithClient(mock, "bucket", "")
+	_, err := remote.Put(ctx, "reports/1.json", strings.NewReader(`{"n":1}`), PutOptions{})
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	writeLocalFiles(t, root, map[string]string{"reports/1.json": `{"n":0}`})
+	local, err := NewLocalObjectStore(root)
+	require.NoError(t, err)
+
+	plan, err := PlanSync(ctx, local, remote, SyncOptions{Mode: SyncPull})
+	require.NoError(t, err)
+	require.Len(t, plan.Actions, 1)
+	assert.Equal(t, SyncOpDownload, plan.Actions[0].Op)
+	assert.Equal(t, "content differs", plan.Actions[0].Reason)
+
+	_, err = Sync(ctx, local, remote, SyncOptions{Mode: SyncPull})
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(root, "reports", "1.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"n":1}`, string(data))
+}
+
+// TestSyncSizeMtimeIgnoresMultipartETag tests comparison when ETags are not MD5s
+func TestSyncSizeMtimeIgnoresMultipartETag(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	writeLocalFiles(t, root, map[string]string{"big.bin": "0123456789"})
+	local, err := NewLocalObjectStore(root)
+	require.NoError(t, err)
+	remote, err := NewLocalObjectStore(t.TempDir())
+	require.NoError(t, err)
+
+	opts := SyncOptions{Mode: SyncPush, Compare: CompareSizeMtime}
+	_, err = Sync(ctx, local, remote, opts)
+	require.NoError(t, err)
+
+	plan, err := PlanSync(ctx, local, remote, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, plan.Count(SyncOpSkip))
+	assert.Equal(t, "unchanged (size and mtime match)", plan.Actions[0].Reason)
+}
+
+// TestSyncFilters tests include and exclude globs
+func TestSyncFilters(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	writeLocalFiles(t, root, map[string]string{
+		"build/app.tar.gz":    "a",
+		"build/tmp/x.tar.gz":  "b",
+		"build/notes.md":      "c",
+		"src/main.go":         "d",
+		"build/cache/app.log": "e",
+	})
+	local, err := NewLocalObjectStore(root)
+	require.NoError(t, err)
+	remote, err := NewLocalObjectStore(t.TempDir())
+	require.NoError(t, err)
+
+	plan, err := PlanSync(ctx, local, remote, SyncOptions{
+		Include: []string{"build/**"},
+		Exclude: []string{"**/tmp/**", "*.log"},
+	})
+	require.NoError(t, err)
+
+	var keys []string
+	for _, a := range plan.Actions {
+		keys = append(keys, a.Key)
+	}
+	assert.Equal(t, []string{"build/app.tar.gz", "build/notes.md"}, keys)
+
+	_, err = PlanSync(ctx, local, remote, SyncOptions{Include: []string{"[abc"}})
+	assert.Error(t, err)
+}
+
+// TestSyncResumeManifest tests that completed keys are skipped after an interruption
+func TestSyncResumeManifest(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	writeLocalFiles(t, root, map[string]string{"a.txt": "alpha", "b.txt": "beta"})
+	local, err := NewLocalObjectStore(root)
+	require.NoError(t, err)
+	remote, err := NewLocalObjectStore(t.TempDir())
+	require.NoError(t, err)
+
+	manifestPath := filepath.Join(t.TempDir(), "sync-manifest.json")
+	aInfo, err := local.Stat(ctx, "a.txt")
+	require.NoError(t, err)
+
+	// Simulate an interrupted run that completed a.txt
+	_, err = remote.Put(ctx, "a.txt", strings.NewReader("alpha"), PutOptions{})
+	require.NoError(t, err)
+	manifest := &syncManifest{Mode: SyncPush, Completed: map[string]manifestEntry{
+		"a.txt": {Op: SyncOpUpload, Size: aInfo.Size, ETag: aInfo.ETag, LastModified: aInfo.LastModified},
+	}}
+	require.NoError(t, manifest.save(manifestPath))
+
+	summary, err := Sync(ctx, local, remote, SyncOptions{Mode: SyncPush, ManifestPath: manifestPath})
+	require.NoError(t, err)
+	require.Len(t, summary.Results, 2)
+	assert.Equal(t, "completed by previous run", summary.Results[0].Reason)
+	assert.Equal(t, SyncOpUpload, summary.Results[1].Op)
+
+	_, err = os.Stat(manifestPath)
+	assert.True(t, os.IsNotExist(err), "manifest is removed after a clean run")
+}
+
+// failingPutStore rejects uploads of one key
+type failingPutStore struct {
+	*LocalObjectStore
+	failKey string
+}
+
+func (s *failingPutStore) Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (*ObjectInfo, error) {
+	if key == s.failKey {
+		return nil, errors.New("upload rejected")
+	}
+	return s.LocalObjectStore.Put(ctx, key, body, opts)
+}
+
+// TestSyncManifestBatching tests that a failed sync flushes every completed key
+// with a small worker pool and more actions than one flush batch
+func TestSyncManifestBatching(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < manifestFlushBatch+25; i++ {
+		files[fmt.Sprintf("f%03d.txt", i)] = fmt.Sprintf("content %d", i)
+	}
+	writeLocalFiles(t, root, files)
+	local, err := NewLocalObjectStore(root)
+	require.NoError(t, err)
+	dst, err := NewLocalObjectStore(t.TempDir())
+	require.NoError(t, err)
+	remote := &failingPutStore{LocalObjectStore: dst, failKey: "f007.txt"}
+
+	manifestPath := filepath.Join(t.TempDir(), "sync-manifest.json")
+	summary, err := Sync(ctx, local, remote, SyncOptions{Mode: SyncPush, ManifestPath: manifestPath, Concurrency: 3})
+	require.Error(t, err)
+	assert.Equal(t, 1, summary.ErrorCount)
+	assert.Equal(t, len(files)-1, summary.SuccessCount)
+
+	manifest, err := loadSyncManifest(SyncOptions{Mode: SyncPush, ManifestPath: manifestPath})
+	require.NoError(t, err)
+	assert.Len(t, manifest.Completed, len(files)-1)
+	assert.NotContains(t, manifest.Completed, "f007.txt")
+}
@@ -5,7 +5,6 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -22,8 +21,8 @@ type ArchivalConfig struct {
 	// ArchiveAfterDays specifies how old traces must be before archival (default: 90 days)
 	ArchiveAfterDays int
 
-	// DeleteAfterDays specifies when to delete archived traces (default: 365 days)
-	// Set to 0 to keep forever
+	// DeleteAfterDays specifies when to delete archived traces, counted from the
+	// trace start (default: 365 days). Set to 0 to keep forever
 	DeleteAfterDays int
 
 	// S3Bucket for archived traces
@@ -41,9 +40,19 @@ type ArchivalConfig struct {
 
 	// DryRun mode logs what would be archived without doing it
 	DryRun bool
+
+	// Policies override ArchiveAfterDays and DeleteAfterDays for matching traces.
+	// Traces matching no policy use those defaults.
+	Policies []RetentionPolicy
+
+	// SnapshotRetentionDays is how long dependency graph snapshots are kept
+	// (default: 90 days). Set to -1 to keep forever
+	SnapshotRetentionDays int
 }
 
-// ArchivalManager handles trace archival to S3 Glacier
+// ArchivalManager handles trace archival to S3 Glacier.
+// It talks to S3 directly rather than through storage.ObjectStore because it
+// relies on storage classes, object restore and bucket lifecycle rules.
 type ArchivalManager struct {
 	db       *sql.DB
 	s3Client *s3.Client
@@ -54,6 +63,7 @@ type ArchivalManager struct {
 type ArchivalStats struct {
 	TracesArchived      int64
 	TracesDeleted       int64
+	SnapshotsDeleted    int64
 	BytesArchived       int64
 	LifecyclePolicies   int
 	LastArchivedAt      time.Time
@@ -90,6 +100,9 @@ func NewArchivalManager(db *sql.DB, s3Client *s3.Client, config ArchivalConfig)
 	if config.BatchSize == 0 {
 		config.BatchSize = 1000
 	}
+	if config.SnapshotRetentionDays == 0 {
+		config.SnapshotRetentionDays = 90
+	}
 
 	return &ArchivalManager{
 		db:       db,
@@ -98,109 +111,68 @@ func NewArchivalManager(db *sql.DB, s3Client *s3.Client, config ArchivalConfig)
 	}
 }
 
-// ArchiveOldTraces archives traces older than ArchiveAfterDays
+// ArchiveOldTraces archives traces whose retention policy moves them out of Postgres
 func (am *ArchivalManager) ArchiveOldTraces(ctx context.Context) (*ArchivalStats, error) {
 	stats := &ArchivalStats{}
 
-	// Find traces to archive
-	cutoffDate := time.Now().AddDate(0, 0, -am.config.ArchiveAfterDays)
-
-	query := `
-		SELECT
-			correlation_id,
-			operation_id,
-			service_id,
-			started_at,
-			request_s3_url,
-			response_s3_url
-		FROM action_executions
-		WHERE started_at < $1
-			AND archived_at IS NULL
-		ORDER BY started_at
-		LIMIT $2
-	`
-
-	rows, err := am.db.QueryContext(ctx, query, cutoffDate, am.config.BatchSize)
+	plan, err := am.retentionPlan()
 	if err != nil {
-		return nil, fmt.Errorf("query old traces: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var (
-			correlationID string
-			operationID   string
-			serviceID     string
-			startedAt     time.Time
-			requestS3URL  sql.NullString
-			responseS3URL sql.NullString
-		)
-
-		if err := rows.Scan(&correlationID, &operationID, &serviceID, &startedAt, &requestS3URL, &responseS3URL); err != nil {
-			return stats, fmt.Errorf("scan row: %w", err)
+		return nil, err
+	}
+	items, err := plan.dueTraces(ctx, am.db, RetentionArchive, am.config.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if am.config.DryRun {
+			fmt.Printf("[DRY RUN] Would archive trace: %s (correlation: %s, service: %s, policy: %s)\n",
+				item.OperationID, item.CorrelationID, item.ServiceID, item.Policy)
+			continue
 		}
 
 		// Archive this trace
-		if err := am.archiveTrace(ctx, correlationID, operationID, serviceID, startedAt, requestS3URL, responseS3URL); err != nil {
+		if err := am.archiveTrace(ctx, item); err != nil {
 			// Log error but continue
-			fmt.Printf("Failed to archive trace %s: %v\n", operationID, err)
+			fmt.Printf("Failed to archive trace %s: %v\n", item.OperationID, err)
 			continue
 		}
 
 		stats.TracesArchived++
 		stats.LastArchivedAt = time.Now()
 
-		if stats.OldestArchivedTrace.IsZero() || startedAt.Before(stats.OldestArchivedTrace) {
-			stats.OldestArchivedTrace = startedAt
+		if stats.OldestArchivedTrace.IsZero() || item.StartedAt.Before(stats.OldestArchivedTrace) {
+			stats.OldestArchivedTrace = item.StartedAt
 		}
 	}
 
-	return stats, rows.Err()
+	return stats, nil
 }
 
 // archiveTrace archives a single trace
-func (am *ArchivalManager) archiveTrace(ctx context.Context, correlationID, operationID, serviceID string, startedAt time.Time, requestS3URL, responseS3URL sql.NullString) error {
-	if am.config.DryRun {
-		fmt.Printf("[DRY RUN] Would archive trace: %s (correlation: %s, service: %s)\n", operationID, correlationID, serviceID)
-		return nil
-	}
-
+func (am *ArchivalManager) archiveTrace(ctx context.Context, item RetentionItem) error {
 	// Create archived metadata
 	archived := ArchivedTrace{
-		CorrelationID: correlationID,
-		OperationID:   operationID,
-		ServiceID:     serviceID,
+		CorrelationID: item.CorrelationID,
+		OperationID:   item.OperationID,
+		ServiceID:     item.ServiceID,
 		ArchivedAt:    time.Now(),
-		S3Key:         fmt.Sprintf("%s%s/%s.json", am.config.S3Prefix, startedAt.Format("2006/01/02"), operationID),
+		S3Key:         fmt.Sprintf("%s%s/%s.json", am.config.S3Prefix, item.StartedAt.Format("2006/01/02"), item.OperationID),
 		StorageClass:  am.config.GlacierStorageClass,
 		RestoreStatus: "",
 	}
 
-	// Fetch full trace data from PostgreSQL
-	var traceData map[string]interface{}
+	// Fetch full trace data (including payload URLs) from PostgreSQL
+	var jsonData []byte
 	err := am.db.QueryRowContext(ctx, `
 		SELECT row_to_json(action_executions.*)
 		FROM action_executions
 		WHERE operation_id = $1
-	`, operationID).Scan(&traceData)
+	`, item.OperationID).Scan(&jsonData)
 	if err != nil {
 		return fmt.Errorf("fetch trace data: %w", err)
 	}
 
-	// Add S3 payload URLs to metadata
-	if requestS3URL.Valid {
-		traceData["request_s3_url"] = requestS3URL.String
-	}
-	if responseS3URL.Valid {
-		traceData["response_s3_url"] = responseS3URL.String
-	}
-
-	// Marshal to JSON
-	jsonData, err := json.Marshal(traceData)
-	if err != nil {
-		return fmt.Errorf("marshal trace: %w", err)
-	}
-
 	// Upload to S3 with Glacier storage class
 	_, err = am.s3Client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:       aws.String(am.config.S3Bucket),
@@ -208,10 +180,11 @@ func (am *ArchivalManager) archiveTrace(ctx context.Context, correlationID, oper
 		Body:         bytes.NewReader(jsonData),
 		StorageClass: types.StorageClass(am.config.GlacierStorageClass),
 		Metadata: map[string]string{
-			"correlation_id": correlationID,
-			"operation_id":   operationID,
-			"service_id":     serviceID,
-			"archived_at":    archived.ArchivedAt.Format(time.RFC3339),
+			"correlation_id":   item.CorrelationID,
+			"operation_id":     item.OperationID,
+			"service_id":       item.ServiceID,
+			"archived_at":      archived.ArchivedAt.Format(time.RFC3339),
+			"retention_policy": item.Policy,
 		},
 	})
 	if err != nil {
@@ -224,7 +197,7 @@ func (am *ArchivalManager) archiveTrace(ctx context.Context, correlationID, oper
 		SET archived_at = $1,
 		    archived_s3_key = $2
 		WHERE operation_id = $3
-	`, archived.ArchivedAt, archived.S3Key, operationID)
+	`, archived.ArchivedAt, archived.S3Key, item.OperationID)
 	if err != nil {
 		return fmt.Errorf("mark as archived: %w", err)
 	}
@@ -232,67 +205,51 @@ func (am *ArchivalManager) archiveTrace(ctx context.Context, correlationID, oper
 	return nil
 }
 
-// DeleteOldArchivedTraces deletes archived traces older than DeleteAfterDays
+// DeleteOldArchivedTraces deletes traces whose retention policy has expired:
+// archived traces after ArchiveDays and unarchived traces of SkipArchive policies.
+// Traces under a legal hold are kept. Dependency graph snapshots older than
+// SnapshotRetentionDays are deleted as well.
 func (am *ArchivalManager) DeleteOldArchivedTraces(ctx context.Context) (*ArchivalStats, error) {
 	stats := &ArchivalStats{}
 
-	if am.config.DeleteAfterDays == 0 {
-		// Keep forever
-		return stats, nil
+	plan, err := am.retentionPlan()
+	if err != nil {
+		return nil, err
 	}
-
-	// Find archived traces to delete
-	cutoffDate := time.Now().AddDate(0, 0, -am.config.DeleteAfterDays)
-
-	query := `
-		SELECT
-			operation_id,
-			archived_s3_key
-		FROM action_executions
-		WHERE archived_at < $1
-			AND archived_at IS NOT NULL
-		ORDER BY archived_at
-		LIMIT $2
-	`
-
-	rows, err := am.db.QueryContext(ctx, query, cutoffDate, am.config.BatchSize)
+	items, err := plan.dueTraces(ctx, am.db, RetentionDelete, am.config.BatchSize)
 	if err != nil {
-		return nil, fmt.Errorf("query old archived traces: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var (
-			operationID string
-			s3Key       string
-		)
-
-		if err := rows.Scan(&operationID, &s3Key); err != nil {
-			return stats, fmt.Errorf("scan row: %w", err)
-		}
 
+	for _, item := range items {
 		if am.config.DryRun {
-			fmt.Printf("[DRY RUN] Would delete archived trace: %s (s3://%s/%s)\n", operationID, am.config.S3Bucket, s3Key)
+			fmt.Printf("[DRY RUN] Would delete trace: %s (policy: %s, archive: %q)\n", item.OperationID, item.Policy, item.ArchivedS3Key)
 			continue
 		}
 
 		// Delete from S3
-		_, err := am.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
-			Bucket: aws.String(am.config.S3Bucket),
-			Key:    aws.String(s3Key),
-		})
-		if err != nil {
-			fmt.Printf("Failed to delete S3 object %s: %v\n", s3Key, err)
-			// Continue anyway
+		if item.ArchivedS3Key != "" {
+			_, err := am.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket: aws.String(am.config.S3Bucket),
+				Key:    aws.String(item.ArchivedS3Key),
+			})
+			if err != nil {
+				fmt.Printf("Failed to delete S3 object %s: %v\n", item.ArchivedS3Key, err)
+				// Continue anyway
+			}
 		}
 
-		// Delete from PostgreSQL
+		// Delete from PostgreSQL, re-checking the legal hold
 		_, err = am.db.ExecContext(ctx, `
-			DELETE FROM action_executions
-			WHERE operation_id = $1
-		`, operationID)
+			DELETE FROM action_executions ae
+			WHERE ae.operation_id = $1
+				AND NOT EXISTS (
+					SELECT 1 FROM trace_legal_holds h
+					WHERE h.correlation_id = ae.correlation_id AND h.released_at IS NULL
+				)
+		`, item.OperationID)
 		if err != nil {
-			fmt.Printf("Failed to delete trace record %s: %v\n", operationID, err)
+			fmt.Printf("Failed to delete trace record %s: %v\n", item.OperationID, err)
 			continue
 		}
 
@@ -300,7 +257,80 @@ func (am *ArchivalManager) DeleteOldArchivedTraces(ctx context.Context) (*Archiv
 		stats.LastDeletedAt = time.Now()
 	}
 
-	return stats, rows.Err()
+	if before, ok := am.snapshotCutoff(); ok {
+		if am.config.DryRun {
+			fmt.Printf("[DRY RUN] Would delete dependency snapshots captured before %s\n", before.Format(time.RFC3339))
+		} else {
+			deleted, err := pruneDependencySnapshots(ctx, am.db, before)
+			if err != nil {
+				return stats, err
+			}
+			stats.SnapshotsDeleted = deleted
+		}
+	}
+
+	return stats, nil
+}
+
+// snapshotCutoff returns the capture time before which dependency snapshots
+// expire, and false if snapshots are kept forever
+func (am *ArchivalManager) snapshotCutoff() (time.Time, bool) {
+	if am.config.SnapshotRetentionDays < 0 {
+		return time.Time{}, false
+	}
+	return time.Now().AddDate(0, 0, -am.config.SnapshotRetentionDays), true
+}
+
+// PlanRetention reports what ArchiveOldTraces and DeleteOldArchivedTraces would
+// do, per policy, without changing anything. Items lists up to BatchSize due traces.
+func (am *ArchivalManager) PlanRetention(ctx context.Context) (*RetentionReport, error) {
+	plan, err := am.retentionPlan()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RetentionReport{GeneratedAt: time.Now()}
+	if report.Policies, err = plan.report(ctx, am.db); err != nil {
+		return nil, err
+	}
+	if report.Items, err = plan.dueTraces(ctx, am.db, "", am.config.BatchSize); err != nil {
+		return nil, err
+	}
+	for _, p := range report.Policies {
+		report.Totals.ToArchive += p.ToArchive
+		report.Totals.ToDelete += p.ToDelete
+		report.Totals.Held += p.Held
+	}
+	if before, ok := am.snapshotCutoff(); ok {
+		err := am.db.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM dependency_graph_snapshots WHERE captured_at < $1`, before,
+		).Scan(&report.Totals.SnapshotsToDelete)
+		if err != nil {
+			return nil, fmt.Errorf("count expired snapshots: %w", err)
+		}
+	}
+	return report, nil
+}
+
+// DefaultRetentionPolicy returns the policy for traces matching no configured policy
+func (am *ArchivalManager) DefaultRetentionPolicy() RetentionPolicy {
+	policy := RetentionPolicy{Name: "default", HotDays: am.config.ArchiveAfterDays}
+	switch {
+	case am.config.DeleteAfterDays > am.config.ArchiveAfterDays:
+		policy.ArchiveDays = am.config.DeleteAfterDays - am.config.ArchiveAfterDays
+	case am.config.DeleteAfterDays > 0:
+		policy.HotDays = am.config.DeleteAfterDays
+		policy.SkipArchive = true
+	}
+	return policy
+}
+
+// retentionPlan validates the configured policies and builds the retention query
+func (am *ArchivalManager) retentionPlan() (*retentionPlan, error) {
+	if err := ValidateRetentionPolicies(am.config.Policies); err != nil {
+		return nil, err
+	}
+	return newRetentionPlan(am.config.Policies, am.DefaultRetentionPolicy()), nil
 }
 
 // RestoreArchivedTrace initiates Glacier restore for a trace
@@ -10,8 +10,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/lib/pq"
 )
 
@@ -30,7 +28,7 @@ var DefaultPIIPatterns = []PIIPattern{
 	{Type: "credit_card", Pattern: `\b\d{4}[-\s]?\d{4}[-\s]?\d{4}[-\s]?\d{4}\b`, Confidence: 0.90},
 	{Type: "ip_address", Pattern: `\b\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}\b`, Confidence: 0.80},
 	{Type: "passport", Pattern: `\b[A-Z]{1,2}\d{6,9}\b`, Confidence: 0.70},
-	{Type: "iban", Pattern: `\b[A-Z]{2}\d{2}[A-Z0-9]{1,30}\b`, Confidence: 0.75},
+	{Type: "iban", Pattern: `\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`, Confidence: 0.75},
 }
 
 // PIIDetection represents a detected PII occurrence
@@ -53,6 +51,7 @@ type ErasureResult struct {
 	DeletedPII     int
 	S3URLsToDelete []string
 	ErasureCertID  string
+	HeldActions    int // Actions kept because their correlation ID is under legal hold
 }
 
 // ComplianceConfig holds configuration for compliance features
@@ -65,12 +64,24 @@ type ComplianceConfig struct {
 }
 
 // EraseTraces implements GDPR Right to Erasure (Article 17)
-// Deletes all traces for a data subject or correlation ID from PostgreSQL and S3
+// Deletes all traces for a data subject or correlation ID from PostgreSQL and S3.
+// Traces under a legal hold are kept and counted in HeldActions; erasing a held
+// correlation ID returns ErrLegalHold.
 func (t *Tracer) EraseTraces(ctx context.Context, dataSubjectID, correlationID, userID, purpose string) (*ErasureResult, error) {
 	if dataSubjectID == "" && correlationID == "" {
 		return nil, fmt.Errorf("must provide either data_subject_id or correlation_id")
 	}
 
+	if correlationID != "" {
+		held, err := t.IsOnLegalHold(ctx, correlationID)
+		if err != nil {
+			return nil, err
+		}
+		if held {
+			return nil, fmt.Errorf("%w: %s", ErrLegalHold, correlationID)
+		}
+	}
+
 	// Call PostgreSQL function to perform erasure
 	query := `SELECT * FROM gdpr_erase_traces($1, $2, $3, $4)`
 
@@ -88,6 +99,7 @@ func (t *Tracer) EraseTraces(ctx context.Context, dataSubjectID, correlationID,
 		&result.DeletedActions,
 		&result.DeletedPII,
 		&s3URLsArray,
+		&result.HeldActions,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute erasure: %w", err)
@@ -100,13 +112,10 @@ func (t *Tracer) EraseTraces(ctx context.Context, dataSubjectID, correlationID,
 		}
 	}
 
-	// Delete S3 objects
-	if t.config.S3Client != nil {
-		for _, s3URL := range result.S3URLsToDelete {
-			if err := t.deleteS3Object(ctx, s3URL); err != nil {
-				t.logError(fmt.Sprintf("Failed to delete S3 object: %s", s3URL), err)
-				// Continue with other deletions
-			}
+	// Delete stored payloads
+	if len(result.S3URLsToDelete) > 0 {
+		if err := t.deletePayloads(ctx, result.S3URLsToDelete); err != nil {
+			t.logError("Failed to delete payloads", err)
 		}
 	}
 
@@ -278,8 +287,13 @@ func (t *Tracer) GetAuditTrail(ctx context.Context, dataSubjectID, correlationID
 	return results, nil
 }
 
-// DetectPII scans data for PII patterns
+// DetectPII scans data for PII patterns.
+// Without explicit patterns the tracer's PII rules (Config.PIIRules) are used, which
+// also report the JSON field path of each detection.
 func (t *Tracer) DetectPII(data string, patterns []PIIPattern) []PIIDetection {
+	if patterns == nil && t.pii != nil {
+		return t.pii.Detect([]byte(data))
+	}
 	if patterns == nil {
 		patterns = DefaultPIIPatterns
 	}
@@ -350,27 +364,26 @@ func RedactPII(data string, detections []PIIDetection) string {
 	return redacted
 }
 
-// deleteS3Object deletes an S3 object by S3 URL
-func (t *Tracer) deleteS3Object(ctx context.Context, s3URL string) error {
-	// Parse S3 URL: s3://bucket/key
-	if !strings.HasPrefix(s3URL, "s3://") {
-		return fmt.Errorf("invalid S3 URL: %s", s3URL)
+// deletePayloads deletes the payloads referenced by s3://bucket/key URLs from
+// payload storage, continuing past individual failures
+func (t *Tracer) deletePayloads(ctx context.Context, urls []string) error {
+	store, err := t.payloadStore()
+	if err != nil {
+		return err
 	}
 
-	parts := strings.SplitN(strings.TrimPrefix(s3URL, "s3://"), "/", 2)
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid S3 URL format: %s", s3URL)
+	for _, s3URL := range urls {
+		key, ok := payloadKey(s3URL)
+		if !ok {
+			t.logError(fmt.Sprintf("Failed to delete payload: %s", s3URL), fmt.Errorf("invalid S3 URL"))
+			continue
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			t.logError(fmt.Sprintf("Failed to delete payload: %s", s3URL), err)
+			// Continue with other deletions
+		}
 	}
-
-	bucket := parts[0]
-	key := parts[1]
-
-	_, err := t.config.S3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	})
-
-	return err
+	return nil
 }
 
 // DeleteExpiredTraces runs retention policy enforcement
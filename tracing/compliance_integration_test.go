@@ -0,0 +1,147 @@
+//go:build integration
+
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"eve.evalgo.org/storage"
+)
+
+// setupInitSQL starts a TimescaleDB container and applies docker/postgres/init.sql
+func setupInitSQL(t *testing.T) *sql.DB {
+	ctx := context.Background()
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "timescale/timescaledb:latest-pg16",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_USER":     "claude",
+				"POSTGRES_PASSWORD": "testpass",
+				"POSTGRES_DB":       "action_traces",
+			},
+			WaitingFor: wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	require.NoError(t, err, "Failed to start TimescaleDB container")
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%s user=claude password=testpass dbname=action_traces sslmode=disable", host, port.Port()))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := os.ReadFile("../docker/postgres/init.sql")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, string(schema))
+	require.NoError(t, err, "Failed to apply init.sql")
+	return db
+}
+
+// insertAction inserts an action execution with stored payload URLs
+func insertAction(t *testing.T, db *sql.DB, correlationID, dataSubjectID string) {
+	_, err := db.Exec(`
+		INSERT INTO action_executions (correlation_id, operation_id, action_type, service_id,
+			data_subject_id, request_url, logs_url, retention_until)
+		VALUES ($1, 'op', 'SearchAction', 'crm', $2, $3, $4, NOW() - INTERVAL '1 day')
+	`, correlationID, dataSubjectID,
+		"s3://traces/"+correlationID+"/op/request.json",
+		"s3://traces/"+correlationID+"/op/logs.txt")
+	require.NoError(t, err)
+}
+
+// TestEraseTracesInitSQL tests GDPR erasure against the schema in docker/postgres/init.sql
+func TestEraseTracesInitSQL(t *testing.T) {
+	db := setupInitSQL(t)
+	ctx := context.Background()
+
+	store, err := storage.NewLocalObjectStore(t.TempDir())
+	require.NoError(t, err)
+	tracer := &Tracer{config: Config{DB: db, ObjectStore: store}}
+	require.NoError(t, tracer.UploadLogs(ctx, "corr-free", "op", []byte("log line")))
+
+	insertAction(t, db, "corr-free", "alice")
+	insertAction(t, db, "corr-held", "alice")
+	insertAction(t, db, "corr-other", "bob")
+
+	_, err = tracer.PlaceLegalHold(ctx, "corr-held", "litigation", "legal")
+	require.NoError(t, err)
+
+	result, err := tracer.EraseTraces(ctx, "alice", "", "dpo", "GDPR Right to Erasure")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.DeletedActions)
+	assert.Equal(t, 1, result.HeldActions)
+	sort.Strings(result.S3URLsToDelete)
+	assert.Equal(t, []string{
+		"s3://traces/corr-free/op/logs.txt",
+		"s3://traces/corr-free/op/request.json",
+	}, result.S3URLsToDelete)
+
+	_, err = store.Stat(ctx, "corr-free/op/logs.txt")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound, "erased payloads are deleted")
+
+	_, err = tracer.EraseTraces(ctx, "", "corr-held", "dpo", "GDPR Right to Erasure")
+	assert.ErrorIs(t, err, ErrLegalHold)
+
+	deleted, err := tracer.DeleteExpiredTraces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted, "retention skips held traces")
+
+	require.NoError(t, tracer.ReleaseLegalHold(ctx, "corr-held", "legal"))
+	result, err = tracer.EraseTraces(ctx, "", "corr-held", "dpo", "GDPR Right to Erasure")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.DeletedActions)
+	assert.Zero(t, result.HeldActions)
+}
+
+// TestDependencySnapshotRetentionInitSQL tests snapshot pruning and its retention report
+func TestDependencySnapshotRetentionInitSQL(t *testing.T) {
+	db := setupInitSQL(t)
+	ctx := context.Background()
+
+	for _, age := range []time.Duration{0, 10 * 24 * time.Hour, 100 * 24 * time.Hour} {
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO dependency_graph_snapshots (window_hours, captured_at, service_count, edge_count, graph)
+			VALUES (24, $1, 0, 0, '{"services":[],"edges":[]}')
+		`, time.Now().Add(-age))
+		require.NoError(t, err)
+	}
+
+	archival := NewArchivalManager(db, nil, ArchivalConfig{})
+	report, err := archival.PlanRetention(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Totals.SnapshotsToDelete, "default keeps 90 days")
+
+	stats, err := archival.DeleteOldArchivedTraces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.SnapshotsDeleted)
+
+	mapper := NewDependencyMapper(db)
+	deleted, err := mapper.PruneSnapshots(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	snapshots, err := mapper.ListSnapshots(ctx, 24, 0)
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+}
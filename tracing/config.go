@@ -3,12 +3,15 @@ package tracing
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"eve.evalgo.org/storage"
 )
 
 // Config holds tracer configuration
@@ -25,6 +28,10 @@ type Config struct {
 	// S3 bucket name for traces (e.g., "eve-traces")
 	S3Bucket string
 
+	// ObjectStore for payload storage (optional, defaults to S3Client/S3Bucket)
+	// Set to a storage.LocalObjectStore to keep payloads on disk
+	ObjectStore storage.ObjectStore
+
 	// S3 endpoint URL (for Hetzner or MinIO)
 	S3Endpoint string
 
@@ -47,6 +54,16 @@ type Config struct {
 	EnablePII     bool   // Enable PII detection
 	LegalBasis    string // Default legal basis for processing
 
+	// RetentionPolicies set retention_until per service, action type and legal
+	// basis; traces matching no policy use RetentionDays
+	RetentionPolicies []RetentionPolicy
+
+	// PIIRules configures PII detection and redaction (default: DefaultPIIRuleSet)
+	PIIRules *PIIRuleSet
+
+	// RedactPayloads applies the PII rule actions to payloads before they are stored
+	RedactPayloads bool
+
 	// Async export settings
 	AsyncExport bool                // Enable async trace export (default: true)
 	AsyncConfig AsyncExporterConfig // Async exporter configuration
@@ -58,6 +75,14 @@ type Config struct {
 	// Sampling settings
 	SamplingEnabled bool           // Enable tail-based sampling (default: false)
 	SamplingConfig  SamplingConfig // Sampling configuration
+
+	// Routes selects the requests traced by Middleware (default: DefaultTraceRoutes)
+	Routes []TraceRoute
+
+	// MaxBodySize caps how much of a request or response body is buffered for tracing
+	// (default: DefaultMaxBodySize). Larger bodies reach the handler unchanged but
+	// their payloads are not stored.
+	MaxBodySize int64
 }
 
 // Tracer handles action execution tracing
@@ -66,6 +91,7 @@ type Tracer struct {
 	asyncExporter *AsyncExporter // Optional async exporter
 	metrics       *Metrics       // Optional Prometheus metrics
 	sampler       *Sampler       // Optional tail-based sampler
+	pii           *PIIRedactor   // Compiled PII rules (nil if PII detection is disabled)
 }
 
 // New creates a new tracer instance
@@ -91,6 +117,20 @@ func New(config Config) *Tracer {
 		tracer.sampler = NewSampler(config.SamplingConfig)
 	}
 
+	// Compile PII rules if PII detection or redaction is enabled
+	if config.EnablePII || config.RedactPayloads {
+		rules := config.PIIRules
+		if rules == nil {
+			rules = DefaultPIIRuleSet()
+		}
+		pii, err := rules.Compile()
+		if err != nil {
+			tracer.logError("Invalid PII rules, using defaults", err)
+			pii, _ = DefaultPIIRuleSet().Compile()
+		}
+		tracer.pii = pii
+	}
+
 	return tracer
 }
 
@@ -101,6 +141,11 @@ func New(config Config) *Tracer {
 //   - TRACING_STORE_PAYLOADS: Store request/response in S3 (default: false)
 //   - TRACING_EXCLUDE_ACTIONS: Comma-separated action types to exclude (e.g., "WaitAction,SearchAction")
 //   - TRACING_EXCLUDE_OBJECTS: Comma-separated object types to exclude (e.g., "Database,DataFeed")
+//   - TRACING_ROUTES: Comma-separated route patterns to trace (e.g., "/v1/api/semantic/action,/v1/api/*")
+//   - TRACING_MAX_BODY_SIZE: Maximum bytes buffered per body for tracing (default: 1048576)
+//   - TRACING_RETENTION_POLICIES: Path to a YAML/JSON file of retention policies
+//   - TRACING_PII_RULES: Path to a YAML/JSON PII rule set (default: DefaultPIIRuleSet)
+//   - TRACING_REDACT_PAYLOADS: Redact PII in payloads before storing them (default: true)
 //   - S3_BUCKET: S3 bucket name (default: eve-traces)
 //   - S3_ENDPOINT_URL: S3 endpoint URL (optional, for Hetzner/MinIO)
 func NewFromEnv(serviceID string, db *sql.DB, s3Client *s3.Client) *Tracer {
@@ -142,6 +187,21 @@ func NewFromEnv(serviceID string, db *sql.DB, s3Client *s3.Client) *Tracer {
 		}
 	}
 
+	// Parse traced routes
+	if routes := os.Getenv("TRACING_ROUTES"); routes != "" {
+		for _, path := range strings.Split(routes, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				config.Routes = append(config.Routes, TraceRoute{Path: path})
+			}
+		}
+	}
+
+	if maxBody := os.Getenv("TRACING_MAX_BODY_SIZE"); maxBody != "" {
+		if n, err := strconv.ParseInt(maxBody, 10, 64); err == nil && n > 0 {
+			config.MaxBodySize = n
+		}
+	}
+
 	// Parse GDPR compliance settings
 	config.DataRegion = os.Getenv("DATA_REGION")
 	if config.DataRegion == "" {
@@ -156,9 +216,30 @@ func NewFromEnv(serviceID string, db *sql.DB, s3Client *s3.Client) *Tracer {
 		}
 	}
 
+	// Parse retention policies
+	if policiesPath := os.Getenv("TRACING_RETENTION_POLICIES"); policiesPath != "" {
+		policies, err := LoadRetentionPolicies(policiesPath)
+		if err != nil {
+			fmt.Printf("[tracing] Failed to load retention policies: %v\n", err)
+		} else {
+			config.RetentionPolicies = policies
+		}
+	}
+
 	// Parse PII detection setting (default: true)
 	config.EnablePII = os.Getenv("TRACING_ENABLE_PII") != "false"
 
+	// Parse PII rules and payload redaction (enabled by default)
+	if rulesPath := os.Getenv("TRACING_PII_RULES"); rulesPath != "" {
+		rules, err := LoadPIIRuleSet(rulesPath)
+		if err != nil {
+			fmt.Printf("[tracing] Failed to load PII rules: %v\n", err)
+		} else {
+			config.PIIRules = rules
+		}
+	}
+	config.RedactPayloads = os.Getenv("TRACING_REDACT_PAYLOADS") != "false"
+
 	// Parse legal basis
 	config.LegalBasis = os.Getenv("TRACING_LEGAL_BASIS")
 	if config.LegalBasis == "" {
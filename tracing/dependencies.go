@@ -6,6 +6,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,6 +17,7 @@ type DependencyGraph struct {
 	dependencies map[string]map[string]*DependencyEdge // from -> to -> edge
 	services     map[string]*ServiceNode
 	lastUpdated  time.Time
+	windowHours  int // Time window of the traces the graph was built from
 }
 
 // ServiceNode represents a service in the dependency graph
@@ -91,7 +94,7 @@ func (dm *DependencyMapper) BuildGraphFromTraces(ctx context.Context, hours int)
 			w2.service_id as to_service,
 			w1.action_type,
 			w1.object_type,
-			CASE WHEN w1.action_status = 'failed' THEN 1 ELSE 0 END as is_error,
+			CASE WHEN w1.action_status IN ('failed', 'FailedActionStatus') THEN 1 ELSE 0 END as is_error,
 			w1.duration_ms,
 			COUNT(*) OVER (PARTITION BY w1.service_id, w2.service_id) as call_count
 		FROM workflow_traces w1
@@ -113,6 +116,7 @@ func (dm *DependencyMapper) BuildGraphFromTraces(ctx context.Context, hours int)
 		dependencies: make(map[string]map[string]*DependencyEdge),
 		services:     make(map[string]*ServiceNode),
 		lastUpdated:  time.Now(),
+		windowHours:  hours,
 	}
 
 	// Process rows
@@ -227,6 +231,7 @@ func (dm *DependencyMapper) GetGraph() *DependencyGraph {
 		dependencies: make(map[string]map[string]*DependencyEdge),
 		services:     make(map[string]*ServiceNode),
 		lastUpdated:  dm.graph.lastUpdated,
+		windowHours:  dm.graph.windowHours,
 	}
 
 	for serviceID, service := range dm.graph.services {
@@ -301,6 +306,11 @@ func (dm *DependencyMapper) DetectCircularDependencies() []CircularDependency {
 	dm.mu.RLock()
 	defer dm.mu.RUnlock()
 
+	return detectCycles(dm.graph)
+}
+
+// detectCycles runs the cycle detection on a graph the caller has locked or owns
+func detectCycles(graph *DependencyGraph) []CircularDependency {
 	cycles := []CircularDependency{}
 	visited := make(map[string]bool)
 	recStack := make(map[string]bool)
@@ -313,7 +323,7 @@ func (dm *DependencyMapper) DetectCircularDependencies() []CircularDependency {
 		path = append(path, service)
 
 		// Check all dependencies
-		if deps, exists := dm.graph.dependencies[service]; exists {
+		if deps, exists := graph.dependencies[service]; exists {
 			for toService := range deps {
 				if !visited[toService] {
 					if dfs(toService, path) {
@@ -346,7 +356,7 @@ func (dm *DependencyMapper) DetectCircularDependencies() []CircularDependency {
 	}
 
 	// Run DFS from each unvisited service
-	for serviceID := range dm.graph.services {
+	for _, serviceID := range sortedKeys(graph.services) {
 		if !visited[serviceID] {
 			dfs(serviceID, []string{})
 		}
@@ -360,12 +370,26 @@ func (dm *DependencyMapper) ExportToDOT() string {
 	dm.mu.RLock()
 	defer dm.mu.RUnlock()
 
+	return exportDOT(dm.graph)
+}
+
+// ExportToMermaid exports dependency graph to a Mermaid flowchart
+func (dm *DependencyMapper) ExportToMermaid() string {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	return exportMermaid(dm.graph)
+}
+
+// exportDOT renders a graph in Graphviz DOT format (services and edges sorted by ID)
+func exportDOT(graph *DependencyGraph) string {
 	dot := "digraph ServiceDependencies {\n"
 	dot += "  rankdir=LR;\n"
 	dot += "  node [shape=box, style=rounded];\n\n"
 
 	// Add nodes
-	for serviceID, service := range dm.graph.services {
+	for _, serviceID := range sortedKeys(graph.services) {
+		service := graph.services[serviceID]
 		errorRate := 0.0
 		if service.RequestCount > 0 {
 			errorRate = float64(service.ErrorCount) / float64(service.RequestCount) * 100
@@ -386,8 +410,10 @@ func (dm *DependencyMapper) ExportToDOT() string {
 	dot += "\n"
 
 	// Add edges
-	for from, deps := range dm.graph.dependencies {
-		for to, edge := range deps {
+	for _, from := range sortedKeys(graph.dependencies) {
+		deps := graph.dependencies[from]
+		for _, to := range sortedKeys(deps) {
+			edge := deps[to]
 			label := fmt.Sprintf("%d calls\\n%.0fms", edge.CallCount, edge.AvgLatencyMs)
 
 			// Edge color based on error rate
@@ -410,6 +436,67 @@ func (dm *DependencyMapper) ExportToDOT() string {
 	return dot
 }
 
+// exportMermaid renders a graph as a Mermaid flowchart, colored like exportDOT
+func exportMermaid(graph *DependencyGraph) string {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+
+	// Add nodes
+	for _, serviceID := range sortedKeys(graph.services) {
+		service := graph.services[serviceID]
+		errorRate := 0.0
+		if service.RequestCount > 0 {
+			errorRate = float64(service.ErrorCount) / float64(service.RequestCount) * 100
+		}
+
+		id := mermaidID(serviceID)
+		fmt.Fprintf(&b, "  %s[\"%s<br/>%d req<br/>%.2f%% errors<br/>%.0fms avg\"]\n",
+			id, serviceID, service.RequestCount, errorRate, service.AvgLatencyMs)
+		if errorRate > 10 {
+			fmt.Fprintf(&b, "  style %s fill:#f99,stroke:#c00\n", id)
+		} else if errorRate > 5 {
+			fmt.Fprintf(&b, "  style %s fill:#fc9,stroke:#f90\n", id)
+		}
+	}
+
+	// Add edges (linkStyle refers to edges by declaration order)
+	edgeIndex := 0
+	for _, from := range sortedKeys(graph.dependencies) {
+		deps := graph.dependencies[from]
+		for _, to := range sortedKeys(deps) {
+			edge := deps[to]
+			fmt.Fprintf(&b, "  %s -->|\"%d calls, %.0fms\"| %s\n",
+				mermaidID(from), edge.CallCount, edge.AvgLatencyMs, mermaidID(to))
+			if edge.CallCount > 0 {
+				errorRate := float64(edge.ErrorCount) / float64(edge.CallCount) * 100
+				if errorRate > 10 {
+					fmt.Fprintf(&b, "  linkStyle %d stroke:red\n", edgeIndex)
+				} else if errorRate > 5 {
+					fmt.Fprintf(&b, "  linkStyle %d stroke:orange\n", edgeIndex)
+				}
+			}
+			edgeIndex++
+		}
+	}
+
+	return b.String()
+}
+
+// mermaidID turns a service ID into a Mermaid node ID. Letters and digits are
+// kept and every other byte is escaped as _xx, so distinct IDs never collide.
+func mermaidID(serviceID string) string {
+	var b strings.Builder
+	for i := 0; i < len(serviceID); i++ {
+		c := serviceID[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	return b.String()
+}
+
 // ExportToJSON exports dependency graph to JSON
 func (dm *DependencyMapper) ExportToJSON() (string, error) {
 	dm.mu.RLock()
@@ -443,8 +530,18 @@ func (dm *DependencyMapper) GetMetrics() map[string]interface{} {
 		"total_services":     len(dm.graph.services),
 		"total_dependencies": totalEdges,
 		"last_updated":       dm.graph.lastUpdated,
-		"has_cycles":         len(dm.DetectCircularDependencies()) > 0,
+		"has_cycles":         len(detectCycles(dm.graph)) > 0,
+	}
+}
+
+// sortedKeys returns the keys of a map in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return keys
 }
 
 // Helper function
@@ -0,0 +1,185 @@
+// Package tracing - HTTP handlers for the service dependency graph
+package tracing
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterRoutes adds the dependency graph endpoints to an Echo group.
+//
+// Routes:
+//   - GET /dependencies?window=&format=: live graph of a time window
+//   - GET /dependencies/snapshots?window=&limit=: persisted snapshots, newest first
+//   - GET /dependencies/snapshots/:id?format=: a persisted snapshot
+//   - GET /dependencies/diff?window=&base=&since=&current=&threshold=: changes between snapshots
+//
+// window is in hours (default: the first configured window). format is json
+// (default), dot or mermaid. The diff compares snapshot current (default: the live
+// graph) with snapshot base, or the latest snapshot at or before since (RFC 3339),
+// or by default the latest snapshot one window before current. threshold is the
+// error rate increase reported as a regression (default 0.05).
+func (r *DependencyRefresher) RegisterRoutes(g *echo.Group) {
+	g.GET("/dependencies", r.handleGraph)
+	g.GET("/dependencies/snapshots", r.handleListSnapshots)
+	g.GET("/dependencies/snapshots/:id", r.handleGetSnapshot)
+	g.GET("/dependencies/diff", r.handleDiff)
+}
+
+// handleGraph returns the live graph of a window
+func (r *DependencyRefresher) handleGraph(c echo.Context) error {
+	mapper, err := r.windowMapper(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err)
+	}
+
+	snapshot := mapper.Snapshot()
+	if c.QueryParam("format") == "" || c.QueryParam("format") == "json" {
+		lastRefresh, refreshErr := r.LastRefresh()
+		response := map[string]interface{}{
+			"window_hours": snapshot.WindowHours,
+			"captured_at":  snapshot.CapturedAt,
+			"services":     snapshot.Services,
+			"edges":        snapshot.Edges,
+			"cycles":       mapper.DetectCircularDependencies(),
+			"last_refresh": lastRefresh,
+		}
+		if refreshErr != nil {
+			response["refresh_error"] = refreshErr.Error()
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+	return writeSnapshot(c, snapshot)
+}
+
+// handleListSnapshots returns persisted snapshots of a window
+func (r *DependencyRefresher) handleListSnapshots(c echo.Context) error {
+	mapper, err := r.windowMapper(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err)
+	}
+	limit, err := intParam(c, "limit", DefaultQueryLimit)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err)
+	}
+
+	snapshots, err := mapper.ListSnapshots(c.Request().Context(), r.window(c), limit)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, snapshots)
+}
+
+// handleGetSnapshot returns a persisted snapshot
+func (r *DependencyRefresher) handleGetSnapshot(c echo.Context) error {
+	snapshot, status, err := loadSnapshot(c, r.mappers[r.config.Windows[0]], c.Param("id"))
+	if err != nil {
+		return errorJSON(c, status, err)
+	}
+	return writeSnapshot(c, snapshot)
+}
+
+// handleDiff compares two snapshots of a window
+func (r *DependencyRefresher) handleDiff(c echo.Context) error {
+	ctx := c.Request().Context()
+	mapper, err := r.windowMapper(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err)
+	}
+	window := r.window(c)
+
+	threshold := 0.0
+	if value := c.QueryParam("threshold"); value != "" {
+		if threshold, err = strconv.ParseFloat(value, 64); err != nil || threshold < 0 {
+			return errorJSON(c, http.StatusBadRequest, fmt.Errorf("invalid threshold: %q", value))
+		}
+	}
+
+	current := mapper.Snapshot()
+	if value := c.QueryParam("current"); value != "" {
+		var status int
+		if current, status, err = loadSnapshot(c, mapper, value); err != nil {
+			return errorJSON(c, status, err)
+		}
+	}
+
+	var base *DependencySnapshot
+	switch {
+	case c.QueryParam("base") != "":
+		var status int
+		if base, status, err = loadSnapshot(c, mapper, c.QueryParam("base")); err != nil {
+			return errorJSON(c, status, err)
+		}
+	default:
+		since, err := timeParam(c, "since")
+		if err != nil {
+			return errorJSON(c, http.StatusBadRequest, err)
+		}
+		if since.IsZero() {
+			since = current.CapturedAt.Add(-time.Duration(window) * time.Hour)
+		}
+		base, err = mapper.SnapshotAt(ctx, window, since)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorJSON(c, http.StatusNotFound, fmt.Errorf("no %dh snapshot at or before %s", window, since.Format(time.RFC3339)))
+		}
+		if err != nil {
+			return errorJSON(c, http.StatusInternalServerError, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, CompareSnapshots(base, current, threshold))
+}
+
+// loadSnapshot loads a snapshot by ID, returning the HTTP status for failures
+func loadSnapshot(c echo.Context, mapper *DependencyMapper, value string) (*DependencySnapshot, int, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid snapshot id: %q", value)
+	}
+	snapshot, err := mapper.GetSnapshot(c.Request().Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, http.StatusNotFound, fmt.Errorf("snapshot %d not found", id)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return snapshot, http.StatusOK, nil
+}
+
+// window returns the requested window in hours
+func (r *DependencyRefresher) window(c echo.Context) int {
+	window, err := intParam(c, "window", r.config.Windows[0])
+	if err != nil {
+		return 0
+	}
+	return window
+}
+
+// windowMapper returns the mapper of the requested window
+func (r *DependencyRefresher) windowMapper(c echo.Context) (*DependencyMapper, error) {
+	mapper, ok := r.Mapper(r.window(c))
+	if !ok {
+		return nil, fmt.Errorf("invalid window %q (configured: %v)", c.QueryParam("window"), r.config.Windows)
+	}
+	return mapper, nil
+}
+
+// writeSnapshot writes a snapshot in the requested format
+func writeSnapshot(c echo.Context, snapshot *DependencySnapshot) error {
+	switch format := c.QueryParam("format"); format {
+	case "", "json":
+		return c.JSON(http.StatusOK, snapshot)
+	case "dot":
+		return c.Blob(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(snapshot.ExportToDOT()))
+	case "mermaid":
+		return c.String(http.StatusOK, snapshot.ExportToMermaid())
+	default:
+		return errorJSON(c, http.StatusBadRequest, fmt.Errorf("unknown format %q (json, dot, mermaid)", format))
+	}
+}
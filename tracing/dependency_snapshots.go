@@ -0,0 +1,440 @@
+// Package tracing - Dependency graph snapshots and background refresh
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultErrorRateRegression is the error rate increase (absolute, 0.05 = 5 percentage
+// points) that CompareSnapshots reports as a regression
+const DefaultErrorRateRegression = 0.05
+
+// DefaultSnapshotRetention is how long DependencyRefresher keeps persisted snapshots
+const DefaultSnapshotRetention = 30 * 24 * time.Hour
+
+// DependencySnapshot is a point-in-time copy of a dependency graph for one time window
+type DependencySnapshot struct {
+	ID          int64             `json:"id,omitempty"`
+	WindowHours int               `json:"window_hours"`
+	CapturedAt  time.Time         `json:"captured_at"`
+	Services    []*ServiceNode    `json:"services"`
+	Edges       []*DependencyEdge `json:"edges"`
+}
+
+// DependencySnapshotInfo describes a persisted snapshot without its graph
+type DependencySnapshotInfo struct {
+	ID           int64     `json:"id"`
+	WindowHours  int       `json:"window_hours"`
+	CapturedAt   time.Time `json:"captured_at"`
+	ServiceCount int       `json:"service_count"`
+	EdgeCount    int       `json:"edge_count"`
+}
+
+// DependencyDiff describes how the dependency graph changed between two snapshots
+type DependencyDiff struct {
+	BaseID            int64                 `json:"base_id,omitempty"`
+	BaseCapturedAt    time.Time             `json:"base_captured_at"`
+	CurrentID         int64                 `json:"current_id,omitempty"`
+	CurrentCapturedAt time.Time             `json:"current_captured_at"`
+	NewServices       []string              `json:"new_services"`
+	RemovedServices   []string              `json:"removed_services"`
+	NewEdges          []*DependencyEdge     `json:"new_edges"`
+	RemovedEdges      []*DependencyEdge     `json:"removed_edges"`
+	ErrorRegressions  []ErrorRateRegression `json:"error_regressions"`
+}
+
+// ErrorRateRegression is an edge whose error rate increased between snapshots
+type ErrorRateRegression struct {
+	FromService      string  `json:"from_service"`
+	ToService        string  `json:"to_service"`
+	BaseErrorRate    float64 `json:"base_error_rate"`
+	CurrentErrorRate float64 `json:"current_error_rate"`
+	CallCount        int64   `json:"call_count"`
+}
+
+// Snapshot returns a copy of the current graph, services and edges sorted by service ID
+func (dm *DependencyMapper) Snapshot() *DependencySnapshot {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	snapshot := &DependencySnapshot{
+		WindowHours: dm.graph.windowHours,
+		CapturedAt:  dm.graph.lastUpdated,
+		Services:    make([]*ServiceNode, 0, len(dm.graph.services)),
+		Edges:       []*DependencyEdge{},
+	}
+	for _, serviceID := range sortedKeys(dm.graph.services) {
+		serviceCopy := *dm.graph.services[serviceID]
+		snapshot.Services = append(snapshot.Services, &serviceCopy)
+	}
+	for _, from := range sortedKeys(dm.graph.dependencies) {
+		deps := dm.graph.dependencies[from]
+		for _, to := range sortedKeys(deps) {
+			edgeCopy := *deps[to]
+			snapshot.Edges = append(snapshot.Edges, &edgeCopy)
+		}
+	}
+	return snapshot
+}
+
+// SaveSnapshot persists the current graph in dependency_graph_snapshots
+func (dm *DependencyMapper) SaveSnapshot(ctx context.Context) (*DependencySnapshot, error) {
+	snapshot := dm.Snapshot()
+
+	graph, err := json.Marshal(snapshotGraph{Services: snapshot.Services, Edges: snapshot.Edges})
+	if err != nil {
+		return nil, fmt.Errorf("marshal snapshot: %w", err)
+	}
+
+	query := `
+		INSERT INTO dependency_graph_snapshots (window_hours, captured_at, service_count, edge_count, graph)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err = dm.db.QueryRowContext(ctx, query,
+		snapshot.WindowHours, snapshot.CapturedAt, len(snapshot.Services), len(snapshot.Edges), graph,
+	).Scan(&snapshot.ID)
+	if err != nil {
+		return nil, fmt.Errorf("insert snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// PruneSnapshots deletes persisted snapshots of all windows captured before t
+// and returns the number of deleted snapshots
+func (dm *DependencyMapper) PruneSnapshots(ctx context.Context, before time.Time) (int64, error) {
+	return pruneDependencySnapshots(ctx, dm.db, before)
+}
+
+// pruneDependencySnapshots deletes snapshots captured before t
+func pruneDependencySnapshots(ctx context.Context, db *sql.DB, before time.Time) (int64, error) {
+	result, err := db.ExecContext(ctx, `DELETE FROM dependency_graph_snapshots WHERE captured_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("prune snapshots: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListSnapshots returns persisted snapshots of a time window, newest first
+func (dm *DependencyMapper) ListSnapshots(ctx context.Context, windowHours, limit int) ([]DependencySnapshotInfo, error) {
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+
+	query := `
+		SELECT id, window_hours, captured_at, service_count, edge_count
+		FROM dependency_graph_snapshots
+		WHERE window_hours = $1
+		ORDER BY captured_at DESC
+		LIMIT $2
+	`
+	rows, err := dm.db.QueryContext(ctx, query, windowHours, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []DependencySnapshotInfo{}
+	for rows.Next() {
+		var info DependencySnapshotInfo
+		if err := rows.Scan(&info.ID, &info.WindowHours, &info.CapturedAt, &info.ServiceCount, &info.EdgeCount); err != nil {
+			return nil, fmt.Errorf("scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, info)
+	}
+	return snapshots, rows.Err()
+}
+
+// GetSnapshot loads a persisted snapshot (sql.ErrNoRows if it does not exist)
+func (dm *DependencyMapper) GetSnapshot(ctx context.Context, id int64) (*DependencySnapshot, error) {
+	query := `
+		SELECT id, window_hours, captured_at, graph
+		FROM dependency_graph_snapshots
+		WHERE id = $1
+	`
+	return scanSnapshot(dm.db.QueryRowContext(ctx, query, id))
+}
+
+// SnapshotAt loads the latest snapshot of a time window captured at or before t
+// (sql.ErrNoRows if there is none)
+func (dm *DependencyMapper) SnapshotAt(ctx context.Context, windowHours int, t time.Time) (*DependencySnapshot, error) {
+	query := `
+		SELECT id, window_hours, captured_at, graph
+		FROM dependency_graph_snapshots
+		WHERE window_hours = $1 AND captured_at <= $2
+		ORDER BY captured_at DESC
+		LIMIT 1
+	`
+	return scanSnapshot(dm.db.QueryRowContext(ctx, query, windowHours, t))
+}
+
+// snapshotGraph is the JSON stored in dependency_graph_snapshots.graph
+type snapshotGraph struct {
+	Services []*ServiceNode    `json:"services"`
+	Edges    []*DependencyEdge `json:"edges"`
+}
+
+// scanSnapshot reads a snapshot row
+func scanSnapshot(row *sql.Row) (*DependencySnapshot, error) {
+	var (
+		snapshot DependencySnapshot
+		raw      []byte
+		graph    snapshotGraph
+	)
+	if err := row.Scan(&snapshot.ID, &snapshot.WindowHours, &snapshot.CapturedAt, &raw); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &graph); err != nil {
+		return nil, fmt.Errorf("decode snapshot %d: %w", snapshot.ID, err)
+	}
+	snapshot.Services = graph.Services
+	snapshot.Edges = graph.Edges
+	return &snapshot, nil
+}
+
+// ExportToDOT exports the snapshot to Graphviz DOT format
+func (s *DependencySnapshot) ExportToDOT() string {
+	return exportDOT(s.graph())
+}
+
+// ExportToMermaid exports the snapshot to a Mermaid flowchart
+func (s *DependencySnapshot) ExportToMermaid() string {
+	return exportMermaid(s.graph())
+}
+
+// graph rebuilds the map form used by the exporters
+func (s *DependencySnapshot) graph() *DependencyGraph {
+	graph := &DependencyGraph{
+		dependencies: make(map[string]map[string]*DependencyEdge),
+		services:     make(map[string]*ServiceNode),
+		lastUpdated:  s.CapturedAt,
+		windowHours:  s.WindowHours,
+	}
+	for _, service := range s.Services {
+		graph.services[service.ServiceID] = service
+	}
+	for _, edge := range s.Edges {
+		if graph.dependencies[edge.FromService] == nil {
+			graph.dependencies[edge.FromService] = make(map[string]*DependencyEdge)
+		}
+		graph.dependencies[edge.FromService][edge.ToService] = edge
+	}
+	return graph
+}
+
+// CompareSnapshots reports services and edges added or removed between base and
+// current, and edges whose error rate grew by more than threshold (0 uses
+// DefaultErrorRateRegression). Regressions are sorted by the largest increase.
+func CompareSnapshots(base, current *DependencySnapshot, threshold float64) *DependencyDiff {
+	if threshold <= 0 {
+		threshold = DefaultErrorRateRegression
+	}
+
+	diff := &DependencyDiff{
+		BaseID:            base.ID,
+		BaseCapturedAt:    base.CapturedAt,
+		CurrentID:         current.ID,
+		CurrentCapturedAt: current.CapturedAt,
+		NewServices:       []string{},
+		RemovedServices:   []string{},
+		NewEdges:          []*DependencyEdge{},
+		RemovedEdges:      []*DependencyEdge{},
+		ErrorRegressions:  []ErrorRateRegression{},
+	}
+
+	baseGraph, currentGraph := base.graph(), current.graph()
+
+	for _, serviceID := range sortedKeys(currentGraph.services) {
+		if _, ok := baseGraph.services[serviceID]; !ok {
+			diff.NewServices = append(diff.NewServices, serviceID)
+		}
+	}
+	for _, serviceID := range sortedKeys(baseGraph.services) {
+		if _, ok := currentGraph.services[serviceID]; !ok {
+			diff.RemovedServices = append(diff.RemovedServices, serviceID)
+		}
+	}
+
+	for _, edge := range current.Edges {
+		baseEdge, ok := baseGraph.dependencies[edge.FromService][edge.ToService]
+		if !ok {
+			diff.NewEdges = append(diff.NewEdges, edge)
+			continue
+		}
+		baseRate, currentRate := edgeErrorRate(baseEdge), edgeErrorRate(edge)
+		if currentRate-baseRate > threshold {
+			diff.ErrorRegressions = append(diff.ErrorRegressions, ErrorRateRegression{
+				FromService:      edge.FromService,
+				ToService:        edge.ToService,
+				BaseErrorRate:    baseRate,
+				CurrentErrorRate: currentRate,
+				CallCount:        edge.CallCount,
+			})
+		}
+	}
+	for _, edge := range base.Edges {
+		if _, ok := currentGraph.dependencies[edge.FromService][edge.ToService]; !ok {
+			diff.RemovedEdges = append(diff.RemovedEdges, edge)
+		}
+	}
+
+	sort.SliceStable(diff.ErrorRegressions, func(i, j int) bool {
+		a, b := diff.ErrorRegressions[i], diff.ErrorRegressions[j]
+		return a.CurrentErrorRate-a.BaseErrorRate > b.CurrentErrorRate-b.BaseErrorRate
+	})
+	return diff
+}
+
+// edgeErrorRate returns the fraction of failed calls on an edge
+func edgeErrorRate(edge *DependencyEdge) float64 {
+	if edge.CallCount == 0 {
+		return 0
+	}
+	return float64(edge.ErrorCount) / float64(edge.CallCount)
+}
+
+// DependencyRefresherConfig configures background dependency graph refreshes
+type DependencyRefresherConfig struct {
+	Windows           []int         // Time windows in hours (default: 1, 24)
+	Interval          time.Duration // Refresh interval (default: 5m)
+	PersistSnapshots  bool          // Store a snapshot per window after each refresh
+	SnapshotRetention time.Duration // Persisted snapshots older than this are pruned after each refresh (default: 30 days, negative keeps all)
+}
+
+// DependencyRefresher keeps one dependency graph per time window up to date
+type DependencyRefresher struct {
+	config  DependencyRefresherConfig
+	db      *sql.DB
+	mappers map[int]*DependencyMapper
+
+	mu          sync.RWMutex
+	lastRefresh time.Time
+	lastErr     error
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+// NewDependencyRefresher creates a refresher; call Start to begin refreshing
+func NewDependencyRefresher(db *sql.DB, config DependencyRefresherConfig) *DependencyRefresher {
+	if len(config.Windows) == 0 {
+		config.Windows = []int{1, 24}
+	}
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Minute
+	}
+	if config.SnapshotRetention == 0 {
+		config.SnapshotRetention = DefaultSnapshotRetention
+	}
+
+	r := &DependencyRefresher{
+		config:  config,
+		db:      db,
+		mappers: make(map[int]*DependencyMapper, len(config.Windows)),
+	}
+	for _, hours := range config.Windows {
+		r.mappers[hours] = NewDependencyMapper(db)
+	}
+	return r
+}
+
+// Start refreshes all windows immediately and then every Interval until ctx is
+// cancelled or Shutdown is called
+func (r *DependencyRefresher) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+				fmt.Printf("[tracing] Failed to refresh dependency graph: %v\n", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown stops background refreshes
+func (r *DependencyRefresher) Shutdown(ctx context.Context) error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("shutdown timeout: %w", ctx.Err())
+	}
+}
+
+// Refresh rebuilds the graph of every window, persists snapshots if configured
+// and prunes snapshots older than SnapshotRetention
+func (r *DependencyRefresher) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, hours := range r.config.Windows {
+		mapper := r.mappers[hours]
+		if err := mapper.BuildGraphFromTraces(ctx, hours); err != nil {
+			errs = append(errs, fmt.Errorf("window %dh: %w", hours, err))
+			continue
+		}
+		if r.config.PersistSnapshots {
+			if _, err := mapper.SaveSnapshot(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("window %dh: %w", hours, err))
+			}
+		}
+	}
+	if r.config.PersistSnapshots && r.config.SnapshotRetention > 0 {
+		if _, err := pruneDependencySnapshots(ctx, r.db, time.Now().Add(-r.config.SnapshotRetention)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := errors.Join(errs...)
+
+	r.mu.Lock()
+	r.lastRefresh = time.Now()
+	r.lastErr = err
+	r.mu.Unlock()
+
+	return err
+}
+
+// Mapper returns the mapper of a time window
+func (r *DependencyRefresher) Mapper(windowHours int) (*DependencyMapper, bool) {
+	mapper, ok := r.mappers[windowHours]
+	return mapper, ok
+}
+
+// Windows returns the configured time windows in hours
+func (r *DependencyRefresher) Windows() []int {
+	return append([]int(nil), r.config.Windows...)
+}
+
+// LastRefresh returns when the graphs were last refreshed and the error of that refresh
+func (r *DependencyRefresher) LastRefresh() (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lastRefresh, r.lastErr
+}
@@ -0,0 +1,286 @@
+package tracing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// edge creates a dependency edge with call and error counts
+func edge(from, to string, calls, errors int64) *DependencyEdge {
+	return &DependencyEdge{FromService: from, ToService: to, CallCount: calls, ErrorCount: errors}
+}
+
+// snapshotOf creates a snapshot whose services are the endpoints of edges plus extra
+func snapshotOf(id int64, edges []*DependencyEdge, extra ...string) *DependencySnapshot {
+	snapshot := &DependencySnapshot{ID: id, WindowHours: 1, CapturedAt: time.Unix(id*3600, 0).UTC(), Edges: edges}
+	seen := map[string]bool{}
+	add := func(serviceID string) {
+		if !seen[serviceID] {
+			seen[serviceID] = true
+			snapshot.Services = append(snapshot.Services, &ServiceNode{ServiceID: serviceID})
+		}
+	}
+	for _, e := range edges {
+		add(e.FromService)
+		add(e.ToService)
+	}
+	for _, serviceID := range extra {
+		add(serviceID)
+	}
+	return snapshot
+}
+
+// edgeNames formats edges as from->to for comparisons
+func edgeNames(edges []*DependencyEdge) []string {
+	names := []string{}
+	for _, e := range edges {
+		names = append(names, e.FromService+"->"+e.ToService)
+	}
+	return names
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	base := snapshotOf(1, []*DependencyEdge{
+		edge("api", "db", 100, 1),
+		edge("api", "cache", 100, 0),
+		edge("worker", "queue", 50, 0),
+	})
+
+	tests := []struct {
+		name            string
+		current         *DependencySnapshot
+		threshold       float64
+		newServices     []string
+		removedServices []string
+		newEdges        []string
+		removedEdges    []string
+		regressions     []string
+	}{
+		{
+			name: "unchanged",
+			current: snapshotOf(2, []*DependencyEdge{
+				edge("api", "db", 200, 2), edge("api", "cache", 100, 0), edge("worker", "queue", 50, 0),
+			}),
+		},
+		{
+			name: "added and removed services and edges",
+			current: snapshotOf(2, []*DependencyEdge{
+				edge("api", "db", 100, 1), edge("api", "search", 10, 0), edge("worker", "queue", 50, 0),
+			}, "scheduler"),
+			newServices:     []string{"scheduler", "search"},
+			removedServices: []string{"cache"},
+			newEdges:        []string{"api->search"},
+			removedEdges:    []string{"api->cache"},
+		},
+		{
+			name: "regressions above the default threshold, largest first",
+			current: snapshotOf(2, []*DependencyEdge{
+				edge("api", "db", 100, 7), edge("api", "cache", 100, 30), edge("worker", "queue", 50, 2),
+			}),
+			regressions: []string{"api->cache", "api->db"},
+		},
+		{
+			name: "custom threshold",
+			current: snapshotOf(2, []*DependencyEdge{
+				edge("api", "db", 100, 7), edge("api", "cache", 100, 30), edge("worker", "queue", 50, 2),
+			}),
+			threshold:   0.2,
+			regressions: []string{"api->cache"},
+		},
+		{
+			name: "edges without calls never regress",
+			current: snapshotOf(2, []*DependencyEdge{
+				edge("api", "db", 0, 0), edge("api", "cache", 0, 0), edge("worker", "queue", 0, 0),
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := CompareSnapshots(base, tt.current, tt.threshold)
+
+			assert.Equal(t, int64(1), diff.BaseID)
+			assert.Equal(t, int64(2), diff.CurrentID)
+			assert.Equal(t, base.CapturedAt, diff.BaseCapturedAt)
+			assert.Equal(t, orEmpty(tt.newServices), diff.NewServices)
+			assert.Equal(t, orEmpty(tt.removedServices), diff.RemovedServices)
+			assert.Equal(t, orEmpty(tt.newEdges), edgeNames(diff.NewEdges))
+			assert.Equal(t, orEmpty(tt.removedEdges), edgeNames(diff.RemovedEdges))
+
+			regressions := []string{}
+			for _, r := range diff.ErrorRegressions {
+				regressions = append(regressions, r.FromService+"->"+r.ToService)
+				assert.Greater(t, r.CurrentErrorRate, r.BaseErrorRate)
+			}
+			assert.Equal(t, orEmpty(tt.regressions), regressions)
+		})
+	}
+}
+
+// orEmpty returns an empty slice for nil so JSON-style empty lists compare equal
+func orEmpty(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func TestCompareSnapshotsRegressionDetails(t *testing.T) {
+	diff := CompareSnapshots(
+		snapshotOf(1, []*DependencyEdge{edge("api", "db", 100, 5)}),
+		snapshotOf(2, []*DependencyEdge{edge("api", "db", 200, 50)}),
+		0,
+	)
+	require.Len(t, diff.ErrorRegressions, 1)
+	assert.Equal(t, ErrorRateRegression{
+		FromService: "api", ToService: "db", BaseErrorRate: 0.05, CurrentErrorRate: 0.25, CallCount: 200,
+	}, diff.ErrorRegressions[0])
+}
+
+// newTestMapper returns a mapper with a small graph and no database
+func newTestMapper() *DependencyMapper {
+	mapper := NewDependencyMapper(nil)
+	mapper.graph.windowHours = 1
+	mapper.addOrUpdateService("web", "SearchAction", "Product", 0, 10)
+	mapper.addOrUpdateService("api", "SearchAction", "Product", 1, 20)
+	mapper.addOrUpdateService("db", "ReadAction", "Row", 0, 5)
+	mapper.addOrUpdateDependency("web", "api", "SearchAction", 0, 20)
+	mapper.addOrUpdateDependency("api", "db", "ReadAction", 1, 5)
+	mapper.addOrUpdateDependency("api", "db", "ReadAction", 0, 15)
+	return mapper
+}
+
+func TestDependencyMapperSnapshot(t *testing.T) {
+	mapper := newTestMapper()
+
+	snapshot := mapper.Snapshot()
+	assert.Equal(t, 1, snapshot.WindowHours)
+	assert.Equal(t, []string{"api", "db", "web"}, []string{
+		snapshot.Services[0].ServiceID, snapshot.Services[1].ServiceID, snapshot.Services[2].ServiceID,
+	})
+	assert.Equal(t, []string{"api->db", "web->api"}, edgeNames(snapshot.Edges))
+	assert.Equal(t, int64(2), snapshot.Edges[0].CallCount)
+	assert.Equal(t, 10.0, snapshot.Edges[0].AvgLatencyMs)
+
+	// The snapshot is a copy
+	snapshot.Edges[0].CallCount = 99
+	snapshot.Services[0].RequestCount = 99
+	again := mapper.Snapshot()
+	assert.Equal(t, int64(2), again.Edges[0].CallCount)
+	assert.Equal(t, int64(1), again.Services[0].RequestCount)
+
+	// Exports of a snapshot match the live graph
+	assert.Equal(t, mapper.ExportToDOT(), again.ExportToDOT())
+	assert.Equal(t, mapper.ExportToMermaid(), again.ExportToMermaid())
+	assert.Contains(t, again.ExportToDOT(), `"api" -> "db" [label="2 calls\n10ms", color=red];`)
+
+	// A JSON round trip (as stored in dependency_graph_snapshots) keeps the graph
+	data, err := json.Marshal(snapshotGraph{Services: again.Services, Edges: again.Edges})
+	require.NoError(t, err)
+	var decoded snapshotGraph
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	restored := &DependencySnapshot{WindowHours: 1, CapturedAt: again.CapturedAt, Services: decoded.Services, Edges: decoded.Edges}
+	assert.Equal(t, again.ExportToMermaid(), restored.ExportToMermaid())
+	assert.Empty(t, CompareSnapshots(again, restored, 0).NewEdges)
+}
+
+// newDependencyServer serves the dependency routes of a refresher whose 1h graph is populated
+func newDependencyServer(t *testing.T) *echo.Echo {
+	t.Helper()
+	refresher := NewDependencyRefresher(nil, DependencyRefresherConfig{Windows: []int{1, 24}})
+	refresher.mappers[1] = newTestMapper()
+
+	e := echo.New()
+	refresher.RegisterRoutes(e.Group("/api"))
+	return e
+}
+
+func TestDependencyHandlersGraph(t *testing.T) {
+	e := newDependencyServer(t)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/dependencies")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var graph struct {
+		WindowHours int               `json:"window_hours"`
+		Services    []*ServiceNode    `json:"services"`
+		Edges       []*DependencyEdge `json:"edges"`
+		Cycles      []interface{}     `json:"cycles"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &graph))
+	assert.Equal(t, 1, graph.WindowHours, "defaults to the first configured window")
+	assert.Len(t, graph.Services, 3)
+	assert.Equal(t, []string{"api->db", "web->api"}, edgeNames(graph.Edges))
+	assert.Empty(t, graph.Cycles)
+
+	rec = get("/api/dependencies?window=24")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"services":[]`)
+
+	rec = get("/api/dependencies?format=dot")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "digraph ServiceDependencies {"))
+	assert.Equal(t, "text/vnd.graphviz; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+
+	rec = get("/api/dependencies?format=mermaid")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "graph LR\n"))
+}
+
+func TestDependencyHandlersValidateParameters(t *testing.T) {
+	e := newDependencyServer(t)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"unknown format", "/api/dependencies?format=png", http.StatusBadRequest},
+		{"unconfigured window", "/api/dependencies?window=6", http.StatusBadRequest},
+		{"invalid window", "/api/dependencies?window=day", http.StatusBadRequest},
+		{"invalid limit", "/api/dependencies/snapshots?limit=many", http.StatusBadRequest},
+		{"invalid snapshot id", "/api/dependencies/snapshots/latest", http.StatusBadRequest},
+		{"invalid threshold", "/api/dependencies/diff?threshold=-0.1", http.StatusBadRequest},
+		{"invalid base", "/api/dependencies/diff?base=first", http.StatusBadRequest},
+		{"invalid current", "/api/dependencies/diff?current=now", http.StatusBadRequest},
+		{"invalid since", "/api/dependencies/diff?since=yesterday", http.StatusBadRequest},
+		{"diff window", "/api/dependencies/diff?window=6", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.want, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"error"`)
+		})
+	}
+}
+
+func TestMermaidIDsAreDistinct(t *testing.T) {
+	ids := map[string]string{}
+	for _, serviceID := range []string{"svc-a", "svc_a", "svc.a", "svc a", "svca", "svc_2da"} {
+		id := mermaidID(serviceID)
+		assert.Regexp(t, `^[A-Za-z0-9_]+$`, id)
+		if other, ok := ids[id]; ok {
+			t.Errorf("%q and %q both map to %q", other, serviceID, id)
+		}
+		ids[id] = serviceID
+	}
+
+	mapper := NewDependencyMapper(nil)
+	mapper.addOrUpdateService("svc-a", "SearchAction", "Product", 0, 10)
+	mapper.addOrUpdateService("svc_a", "SearchAction", "Product", 0, 10)
+	mapper.addOrUpdateDependency("svc-a", "svc_a", "SearchAction", 0, 10)
+	assert.Contains(t, mapper.ExportToMermaid(), mermaidID("svc-a")+` -->|"1 calls, 10ms"| `+mermaidID("svc_a"))
+}
@@ -21,6 +21,7 @@ type InitConfig struct {
 }
 
 // Init initializes tracing from environment variables with sensible defaults
+// and registers the tracer as DefaultTracer
 // Returns nil tracer if tracing is disabled or initialization fails (when DisableIfMissing=true)
 func Init(cfg InitConfig) *Tracer {
 	// Check if tracing is globally disabled
@@ -68,6 +69,9 @@ func Init(cfg InitConfig) *Tracer {
 	// Create tracer from environment
 	tracer := NewFromEnv(cfg.ServiceID, db, s3Client)
 
+	// Outgoing calls through Transport record client spans with this tracer
+	SetDefaultTracer(tracer)
+
 	log.Printf("✓ Tracing initialized for %s (payloads: %v)", cfg.ServiceID, tracer.config.StorePayloads)
 
 	return tracer
@@ -0,0 +1,155 @@
+// Package tracing - Legal holds exempting workflows from deletion and erasure
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLegalHold is returned when an operation would delete traces under a legal hold
+var ErrLegalHold = errors.New("correlation ID is under legal hold")
+
+// ErrNoLegalHold is returned when releasing a correlation ID without an active hold
+var ErrNoLegalHold = errors.New("correlation ID has no active legal hold")
+
+// LegalHold exempts all traces of a correlation ID from retention deletion and
+// GDPR erasure until it is released
+type LegalHold struct {
+	ID            int64      `json:"id"`
+	CorrelationID string     `json:"correlation_id"`
+	Reason        string     `json:"reason"`
+	PlacedBy      string     `json:"placed_by"`
+	PlacedAt      time.Time  `json:"placed_at"`
+	ReleasedBy    string     `json:"released_by,omitempty"`
+	ReleasedAt    *time.Time `json:"released_at,omitempty"`
+}
+
+// PlaceLegalHold puts a correlation ID under legal hold.
+// Placing a hold on an already held correlation ID returns the existing hold.
+func (t *Tracer) PlaceLegalHold(ctx context.Context, correlationID, reason, userID string) (*LegalHold, error) {
+	if correlationID == "" || reason == "" {
+		return nil, fmt.Errorf("correlation_id and reason are required")
+	}
+
+	_, err := t.config.DB.ExecContext(ctx, `
+		INSERT INTO trace_legal_holds (correlation_id, reason, placed_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (correlation_id) WHERE released_at IS NULL DO NOTHING
+	`, correlationID, reason, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place legal hold: %w", err)
+	}
+
+	hold, err := t.GetLegalHold(ctx, correlationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.LogTraceAccess(ctx, userID, "legal_hold", "workflow_trace",
+		correlationID, "", "", 0, reason, nil); err != nil {
+		t.logError("Failed to audit legal hold", err)
+	}
+
+	return hold, nil
+}
+
+// ReleaseLegalHold releases the active hold of a correlation ID
+func (t *Tracer) ReleaseLegalHold(ctx context.Context, correlationID, userID string) error {
+	result, err := t.config.DB.ExecContext(ctx, `
+		UPDATE trace_legal_holds
+		SET released_at = NOW(), released_by = $2
+		WHERE correlation_id = $1 AND released_at IS NULL
+	`, correlationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to release legal hold: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ErrNoLegalHold, correlationID)
+	}
+
+	if err := t.LogTraceAccess(ctx, userID, "legal_hold_release", "workflow_trace",
+		correlationID, "", "", 0, "", nil); err != nil {
+		t.logError("Failed to audit legal hold release", err)
+	}
+
+	return nil
+}
+
+// GetLegalHold returns the active hold of a correlation ID, or ErrNoLegalHold
+func (t *Tracer) GetLegalHold(ctx context.Context, correlationID string) (*LegalHold, error) {
+	row := t.config.DB.QueryRowContext(ctx, `
+		SELECT id, correlation_id, reason, placed_by, placed_at, released_by, released_at
+		FROM trace_legal_holds
+		WHERE correlation_id = $1 AND released_at IS NULL
+	`, correlationID)
+
+	hold, err := scanLegalHold(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrNoLegalHold, correlationID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get legal hold: %w", err)
+	}
+	return hold, nil
+}
+
+// IsOnLegalHold reports whether a correlation ID has an active hold
+func (t *Tracer) IsOnLegalHold(ctx context.Context, correlationID string) (bool, error) {
+	var held bool
+	err := t.config.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM trace_legal_holds
+			WHERE correlation_id = $1 AND released_at IS NULL
+		)
+	`, correlationID).Scan(&held)
+	if err != nil {
+		return false, fmt.Errorf("failed to check legal hold: %w", err)
+	}
+	return held, nil
+}
+
+// ListLegalHolds returns legal holds, newest first. Released holds are included
+// when includeReleased is set.
+func (t *Tracer) ListLegalHolds(ctx context.Context, includeReleased bool) ([]LegalHold, error) {
+	rows, err := t.config.DB.QueryContext(ctx, `
+		SELECT id, correlation_id, reason, placed_by, placed_at, released_by, released_at
+		FROM trace_legal_holds
+		WHERE $1 OR released_at IS NULL
+		ORDER BY placed_at DESC
+	`, includeReleased)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legal holds: %w", err)
+	}
+	defer rows.Close()
+
+	holds := []LegalHold{}
+	for rows.Next() {
+		hold, err := scanLegalHold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan legal hold: %w", err)
+		}
+		holds = append(holds, *hold)
+	}
+	return holds, rows.Err()
+}
+
+// scanLegalHold scans a trace_legal_holds row
+func scanLegalHold(row interface{ Scan(...interface{}) error }) (*LegalHold, error) {
+	var (
+		hold       LegalHold
+		releasedBy sql.NullString
+		releasedAt sql.NullTime
+	)
+	if err := row.Scan(&hold.ID, &hold.CorrelationID, &hold.Reason, &hold.PlacedBy,
+		&hold.PlacedAt, &releasedBy, &releasedAt); err != nil {
+		return nil, err
+	}
+	hold.ReleasedBy = releasedBy.String
+	if releasedAt.Valid {
+		hold.ReleasedAt = &releasedAt.Time
+	}
+	return &hold, nil
+}
@@ -102,6 +102,11 @@ func (l *Logger) WithCtx(ctx context.Context) *Logger {
 	return &Logger{log: log}
 }
 
+// WithHook creates a logger that runs h for every event (e.g. otel.NewZerologHook)
+func (l *Logger) WithHook(h zerolog.Hook) *Logger {
+	return &Logger{log: l.log.Hook(h)}
+}
+
 // WithFields creates a logger with additional fields
 func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
 	log := l.log
@@ -3,11 +3,9 @@
 package tracing
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
@@ -16,12 +14,13 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-// Middleware returns an Echo middleware that captures action execution traces
+// Middleware returns an Echo middleware that captures action execution traces.
+// Only requests matching Config.Routes (default: the semantic action endpoint) are traced.
 func (t *Tracer) Middleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			// Only trace semantic action endpoint
-			if c.Path() != "/v1/api/semantic/action" {
+			route, ok := t.matchRoute(c)
+			if !ok {
 				return next(c)
 			}
 
@@ -46,17 +45,36 @@ func (t *Tracer) Middleware() echo.MiddlewareFunc {
 			c.Set("correlation_id", correlationID)
 			c.Set("operation_id", operationID)
 			c.Set("parent_operation_id", parentOperationID)
+			// Also in the request context so outgoing calls through Transport propagate them
+			c.SetRequest(c.Request().WithContext(ContextWithTraceIDs(c.Request().Context(), correlationID, operationID)))
 
-			// Capture request body
-			reqBody, err := io.ReadAll(c.Request().Body)
+			// Capture request body (bounded, the handler still receives all of it)
+			reqBody, reqTruncated, err := captureRequestBody(c.Request(), t.maxBodySize())
 			if err != nil {
 				return err
 			}
-			c.Request().Body = io.NopCloser(bytes.NewBuffer(reqBody))
+
+			// Multipart requests carry the semantic action in the "action" part
+			if action := multipartAction(c.Request().Header.Get(echo.HeaderContentType), reqBody); action != nil {
+				reqBody = action
+			}
 
 			// Parse action type for metadata extraction
 			actionType, objectType := parseActionTypes(reqBody)
 
+			// Route extractors override the JSON-LD types for other handlers
+			var dataSubjectID string
+			if route.Extract != nil {
+				attrs := route.Extract(c, reqBody)
+				if attrs.ActionType != "" {
+					actionType = attrs.ActionType
+				}
+				if attrs.ObjectType != "" {
+					objectType = attrs.ObjectType
+				}
+				dataSubjectID = attrs.DataSubjectID
+			}
+
 			// Parse action-level tracing metadata
 			metaTrace, metaStorePayload := parseTracingMeta(reqBody)
 
@@ -70,8 +88,8 @@ func (t *Tracer) Middleware() echo.MiddlewareFunc {
 				return next(c)
 			}
 
-			// Store action-level payload preference
-			c.Set("meta_store_payload", metaStorePayload)
+			// Store action-level payload preference (partial payloads are never stored)
+			c.Set("meta_store_payload", metaStorePayload && !reqTruncated)
 
 			// Extract OpenTelemetry trace/span IDs if available
 			var otelTraceID, otelSpanID string
@@ -86,7 +104,7 @@ func (t *Tracer) Middleware() echo.MiddlewareFunc {
 			// Create response recorder
 			rec := &responseRecorder{
 				ResponseWriter: c.Response().Writer,
-				body:           &bytes.Buffer{},
+				body:           &limitedBuffer{limit: t.maxBodySize()},
 			}
 			c.Response().Writer = rec
 
@@ -101,6 +119,10 @@ func (t *Tracer) Middleware() echo.MiddlewareFunc {
 			if statusCode == 0 {
 				statusCode = 200
 			}
+			// Non-JSON-LD handlers report failure through the status code only
+			if (handlerErr != nil || statusCode >= 400) && actionStatus == "CompletedActionStatus" {
+				actionStatus = "FailedActionStatus"
+			}
 
 			// Extract error if present
 			var errorMsg string
@@ -117,6 +139,9 @@ func (t *Tracer) Middleware() echo.MiddlewareFunc {
 					metaStorePayloadFlag = flag
 				}
 			}
+			if rec.body.truncated {
+				metaStorePayloadFlag = false
+			}
 
 			// Get OpenTelemetry IDs
 			otelTraceIDVal, _ := c.Get("otel_trace_id").(string)
@@ -129,6 +154,7 @@ func (t *Tracer) Middleware() echo.MiddlewareFunc {
 				parentOperationID: parentOperationID,
 				actionType:        actionType,
 				objectType:        objectType,
+				dataSubjectID:     dataSubjectID,
 				startTime:         startTime,
 				duration:          duration,
 				actionStatus:      actionStatus,
@@ -146,45 +172,47 @@ func (t *Tracer) Middleware() echo.MiddlewareFunc {
 				otelSpanID:        otelSpanIDVal,
 			}
 
-			// Apply tail-based sampling if enabled
-			shouldExport := true
+			t.exportTrace(trace)
 
-			if t.sampler != nil {
-				decision := t.sampler.ShouldSample(&trace)
-				shouldExport = decision.ShouldSample
+			return handlerErr
+		}
+	}
+}
 
-				// Record sampling metrics
-				if t.metrics != nil {
-					if decision.ShouldSample {
-						t.metrics.SamplingDecisions.WithLabelValues(t.config.ServiceID, "sampled", decision.Reason).Inc()
-					} else {
-						t.metrics.SamplingDecisions.WithLabelValues(t.config.ServiceID, "rejected", decision.Reason).Inc()
-					}
-				}
-			}
+// exportTrace applies tail-based sampling and hands the trace to the exporter
+func (t *Tracer) exportTrace(rec traceRecord) {
+	if t.sampler != nil {
+		decision := t.sampler.ShouldSample(&rec)
 
-			// Export trace only if sampling decision is positive
-			if shouldExport {
-				// Export trace (async if enabled, otherwise sync)
-				if t.asyncExporter != nil {
-					// Async export - non-blocking
-					t.asyncExporter.QueueTrace(trace)
-				} else {
-					// Fallback to sync export in goroutine
-					go t.recordTrace(trace)
-				}
+		// Record sampling metrics
+		if t.metrics != nil {
+			if decision.ShouldSample {
+				t.metrics.SamplingDecisions.WithLabelValues(t.config.ServiceID, "sampled", decision.Reason).Inc()
+			} else {
+				t.metrics.SamplingDecisions.WithLabelValues(t.config.ServiceID, "rejected", decision.Reason).Inc()
 			}
-			// Note: Sampling rejections are tracked in metrics, no need to log
+		}
 
-			return handlerErr
+		// Sampling rejections are tracked in metrics, no need to log
+		if !decision.ShouldSample {
+			return
 		}
 	}
+
+	// Export trace (async if enabled, otherwise sync)
+	if t.asyncExporter != nil {
+		// Async export - non-blocking
+		t.asyncExporter.QueueTrace(rec)
+	} else {
+		// Fallback to sync export in goroutine
+		go t.recordTrace(rec)
+	}
 }
 
-// responseRecorder captures response data
+// responseRecorder captures response data up to the tracer's body size limit
 type responseRecorder struct {
 	http.ResponseWriter
-	body *bytes.Buffer
+	body *limitedBuffer
 }
 
 func (r *responseRecorder) Write(b []byte) (int, error) {
@@ -265,6 +293,7 @@ type traceRecord struct {
 	parentOperationID string
 	actionType        string
 	objectType        string
+	dataSubjectID     string // Set by route extractors, otherwise parsed from the request
 	startTime         time.Time
 	duration          time.Duration
 	actionStatus      string
@@ -278,6 +307,7 @@ type traceRecord struct {
 	clientIP          string
 	userAgent         string
 	metaStorePayload  bool   // Action-level payload storage preference
+	clientSpan        bool   // Recorded by Transport for an outgoing call (no payloads)
 	otelTraceID       string // OpenTelemetry trace ID
 	otelSpanID        string // OpenTelemetry span ID
 }
@@ -288,7 +318,7 @@ func (t *Tracer) recordTrace(rec traceRecord) {
 
 	// Check if payloads should be stored
 	// Priority: 1) Credentials (never), 2) Action meta flag, 3) Config
-	storePayloads := t.shouldStorePayload(rec.actionType, rec.objectType) && rec.metaStorePayload
+	storePayloads := t.shouldStorePayload(rec.actionType, rec.objectType) && rec.metaStorePayload && !rec.clientSpan
 
 	// S3 URLs (only set if payloads are stored)
 	var requestURL, responseURL string
@@ -307,7 +337,7 @@ func (t *Tracer) recordTrace(rec traceRecord) {
 		if err := t.uploadToS3(ctx, rec.correlationID, rec.operationID, "response.json", rec.responseBody); err != nil {
 			t.logError("Failed to upload response to S3", err)
 		}
-	} else {
+	} else if !rec.clientSpan {
 		// For credential-related actions, mark URLs as redacted
 		requestURL = "[REDACTED - Credential payload not stored]"
 		responseURL = "[REDACTED - Credential payload not stored]"
@@ -317,12 +347,15 @@ func (t *Tracer) recordTrace(rec traceRecord) {
 	metadata := t.extractMetadata(rec.actionType, rec.objectType, rec.requestBody, rec.responseBody)
 
 	// Extract data subject ID from metadata or request (if present)
-	dataSubjectID := extractDataSubjectID(rec.requestBody)
+	dataSubjectID := rec.dataSubjectID
+	if dataSubjectID == "" {
+		dataSubjectID = extractDataSubjectID(rec.requestBody)
+	}
 
-	// Calculate retention until based on config
+	// Calculate retention until based on the matching retention policy or config
 	var retentionUntil interface{}
-	if t.config.RetentionDays > 0 {
-		retentionUntil = rec.startTime.AddDate(0, 0, t.config.RetentionDays)
+	if days := t.retentionDays(rec.actionType); days > 0 {
+		retentionUntil = rec.startTime.AddDate(0, 0, days)
 	}
 
 	// Detect PII if enabled
@@ -338,6 +371,7 @@ func (t *Tracer) recordTrace(rec traceRecord) {
 			// Record PII detections asynchronously
 			go func() {
 				for _, detection := range requestPII {
+					detection.Redacted = detection.Redacted && storePayloads && t.redactsPayloads()
 					detection.CorrelationID = rec.correlationID
 					detection.OperationID = rec.operationID
 					detection.Location = "request"
@@ -345,6 +379,7 @@ func (t *Tracer) recordTrace(rec traceRecord) {
 					_ = t.RecordPIIDetection(context.Background(), detection)
 				}
 				for _, detection := range responsePII {
+					detection.Redacted = detection.Redacted && storePayloads && t.redactsPayloads()
 					detection.CorrelationID = rec.correlationID
 					detection.OperationID = rec.operationID
 					detection.Location = "response"
@@ -374,6 +409,12 @@ func (t *Tracer) recordTrace(rec traceRecord) {
 		)
 	`
 
+	// Outgoing calls have no client address, and client_ip (INET) rejects empty strings
+	var clientIP interface{} = rec.clientIP
+	if rec.clientSpan {
+		clientIP = nil
+	}
+
 	_, err := t.config.DB.ExecContext(ctx, query,
 		rec.correlationID,
 		rec.operationID,
@@ -394,7 +435,7 @@ func (t *Tracer) recordTrace(rec traceRecord) {
 		int64(len(rec.requestBody)),
 		int64(len(rec.responseBody)),
 		metadata,
-		rec.clientIP,
+		clientIP,
 		rec.userAgent,
 		nullString(rec.otelTraceID),
 		nullString(rec.otelSpanID),
@@ -446,10 +487,10 @@ func (t *Tracer) recordTrace(rec traceRecord) {
 				responsePII := t.DetectPII(string(rec.responseBody), nil)
 
 				for _, detection := range requestPII {
-					t.metrics.RecordPIIDetection(detection.PIIType, "request", false)
+					t.metrics.RecordPIIDetection(detection.PIIType, "request", detection.Redacted && storePayloads && t.redactsPayloads())
 				}
 				for _, detection := range responsePII {
-					t.metrics.RecordPIIDetection(detection.PIIType, "response", false)
+					t.metrics.RecordPIIDetection(detection.PIIType, "response", detection.Redacted && storePayloads && t.redactsPayloads())
 				}
 			}
 		}
@@ -0,0 +1,26 @@
+// Package tracing - Embedded schema migrations
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+
+	"eve.evalgo.org/db/migrate"
+)
+
+// SchemaComponent identifies the tracing schema in schema_migrations
+const SchemaComponent = "tracing"
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+func init() {
+	migrate.Register(migrate.MustLoad(SchemaComponent, migrationFS, "migrations"))
+}
+
+// Migrate brings the action_executions schema (tables, GDPR and audit functions) up to date.
+// It replaces applying docker/postgres/init.sql by hand on a fresh database.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return migrate.New(db).Up(ctx, SchemaComponent)
+}
@@ -0,0 +1,6 @@
+DROP FUNCTION IF EXISTS query_by_metadata(TEXT, TEXT, TEXT, TEXT);
+DROP FUNCTION IF EXISTS get_slow_actions(BIGINT, INTEGER);
+DROP FUNCTION IF EXISTS get_failed_actions(TEXT, INTEGER);
+DROP FUNCTION IF EXISTS get_workflow_trace(TEXT);
+DROP TABLE IF EXISTS action_metadata_schemas;
+DROP TABLE IF EXISTS action_executions;
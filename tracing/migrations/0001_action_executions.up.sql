@@ -0,0 +1,341 @@
+-- Action execution tracing: metadata in PostgreSQL, payloads in object storage.
+-- TimescaleDB is used when available (hypertable + 90 day retention) but not required.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS action_executions (
+    id UUID DEFAULT uuid_generate_v4(),
+
+    -- Correlation tracking (links related actions in a workflow)
+    correlation_id TEXT NOT NULL,
+    operation_id TEXT NOT NULL,
+    parent_operation_id TEXT,  -- For nested/chained actions
+
+    -- Action identity (from JSON-LD @type fields)
+    action_type TEXT NOT NULL,         -- @type: "CreateAction", "TransferAction", etc.
+    object_type TEXT,                  -- object.@type: "SoftwareApplication", "Database", etc.
+    target_type TEXT,                  -- target.@type (if present)
+    instrument_type TEXT,              -- instrument.@type (if present)
+    action_context TEXT DEFAULT 'https://schema.org',  -- @context
+
+    -- Service information
+    service_id TEXT NOT NULL,
+    endpoint TEXT,
+    http_method TEXT,
+
+    -- Timing
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+
+    -- Status
+    action_status TEXT,  -- "CompletedActionStatus", "FailedActionStatus", "ActiveActionStatus"
+    error_message TEXT,
+    error_type TEXT,
+
+    -- S3 Storage References (URLs, NOT the actual data)
+    request_url TEXT,      -- s3://eve-traces/{correlation_id}/{operation_id}/request.json
+    response_url TEXT,     -- s3://eve-traces/{correlation_id}/{operation_id}/response.json
+    logs_url TEXT,         -- s3://eve-traces/{correlation_id}/{operation_id}/logs.txt
+    artifacts_url TEXT,    -- s3://eve-traces/{correlation_id}/{operation_id}/artifacts/
+
+    -- Size tracking (for monitoring)
+    request_size_bytes BIGINT DEFAULT 0,
+    response_size_bytes BIGINT DEFAULT 0,
+    logs_size_bytes BIGINT DEFAULT 0,
+
+    -- Queryable metadata (small, extracted fields for filtering)
+    -- This is action-type specific and varies based on action+object combination
+    metadata JSONB DEFAULT '{}'::jsonb,
+
+    -- Request context
+    client_ip INET,
+    user_agent TEXT,
+
+    -- Tags for custom categorization
+    tags TEXT[] DEFAULT '{}',
+
+    -- OpenTelemetry integration (links to technical traces)
+    otel_trace_id TEXT,  -- OpenTelemetry trace ID (32-char hex)
+    otel_span_id TEXT,   -- OpenTelemetry span ID (16-char hex)
+
+    PRIMARY KEY (started_at, correlation_id, operation_id)
+);
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'timescaledb')
+       AND current_setting('shared_preload_libraries', true) LIKE '%timescaledb%' THEN
+        CREATE EXTENSION IF NOT EXISTS timescaledb;
+        PERFORM create_hypertable('action_executions', 'started_at',
+            if_not_exists => TRUE, chunk_time_interval => INTERVAL '1 day');
+        PERFORM add_retention_policy('action_executions', INTERVAL '90 days', if_not_exists => TRUE);
+    END IF;
+END $$;
+
+-- Query by correlation (get all actions in a workflow)
+CREATE INDEX IF NOT EXISTS idx_action_exec_correlation
+    ON action_executions (correlation_id, started_at DESC);
+
+-- Query by operation ID (get specific action)
+CREATE INDEX IF NOT EXISTS idx_action_exec_operation
+    ON action_executions (operation_id);
+
+-- Query by service (get all actions for a service)
+CREATE INDEX IF NOT EXISTS idx_action_exec_service
+    ON action_executions (service_id, started_at DESC);
+
+-- Query by action type (get all CreateActions, etc.)
+CREATE INDEX IF NOT EXISTS idx_action_exec_action_type
+    ON action_executions (action_type, started_at DESC);
+
+-- Query by action + object type combination
+CREATE INDEX IF NOT EXISTS idx_action_exec_type_combo
+    ON action_executions (action_type, object_type, started_at DESC);
+
+-- Query by status (get failed actions)
+CREATE INDEX IF NOT EXISTS idx_action_exec_status
+    ON action_executions (action_status, started_at DESC);
+
+-- Query by parent (get child actions)
+CREATE INDEX IF NOT EXISTS idx_action_exec_parent
+    ON action_executions (parent_operation_id, started_at DESC)
+    WHERE parent_operation_id IS NOT NULL;
+
+-- GIN index for metadata JSONB queries
+CREATE INDEX IF NOT EXISTS idx_action_exec_metadata
+    ON action_executions USING GIN (metadata);
+
+-- GIN index for tags array
+CREATE INDEX IF NOT EXISTS idx_action_exec_tags
+    ON action_executions USING GIN (tags);
+
+-- Index for OpenTelemetry trace ID (link semantic → OTel)
+CREATE INDEX IF NOT EXISTS idx_action_exec_otel_trace
+    ON action_executions (otel_trace_id)
+    WHERE otel_trace_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS action_metadata_schemas (
+    id SERIAL PRIMARY KEY,
+    action_type TEXT NOT NULL,
+    object_type TEXT NOT NULL,
+    schema_definition JSONB NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE(action_type, object_type)
+);
+
+-- Insert common action metadata schemas
+INSERT INTO action_metadata_schemas (action_type, object_type, schema_definition, description) VALUES
+
+-- Container creation
+('CreateAction', 'SoftwareApplication',
+'{
+    "fields": {
+        "container_id": {"type": "string", "description": "Docker/Podman container ID"},
+        "image": {"type": "string", "description": "Container image name"},
+        "started": {"type": "boolean", "description": "Whether container was started"},
+        "ports": {"type": "array", "description": "Port mappings"},
+        "health_status": {"type": "string", "description": "Container health status"}
+    },
+    "required": ["container_id", "image"]
+}'::jsonb,
+'Container creation metadata'),
+
+-- Database migration
+('TransferAction', 'Database',
+'{
+    "fields": {
+        "source_database": {"type": "string", "description": "Source database name"},
+        "target_database": {"type": "string", "description": "Target database name"},
+        "migration_type": {"type": "string", "description": "full, incremental, schema_only"},
+        "total_tables": {"type": "number", "description": "Total tables to migrate"},
+        "completed_tables": {"type": "number", "description": "Tables migrated so far"},
+        "total_rows": {"type": "number", "description": "Total rows to migrate"},
+        "transferred_rows": {"type": "number", "description": "Rows migrated so far"},
+        "progress_percent": {"type": "number", "description": "Migration progress 0-100"},
+        "current_table": {"type": "string", "description": "Currently migrating table"},
+        "verification_pending": {"type": "boolean", "description": "Whether verification is pending"}
+    },
+    "required": ["source_database", "target_database", "progress_percent"]
+}'::jsonb,
+'Database migration metadata'),
+
+-- Backup operations
+('UploadAction', 'Dataset',
+'{
+    "fields": {
+        "backup_type": {"type": "string", "description": "full, incremental, differential"},
+        "source_database": {"type": "string", "description": "Database being backed up"},
+        "backup_size_bytes": {"type": "number", "description": "Backup size in bytes"},
+        "compression_type": {"type": "string", "description": "Compression algorithm used"},
+        "checksum": {"type": "string", "description": "Backup file checksum"},
+        "storage_location": {"type": "string", "description": "S3 path or file location"},
+        "retention_days": {"type": "number", "description": "How long to keep backup"},
+        "expires_at": {"type": "datetime", "description": "When backup expires"}
+    },
+    "required": ["backup_type", "storage_location", "checksum"]
+}'::jsonb,
+'Backup operation metadata'),
+
+-- ETL transformations
+('ReplaceAction', 'DataFeed',
+'{
+    "fields": {
+        "source_tables": {"type": "array", "description": "Source tables"},
+        "destination_table": {"type": "string", "description": "Destination table"},
+        "input_rows": {"type": "number", "description": "Input row count"},
+        "output_rows": {"type": "number", "description": "Output row count"},
+        "filtered_rows": {"type": "number", "description": "Rows filtered out"},
+        "rows_per_second": {"type": "number", "description": "Processing throughput"},
+        "data_quality_passed": {"type": "boolean", "description": "Whether quality checks passed"}
+    },
+    "required": ["input_rows", "output_rows"]
+}'::jsonb,
+'ETL transformation metadata'),
+
+-- CI/CD builds
+('ExecuteAction', 'SoftwareSourceCode',
+'{
+    "fields": {
+        "repository": {"type": "string", "description": "Git repository"},
+        "branch": {"type": "string", "description": "Git branch"},
+        "commit_sha": {"type": "string", "description": "Git commit SHA"},
+        "build_number": {"type": "number", "description": "Build number"},
+        "pipeline_name": {"type": "string", "description": "Pipeline name"},
+        "tests_passed": {"type": "number", "description": "Number of tests passed"},
+        "tests_failed": {"type": "number", "description": "Number of tests failed"},
+        "artifacts_count": {"type": "number", "description": "Number of artifacts generated"}
+    },
+    "required": ["repository", "commit_sha"]
+}'::jsonb,
+'CI/CD build metadata')
+
+ON CONFLICT (action_type, object_type) DO NOTHING;
+
+-- Get full workflow trace (all actions for a correlation ID)
+CREATE OR REPLACE FUNCTION get_workflow_trace(p_correlation_id TEXT)
+RETURNS TABLE (
+    operation_id TEXT,
+    parent_operation_id TEXT,
+    action_type TEXT,
+    object_type TEXT,
+    service_id TEXT,
+    started_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+    action_status TEXT,
+    request_url TEXT,
+    response_url TEXT,
+    metadata JSONB
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        ae.operation_id,
+        ae.parent_operation_id,
+        ae.action_type,
+        ae.object_type,
+        ae.service_id,
+        ae.started_at,
+        ae.duration_ms,
+        ae.action_status,
+        ae.request_url,
+        ae.response_url,
+        ae.metadata
+    FROM action_executions ae
+    WHERE ae.correlation_id = p_correlation_id
+    ORDER BY ae.started_at ASC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get failed actions for a service
+CREATE OR REPLACE FUNCTION get_failed_actions(
+    p_service_id TEXT,
+    p_hours INTEGER DEFAULT 24
+)
+RETURNS TABLE (
+    operation_id TEXT,
+    correlation_id TEXT,
+    action_type TEXT,
+    started_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+    error_message TEXT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        ae.operation_id,
+        ae.correlation_id,
+        ae.action_type,
+        ae.started_at,
+        ae.duration_ms,
+        ae.error_message
+    FROM action_executions ae
+    WHERE ae.service_id = p_service_id
+      AND ae.action_status = 'FailedActionStatus'
+      AND ae.started_at > NOW() - (p_hours || ' hours')::INTERVAL
+    ORDER BY ae.started_at DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get slow actions (duration > threshold)
+CREATE OR REPLACE FUNCTION get_slow_actions(
+    p_threshold_ms BIGINT DEFAULT 5000,
+    p_hours INTEGER DEFAULT 24
+)
+RETURNS TABLE (
+    operation_id TEXT,
+    action_type TEXT,
+    object_type TEXT,
+    service_id TEXT,
+    duration_ms BIGINT,
+    started_at TIMESTAMPTZ
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        ae.operation_id,
+        ae.action_type,
+        ae.object_type,
+        ae.service_id,
+        ae.duration_ms,
+        ae.started_at
+    FROM action_executions ae
+    WHERE ae.duration_ms > p_threshold_ms
+      AND ae.started_at > NOW() - (p_hours || ' hours')::INTERVAL
+    ORDER BY ae.duration_ms DESC
+    LIMIT 50;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Query by metadata field (e.g., find all migrations for a specific database)
+CREATE OR REPLACE FUNCTION query_by_metadata(
+    p_action_type TEXT,
+    p_object_type TEXT,
+    p_metadata_key TEXT,
+    p_metadata_value TEXT
+)
+RETURNS TABLE (
+    operation_id TEXT,
+    correlation_id TEXT,
+    started_at TIMESTAMPTZ,
+    action_status TEXT,
+    metadata JSONB
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        ae.operation_id,
+        ae.correlation_id,
+        ae.started_at,
+        ae.action_status,
+        ae.metadata
+    FROM action_executions ae
+    WHERE ae.action_type = p_action_type
+      AND ae.object_type = p_object_type
+      AND ae.metadata->>p_metadata_key = p_metadata_value
+    ORDER BY ae.started_at DESC;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,21 @@
+DROP FUNCTION IF EXISTS delete_expired_traces();
+DROP FUNCTION IF EXISTS get_audit_trail(TEXT, TEXT, INTEGER);
+DROP FUNCTION IF EXISTS log_trace_access(TEXT, TEXT, TEXT, TEXT, TEXT, TEXT, INTEGER, TEXT, JSONB);
+DROP FUNCTION IF EXISTS gdpr_export_data(TEXT);
+DROP FUNCTION IF EXISTS gdpr_pseudonymize_traces(TEXT, TEXT);
+DROP FUNCTION IF EXISTS gdpr_erase_traces(TEXT, TEXT, TEXT, TEXT);
+DROP TABLE IF EXISTS pii_detections;
+DROP TABLE IF EXISTS trace_access_audit;
+
+DROP INDEX IF EXISTS idx_action_exec_region;
+DROP INDEX IF EXISTS idx_action_exec_retention;
+DROP INDEX IF EXISTS idx_action_exec_data_subject;
+
+ALTER TABLE action_executions
+    DROP COLUMN IF EXISTS pii_redacted,
+    DROP COLUMN IF EXISTS contains_pii,
+    DROP COLUMN IF EXISTS retention_until,
+    DROP COLUMN IF EXISTS data_region,
+    DROP COLUMN IF EXISTS consent_id,
+    DROP COLUMN IF EXISTS legal_basis,
+    DROP COLUMN IF EXISTS data_subject_id;
@@ -0,0 +1,350 @@
+-- GDPR and compliance extensions: data subject tracking, access audit,
+-- PII detections, erasure/pseudonymization/export and retention functions.
+
+ALTER TABLE action_executions
+    ADD COLUMN IF NOT EXISTS data_subject_id TEXT,
+    ADD COLUMN IF NOT EXISTS legal_basis TEXT,
+    ADD COLUMN IF NOT EXISTS consent_id TEXT,
+    ADD COLUMN IF NOT EXISTS data_region TEXT DEFAULT 'us',
+    ADD COLUMN IF NOT EXISTS retention_until TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS contains_pii BOOLEAN DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS pii_redacted BOOLEAN DEFAULT FALSE;
+
+-- Index for data subject queries
+CREATE INDEX IF NOT EXISTS idx_action_exec_data_subject
+    ON action_executions (data_subject_id, started_at DESC)
+    WHERE data_subject_id IS NOT NULL;
+
+-- Index for retention management
+CREATE INDEX IF NOT EXISTS idx_action_exec_retention
+    ON action_executions (retention_until)
+    WHERE retention_until IS NOT NULL;
+
+-- Index for regional queries
+CREATE INDEX IF NOT EXISTS idx_action_exec_region
+    ON action_executions (data_region, started_at DESC);
+
+-- ============================================================================
+-- Audit Logging Table
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS trace_access_audit (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    accessed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Who accessed
+    user_id TEXT NOT NULL,
+    user_email TEXT,
+    user_ip INET,
+
+    -- What was accessed
+    access_type TEXT NOT NULL,  -- 'query', 'view', 'export', 'delete'
+    resource_type TEXT NOT NULL,  -- 'workflow_trace', 'action_detail', 'metadata'
+    correlation_id TEXT,
+    operation_id TEXT,
+    data_subject_id TEXT,
+
+    -- How it was accessed
+    query_parameters JSONB,
+    results_count INTEGER,
+
+    -- Why it was accessed (justification)
+    purpose TEXT,
+    legal_basis TEXT,
+
+    -- Audit trail
+    session_id TEXT,
+    request_id TEXT
+);
+
+-- Index for audit queries
+CREATE INDEX IF NOT EXISTS idx_audit_accessed_at
+    ON trace_access_audit (accessed_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_audit_user
+    ON trace_access_audit (user_id, accessed_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_audit_correlation
+    ON trace_access_audit (correlation_id)
+    WHERE correlation_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_data_subject
+    ON trace_access_audit (data_subject_id)
+    WHERE data_subject_id IS NOT NULL;
+
+-- ============================================================================
+-- PII Detection Table
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS pii_detections (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Where PII was found
+    correlation_id TEXT NOT NULL,
+    operation_id TEXT NOT NULL,
+    location TEXT NOT NULL,  -- 'request', 'response', 'metadata'
+    field_path TEXT,  -- JSON path like '$.object.identifier'
+
+    -- What type of PII
+    pii_type TEXT NOT NULL,  -- 'email', 'phone', 'ssn', 'credit_card', 'ip_address'
+    pattern_matched TEXT,
+    confidence FLOAT,  -- 0.0-1.0
+
+    -- Was it redacted/tokenized?
+    redacted BOOLEAN DEFAULT FALSE,
+    token TEXT,  -- If tokenized
+
+    -- Data subject tracking
+    data_subject_id TEXT
+);
+
+-- Index for PII queries
+CREATE INDEX IF NOT EXISTS idx_pii_correlation
+    ON pii_detections (correlation_id, operation_id);
+
+CREATE INDEX IF NOT EXISTS idx_pii_type
+    ON pii_detections (pii_type, detected_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_pii_data_subject
+    ON pii_detections (data_subject_id)
+    WHERE data_subject_id IS NOT NULL;
+
+-- ============================================================================
+-- GDPR Erasure Functions
+-- ============================================================================
+
+-- Full erasure: Delete all traces for a data subject or correlation ID
+CREATE OR REPLACE FUNCTION gdpr_erase_traces(
+    p_data_subject_id TEXT DEFAULT NULL,
+    p_correlation_id TEXT DEFAULT NULL,
+    p_user_id TEXT DEFAULT 'system',
+    p_purpose TEXT DEFAULT 'GDPR Right to Erasure'
+)
+RETURNS TABLE (
+    deleted_actions INTEGER,
+    deleted_pii INTEGER,
+    s3_urls_to_delete TEXT[]
+) AS $$
+DECLARE
+    v_deleted_actions INTEGER;
+    v_deleted_pii INTEGER;
+    v_s3_urls TEXT[];
+BEGIN
+    -- Validate input
+    IF p_data_subject_id IS NULL AND p_correlation_id IS NULL THEN
+        RAISE EXCEPTION 'Must provide either data_subject_id or correlation_id';
+    END IF;
+
+    -- Collect S3 URLs that need to be deleted
+    SELECT ARRAY_AGG(DISTINCT url)
+    INTO v_s3_urls
+    FROM (
+        SELECT request_url AS url FROM action_executions
+        WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+          AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id)
+          AND request_url IS NOT NULL
+          AND request_url NOT LIKE '[REDACTED%'
+        UNION
+        SELECT response_url AS url FROM action_executions
+        WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+          AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id)
+          AND response_url IS NOT NULL
+          AND response_url NOT LIKE '[REDACTED%'
+        UNION
+        SELECT logs_url AS url FROM action_executions
+        WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+          AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id)
+          AND logs_url IS NOT NULL
+    ) urls;
+
+    -- Log the erasure action in audit table
+    INSERT INTO trace_access_audit (
+        user_id, access_type, resource_type,
+        correlation_id, data_subject_id,
+        purpose, legal_basis,
+        query_parameters
+    ) VALUES (
+        p_user_id, 'delete', 'gdpr_erasure',
+        p_correlation_id, p_data_subject_id,
+        p_purpose, 'GDPR Article 17',
+        jsonb_build_object(
+            'data_subject_id', p_data_subject_id,
+            'correlation_id', p_correlation_id
+        )
+    );
+
+    -- Delete PII detections
+    DELETE FROM pii_detections
+    WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+      AND (p_correlation_id IS NULL OR correlation_id IN (
+          SELECT correlation_id FROM action_executions
+          WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+            AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id)
+      ));
+
+    GET DIAGNOSTICS v_deleted_pii = ROW_COUNT;
+
+    -- Delete action executions
+    DELETE FROM action_executions
+    WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+      AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id);
+
+    GET DIAGNOSTICS v_deleted_actions = ROW_COUNT;
+
+    -- Return results
+    RETURN QUERY SELECT v_deleted_actions, v_deleted_pii, v_s3_urls;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Pseudonymization: Replace identifiable data with hashes
+CREATE OR REPLACE FUNCTION gdpr_pseudonymize_traces(
+    p_data_subject_id TEXT,
+    p_user_id TEXT DEFAULT 'system'
+)
+RETURNS INTEGER AS $$
+DECLARE
+    v_updated INTEGER;
+BEGIN
+    -- Log the pseudonymization
+    INSERT INTO trace_access_audit (
+        user_id, access_type, resource_type,
+        data_subject_id, purpose, legal_basis
+    ) VALUES (
+        p_user_id, 'pseudonymize', 'gdpr_pseudonymization',
+        p_data_subject_id, 'GDPR Article 17 - Pseudonymization', 'GDPR Article 17'
+    );
+
+    -- Replace data_subject_id with hash
+    UPDATE action_executions
+    SET data_subject_id = 'PSEUDONYMIZED-' || MD5(data_subject_id),
+        client_ip = NULL,
+        user_agent = '[REDACTED]',
+        metadata = metadata - 'email' - 'phone' - 'name' - 'address'
+    WHERE data_subject_id = p_data_subject_id;
+
+    GET DIAGNOSTICS v_updated = ROW_COUNT;
+
+    RETURN v_updated;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Export data for GDPR data portability (Article 20)
+CREATE OR REPLACE FUNCTION gdpr_export_data(
+    p_data_subject_id TEXT
+)
+RETURNS TABLE (
+    correlation_id TEXT,
+    operation_id TEXT,
+    action_type TEXT,
+    started_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+    action_status TEXT,
+    service_id TEXT,
+    metadata JSONB,
+    request_url TEXT,
+    response_url TEXT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        ae.correlation_id,
+        ae.operation_id,
+        ae.action_type,
+        ae.started_at,
+        ae.duration_ms,
+        ae.action_status,
+        ae.service_id,
+        ae.metadata,
+        ae.request_url,
+        ae.response_url
+    FROM action_executions ae
+    WHERE ae.data_subject_id = p_data_subject_id
+    ORDER BY ae.started_at DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- Audit Helper Functions
+-- ============================================================================
+
+-- Log trace access
+CREATE OR REPLACE FUNCTION log_trace_access(
+    p_user_id TEXT,
+    p_access_type TEXT,
+    p_resource_type TEXT,
+    p_correlation_id TEXT DEFAULT NULL,
+    p_operation_id TEXT DEFAULT NULL,
+    p_data_subject_id TEXT DEFAULT NULL,
+    p_results_count INTEGER DEFAULT NULL,
+    p_purpose TEXT DEFAULT NULL,
+    p_query_parameters JSONB DEFAULT NULL
+)
+RETURNS UUID AS $$
+DECLARE
+    v_audit_id UUID;
+BEGIN
+    INSERT INTO trace_access_audit (
+        user_id, access_type, resource_type,
+        correlation_id, operation_id, data_subject_id,
+        results_count, purpose, query_parameters
+    ) VALUES (
+        p_user_id, p_access_type, p_resource_type,
+        p_correlation_id, p_operation_id, p_data_subject_id,
+        p_results_count, p_purpose, p_query_parameters
+    ) RETURNING id INTO v_audit_id;
+
+    RETURN v_audit_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Get audit trail for a data subject
+CREATE OR REPLACE FUNCTION get_audit_trail(
+    p_data_subject_id TEXT DEFAULT NULL,
+    p_correlation_id TEXT DEFAULT NULL,
+    p_hours INTEGER DEFAULT 168  -- Default 7 days
+)
+RETURNS TABLE (
+    accessed_at TIMESTAMPTZ,
+    user_id TEXT,
+    access_type TEXT,
+    resource_type TEXT,
+    purpose TEXT,
+    results_count INTEGER
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        aa.accessed_at,
+        aa.user_id,
+        aa.access_type,
+        aa.resource_type,
+        aa.purpose,
+        aa.results_count
+    FROM trace_access_audit aa
+    WHERE (p_data_subject_id IS NULL OR aa.data_subject_id = p_data_subject_id)
+      AND (p_correlation_id IS NULL OR aa.correlation_id = p_correlation_id)
+      AND aa.accessed_at > NOW() - (p_hours || ' hours')::INTERVAL
+    ORDER BY aa.accessed_at DESC;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- Retention Policy Enforcement
+-- ============================================================================
+
+-- Auto-delete expired traces
+CREATE OR REPLACE FUNCTION delete_expired_traces()
+RETURNS INTEGER AS $$
+DECLARE
+    v_deleted INTEGER;
+BEGIN
+    DELETE FROM action_executions
+    WHERE retention_until IS NOT NULL
+      AND retention_until < NOW();
+
+    GET DIAGNOSTICS v_deleted = ROW_COUNT;
+
+    RETURN v_deleted;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,14 @@
+-- Service dependency graph snapshots, one row per refresh and time window,
+-- used to compare the call graph between deployments.
+
+CREATE TABLE IF NOT EXISTS dependency_graph_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    window_hours INTEGER NOT NULL,
+    captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    service_count INTEGER NOT NULL,
+    edge_count INTEGER NOT NULL,
+    graph JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_dependency_snapshots_window
+    ON dependency_graph_snapshots (window_hours, captured_at DESC);
@@ -0,0 +1,109 @@
+-- Restore the erasure and retention functions without legal hold checks
+DROP FUNCTION IF EXISTS gdpr_erase_traces(TEXT, TEXT, TEXT, TEXT);
+
+-- Full erasure: Delete all traces for a data subject or correlation ID
+CREATE OR REPLACE FUNCTION gdpr_erase_traces(
+    p_data_subject_id TEXT DEFAULT NULL,
+    p_correlation_id TEXT DEFAULT NULL,
+    p_user_id TEXT DEFAULT 'system',
+    p_purpose TEXT DEFAULT 'GDPR Right to Erasure'
+)
+RETURNS TABLE (
+    deleted_actions INTEGER,
+    deleted_pii INTEGER,
+    s3_urls_to_delete TEXT[]
+) AS $$
+DECLARE
+    v_deleted_actions INTEGER;
+    v_deleted_pii INTEGER;
+    v_s3_urls TEXT[];
+BEGIN
+    -- Validate input
+    IF p_data_subject_id IS NULL AND p_correlation_id IS NULL THEN
+        RAISE EXCEPTION 'Must provide either data_subject_id or correlation_id';
+    END IF;
+
+    -- Collect S3 URLs that need to be deleted
+    SELECT ARRAY_AGG(DISTINCT url)
+    INTO v_s3_urls
+    FROM (
+        SELECT request_url AS url FROM action_executions
+        WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+          AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id)
+          AND request_url IS NOT NULL
+          AND request_url NOT LIKE '[REDACTED%'
+        UNION
+        SELECT response_url AS url FROM action_executions
+        WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+          AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id)
+          AND response_url IS NOT NULL
+          AND response_url NOT LIKE '[REDACTED%'
+        UNION
+        SELECT logs_url AS url FROM action_executions
+        WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+          AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id)
+          AND logs_url IS NOT NULL
+    ) urls;
+
+    -- Log the erasure action in audit table
+    INSERT INTO trace_access_audit (
+        user_id, access_type, resource_type,
+        correlation_id, data_subject_id,
+        purpose, legal_basis,
+        query_parameters
+    ) VALUES (
+        p_user_id, 'delete', 'gdpr_erasure',
+        p_correlation_id, p_data_subject_id,
+        p_purpose, 'GDPR Article 17',
+        jsonb_build_object(
+            'data_subject_id', p_data_subject_id,
+            'correlation_id', p_correlation_id
+        )
+    );
+
+    -- Delete PII detections
+    DELETE FROM pii_detections
+    WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+      AND (p_correlation_id IS NULL OR correlation_id IN (
+          SELECT correlation_id FROM action_executions
+          WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+            AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id)
+      ));
+
+    GET DIAGNOSTICS v_deleted_pii = ROW_COUNT;
+
+    -- Delete action executions
+    DELETE FROM action_executions
+    WHERE (p_data_subject_id IS NULL OR data_subject_id = p_data_subject_id)
+      AND (p_correlation_id IS NULL OR correlation_id = p_correlation_id);
+
+    GET DIAGNOSTICS v_deleted_actions = ROW_COUNT;
+
+    -- Return results
+    RETURN QUERY SELECT v_deleted_actions, v_deleted_pii, v_s3_urls;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Auto-delete expired traces
+CREATE OR REPLACE FUNCTION delete_expired_traces()
+RETURNS INTEGER AS $$
+DECLARE
+    v_deleted INTEGER;
+BEGIN
+    DELETE FROM action_executions
+    WHERE retention_until IS NOT NULL
+      AND retention_until < NOW();
+
+    GET DIAGNOSTICS v_deleted = ROW_COUNT;
+
+    RETURN v_deleted;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS trace_legal_holds;
+
+DROP INDEX IF EXISTS idx_action_exec_archived;
+
+ALTER TABLE action_executions
+    DROP COLUMN IF EXISTS archived_s3_key,
+    DROP COLUMN IF EXISTS archived_at;
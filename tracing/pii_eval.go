@@ -0,0 +1,157 @@
+// Package tracing - Evaluation of PII rules against labelled sample payloads
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// PIISample is a labelled payload for evaluating PII rules
+type PIISample struct {
+	Name     string           `json:"name"`
+	Payload  json.RawMessage  `json:"payload,omitempty"` // JSON payload
+	Text     string           `json:"text,omitempty"`    // Plain text payload (logs)
+	Expected []PIIExpectation `json:"expected"`          // PII the rules should find
+}
+
+// PIIExpectation is a PII value a sample contains
+type PIIExpectation struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// PIITypeScore holds detection counts and scores for one PII type (or all types)
+type PIITypeScore struct {
+	Type           string  `json:"type"`
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+}
+
+// PIIEvaluationError is a detection that did not match the labels
+type PIIEvaluationError struct {
+	Sample string `json:"sample"`
+	Kind   string `json:"kind"` // false_positive, false_negative
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+}
+
+// PIIEvaluation reports how well a rule set detects PII in labelled samples
+type PIIEvaluation struct {
+	Overall PIITypeScore         `json:"overall"`
+	Types   []PIITypeScore       `json:"types"`
+	Errors  []PIIEvaluationError `json:"errors"`
+}
+
+// LoadPIISamples reads labelled samples from a JSON file
+func LoadPIISamples(path string) ([]PIISample, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read PII samples: %w", err)
+	}
+	var samples []PIISample
+	if err := json.Unmarshal(data, &samples); err != nil {
+		return nil, fmt.Errorf("parse PII samples: %w", err)
+	}
+	return samples, nil
+}
+
+// EvaluatePIIRules runs the redactor over the samples and scores its detections.
+// A detection is a true positive if the sample expects its type and value.
+func EvaluatePIIRules(redactor *PIIRedactor, samples []PIISample) *PIIEvaluation {
+	scores := make(map[string]*PIITypeScore)
+	score := func(piiType string) *PIITypeScore {
+		if scores[piiType] == nil {
+			scores[piiType] = &PIITypeScore{Type: piiType}
+		}
+		return scores[piiType]
+	}
+
+	eval := &PIIEvaluation{Errors: []PIIEvaluationError{}}
+	for _, sample := range samples {
+		payload := []byte(sample.Text)
+		if len(sample.Payload) > 0 {
+			payload = sample.Payload
+		}
+
+		expected := make(map[PIIExpectation]bool, len(sample.Expected))
+		for _, e := range sample.Expected {
+			expected[e] = true
+		}
+		found := make(map[PIIExpectation]bool)
+		for _, detection := range redactor.Detect(payload) {
+			found[PIIExpectation{Type: detection.PIIType, Value: detection.PatternMatched}] = true
+		}
+
+		for f := range found {
+			if expected[f] {
+				score(f.Type).TruePositives++
+			} else {
+				score(f.Type).FalsePositives++
+				eval.Errors = append(eval.Errors, PIIEvaluationError{Sample: sample.Name, Kind: "false_positive", Type: f.Type, Value: f.Value})
+			}
+		}
+		for e := range expected {
+			if !found[e] {
+				score(e.Type).FalseNegatives++
+				eval.Errors = append(eval.Errors, PIIEvaluationError{Sample: sample.Name, Kind: "false_negative", Type: e.Type, Value: e.Value})
+			}
+		}
+	}
+
+	eval.Overall.Type = "all"
+	for _, piiType := range sortedKeys(scores) {
+		s := scores[piiType]
+		s.Precision, s.Recall = precisionRecall(s.TruePositives, s.FalsePositives, s.FalseNegatives)
+		eval.Types = append(eval.Types, *s)
+
+		eval.Overall.TruePositives += s.TruePositives
+		eval.Overall.FalsePositives += s.FalsePositives
+		eval.Overall.FalseNegatives += s.FalseNegatives
+	}
+	eval.Overall.Precision, eval.Overall.Recall = precisionRecall(
+		eval.Overall.TruePositives, eval.Overall.FalsePositives, eval.Overall.FalseNegatives)
+
+	sort.Slice(eval.Errors, func(i, j int) bool {
+		a, b := eval.Errors[i], eval.Errors[j]
+		if a.Sample != b.Sample {
+			return a.Sample < b.Sample
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Type+a.Value < b.Type+b.Value
+	})
+	return eval
+}
+
+// Report formats the evaluation as a table followed by the mismatches
+func (e *PIIEvaluation) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-14s %5s %5s %5s %9s %7s\n", "type", "tp", "fp", "fn", "precision", "recall")
+	for _, s := range append(e.Types, e.Overall) {
+		fmt.Fprintf(&b, "%-14s %5d %5d %5d %9.3f %7.3f\n",
+			s.Type, s.TruePositives, s.FalsePositives, s.FalseNegatives, s.Precision, s.Recall)
+	}
+	for _, err := range e.Errors {
+		fmt.Fprintf(&b, "%s: %s %s %q\n", err.Sample, err.Kind, err.Type, err.Value)
+	}
+	return b.String()
+}
+
+// precisionRecall computes precision and recall; both are 1 when there is nothing to find
+func precisionRecall(tp, fp, fn int) (precision, recall float64) {
+	precision, recall = 1, 1
+	if tp+fp > 0 {
+		precision = float64(tp) / float64(tp+fp)
+	}
+	if tp+fn > 0 {
+		recall = float64(tp) / float64(tp+fn)
+	}
+	return precision, recall
+}
@@ -0,0 +1,637 @@
+// Package tracing - Configurable PII detection and redaction rules
+package tracing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PIIAction is what happens to a value matched by a PII rule
+type PIIAction string
+
+const (
+	// PIIActionDetect records the detection and leaves the value unchanged
+	PIIActionDetect PIIAction = "detect"
+	// PIIActionMask replaces the value with [REDACTED_<TYPE>]
+	PIIActionMask PIIAction = "mask"
+	// PIIActionHash replaces the value with a salted hash, keeping values joinable
+	PIIActionHash PIIAction = "hash"
+	// PIIActionDrop removes the field (JSON) or the matched text (plain text)
+	PIIActionDrop PIIAction = "drop"
+)
+
+// PII validators reduce false positives of pattern rules
+const (
+	PIIValidatorLuhn = "luhn" // Card numbers (Luhn checksum)
+	PIIValidatorIBAN = "iban" // IBANs (ISO 13616 mod 97 checksum)
+)
+
+// PIIRule detects one kind of PII.
+//
+// A rule with a Pattern scans string values; with Paths it applies to the values of
+// matching JSON fields only. A rule with Paths and no Pattern treats the whole field
+// value as PII. Paths are dotted keys ("customer.email", "$.customer.email"), where
+// "*" matches one key and "**" any number of keys; arrays are traversed transparently
+// and keys are matched case-insensitively.
+type PIIRule struct {
+	Name       string    `json:"name" yaml:"name"`
+	Type       string    `json:"type" yaml:"type"` // Recorded as pii_type (email, iban, ...)
+	Pattern    string    `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Paths      []string  `json:"paths,omitempty" yaml:"paths,omitempty"`
+	Validator  string    `json:"validator,omitempty" yaml:"validator,omitempty"` // luhn, iban
+	Action     PIIAction `json:"action,omitempty" yaml:"action,omitempty"`       // Default: mask
+	Confidence float64   `json:"confidence,omitempty" yaml:"confidence,omitempty"`
+	Allowlist  []string  `json:"allowlist,omitempty" yaml:"allowlist,omitempty"` // Values never reported by this rule
+}
+
+// PIIRuleSet is a set of PII rules, typically loaded from a YAML or JSON file
+type PIIRuleSet struct {
+	// IncludeDefaults prepends DefaultPIIRuleSet's rules
+	IncludeDefaults bool `json:"include_defaults,omitempty" yaml:"include_defaults,omitempty"`
+
+	Rules []PIIRule `json:"rules" yaml:"rules"`
+
+	// Allowlist lists values never reported by any rule (e.g. test card numbers, support emails)
+	Allowlist []string `json:"allowlist,omitempty" yaml:"allowlist,omitempty"`
+
+	// AllowPatterns lists regexes of values never reported (e.g. "@example\\.com$")
+	AllowPatterns []string `json:"allow_patterns,omitempty" yaml:"allow_patterns,omitempty"`
+
+	// IgnorePaths lists JSON paths that are never scanned (e.g. "@context", "meta.requestId")
+	IgnorePaths []string `json:"ignore_paths,omitempty" yaml:"ignore_paths,omitempty"`
+
+	// HashSalt keys the hash action (HMAC-SHA256); keep it secret and stable.
+	// Required when any rule uses the hash action
+	HashSalt string `json:"hash_salt,omitempty" yaml:"hash_salt,omitempty"`
+}
+
+// DefaultPIIRuleSet converts DefaultPIIPatterns into rules. Card numbers and IBANs are
+// checksum-validated and masked; other patterns with a confidence below 0.85 only
+// detect, matching RedactPII.
+func DefaultPIIRuleSet() *PIIRuleSet {
+	rules := make([]PIIRule, 0, len(DefaultPIIPatterns))
+	for _, pattern := range DefaultPIIPatterns {
+		rule := PIIRule{
+			Name:       pattern.Type,
+			Type:       pattern.Type,
+			Pattern:    pattern.Pattern,
+			Action:     PIIActionMask,
+			Confidence: pattern.Confidence,
+		}
+		switch pattern.Type {
+		case "credit_card":
+			rule.Validator = PIIValidatorLuhn
+		case "iban":
+			rule.Validator = PIIValidatorIBAN
+		}
+		// A passed checksum leaves few false positives
+		if rule.Validator != "" && rule.Confidence < 0.95 {
+			rule.Confidence = 0.95
+		}
+		if pattern.Confidence < 0.85 {
+			rule.Action = PIIActionDetect
+		}
+		rules = append(rules, rule)
+	}
+	return &PIIRuleSet{Rules: rules}
+}
+
+// LoadPIIRuleSet reads a rule set from a YAML or JSON file
+func LoadPIIRuleSet(path string) (*PIIRuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read PII rules: %w", err)
+	}
+	return ParsePIIRuleSet(data)
+}
+
+// ParsePIIRuleSet parses a YAML or JSON rule set
+func ParsePIIRuleSet(data []byte) (*PIIRuleSet, error) {
+	var rules PIIRuleSet
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse PII rules: %w", err)
+	}
+	return &rules, nil
+}
+
+// PIIRedactor applies a compiled rule set to payloads
+type PIIRedactor struct {
+	rules         []compiledPIIRule
+	allow         map[string]bool
+	allowPatterns []*regexp.Regexp
+	ignorePaths   [][]string
+	salt          []byte
+}
+
+// compiledPIIRule is a validated PIIRule
+type compiledPIIRule struct {
+	PIIRule
+	re    *regexp.Regexp
+	paths [][]string
+	allow map[string]bool
+}
+
+// Compile validates the rule set and prepares it for use
+func (rs *PIIRuleSet) Compile() (*PIIRedactor, error) {
+	rules := rs.Rules
+	if rs.IncludeDefaults {
+		rules = append(DefaultPIIRuleSet().Rules, rules...)
+	}
+
+	r := &PIIRedactor{
+		allow: stringSet(rs.Allowlist),
+		salt:  []byte(rs.HashSalt),
+	}
+	for _, pattern := range rs.AllowPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("allow pattern %q: %w", pattern, err)
+		}
+		r.allowPatterns = append(r.allowPatterns, re)
+	}
+	for _, path := range rs.IgnorePaths {
+		r.ignorePaths = append(r.ignorePaths, splitPIIPath(path))
+	}
+
+	for i, rule := range rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i)
+		}
+		if rule.Type == "" {
+			return nil, fmt.Errorf("PII %s: type is required", name)
+		}
+		if rule.Pattern == "" && len(rule.Paths) == 0 {
+			return nil, fmt.Errorf("PII %s: pattern or paths is required", name)
+		}
+
+		compiled := compiledPIIRule{PIIRule: rule, allow: stringSet(rule.Allowlist)}
+		switch compiled.Action {
+		case "":
+			compiled.Action = PIIActionMask
+		case PIIActionHash:
+			// An unsalted hash of a low-entropy value is reversible by brute force
+			if rs.HashSalt == "" {
+				return nil, fmt.Errorf("PII %s: action hash requires hash_salt", name)
+			}
+		case PIIActionDetect, PIIActionMask, PIIActionDrop:
+		default:
+			return nil, fmt.Errorf("PII %s: unknown action %q", name, rule.Action)
+		}
+		switch compiled.Validator {
+		case "", PIIValidatorLuhn, PIIValidatorIBAN:
+		default:
+			return nil, fmt.Errorf("PII %s: unknown validator %q", name, rule.Validator)
+		}
+		if compiled.Confidence <= 0 {
+			compiled.Confidence = 0.9
+			if compiled.Pattern == "" {
+				compiled.Confidence = 1
+			}
+		}
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("PII %s: %w", name, err)
+			}
+			compiled.re = re
+		}
+		for _, path := range rule.Paths {
+			compiled.paths = append(compiled.paths, splitPIIPath(path))
+		}
+		r.rules = append(r.rules, compiled)
+	}
+
+	// Field rules are applied in order of confidence
+	sort.SliceStable(r.rules, func(i, j int) bool { return r.rules[i].Confidence > r.rules[j].Confidence })
+	return r, nil
+}
+
+// Detect reports PII in a JSON or plain text payload without modifying it
+func (r *PIIRedactor) Detect(data []byte) []PIIDetection {
+	scan := &piiScan{redactor: r}
+	scan.run(data)
+	return scan.detections
+}
+
+// Redact applies the rule actions to a JSON or plain text payload. JSON payloads are
+// rewritten (keys sorted, numbers preserved); payloads without applied actions are
+// returned unchanged.
+func (r *PIIRedactor) Redact(data []byte) ([]byte, []PIIDetection) {
+	scan := &piiScan{redactor: r, apply: true}
+	out := scan.run(data)
+	return out, scan.detections
+}
+
+// piiScan holds the state of one Detect or Redact call
+type piiScan struct {
+	redactor   *PIIRedactor
+	apply      bool
+	changed    bool
+	detections []PIIDetection
+}
+
+// run scans data as JSON if it parses, otherwise as plain text
+func (s *piiScan) run(data []byte) []byte {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err == nil && !decoder.More() {
+			result, _ := s.walk(value, nil, "")
+			if !s.changed {
+				return data
+			}
+			out, err := json.Marshal(result)
+			if err != nil {
+				return data
+			}
+			return out
+		}
+	}
+
+	text, _ := s.scanString(string(data), nil, "", false)
+	if !s.changed {
+		return data
+	}
+	return []byte(text)
+}
+
+// walk scans a JSON value; drop reports that the value must be removed from its parent
+func (s *piiScan) walk(value interface{}, keys []string, path string) (result interface{}, drop bool) {
+	if s.redactor.ignored(keys) {
+		return value, false
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			childKeys := append(keys[:len(keys):len(keys)], strings.ToLower(key))
+			childPath := joinPIIPath(path, key)
+
+			replacement, handled, dropField := s.fieldRules(v[key], childKeys, childPath)
+			if !handled {
+				replacement, dropField = s.walk(v[key], childKeys, childPath)
+			}
+			if dropField {
+				delete(v, key)
+			} else {
+				v[key] = replacement
+			}
+		}
+		return v, false
+
+	case []interface{}:
+		kept := v[:0]
+		for i, item := range v {
+			replacement, dropItem := s.walk(item, keys, fmt.Sprintf("%s[%d]", path, i))
+			if !dropItem {
+				kept = append(kept, replacement)
+			}
+		}
+		return kept, false
+
+	case string:
+		return s.scanString(v, keys, path, true)
+
+	case json.Number:
+		text, dropField := s.scanString(v.String(), keys, path, true)
+		if text == v.String() {
+			return v, dropField
+		}
+		return text, dropField
+
+	default:
+		return value, false
+	}
+}
+
+// fieldRules applies whole-value rules (paths without pattern) to a field
+func (s *piiScan) fieldRules(value interface{}, keys []string, path string) (result interface{}, handled, drop bool) {
+	for _, rule := range s.redactor.rules {
+		if rule.re != nil || !rule.matchesPath(keys) {
+			continue
+		}
+
+		var text string
+		if isScalar(value) {
+			if text = scalarString(value); text == "" || !rule.valid(text) || s.redactor.allowed(rule, text) {
+				continue
+			}
+		} else {
+			encoded, _ := json.Marshal(value)
+			text = string(encoded)
+		}
+
+		detection := s.record(rule, text, path)
+		if !s.apply || rule.Action == PIIActionDetect {
+			continue
+		}
+		s.changed = true
+		switch rule.Action {
+		case PIIActionDrop:
+			return nil, true, true
+		case PIIActionHash:
+			return detection.Token, true, false
+		default:
+			return maskPII(rule.Type), true, false
+		}
+	}
+	return value, false, false
+}
+
+// piiMatch is a pattern match within a string
+type piiMatch struct {
+	start, end int
+	rule       *compiledPIIRule
+}
+
+// scanString applies pattern rules to a string. In JSON (field true) a drop action
+// removes the whole field; in plain text it removes the matched text.
+func (s *piiScan) scanString(text string, keys []string, path string, field bool) (string, bool) {
+	var matches []piiMatch
+	for i := range s.redactor.rules {
+		rule := &s.redactor.rules[i]
+		if rule.re == nil || (len(rule.paths) > 0 && !rule.matchesPath(keys)) {
+			continue
+		}
+		for _, loc := range rule.re.FindAllStringIndex(text, -1) {
+			value := text[loc[0]:loc[1]]
+			if !rule.valid(value) || s.redactor.allowed(*rule, value) {
+				continue
+			}
+			matches = append(matches, piiMatch{start: loc[0], end: loc[1], rule: rule})
+		}
+	}
+	if len(matches) == 0 {
+		return text, false
+	}
+
+	// Keep the most confident of overlapping matches
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].rule.Confidence != matches[j].rule.Confidence {
+			return matches[i].rule.Confidence > matches[j].rule.Confidence
+		}
+		return matches[i].start < matches[j].start
+	})
+	var selected []piiMatch
+	for _, m := range matches {
+		overlaps := false
+		for _, other := range selected {
+			if m.start < other.end && other.start < m.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			selected = append(selected, m)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].start < selected[j].start })
+
+	var (
+		out  strings.Builder
+		last int
+		drop bool
+	)
+	for _, m := range selected {
+		value := text[m.start:m.end]
+		detection := s.record(*m.rule, value, path)
+
+		out.WriteString(text[last:m.start])
+		last = m.end
+		if !s.apply || m.rule.Action == PIIActionDetect {
+			out.WriteString(value)
+			continue
+		}
+		s.changed = true
+		switch m.rule.Action {
+		case PIIActionDrop:
+			drop = drop || field
+		case PIIActionHash:
+			out.WriteString(detection.Token)
+		default:
+			out.WriteString(maskPII(m.rule.Type))
+		}
+	}
+	out.WriteString(text[last:])
+	return out.String(), drop
+}
+
+// record adds a detection for a matched value
+func (s *piiScan) record(rule compiledPIIRule, value, path string) PIIDetection {
+	detection := PIIDetection{
+		FieldPath:      path,
+		PIIType:        rule.Type,
+		PatternMatched: value,
+		Confidence:     rule.Confidence,
+		Redacted:       rule.Action != PIIActionDetect,
+	}
+	if rule.Action == PIIActionHash {
+		detection.Token = s.redactor.hash(value)
+	}
+	s.detections = append(s.detections, detection)
+	return detection
+}
+
+// hash returns the pseudonymization token of a value
+func (r *PIIRedactor) hash(value string) string {
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(value))
+	return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// allowed reports whether a value is allowlisted for a rule
+func (r *PIIRedactor) allowed(rule compiledPIIRule, value string) bool {
+	if r.allow[value] || rule.allow[value] {
+		return true
+	}
+	for _, re := range r.allowPatterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored reports whether a JSON path is excluded from scanning
+func (r *PIIRedactor) ignored(keys []string) bool {
+	for _, pattern := range r.ignorePaths {
+		if matchPIIPath(pattern, keys) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPath reports whether a rule applies at a JSON path
+func (rule compiledPIIRule) matchesPath(keys []string) bool {
+	for _, pattern := range rule.paths {
+		if matchPIIPath(pattern, keys) {
+			return true
+		}
+	}
+	return false
+}
+
+// valid applies the rule's checksum validator
+func (rule compiledPIIRule) valid(value string) bool {
+	switch rule.Validator {
+	case PIIValidatorLuhn:
+		return validLuhn(value)
+	case PIIValidatorIBAN:
+		return validIBAN(value)
+	default:
+		return true
+	}
+}
+
+// validLuhn checks a 12-19 digit number (spaces and dashes ignored) with the Luhn algorithm
+func validLuhn(value string) bool {
+	var digits []int
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, int(r-'0'))
+		case r == ' ' || r == '-':
+		default:
+			return false
+		}
+	}
+	if len(digits) < 12 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if (len(digits)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// validIBAN checks an IBAN (spaces ignored) with the ISO 13616 mod 97 checksum
+func validIBAN(value string) bool {
+	iban := strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	for i, r := range iban {
+		switch {
+		case i < 2 && (r < 'A' || r > 'Z'):
+			return false
+		case i >= 2 && i < 4 && (r < '0' || r > '9'):
+			return false
+		case (r < '0' || r > '9') && (r < 'A' || r > 'Z'):
+			return false
+		}
+	}
+
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for _, r := range rearranged {
+		var n int
+		if r >= 'A' {
+			n = int(r-'A') + 10
+			remainder = (remainder*100 + n) % 97
+		} else {
+			remainder = (remainder*10 + int(r-'0')) % 97
+		}
+	}
+	return remainder == 1
+}
+
+// splitPIIPath splits a dotted path into lower-case keys
+func splitPIIPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	var keys []string
+	for _, key := range strings.Split(path, ".") {
+		if key = strings.TrimSuffix(key, "[*]"); key != "" {
+			keys = append(keys, strings.ToLower(key))
+		}
+	}
+	return keys
+}
+
+// matchPIIPath matches lower-case keys against a path pattern with * and ** wildcards
+func matchPIIPath(pattern, keys []string) bool {
+	if len(pattern) == 0 {
+		return len(keys) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(keys); i++ {
+			if matchPIIPath(pattern[1:], keys[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(keys) == 0 || (pattern[0] != "*" && pattern[0] != keys[0]) {
+		return false
+	}
+	return matchPIIPath(pattern[1:], keys[1:])
+}
+
+// joinPIIPath appends a key to a JSON path
+func joinPIIPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// maskPII returns the mask marker of a PII type, as used by RedactPII
+func maskPII(piiType string) string {
+	return fmt.Sprintf("[REDACTED_%s]", strings.ToUpper(piiType))
+}
+
+// scalarString formats a JSON scalar; objects and arrays yield ""
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// isScalar reports whether value is not a JSON object or array
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	default:
+		return true
+	}
+}
+
+// stringSet builds a lookup set
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
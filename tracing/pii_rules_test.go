@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidLuhn(t *testing.T) {
+	assert.True(t, validLuhn("4111 1111 1111 1111"))
+	assert.True(t, validLuhn("5500-0000-0000-0004"))
+	assert.False(t, validLuhn("4111 1111 1111 1112"))
+	assert.False(t, validLuhn("1234"))
+	assert.False(t, validLuhn("4111x1111x1111x1111"))
+}
+
+func TestValidIBAN(t *testing.T) {
+	assert.True(t, validIBAN("DE89370400440532013000"))
+	assert.True(t, validIBAN("GB82 WEST 1234 5698 7654 32"))
+	assert.False(t, validIBAN("DE89370400440532013001"))
+	assert.False(t, validIBAN("DE89"))
+	assert.False(t, validIBAN("1289370400440532013000"))
+}
+
+func TestMatchPIIPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		keys    []string
+		want    bool
+	}{
+		{"customer.email", []string{"customer", "email"}, true},
+		{"$.customer.email", []string{"customer", "email"}, true},
+		{"customer.Email", []string{"customer", "email"}, true},
+		{"customer.email", []string{"agent", "email"}, false},
+		{"*.email", []string{"agent", "email"}, true},
+		{"*.email", []string{"object", "agent", "email"}, false},
+		{"**.email", []string{"object", "agent", "email"}, true},
+		{"**.email", []string{"email"}, true},
+		{"items[*].iban", []string{"items", "iban"}, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchPIIPath(splitPIIPath(tt.pattern), tt.keys), "%s %v", tt.pattern, tt.keys)
+	}
+}
+
+func TestPIIRedactorActions(t *testing.T) {
+	rules, err := ParsePIIRuleSet([]byte(`
+rules:
+  - name: customer-email
+    type: email
+    paths: ["**.email"]
+    action: hash
+  - name: password
+    type: credential
+    paths: ["**.password"]
+    action: drop
+  - name: card
+    type: credit_card
+    pattern: '\b\d{4}[ -]?\d{4}[ -]?\d{4}[ -]?\d{4}\b'
+    validator: luhn
+    action: mask
+allowlist: ["4242 4242 4242 4242"]
+ignore_paths: ["meta"]
+hash_salt: test-salt
+`))
+	require.NoError(t, err)
+	redactor, err := rules.Compile()
+	require.NoError(t, err)
+
+	payload := []byte(`{
+		"agent": {"email": "jane@example.org", "password": "s3cret"},
+		"items": [{"note": "card 4111 1111 1111 1111 on file"}, {"note": "test card 4242 4242 4242 4242"}],
+		"meta": {"email": "kept@example.org"},
+		"amount": 12.50
+	}`)
+	out, detections := redactor.Redact(payload)
+
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(out, &result))
+
+	agent := result["agent"].(map[string]interface{})
+	assert.Equal(t, redactor.hash("jane@example.org"), agent["email"])
+	assert.NotContains(t, agent, "password")
+
+	items := result["items"].([]interface{})
+	assert.Equal(t, "card [REDACTED_CREDIT_CARD] on file", items[0].(map[string]interface{})["note"])
+	assert.Equal(t, "test card 4242 4242 4242 4242", items[1].(map[string]interface{})["note"])
+
+	assert.Equal(t, "kept@example.org", result["meta"].(map[string]interface{})["email"])
+	assert.Contains(t, string(out), `"amount":12.50`)
+
+	paths := map[string]string{}
+	for _, d := range detections {
+		paths[d.FieldPath] = d.PIIType
+	}
+	assert.Equal(t, map[string]string{
+		"agent.email":    "email",
+		"agent.password": "credential",
+		"items[0].note":  "credit_card",
+	}, paths)
+}
+
+func TestPIIRedactorPlainText(t *testing.T) {
+	redactor, err := DefaultPIIRuleSet().Compile()
+	require.NoError(t, err)
+
+	out, detections := redactor.Redact([]byte("login by jane@example.org from 10.0.0.7"))
+	assert.Equal(t, "login by [REDACTED_EMAIL] from 10.0.0.7", string(out))
+	assert.Len(t, detections, 2)
+
+	unchanged := []byte(`{"name": "eve"}`)
+	out, detections = redactor.Redact(unchanged)
+	assert.Equal(t, unchanged, out)
+	assert.Empty(t, detections)
+}
+
+func TestPIIRuleSetCompileErrors(t *testing.T) {
+	for _, rules := range []PIIRuleSet{
+		{Rules: []PIIRule{{Pattern: "x"}}},
+		{Rules: []PIIRule{{Type: "email"}}},
+		{Rules: []PIIRule{{Type: "email", Pattern: "("}}},
+		{Rules: []PIIRule{{Type: "email", Pattern: "x", Action: "shred"}}},
+		{Rules: []PIIRule{{Type: "email", Pattern: "x", Validator: "crc"}}},
+		{Rules: []PIIRule{{Type: "email", Pattern: "x", Action: PIIActionHash}}},
+		{AllowPatterns: []string{"("}},
+	} {
+		_, err := rules.Compile()
+		assert.Error(t, err)
+	}
+}
+
+func TestDefaultPIIRulesPrecision(t *testing.T) {
+	samples, err := LoadPIISamples("testdata/pii_samples.json")
+	require.NoError(t, err)
+	redactor, err := DefaultPIIRuleSet().Compile()
+	require.NoError(t, err)
+
+	eval := EvaluatePIIRules(redactor, samples)
+	t.Log("\n" + eval.Report())
+
+	assert.GreaterOrEqual(t, eval.Overall.Precision, 0.9)
+	assert.GreaterOrEqual(t, eval.Overall.Recall, 0.9)
+}
@@ -0,0 +1,519 @@
+// Package tracing - Query API over action_executions
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"eve.evalgo.org/storage"
+)
+
+// ActionExecution is one traced action as stored in action_executions
+type ActionExecution struct {
+	CorrelationID     string          `json:"correlation_id"`
+	OperationID       string          `json:"operation_id"`
+	ParentOperationID string          `json:"parent_operation_id,omitempty"`
+	ActionType        string          `json:"action_type"`
+	ObjectType        string          `json:"object_type,omitempty"`
+	ServiceID         string          `json:"service_id"`
+	Endpoint          string          `json:"endpoint,omitempty"`
+	HTTPMethod        string          `json:"http_method,omitempty"`
+	StartedAt         time.Time       `json:"started_at"`
+	CompletedAt       *time.Time      `json:"completed_at,omitempty"`
+	DurationMS        int64           `json:"duration_ms"`
+	ActionStatus      string          `json:"action_status,omitempty"`
+	ErrorMessage      string          `json:"error_message,omitempty"`
+	ErrorType         string          `json:"error_type,omitempty"`
+	RequestURL        string          `json:"request_url,omitempty"`
+	ResponseURL       string          `json:"response_url,omitempty"`
+	LogsURL           string          `json:"logs_url,omitempty"`
+	RequestSizeBytes  int64           `json:"request_size_bytes"`
+	ResponseSizeBytes int64           `json:"response_size_bytes"`
+	Metadata          json.RawMessage `json:"metadata,omitempty"`
+	OTelTraceID       string          `json:"otel_trace_id,omitempty"`
+	OTelSpanID        string          `json:"otel_span_id,omitempty"`
+}
+
+// OperationNode is an action with its child actions (linked by parent_operation_id)
+type OperationNode struct {
+	*ActionExecution
+	Children []*OperationNode `json:"children,omitempty"`
+}
+
+// WorkflowSummary aggregates the actions sharing a correlation ID
+type WorkflowSummary struct {
+	CorrelationID string    `json:"correlation_id"`
+	StartedAt     time.Time `json:"started_at"`
+	CompletedAt   time.Time `json:"completed_at"`
+	ActionCount   int       `json:"action_count"`
+	FailedCount   int       `json:"failed_count"`
+	Services      []string  `json:"services"`
+}
+
+// ActionQuery filters action executions. Zero values are ignored.
+//
+// Metadata maps a JSON path into the metadata column to the expected text value.
+// Paths use dots for nesting and may start with "$." ("source_database",
+// "$.progress.current_table").
+type ActionQuery struct {
+	CorrelationID string
+	ServiceID     string
+	ActionType    string
+	ObjectType    string
+	Status        string
+	Since         time.Time
+	Until         time.Time
+	MinDurationMS int64
+	Metadata      map[string]string
+	Limit         int
+	Offset        int
+}
+
+// DefaultQueryLimit applies when ActionQuery.Limit is not set
+const DefaultQueryLimit = 100
+
+// actionExecutionColumns is the select list matching scanActionExecution
+const actionExecutionColumns = `
+	correlation_id, operation_id, parent_operation_id,
+	action_type, object_type, service_id, endpoint, http_method,
+	started_at, completed_at, duration_ms,
+	action_status, error_message, error_type,
+	request_url, response_url, logs_url,
+	request_size_bytes, response_size_bytes,
+	metadata, otel_trace_id, otel_span_id`
+
+// QueryActions returns action executions matching the query, newest first
+func (t *Tracer) QueryActions(ctx context.Context, q ActionQuery) ([]*ActionExecution, error) {
+	where, args, err := q.whereClause()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	args = append(args, limit, q.Offset)
+
+	query := fmt.Sprintf(`SELECT %s FROM action_executions%s ORDER BY started_at DESC LIMIT $%d OFFSET $%d`,
+		actionExecutionColumns, where, len(args)-1, len(args))
+
+	rows, err := t.config.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query actions: %w", err)
+	}
+	defer rows.Close()
+
+	return collectActionExecutions(rows)
+}
+
+// ListWorkflows returns one summary per correlation ID among the matching actions, newest first
+func (t *Tracer) ListWorkflows(ctx context.Context, q ActionQuery) ([]WorkflowSummary, error) {
+	where, args, err := q.whereClause()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	args = append(args, limit, q.Offset)
+
+	query := fmt.Sprintf(`
+		SELECT correlation_id,
+			MIN(started_at),
+			MAX(COALESCE(completed_at, started_at)),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE action_status = 'FailedActionStatus'),
+			ARRAY_AGG(DISTINCT service_id)
+		FROM action_executions%s
+		GROUP BY correlation_id
+		ORDER BY MIN(started_at) DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
+
+	rows, err := t.config.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+	defer rows.Close()
+
+	var results []WorkflowSummary
+	for rows.Next() {
+		var summary WorkflowSummary
+		var services pq.StringArray
+		if err := rows.Scan(&summary.CorrelationID, &summary.StartedAt, &summary.CompletedAt,
+			&summary.ActionCount, &summary.FailedCount, &services); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		summary.Services = services
+		results = append(results, summary)
+	}
+	return results, rows.Err()
+}
+
+// GetOperationTree returns all actions of a workflow nested by parent_operation_id.
+// Actions whose parent is not part of the workflow become roots; siblings are ordered by start time.
+func (t *Tracer) GetOperationTree(ctx context.Context, correlationID string) ([]*OperationNode, error) {
+	if correlationID == "" {
+		return nil, fmt.Errorf("correlation_id is required")
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM action_executions WHERE correlation_id = $1 ORDER BY started_at ASC`,
+		actionExecutionColumns)
+
+	rows, err := t.config.DB.QueryContext(ctx, query, correlationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow trace: %w", err)
+	}
+	defer rows.Close()
+
+	actions, err := collectActionExecutions(rows)
+	if err != nil {
+		return nil, err
+	}
+	return BuildOperationTree(actions), nil
+}
+
+// BuildOperationTree nests actions by parent_operation_id, keeping their order
+func BuildOperationTree(actions []*ActionExecution) []*OperationNode {
+	nodes := make(map[string]*OperationNode, len(actions))
+	for _, action := range actions {
+		nodes[action.OperationID] = &OperationNode{ActionExecution: action}
+	}
+
+	var roots []*OperationNode
+	for _, action := range actions {
+		node := nodes[action.OperationID]
+		parent, ok := nodes[action.ParentOperationID]
+		if !ok || parent == node {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return roots
+}
+
+// GetFailedActions returns failed actions of a service (get_failed_actions)
+func (t *Tracer) GetFailedActions(ctx context.Context, serviceID string, hours int) ([]*ActionExecution, error) {
+	rows, err := t.config.DB.QueryContext(ctx, `SELECT * FROM get_failed_actions($1, $2)`, serviceID, hours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed actions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*ActionExecution
+	for rows.Next() {
+		var (
+			action       ActionExecution
+			errorMessage sql.NullString
+			durationMS   sql.NullInt64
+		)
+		if err := rows.Scan(&action.OperationID, &action.CorrelationID, &action.ActionType,
+			&action.StartedAt, &durationMS, &errorMessage); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		action.ServiceID = serviceID
+		action.ActionStatus = "FailedActionStatus"
+		action.DurationMS = durationMS.Int64
+		action.ErrorMessage = errorMessage.String
+		results = append(results, &action)
+	}
+	return results, rows.Err()
+}
+
+// GetSlowActions returns the slowest actions above thresholdMS (get_slow_actions)
+func (t *Tracer) GetSlowActions(ctx context.Context, thresholdMS int64, hours int) ([]*ActionExecution, error) {
+	rows, err := t.config.DB.QueryContext(ctx, `SELECT * FROM get_slow_actions($1, $2)`, thresholdMS, hours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get slow actions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*ActionExecution
+	for rows.Next() {
+		var (
+			action     ActionExecution
+			objectType sql.NullString
+			durationMS sql.NullInt64
+		)
+		if err := rows.Scan(&action.OperationID, &action.ActionType, &objectType,
+			&action.ServiceID, &durationMS, &action.StartedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		action.ObjectType = objectType.String
+		action.DurationMS = durationMS.Int64
+		results = append(results, &action)
+	}
+	return results, rows.Err()
+}
+
+// QueryByMetadata returns actions whose top-level metadata key equals value (query_by_metadata)
+func (t *Tracer) QueryByMetadata(ctx context.Context, actionType, objectType, key, value string) ([]*ActionExecution, error) {
+	rows, err := t.config.DB.QueryContext(ctx, `SELECT * FROM query_by_metadata($1, $2, $3, $4)`,
+		actionType, objectType, key, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query by metadata: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*ActionExecution
+	for rows.Next() {
+		var (
+			action       ActionExecution
+			actionStatus sql.NullString
+			metadata     []byte
+		)
+		if err := rows.Scan(&action.OperationID, &action.CorrelationID, &action.StartedAt,
+			&actionStatus, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		action.ActionType = actionType
+		action.ObjectType = objectType
+		action.ActionStatus = actionStatus.String
+		if len(metadata) > 0 {
+			action.Metadata = metadata
+		}
+		results = append(results, &action)
+	}
+	return results, rows.Err()
+}
+
+// GetAction returns a single action by correlation and operation ID
+func (t *Tracer) GetAction(ctx context.Context, correlationID, operationID string) (*ActionExecution, error) {
+	query := fmt.Sprintf(`SELECT %s FROM action_executions WHERE correlation_id = $1 AND operation_id = $2 LIMIT 1`,
+		actionExecutionColumns)
+
+	rows, err := t.config.DB.QueryContext(ctx, query, correlationID, operationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get action: %w", err)
+	}
+	defer rows.Close()
+
+	actions, err := collectActionExecutions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return actions[0], nil
+}
+
+// PayloadLinks holds temporary download URLs for the stored payloads of an action.
+// Links are empty when the payload was not stored (e.g. redacted credentials).
+type PayloadLinks struct {
+	Request   string    `json:"request,omitempty"`
+	Response  string    `json:"response,omitempty"`
+	Logs      string    `json:"logs,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PresignPayloads returns presigned links to an action's stored payloads.
+// Returns storage.ErrPresignUnsupported when the payload store cannot sign URLs;
+// use GetRequest/GetResponse/GetLogs to stream the payloads instead.
+func (t *Tracer) PresignPayloads(ctx context.Context, action *ActionExecution, expires time.Duration) (*PayloadLinks, error) {
+	store, err := t.payloadStore()
+	if err != nil {
+		return nil, err
+	}
+	presigner, ok := store.(storage.Presigner)
+	if !ok {
+		return nil, storage.ErrPresignUnsupported
+	}
+
+	links := &PayloadLinks{ExpiresAt: time.Now().Add(expires)}
+	for _, payload := range []struct {
+		url  string
+		link *string
+	}{
+		{action.RequestURL, &links.Request},
+		{action.ResponseURL, &links.Response},
+		{action.LogsURL, &links.Logs},
+	} {
+		key, ok := payloadKey(payload.url)
+		if !ok {
+			continue
+		}
+		signed, err := presigner.PresignGet(ctx, key, expires)
+		if err != nil {
+			return nil, err
+		}
+		*payload.link = signed
+	}
+	return links, nil
+}
+
+// payloadKey extracts the object key from an s3://bucket/key payload URL
+func payloadKey(s3URL string) (string, bool) {
+	if !strings.HasPrefix(s3URL, "s3://") {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(s3URL, "s3://"), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// whereClause builds the SQL filter and positional arguments for the query
+func (q ActionQuery) whereClause() (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	add := func(condition string, values ...interface{}) {
+		args = append(args, values...)
+		placeholders := make([]interface{}, len(values))
+		for i := range values {
+			placeholders[i] = len(args) - len(values) + i + 1
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+
+	if q.CorrelationID != "" {
+		add("correlation_id = $%d", q.CorrelationID)
+	}
+	if q.ServiceID != "" {
+		add("service_id = $%d", q.ServiceID)
+	}
+	if q.ActionType != "" {
+		add("action_type = $%d", q.ActionType)
+	}
+	if q.ObjectType != "" {
+		add("object_type = $%d", q.ObjectType)
+	}
+	if q.Status != "" {
+		add("action_status = $%d", q.Status)
+	}
+	if !q.Since.IsZero() {
+		add("started_at >= $%d", q.Since)
+	}
+	if !q.Until.IsZero() {
+		add("started_at < $%d", q.Until)
+	}
+	if q.MinDurationMS > 0 {
+		add("duration_ms >= $%d", q.MinDurationMS)
+	}
+
+	// Sorted for stable SQL text
+	paths := make([]string, 0, len(q.Metadata))
+	for path := range q.Metadata {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		segments, err := metadataPath(path)
+		if err != nil {
+			return "", nil, err
+		}
+		add("metadata #>> $%d = $%d", pq.Array(segments), q.Metadata[path])
+	}
+
+	if len(conditions) == 0 {
+		return "", args, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+// metadataPath splits a dotted JSON path ("$.a.b" or "a.b") into segments
+func metadataPath(path string) ([]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid metadata path %q", path)
+	}
+	segments := strings.Split(trimmed, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("invalid metadata path %q", path)
+		}
+	}
+	return segments, nil
+}
+
+// collectActionExecutions scans rows selected with actionExecutionColumns
+func collectActionExecutions(rows *sql.Rows) ([]*ActionExecution, error) {
+	var results []*ActionExecution
+	for rows.Next() {
+		action, err := scanActionExecution(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, action)
+	}
+	return results, rows.Err()
+}
+
+// scanActionExecution scans one row selected with actionExecutionColumns
+func scanActionExecution(rows *sql.Rows) (*ActionExecution, error) {
+	var (
+		action            ActionExecution
+		parentOperationID sql.NullString
+		objectType        sql.NullString
+		endpoint          sql.NullString
+		httpMethod        sql.NullString
+		completedAt       sql.NullTime
+		durationMS        sql.NullInt64
+		actionStatus      sql.NullString
+		errorMessage      sql.NullString
+		errorType         sql.NullString
+		requestURL        sql.NullString
+		responseURL       sql.NullString
+		logsURL           sql.NullString
+		requestSize       sql.NullInt64
+		responseSize      sql.NullInt64
+		metadata          []byte
+		otelTraceID       sql.NullString
+		otelSpanID        sql.NullString
+	)
+
+	err := rows.Scan(
+		&action.CorrelationID, &action.OperationID, &parentOperationID,
+		&action.ActionType, &objectType, &action.ServiceID, &endpoint, &httpMethod,
+		&action.StartedAt, &completedAt, &durationMS,
+		&actionStatus, &errorMessage, &errorType,
+		&requestURL, &responseURL, &logsURL,
+		&requestSize, &responseSize,
+		&metadata, &otelTraceID, &otelSpanID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	action.ParentOperationID = parentOperationID.String
+	action.ObjectType = objectType.String
+	action.Endpoint = endpoint.String
+	action.HTTPMethod = httpMethod.String
+	if completedAt.Valid {
+		action.CompletedAt = &completedAt.Time
+	}
+	action.DurationMS = durationMS.Int64
+	action.ActionStatus = actionStatus.String
+	action.ErrorMessage = errorMessage.String
+	action.ErrorType = errorType.String
+	action.RequestURL = requestURL.String
+	action.ResponseURL = responseURL.String
+	action.LogsURL = logsURL.String
+	action.RequestSizeBytes = requestSize.Int64
+	action.ResponseSizeBytes = responseSize.Int64
+	if len(metadata) > 0 {
+		action.Metadata = metadata
+	}
+	action.OTelTraceID = otelTraceID.String
+	action.OTelSpanID = otelSpanID.String
+
+	return &action, nil
+}
+
+// isPresignUnsupported reports whether err means payloads must be streamed instead
+func isPresignUnsupported(err error) bool {
+	return errors.Is(err, storage.ErrPresignUnsupported)
+}
@@ -0,0 +1,343 @@
+// Package tracing - HTTP handlers for the trace query API
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// QueryAPIConfig configures the trace query endpoints
+type QueryAPIConfig struct {
+	// UserIDHeader names the header identifying the caller for the audit log (default "X-User-ID")
+	UserIDHeader string
+
+	// PurposeHeader names the header carrying the access justification (default "X-Access-Purpose")
+	PurposeHeader string
+
+	// PresignTTL is the lifetime of payload links (default 15 minutes)
+	PresignTTL time.Duration
+
+	// MaxLimit caps the limit query parameter (default 1000)
+	MaxLimit int
+}
+
+// queryAPI serves the trace query endpoints for a tracer
+type queryAPI struct {
+	tracer *Tracer
+	config QueryAPIConfig
+}
+
+// RegisterQueryRoutes adds the trace query endpoints to an Echo group.
+// Every request is recorded in trace_access_audit via LogTraceAccess.
+//
+// Routes:
+//   - GET /traces: workflows (one entry per correlation ID) matching the filters
+//   - GET /traces/actions: individual actions matching the filters
+//   - GET /traces/failed?service=&hours=: failed actions of a service
+//   - GET /traces/slow?threshold_ms=&hours=: slowest actions
+//   - GET /traces/:correlation_id: operation tree of a workflow
+//   - GET /traces/:correlation_id/operations/:operation_id: action with payload links
+//   - GET /traces/:correlation_id/operations/:operation_id/payloads/:kind: payload content (request, response, logs)
+//
+// Filters: correlation_id, service, action_type, object_type, status, since, until
+// (RFC 3339), min_duration_ms, limit, offset and meta.<json.path>=<value>.
+func (t *Tracer) RegisterQueryRoutes(g *echo.Group, config QueryAPIConfig) {
+	if config.UserIDHeader == "" {
+		config.UserIDHeader = "X-User-ID"
+	}
+	if config.PurposeHeader == "" {
+		config.PurposeHeader = "X-Access-Purpose"
+	}
+	if config.PresignTTL <= 0 {
+		config.PresignTTL = 15 * time.Minute
+	}
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = 1000
+	}
+
+	api := &queryAPI{tracer: t, config: config}
+	g.GET("/traces", api.handleListWorkflows)
+	g.GET("/traces/actions", api.handleQueryActions)
+	g.GET("/traces/failed", api.handleFailedActions)
+	g.GET("/traces/slow", api.handleSlowActions)
+	g.GET("/traces/:correlation_id", api.handleOperationTree)
+	g.GET("/traces/:correlation_id/operations/:operation_id", api.handleGetAction)
+	g.GET("/traces/:correlation_id/operations/:operation_id/payloads/:kind", api.handleGetPayload)
+}
+
+// handleListWorkflows returns workflow summaries
+func (a *queryAPI) handleListWorkflows(c echo.Context) error {
+	q, err := a.parseQuery(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err)
+	}
+	workflows, err := a.tracer.ListWorkflows(c.Request().Context(), q)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err)
+	}
+	a.audit(c, "query", "workflow_trace", q.CorrelationID, "", len(workflows))
+	return c.JSON(http.StatusOK, workflows)
+}
+
+// handleQueryActions returns actions matching the filters
+func (a *queryAPI) handleQueryActions(c echo.Context) error {
+	q, err := a.parseQuery(c)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err)
+	}
+	actions, err := a.tracer.QueryActions(c.Request().Context(), q)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err)
+	}
+	resource := "action_detail"
+	if len(q.Metadata) > 0 {
+		resource = "metadata"
+	}
+	a.audit(c, "query", resource, q.CorrelationID, "", len(actions))
+	return c.JSON(http.StatusOK, actions)
+}
+
+// handleFailedActions returns failed actions of a service
+func (a *queryAPI) handleFailedActions(c echo.Context) error {
+	serviceID := c.QueryParam("service")
+	if serviceID == "" {
+		return errorJSON(c, http.StatusBadRequest, fmt.Errorf("service is required"))
+	}
+	hours, err := intParam(c, "hours", 24)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err)
+	}
+	actions, err := a.tracer.GetFailedActions(c.Request().Context(), serviceID, hours)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err)
+	}
+	a.audit(c, "query", "action_detail", "", "", len(actions))
+	return c.JSON(http.StatusOK, actions)
+}
+
+// handleSlowActions returns the slowest actions
+func (a *queryAPI) handleSlowActions(c echo.Context) error {
+	threshold, err := intParam(c, "threshold_ms", 5000)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err)
+	}
+	hours, err := intParam(c, "hours", 24)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err)
+	}
+	actions, err := a.tracer.GetSlowActions(c.Request().Context(), int64(threshold), hours)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err)
+	}
+	a.audit(c, "query", "action_detail", "", "", len(actions))
+	return c.JSON(http.StatusOK, actions)
+}
+
+// handleOperationTree returns the nested actions of a workflow
+func (a *queryAPI) handleOperationTree(c echo.Context) error {
+	correlationID := c.Param("correlation_id")
+	tree, err := a.tracer.GetOperationTree(c.Request().Context(), correlationID)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err)
+	}
+	if len(tree) == 0 {
+		return errorJSON(c, http.StatusNotFound, fmt.Errorf("workflow %s not found", correlationID))
+	}
+	a.audit(c, "view", "workflow_trace", correlationID, "", countNodes(tree))
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"correlation_id": correlationID,
+		"operations":     tree,
+	})
+}
+
+// handleGetAction returns one action and links to its payloads
+func (a *queryAPI) handleGetAction(c echo.Context) error {
+	ctx := c.Request().Context()
+	correlationID := c.Param("correlation_id")
+	operationID := c.Param("operation_id")
+
+	action, err := a.tracer.GetAction(ctx, correlationID, operationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorJSON(c, http.StatusNotFound, fmt.Errorf("operation %s not found", operationID))
+	}
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err)
+	}
+
+	links, err := a.tracer.PresignPayloads(ctx, action, a.config.PresignTTL)
+	if isPresignUnsupported(err) {
+		links = a.streamLinks(c, action)
+	} else if err != nil {
+		a.tracer.logError("Failed to presign payload links", err)
+		links = nil
+	}
+
+	a.audit(c, "view", "action_detail", correlationID, operationID, 1)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"action":   action,
+		"payloads": links,
+	})
+}
+
+// handleGetPayload streams a stored payload (for stores that cannot presign)
+func (a *queryAPI) handleGetPayload(c echo.Context) error {
+	ctx := c.Request().Context()
+	correlationID := c.Param("correlation_id")
+	operationID := c.Param("operation_id")
+
+	var (
+		data        []byte
+		err         error
+		contentType = echo.MIMEApplicationJSON
+	)
+	switch c.Param("kind") {
+	case "request":
+		data, err = a.tracer.GetRequest(ctx, correlationID, operationID)
+	case "response":
+		data, err = a.tracer.GetResponse(ctx, correlationID, operationID)
+	case "logs":
+		data, err = a.tracer.GetLogs(ctx, correlationID, operationID)
+		contentType = echo.MIMETextPlainCharsetUTF8
+	default:
+		return errorJSON(c, http.StatusBadRequest, fmt.Errorf("unknown payload %q", c.Param("kind")))
+	}
+	if err != nil {
+		return errorJSON(c, http.StatusNotFound, err)
+	}
+
+	a.audit(c, "export", "action_detail", correlationID, operationID, 1)
+	return c.Blob(http.StatusOK, contentType, data)
+}
+
+// streamLinks points payload links at handleGetPayload
+func (a *queryAPI) streamLinks(c echo.Context, action *ActionExecution) *PayloadLinks {
+	base := strings.TrimSuffix(c.Request().URL.Path, "/") + "/payloads/"
+	links := &PayloadLinks{}
+	if _, ok := payloadKey(action.RequestURL); ok {
+		links.Request = base + "request"
+	}
+	if _, ok := payloadKey(action.ResponseURL); ok {
+		links.Response = base + "response"
+	}
+	if _, ok := payloadKey(action.LogsURL); ok {
+		links.Logs = base + "logs"
+	}
+	return links
+}
+
+// parseQuery reads ActionQuery filters from query parameters
+func (a *queryAPI) parseQuery(c echo.Context) (ActionQuery, error) {
+	q := ActionQuery{
+		CorrelationID: c.QueryParam("correlation_id"),
+		ServiceID:     c.QueryParam("service"),
+		ActionType:    c.QueryParam("action_type"),
+		ObjectType:    c.QueryParam("object_type"),
+		Status:        c.QueryParam("status"),
+	}
+
+	var err error
+	if q.Since, err = timeParam(c, "since"); err != nil {
+		return q, err
+	}
+	if q.Until, err = timeParam(c, "until"); err != nil {
+		return q, err
+	}
+	minDuration, err := intParam(c, "min_duration_ms", 0)
+	if err != nil {
+		return q, err
+	}
+	q.MinDurationMS = int64(minDuration)
+	if q.Limit, err = intParam(c, "limit", DefaultQueryLimit); err != nil {
+		return q, err
+	}
+	if q.Limit > a.config.MaxLimit {
+		q.Limit = a.config.MaxLimit
+	}
+	if q.Offset, err = intParam(c, "offset", 0); err != nil {
+		return q, err
+	}
+
+	for name, values := range c.QueryParams() {
+		if path, ok := strings.CutPrefix(name, "meta."); ok && len(values) > 0 {
+			if q.Metadata == nil {
+				q.Metadata = make(map[string]string)
+			}
+			q.Metadata[path] = values[0]
+		}
+	}
+	return q, nil
+}
+
+// audit records the request in trace_access_audit; failures are logged, not returned
+func (a *queryAPI) audit(c echo.Context, accessType, resourceType, correlationID, operationID string, results int) {
+	if a.tracer.config.DB == nil {
+		return
+	}
+	userID := c.Request().Header.Get(a.config.UserIDHeader)
+	if userID == "" {
+		userID = "anonymous"
+	}
+
+	params := make(map[string]interface{})
+	for name, values := range c.QueryParams() {
+		params[name] = strings.Join(values, ",")
+	}
+	params["path"] = c.Request().URL.Path
+
+	// Detached so a cancelled request is still audited
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request().Context()), 5*time.Second)
+	defer cancel()
+	err := a.tracer.LogTraceAccess(ctx, userID, accessType, resourceType,
+		correlationID, operationID, "", results, c.Request().Header.Get(a.config.PurposeHeader), params)
+	if err != nil {
+		a.tracer.logError("Failed to log trace access", err)
+	}
+}
+
+// countNodes counts all nodes in an operation tree
+func countNodes(nodes []*OperationNode) int {
+	n := len(nodes)
+	for _, node := range nodes {
+		n += countNodes(node.Children)
+	}
+	return n
+}
+
+// intParam parses an integer query parameter
+func intParam(c echo.Context, name string, fallback int) (int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return n, nil
+}
+
+// timeParam parses an RFC 3339 query parameter
+func timeParam(c echo.Context, name string) (time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return parsed, nil
+}
+
+// errorJSON writes an error response
+func errorJSON(c echo.Context, status int, err error) error {
+	return c.JSON(status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,240 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eve.evalgo.org/storage"
+)
+
+func TestActionQueryWhereClause(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+
+	tests := []struct {
+		name  string
+		query ActionQuery
+		where string
+		args  []interface{}
+	}{
+		{
+			name:  "no filters",
+			query: ActionQuery{Limit: 10, Offset: 5},
+			where: "",
+			args:  nil,
+		},
+		{
+			name:  "single filter",
+			query: ActionQuery{ServiceID: "crm"},
+			where: " WHERE service_id = $1",
+			args:  []interface{}{"crm"},
+		},
+		{
+			name: "all columns",
+			query: ActionQuery{
+				CorrelationID: "corr", ServiceID: "crm", ActionType: "SearchAction", ObjectType: "Person",
+				Status: "FailedActionStatus", Since: since, Until: until, MinDurationMS: 250,
+			},
+			where: " WHERE correlation_id = $1 AND service_id = $2 AND action_type = $3 AND object_type = $4" +
+				" AND action_status = $5 AND started_at >= $6 AND started_at < $7 AND duration_ms >= $8",
+			args: []interface{}{"corr", "crm", "SearchAction", "Person", "FailedActionStatus", since, until, int64(250)},
+		},
+		{
+			name: "metadata sorted by path after columns",
+			query: ActionQuery{
+				Status:   "CompletedActionStatus",
+				Metadata: map[string]string{"$.progress.table": "users", "source": "pg"},
+			},
+			where: " WHERE action_status = $1 AND metadata #>> $2 = $3 AND metadata #>> $4 = $5",
+			args: []interface{}{
+				"CompletedActionStatus",
+				pq.Array([]string{"progress", "table"}), "users",
+				pq.Array([]string{"source"}), "pg",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args, err := tt.query.whereClause()
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, where)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+
+	_, _, err := ActionQuery{ServiceID: "crm", Metadata: map[string]string{"a..b": "x"}}.whereClause()
+	assert.ErrorContains(t, err, "invalid metadata path")
+}
+
+func TestMetadataPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "source", want: []string{"source"}},
+		{path: "$.source", want: []string{"source"}},
+		{path: "progress.current_table", want: []string{"progress", "current_table"}},
+		{path: "$.progress.current_table", want: []string{"progress", "current_table"}},
+		{path: "", wantErr: true},
+		{path: "$", wantErr: true},
+		{path: "$.", wantErr: true},
+		{path: "a..b", wantErr: true},
+		{path: "a.", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := metadataPath(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPayloadKey(t *testing.T) {
+	tests := []struct {
+		url  string
+		key  string
+		isOK bool
+	}{
+		{"s3://traces/corr/op/request.json", "corr/op/request.json", true},
+		{"s3://traces/file", "file", true},
+		{"s3://traces/", "", false},
+		{"s3://traces", "", false},
+		{"https://traces/corr/op/request.json", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		key, ok := payloadKey(tt.url)
+		assert.Equal(t, tt.isOK, ok, tt.url)
+		assert.Equal(t, tt.key, key, tt.url)
+	}
+}
+
+func TestBuildOperationTree(t *testing.T) {
+	actions := []*ActionExecution{
+		{OperationID: "root"},
+		{OperationID: "child-1", ParentOperationID: "root"},
+		{OperationID: "orphan", ParentOperationID: "other-workflow"},
+		{OperationID: "grandchild", ParentOperationID: "child-1"},
+		{OperationID: "child-2", ParentOperationID: "root"},
+		{OperationID: "self", ParentOperationID: "self"},
+	}
+
+	roots := BuildOperationTree(actions)
+
+	require.Len(t, roots, 3)
+	assert.Equal(t, "root", roots[0].OperationID)
+	assert.Equal(t, "orphan", roots[1].OperationID, "actions with an unknown parent become roots")
+	assert.Equal(t, "self", roots[2].OperationID, "self-referencing actions become roots")
+	assert.Empty(t, roots[1].Children)
+
+	require.Len(t, roots[0].Children, 2)
+	assert.Equal(t, "child-1", roots[0].Children[0].OperationID)
+	assert.Equal(t, "child-2", roots[0].Children[1].OperationID)
+	require.Len(t, roots[0].Children[0].Children, 1)
+	assert.Equal(t, "grandchild", roots[0].Children[0].Children[0].OperationID)
+
+	assert.Equal(t, 6, countNodes(roots))
+	assert.Empty(t, BuildOperationTree(nil))
+}
+
+// newQueryServer serves the query routes of a tracer without a database
+func newQueryServer(t *testing.T, tracer *Tracer) *echo.Echo {
+	t.Helper()
+	e := echo.New()
+	tracer.RegisterQueryRoutes(e.Group("/api"), QueryAPIConfig{MaxLimit: 50})
+	return e
+}
+
+func TestQueryHandlersValidateParameters(t *testing.T) {
+	e := newQueryServer(t, &Tracer{})
+
+	tests := []struct {
+		name  string
+		path  string
+		error string
+	}{
+		{"failed without service", "/api/traces/failed", "service is required"},
+		{"failed with bad hours", "/api/traces/failed?service=crm&hours=abc", `invalid hours: "abc"`},
+		{"failed with negative hours", "/api/traces/failed?service=crm&hours=-1", `invalid hours: "-1"`},
+		{"slow with bad threshold", "/api/traces/slow?threshold_ms=fast", `invalid threshold_ms: "fast"`},
+		{"slow with bad hours", "/api/traces/slow?hours=1.5", `invalid hours: "1.5"`},
+		{"list with bad since", "/api/traces?since=yesterday", `invalid since: "yesterday"`},
+		{"list with bad until", "/api/traces?until=2025-01-01", `invalid until: "2025-01-01"`},
+		{"actions with bad limit", "/api/traces/actions?limit=ten", `invalid limit: "ten"`},
+		{"actions with bad offset", "/api/traces/actions?offset=-5", `invalid offset: "-5"`},
+		{"actions with bad duration", "/api/traces/actions?min_duration_ms=x", `invalid min_duration_ms: "x"`},
+		{"unknown payload kind", "/api/traces/corr/operations/op/payloads/secrets", `unknown payload "secrets"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.JSONEq(t, `{"error":`+quote(tt.error)+`}`, rec.Body.String())
+		})
+	}
+}
+
+func TestQueryAPIParseQuery(t *testing.T) {
+	api := &queryAPI{tracer: &Tracer{}, config: QueryAPIConfig{MaxLimit: 200}}
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/traces/actions?service=crm&status=FailedActionStatus&since=2025-01-01T00:00:00Z&limit=500&offset=20"+
+			"&min_duration_ms=100&meta.source=pg&meta.$.progress.table=users", nil)
+	q, err := api.parseQuery(e.NewContext(req, httptest.NewRecorder()))
+	require.NoError(t, err)
+
+	assert.Equal(t, "crm", q.ServiceID)
+	assert.Equal(t, "FailedActionStatus", q.Status)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), q.Since)
+	assert.Equal(t, 200, q.Limit, "limit is capped at MaxLimit")
+	assert.Equal(t, 20, q.Offset)
+	assert.Equal(t, int64(100), q.MinDurationMS)
+	assert.Equal(t, map[string]string{"source": "pg", "$.progress.table": "users"}, q.Metadata)
+
+	req = httptest.NewRequest(http.MethodGet, "/traces/actions", nil)
+	q, err = api.parseQuery(e.NewContext(req, httptest.NewRecorder()))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultQueryLimit, q.Limit)
+	assert.Nil(t, q.Metadata)
+}
+
+func TestQueryHandlersStreamPayloads(t *testing.T) {
+	store, err := storage.NewLocalObjectStore(t.TempDir())
+	require.NoError(t, err)
+	tracer := &Tracer{config: Config{ObjectStore: store}}
+	require.NoError(t, tracer.UploadLogs(context.Background(), "corr", "op", []byte("done")))
+	e := newQueryServer(t, tracer)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/traces/corr/operations/op/payloads/logs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMETextPlainCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "done", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/traces/corr/operations/op/payloads/request", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// quote returns s as a JSON string literal
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
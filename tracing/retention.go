@@ -0,0 +1,364 @@
+// Package tracing - Declarative retention policies and dry-run retention reports
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+)
+
+// Retention actions reported for due traces
+const (
+	RetentionArchive = "archive" // Move from Postgres to the S3 archive
+	RetentionDelete  = "delete"  // Delete from Postgres and the S3 archive
+)
+
+// RetentionPolicy describes how long matching traces are kept.
+// Traces stay in Postgres for HotDays, are then archived to S3 and deleted
+// ArchiveDays after archival. Empty selectors (or "*") match any value; the
+// policy with the most selectors wins, ties go to the first listed policy.
+type RetentionPolicy struct {
+	Name       string `yaml:"name" json:"name"`
+	ServiceID  string `yaml:"service_id,omitempty" json:"service_id,omitempty"`
+	ActionType string `yaml:"action_type,omitempty" json:"action_type,omitempty"`
+	LegalBasis string `yaml:"legal_basis,omitempty" json:"legal_basis,omitempty"`
+
+	// HotDays is how long traces stay in Postgres before archival
+	HotDays int `yaml:"hot_days" json:"hot_days"`
+
+	// ArchiveDays is how long archived traces are kept (0 = keep forever)
+	ArchiveDays int `yaml:"archive_days,omitempty" json:"archive_days,omitempty"`
+
+	// SkipArchive deletes traces after HotDays instead of archiving them
+	SkipArchive bool `yaml:"skip_archive,omitempty" json:"skip_archive,omitempty"`
+}
+
+// RetentionPolicies is the file format read by LoadRetentionPolicies
+type RetentionPolicies struct {
+	Policies []RetentionPolicy `yaml:"policies" json:"policies"`
+}
+
+// LoadRetentionPolicies reads retention policies from a YAML or JSON file
+func LoadRetentionPolicies(path string) ([]RetentionPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read retention policies: %w", err)
+	}
+	return ParseRetentionPolicies(data)
+}
+
+// ParseRetentionPolicies parses and validates YAML or JSON retention policies
+func ParseRetentionPolicies(data []byte) ([]RetentionPolicy, error) {
+	var file RetentionPolicies
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse retention policies: %w", err)
+	}
+	if err := ValidateRetentionPolicies(file.Policies); err != nil {
+		return nil, err
+	}
+	return file.Policies, nil
+}
+
+// ValidateRetentionPolicies checks that policies are named uniquely and have valid periods
+func ValidateRetentionPolicies(policies []RetentionPolicy) error {
+	names := make(map[string]bool, len(policies))
+	for i, p := range policies {
+		if p.Name == "" {
+			return fmt.Errorf("retention policy %d: name is required", i)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("retention policy %q: duplicate name", p.Name)
+		}
+		names[p.Name] = true
+		if p.HotDays <= 0 {
+			return fmt.Errorf("retention policy %q: hot_days must be positive", p.Name)
+		}
+		if p.ArchiveDays < 0 {
+			return fmt.Errorf("retention policy %q: archive_days must not be negative", p.Name)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the policy applies to a trace
+func (p RetentionPolicy) Matches(serviceID, actionType, legalBasis string) bool {
+	return selectorMatches(p.ServiceID, serviceID) &&
+		selectorMatches(p.ActionType, actionType) &&
+		selectorMatches(p.LegalBasis, legalBasis)
+}
+
+// TotalDays returns how long a trace is kept in total (0 = forever)
+func (p RetentionPolicy) TotalDays() int {
+	switch {
+	case p.SkipArchive:
+		return p.HotDays
+	case p.ArchiveDays == 0:
+		return 0
+	default:
+		return p.HotDays + p.ArchiveDays
+	}
+}
+
+// specificity counts the selectors a policy sets
+func (p RetentionPolicy) specificity() int {
+	n := 0
+	for _, selector := range []string{p.ServiceID, p.ActionType, p.LegalBasis} {
+		if !wildcard(selector) {
+			n++
+		}
+	}
+	return n
+}
+
+// MatchRetentionPolicy returns the most specific policy applying to a trace
+func MatchRetentionPolicy(policies []RetentionPolicy, serviceID, actionType, legalBasis string) (RetentionPolicy, bool) {
+	for _, p := range orderRetentionPolicies(policies) {
+		if p.Matches(serviceID, actionType, legalBasis) {
+			return p, true
+		}
+	}
+	return RetentionPolicy{}, false
+}
+
+// orderRetentionPolicies sorts policies by specificity, keeping the listed order for ties
+func orderRetentionPolicies(policies []RetentionPolicy) []RetentionPolicy {
+	ordered := append([]RetentionPolicy(nil), policies...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].specificity() > ordered[j].specificity()
+	})
+	return ordered
+}
+
+// retentionDays returns how long the tracer keeps traces of an action type (0 = forever)
+func (t *Tracer) retentionDays(actionType string) int {
+	if policy, ok := MatchRetentionPolicy(t.config.RetentionPolicies, t.config.ServiceID, actionType, t.config.LegalBasis); ok {
+		return policy.TotalDays()
+	}
+	return t.config.RetentionDays
+}
+
+func wildcard(selector string) bool {
+	return selector == "" || selector == "*"
+}
+
+func selectorMatches(selector, value string) bool {
+	return wildcard(selector) || selector == value
+}
+
+// RetentionReport describes what a retention run would archive and delete
+type RetentionReport struct {
+	GeneratedAt time.Time               `json:"generated_at"`
+	Policies    []RetentionPolicyReport `json:"policies"`
+	Items       []RetentionItem         `json:"items"` // Due traces, oldest first, up to BatchSize
+	Totals      RetentionTotals         `json:"totals"`
+}
+
+// RetentionPolicyReport counts the due traces of one policy
+type RetentionPolicyReport struct {
+	Policy    RetentionPolicy `json:"policy"`
+	ToArchive int64           `json:"to_archive"`
+	ToDelete  int64           `json:"to_delete"`
+	Held      int64           `json:"held"`                 // Due but exempt by a legal hold
+	OldestDue *time.Time      `json:"oldest_due,omitempty"` // Oldest due trace that is not held
+}
+
+// RetentionTotals sums the policy reports and counts expired dependency snapshots
+type RetentionTotals struct {
+	ToArchive         int64 `json:"to_archive"`
+	ToDelete          int64 `json:"to_delete"`
+	Held              int64 `json:"held"`
+	SnapshotsToDelete int64 `json:"snapshots_to_delete"` // Expired dependency graph snapshots
+}
+
+// RetentionItem is a trace that is due for archival or deletion
+type RetentionItem struct {
+	CorrelationID string     `json:"correlation_id"`
+	OperationID   string     `json:"operation_id"`
+	ServiceID     string     `json:"service_id"`
+	ActionType    string     `json:"action_type"`
+	Policy        string     `json:"policy"`
+	Action        string     `json:"action"` // archive, delete
+	StartedAt     time.Time  `json:"started_at"`
+	ArchivedAt    *time.Time `json:"archived_at,omitempty"`
+	ArchivedS3Key string     `json:"archived_s3_key,omitempty"`
+}
+
+// retentionPlan classifies traces by policy in SQL.
+// The policies are ordered by specificity; the last one matches everything.
+type retentionPlan struct {
+	policies []RetentionPolicy
+	args     []interface{}
+	cte      string
+}
+
+// newRetentionPlan builds the classification query for the policies and a fallback policy
+func newRetentionPlan(policies []RetentionPolicy, fallback RetentionPolicy) *retentionPlan {
+	plan := &retentionPlan{policies: append(orderRetentionPolicies(policies), fallback)}
+
+	var cases strings.Builder
+	hot := make([]int64, len(plan.policies))
+	archive := make([]int64, len(plan.policies))
+	skip := make([]bool, len(plan.policies))
+	idx := make([]int64, len(plan.policies))
+	for i, p := range plan.policies {
+		var conds []string
+		for _, sel := range []struct{ column, value string }{
+			{"ae.service_id", p.ServiceID},
+			{"ae.action_type", p.ActionType},
+			{"ae.legal_basis", p.LegalBasis},
+		} {
+			if !wildcard(sel.value) {
+				conds = append(conds, fmt.Sprintf("%s = %s", sel.column, plan.arg(sel.value)))
+			}
+		}
+		if len(conds) == 0 {
+			conds = []string{"TRUE"}
+		}
+		fmt.Fprintf(&cases, "\n\t\t\t\t\tWHEN %s THEN %d", strings.Join(conds, " AND "), i)
+
+		idx[i], hot[i], archive[i], skip[i] = int64(i), int64(p.HotDays), int64(p.ArchiveDays), p.SkipArchive
+	}
+
+	plan.cte = fmt.Sprintf(`
+		WITH retention_policies AS (
+			SELECT *
+			FROM unnest(%s::int[], %s::int[], %s::int[], %s::boolean[])
+				AS p(policy_idx, hot_days, archive_days, skip_archive)
+		), retention_candidates AS (
+			SELECT
+				ae.correlation_id,
+				ae.operation_id,
+				ae.service_id,
+				ae.action_type,
+				ae.started_at,
+				ae.archived_at,
+				ae.archived_s3_key,
+				CASE%s
+				END AS policy_idx,
+				EXISTS (
+					SELECT 1 FROM trace_legal_holds h
+					WHERE h.correlation_id = ae.correlation_id AND h.released_at IS NULL
+				) AS held
+			FROM action_executions ae
+		), retention_due AS (
+			SELECT
+				c.*,
+				CASE
+					WHEN c.archived_at IS NULL AND c.started_at < NOW() - make_interval(days => p.hot_days)
+						THEN CASE WHEN p.skip_archive THEN 'delete' ELSE 'archive' END
+					WHEN c.archived_at IS NOT NULL AND p.archive_days > 0
+						AND c.archived_at < NOW() - make_interval(days => p.archive_days)
+						THEN 'delete'
+				END AS retention_action
+			FROM retention_candidates c
+			JOIN retention_policies p USING (policy_idx)
+		)`,
+		plan.arg(pq.Array(idx)), plan.arg(pq.Array(hot)), plan.arg(pq.Array(archive)), plan.arg(pq.Array(skip)),
+		cases.String())
+	return plan
+}
+
+// arg adds a query argument and returns its placeholder
+func (p *retentionPlan) arg(value interface{}) string {
+	p.args = append(p.args, value)
+	return fmt.Sprintf("$%d", len(p.args))
+}
+
+// query appends a statement to the classification CTE. The statement refers to
+// its own arguments as %[1]s, %[2]s, ... which are numbered after the plan's.
+func (p *retentionPlan) query(statement string, args ...interface{}) (string, []interface{}) {
+	placeholders := make([]interface{}, len(args))
+	for i := range args {
+		placeholders[i] = fmt.Sprintf("$%d", len(p.args)+i+1)
+	}
+	all := append(append([]interface{}(nil), p.args...), args...)
+	return p.cte + "\n" + fmt.Sprintf(statement, placeholders...), all
+}
+
+// dueTraces returns traces due for an action (or any action if empty) that are not held
+func (p *retentionPlan) dueTraces(ctx context.Context, db *sql.DB, action string, limit int) ([]RetentionItem, error) {
+	query, args := p.query(`
+		SELECT correlation_id, operation_id, service_id, action_type, started_at,
+			archived_at, archived_s3_key, policy_idx, retention_action
+		FROM retention_due
+		WHERE retention_action IS NOT NULL
+			AND NOT held
+			AND (%[1]s = '' OR retention_action = %[1]s)
+		ORDER BY started_at
+		LIMIT %[2]s`, action, limit)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query due traces: %w", err)
+	}
+	defer rows.Close()
+
+	items := []RetentionItem{}
+	for rows.Next() {
+		var (
+			item       RetentionItem
+			archivedAt sql.NullTime
+			s3Key      sql.NullString
+			policyIdx  int
+		)
+		if err := rows.Scan(&item.CorrelationID, &item.OperationID, &item.ServiceID, &item.ActionType,
+			&item.StartedAt, &archivedAt, &s3Key, &policyIdx, &item.Action); err != nil {
+			return nil, fmt.Errorf("scan due trace: %w", err)
+		}
+		if archivedAt.Valid {
+			item.ArchivedAt = &archivedAt.Time
+		}
+		item.ArchivedS3Key = s3Key.String
+		item.Policy = p.policies[policyIdx].Name
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// report counts due and held traces per policy
+func (p *retentionPlan) report(ctx context.Context, db *sql.DB) ([]RetentionPolicyReport, error) {
+	query, args := p.query(`
+		SELECT
+			policy_idx,
+			COUNT(*) FILTER (WHERE retention_action = 'archive' AND NOT held),
+			COUNT(*) FILTER (WHERE retention_action = 'delete' AND NOT held),
+			COUNT(*) FILTER (WHERE held),
+			MIN(started_at) FILTER (WHERE NOT held)
+		FROM retention_due
+		WHERE retention_action IS NOT NULL
+		GROUP BY policy_idx`)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query retention report: %w", err)
+	}
+	defer rows.Close()
+
+	reports := make([]RetentionPolicyReport, len(p.policies))
+	for i, policy := range p.policies {
+		reports[i].Policy = policy
+	}
+	for rows.Next() {
+		var (
+			policyIdx int
+			oldest    sql.NullTime
+			r         RetentionPolicyReport
+		)
+		if err := rows.Scan(&policyIdx, &r.ToArchive, &r.ToDelete, &r.Held, &oldest); err != nil {
+			return nil, fmt.Errorf("scan retention report: %w", err)
+		}
+		r.Policy = p.policies[policyIdx]
+		if oldest.Valid {
+			r.OldestDue = &oldest.Time
+		}
+		reports[policyIdx] = r
+	}
+	return reports, rows.Err()
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetentionPolicies(t *testing.T) {
+	policies, err := ParseRetentionPolicies([]byte(`
+policies:
+  - name: payments
+    service_id: payment-service
+    hot_days: 30
+    archive_days: 3650
+  - name: debug
+    action_type: WaitAction
+    hot_days: 7
+    skip_archive: true
+`))
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	assert.Equal(t, 3680, policies[0].TotalDays())
+	assert.Equal(t, 7, policies[1].TotalDays())
+
+	_, err = ParseRetentionPolicies([]byte("policies:\n  - name: a\n    hot_days: 0\n"))
+	assert.ErrorContains(t, err, "hot_days")
+
+	_, err = ParseRetentionPolicies([]byte("policies:\n  - name: a\n    hot_days: 1\n  - name: a\n    hot_days: 2\n"))
+	assert.ErrorContains(t, err, "duplicate")
+}
+
+func TestMatchRetentionPolicy(t *testing.T) {
+	policies := []RetentionPolicy{
+		{Name: "any", HotDays: 90},
+		{Name: "service", ServiceID: "crm", HotDays: 30},
+		{Name: "service-action", ServiceID: "crm", ActionType: "SearchAction", HotDays: 7},
+		{Name: "consent", ServiceID: "*", LegalBasis: "Consent", HotDays: 14},
+	}
+
+	tests := []struct {
+		service, action, basis string
+		want                   string
+	}{
+		{"crm", "SearchAction", "Consent", "service-action"},
+		{"crm", "CreateAction", "Consent", "service"}, // Tie with consent, listed first
+		{"billing", "CreateAction", "Consent", "consent"},
+		{"billing", "CreateAction", "Contract", "any"},
+	}
+	for _, tt := range tests {
+		policy, ok := MatchRetentionPolicy(policies, tt.service, tt.action, tt.basis)
+		require.True(t, ok)
+		assert.Equal(t, tt.want, policy.Name, "%s %s %s", tt.service, tt.action, tt.basis)
+	}
+
+	_, ok := MatchRetentionPolicy(policies[1:2], "billing", "", "")
+	assert.False(t, ok)
+}
+
+func TestDefaultRetentionPolicy(t *testing.T) {
+	am := NewArchivalManager(nil, nil, ArchivalConfig{ArchiveAfterDays: 90, DeleteAfterDays: 365})
+	assert.Equal(t, RetentionPolicy{Name: "default", HotDays: 90, ArchiveDays: 275}, am.DefaultRetentionPolicy())
+
+	am = NewArchivalManager(nil, nil, ArchivalConfig{ArchiveAfterDays: 90, DeleteAfterDays: 30})
+	assert.Equal(t, RetentionPolicy{Name: "default", HotDays: 30, SkipArchive: true}, am.DefaultRetentionPolicy())
+}
+
+func TestRetentionPlanQuery(t *testing.T) {
+	plan := newRetentionPlan([]RetentionPolicy{
+		{Name: "any", HotDays: 90},
+		{Name: "crm", ServiceID: "crm", LegalBasis: "Consent", HotDays: 30},
+	}, RetentionPolicy{Name: "default", HotDays: 90})
+
+	require.Len(t, plan.policies, 3)
+	assert.Equal(t, "crm", plan.policies[0].Name)
+	assert.Equal(t, "default", plan.policies[2].Name)
+	assert.Contains(t, plan.cte, "WHEN ae.service_id = $1 AND ae.legal_basis = $2 THEN 0")
+	assert.Contains(t, plan.cte, "WHEN TRUE THEN 1")
+
+	query, args := plan.query("SELECT %[1]s, %[2]s", "a", 1)
+	assert.True(t, strings.HasSuffix(query, "SELECT $7, $8"))
+	assert.Len(t, args, 8)
+}
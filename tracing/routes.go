@@ -0,0 +1,156 @@
+// Package tracing - Route selection and attribute extraction for the middleware
+package tracing
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultMaxBodySize is the default limit for buffering bodies for tracing (1 MiB)
+const DefaultMaxBodySize int64 = 1 << 20
+
+// DefaultTraceRoutes traces the semantic action endpoint only
+var DefaultTraceRoutes = []TraceRoute{
+	{Path: "/v1/api/semantic/action"},
+}
+
+// RouteAttributes identifies a traced request
+type RouteAttributes struct {
+	ActionType    string
+	ObjectType    string
+	DataSubjectID string
+}
+
+// RouteExtractor derives trace attributes from a request.
+// body holds at most Config.MaxBodySize bytes; for multipart requests it is the
+// "action" part. Empty fields fall back to JSON-LD parsing of body.
+type RouteExtractor func(c echo.Context, body []byte) RouteAttributes
+
+// TraceRoute selects requests for the tracing middleware.
+//
+// Path is matched against the echo route pattern (c.Path(), e.g. "/v1/repos/:id");
+// a trailing "*" matches any suffix ("/v1/api/*"). Method is optional.
+type TraceRoute struct {
+	Method  string
+	Path    string
+	Extract RouteExtractor
+}
+
+// StaticAttributes returns an extractor for routes with fixed action and object types
+func StaticAttributes(actionType, objectType string) RouteExtractor {
+	return func(c echo.Context, body []byte) RouteAttributes {
+		return RouteAttributes{ActionType: actionType, ObjectType: objectType}
+	}
+}
+
+// matches reports whether the route selects the request
+func (r TraceRoute) matches(method, path string) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(r.Path, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return r.Path == path
+}
+
+// matchRoute returns the first configured route selecting the request
+func (t *Tracer) matchRoute(c echo.Context) (TraceRoute, bool) {
+	routes := t.config.Routes
+	if routes == nil {
+		routes = DefaultTraceRoutes
+	}
+	for _, route := range routes {
+		if route.matches(c.Request().Method, c.Path()) {
+			return route, true
+		}
+	}
+	return TraceRoute{}, false
+}
+
+// maxBodySize returns the configured body buffering limit
+func (t *Tracer) maxBodySize() int64 {
+	if t.config.MaxBodySize > 0 {
+		return t.config.MaxBodySize
+	}
+	return DefaultMaxBodySize
+}
+
+// captureRequestBody buffers up to limit bytes of the request body for tracing.
+// The handler still sees the complete body: anything beyond the limit is streamed
+// from the original reader. truncated reports whether the body exceeded the limit.
+func captureRequestBody(req *http.Request, limit int64) (body []byte, truncated bool, err error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, false, nil
+	}
+
+	body, err = io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if int64(len(body)) <= limit {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		return body, false, nil
+	}
+
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+	return body[:limit], true, nil
+}
+
+// multipartAction extracts the "action" part of a multipart/form-data body,
+// as parsed by semantic.ParseMultipartSemanticRequest. Returns nil if the body is
+// not multipart or the part is not within the buffered prefix.
+func multipartAction(contentType string, body []byte) []byte {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil
+		}
+		if part.FormName() != "action" {
+			continue
+		}
+		action, err := io.ReadAll(part)
+		if err != nil {
+			return nil
+		}
+		return action
+	}
+}
+
+// limitedBuffer keeps at most limit bytes and records whether more were written
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+// Write stores data up to the limit and always reports success
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - int64(b.Len())
+	if remaining <= 0 {
+		b.truncated = b.truncated || len(p) > 0
+		return len(p), nil
+	}
+	if int64(len(p)) > remaining {
+		b.truncated = true
+		b.Buffer.Write(p[:remaining])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
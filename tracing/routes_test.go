@@ -0,0 +1,173 @@
+package tracing
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceRouteMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		route  TraceRoute
+		method string
+		path   string
+		want   bool
+	}{
+		{"exact path any method", TraceRoute{Path: "/v1/api/semantic/action"}, "POST", "/v1/api/semantic/action", true},
+		{"exact path differs", TraceRoute{Path: "/v1/api/semantic/action"}, "POST", "/v1/api/semantic/actions", false},
+		{"exact path is not a prefix", TraceRoute{Path: "/v1/api"}, "GET", "/v1/api/x", false},
+		{"method matches case-insensitively", TraceRoute{Method: "post", Path: "/v1/repos/:id"}, "POST", "/v1/repos/:id", true},
+		{"method differs", TraceRoute{Method: "GET", Path: "/v1/repos/:id"}, "DELETE", "/v1/repos/:id", false},
+		{"wildcard suffix", TraceRoute{Path: "/v1/api/*"}, "GET", "/v1/api/semantic/action", true},
+		{"wildcard matches the prefix itself", TraceRoute{Path: "/v1/api/*"}, "GET", "/v1/api/", true},
+		{"wildcard outside prefix", TraceRoute{Path: "/v1/api/*"}, "GET", "/v2/api/x", false},
+		{"bare wildcard", TraceRoute{Path: "*"}, "PUT", "/anything", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.route.matches(tt.method, tt.path))
+		})
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	e := echo.New()
+	newContext := func(method, path string) echo.Context {
+		c := e.NewContext(httptest.NewRequest(method, path, nil), httptest.NewRecorder())
+		c.SetPath(path)
+		return c
+	}
+
+	route, ok := (&Tracer{}).matchRoute(newContext(http.MethodPost, "/v1/api/semantic/action"))
+	assert.True(t, ok, "default routes trace the semantic action endpoint")
+	assert.Equal(t, DefaultTraceRoutes[0].Path, route.Path)
+
+	tracer := &Tracer{config: Config{Routes: []TraceRoute{
+		{Method: "GET", Path: "/v1/repos/*", Extract: StaticAttributes("SearchAction", "Repository")},
+		{Path: "/v1/repos/*"},
+	}}}
+	route, ok = tracer.matchRoute(newContext(http.MethodPost, "/v1/repos/:id"))
+	require.True(t, ok)
+	assert.Nil(t, route.Extract, "first matching route wins")
+
+	route, ok = tracer.matchRoute(newContext(http.MethodGet, "/v1/repos/:id"))
+	require.True(t, ok)
+	assert.Equal(t, RouteAttributes{ActionType: "SearchAction", ObjectType: "Repository"}, route.Extract(nil, nil))
+
+	_, ok = tracer.matchRoute(newContext(http.MethodPost, "/v1/api/semantic/action"))
+	assert.False(t, ok, "configured routes replace the defaults")
+}
+
+func TestCaptureRequestBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		limit     int64
+		captured  string
+		truncated bool
+	}{
+		{"below limit", "hello", 10, "hello", false},
+		{"at limit", "hello", 5, "hello", false},
+		{"above limit", "hello world", 5, "hello", true},
+		{"empty", "", 5, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+
+			body, truncated, err := captureRequestBody(req, tt.limit)
+			require.NoError(t, err)
+			assert.Equal(t, tt.captured, string(body))
+			assert.Equal(t, tt.truncated, truncated)
+
+			// The next handler still reads the complete body
+			rest, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(rest))
+			assert.NoError(t, req.Body.Close())
+		})
+	}
+
+	t.Run("no body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		body, truncated, err := captureRequestBody(req, 5)
+		require.NoError(t, err)
+		assert.Nil(t, body)
+		assert.False(t, truncated)
+		assert.Equal(t, http.NoBody, req.Body)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(errReader{}))
+		_, _, err := captureRequestBody(req, 5)
+		assert.Error(t, err)
+	})
+}
+
+// errReader fails every read
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
+
+func TestMultipartAction(t *testing.T) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	require.NoError(t, writer.WriteField("metadata", `{"ignored":true}`))
+	require.NoError(t, writer.WriteField("action", `{"@type":"UploadAction"}`))
+	file, err := writer.CreateFormFile("file", "data.bin")
+	require.NoError(t, err)
+	_, err = file.Write(bytes.Repeat([]byte("x"), 64))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	body := buf.Bytes()
+
+	assert.JSONEq(t, `{"@type":"UploadAction"}`, string(multipartAction(writer.FormDataContentType(), body)))
+
+	// The action part is found even when the buffered prefix cuts off the file
+	cut := bytes.Index(body, []byte("data.bin"))
+	assert.JSONEq(t, `{"@type":"UploadAction"}`, string(multipartAction(writer.FormDataContentType(), body[:cut])))
+
+	// Action part beyond the buffered prefix
+	cut = bytes.Index(body, []byte(`name="action"`))
+	assert.Nil(t, multipartAction(writer.FormDataContentType(), body[:cut]))
+
+	assert.Nil(t, multipartAction("application/json", body))
+	assert.Nil(t, multipartAction("multipart/form-data", body), "boundary is required")
+	assert.Nil(t, multipartAction("multipart/form-data; boundary=other", body))
+	assert.Nil(t, multipartAction("not a media type;;", body))
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buf := &limitedBuffer{limit: 8}
+
+	n, err := buf.Write([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.False(t, buf.truncated)
+
+	n, err = buf.Write([]byte(" world"))
+	require.NoError(t, err)
+	assert.Equal(t, 6, n, "writes always report the full length")
+	assert.True(t, buf.truncated)
+	assert.Equal(t, "hello wo", buf.String())
+
+	n, err = buf.Write([]byte("!"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "hello wo", buf.String())
+
+	exact := &limitedBuffer{limit: 5}
+	_, _ = exact.Write([]byte("hello"))
+	_, _ = exact.Write(nil)
+	assert.False(t, exact.truncated, "filling the buffer exactly is not truncation")
+	assert.Equal(t, "hello", exact.String())
+}
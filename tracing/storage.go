@@ -5,28 +5,35 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"eve.evalgo.org/storage"
 )
 
-// uploadToS3 uploads data to S3 storage
-func (t *Tracer) uploadToS3(ctx context.Context, correlationID, operationID, filename string, data []byte) error {
+// payloadStore returns the object store used for trace payloads
+func (t *Tracer) payloadStore() (storage.ObjectStore, error) {
+	if t.config.ObjectStore != nil {
+		return t.config.ObjectStore, nil
+	}
 	if t.config.S3Client == nil {
-		return fmt.Errorf("S3 client not configured")
+		return nil, fmt.Errorf("S3 client not configured")
 	}
+	return storage.NewS3ObjectStoreWithClient(t.config.S3Client, t.config.S3Bucket, ""), nil
+}
 
-	// Construct S3 key: {correlation_id}/{operation_id}/{filename}
-	key := fmt.Sprintf("%s/%s/%s", correlationID, operationID, filename)
+// uploadToS3 uploads data to payload storage
+func (t *Tracer) uploadToS3(ctx context.Context, correlationID, operationID, filename string, data []byte) error {
+	// Apply PII rule actions (mask, hash, drop) before the payload leaves the process
+	if t.redactsPayloads() {
+		data, _ = t.pii.Redact(data)
+	}
 
-	// Upload to S3
-	_, err := t.config.S3Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(t.config.S3Bucket),
-		Key:         aws.String(key),
-		Body:        bytes.NewReader(data),
-		ContentType: aws.String("application/json"),
-	})
+	// Construct key: {correlation_id}/{operation_id}/{filename}
+	key := fmt.Sprintf("%s/%s/%s", correlationID, operationID, filename)
+	return t.putPayload(ctx, key, data, "application/json")
+}
 
-	return err
+// redactsPayloads reports whether stored payloads are redacted with the PII rules
+func (t *Tracer) redactsPayloads() bool {
+	return t.config.RedactPayloads && t.pii != nil
 }
 
 // uploadLogsToS3 uploads logs to S3
@@ -36,46 +43,32 @@ func (t *Tracer) UploadLogs(ctx context.Context, correlationID, operationID stri
 
 // uploadArtifactToS3 uploads build artifacts to S3
 func (t *Tracer) UploadArtifact(ctx context.Context, correlationID, operationID, artifactName string, data []byte) error {
-	if t.config.S3Client == nil {
-		return fmt.Errorf("S3 client not configured")
-	}
-
 	key := fmt.Sprintf("%s/%s/artifacts/%s", correlationID, operationID, artifactName)
+	return t.putPayload(ctx, key, data, "")
+}
 
-	_, err := t.config.S3Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(t.config.S3Bucket),
-		Key:    aws.String(key),
-		Body:   bytes.NewReader(data),
+// putPayload writes data to key in payload storage
+func (t *Tracer) putPayload(ctx context.Context, key string, data []byte, contentType string) error {
+	store, err := t.payloadStore()
+	if err != nil {
+		return err
+	}
+	_, err = store.Put(ctx, key, bytes.NewReader(data), storage.PutOptions{
+		ContentType: contentType,
+		Size:        int64(len(data)),
 	})
-
 	return err
 }
 
-// downloadFromS3 retrieves data from S3
+// downloadFromS3 retrieves data from payload storage
 func (t *Tracer) downloadFromS3(ctx context.Context, correlationID, operationID, filename string) ([]byte, error) {
-	if t.config.S3Client == nil {
-		return nil, fmt.Errorf("S3 client not configured")
-	}
-
-	key := fmt.Sprintf("%s/%s/%s", correlationID, operationID, filename)
-
-	result, err := t.config.S3Client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(t.config.S3Bucket),
-		Key:    aws.String(key),
-	})
+	store, err := t.payloadStore()
 	if err != nil {
 		return nil, err
 	}
-	defer result.Body.Close()
 
-	// Read response body
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(result.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
+	key := fmt.Sprintf("%s/%s/%s", correlationID, operationID, filename)
+	return storage.GetBytes(ctx, store, key)
 }
 
 // GetRequest retrieves the full request JSON-LD from S3
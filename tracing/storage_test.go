@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eve.evalgo.org/storage"
+)
+
+// TestDeletePayloadsUsesObjectStore tests that erased payloads are removed from the configured object store
+func TestDeletePayloadsUsesObjectStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalObjectStore(t.TempDir())
+	require.NoError(t, err)
+	tracer := &Tracer{config: Config{ObjectStore: store}}
+
+	require.NoError(t, tracer.UploadLogs(ctx, "corr-1", "op-1", []byte("log line")))
+	require.NoError(t, tracer.UploadLogs(ctx, "corr-1", "op-2", []byte("kept")))
+
+	require.NoError(t, tracer.deletePayloads(ctx, []string{
+		"s3://traces/corr-1/op-1/logs.txt",
+		"not-a-url",
+		"s3://traces/corr-1/missing/logs.txt",
+	}))
+
+	_, err = store.Stat(ctx, "corr-1/op-1/logs.txt")
+	assert.True(t, errors.Is(err, storage.ErrObjectNotFound))
+
+	logs, err := tracer.GetLogs(ctx, "corr-1", "op-2")
+	require.NoError(t, err)
+	assert.Equal(t, "kept", string(logs))
+
+	assert.Error(t, (&Tracer{}).deletePayloads(ctx, []string{"s3://traces/a/b"}), "no payload storage configured")
+}
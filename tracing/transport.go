@@ -0,0 +1,254 @@
+// Package tracing - Outgoing HTTP instrumentation
+package tracing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxActionTypeBody bounds the request body read to detect the action @type
+const maxActionTypeBody = 1 << 20
+
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefaultTracer sets the tracer used by Transport instances without an explicit Tracer.
+// Init registers the tracer it creates; pass nil to stop recording client spans.
+func SetDefaultTracer(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// DefaultTracer returns the tracer registered with SetDefaultTracer (may be nil)
+func DefaultTracer() *Tracer {
+	return defaultTracer.Load()
+}
+
+// Transport is an http.RoundTripper that propagates trace context to downstream services.
+//
+// For every request it:
+//   - Injects X-Correlation-ID, X-Operation-ID and X-Parent-Operation-ID from the
+//     request context (see ContextWithTraceIDs; the echo Middleware sets them)
+//   - Starts an OpenTelemetry client span and injects the W3C traceparent header
+//   - Records a client-side action_executions row (duration, status, error) when a
+//     tracer is available and the request belongs to a correlated workflow
+//
+// The span and the recorded duration cover the response body: they end when the
+// body is read to EOF or closed, or right away on errors and bodyless responses.
+// Headers already set by the caller are left untouched. Transport is installed by
+// default in transport.Manager, http.Execute and executor.HTTPExecutor.
+type Transport struct {
+	// Base performs the actual request (default http.DefaultTransport)
+	Base http.RoundTripper
+
+	// Tracer records client spans (default DefaultTracer())
+	Tracer *Tracer
+}
+
+// NewTransport wraps base with trace propagation
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// Transport wraps base with trace propagation that records client spans with this tracer
+func (t *Tracer) Transport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base, Tracer: t}
+}
+
+// RoundTrip implements http.RoundTripper
+func (tr *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := tr.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	tracer := tr.Tracer
+	if tracer == nil {
+		tracer = DefaultTracer()
+	}
+
+	ctx := req.Context()
+	ctx, span := otel.Tracer("eve.evalgo.org/tracing").Start(ctx, "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.String()),
+		))
+
+	// A RoundTripper must not modify the caller's request
+	out := req.Clone(ctx)
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(out.Header))
+
+	correlationID := out.Header.Get("X-Correlation-ID")
+	if correlationID == "" {
+		correlationID = GetCorrelationIDFromContext(ctx)
+	}
+	parentOperationID := GetOperationIDFromContext(ctx)
+
+	record := correlationID != "" && tracer != nil && tracer.config.Enabled && tracer.config.DB != nil
+	clientOperationID := ""
+	if record {
+		clientOperationID = fmt.Sprintf("op-%s", uuid.New().String()[:8])
+	}
+
+	if correlationID != "" {
+		setHeaderDefault(out.Header, "X-Correlation-ID", correlationID)
+		setHeaderDefault(out.Header, "X-Operation-ID", fmt.Sprintf("op-%s", uuid.New().String()[:8]))
+		// The client span (if recorded) sits between the caller and the downstream operation
+		if clientOperationID != "" {
+			setHeaderDefault(out.Header, "X-Parent-Operation-ID", clientOperationID)
+		} else if parentOperationID != "" {
+			setHeaderDefault(out.Header, "X-Parent-Operation-ID", parentOperationID)
+		}
+	}
+
+	actionType, objectType := "Unknown", "Unknown"
+	if record {
+		actionType, objectType = requestActionTypes(out)
+		record = tracer.shouldTrace(actionType, objectType)
+	}
+
+	startTime := time.Now()
+	resp, err := base.RoundTrip(out)
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	}
+
+	// finish ends the span and records the client action once the exchange is over
+	finish := func(bodyErr error) {
+		duration := time.Since(startTime)
+		defer span.End()
+
+		var errorMsg, errorType string
+		switch {
+		case err != nil:
+			errorMsg = err.Error()
+			errorType = fmt.Sprintf("%T", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		case statusCode >= 400:
+			errorMsg = fmt.Sprintf("HTTP %d", statusCode)
+			errorType = "HTTPError"
+			span.SetStatus(codes.Error, errorMsg)
+		case bodyErr != nil:
+			errorMsg = bodyErr.Error()
+			errorType = fmt.Sprintf("%T", bodyErr)
+			span.RecordError(bodyErr)
+			span.SetStatus(codes.Error, bodyErr.Error())
+		}
+
+		if !record {
+			return
+		}
+
+		actionStatus := "CompletedActionStatus"
+		if errorMsg != "" {
+			actionStatus = "FailedActionStatus"
+		}
+
+		rec := traceRecord{
+			correlationID:     correlationID,
+			operationID:       clientOperationID,
+			parentOperationID: parentOperationID,
+			actionType:        actionType,
+			objectType:        objectType,
+			startTime:         startTime,
+			duration:          duration,
+			actionStatus:      actionStatus,
+			statusCode:        statusCode,
+			errorMsg:          errorMsg,
+			errorType:         errorType,
+			endpoint:          out.URL.Scheme + "://" + out.URL.Host + out.URL.Path,
+			httpMethod:        out.Method,
+			userAgent:         out.UserAgent(),
+			clientSpan:        true,
+		}
+		if sc := span.SpanContext(); sc.IsValid() {
+			rec.otelTraceID = sc.TraceID().String()
+			rec.otelSpanID = sc.SpanID().String()
+		}
+		tracer.exportTrace(rec)
+	}
+
+	// The exchange lasts until the body is consumed; upgraded connections
+	// (101) keep their writable body and are finished right away
+	if err != nil || resp.Body == nil || resp.Body == http.NoBody || statusCode == http.StatusSwitchingProtocols {
+		finish(nil)
+		return resp, err
+	}
+	resp.Body = &tracedBody{ReadCloser: resp.Body, finish: finish}
+	return resp, nil
+}
+
+// tracedBody finishes the client span when the response body reaches EOF,
+// fails or is closed, whichever happens first
+type tracedBody struct {
+	io.ReadCloser
+	once   sync.Once
+	finish func(bodyErr error)
+}
+
+// Read reads from the response body, finishing the span at EOF or on error
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	switch {
+	case err == io.EOF:
+		b.once.Do(func() { b.finish(nil) })
+	case err != nil:
+		b.once.Do(func() { b.finish(err) })
+	}
+	return n, err
+}
+
+// Close closes the response body and finishes the span if still open
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.finish(nil) })
+	return err
+}
+
+// WrapClient installs a Transport on client, keeping its existing transport as base.
+// Clients that already use a Transport are returned unchanged.
+func WrapClient(client *http.Client) *http.Client {
+	if _, ok := client.Transport.(*Transport); !ok {
+		client.Transport = NewTransport(client.Transport)
+	}
+	return client
+}
+
+// setHeaderDefault sets a header unless the caller already set it
+func setHeaderDefault(header http.Header, key, value string) {
+	if header.Get(key) == "" {
+		header.Set(key, value)
+	}
+}
+
+// requestActionTypes detects the JSON-LD action and object types of a replayable request body
+func requestActionTypes(req *http.Request) (actionType, objectType string) {
+	if req.GetBody == nil || req.ContentLength <= 0 || req.ContentLength > maxActionTypeBody {
+		return "Unknown", "Unknown"
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "Unknown", "Unknown"
+	}
+	defer body.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(body, maxActionTypeBody)); err != nil {
+		return "Unknown", "Unknown"
+	}
+	return parseActionTypes(buf.Bytes())
+}
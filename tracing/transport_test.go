@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// recordSpans installs a span recorder as the global tracer provider for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestTransportEndsSpanWithResponseBody(t *testing.T) {
+	recorder := recordSpans(t)
+	transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("payload")), Request: req}, nil
+	}))
+
+	t.Run("read to EOF", func(t *testing.T) {
+		recorder.Reset()
+		resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.test/", nil))
+		require.NoError(t, err)
+		assert.Empty(t, recorder.Ended(), "span stays open until the body is consumed")
+
+		time.Sleep(20 * time.Millisecond)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "payload", string(body))
+		require.Len(t, recorder.Ended(), 1)
+
+		span := recorder.Ended()[0]
+		assert.GreaterOrEqual(t, span.EndTime().Sub(span.StartTime()), 20*time.Millisecond)
+		assert.Equal(t, codes.Unset, span.Status().Code)
+
+		require.NoError(t, resp.Body.Close())
+		assert.Len(t, recorder.Ended(), 1, "closing after EOF does not end the span again")
+	})
+
+	t.Run("closed early", func(t *testing.T) {
+		recorder.Reset()
+		resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.test/", nil))
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Len(t, recorder.Ended(), 1)
+	})
+}
+
+func TestTransportEndsSpanImmediately(t *testing.T) {
+	recorder := recordSpans(t)
+
+	tests := []struct {
+		name   string
+		resp   *http.Response
+		err    error
+		status codes.Code
+	}{
+		{"transport error", nil, errors.New("connection refused"), codes.Error},
+		{"no body", &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil, codes.Unset},
+		{"nil body", &http.Response{StatusCode: http.StatusNotFound}, nil, codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return tt.resp, tt.err
+			}))
+
+			resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.test/", nil))
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.resp, resp)
+			require.Len(t, recorder.Ended(), 1)
+			assert.Equal(t, tt.status, recorder.Ended()[0].Status().Code)
+		})
+	}
+}
+
+func TestTransportRecordsBodyReadError(t *testing.T) {
+	recorder := recordSpans(t)
+	transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(errReader{})}, nil
+	}))
+
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.test/", nil))
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.NoError(t, resp.Body.Close())
+
+	require.Len(t, recorder.Ended(), 1)
+	assert.Equal(t, codes.Error, recorder.Ended()[0].Status().Code)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+
+	"eve.evalgo.org/tracing"
 )
 
 // Manager manages multiple transports and routes requests based on URL scheme.
@@ -99,10 +101,11 @@ func (m *Manager) RoundTrip(req *http.Request) (*http.Response, error) {
 	return transport.RoundTrip(req)
 }
 
-// Client creates an http.Client that uses this transport manager
+// Client creates an http.Client that uses this transport manager.
+// Requests propagate correlation IDs and W3C trace context (see tracing.Transport).
 func (m *Manager) Client(timeout int) *http.Client {
 	return &http.Client{
-		Transport: m,
+		Transport: tracing.NewTransport(m),
 		// Note: timeout is handled by individual transports
 	}
 }
@@ -1,48 +1,24 @@
 package workflow
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
 
 	"eve.evalgo.org/semantic"
-	"github.com/google/uuid"
 )
 
 // Expander converts workflow definitions into executable semantic actions
 
 // ExpandToActions converts a WorkflowDefinition into a list of SemanticScheduledActions
+// Sub-workflow steps require a resolver; use Expand with ExpandOptions for those.
 func ExpandToActions(workflow *semantic.WorkflowDefinition) ([]*semantic.SemanticScheduledAction, error) {
-	// Generate unique instance ID for this workflow run
-	instanceID := uuid.New().String()
-	fmt.Fprintf(os.Stderr, "DEBUG expander: Generated workflow instance ID: %s\n", instanceID)
-
-	var actions []*semantic.SemanticScheduledAction
-
-	for _, workflowAction := range workflow.Actions {
-		switch workflowAction.Type {
-		case "action":
-			// Single action → single action (with merged deps)
-			action, err := mergeActionDependencies(workflowAction.Action, workflowAction.DependsOn, instanceID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to process action '%s': %w", workflowAction.Action.Identifier, err)
-			}
-			actions = append(actions, action)
-
-		case "loop":
-			// Loop (ItemList) → multiple actions
-			loopActions, err := expandLoop(workflowAction.Loop, workflowAction.DependsOn, instanceID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to expand loop '%s': %w", workflowAction.Loop.Identifier, err)
-			}
-			actions = append(actions, loopActions...)
-
-		default:
-			return nil, fmt.Errorf("unsupported action type: %s", workflowAction.Type)
-		}
+	expansion, err := Expand(context.Background(), workflow, ExpandOptions{})
+	if err != nil {
+		return nil, err
 	}
-
-	return actions, nil
+	return expansion.Actions, nil
 }
 
 // prefixIdentifier prefixes an identifier with the workflow instance ID
@@ -186,6 +186,21 @@ func parseStepElement(element interface{}, position int) (*semantic.WorkflowActi
 			Position: position,
 		}, nil
 
+	case semantic.SubWorkflowActionType:
+		var invocation semantic.SemanticWorkflowInvocation
+		if err := json.Unmarshal(data, &invocation); err != nil {
+			return nil, fmt.Errorf("failed to parse SubWorkflowAction: %w", err)
+		}
+		if invocation.Workflow == "" {
+			return nil, fmt.Errorf("SubWorkflowAction must reference a workflow via exampleOfWork")
+		}
+		return &semantic.WorkflowAction{
+			Type:        "subworkflow",
+			SubWorkflow: &invocation,
+			DependsOn:   invocation.Requires,
+			Position:    position,
+		}, nil
+
 	default:
 		return nil, fmt.Errorf("unsupported step element type: %s", typeDetector.Type)
 	}
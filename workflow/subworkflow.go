@@ -0,0 +1,418 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"eve.evalgo.org/db/repository"
+	"eve.evalgo.org/semantic"
+	"eve.evalgo.org/semantic/runtime"
+	"github.com/google/uuid"
+)
+
+// Sub-workflow expansion: a workflow step of @type SubWorkflowAction references a
+// stored workflow template. During expansion the template is loaded through a
+// WorkflowResolver, its ${parameter} references are substituted, and its actions
+// are prefixed with a fresh instance ID. The child instance records the calling
+// instance as parent and the top-level instance as root; with a LineageRecorder
+// the lineage of every instance is persisted so cancellation can reach children.
+
+// DefaultMaxSubWorkflowDepth limits how deeply sub-workflows may nest
+const DefaultMaxSubWorkflowDepth = 10
+
+// WorkflowResolver loads stored workflow templates by identifier
+type WorkflowResolver interface {
+	ResolveWorkflow(ctx context.Context, templateID string) (*semantic.WorkflowDefinition, error)
+}
+
+// WorkflowResolverFunc adapts a function to the WorkflowResolver interface
+type WorkflowResolverFunc func(ctx context.Context, templateID string) (*semantic.WorkflowDefinition, error)
+
+// ResolveWorkflow calls f(ctx, templateID)
+func (f WorkflowResolverFunc) ResolveWorkflow(ctx context.Context, templateID string) (*semantic.WorkflowDefinition, error) {
+	return f(ctx, templateID)
+}
+
+// LineageRecorder is the subset of repository.WorkflowLineageRepository used here
+type LineageRecorder interface {
+	LinkWorkflowLineage(ctx context.Context, lineage repository.WorkflowLineage) error
+}
+
+// ExpandOptions configures workflow expansion
+type ExpandOptions struct {
+	Resolver         WorkflowResolver       // Required when the workflow contains sub-workflow steps
+	Lineage          LineageRecorder        // Persists parent/root lineage of every instance (optional)
+	InstanceID       string                 // Instance ID for this run (generated if empty)
+	ParentWorkflowID string                 // Instance ID of the calling workflow (empty for top-level runs)
+	RootWorkflowID   string                 // Instance ID of the top-level workflow (defaults to InstanceID)
+	Parameters       map[string]interface{} // Values substituted into ${name} references
+	MaxDepth         int                    // Maximum sub-workflow nesting (default DefaultMaxSubWorkflowDepth)
+}
+
+// Expansion is the result of expanding one workflow instance
+// Actions contains the actions of this instance and of all its sub-workflows,
+// with dependencies already rewired across instance boundaries.
+type Expansion struct {
+	InstanceID       string
+	TemplateID       string
+	ParentWorkflowID string
+	RootWorkflowID   string
+	ParentActionID   string // Sub-workflow step in the parent that created this instance
+	Actions          []*semantic.SemanticScheduledAction
+	Children         []*Expansion
+}
+
+// Walk visits this expansion and all nested sub-workflow expansions, parents first
+func (e *Expansion) Walk(fn func(*Expansion) error) error {
+	if err := fn(e); err != nil {
+		return err
+	}
+	for _, child := range e.Children {
+		if err := child.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DescendantIDs returns the instance IDs of all nested sub-workflows
+func (e *Expansion) DescendantIDs() []string {
+	var ids []string
+	_ = e.Walk(func(x *Expansion) error {
+		if x != e {
+			ids = append(ids, x.InstanceID)
+		}
+		return nil
+	})
+	return ids
+}
+
+// Expand converts a WorkflowDefinition into semantic actions, expanding
+// sub-workflow steps through opts.Resolver
+func Expand(ctx context.Context, workflow *semantic.WorkflowDefinition, opts ExpandOptions) (*Expansion, error) {
+	if workflow == nil {
+		return nil, fmt.Errorf("workflow is nil")
+	}
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = DefaultMaxSubWorkflowDepth
+	}
+	if len(opts.Parameters) > 0 {
+		substituted, err := SubstituteParameters(workflow, opts.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		workflow = substituted
+	}
+	expansion, err := expandWorkflow(ctx, workflow, opts, 0, map[string]bool{workflow.ID: true})
+	if err != nil {
+		return nil, err
+	}
+	if opts.Lineage != nil {
+		if err := RecordLineage(ctx, opts.Lineage, expansion); err != nil {
+			return nil, err
+		}
+	}
+	return expansion, nil
+}
+
+// RecordLineage persists the lineage of an expansion and all nested
+// sub-workflow instances, parents first. Expand calls it when
+// ExpandOptions.Lineage is set.
+func RecordLineage(ctx context.Context, recorder LineageRecorder, expansion *Expansion) error {
+	createdAt := time.Now()
+	return expansion.Walk(func(e *Expansion) error {
+		err := recorder.LinkWorkflowLineage(ctx, repository.WorkflowLineage{
+			InstanceID:       e.InstanceID,
+			TemplateID:       e.TemplateID,
+			ParentWorkflowID: e.ParentWorkflowID,
+			RootWorkflowID:   e.RootWorkflowID,
+			ActionID:         e.ParentActionID,
+			CreatedAt:        createdAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record lineage of workflow instance %s: %w", e.InstanceID, err)
+		}
+		return nil
+	})
+}
+
+// expandWorkflow expands one workflow instance and recurses into sub-workflows
+func expandWorkflow(ctx context.Context, workflow *semantic.WorkflowDefinition, opts ExpandOptions, depth int, visiting map[string]bool) (*Expansion, error) {
+	// Generate unique instance ID for this workflow run
+	instanceID := opts.InstanceID
+	if instanceID == "" {
+		instanceID = uuid.New().String()
+	}
+
+	rootID := opts.RootWorkflowID
+	if rootID == "" {
+		rootID = instanceID
+	}
+
+	expansion := &Expansion{
+		InstanceID:       instanceID,
+		TemplateID:       workflow.ID,
+		ParentWorkflowID: opts.ParentWorkflowID,
+		RootWorkflowID:   rootID,
+	}
+
+	// Prefixed sub-workflow step ID → exit actions of the expanded child
+	subWorkflowExits := make(map[string][]string)
+
+	for _, workflowAction := range workflow.Actions {
+		switch workflowAction.Type {
+		case "action":
+			// Single action → single action (with merged deps)
+			action, err := mergeActionDependencies(workflowAction.Action, workflowAction.DependsOn, instanceID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process action '%s': %w", workflowAction.Action.Identifier, err)
+			}
+			expansion.Actions = append(expansion.Actions, action)
+
+		case "loop":
+			// Loop (ItemList) → multiple actions
+			loopActions, err := expandLoop(workflowAction.Loop, workflowAction.DependsOn, instanceID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand loop '%s': %w", workflowAction.Loop.Identifier, err)
+			}
+			expansion.Actions = append(expansion.Actions, loopActions...)
+
+		case "subworkflow":
+			// Sub-workflow → expanded child instance
+			invocation := workflowAction.SubWorkflow
+			if invocation == nil {
+				return nil, fmt.Errorf("sub-workflow step at position %d has no invocation", workflowAction.Position)
+			}
+			stepID := invocation.Identifier
+			if stepID == "" {
+				stepID = invocation.ID
+			}
+			if stepID == "" {
+				return nil, fmt.Errorf("sub-workflow step at position %d has no identifier", workflowAction.Position)
+			}
+
+			child, err := expandSubWorkflow(ctx, invocation, opts, instanceID, rootID, depth, visiting)
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand sub-workflow '%s': %w", stepID, err)
+			}
+			child.ParentActionID = prefixIdentifier(instanceID, stepID)
+
+			// Entry actions of the child wait for the step's own dependencies
+			var stepDeps []string
+			for _, dep := range uniqueStrings(append(append([]string{}, invocation.Requires...), workflowAction.DependsOn...)) {
+				stepDeps = append(stepDeps, prefixIdentifier(instanceID, dep))
+			}
+			for _, action := range child.Actions {
+				if len(action.Requires) == 0 {
+					action.Requires = append([]string{}, stepDeps...)
+				}
+			}
+
+			subWorkflowExits[prefixIdentifier(instanceID, stepID)] = exitActions(child.Actions)
+			expansion.Children = append(expansion.Children, child)
+			expansion.Actions = append(expansion.Actions, child.Actions...)
+
+		default:
+			return nil, fmt.Errorf("unsupported action type: %s", workflowAction.Type)
+		}
+	}
+
+	// Actions that depend on a sub-workflow step wait for all of its exit actions
+	if len(subWorkflowExits) > 0 {
+		for _, action := range expansion.Actions {
+			var requires []string
+			for _, dep := range action.Requires {
+				if exits, ok := subWorkflowExits[dep]; ok {
+					requires = append(requires, exits...)
+					continue
+				}
+				requires = append(requires, dep)
+			}
+			action.Requires = uniqueStrings(requires)
+		}
+	}
+
+	// Record lineage on actions owned by this instance
+	for _, action := range expansion.Actions {
+		if action.WorkflowGroup == "" {
+			action.WorkflowGroup = instanceID
+		}
+		if action.PartOf == "" {
+			action.PartOf = instanceID
+		}
+		if action.InstanceOf == "" && workflow.ID != "" {
+			action.InstanceOf = workflow.ID
+		}
+	}
+
+	return expansion, nil
+}
+
+// expandSubWorkflow resolves, parameterizes and expands the template referenced by invocation
+func expandSubWorkflow(ctx context.Context, invocation *semantic.SemanticWorkflowInvocation, opts ExpandOptions, parentID, rootID string, depth int, visiting map[string]bool) (*Expansion, error) {
+	if opts.Resolver == nil {
+		return nil, fmt.Errorf("no workflow resolver configured")
+	}
+	if depth+1 > opts.MaxDepth {
+		return nil, fmt.Errorf("sub-workflow nesting exceeds max depth %d", opts.MaxDepth)
+	}
+	if visiting[invocation.Workflow] {
+		return nil, fmt.Errorf("recursive sub-workflow reference to '%s'", invocation.Workflow)
+	}
+
+	template, params, err := resolveSubWorkflow(ctx, opts.Resolver, invocation)
+	if err != nil {
+		return nil, err
+	}
+
+	// Always work on a copy so cached templates are never modified
+	template, err = SubstituteParameters(template, params)
+	if err != nil {
+		return nil, err
+	}
+
+	visiting[invocation.Workflow] = true
+	defer delete(visiting, invocation.Workflow)
+
+	child, err := expandWorkflow(ctx, template, ExpandOptions{
+		Resolver:         opts.Resolver,
+		ParentWorkflowID: parentID,
+		RootWorkflowID:   rootID,
+		MaxDepth:         opts.MaxDepth,
+	}, depth+1, visiting)
+	if err != nil {
+		return nil, err
+	}
+	child.TemplateID = invocation.Workflow
+	return child, nil
+}
+
+// resolveSubWorkflow loads the workflow referenced by invocation and returns it
+// with the parameters to substitute. With a TemplateWorkflowResolver the
+// invocation parameters are validated against the template parameter schema
+// and defaults are applied; other resolvers get the parameters as supplied.
+func resolveSubWorkflow(ctx context.Context, resolver WorkflowResolver, invocation *semantic.SemanticWorkflowInvocation) (*semantic.WorkflowDefinition, map[string]interface{}, error) {
+	templates, ok := resolver.(TemplateWorkflowResolver)
+	if !ok {
+		workflow, err := resolver.ResolveWorkflow(ctx, invocation.Workflow)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve workflow '%s': %w", invocation.Workflow, err)
+		}
+		if workflow == nil {
+			return nil, nil, fmt.Errorf("workflow '%s' not found", invocation.Workflow)
+		}
+		return workflow, invocation.Parameters, nil
+	}
+
+	template, err := templates.ResolveTemplate(ctx, invocation.Workflow)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve workflow '%s': %w", invocation.Workflow, err)
+	}
+	if template == nil {
+		return nil, nil, fmt.Errorf("workflow '%s' not found", invocation.Workflow)
+	}
+	params, err := template.ValidateParameters(invocation.Parameters)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid parameters for workflow '%s': %w", invocation.Workflow, err)
+	}
+	workflow, err := ParseTemplate(template)
+	if err != nil {
+		return nil, nil, err
+	}
+	return workflow, params, nil
+}
+
+// SubstituteParameters returns a deep copy of workflow with ${name} references
+// replaced by the matching parameter values. References that do not name a
+// parameter (e.g. ${action-id.result.field}) are left for runtime resolution.
+// String values are inserted as they are; numbers, booleans, objects and
+// arrays are inserted as JSON.
+func SubstituteParameters(workflow *semantic.WorkflowDefinition, params map[string]interface{}) (*semantic.WorkflowDefinition, error) {
+	data, err := json.Marshal(workflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy workflow: %w", err)
+	}
+
+	if len(params) > 0 {
+		replacements := make(map[string]string, len(params))
+		for name, param := range params {
+			text, err := parameterText(param)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for parameter '%s': %w", name, err)
+			}
+			replacements["${"+name+"}"] = text
+		}
+
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return nil, fmt.Errorf("failed to copy workflow: %w", err)
+		}
+		substituted, err := runtime.WalkJSON(generic, func(value string) (string, error) {
+			if !strings.Contains(value, "${") {
+				return value, nil
+			}
+			for reference, text := range replacements {
+				value = strings.ReplaceAll(value, reference, text)
+			}
+			return value, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("parameter substitution failed: %w", err)
+		}
+		if data, err = json.Marshal(substituted); err != nil {
+			return nil, fmt.Errorf("failed to copy workflow: %w", err)
+		}
+	}
+
+	var copied semantic.WorkflowDefinition
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("failed to copy workflow: %w", err)
+	}
+	return &copied, nil
+}
+
+// parameterText formats a parameter value for substitution into a string
+func parameterText(value interface{}) (string, error) {
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// exitActions returns the identifiers of actions no other action depends on
+func exitActions(actions []*semantic.SemanticScheduledAction) []string {
+	required := make(map[string]bool)
+	for _, action := range actions {
+		for _, dep := range action.Requires {
+			required[dep] = true
+		}
+	}
+
+	var exits []string
+	for _, action := range actions {
+		if !required[action.Identifier] {
+			exits = append(exits, action.Identifier)
+		}
+	}
+	return exits
+}
+
+// uniqueStrings removes duplicates while preserving order
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,287 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"eve.evalgo.org/db/repository"
+	"eve.evalgo.org/semantic"
+)
+
+type fakeLineage []repository.WorkflowLineage
+
+func (f *fakeLineage) LinkWorkflowLineage(ctx context.Context, lineage repository.WorkflowLineage) error {
+	*f = append(*f, lineage)
+	return nil
+}
+
+func mustParse(t *testing.T, doc string) *semantic.WorkflowDefinition {
+	t.Helper()
+	wf, err := ParseWorkflow([]byte(doc))
+	if err != nil {
+		t.Fatalf("failed to parse workflow: %v", err)
+	}
+	return wf
+}
+
+func templateResolver(t *testing.T, docs map[string]string) WorkflowResolver {
+	return WorkflowResolverFunc(func(ctx context.Context, templateID string) (*semantic.WorkflowDefinition, error) {
+		doc, ok := docs[templateID]
+		if !ok {
+			return nil, fmt.Errorf("template %s not found", templateID)
+		}
+		return mustParse(t, doc), nil
+	})
+}
+
+const parentWorkflow = `{
+	"@type": "HowTo", "identifier": "parent",
+	"step": [
+		{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "fetch"}},
+		{"@type": "HowToStep", "itemListElement": {"@type": "SubWorkflowAction", "identifier": "process",
+			"exampleOfWork": "child", "requires": ["fetch"], "additionalProperty": {"target": "archive"}}},
+		{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "notify", "requires": ["process"]}}
+	]
+}`
+
+const childWorkflow = `{
+	"@type": "HowTo", "identifier": "child",
+	"step": [
+		{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "transform",
+			"additionalProperty": {"output": "${target}", "input": "${fetch.result.contentUrl}"}}},
+		{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "store", "requires": ["transform"]}}
+	]
+}`
+
+func actionsByID(actions []*semantic.SemanticScheduledAction) map[string]*semantic.SemanticScheduledAction {
+	byID := make(map[string]*semantic.SemanticScheduledAction, len(actions))
+	for _, action := range actions {
+		byID[action.Identifier] = action
+	}
+	return byID
+}
+
+func TestExpand_SubWorkflow(t *testing.T) {
+	resolver := templateResolver(t, map[string]string{"child": childWorkflow})
+	expansion, err := Expand(context.Background(), mustParse(t, parentWorkflow), ExpandOptions{
+		Resolver:   resolver,
+		InstanceID: "p",
+	})
+	if err != nil {
+		t.Fatalf("expand failed: %v", err)
+	}
+
+	if len(expansion.Children) != 1 {
+		t.Fatalf("expected 1 child expansion, got %d", len(expansion.Children))
+	}
+	child := expansion.Children[0]
+	if child.TemplateID != "child" || child.ParentWorkflowID != "p" || child.RootWorkflowID != "p" {
+		t.Fatalf("unexpected child lineage: %+v", child)
+	}
+	if child.ParentActionID != "p--process" {
+		t.Fatalf("expected parent action p--process, got %q", child.ParentActionID)
+	}
+	if ids := expansion.DescendantIDs(); !reflect.DeepEqual(ids, []string{child.InstanceID}) {
+		t.Fatalf("unexpected descendants: %v", ids)
+	}
+
+	actions := actionsByID(expansion.Actions)
+	if len(actions) != 4 {
+		t.Fatalf("expected 4 actions, got %d", len(actions))
+	}
+
+	transform := actions[child.InstanceID+"--transform"]
+	if transform == nil {
+		t.Fatalf("child action transform missing: %v", actions)
+	}
+	if !reflect.DeepEqual(transform.Requires, []string{"p--fetch"}) {
+		t.Fatalf("entry action should wait for the step dependencies, got %v", transform.Requires)
+	}
+	if transform.PartOf != child.InstanceID || transform.WorkflowGroup != child.InstanceID {
+		t.Fatalf("child action should belong to the child instance, got partOf=%q group=%q", transform.PartOf, transform.WorkflowGroup)
+	}
+
+	notify := actions["p--notify"]
+	if !reflect.DeepEqual(notify.Requires, []string{child.InstanceID + "--store"}) {
+		t.Fatalf("dependency on the step should wait for the child exit actions, got %v", notify.Requires)
+	}
+	if notify.PartOf != "p" || notify.InstanceOf != "parent" {
+		t.Fatalf("parent action lineage wrong: partOf=%q instanceOf=%q", notify.PartOf, notify.InstanceOf)
+	}
+}
+
+func TestExpand_RecordsLineage(t *testing.T) {
+	var lineage fakeLineage
+	expansion, err := Expand(context.Background(), mustParse(t, parentWorkflow), ExpandOptions{
+		Resolver:   templateResolver(t, map[string]string{"child": childWorkflow}),
+		Lineage:    &lineage,
+		InstanceID: "p",
+	})
+	if err != nil {
+		t.Fatalf("expand failed: %v", err)
+	}
+
+	if len(lineage) != 2 {
+		t.Fatalf("expected 2 lineage records, got %+v", lineage)
+	}
+	if lineage[0].InstanceID != "p" || lineage[0].ParentWorkflowID != "" || lineage[0].RootWorkflowID != "p" {
+		t.Fatalf("unexpected root lineage: %+v", lineage[0])
+	}
+	childID := expansion.Children[0].InstanceID
+	want := repository.WorkflowLineage{
+		InstanceID:       childID,
+		TemplateID:       "child",
+		ParentWorkflowID: "p",
+		RootWorkflowID:   "p",
+		ActionID:         "p--process",
+		CreatedAt:        lineage[1].CreatedAt,
+	}
+	if !reflect.DeepEqual(lineage[1], want) {
+		t.Fatalf("unexpected child lineage:\n got %+v\nwant %+v", lineage[1], want)
+	}
+}
+
+func TestExpand_Errors(t *testing.T) {
+	recursive := `{
+		"@type": "HowTo", "identifier": "loop",
+		"step": [{"@type": "HowToStep", "itemListElement": {"@type": "SubWorkflowAction", "identifier": "again", "exampleOfWork": "loop"}}]
+	}`
+	nested := `{
+		"@type": "HowTo", "identifier": "outer",
+		"step": [{"@type": "HowToStep", "itemListElement": {"@type": "SubWorkflowAction", "identifier": "inner", "exampleOfWork": "parent"}}]
+	}`
+	resolver := templateResolver(t, map[string]string{"loop": recursive, "parent": parentWorkflow, "child": childWorkflow})
+
+	tests := []struct {
+		name string
+		doc  string
+		opts ExpandOptions
+		want string
+	}{
+		{"missing resolver", parentWorkflow, ExpandOptions{}, "no workflow resolver configured"},
+		{"unknown template", parentWorkflow, ExpandOptions{Resolver: templateResolver(t, nil)}, "failed to resolve workflow 'child'"},
+		{"recursion", recursive, ExpandOptions{Resolver: resolver}, "recursive sub-workflow reference to 'loop'"},
+		{"max depth", nested, ExpandOptions{Resolver: resolver, MaxDepth: 1}, "exceeds max depth 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lineage fakeLineage
+			tt.opts.Lineage = &lineage
+			_, err := Expand(context.Background(), mustParse(t, tt.doc), tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+			if len(lineage) != 0 {
+				t.Fatalf("failed expansion must not record lineage, got %+v", lineage)
+			}
+		})
+	}
+}
+
+func TestSubstituteParameters(t *testing.T) {
+	original := mustParse(t, childWorkflow)
+
+	substituted, err := SubstituteParameters(original, map[string]interface{}{"target": "archive", "unused": 1})
+	if err != nil {
+		t.Fatalf("substitution failed: %v", err)
+	}
+
+	props := substituted.Actions[0].Action.Properties
+	if props["output"] != "archive" {
+		t.Fatalf("expected ${target} to be replaced, got %v", props["output"])
+	}
+	if props["input"] != "${fetch.result.contentUrl}" {
+		t.Fatalf("runtime references must be left alone, got %v", props["input"])
+	}
+	if original.Actions[0].Action.Properties["output"] != "${target}" {
+		t.Fatal("substitution must not modify the original workflow")
+	}
+
+	structured, err := SubstituteParameters(original, map[string]interface{}{
+		"target": map[string]interface{}{"bucket": "archive", "keys": []interface{}{"a", 1}},
+	})
+	if err != nil {
+		t.Fatalf("substitution failed: %v", err)
+	}
+	if got := structured.Actions[0].Action.Properties["output"]; got != `{"bucket":"archive","keys":["a",1]}` {
+		t.Fatalf("expected structured value as JSON, got %v", got)
+	}
+	if _, err := SubstituteParameters(original, map[string]interface{}{"target": func() {}}); err == nil {
+		t.Fatal("expected error for a value that cannot be marshaled")
+	}
+
+	copied, err := SubstituteParameters(original, nil)
+	if err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	if copied == original || copied.Actions[0].Action == original.Actions[0].Action {
+		t.Fatal("expected a deep copy without parameters")
+	}
+}
+
+func TestExpand_SubWorkflowTemplateParameters(t *testing.T) {
+	ctx := context.Background()
+	store := fakeTemplateStore{}
+	child := &semantic.SemanticWorkflowTemplate{
+		Type:       semantic.WorkflowTemplateType,
+		Identifier: "child",
+		Name:       "Child",
+		Parameters: []semantic.WorkflowParameterSpec{
+			param("target", "Text", true, nil),
+			param("retries", "Integer", false, 3),
+			param("options", "StructuredValue", false, nil),
+		},
+		Workflow: []byte(`{
+			"@type": "HowTo", "identifier": "child",
+			"step": [
+				{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "transform",
+					"additionalProperty": {"output": "${target}", "retries": "${retries}", "options": "${options}"}}}
+			]
+		}`),
+	}
+	if _, err := store.SaveTemplateVersion(ctx, child); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	invoke := func(params string) (*Expansion, error) {
+		return Expand(ctx, mustParse(t, `{
+			"@type": "HowTo", "identifier": "parent",
+			"step": [
+				{"@type": "HowToStep", "itemListElement": {"@type": "SubWorkflowAction", "identifier": "process",
+					"exampleOfWork": "child", "additionalProperty": `+params+`}}
+			]
+		}`), ExpandOptions{Resolver: TemplateResolver(store), InstanceID: "p"})
+	}
+
+	t.Run("defaults and structured values", func(t *testing.T) {
+		expansion, err := invoke(`{"target": "archive", "options": {"compress": true, "tags": ["a", "b"]}}`)
+		if err != nil {
+			t.Fatalf("expand failed: %v", err)
+		}
+		props := expansion.Children[0].Actions[0].Properties
+		if props["output"] != "archive" {
+			t.Fatalf("expected target to be substituted, got %v", props["output"])
+		}
+		if props["retries"] != "3" {
+			t.Fatalf("expected default retries, got %v", props["retries"])
+		}
+		if props["options"] != `{"compress":true,"tags":["a","b"]}` {
+			t.Fatalf("expected structured value as JSON, got %v", props["options"])
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for name, params := range map[string]string{
+			"missing required": `{"retries": 1}`,
+			"wrong type":       `{"target": "archive", "retries": "often"}`,
+			"unknown":          `{"target": "archive", "color": "red"}`,
+		} {
+			if _, err := invoke(params); err == nil || !strings.Contains(err.Error(), "invalid parameters for workflow 'child'") {
+				t.Fatalf("%s: expected parameter validation error, got %v", name, err)
+			}
+		}
+	})
+}
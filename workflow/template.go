@@ -0,0 +1,287 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"eve.evalgo.org/semantic"
+	"eve.evalgo.org/semantic/runtime"
+)
+
+// Template handling: versioned workflow templates are stored immutably (see
+// repository.WorkflowTemplateRepository). Instances are created from a specific
+// version after validating the supplied parameters against the template schema.
+
+// TemplateStore is the subset of repository.WorkflowTemplateRepository used here
+type TemplateStore interface {
+	SaveTemplateVersion(ctx context.Context, template *semantic.SemanticWorkflowTemplate) (int, error)
+	GetTemplate(ctx context.Context, templateID string, version int) (*semantic.SemanticWorkflowTemplate, error)
+}
+
+// ParseTemplate parses the workflow JSON-LD embedded in a template
+func ParseTemplate(template *semantic.SemanticWorkflowTemplate) (*semantic.WorkflowDefinition, error) {
+	if template == nil {
+		return nil, fmt.Errorf("template is nil")
+	}
+	definition, err := ParseWorkflow(template.Workflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w",
+			semantic.TemplateRef(template.Identifier, template.Version), err)
+	}
+	return definition, nil
+}
+
+// TemplateWorkflowResolver is a WorkflowResolver that also loads the template
+// behind a reference. Parameters of sub-workflows resolved through it are
+// validated against the template parameter schema, with defaults applied,
+// before they are substituted.
+type TemplateWorkflowResolver interface {
+	WorkflowResolver
+	ResolveTemplate(ctx context.Context, ref string) (*semantic.SemanticWorkflowTemplate, error)
+}
+
+// TemplateResolver resolves sub-workflow references against a TemplateStore.
+// References may pin a version ("identifier@3") or use the latest ("identifier").
+func TemplateResolver(store TemplateStore) TemplateWorkflowResolver {
+	return &templateStoreResolver{store: store}
+}
+
+// templateStoreResolver implements TemplateWorkflowResolver over a TemplateStore
+type templateStoreResolver struct {
+	store TemplateStore
+}
+
+// ResolveTemplate loads the template version referenced by ref
+func (r *templateStoreResolver) ResolveTemplate(ctx context.Context, ref string) (*semantic.SemanticWorkflowTemplate, error) {
+	templateID, version, err := semantic.ParseTemplateRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	return r.store.GetTemplate(ctx, templateID, version)
+}
+
+// ResolveWorkflow loads and parses the template referenced by ref
+func (r *templateStoreResolver) ResolveWorkflow(ctx context.Context, ref string) (*semantic.WorkflowDefinition, error) {
+	template, err := r.ResolveTemplate(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTemplate(template)
+}
+
+// Instance is a workflow instance created from a template version
+type Instance struct {
+	TemplateID      string
+	TemplateVersion int
+	Parameters      map[string]interface{} // Effective parameters (defaults applied)
+	Expansion       *Expansion
+}
+
+// Instantiate validates params against the template schema and expands the
+// template into a new workflow instance. opts.Parameters is ignored; the
+// validated parameters are used instead.
+func Instantiate(ctx context.Context, template *semantic.SemanticWorkflowTemplate, params map[string]interface{}, opts ExpandOptions) (*Instance, error) {
+	if template == nil {
+		return nil, fmt.Errorf("template is nil")
+	}
+
+	effective, err := template.ValidateParameters(params)
+	if err != nil {
+		return nil, err
+	}
+
+	definition, err := ParseTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Parameters = effective
+	expansion, err := Expand(ctx, definition, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instance{
+		TemplateID:      template.Identifier,
+		TemplateVersion: template.Version,
+		Parameters:      effective,
+		Expansion:       expansion,
+	}, nil
+}
+
+// StartedEvent creates the workflow started event for this instance
+func (i *Instance) StartedEvent() *runtime.Event {
+	return runtime.NewWorkflowStartedEvent(
+		i.Expansion.InstanceID,
+		i.TemplateID,
+		fmt.Sprintf("%d", i.TemplateVersion),
+		len(i.Expansion.Actions),
+		semantic.StringParameters(i.Parameters),
+	)
+}
+
+// TemplateDiff describes the changes between two template versions
+type TemplateDiff struct {
+	TemplateID        string   `json:"templateId"`
+	FromVersion       int      `json:"fromVersion"`
+	ToVersion         int      `json:"toVersion"`
+	AddedParameters   []string `json:"addedParameters,omitempty"`
+	RemovedParameters []string `json:"removedParameters,omitempty"`
+	ChangedParameters []string `json:"changedParameters,omitempty"`
+	AddedActions      []string `json:"addedActions,omitempty"`
+	RemovedActions    []string `json:"removedActions,omitempty"`
+	ChangedActions    []string `json:"changedActions,omitempty"`
+	MetadataChanged   bool     `json:"metadataChanged,omitempty"` // Name or description changed
+}
+
+// IsEmpty returns true if both versions are equivalent
+func (d *TemplateDiff) IsEmpty() bool {
+	return len(d.AddedParameters) == 0 && len(d.RemovedParameters) == 0 && len(d.ChangedParameters) == 0 &&
+		len(d.AddedActions) == 0 && len(d.RemovedActions) == 0 && len(d.ChangedActions) == 0 &&
+		!d.MetadataChanged
+}
+
+// DiffTemplates compares two versions of a template by parameter name and action identifier
+func DiffTemplates(from, to *semantic.SemanticWorkflowTemplate) (*TemplateDiff, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("both templates are required")
+	}
+
+	diff := &TemplateDiff{
+		TemplateID:      to.Identifier,
+		FromVersion:     from.Version,
+		ToVersion:       to.Version,
+		MetadataChanged: from.Name != to.Name || from.Description != to.Description,
+	}
+
+	fromParams := make(map[string][]byte, len(from.Parameters))
+	for _, p := range from.Parameters {
+		fromParams[p.Name], _ = json.Marshal(p)
+	}
+	toParams := make(map[string][]byte, len(to.Parameters))
+	for _, p := range to.Parameters {
+		toParams[p.Name], _ = json.Marshal(p)
+	}
+	diff.AddedParameters, diff.RemovedParameters, diff.ChangedParameters = diffKeyed(fromParams, toParams)
+
+	fromDef, err := ParseTemplate(from)
+	if err != nil {
+		return nil, err
+	}
+	toDef, err := ParseTemplate(to)
+	if err != nil {
+		return nil, err
+	}
+	fromActions, err := actionsByIdentifier(fromDef)
+	if err != nil {
+		return nil, err
+	}
+	toActions, err := actionsByIdentifier(toDef)
+	if err != nil {
+		return nil, err
+	}
+	diff.AddedActions, diff.RemovedActions, diff.ChangedActions = diffKeyed(fromActions, toActions)
+
+	return diff, nil
+}
+
+// RollbackTemplate stores the content of an older version as a new version.
+// History stays immutable: the rolled-back version records the source in BasedOn.
+func RollbackTemplate(ctx context.Context, store TemplateStore, templateID string, toVersion int, note string) (*semantic.SemanticWorkflowTemplate, error) {
+	source, err := store.GetTemplate(ctx, templateID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	rolledBack := *source
+	rolledBack.BasedOn = source.Version
+	rolledBack.Created = time.Now()
+	rolledBack.VersionNote = note
+	if rolledBack.VersionNote == "" {
+		rolledBack.VersionNote = fmt.Sprintf("rollback to version %d", source.Version)
+	}
+
+	if _, err := store.SaveTemplateVersion(ctx, &rolledBack); err != nil {
+		return nil, err
+	}
+	return &rolledBack, nil
+}
+
+// actionsByIdentifier serializes every step of a workflow keyed by identifier
+func actionsByIdentifier(definition *semantic.WorkflowDefinition) (map[string][]byte, error) {
+	actions := make(map[string][]byte)
+	add := func(id string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		actions[id] = data
+		return nil
+	}
+
+	for _, wa := range definition.Actions {
+		switch wa.Type {
+		case "action":
+			if wa.Action == nil {
+				continue
+			}
+			if err := add(actionKey(wa.Action.Identifier, wa.Action.ID), wa); err != nil {
+				return nil, err
+			}
+		case "loop":
+			if wa.Loop == nil {
+				continue
+			}
+			for _, item := range wa.Loop.ItemListElement {
+				if item.Item == nil {
+					continue
+				}
+				if err := add(actionKey(item.Item.Identifier, item.Item.ID), item); err != nil {
+					return nil, err
+				}
+			}
+		case "subworkflow":
+			if wa.SubWorkflow == nil {
+				continue
+			}
+			if err := add(actionKey(wa.SubWorkflow.Identifier, wa.SubWorkflow.ID), wa); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return actions, nil
+}
+
+// actionKey prefers identifier over @id, matching the expander
+func actionKey(identifier, id string) string {
+	if identifier != "" {
+		return identifier
+	}
+	return id
+}
+
+// diffKeyed compares two keyed sets of serialized values
+func diffKeyed(from, to map[string][]byte) (added, removed, changed []string) {
+	for key, value := range to {
+		old, ok := from[key]
+		switch {
+		case !ok:
+			added = append(added, key)
+		case !bytes.Equal(old, value):
+			changed = append(changed, key)
+		}
+	}
+	for key := range from {
+		if _, ok := to[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
@@ -0,0 +1,230 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"eve.evalgo.org/semantic"
+)
+
+type fakeTemplateStore map[string][]*semantic.SemanticWorkflowTemplate
+
+func (f fakeTemplateStore) SaveTemplateVersion(ctx context.Context, template *semantic.SemanticWorkflowTemplate) (int, error) {
+	template.Version = len(f[template.Identifier]) + 1
+	f[template.Identifier] = append(f[template.Identifier], template)
+	return template.Version, nil
+}
+
+func (f fakeTemplateStore) GetTemplate(ctx context.Context, templateID string, version int) (*semantic.SemanticWorkflowTemplate, error) {
+	versions := f[templateID]
+	if version == 0 {
+		version = len(versions)
+	}
+	if version < 1 || version > len(versions) {
+		return nil, fmt.Errorf("template %s version %d not found", templateID, version)
+	}
+	return versions[version-1], nil
+}
+
+func newTemplate(version int, workflow string, params ...semantic.WorkflowParameterSpec) *semantic.SemanticWorkflowTemplate {
+	return &semantic.SemanticWorkflowTemplate{
+		Type:       semantic.WorkflowTemplateType,
+		Identifier: "backup",
+		Version:    version,
+		Name:       "Backup",
+		Parameters: params,
+		Workflow:   []byte(workflow),
+	}
+}
+
+func param(name, valueType string, required bool, defaultValue interface{}) semantic.WorkflowParameterSpec {
+	return semantic.WorkflowParameterSpec{
+		PropertyValueSpec: semantic.PropertyValueSpec{Type: "PropertyValue", Name: name, ValueType: valueType},
+		Required:          required,
+		DefaultValue:      defaultValue,
+	}
+}
+
+const backupV1 = `{
+	"@type": "HowTo", "identifier": "backup",
+	"step": [
+		{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "dump",
+			"additionalProperty": {"database": "${database}", "retention": "${retention}"}}},
+		{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "upload", "requires": ["dump"]}},
+		{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "cleanup", "requires": ["upload"]}}
+	]
+}`
+
+const backupV2 = `{
+	"@type": "HowTo", "identifier": "backup",
+	"step": [
+		{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "dump",
+			"additionalProperty": {"database": "${database}", "compress": true}}},
+		{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "upload", "requires": ["dump"]}},
+		{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "verify", "requires": ["upload"]}}
+	]
+}`
+
+func TestDiffTemplates(t *testing.T) {
+	v1 := newTemplate(1, backupV1, param("database", "Text", true, nil), param("retention", "Number", false, 7))
+	v2 := newTemplate(2, backupV2, param("database", "Text", true, nil), param("retention", "Number", false, 30), param("bucket", "Text", false, nil))
+	v2.Description = "Nightly database backup"
+
+	tests := []struct {
+		name     string
+		from, to *semantic.SemanticWorkflowTemplate
+		want     TemplateDiff
+	}{
+		{
+			name: "same version",
+			from: v1, to: v1,
+			want: TemplateDiff{TemplateID: "backup", FromVersion: 1, ToVersion: 1},
+		},
+		{
+			name: "forward",
+			from: v1, to: v2,
+			want: TemplateDiff{
+				TemplateID: "backup", FromVersion: 1, ToVersion: 2,
+				AddedParameters:   []string{"bucket"},
+				ChangedParameters: []string{"retention"},
+				AddedActions:      []string{"verify"},
+				RemovedActions:    []string{"cleanup"},
+				ChangedActions:    []string{"dump"},
+				MetadataChanged:   true,
+			},
+		},
+		{
+			name: "backward",
+			from: v2, to: v1,
+			want: TemplateDiff{
+				TemplateID: "backup", FromVersion: 2, ToVersion: 1,
+				RemovedParameters: []string{"bucket"},
+				ChangedParameters: []string{"retention"},
+				AddedActions:      []string{"cleanup"},
+				RemovedActions:    []string{"verify"},
+				ChangedActions:    []string{"dump"},
+				MetadataChanged:   true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := DiffTemplates(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("diff failed: %v", err)
+			}
+			if !reflect.DeepEqual(*diff, tt.want) {
+				t.Fatalf("unexpected diff:\n got %+v\nwant %+v", *diff, tt.want)
+			}
+			if diff.IsEmpty() != (tt.from == tt.to) {
+				t.Fatalf("IsEmpty() = %v", diff.IsEmpty())
+			}
+		})
+	}
+
+	if _, err := DiffTemplates(v1, nil); err == nil {
+		t.Fatal("expected error for missing template")
+	}
+	if _, err := DiffTemplates(v1, newTemplate(2, `{"@type": "Unknown"}`)); err == nil {
+		t.Fatal("expected error for unparseable workflow")
+	}
+}
+
+func TestRollbackTemplate(t *testing.T) {
+	ctx := context.Background()
+	store := fakeTemplateStore{}
+	for _, workflow := range []string{backupV1, backupV2} {
+		if _, err := store.SaveTemplateVersion(ctx, newTemplate(0, workflow)); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	t.Run("to existing version", func(t *testing.T) {
+		rolledBack, err := RollbackTemplate(ctx, store, "backup", 1, "")
+		if err != nil {
+			t.Fatalf("rollback failed: %v", err)
+		}
+		if rolledBack.Version != 3 || rolledBack.BasedOn != 1 {
+			t.Fatalf("expected version 3 based on 1, got version %d based on %d", rolledBack.Version, rolledBack.BasedOn)
+		}
+		if rolledBack.VersionNote != "rollback to version 1" {
+			t.Fatalf("unexpected version note %q", rolledBack.VersionNote)
+		}
+		if string(rolledBack.Workflow) != backupV1 {
+			t.Fatal("rolled back version should carry the content of version 1")
+		}
+
+		original, _ := store.GetTemplate(ctx, "backup", 1)
+		if original.Version != 1 || original.BasedOn != 0 {
+			t.Fatalf("history must stay immutable, got %+v", original)
+		}
+
+		diff, err := DiffTemplates(original, rolledBack)
+		if err != nil {
+			t.Fatalf("diff failed: %v", err)
+		}
+		if !diff.IsEmpty() {
+			t.Fatalf("rolled back version should equal version 1, got %+v", diff)
+		}
+	})
+
+	t.Run("to missing version", func(t *testing.T) {
+		if _, err := RollbackTemplate(ctx, store, "backup", 9, "restore"); err == nil {
+			t.Fatal("expected error for missing version")
+		}
+		if _, err := RollbackTemplate(ctx, store, "unknown", 1, "restore"); err == nil {
+			t.Fatal("expected error for missing template")
+		}
+		if len(store["backup"]) != 3 {
+			t.Fatalf("failed rollbacks must not store versions, got %d", len(store["backup"]))
+		}
+	})
+}
+
+func TestInstantiate(t *testing.T) {
+	template := newTemplate(1, backupV1, param("database", "Text", true, nil), param("retention", "Number", false, 7))
+
+	t.Run("substitutes parameters", func(t *testing.T) {
+		instance, err := Instantiate(context.Background(), template, map[string]interface{}{"database": "orders"}, ExpandOptions{
+			InstanceID: "i",
+			Parameters: map[string]interface{}{"database": "ignored"},
+		})
+		if err != nil {
+			t.Fatalf("instantiate failed: %v", err)
+		}
+		if instance.TemplateID != "backup" || instance.TemplateVersion != 1 {
+			t.Fatalf("unexpected instance %+v", instance)
+		}
+		if !reflect.DeepEqual(instance.Parameters, map[string]interface{}{"database": "orders", "retention": float64(7)}) {
+			t.Fatalf("unexpected effective parameters %v", instance.Parameters)
+		}
+
+		dump := actionsByID(instance.Expansion.Actions)["i--dump"]
+		if dump == nil {
+			t.Fatal("dump action missing")
+		}
+		if dump.Properties["database"] != "orders" || dump.Properties["retention"] != "7" {
+			t.Fatalf("parameters not substituted: %v", dump.Properties)
+		}
+		if strings.Contains(string(template.Workflow), "orders") {
+			t.Fatal("template must not be modified")
+		}
+
+		event := instance.StartedEvent()
+		if event == nil {
+			t.Fatal("expected started event")
+		}
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		if _, err := Instantiate(context.Background(), template, nil, ExpandOptions{}); err == nil {
+			t.Fatal("expected error for missing required parameter")
+		}
+		if _, err := Instantiate(context.Background(), template, map[string]interface{}{"database": "orders", "retention": "weekly"}, ExpandOptions{}); err == nil {
+			t.Fatal("expected error for non-numeric retention")
+		}
+	})
+}
@@ -0,0 +1,539 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"eve.evalgo.org/graph"
+	"eve.evalgo.org/registry"
+	"eve.evalgo.org/semantic"
+	"eve.evalgo.org/semantic/runtime"
+)
+
+// Validator performs static checks on workflow JSON-LD before execution.
+// Every finding is reported as a Diagnostic carrying a JSON pointer (RFC 6901)
+// into the original document, so editors and APIs can point at the offending field.
+
+// Severity of a validation diagnostic
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic codes
+const (
+	CodeParse              = "parse-error"
+	CodeUnknownType        = "unknown-type"
+	CodeMissingIdentifier  = "missing-identifier"
+	CodeDuplicateID        = "duplicate-identifier"
+	CodeMissingDependency  = "missing-dependency"
+	CodeCycle              = "dependency-cycle"
+	CodeUnresolvableTarget = "unresolvable-target"
+	CodeUnknownReference   = "unknown-reference"
+	CodeForwardReference   = "forward-reference"
+	CodeUnorderedReference = "reference-not-dependency"
+	CodeUnknownParameter   = "unknown-parameter"
+	CodeUnresolvableWork   = "unresolvable-workflow"
+)
+
+// Diagnostic is a single validation finding
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Pointer  string   `json:"pointer"` // JSON pointer into the original document ("" = whole document)
+	ActionID string   `json:"actionId,omitempty"`
+}
+
+// ValidationReport collects all diagnostics for one workflow document
+type ValidationReport struct {
+	WorkflowID  string       `json:"workflowId,omitempty"`
+	Valid       bool         `json:"valid"` // No error-level diagnostics
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Errors returns the error-level diagnostics
+func (r *ValidationReport) Errors() []Diagnostic {
+	return r.filter(SeverityError)
+}
+
+// Warnings returns the warning-level diagnostics
+func (r *ValidationReport) Warnings() []Diagnostic {
+	return r.filter(SeverityWarning)
+}
+
+func (r *ValidationReport) filter(severity Severity) []Diagnostic {
+	var out []Diagnostic
+	for _, d := range r.Diagnostics {
+		if d.Severity == severity {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Err returns an error summarizing all error-level diagnostics, or nil
+func (r *ValidationReport) Err() error {
+	errs := r.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, d := range errs {
+		msgs[i] = fmt.Sprintf("%s: %s", d.Pointer, d.Message)
+	}
+	return fmt.Errorf("workflow validation failed: %s", strings.Join(msgs, "; "))
+}
+
+// CapabilityChecker verifies that a service supports an action type.
+// registry.Client implements this interface.
+type CapabilityChecker interface {
+	GetActionCapability(ctx context.Context, serviceID, actionType string) (*registry.ActionCapability, error)
+}
+
+// ValidateOptions configures optional checks
+type ValidateOptions struct {
+	Capabilities CapabilityChecker // Verify registry:// targets (skipped if nil)
+	Resolver     WorkflowResolver  // Verify sub-workflow references (skipped if nil)
+	Parameters   []string          // Known ${parameter} names (parameter check skipped if nil)
+}
+
+// KnownActionTypes lists the @type values accepted for workflow actions without
+// a registry lookup
+var KnownActionTypes = map[string]bool{
+	"Action": true, "ScheduledAction": true, "MapAction": true, "AssessAction": true,
+	"SearchAction": true, "RetrieveAction": true, "CreateAction": true, "UpdateAction": true,
+	"DeleteAction": true, "ReplaceAction": true, "TransferAction": true, "UploadAction": true,
+	"DownloadAction": true, "ActivateAction": true, "DeactivateAction": true, "ConnectAction": true,
+	"AssignAction": true, "SendAction": true, "ReceiveAction": true, "CheckAction": true,
+	"ControlAction": true, "ConsumeAction": true, "ViewAction": true, "CommunicateAction": true,
+	"AddAction": true, "RemoveAction": true,
+}
+
+// actionEntry is an action (or sub-workflow step) located in the raw document
+type actionEntry struct {
+	id       string
+	typ      string
+	pointer  string
+	order    int
+	raw      map[string]interface{}
+	requires []dependencyRef
+	isSub    bool
+}
+
+// dependencyRef is a dependency together with where it was declared
+type dependencyRef struct {
+	id      string
+	pointer string
+}
+
+// validator holds state while checking one document
+type validator struct {
+	ctx     context.Context
+	opts    ValidateOptions
+	report  *ValidationReport
+	entries []*actionEntry
+}
+
+// Validate statically checks a workflow JSON-LD document.
+// The returned report lists all findings; report.Valid is false if any error was found.
+func Validate(ctx context.Context, doc []byte, opts ValidateOptions) *ValidationReport {
+	v := &validator{ctx: ctx, opts: opts, report: &ValidationReport{}}
+
+	var root map[string]interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		v.add(SeverityError, CodeParse, "", "", fmt.Sprintf("invalid JSON: %v", err))
+		return v.finish()
+	}
+
+	v.report.WorkflowID = firstString(root, "@id", "identifier")
+	v.collect(root)
+
+	// Structural problems already reported by collect would only be repeated
+	if _, err := ParseWorkflow(doc); err != nil && len(v.report.Errors()) == 0 {
+		v.add(SeverityError, CodeParse, "", "", err.Error())
+	}
+
+	index := v.checkIdentifiers()
+	v.checkDependencies(index)
+	v.checkCycles(index)
+	v.checkReferences(index)
+	v.checkTargets()
+	v.checkSubWorkflows()
+
+	return v.finish()
+}
+
+// collect locates all actions in the raw document by workflow type
+func (v *validator) collect(root map[string]interface{}) {
+	typ, _ := root["@type"].(string)
+	switch typ {
+	case "ItemList":
+		v.collectItemList(root, "", nil)
+	case "HowTo":
+		steps, _ := root["step"].([]interface{})
+		for i, s := range steps {
+			stepPtr := pointer("", "step", i)
+			step, ok := s.(map[string]interface{})
+			if !ok {
+				v.add(SeverityError, CodeParse, stepPtr, "", "step must be an object")
+				continue
+			}
+			if t, _ := step["@type"].(string); t != "HowToStep" {
+				v.add(SeverityError, CodeUnknownType, pointer(stepPtr, "@type"), "",
+					fmt.Sprintf("expected step @type 'HowToStep', got '%s'", t))
+			}
+			v.collectStepElement(step["itemListElement"], pointer(stepPtr, "itemListElement"))
+		}
+	case "ScheduledAction", "MapAction":
+		v.addAction(root, "", nil)
+	default:
+		v.add(SeverityError, CodeUnknownType, "/@type", "", fmt.Sprintf("unsupported workflow type: '%s'", typ))
+	}
+}
+
+// collectStepElement handles the element of a HowToStep
+func (v *validator) collectStepElement(element interface{}, ptr string) {
+	elem, ok := element.(map[string]interface{})
+	if !ok {
+		v.add(SeverityError, CodeParse, ptr, "", "step element must be an object")
+		return
+	}
+	typ, _ := elem["@type"].(string)
+	switch typ {
+	case "ItemList":
+		v.collectItemList(elem, ptr, nil)
+	case semantic.SubWorkflowActionType:
+		entry := v.addAction(elem, ptr, nil)
+		entry.isSub = true
+	case "ScheduledAction":
+		v.addAction(elem, ptr, nil)
+	default:
+		v.add(SeverityError, CodeUnknownType, pointer(ptr, "@type"), firstString(elem, "identifier", "@id"),
+			fmt.Sprintf("unsupported step element type: '%s'", typ))
+	}
+}
+
+// collectItemList handles ItemList items, which inherit the list's dependsOn
+func (v *validator) collectItemList(list map[string]interface{}, ptr string, inherited []dependencyRef) {
+	deps := append([]dependencyRef{}, inherited...)
+	deps = append(deps, stringRefs(list["dependsOn"], pointer(ptr, "dependsOn"))...)
+
+	items, _ := list["itemListElement"].([]interface{})
+	for i, it := range items {
+		itemPtr := pointer(ptr, "itemListElement", i)
+		item, ok := it.(map[string]interface{})
+		if !ok {
+			v.add(SeverityError, CodeParse, itemPtr, "", "list item must be an object")
+			continue
+		}
+		if t, _ := item["@type"].(string); t != "ListItem" {
+			v.add(SeverityError, CodeUnknownType, pointer(itemPtr, "@type"), "",
+				fmt.Sprintf("expected itemListElement @type 'ListItem', got '%s'", t))
+		}
+		action, ok := item["item"].(map[string]interface{})
+		if !ok {
+			v.add(SeverityError, CodeParse, pointer(itemPtr, "item"), "", "list item has no action")
+			continue
+		}
+		v.addAction(action, pointer(itemPtr, "item"), deps)
+	}
+}
+
+// addAction records an action found at ptr
+func (v *validator) addAction(raw map[string]interface{}, ptr string, inherited []dependencyRef) *actionEntry {
+	entry := &actionEntry{
+		id:      firstString(raw, "identifier", "@id"),
+		pointer: ptr,
+		order:   len(v.entries),
+		raw:     raw,
+	}
+	entry.typ, _ = raw["@type"].(string)
+	entry.requires = append(append([]dependencyRef{}, inherited...), stringRefs(raw["requires"], pointer(ptr, "requires"))...)
+	v.entries = append(v.entries, entry)
+	return entry
+}
+
+// checkIdentifiers reports missing and duplicate identifiers and returns the ID index
+func (v *validator) checkIdentifiers() map[string]*actionEntry {
+	index := make(map[string]*actionEntry, len(v.entries))
+	for _, e := range v.entries {
+		if e.id == "" {
+			v.add(SeverityError, CodeMissingIdentifier, e.pointer, "", "action has no identifier or @id")
+			continue
+		}
+		if first, exists := index[e.id]; exists {
+			v.add(SeverityError, CodeDuplicateID, pointer(e.pointer, "identifier"), e.id,
+				fmt.Sprintf("identifier '%s' already used at %s", e.id, first.pointer))
+			continue
+		}
+		index[e.id] = e
+		if !e.isSub && !KnownActionTypes[e.typ] && v.opts.Capabilities == nil {
+			v.add(SeverityError, CodeUnknownType, pointer(e.pointer, "@type"), e.id,
+				fmt.Sprintf("unknown action @type '%s'", e.typ))
+		}
+	}
+	return index
+}
+
+// checkDependencies reports requires/dependsOn entries that name no action
+func (v *validator) checkDependencies(index map[string]*actionEntry) {
+	for _, e := range v.entries {
+		for _, dep := range e.requires {
+			if _, ok := index[dep.id]; !ok {
+				v.add(SeverityError, CodeMissingDependency, dep.pointer, e.id,
+					fmt.Sprintf("dependency '%s' does not exist in this workflow", dep.id))
+			}
+		}
+	}
+}
+
+// checkCycles reports the first dependency cycle using the graph utilities
+func (v *validator) checkCycles(index map[string]*actionEntry) {
+	nodes := make([]*semantic.SemanticScheduledAction, 0, len(index))
+	for _, e := range v.entries {
+		if index[e.id] != e {
+			continue
+		}
+		node := &semantic.SemanticScheduledAction{Requires: dependencyIDs(e.requires)}
+		node.Identifier = e.id
+		nodes = append(nodes, node)
+	}
+
+	cycle := graph.FindCycle(nodes)
+	if cycle == nil {
+		return
+	}
+
+	// Point at the dependency entry that closes the first edge of the cycle
+	from := index[cycle[0]]
+	ptr := from.pointer
+	for _, dep := range from.requires {
+		if dep.id == cycle[1] {
+			ptr = dep.pointer
+			break
+		}
+	}
+	v.add(SeverityError, CodeCycle, ptr, from.id,
+		fmt.Sprintf("circular dependency: %s", strings.Join(cycle, " -> ")))
+}
+
+// checkReferences verifies ${action-id.path} and ${parameter} references
+func (v *validator) checkReferences(index map[string]*actionEntry) {
+	known := make(map[string]bool, len(v.opts.Parameters))
+	for _, p := range v.opts.Parameters {
+		known[p] = true
+	}
+
+	for _, e := range v.entries {
+		if e.id == "" || index[e.id] != e {
+			continue
+		}
+		ancestors := v.ancestors(e, index)
+
+		walkStrings(e.raw, e.pointer, func(ptr, value string) {
+			for _, ref := range runtime.ExtractVariableReferences(value) {
+				actionID, _, isAction := strings.Cut(ref, ".")
+				if !isAction {
+					if v.opts.Parameters != nil && !known[ref] {
+						v.add(SeverityWarning, CodeUnknownParameter, ptr, e.id,
+							fmt.Sprintf("${%s} does not name a declared parameter", ref))
+					}
+					continue
+				}
+
+				target, ok := index[actionID]
+				switch {
+				case !ok:
+					v.add(SeverityError, CodeUnknownReference, ptr, e.id,
+						fmt.Sprintf("${%s} references unknown action '%s'", ref, actionID))
+				case target.order >= e.order:
+					v.add(SeverityError, CodeForwardReference, ptr, e.id,
+						fmt.Sprintf("${%s} references action '%s' which is defined later", ref, actionID))
+				case !ancestors[actionID]:
+					v.add(SeverityWarning, CodeUnorderedReference, ptr, e.id,
+						fmt.Sprintf("${%s} references '%s' which is not a dependency and may not have completed", ref, actionID))
+				}
+			}
+		})
+	}
+}
+
+// checkTargets verifies registry:// targets against the capability registry
+func (v *validator) checkTargets() {
+	if v.opts.Capabilities == nil {
+		return
+	}
+
+	type key struct{ service, actionType string }
+	results := make(map[key]error)
+
+	for _, e := range v.entries {
+		if e.isSub {
+			continue
+		}
+		targetURL, ptr := actionTargetURL(e.raw, e.pointer)
+		if !strings.HasPrefix(targetURL, "registry://") {
+			if !KnownActionTypes[e.typ] {
+				v.add(SeverityError, CodeUnknownType, pointer(e.pointer, "@type"), e.id,
+					fmt.Sprintf("unknown action @type '%s'", e.typ))
+			}
+			continue
+		}
+
+		service, _, _ := strings.Cut(strings.TrimPrefix(targetURL, "registry://"), "/")
+		k := key{service, e.typ}
+		err, checked := results[k]
+		if !checked {
+			_, err = v.opts.Capabilities.GetActionCapability(v.ctx, service, e.typ)
+			results[k] = err
+		}
+		if err != nil {
+			v.add(SeverityError, CodeUnresolvableTarget, ptr, e.id,
+				fmt.Sprintf("target %s cannot handle %s: %v", targetURL, e.typ, err))
+		}
+	}
+}
+
+// checkSubWorkflows verifies that referenced workflow templates resolve
+func (v *validator) checkSubWorkflows() {
+	if v.opts.Resolver == nil {
+		return
+	}
+	for _, e := range v.entries {
+		if !e.isSub {
+			continue
+		}
+		ref, _ := e.raw["exampleOfWork"].(string)
+		if ref == "" {
+			v.add(SeverityError, CodeUnresolvableWork, e.pointer, e.id, "SubWorkflowAction has no exampleOfWork")
+			continue
+		}
+		if _, err := v.opts.Resolver.ResolveWorkflow(v.ctx, ref); err != nil {
+			v.add(SeverityError, CodeUnresolvableWork, pointer(e.pointer, "exampleOfWork"), e.id,
+				fmt.Sprintf("workflow '%s' cannot be resolved: %v", ref, err))
+		}
+	}
+}
+
+// ancestors returns the transitive dependencies of e
+func (v *validator) ancestors(e *actionEntry, index map[string]*actionEntry) map[string]bool {
+	seen := make(map[string]bool)
+	queue := dependencyIDs(e.requires)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if dep, ok := index[id]; ok {
+			queue = append(queue, dependencyIDs(dep.requires)...)
+		}
+	}
+	return seen
+}
+
+func (v *validator) add(severity Severity, code, ptr, actionID, message string) {
+	v.report.Diagnostics = append(v.report.Diagnostics, Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Message:  message,
+		Pointer:  ptr,
+		ActionID: actionID,
+	})
+}
+
+func (v *validator) finish() *ValidationReport {
+	v.report.Valid = len(v.report.Errors()) == 0
+	if v.report.Diagnostics == nil {
+		v.report.Diagnostics = []Diagnostic{}
+	}
+	return v.report
+}
+
+// actionTargetURL returns the routing URL of an action and its pointer.
+// controlMetadata.url takes precedence over target.url, matching the expander.
+func actionTargetURL(raw map[string]interface{}, ptr string) (string, string) {
+	if meta, ok := raw["controlMetadata"].(map[string]interface{}); ok {
+		if u, _ := meta["url"].(string); u != "" {
+			return u, pointer(ptr, "controlMetadata", "url")
+		}
+	}
+	if target, ok := raw["target"].(map[string]interface{}); ok {
+		if u, _ := target["url"].(string); u != "" {
+			return u, pointer(ptr, "target", "url")
+		}
+	}
+	return "", ptr
+}
+
+// walkStrings calls fn for every string value below data (excluding dependency lists)
+func walkStrings(data interface{}, ptr string, fn func(ptr, value string)) {
+	switch val := data.(type) {
+	case string:
+		fn(ptr, val)
+	case map[string]interface{}:
+		for k, child := range val {
+			if k == "requires" || k == "dependsOn" {
+				continue
+			}
+			walkStrings(child, pointer(ptr, k), fn)
+		}
+	case []interface{}:
+		for i, child := range val {
+			walkStrings(child, pointer(ptr, i), fn)
+		}
+	}
+}
+
+// pointer appends RFC 6901 escaped tokens to a JSON pointer
+func pointer(base string, tokens ...interface{}) string {
+	var sb strings.Builder
+	sb.WriteString(base)
+	for _, t := range tokens {
+		sb.WriteByte('/')
+		switch tok := t.(type) {
+		case int:
+			sb.WriteString(strconv.Itoa(tok))
+		case string:
+			sb.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(tok))
+		}
+	}
+	return sb.String()
+}
+
+// stringRefs converts a JSON string array into dependency references
+func stringRefs(data interface{}, ptr string) []dependencyRef {
+	list, _ := data.([]interface{})
+	refs := make([]dependencyRef, 0, len(list))
+	for i, item := range list {
+		if s, ok := item.(string); ok {
+			refs = append(refs, dependencyRef{id: s, pointer: pointer(ptr, i)})
+		}
+	}
+	return refs
+}
+
+func dependencyIDs(refs []dependencyRef) []string {
+	ids := make([]string, len(refs))
+	for i, r := range refs {
+		ids[i] = r.id
+	}
+	return ids
+}
+
+// firstString returns the first non-empty string value among keys
+func firstString(m map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := m[k].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"eve.evalgo.org/registry"
+)
+
+type fakeCapabilities map[string]bool
+
+func (f fakeCapabilities) GetActionCapability(ctx context.Context, serviceID, actionType string) (*registry.ActionCapability, error) {
+	if f[serviceID+"/"+actionType] {
+		return &registry.ActionCapability{ActionType: actionType}, nil
+	}
+	return nil, fmt.Errorf("action type %s not found in service %s capabilities", actionType, serviceID)
+}
+
+func findDiagnostic(report *ValidationReport, code string) *Diagnostic {
+	for i := range report.Diagnostics {
+		if report.Diagnostics[i].Code == code {
+			return &report.Diagnostics[i]
+		}
+	}
+	return nil
+}
+
+func TestValidate_ValidWorkflow(t *testing.T) {
+	doc := `{
+		"@type": "HowTo", "identifier": "wf",
+		"step": [
+			{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "fetch"}},
+			{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "store",
+				"requires": ["fetch"], "additionalProperty": {"url": "${fetch.result.contentUrl}"}}}
+		]
+	}`
+
+	report := Validate(context.Background(), []byte(doc), ValidateOptions{})
+	if !report.Valid {
+		t.Fatalf("expected valid workflow, got %+v", report.Diagnostics)
+	}
+}
+
+func TestValidate_ReportsPointers(t *testing.T) {
+	doc := `{
+		"@type": "HowTo", "identifier": "wf",
+		"step": [
+			{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "a",
+				"requires": ["missing"], "additionalProperty": {"input": "${b.result.value}"}}},
+			{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "b", "requires": ["c"]}},
+			{"@type": "HowToStep", "itemListElement": {"@type": "ScheduledAction", "identifier": "c", "requires": ["b"]}}
+		]
+	}`
+
+	report := Validate(context.Background(), []byte(doc), ValidateOptions{})
+	if report.Valid {
+		t.Fatal("expected invalid workflow")
+	}
+
+	cases := map[string]string{
+		CodeMissingDependency: "/step/0/itemListElement/requires/0",
+		CodeForwardReference:  "/step/0/itemListElement/additionalProperty/input",
+		CodeCycle:             "/step/1/itemListElement/requires/0",
+	}
+	for code, ptr := range cases {
+		d := findDiagnostic(report, code)
+		if d == nil {
+			t.Errorf("expected %s diagnostic, got %+v", code, report.Diagnostics)
+			continue
+		}
+		if d.Pointer != ptr {
+			t.Errorf("%s: expected pointer %s, got %s", code, ptr, d.Pointer)
+		}
+	}
+}
+
+func TestValidate_RegistryTargets(t *testing.T) {
+	doc := `{
+		"@type": "ItemList", "identifier": "wf",
+		"itemListElement": [
+			{"@type": "ListItem", "position": 1, "item": {"@type": "SearchAction", "identifier": "q",
+				"target": {"@type": "EntryPoint", "url": "registry://sparqlservice/v1/api/semantic/action"}}},
+			{"@type": "ListItem", "position": 2, "item": {"@type": "CreateAction", "identifier": "c",
+				"target": {"@type": "EntryPoint", "url": "registry://sparqlservice/v1/api/semantic/action"}}}
+		]
+	}`
+
+	caps := fakeCapabilities{"sparqlservice/SearchAction": true}
+	report := Validate(context.Background(), []byte(doc), ValidateOptions{Capabilities: caps})
+
+	errs := report.Errors()
+	if len(errs) != 1 || errs[0].Code != CodeUnresolvableTarget {
+		t.Fatalf("expected one unresolvable-target error, got %+v", report.Diagnostics)
+	}
+	if errs[0].Pointer != "/itemListElement/1/item/target/url" {
+		t.Errorf("unexpected pointer %s", errs[0].Pointer)
+	}
+}